@@ -0,0 +1,177 @@
+/*
+mdl-signer is a reference remote signer for the remote wallets of a node.
+
+It loads a wallet file, decrypting it if it is encrypted, and signs the transaction inputs
+of its addresses on request over HTTP, on a loopback address or on a Unix socket.
+The node holds a remote wallet with the public keys of the wallet, created with
+POST /api/v1/wallet/create with type=remote and the signer url.
+
+It signs every request for the addresses of the wallet, access to its address or socket
+must be restricted to the node.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/util/logging"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+const (
+	defaultListen = "127.0.0.1:6480"
+)
+
+var (
+	logger = logging.MustGetLogger("main")
+	help   = fmt.Sprintf(`mdl-signer signs the transaction inputs of the addresses of a wallet for the remote wallets of a node.
+
+It listens on %s by default. May be overridden with the -listen flag, which is a loopback
+host:port or the path of a Unix socket. The signer url of the remote wallet is http://<host:port>
+or unix://<socket path>.
+
+The password of an encrypted wallet is read from the file given with the -password-file flag,
+or prompted for.`, defaultListen)
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\n\nUsage of %s:\n", help, os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	wltFile := flag.String("wallet", "", "wallet file holding the secret keys [required]")
+	passwordFile := flag.String("password-file", "", "file containing the password of an encrypted wallet")
+	listen := flag.String("listen", defaultListen, "loopback host:port or Unix socket path to listen on")
+
+	flag.Parse()
+
+	if err := run(*wltFile, *passwordFile, *listen); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(wltFile, passwordFile, listen string) error {
+	if wltFile == "" {
+		return errors.New("-wallet is required")
+	}
+
+	w, err := loadWallet(wltFile, passwordFile)
+	if err != nil {
+		return err
+	}
+	defer w.Erase()
+
+	ln, signerURL, err := listenSigner(listen)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler: wallet.NewRemoteSignerHandler(wallet.NewLocalSigner(w), w.Entries),
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-quit
+		logger.Info("Shutting down")
+		if err := srv.Close(); err != nil {
+			logger.WithError(err).Error("Close failed")
+		}
+	}()
+
+	logger.Infof("Signing for %d addresses of %s at %s", len(w.Entries), w.Filename(), signerURL)
+
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// loadWallet loads a MDL wallet file with its secret keys, decrypting it if it is encrypted
+func loadWallet(wltFile, passwordFile string) (*wallet.Wallet, error) {
+	w, err := wallet.Load(wltFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.IsWatchOnly() || w.IsRemote() {
+		return nil, fmt.Errorf("%s has no secret keys", wltFile)
+	}
+
+	for _, e := range w.Entries {
+		if _, ok := e.Address.(cipher.Address); !ok {
+			return nil, fmt.Errorf("%s is not a %s wallet", wltFile, wallet.CoinTypeMDL)
+		}
+	}
+
+	if !w.IsEncrypted() {
+		if passwordFile != "" {
+			return nil, errors.New("-password-file is set, but the wallet is not encrypted")
+		}
+		return w, nil
+	}
+
+	var password []byte
+	if passwordFile != "" {
+		b, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read -password-file failed: %v", err)
+		}
+		password = []byte(strings.TrimRight(string(b), "\r\n"))
+	} else {
+		fmt.Fprint(os.Stdout, "enter wallet password:")
+		password, err = terminal.ReadPassword(int(syscall.Stdin)) // nolint: unconvert
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(os.Stdout, "")
+	}
+
+	return w.Unlock(password)
+}
+
+// listenSigner listens on a loopback host:port or on a Unix socket path, returning the signer url of the listener
+func listenSigner(listen string) (net.Listener, string, error) {
+	signerURL := "unix://" + listen
+	if !strings.HasPrefix(listen, "/") {
+		signerURL = "http://" + listen
+	}
+
+	network, addr, _, err := wallet.ParseSignerURL(signerURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid -listen %q: %v", listen, err)
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Only the owner of the process can connect to the socket
+	if network == "unix" {
+		if err := os.Chmod(addr, 0600); err != nil {
+			ln.Close()
+			return nil, "", err
+		}
+	}
+
+	return ln, signerURL, nil
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/daemon"
 	"github.com/MDLlife/MDL/src/kvstorage"
+	"github.com/MDLlife/MDL/src/pst"
 	"github.com/MDLlife/MDL/src/readable"
+	"github.com/MDLlife/MDL/src/wallet"
 )
 
 const (
@@ -574,6 +576,25 @@ func (c *Client) BlockchainProgress() (*readable.BlockchainProgress, error) {
 	return &b, nil
 }
 
+// FeeEstimate makes a request to GET /api/v2/fee/estimate to estimate the coin hours to burn per kilobyte
+// for confidence levels. The node's default confidence levels are used if confidences is empty.
+func (c *Client) FeeEstimate(confidences []string) (*FeeEstimateResponse, error) {
+	endpoint := "/api/v2/fee/estimate"
+	if len(confidences) != 0 {
+		v := url.Values{}
+		v.Add("confidence", strings.Join(confidences, ","))
+		endpoint = "/api/v2/fee/estimate?" + v.Encode()
+	}
+
+	var rsp FeeEstimateResponse
+	ok, err := c.GetV2(endpoint, &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
+
 // Balance makes a request to POST /api/v1/balance?addrs=xxx
 func (c *Client) Balance(addrs []string) (*BalanceResponse, error) {
 	v := url.Values{}
@@ -678,6 +699,146 @@ func (c *Client) CreateEncryptedWallet(seed, label, password string, scanN int)
 	return &w, nil
 }
 
+// CreateBip44Wallet makes a request to POST /api/v1/wallet/create and try to create
+// a bip44 wallet for the given account. The seed must be a bip39 mnemonic.
+// The wallet is encrypted if password is not empty.
+// The optional seedPassphrase is the bip39 seed passphrase, it requires a password.
+// If scanN is <= 0, the scan number defaults to 1
+func (c *Client) CreateBip44Wallet(seed, seedPassphrase, label, password string, account uint32, scanN int) (*WalletResponse, error) {
+	v := url.Values{}
+	v.Add("seed", seed)
+	v.Add("label", label)
+	v.Add("type", "bip44")
+	v.Add("bip44-account", fmt.Sprint(account))
+
+	if seedPassphrase != "" {
+		v.Add("seed-passphrase", seedPassphrase)
+	}
+
+	if password != "" {
+		v.Add("encrypt", "true")
+		v.Add("password", password)
+	}
+
+	if scanN > 0 {
+		v.Add("scan", fmt.Sprint(scanN))
+	}
+
+	var w WalletResponse
+	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateXPubWallet makes a request to POST /api/v1/wallet/create and try to create
+// a watch-only wallet from the xpub of a bip44 account.
+// If scanN is <= 0, the scan number defaults to 1
+func (c *Client) CreateXPubWallet(xpub, label string, scanN int) (*WalletResponse, error) {
+	v := url.Values{}
+	v.Add("xpub", xpub)
+	v.Add("label", label)
+	v.Add("type", "xpub")
+
+	if scanN > 0 {
+		v.Add("scan", fmt.Sprint(scanN))
+	}
+
+	var w WalletResponse
+	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateAddressesWallet makes a request to POST /api/v1/wallet/create and try to create
+// a watch-only wallet from a list of addresses.
+func (c *Client) CreateAddressesWallet(addrs []string, label string) (*WalletResponse, error) {
+	v := url.Values{}
+	v.Add("addresses", strings.Join(addrs, ","))
+	v.Add("label", label)
+	v.Add("type", "addresses")
+
+	var w WalletResponse
+	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateRemoteWallet makes a request to POST /api/v1/wallet/create to create a remote wallet,
+// whose transactions are signed by a remote signer. If pubkeys is empty, all the public keys
+// held by the signer are added to the wallet.
+func (c *Client) CreateRemoteWallet(signer, label string, pubkeys []string) (*WalletResponse, error) {
+	v := url.Values{}
+	v.Add("signer", signer)
+	v.Add("label", label)
+	v.Add("type", "remote")
+	if len(pubkeys) != 0 {
+		v.Add("public-keys", strings.Join(pubkeys, ","))
+	}
+
+	var w WalletResponse
+	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateCollectionWallet makes a request to POST /api/v1/wallet/create to create an empty collection wallet.
+// If password is not empty, the wallet will be encrypted.
+func (c *Client) CreateCollectionWallet(label, password string) (*WalletResponse, error) {
+	v := url.Values{}
+	v.Add("label", label)
+	v.Add("type", "collection")
+	if password != "" {
+		v.Add("password", password)
+		v.Add("encrypt", "true")
+	}
+
+	var w WalletResponse
+	if err := c.PostForm("/api/v1/wallet/create", strings.NewReader(v.Encode()), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ImportWalletKeys makes a request to POST /api/v2/wallet/keys/import to import secret keys into a collection wallet.
+// The keys are hex encoded or in wallet import format.
+func (c *Client) ImportWalletKeys(id string, keys []string, password string) ([]string, error) {
+	req := WalletImportKeysRequest{
+		ID:       id,
+		Keys:     keys,
+		Password: password,
+	}
+
+	var rsp WalletImportKeysResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/keys/import", req, &rsp)
+	if ok {
+		return rsp.Addresses, err
+	}
+
+	return nil, err
+}
+
+// RemoveWalletAddresses makes a request to POST /api/v2/wallet/keys/remove to remove addresses
+// and their secret keys from a collection wallet.
+func (c *Client) RemoveWalletAddresses(id string, addrs []string, password string) (*WalletResponse, error) {
+	req := WalletRemoveAddressesRequest{
+		ID:        id,
+		Addresses: addrs,
+		Password:  password,
+	}
+
+	var rsp WalletResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/keys/remove", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // NewWalletAddress makes a request to POST /api/v1/wallet/newAddress
 // if n is <= 0, defaults to 1
 func (c *Client) NewWalletAddress(id string, n int, password string) ([]string, error) {
@@ -714,11 +875,13 @@ func (c *Client) WalletBalance(id string) (*BalanceResponse, error) {
 // CreateTransactionRequest is sent to /api/v2/transaction
 type CreateTransactionRequest struct {
 	IgnoreUnconfirmed bool           `json:"ignore_unconfirmed"`
+	UnconfirmedChange bool           `json:"unconfirmed_change"`
 	HoursSelection    HoursSelection `json:"hours_selection"`
 	ChangeAddress     *string        `json:"change_address,omitempty"`
 	To                []Receiver     `json:"to"`
 	UxOuts            []string       `json:"unspents,omitempty"`
 	Addresses         []string       `json:"addresses,omitempty"`
+	Replaceable       bool           `json:"replaceable"`
 }
 
 // HoursSelection defines options for hours distribution
@@ -765,6 +928,129 @@ func (c *Client) WalletSignTransaction(req WalletSignTransactionRequest) (*Creat
 	return nil, err
 }
 
+// WalletBumpFee makes a request to POST /api/v2/wallet/transaction/bump-fee.
+// Returns the signed replacement transaction, which has been injected and broadcast.
+func (c *Client) WalletBumpFee(req WalletBumpFeeRequest) (*CreateTransactionResponse, error) {
+	var r CreateTransactionResponse
+	endpoint := "/api/v2/wallet/transaction/bump-fee"
+	ok, err := c.PostJSONV2(endpoint, req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// PendingSpendCreateRequest is sent to POST /api/v2/wallet/spends/create
+type PendingSpendCreateRequest struct {
+	WalletID string `json:"wallet_id"`
+	Creator  string `json:"creator"`
+	CreateTransactionRequest
+}
+
+// PendingSpends makes a request to GET /api/v2/wallet/spends.
+// If walletID is empty, the pending spends of all wallets are returned.
+func (c *Client) PendingSpends(walletID string) ([]PendingSpendResponse, error) {
+	endpoint := "/api/v2/wallet/spends"
+	if walletID != "" {
+		v := url.Values{}
+		v.Add("wallet_id", walletID)
+		endpoint = "/api/v2/wallet/spends?" + v.Encode()
+	}
+
+	var r []PendingSpendResponse
+	ok, err := c.GetV2(endpoint, &r)
+	if !ok {
+		return nil, err
+	}
+
+	return r, err
+}
+
+// CreatePendingSpend makes a request to POST /api/v2/wallet/spends/create
+func (c *Client) CreatePendingSpend(req PendingSpendCreateRequest) (*PendingSpendResponse, error) {
+	var r PendingSpendResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/spends/create", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// ApprovePendingSpend makes a request to POST /api/v2/wallet/spends/approve.
+// Returns the signed transaction, which has been injected and broadcast.
+func (c *Client) ApprovePendingSpend(req PendingSpendApproveRequest) (*CreateTransactionResponse, error) {
+	var r CreateTransactionResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/spends/approve", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// RejectPendingSpend makes a request to POST /api/v2/wallet/spends/reject
+func (c *Client) RejectPendingSpend(req PendingSpendRejectRequest) (*PendingSpendResponse, error) {
+	var r PendingSpendResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/spends/reject", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// CreatePST makes a request to POST /api/v2/pst/create.
+// If walletID is not empty, the bip44 derivation paths of the inputs owned by the wallet are added.
+func (c *Client) CreatePST(encodedTxn, walletID string) (*pst.PST, error) {
+	req := PSTCreateRequest{
+		EncodedTransaction: encodedTxn,
+		WalletID:           walletID,
+	}
+
+	var r pst.PST
+	ok, err := c.PostJSONV2("/api/v2/pst/create", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// SignPST makes a request to POST /api/v2/pst/sign
+func (c *Client) SignPST(req PSTSignRequest) (*pst.PST, error) {
+	var r pst.PST
+	ok, err := c.PostJSONV2("/api/v2/pst/sign", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// CombinePSTs makes a request to POST /api/v2/pst/combine
+func (c *Client) CombinePSTs(psts []*pst.PST) (*pst.PST, error) {
+	req := PSTCombineRequest{
+		PSTs: psts,
+	}
+
+	var r pst.PST
+	ok, err := c.PostJSONV2("/api/v2/pst/combine", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
+// FinalizePST makes a request to POST /api/v2/pst/finalize
+func (c *Client) FinalizePST(p *pst.PST) (*CreateTransactionResponse, error) {
+	req := PSTFinalizeRequest{
+		PST: p,
+	}
+
+	var r CreateTransactionResponse
+	ok, err := c.PostJSONV2("/api/v2/pst/finalize", req, &r)
+	if ok {
+		return &r, err
+	}
+	return nil, err
+}
+
 // CreateTransaction makes a request to POST /api/v2/transaction
 func (c *Client) CreateTransaction(req CreateTransactionRequest) (*CreateTransactionResponse, error) {
 	var r CreateTransactionResponse
@@ -864,6 +1150,43 @@ func (c *Client) WalletSeed(id string, password string) (string, error) {
 	return r.Seed, nil
 }
 
+// WalletSignMessage makes a request to POST /api/v2/wallet/sign-message to sign a message with the secret key of an address of a wallet
+func (c *Client) WalletSignMessage(id, password, addr, msg string) (*WalletSignMessageResponse, error) {
+	req := WalletSignMessageRequest{
+		ID:       id,
+		Password: password,
+		Address:  addr,
+		Message:  msg,
+	}
+
+	var rsp WalletSignMessageResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/sign-message", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// SplitWalletSeed makes a request to POST /api/v2/wallet/seed/split to split the seed of an encrypted wallet
+// into n share mnemonics, any threshold of which recover the seed
+func (c *Client) SplitWalletSeed(id, password string, threshold, n int) (*WalletSplitSeedResponse, error) {
+	req := WalletSplitSeedRequest{
+		ID:        id,
+		Password:  password,
+		Threshold: threshold,
+		Shares:    n,
+	}
+
+	var rsp WalletSplitSeedResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/seed/split", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // NetworkConnection makes a request to GET /api/v1/network/connection
 func (c *Client) NetworkConnection(addr string) (*readable.Connection, error) {
 	v := url.Values{}
@@ -954,6 +1277,15 @@ func (c *Client) PendingTransactionsVerbose() ([]readable.UnconfirmedTransaction
 	return v, nil
 }
 
+// PendingTransactionsEvicted makes a request to GET /api/v1/pendingTxs?evicted=1
+func (c *Client) PendingTransactionsEvicted() ([]readable.EvictedTransaction, error) {
+	var v []readable.EvictedTransaction
+	if err := c.Get("/api/v1/pendingTxs?evicted=1", &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
 // Transaction makes a request to GET /api/v1/transaction
 func (c *Client) Transaction(txid string) (*readable.TransactionWithStatus, error) {
 	v := url.Values{}
@@ -1156,6 +1488,23 @@ func (c *Client) VerifyAddress(addr string) (*VerifyAddressResponse, error) {
 	return nil, err
 }
 
+// VerifyMessage makes a request to POST /api/v2/message/verify to verify that a message was signed by the secret key of an address
+func (c *Client) VerifyMessage(addr, signature, msg string) (*VerifyMessageResponse, error) {
+	req := VerifyMessageRequest{
+		Address:   addr,
+		Message:   msg,
+		Signature: signature,
+	}
+
+	var rsp VerifyMessageResponse
+	ok, err := c.PostJSONV2("/api/v2/message/verify", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
 // RichlistParams are arguments to the /richlist endpoint
 type RichlistParams struct {
 	N                   int
@@ -1211,9 +1560,18 @@ func (c *Client) Health() (*HealthResponse, error) {
 
 // EncryptWallet makes a request to POST /api/v1/wallet/encrypt to encrypt a specific wallet with the given password
 func (c *Client) EncryptWallet(id, password string) (*WalletResponse, error) {
+	return c.EncryptWalletCryptoType(id, password, "")
+}
+
+// EncryptWalletCryptoType makes a request to POST /api/v1/wallet/encrypt to encrypt a specific wallet
+// with the given password and crypto type. An empty cryptoType uses the node's wallet crypto type.
+func (c *Client) EncryptWalletCryptoType(id, password, cryptoType string) (*WalletResponse, error) {
 	v := url.Values{}
 	v.Add("id", id)
 	v.Add("password", password)
+	if cryptoType != "" {
+		v.Add("crypto-type", cryptoType)
+	}
 	var wlt WalletResponse
 	if err := c.PostForm("/api/v1/wallet/encrypt", strings.NewReader(v.Encode()), &wlt); err != nil {
 		return nil, err
@@ -1239,14 +1597,276 @@ func (c *Client) DecryptWallet(id, password string) (*WalletResponse, error) {
 // The password argument is optional, if provided, the recovered wallet will be encrypted with this password,
 // otherwise the recovered wallet will be unencrypted.
 func (c *Client) RecoverWallet(id, seed, password string) (*WalletResponse, error) {
+	return c.RecoverWalletSeedPassphrase(id, seed, "", password)
+}
+
+// RecoverWalletSeedPassphrase makes a request to POST /api/v2/wallet/recover to recover an encrypted
+// bip44 wallet by seed and bip39 seed passphrase. The password is required if seedPassphrase is set.
+func (c *Client) RecoverWalletSeedPassphrase(id, seed, seedPassphrase, password string) (*WalletResponse, error) {
 	req := WalletRecoverRequest{
+		ID:             id,
+		Seed:           seed,
+		SeedPassphrase: seedPassphrase,
+		Password:       password,
+	}
+
+	var rsp WalletResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/recover", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// RecoverWalletFromSeedShares makes a request to POST /api/v2/wallet/recover to recover an encrypted wallet
+// by the share mnemonics of its seed, created with SplitWalletSeed
+func (c *Client) RecoverWalletFromSeedShares(id string, shares []string, seedPassphrase, password string) (*WalletResponse, error) {
+	req := WalletRecoverRequest{
+		ID:             id,
+		SeedShares:     shares,
+		SeedPassphrase: seedPassphrase,
+		Password:       password,
+	}
+
+	var rsp WalletResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/recover", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// ChangeWalletPassword makes a request to POST /api/v2/wallet/password to change the password
+// and/or the crypto type of an encrypted wallet. An empty newPassword keeps the password,
+// an empty cryptoType keeps the crypto type.
+func (c *Client) ChangeWalletPassword(id, password, newPassword, cryptoType string) (*WalletResponse, error) {
+	req := WalletPasswordRequest{
+		ID:          id,
+		Password:    password,
+		NewPassword: newPassword,
+		CryptoType:  cryptoType,
+	}
+
+	var rsp WalletResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/password", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// BackupWallets makes a request to POST /api/v2/wallet/backup to create a backup archive
+// of the wallet id, or of all wallets if id is empty, encrypted with password.
+func (c *Client) BackupWallets(id, password string) (*wallet.BackupArchive, error) {
+	req := WalletBackupRequest{
 		ID:       id,
-		Seed:     seed,
 		Password: password,
 	}
 
+	var rsp wallet.BackupArchive
+	ok, err := c.PostJSONV2("/api/v2/wallet/backup", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// RestoreWallets makes a request to POST /api/v2/wallet/restore to restore the wallets of a backup archive.
+// Wallets that are already loaded are replaced only if overwrite is true.
+func (c *Client) RestoreWallets(archive *wallet.BackupArchive, password string, overwrite bool) ([]WalletResponse, error) {
+	req := WalletRestoreRequest{
+		Archive:   archive,
+		Password:  password,
+		Overwrite: overwrite,
+	}
+
+	var rsp WalletRestoreResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/restore", req, &rsp)
+	if ok {
+		return rsp.Wallets, err
+	}
+
+	return nil, err
+}
+
+// ArchiveWallet makes a request to POST /api/v2/wallet/archive to unload a wallet and move
+// its file into the archive subdirectory of the wallet directory.
+// Returns the path of the archived wallet file.
+func (c *Client) ArchiveWallet(id string) (string, error) {
+	req := WalletArchiveRequest{
+		ID: id,
+	}
+
+	var rsp WalletArchiveResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/archive", req, &rsp)
+	if ok {
+		return rsp.Filename, err
+	}
+
+	return "", err
+}
+
+// DeleteWallet makes a request to POST /api/v2/wallet/delete to unload a wallet and permanently delete its file.
+// The password must be provided if the wallet is encrypted.
+func (c *Client) DeleteWallet(id, password string) error {
+	req := WalletDeleteRequest{
+		ID:       id,
+		Password: password,
+	}
+
+	_, err := c.PostJSONV2("/api/v2/wallet/delete", req, nil)
+	return err
+}
+
+// RenameWallet makes a request to POST /api/v2/wallet/rename to change the filename, which is the id, of a wallet
+func (c *Client) RenameWallet(id, newID string) (*WalletResponse, error) {
+	req := WalletRenameRequest{
+		ID:    id,
+		NewID: newID,
+	}
+
 	var rsp WalletResponse
-	ok, err := c.PostJSONV2("/api/v2/wallet/recover", req, &rsp)
+	ok, err := c.PostJSONV2("/api/v2/wallet/rename", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// FreezeOutputs makes a request to POST /api/v2/wallet/outputs/freeze to freeze unspent outputs in a wallet.
+// Frozen outputs are only spent when they are selected explicitly.
+// Returns the frozen outputs of the wallet.
+func (c *Client) FreezeOutputs(id string, uxOuts []string) ([]string, error) {
+	return c.updateFrozenOutputs("/api/v2/wallet/outputs/freeze", id, uxOuts)
+}
+
+// UnfreezeOutputs makes a request to POST /api/v2/wallet/outputs/unfreeze to unfreeze unspent outputs of a wallet.
+// Returns the frozen outputs of the wallet.
+func (c *Client) UnfreezeOutputs(id string, uxOuts []string) ([]string, error) {
+	return c.updateFrozenOutputs("/api/v2/wallet/outputs/unfreeze", id, uxOuts)
+}
+
+func (c *Client) updateFrozenOutputs(endpoint, id string, uxOuts []string) ([]string, error) {
+	req := WalletFrozenOutputsRequest{
+		ID:     id,
+		UxOuts: uxOuts,
+	}
+
+	var rsp WalletFrozenOutputsResponse
+	ok, err := c.PostJSONV2(endpoint, req, &rsp)
+	if ok {
+		return rsp.FrozenOutputs, err
+	}
+
+	return nil, err
+}
+
+// FrozenOutputs makes a request to GET /api/v2/wallet/outputs/frozen to get the frozen outputs of a wallet
+func (c *Client) FrozenOutputs(id string) ([]string, error) {
+	v := url.Values{}
+	v.Add("id", id)
+
+	var rsp WalletFrozenOutputsResponse
+	ok, err := c.GetV2("/api/v2/wallet/outputs/frozen?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return rsp.FrozenOutputs, err
+}
+
+// UpdateWalletAddress makes a request to POST /api/v2/wallet/address to update the label and metadata of an address.
+// A nil label or meta is left unchanged, an empty label or meta removes it.
+func (c *Client) UpdateWalletAddress(id, addr string, label *string, meta map[string]string) (*readable.WalletEntry, error) {
+	req := WalletAddressRequest{
+		ID:      id,
+		Address: addr,
+		Label:   label,
+		Meta:    meta,
+	}
+
+	var rsp readable.WalletEntry
+	ok, err := c.PostJSONV2("/api/v2/wallet/address", req, &rsp)
+	if ok {
+		return &rsp, err
+	}
+
+	return nil, err
+}
+
+// WalletAddressesByLabel makes a request to GET /api/v2/wallet/address to look up the addresses of a wallet by label
+func (c *Client) WalletAddressesByLabel(id, label string) ([]readable.WalletEntry, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("label", label)
+
+	var rsp []readable.WalletEntry
+	ok, err := c.GetV2("/api/v2/wallet/address?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return rsp, err
+}
+
+// WalletAddressURI makes a request to GET /api/v2/wallet/address/uri to get a payment request URI for an address of a wallet.
+// The amount, hours, label and message are optional, a nil label defaults to the label of the address in the wallet.
+func (c *Client) WalletAddressURI(id, addr, amount, hours string, label *string, message string) (*WalletAddressURIResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+	v.Add("address", addr)
+	if amount != "" {
+		v.Add("amount", amount)
+	}
+	if hours != "" {
+		v.Add("hours", hours)
+	}
+	if label != nil {
+		v.Add("label", *label)
+	}
+	if message != "" {
+		v.Add("message", message)
+	}
+
+	var rsp WalletAddressURIResponse
+	ok, err := c.GetV2("/api/v2/wallet/address/uri?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
+
+// WalletSpendingPolicy makes a request to GET /api/v2/wallet/policy to get the spending policy of a wallet
+func (c *Client) WalletSpendingPolicy(id string) (*WalletSpendingPolicyResponse, error) {
+	v := url.Values{}
+	v.Add("id", id)
+
+	var rsp WalletSpendingPolicyResponse
+	ok, err := c.GetV2("/api/v2/wallet/policy?"+v.Encode(), &rsp)
+	if !ok {
+		return nil, err
+	}
+
+	return &rsp, err
+}
+
+// SetWalletSpendingPolicy makes a request to POST /api/v2/wallet/policy to set the spending policy of an encrypted wallet.
+// An empty policy removes it.
+func (c *Client) SetWalletSpendingPolicy(id, password string, policy readable.SpendingPolicy) (*WalletSpendingPolicyResponse, error) {
+	req := WalletSpendingPolicyRequest{
+		ID:       id,
+		Password: password,
+		Policy:   &policy,
+	}
+
+	var rsp WalletSpendingPolicyResponse
+	ok, err := c.PostJSONV2("/api/v2/wallet/policy", req, &rsp)
 	if ok {
 		return &rsp, err
 	}
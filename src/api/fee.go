@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/MDLlife/MDL/src/visor"
+)
+
+// FeeEstimate is a coin hour fee estimate for a confidence level
+type FeeEstimate struct {
+	Confidence string `json:"confidence"`
+	// HoursPerKB is the number of coin hours to burn per kilobyte of the transaction.
+	// The minimum fee of the burn factor must be burned if it is higher.
+	HoursPerKB uint64 `json:"hours_per_kb"`
+}
+
+// FeeEstimateResponse is the response data for GET /api/v2/fee/estimate
+type FeeEstimateResponse struct {
+	BurnFactor              uint32        `json:"burn_factor"`
+	MaxBlockSize            uint32        `json:"max_block_size"`
+	Blocks                  uint64        `json:"blocks"`
+	FullBlocks              uint64        `json:"full_blocks"`
+	UnconfirmedTransactions uint64        `json:"unconfirmed_transactions"`
+	UnconfirmedSize         uint64        `json:"unconfirmed_size"`
+	Estimates               []FeeEstimate `json:"estimates"`
+}
+
+// NewFeeEstimateResponse creates a FeeEstimateResponse from visor.FeeEstimates
+func NewFeeEstimateResponse(e *visor.FeeEstimates) FeeEstimateResponse {
+	estimates := make([]FeeEstimate, len(e.Estimates))
+	for i, fe := range e.Estimates {
+		estimates[i] = FeeEstimate{
+			Confidence: fe.Confidence.String(),
+			HoursPerKB: fe.FeeRate,
+		}
+	}
+
+	return FeeEstimateResponse{
+		BurnFactor:              e.BurnFactor,
+		MaxBlockSize:            e.MaxBlockSize,
+		Blocks:                  e.Blocks,
+		FullBlocks:              e.FullBlocks,
+		UnconfirmedTransactions: e.UnconfirmedTransactions,
+		UnconfirmedSize:         e.UnconfirmedSize,
+		Estimates:               estimates,
+	}
+}
+
+// URI: /api/v2/fee/estimate
+// Method: GET
+// Args:
+//  confidence [comma separated list of decimals in the range (0, 1], defaults to 0.5,0.8,0.95]
+// Returns the coin hours to burn per kilobyte for a transaction to be included in the next block,
+// estimated from the recent blocks and the unconfirmed transactions.
+func feeEstimateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var confidences []decimal.Decimal
+		if s := r.FormValue("confidence"); s != "" {
+			for _, c := range strings.Split(s, ",") {
+				d, err := decimal.NewFromString(strings.TrimSpace(c))
+				if err != nil {
+					resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid confidence %q", c))
+					writeHTTPResponse(w, resp)
+					return
+				}
+				confidences = append(confidences, d)
+			}
+		}
+
+		estimates, err := gateway.EstimateFees(confidences)
+		if err != nil {
+			var resp HTTPResponse
+			switch err.(type) {
+			case visor.UserError:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			default:
+				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: NewFeeEstimateResponse(estimates),
+		})
+	}
+}
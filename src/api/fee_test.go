@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/visor"
+)
+
+func TestFeeEstimate(t *testing.T) {
+	estimates := &visor.FeeEstimates{
+		BurnFactor:              10,
+		MaxBlockSize:            32768,
+		Blocks:                  100,
+		FullBlocks:              20,
+		UnconfirmedTransactions: 3,
+		UnconfirmedSize:         1024,
+		Estimates: []visor.FeeEstimate{
+			{
+				Confidence: decimal.New(5, -1),
+				FeeRate:    0,
+			},
+			{
+				Confidence: decimal.New(95, -2),
+				FeeRate:    120,
+			},
+		},
+	}
+
+	type gatewayReturnPair struct {
+		estimates *visor.FeeEstimates
+		err       error
+	}
+
+	cases := []struct {
+		name               string
+		method             string
+		query              string
+		status             int
+		gatewayConfidences []decimal.Decimal
+		gatewayReturn      *gatewayReturnPair
+		httpResponse       HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodPost,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "400 - invalid confidence",
+			method:       http.MethodGet,
+			query:        "?confidence=0.5,foo",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid confidence "foo"`),
+		},
+		{
+			name:               "400 - confidence out of range",
+			method:             http.MethodGet,
+			query:              "?confidence=1.5",
+			status:             http.StatusBadRequest,
+			gatewayConfidences: []decimal.Decimal{decimal.New(15, -1)},
+			gatewayReturn: &gatewayReturnPair{
+				err: visor.ErrInvalidFeeEstimateConfidence,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, visor.ErrInvalidFeeEstimateConfidence.Error()),
+		},
+		{
+			name:   "500 - gateway error",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			gatewayReturn: &gatewayReturnPair{
+				err: errors.New("failed"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "failed"),
+		},
+		{
+			name:   "200",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			gatewayReturn: &gatewayReturnPair{
+				estimates: estimates,
+			},
+			httpResponse: HTTPResponse{
+				Data: FeeEstimateResponse{
+					BurnFactor:              10,
+					MaxBlockSize:            32768,
+					Blocks:                  100,
+					FullBlocks:              20,
+					UnconfirmedTransactions: 3,
+					UnconfirmedSize:         1024,
+					Estimates: []FeeEstimate{
+						{
+							Confidence: "0.5",
+							HoursPerKB: 0,
+						},
+						{
+							Confidence: "0.95",
+							HoursPerKB: 120,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:               "200 - confidences",
+			method:             http.MethodGet,
+			query:              "?confidence=0.5,0.95",
+			status:             http.StatusOK,
+			gatewayConfidences: []decimal.Decimal{decimal.New(5, -1), decimal.New(95, -2)},
+			gatewayReturn: &gatewayReturnPair{
+				estimates: estimates,
+			},
+			httpResponse: HTTPResponse{
+				Data: NewFeeEstimateResponse(estimates),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("EstimateFees", tc.gatewayConfidences).Return(tc.gatewayReturn.estimates, tc.gatewayReturn.err)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/fee/estimate"+tc.query, ContentTypeForm, "")
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+
+			if data != nil {
+				var rsp FeeEstimateResponse
+				err := json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, rsp)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package api
 import (
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/daemon"
 	"github.com/MDLlife/MDL/src/kvstorage"
+	"github.com/MDLlife/MDL/src/pst"
 	"github.com/MDLlife/MDL/src/transaction"
 	"github.com/MDLlife/MDL/src/visor"
 	"github.com/MDLlife/MDL/src/visor/historydb"
@@ -52,6 +55,8 @@ type Daemoner interface {
 	GetExchgConnection() []string
 	GetBlockchainProgress(headSeq uint64) *daemon.BlockchainProgress
 	InjectBroadcastTransaction(txn coin.Transaction) error
+	ApprovePendingSpend(id cipher.SHA256, checker string, checkerSig, creatorSig cipher.Sig, password []byte) (*coin.Transaction, []visor.TransactionInput, error)
+	BumpFee(wltID string, password []byte, txid cipher.SHA256, fee uint64) (*coin.Transaction, []visor.TransactionInput, error)
 }
 
 // Visorer interface for visor.Visor methods used by the API
@@ -59,6 +64,7 @@ type Visorer interface {
 	StartedAt() time.Time
 	HeadBkSeq() (uint64, bool, error)
 	GetBlockchainMetadata() (*visor.BlockchainMetadata, error)
+	EstimateFees(confidences []decimal.Decimal) (*visor.FeeEstimates, error)
 	ResendUnconfirmedTxns() ([]cipher.SHA256, error)
 	GetSignedBlockByHash(hash cipher.SHA256) (*coin.SignedBlock, error)
 	GetSignedBlockByHashVerbose(hash cipher.SHA256) (*coin.SignedBlock, [][]visor.TransactionInput, error)
@@ -80,6 +86,7 @@ type Visorer interface {
 	GetRichlist(includeDistribution bool) (visor.Richlist, error)
 	GetAllUnconfirmedTransactions() ([]visor.UnconfirmedTransaction, error)
 	GetAllUnconfirmedTransactionsVerbose() ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
+	GetEvictedUnconfirmedTransactions() ([]visor.EvictedTransaction, error)
 	GetTransaction(txid cipher.SHA256) (*visor.Transaction, error)
 	GetTransactionWithInputs(txid cipher.SHA256) (*visor.Transaction, []visor.TransactionInput, error)
 	GetTransactions(flts []visor.TxFilter) ([]visor.Transaction, error)
@@ -87,25 +94,48 @@ type Visorer interface {
 	GetWalletUnconfirmedTransactions(wltID string) ([]visor.UnconfirmedTransaction, error)
 	GetWalletUnconfirmedTransactionsVerbose(wltID string) ([]visor.UnconfirmedTransaction, [][]visor.TransactionInput, error)
 	GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error)
+	GetWalletFrozenOutputs(wltID string) ([]visor.UnspentOutput, error)
 	CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletCreateTransaction(wltID string, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletCreateTransactionSigned(wltID string, password []byte, p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error)
 	WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error)
+	CreatePendingSpend(wltID, creator string, p transaction.Params, wp visor.CreateTransactionParams) (*visor.PendingSpend, error)
+	GetPendingSpends(wltID string) ([]visor.PendingSpend, error)
+	RejectPendingSpend(id cipher.SHA256, checker string, sig cipher.Sig) (*visor.PendingSpend, error)
+	CreatePST(txn coin.Transaction, wltID string) (*pst.PST, error)
+	WalletSignPST(wltID string, password []byte, p *pst.PST, signIndexes []int) error
 }
 
 // Walleter interface for wallet.Service methods used by the API
 type Walleter interface {
 	UnloadWallet(wltID string) error
-	EncryptWallet(wltID string, password []byte) (*wallet.Wallet, error)
+	EncryptWallet(wltID string, password []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error)
 	DecryptWallet(wltID string, password []byte) (*wallet.Wallet, error)
+	ChangePassword(wltID string, password, newPassword []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error)
+	MigrateCryptoType(wltID string, password []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error)
 	GetWalletSeed(wltID string, password []byte) (string, error)
 	CreateWallet(wltName string, options wallet.Options, bg wallet.BalanceGetter) (*wallet.Wallet, error)
-	RecoverWallet(wltID, seed string, password []byte) (*wallet.Wallet, error)
+	RecoverWallet(wltID, seed, passphrase string, password []byte) (*wallet.Wallet, error)
+	SplitWalletSeed(wltID string, password []byte, threshold, n int) ([]string, error)
+	SignMessage(wltID string, password []byte, addr cipher.Addresser, msg string) (cipher.Sig, error)
+	RecoverWalletFromSeedShares(wltName string, shares []string, passphrase string, password []byte) (*wallet.Wallet, error)
 	NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error)
+	ImportSecretKeys(wltID string, password []byte, keys []cipher.SecKey) ([]cipher.Address, error)
+	RemoveAddresses(wltID string, password []byte, addrs []cipher.Address) error
 	GetWallet(wltID string) (*wallet.Wallet, error)
 	GetWallets() (wallet.Wallets, error)
 	UpdateWalletLabel(wltID, label string) error
+	ArchiveWallet(wltID string) (string, error)
+	DeleteWallet(wltID string, password []byte) error
+	RenameWallet(wltID, newWltID string) (*wallet.Wallet, error)
+	FreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error)
+	UnfreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error)
+	GetFrozenOutputs(wltID string) ([]cipher.SHA256, error)
+	UpdateEntry(wltID string, addr cipher.Address, u wallet.EntryUpdate) (*wallet.Wallet, error)
+	SetSpendingPolicy(wltID string, password []byte, p wallet.SpendingPolicy) (*wallet.Wallet, error)
 	WalletDir() (string, error)
+	Backup(wltID string, password []byte) (*wallet.BackupArchive, error)
+	Restore(archive *wallet.BackupArchive, password []byte, overwrite bool) ([]*wallet.Wallet, error)
 }
 
 // Storer interface for kvstorage.Manager methods used by the API
@@ -471,6 +471,9 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/wallet/transaction/sign", walletSignTransactionHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
+	webHandlerV2("/wallet/transaction/bump-fee", walletBumpFeeHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
 	webHandlerV1("/wallet/transactions", walletTransactionsHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsWallet},
 	})
@@ -489,9 +492,15 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/wallet/seed", walletSeedHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsInsecureWalletSeed},
 	})
+	webHandlerV2("/wallet/seed/split", walletSplitSeedHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsInsecureWalletSeed},
+	})
 	webHandlerV2("/wallet/seed/verify", http.HandlerFunc(walletVerifySeedHandler), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
+	webHandlerV2("/wallet/sign-message", walletSignMessageHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
 
 	webHandlerV1("/wallet/unload", walletUnloadHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
@@ -505,6 +514,62 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/wallet/recover", walletRecoverHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsWallet},
 	})
+	webHandlerV2("/wallet/password", walletPasswordHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/keys/import", walletImportKeysHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/keys/remove", walletRemoveAddressesHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/backup", walletBackupHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/restore", walletRestoreHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/archive", walletArchiveHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/delete", walletDeleteHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/rename", walletRenameHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/outputs/freeze", walletFreezeOutputsHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/outputs/unfreeze", walletUnfreezeOutputsHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/outputs/frozen", walletFrozenOutputsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/address", walletAddressHandler(gateway), map[string][]string{
+		http.MethodGet:  []string{EndpointsWallet},
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/address/uri", walletAddressURIHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/policy", walletSpendingPolicyHandler(gateway), map[string][]string{
+		http.MethodGet:  []string{EndpointsWallet},
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/spends", pendingSpendsHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/spends/create", pendingSpendCreateHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/spends/approve", pendingSpendApproveHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/wallet/spends/reject", pendingSpendRejectHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
 
 	// Blockchain interface
 	webHandlerV1("/blockchain/metadata", blockchainMetadataHandler(gateway), map[string][]string{
@@ -560,6 +625,18 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/transaction/verify", verifyTxnHandler(gateway), map[string][]string{
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/pst/create", pstCreateHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/pst/sign", pstSignHandler(gateway), map[string][]string{
+		http.MethodPost: []string{EndpointsWallet},
+	})
+	webHandlerV2("/pst/combine", pstCombineHandler(), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
+	webHandlerV2("/pst/finalize", pstFinalizeHandler(), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
 	webHandlerV1("/transactions", transactionsHandler(gateway), map[string][]string{
 		http.MethodGet:  []string{EndpointsRead},
 		http.MethodPost: []string{EndpointsRead},
@@ -573,6 +650,9 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV1("/rawtx", rawTxnHandler(gateway), map[string][]string{
 		http.MethodGet: []string{EndpointsRead},
 	})
+	webHandlerV2("/fee/estimate", feeEstimateHandler(gateway), map[string][]string{
+		http.MethodGet: []string{EndpointsRead},
+	})
 
 	// Unspent output related endpoints
 	webHandlerV1("/outputs", outputsHandler(gateway), map[string][]string{
@@ -599,6 +679,9 @@ func newServerMux(c muxConfig, gateway Gatewayer) *http.ServeMux {
 	webHandlerV2("/address/verify", http.HandlerFunc(addressVerifyHandler), map[string][]string{
 		http.MethodPost: []string{EndpointsRead},
 	})
+	webHandlerV2("/message/verify", http.HandlerFunc(verifyMessageHandler), map[string][]string{
+		http.MethodPost: []string{EndpointsRead},
+	})
 
 	// Explorer endpoints
 	webHandlerV1("/coinSupply", coinSupplyHandler(gateway), map[string][]string{
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// WalletSignMessageRequest is the request data for POST /api/v2/wallet/sign-message
+type WalletSignMessageRequest struct {
+	ID string `json:"id"`
+	// Password is required if the wallet is encrypted
+	Password string `json:"password"`
+	Address  string `json:"address"`
+	Message  string `json:"message"`
+}
+
+// WalletSignMessageResponse is the response data for POST /api/v2/wallet/sign-message
+type WalletSignMessageResponse struct {
+	Address   string `json:"address"`
+	Signature string `json:"signature"`
+}
+
+// URI: /api/v2/wallet/sign-message
+// Method: POST
+// Args: JSON body
+// Signs a message with the secret key of an address of the wallet, to prove the control of the address.
+// The address is a MDL or a Bitcoin address, depending on the coin type of the wallet.
+func walletSignMessageHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletSignMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Address == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Message == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "message is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addr, err := wallet.DecodeMessageAddress(req.Address)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		sig, err := gateway.SignMessage(req.ID, []byte(req.Password), addr, req.Message)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletSignMessageResponse{
+				Address:   addr.String(),
+				Signature: sig.Hex(),
+			},
+		})
+	}
+}
+
+// VerifyMessageRequest is the request data for POST /api/v2/message/verify
+type VerifyMessageRequest struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// VerifyMessageResponse is the response data for POST /api/v2/message/verify
+type VerifyMessageResponse struct {
+	Address string `json:"address"`
+	// Coin is the coin type of the address, mdl or bitcoin
+	Coin string `json:"coin"`
+}
+
+// URI: /api/v2/message/verify
+// Method: POST
+// Args: JSON body
+// Verifies that a message was signed by the secret key of a MDL or a Bitcoin address,
+// with POST /api/v2/wallet/sign-message.
+// Returns 422 if the signature is not valid for the address and message.
+func verifyMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if r.Header.Get("Content-Type") != ContentTypeJSON {
+		resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var req VerifyMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Address == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Signature == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "signature is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	addr, err := wallet.DecodeMessageAddress(req.Address)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	sig, err := cipher.SigFromHex(req.Signature)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid signature: %v", err))
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if err := wallet.VerifyMessage(addr, sig, req.Message); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusUnprocessableEntity, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	coin := wallet.CoinTypeMDL
+	if _, ok := addr.(cipher.BitcoinAddress); ok {
+		coin = wallet.CoinTypeBitcoin
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: VerifyMessageResponse{
+			Address: addr.String(),
+			Coin:    string(coin),
+		},
+	})
+}
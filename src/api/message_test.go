@@ -0,0 +1,382 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestWalletSignMessage(t *testing.T) {
+	pubKey, secKey := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pubKey)
+	btcAddr := cipher.BitcoinAddressFromPubKey(pubKey)
+
+	sig, err := wallet.SignMessage("foo", secKey)
+	require.NoError(t, err)
+
+	type gatewayReturnPair struct {
+		sig cipher.Sig
+		err error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		req           *WalletSignMessageRequest
+		gatewayAddr   cipher.Addresser
+		gatewayReturn *gatewayReturnPair
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:   "400 - missing id",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				Address: addr.String(),
+				Message: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:   "400 - missing address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Message: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address is required"),
+		},
+		{
+			name:   "400 - missing message",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "message is required"),
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: "foo",
+				Message: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidMessageAddress.Error()),
+		},
+		{
+			name:   "404 - wallet does not exist",
+			method: http.MethodPost,
+			status: http.StatusNotFound,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Message: "foo",
+			},
+			gatewayAddr: addr,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:   "400 - address not in wallet",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Message: "foo",
+			},
+			gatewayAddr: addr,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrUnknownAddress,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address not found in wallet"),
+		},
+		{
+			name:   "400 - invalid password",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &WalletSignMessageRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Address:  addr.String(),
+				Message:  "foo",
+			},
+			gatewayAddr: addr,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:   "403 - wallet api disabled",
+			method: http.MethodPost,
+			status: http.StatusForbidden,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Message: "foo",
+			},
+			gatewayAddr: addr,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &WalletSignMessageRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Address:  addr.String(),
+				Message:  "foo",
+			},
+			gatewayAddr: addr,
+			gatewayReturn: &gatewayReturnPair{
+				sig: sig,
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletSignMessageResponse{
+					Address:   addr.String(),
+					Signature: sig.Hex(),
+				},
+			},
+		},
+		{
+			name:   "200 - bitcoin address",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &WalletSignMessageRequest{
+				ID:      "foo.wlt",
+				Address: btcAddr.String(),
+				Message: "foo",
+			},
+			gatewayAddr: btcAddr,
+			gatewayReturn: &gatewayReturnPair{
+				sig: sig,
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletSignMessageResponse{
+					Address:   btcAddr.String(),
+					Signature: sig.Hex(),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("SignMessage", tc.req.ID, []byte(tc.req.Password), tc.gatewayAddr, tc.req.Message).Return(tc.gatewayReturn.sig, tc.gatewayReturn.err)
+			}
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			var body string
+			if tc.req != nil {
+				body = toJSON(t, tc.req)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/wallet/sign-message", contentType, body)
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+
+			if data != nil {
+				var rsp WalletSignMessageResponse
+				err := json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, rsp)
+			}
+		})
+	}
+}
+
+func TestVerifyMessage(t *testing.T) {
+	pubKey, secKey := cipher.GenerateKeyPair()
+	addr := cipher.AddressFromPubKey(pubKey)
+	btcAddr := cipher.BitcoinAddressFromPubKey(pubKey)
+
+	sig, err := wallet.SignMessage("foo", secKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		method       string
+		contentType  string
+		status       int
+		req          *VerifyMessageRequest
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:   "400 - missing address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &VerifyMessageRequest{
+				Message:   "foo",
+				Signature: sig.Hex(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address is required"),
+		},
+		{
+			name:   "400 - missing signature",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &VerifyMessageRequest{
+				Address: addr.String(),
+				Message: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "signature is required"),
+		},
+		{
+			name:   "400 - invalid address",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &VerifyMessageRequest{
+				Address:   "foo",
+				Message:   "foo",
+				Signature: sig.Hex(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidMessageAddress.Error()),
+		},
+		{
+			name:   "400 - invalid signature",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			req: &VerifyMessageRequest{
+				Address:   addr.String(),
+				Message:   "foo",
+				Signature: "abcd",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid signature: Invalid signature length"),
+		},
+		{
+			name:   "422 - wrong message",
+			method: http.MethodPost,
+			status: http.StatusUnprocessableEntity,
+			req: &VerifyMessageRequest{
+				Address:   addr.String(),
+				Message:   "bar",
+				Signature: sig.Hex(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, wallet.ErrInvalidMessageSignature.Error()),
+		},
+		{
+			name:   "422 - wrong address",
+			method: http.MethodPost,
+			status: http.StatusUnprocessableEntity,
+			req: &VerifyMessageRequest{
+				Address:   testutil.MakeAddress().String(),
+				Message:   "foo",
+				Signature: sig.Hex(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, wallet.ErrInvalidMessageSignature.Error()),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &VerifyMessageRequest{
+				Address:   addr.String(),
+				Message:   "foo",
+				Signature: sig.Hex(),
+			},
+			httpResponse: HTTPResponse{
+				Data: VerifyMessageResponse{
+					Address: addr.String(),
+					Coin:    "mdl",
+				},
+			},
+		},
+		{
+			name:   "200 - bitcoin address",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			req: &VerifyMessageRequest{
+				Address:   btcAddr.String(),
+				Message:   "foo",
+				Signature: sig.Hex(),
+			},
+			httpResponse: HTTPResponse{
+				Data: VerifyMessageResponse{
+					Address: btcAddr.String(),
+					Coin:    "bitcoin",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			var body string
+			if tc.req != nil {
+				body = toJSON(t, tc.req)
+			}
+
+			rr := servePendingSpendRequest(t, &MockGatewayer{}, tc.method, "/api/v2/message/verify", contentType, body)
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+
+			if data != nil {
+				var rsp VerifyMessageResponse
+				err := json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Equal(t, tc.httpResponse.Data, rsp)
+			}
+		})
+	}
+}
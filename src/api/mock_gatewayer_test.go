@@ -5,9 +5,11 @@ package api
 import cipher "github.com/MDLlife/MDL/src/cipher"
 import coin "github.com/MDLlife/MDL/src/coin"
 import daemon "github.com/MDLlife/MDL/src/daemon"
+import decimal "github.com/shopspring/decimal"
 import historydb "github.com/MDLlife/MDL/src/visor/historydb"
 import kvstorage "github.com/MDLlife/MDL/src/kvstorage"
 import mock "github.com/stretchr/testify/mock"
+import pst "github.com/MDLlife/MDL/src/pst"
 import time "time"
 import transaction "github.com/MDLlife/MDL/src/transaction"
 import visor "github.com/MDLlife/MDL/src/visor"
@@ -53,6 +55,183 @@ func (_m *MockGatewayer) AddressCount() (uint64, error) {
 	return r0, r1
 }
 
+// ApprovePendingSpend provides a mock function with given fields: id, checker, checkerSig, creatorSig, password
+func (_m *MockGatewayer) ApprovePendingSpend(id cipher.SHA256, checker string, checkerSig cipher.Sig, creatorSig cipher.Sig, password []byte) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(id, checker, checkerSig, creatorSig, password)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(cipher.SHA256, string, cipher.Sig, cipher.Sig, []byte) *coin.Transaction); ok {
+		r0 = rf(id, checker, checkerSig, creatorSig, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 []visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(cipher.SHA256, string, cipher.Sig, cipher.Sig, []byte) []visor.TransactionInput); ok {
+		r1 = rf(id, checker, checkerSig, creatorSig, password)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(cipher.SHA256, string, cipher.Sig, cipher.Sig, []byte) error); ok {
+		r2 = rf(id, checker, checkerSig, creatorSig, password)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ArchiveWallet provides a mock function with given fields: wltID
+func (_m *MockGatewayer) ArchiveWallet(wltID string) (string, error) {
+	ret := _m.Called(wltID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(wltID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Backup provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) Backup(wltID string, password []byte) (*wallet.BackupArchive, error) {
+	ret := _m.Called(wltID, password)
+
+	var r0 *wallet.BackupArchive
+	if rf, ok := ret.Get(0).(func(string, []byte) *wallet.BackupArchive); ok {
+		r0 = rf(wltID, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.BackupArchive)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte) error); ok {
+		r1 = rf(wltID, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BumpFee provides a mock function with given fields: wltID, password, txid, fee
+func (_m *MockGatewayer) BumpFee(wltID string, password []byte, txid cipher.SHA256, fee uint64) (*coin.Transaction, []visor.TransactionInput, error) {
+	ret := _m.Called(wltID, password, txid, fee)
+
+	var r0 *coin.Transaction
+	if rf, ok := ret.Get(0).(func(string, []byte, cipher.SHA256, uint64) *coin.Transaction); ok {
+		r0 = rf(wltID, password, txid, fee)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.Transaction)
+		}
+	}
+
+	var r1 []visor.TransactionInput
+	if rf, ok := ret.Get(1).(func(string, []byte, cipher.SHA256, uint64) []visor.TransactionInput); ok {
+		r1 = rf(wltID, password, txid, fee)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]visor.TransactionInput)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, []byte, cipher.SHA256, uint64) error); ok {
+		r2 = rf(wltID, password, txid, fee)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ChangePassword provides a mock function with given fields: wltID, password, newPassword, cryptoType
+func (_m *MockGatewayer) ChangePassword(wltID string, password []byte, newPassword []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, password, newPassword, cryptoType)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, []byte, []byte, wallet.CryptoType) *wallet.Wallet); ok {
+		r0 = rf(wltID, password, newPassword, cryptoType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, []byte, wallet.CryptoType) error); ok {
+		r1 = rf(wltID, password, newPassword, cryptoType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePST provides a mock function with given fields: txn, wltID
+func (_m *MockGatewayer) CreatePST(txn coin.Transaction, wltID string) (*pst.PST, error) {
+	ret := _m.Called(txn, wltID)
+
+	var r0 *pst.PST
+	if rf, ok := ret.Get(0).(func(coin.Transaction, string) *pst.PST); ok {
+		r0 = rf(txn, wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*pst.PST)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(coin.Transaction, string) error); ok {
+		r1 = rf(txn, wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePendingSpend provides a mock function with given fields: wltID, creator, p, wp
+func (_m *MockGatewayer) CreatePendingSpend(wltID string, creator string, p transaction.Params, wp visor.CreateTransactionParams) (*visor.PendingSpend, error) {
+	ret := _m.Called(wltID, creator, p, wp)
+
+	var r0 *visor.PendingSpend
+	if rf, ok := ret.Get(0).(func(string, string, transaction.Params, visor.CreateTransactionParams) *visor.PendingSpend); ok {
+		r0 = rf(wltID, creator, p, wp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.PendingSpend)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, transaction.Params, visor.CreateTransactionParams) error); ok {
+		r1 = rf(wltID, creator, p, wp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateTransaction provides a mock function with given fields: p, wp
 func (_m *MockGatewayer) CreateTransaction(p transaction.Params, wp visor.CreateTransactionParams) (*coin.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(p, wp)
@@ -145,6 +324,20 @@ func (_m *MockGatewayer) DecryptWallet(wltID string, password []byte) (*wallet.W
 	return r0, r1
 }
 
+// DeleteWallet provides a mock function with given fields: wltID, password
+func (_m *MockGatewayer) DeleteWallet(wltID string, password []byte) error {
+	ret := _m.Called(wltID, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(wltID, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DisconnectByGnetID provides a mock function with given fields: gnetID
 func (_m *MockGatewayer) DisconnectByGnetID(gnetID uint64) error {
 	ret := _m.Called(gnetID)
@@ -159,13 +352,13 @@ func (_m *MockGatewayer) DisconnectByGnetID(gnetID uint64) error {
 	return r0
 }
 
-// EncryptWallet provides a mock function with given fields: wltID, password
-func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte) (*wallet.Wallet, error) {
-	ret := _m.Called(wltID, password)
+// EncryptWallet provides a mock function with given fields: wltID, password, cryptoType
+func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, password, cryptoType)
 
 	var r0 *wallet.Wallet
-	if rf, ok := ret.Get(0).(func(string, []byte) *wallet.Wallet); ok {
-		r0 = rf(wltID, password)
+	if rf, ok := ret.Get(0).(func(string, []byte, wallet.CryptoType) *wallet.Wallet); ok {
+		r0 = rf(wltID, password, cryptoType)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*wallet.Wallet)
@@ -173,8 +366,54 @@ func (_m *MockGatewayer) EncryptWallet(wltID string, password []byte) (*wallet.W
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, []byte) error); ok {
-		r1 = rf(wltID, password)
+	if rf, ok := ret.Get(1).(func(string, []byte, wallet.CryptoType) error); ok {
+		r1 = rf(wltID, password, cryptoType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateFees provides a mock function with given fields: confidences
+func (_m *MockGatewayer) EstimateFees(confidences []decimal.Decimal) (*visor.FeeEstimates, error) {
+	ret := _m.Called(confidences)
+
+	var r0 *visor.FeeEstimates
+	if rf, ok := ret.Get(0).(func([]decimal.Decimal) *visor.FeeEstimates); ok {
+		r0 = rf(confidences)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.FeeEstimates)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]decimal.Decimal) error); ok {
+		r1 = rf(confidences)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FreezeOutputs provides a mock function with given fields: wltID, uxIDs
+func (_m *MockGatewayer) FreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error) {
+	ret := _m.Called(wltID, uxIDs)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(string, []cipher.SHA256) []cipher.SHA256); ok {
+		r0 = rf(wltID, uxIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []cipher.SHA256) error); ok {
+		r1 = rf(wltID, uxIDs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -494,6 +733,29 @@ func (_m *MockGatewayer) GetDefaultConnections() []string {
 	return r0
 }
 
+// GetEvictedUnconfirmedTransactions provides a mock function with given fields:
+func (_m *MockGatewayer) GetEvictedUnconfirmedTransactions() ([]visor.EvictedTransaction, error) {
+	ret := _m.Called()
+
+	var r0 []visor.EvictedTransaction
+	if rf, ok := ret.Get(0).(func() []visor.EvictedTransaction); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.EvictedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetExchgConnection provides a mock function with given fields:
 func (_m *MockGatewayer) GetExchgConnection() []string {
 	ret := _m.Called()
@@ -510,6 +772,29 @@ func (_m *MockGatewayer) GetExchgConnection() []string {
 	return r0
 }
 
+// GetFrozenOutputs provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetFrozenOutputs(wltID string) ([]cipher.SHA256, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(string) []cipher.SHA256); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLastBlocks provides a mock function with given fields: num
 func (_m *MockGatewayer) GetLastBlocks(num uint64) ([]coin.SignedBlock, error) {
 	ret := _m.Called(num)
@@ -565,6 +850,29 @@ func (_m *MockGatewayer) GetLastBlocksVerbose(num uint64) ([]coin.SignedBlock, [
 	return r0, r1, r2
 }
 
+// GetPendingSpends provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetPendingSpends(wltID string) ([]visor.PendingSpend, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []visor.PendingSpend
+	if rf, ok := ret.Get(0).(func(string) []visor.PendingSpend); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.PendingSpend)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRichlist provides a mock function with given fields: includeDistribution
 func (_m *MockGatewayer) GetRichlist(includeDistribution bool) (visor.Richlist, error) {
 	ret := _m.Called(includeDistribution)
@@ -999,6 +1307,29 @@ func (_m *MockGatewayer) GetWalletBalance(wltID string) (wallet.BalancePair, wal
 	return r0, r1, r2
 }
 
+// GetWalletFrozenOutputs provides a mock function with given fields: wltID
+func (_m *MockGatewayer) GetWalletFrozenOutputs(wltID string) ([]visor.UnspentOutput, error) {
+	ret := _m.Called(wltID)
+
+	var r0 []visor.UnspentOutput
+	if rf, ok := ret.Get(0).(func(string) []visor.UnspentOutput); ok {
+		r0 = rf(wltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]visor.UnspentOutput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(wltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWalletSeed provides a mock function with given fields: wltID, password
 func (_m *MockGatewayer) GetWalletSeed(wltID string, password []byte) (string, error) {
 	ret := _m.Called(wltID, password)
@@ -1126,6 +1457,29 @@ func (_m *MockGatewayer) HeadBkSeq() (uint64, bool, error) {
 	return r0, r1, r2
 }
 
+// ImportSecretKeys provides a mock function with given fields: wltID, password, keys
+func (_m *MockGatewayer) ImportSecretKeys(wltID string, password []byte, keys []cipher.SecKey) ([]cipher.Address, error) {
+	ret := _m.Called(wltID, password, keys)
+
+	var r0 []cipher.Address
+	if rf, ok := ret.Get(0).(func(string, []byte, []cipher.SecKey) []cipher.Address); ok {
+		r0 = rf(wltID, password, keys)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.Address)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, []cipher.SecKey) error); ok {
+		r1 = rf(wltID, password, keys)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InjectBroadcastTransaction provides a mock function with given fields: txn
 func (_m *MockGatewayer) InjectBroadcastTransaction(txn coin.Transaction) error {
 	ret := _m.Called(txn)
@@ -1140,6 +1494,29 @@ func (_m *MockGatewayer) InjectBroadcastTransaction(txn coin.Transaction) error
 	return r0
 }
 
+// MigrateCryptoType provides a mock function with given fields: wltID, password, cryptoType
+func (_m *MockGatewayer) MigrateCryptoType(wltID string, password []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, password, cryptoType)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, []byte, wallet.CryptoType) *wallet.Wallet); ok {
+		r0 = rf(wltID, password, cryptoType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, wallet.CryptoType) error); ok {
+		r1 = rf(wltID, password, cryptoType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewAddresses provides a mock function with given fields: wltID, password, n
 func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) ([]cipher.Address, error) {
 	ret := _m.Called(wltID, password, n)
@@ -1163,13 +1540,13 @@ func (_m *MockGatewayer) NewAddresses(wltID string, password []byte, n uint64) (
 	return r0, r1
 }
 
-// RecoverWallet provides a mock function with given fields: wltID, seed, password
-func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, password []byte) (*wallet.Wallet, error) {
-	ret := _m.Called(wltID, seed, password)
+// RecoverWallet provides a mock function with given fields: wltID, seed, passphrase, password
+func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, passphrase string, password []byte) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, seed, passphrase, password)
 
 	var r0 *wallet.Wallet
-	if rf, ok := ret.Get(0).(func(string, string, []byte) *wallet.Wallet); ok {
-		r0 = rf(wltID, seed, password)
+	if rf, ok := ret.Get(0).(func(string, string, string, []byte) *wallet.Wallet); ok {
+		r0 = rf(wltID, seed, passphrase, password)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*wallet.Wallet)
@@ -1177,8 +1554,54 @@ func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, password []byt
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, []byte) error); ok {
-		r1 = rf(wltID, seed, password)
+	if rf, ok := ret.Get(1).(func(string, string, string, []byte) error); ok {
+		r1 = rf(wltID, seed, passphrase, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecoverWalletFromSeedShares provides a mock function with given fields: wltName, shares, passphrase, password
+func (_m *MockGatewayer) RecoverWalletFromSeedShares(wltName string, shares []string, passphrase string, password []byte) (*wallet.Wallet, error) {
+	ret := _m.Called(wltName, shares, passphrase, password)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, []string, string, []byte) *wallet.Wallet); ok {
+		r0 = rf(wltName, shares, passphrase, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []string, string, []byte) error); ok {
+		r1 = rf(wltName, shares, passphrase, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RejectPendingSpend provides a mock function with given fields: id, checker, sig
+func (_m *MockGatewayer) RejectPendingSpend(id cipher.SHA256, checker string, sig cipher.Sig) (*visor.PendingSpend, error) {
+	ret := _m.Called(id, checker, sig)
+
+	var r0 *visor.PendingSpend
+	if rf, ok := ret.Get(0).(func(cipher.SHA256, string, cipher.Sig) *visor.PendingSpend); ok {
+		r0 = rf(id, checker, sig)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*visor.PendingSpend)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(cipher.SHA256, string, cipher.Sig) error); ok {
+		r1 = rf(id, checker, sig)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1186,6 +1609,20 @@ func (_m *MockGatewayer) RecoverWallet(wltID string, seed string, password []byt
 	return r0, r1
 }
 
+// RemoveAddresses provides a mock function with given fields: wltID, password, addrs
+func (_m *MockGatewayer) RemoveAddresses(wltID string, password []byte, addrs []cipher.Address) error {
+	ret := _m.Called(wltID, password, addrs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, []cipher.Address) error); ok {
+		r0 = rf(wltID, password, addrs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveStorageValue provides a mock function with given fields: storageType, key
 func (_m *MockGatewayer) RemoveStorageValue(storageType kvstorage.Type, key string) error {
 	ret := _m.Called(storageType, key)
@@ -1200,6 +1637,29 @@ func (_m *MockGatewayer) RemoveStorageValue(storageType kvstorage.Type, key stri
 	return r0
 }
 
+// RenameWallet provides a mock function with given fields: wltID, newWltID
+func (_m *MockGatewayer) RenameWallet(wltID string, newWltID string) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, newWltID)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, string) *wallet.Wallet); ok {
+		r0 = rf(wltID, newWltID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(wltID, newWltID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResendUnconfirmedTxns provides a mock function with given fields:
 func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
 	ret := _m.Called()
@@ -1223,6 +1683,98 @@ func (_m *MockGatewayer) ResendUnconfirmedTxns() ([]cipher.SHA256, error) {
 	return r0, r1
 }
 
+// Restore provides a mock function with given fields: archive, password, overwrite
+func (_m *MockGatewayer) Restore(archive *wallet.BackupArchive, password []byte, overwrite bool) ([]*wallet.Wallet, error) {
+	ret := _m.Called(archive, password, overwrite)
+
+	var r0 []*wallet.Wallet
+	if rf, ok := ret.Get(0).(func(*wallet.BackupArchive, []byte, bool) []*wallet.Wallet); ok {
+		r0 = rf(archive, password, overwrite)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*wallet.BackupArchive, []byte, bool) error); ok {
+		r1 = rf(archive, password, overwrite)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetSpendingPolicy provides a mock function with given fields: wltID, password, p
+func (_m *MockGatewayer) SetSpendingPolicy(wltID string, password []byte, p wallet.SpendingPolicy) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, password, p)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, []byte, wallet.SpendingPolicy) *wallet.Wallet); ok {
+		r0 = rf(wltID, password, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, wallet.SpendingPolicy) error); ok {
+		r1 = rf(wltID, password, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignMessage provides a mock function with given fields: wltID, password, addr, msg
+func (_m *MockGatewayer) SignMessage(wltID string, password []byte, addr cipher.Addresser, msg string) (cipher.Sig, error) {
+	ret := _m.Called(wltID, password, addr, msg)
+
+	var r0 cipher.Sig
+	if rf, ok := ret.Get(0).(func(string, []byte, cipher.Addresser, string) cipher.Sig); ok {
+		r0 = rf(wltID, password, addr, msg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cipher.Sig)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, cipher.Addresser, string) error); ok {
+		r1 = rf(wltID, password, addr, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SplitWalletSeed provides a mock function with given fields: wltID, password, threshold, n
+func (_m *MockGatewayer) SplitWalletSeed(wltID string, password []byte, threshold int, n int) ([]string, error) {
+	ret := _m.Called(wltID, password, threshold, n)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(string, []byte, int, int) []string); ok {
+		r0 = rf(wltID, password, threshold, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []byte, int, int) error); ok {
+		r1 = rf(wltID, password, threshold, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StartedAt provides a mock function with given fields:
 func (_m *MockGatewayer) StartedAt() time.Time {
 	ret := _m.Called()
@@ -1237,6 +1789,29 @@ func (_m *MockGatewayer) StartedAt() time.Time {
 	return r0
 }
 
+// UnfreezeOutputs provides a mock function with given fields: wltID, uxIDs
+func (_m *MockGatewayer) UnfreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error) {
+	ret := _m.Called(wltID, uxIDs)
+
+	var r0 []cipher.SHA256
+	if rf, ok := ret.Get(0).(func(string, []cipher.SHA256) []cipher.SHA256); ok {
+		r0 = rf(wltID, uxIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]cipher.SHA256)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, []cipher.SHA256) error); ok {
+		r1 = rf(wltID, uxIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UnloadWallet provides a mock function with given fields: wltID
 func (_m *MockGatewayer) UnloadWallet(wltID string) error {
 	ret := _m.Called(wltID)
@@ -1251,6 +1826,29 @@ func (_m *MockGatewayer) UnloadWallet(wltID string) error {
 	return r0
 }
 
+// UpdateEntry provides a mock function with given fields: wltID, addr, u
+func (_m *MockGatewayer) UpdateEntry(wltID string, addr cipher.Address, u wallet.EntryUpdate) (*wallet.Wallet, error) {
+	ret := _m.Called(wltID, addr, u)
+
+	var r0 *wallet.Wallet
+	if rf, ok := ret.Get(0).(func(string, cipher.Address, wallet.EntryUpdate) *wallet.Wallet); ok {
+		r0 = rf(wltID, addr, u)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wallet.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, cipher.Address, wallet.EntryUpdate) error); ok {
+		r1 = rf(wltID, addr, u)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateWalletLabel provides a mock function with given fields: wltID, label
 func (_m *MockGatewayer) UpdateWalletLabel(wltID string, label string) error {
 	ret := _m.Called(wltID, label)
@@ -1380,6 +1978,20 @@ func (_m *MockGatewayer) WalletDir() (string, error) {
 	return r0, r1
 }
 
+// WalletSignPST provides a mock function with given fields: wltID, password, p, signIndexes
+func (_m *MockGatewayer) WalletSignPST(wltID string, password []byte, p *pst.PST, signIndexes []int) error {
+	ret := _m.Called(wltID, password, p, signIndexes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte, *pst.PST, []int) error); ok {
+		r0 = rf(wltID, password, p, signIndexes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WalletSignTransaction provides a mock function with given fields: wltID, password, txn, signIndexes
 func (_m *MockGatewayer) WalletSignTransaction(wltID string, password []byte, txn *coin.Transaction, signIndexes []int) (*coin.Transaction, []visor.TransactionInput, error) {
 	ret := _m.Called(wltID, password, txn, signIndexes)
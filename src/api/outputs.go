@@ -7,6 +7,7 @@ import (
 	"github.com/MDLlife/MDL/src/readable"
 	wh "github.com/MDLlife/MDL/src/util/http"
 	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
 )
 
 // outputsHandler returns UxOuts filtered by a set of addresses or a set of hashes
@@ -72,6 +73,40 @@ func outputsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		// Mark the outputs frozen in the loaded wallets, unless the wallet API is disabled
+		wlts, err := gateway.GetWallets()
+		switch err {
+		case nil:
+			markFrozenOutputs(rSummary, wlts)
+		case wallet.ErrWalletAPIDisabled:
+		default:
+			err = fmt.Errorf("gateway.GetWallets failed: %v", err)
+			wh.Error500(w, err.Error())
+			return
+		}
+
 		wh.SendJSONOr500(logger, w, rSummary)
 	}
 }
+
+// markFrozenOutputs sets the frozen flag of the outputs which are frozen in any of the wallets
+func markFrozenOutputs(summary *readable.UnspentOutputsSummary, wlts wallet.Wallets) {
+	frozen := make(map[string]struct{})
+	for _, w := range wlts {
+		for _, h := range w.FrozenOutputs() {
+			frozen[h.Hex()] = struct{}{}
+		}
+	}
+
+	if len(frozen) == 0 {
+		return
+	}
+
+	for _, outs := range []readable.UnspentOutputs{summary.HeadOutputs, summary.OutgoingOutputs, summary.IncomingOutputs} {
+		for i := range outs {
+			if _, ok := frozen[outs[i].Hash]; ok {
+				outs[i].Frozen = true
+			}
+		}
+	}
+}
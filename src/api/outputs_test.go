@@ -13,9 +13,12 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/readable"
+	"github.com/MDLlife/MDL/src/testutil"
 	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
 )
 
 func TestGetOutputsHandler(t *testing.T) {
@@ -27,6 +30,42 @@ func TestGetOutputsHandler(t *testing.T) {
 		addrs   string
 		hashStr string
 	}
+
+	head := readable.BlockHeader{
+		Hash:         "7b8ec8dd836b564f0c85ad088fc744de820345204e154bc1503e04e9d6fdd9f1",
+		PreviousHash: "0000000000000000000000000000000000000000000000000000000000000000",
+		BodyHash:     "0000000000000000000000000000000000000000000000000000000000000000",
+		UxHash:       "0000000000000000000000000000000000000000000000000000000000000000",
+	}
+
+	frozenOutput := visor.UnspentOutput{
+		UxOut: coin.UxOut{
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          1e6,
+			},
+		},
+	}
+	otherOutput := visor.UnspentOutput{
+		UxOut: coin.UxOut{
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        testutil.MakeAddress(),
+				Coins:          2e6,
+			},
+		},
+	}
+	rFrozenOutput, err := readable.NewUnspentOutput(frozenOutput)
+	require.NoError(t, err)
+	rOtherOutput, err := readable.NewUnspentOutput(otherOutput)
+	require.NoError(t, err)
+
+	frozenWallet, err := wallet.NewWallet("t.wlt", wallet.Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	frozenWallet.FreezeOutputs([]cipher.SHA256{frozenOutput.Hash()})
 	tt := []struct {
 		name                      string
 		method                    string
@@ -35,6 +74,8 @@ func TestGetOutputsHandler(t *testing.T) {
 		httpBody                  *httpBody
 		getUnspentOutputsResponse *visor.UnspentOutputsSummary
 		getUnspentOutputsError    error
+		getWallets                wallet.Wallets
+		getWalletsErr             error
 		httpResponse              *readable.UnspentOutputsSummary
 	}{
 		{
@@ -89,6 +130,55 @@ func TestGetOutputsHandler(t *testing.T) {
 				IncomingOutputs: readable.UnspentOutputs{},
 			},
 		},
+		{
+			name:   "500 - getWalletsError",
+			method: http.MethodGet,
+			status: http.StatusInternalServerError,
+			err:    "500 Internal Server Error - gateway.GetWallets failed: getWalletsError",
+			getUnspentOutputsResponse: &visor.UnspentOutputsSummary{
+				HeadBlock: &coin.SignedBlock{},
+			},
+			getWalletsErr: errors.New("getWalletsError"),
+		},
+		{
+			name:   "200 - wallet API disabled",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			getUnspentOutputsResponse: &visor.UnspentOutputsSummary{
+				HeadBlock: &coin.SignedBlock{},
+				Confirmed: []visor.UnspentOutput{frozenOutput},
+			},
+			getWalletsErr: wallet.ErrWalletAPIDisabled,
+			httpResponse: &readable.UnspentOutputsSummary{
+				Head:            head,
+				HeadOutputs:     readable.UnspentOutputs{rFrozenOutput},
+				OutgoingOutputs: readable.UnspentOutputs{},
+				IncomingOutputs: readable.UnspentOutputs{},
+			},
+		},
+		{
+			name:   "200 - frozen outputs",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			getUnspentOutputsResponse: &visor.UnspentOutputsSummary{
+				HeadBlock: &coin.SignedBlock{},
+				Confirmed: []visor.UnspentOutput{frozenOutput},
+				Incoming:  []visor.UnspentOutput{otherOutput},
+			},
+			getWallets: wallet.Wallets{
+				"t.wlt": frozenWallet,
+			},
+			httpResponse: &readable.UnspentOutputsSummary{
+				Head: head,
+				HeadOutputs: readable.UnspentOutputs{func() readable.UnspentOutput {
+					o := rFrozenOutput
+					o.Frozen = true
+					return o
+				}()},
+				OutgoingOutputs: readable.UnspentOutputs{},
+				IncomingOutputs: readable.UnspentOutputs{rOtherOutput},
+			},
+		},
 		{
 			name:   "200 - OK POST",
 			method: http.MethodPost,
@@ -115,6 +205,7 @@ func TestGetOutputsHandler(t *testing.T) {
 			gateway := &MockGatewayer{}
 			endpoint := "/api/v1/outputs"
 			gateway.On("GetUnspentOutputsSummary", mock.Anything).Return(tc.getUnspentOutputsResponse, tc.getUnspentOutputsError)
+			gateway.On("GetWallets").Return(tc.getWallets, tc.getWalletsErr)
 
 			v := url.Values{}
 			if tc.httpBody != nil {
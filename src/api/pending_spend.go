@@ -0,0 +1,368 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/daemon"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/visor/blockdb"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// PendingSpendResponse is a pending wallet spend returned by /api/v2/wallet/spends
+type PendingSpendResponse struct {
+	ID        string `json:"id"`
+	WalletID  string `json:"wallet_id"`
+	Creator   string `json:"creator"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	CreateTransactionResponse
+}
+
+// NewPendingSpendResponse creates a PendingSpendResponse
+func NewPendingSpendResponse(ps *visor.PendingSpend) (*PendingSpendResponse, error) {
+	txnResp, err := NewCreateTransactionResponse(&ps.Transaction, ps.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PendingSpendResponse{
+		ID:                        ps.ID().Hex(),
+		WalletID:                  ps.WalletID,
+		Creator:                   ps.Creator,
+		CreatedAt:                 ps.CreatedAt.Unix(),
+		ExpiresAt:                 ps.ExpiresAt.Unix(),
+		CreateTransactionResponse: *txnResp,
+	}, nil
+}
+
+// URI: /api/v2/wallet/spends
+// Method: GET
+// Args:
+//	wallet_id: wallet id [optional]
+// Returns the pending spends which have not expired, of all wallets or of a wallet
+func pendingSpendsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		spends, err := gateway.GetPendingSpends(r.FormValue("wallet_id"))
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		rsp := make([]PendingSpendResponse, len(spends))
+		for i := range spends {
+			ps, err := NewPendingSpendResponse(&spends[i])
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+			rsp[i] = *ps
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rsp,
+		})
+	}
+}
+
+// pendingSpendCreateRequest is the request data for POST /api/v2/wallet/spends/create
+type pendingSpendCreateRequest struct {
+	WalletID string `json:"wallet_id"`
+	Creator  string `json:"creator"`
+	createTransactionRequest
+}
+
+// Validate validates pendingSpendCreateRequest data
+func (r pendingSpendCreateRequest) Validate() error {
+	if r.WalletID == "" {
+		return errors.New("wallet_id is required")
+	}
+
+	if r.Creator == "" {
+		return errors.New("creator is required")
+	}
+
+	return r.createTransactionRequest.Validate()
+}
+
+// URI: /api/v2/wallet/spends/create
+// Method: POST
+// Args: JSON body, the wallet_id and transaction options of POST /api/v1/wallet/transaction and the creator
+// Creates an unsigned transaction from a wallet and adds it to the pending spends, until it is approved or rejected
+func pendingSpendCreateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req pendingSpendCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := req.parseURIs(); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := req.Validate(); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		ps, err := gateway.CreatePendingSpend(req.WalletID, req.Creator, req.TransactionParams(), req.VisorParams())
+		if err != nil {
+			writeHTTPResponse(w, pendingSpendErrorResponse(err))
+			return
+		}
+
+		rsp, err := NewPendingSpendResponse(ps)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rsp,
+		})
+	}
+}
+
+// PendingSpendApproveRequest is the request data for POST /api/v2/wallet/spends/approve
+type PendingSpendApproveRequest struct {
+	ID               string `json:"id"`
+	Checker          string `json:"checker"`
+	Signature        string `json:"signature"`
+	CreatorSignature string `json:"creator_signature"`
+	Password         string `json:"password"`
+}
+
+// URI: /api/v2/wallet/spends/approve
+// Method: POST
+// Args: JSON body
+// Signs the transaction of a pending spend, injects and broadcasts it.
+// The checker which approves the spend can't be its creator. The approval is verified with the
+// signatures of the checker and of the creator, by the keys registered with -pending-spend-keys.
+func pendingSpendApproveHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PendingSpendApproveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		id, ok := parsePendingSpendID(w, req.ID, req.Checker)
+		if !ok {
+			return
+		}
+
+		checkerSig, ok := parsePendingSpendSig(w, "signature", req.Signature)
+		if !ok {
+			return
+		}
+
+		creatorSig, ok := parsePendingSpendSig(w, "creator_signature", req.CreatorSignature)
+		if !ok {
+			return
+		}
+
+		txn, inputs, err := gateway.ApprovePendingSpend(id, req.Checker, checkerSig, creatorSig, []byte(req.Password))
+		if err != nil {
+			writeHTTPResponse(w, pendingSpendErrorResponse(err))
+			return
+		}
+
+		txnResp, err := NewCreateTransactionResponse(txn, inputs)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: txnResp,
+		})
+	}
+}
+
+// PendingSpendRejectRequest is the request data for POST /api/v2/wallet/spends/reject
+type PendingSpendRejectRequest struct {
+	ID        string `json:"id"`
+	Checker   string `json:"checker"`
+	Signature string `json:"signature"`
+}
+
+// URI: /api/v2/wallet/spends/reject
+// Method: POST
+// Args: JSON body
+// Removes a pending spend without signing it. The rejection is verified with the signature of the checker.
+func pendingSpendRejectHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PendingSpendRejectRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		id, ok := parsePendingSpendID(w, req.ID, req.Checker)
+		if !ok {
+			return
+		}
+
+		sig, ok := parsePendingSpendSig(w, "signature", req.Signature)
+		if !ok {
+			return
+		}
+
+		ps, err := gateway.RejectPendingSpend(id, req.Checker, sig)
+		if err != nil {
+			writeHTTPResponse(w, pendingSpendErrorResponse(err))
+			return
+		}
+
+		rsp, err := NewPendingSpendResponse(ps)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rsp,
+		})
+	}
+}
+
+// parsePendingSpendID checks the id and checker of an approve or reject request.
+// Writes an error response and returns false if they are invalid.
+func parsePendingSpendID(w http.ResponseWriter, idStr, checker string) (cipher.SHA256, bool) {
+	if idStr == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return cipher.SHA256{}, false
+	}
+
+	id, err := cipher.SHA256FromHex(idStr)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid id")
+		writeHTTPResponse(w, resp)
+		return cipher.SHA256{}, false
+	}
+
+	if checker == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "checker is required")
+		writeHTTPResponse(w, resp)
+		return cipher.SHA256{}, false
+	}
+
+	return id, true
+}
+
+// parsePendingSpendSig parses the hex encoded signature of a creator or checker, the field name of the request.
+// Writes an error response and returns false if it is missing or invalid.
+func parsePendingSpendSig(w http.ResponseWriter, name, sigStr string) (cipher.Sig, bool) {
+	if sigStr == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, name+" is required")
+		writeHTTPResponse(w, resp)
+		return cipher.Sig{}, false
+	}
+
+	sig, err := cipher.SigFromHex(sigStr)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid "+name)
+		writeHTTPResponse(w, resp)
+		return cipher.Sig{}, false
+	}
+
+	return sig, true
+}
+
+// pendingSpendErrorResponse maps the errors of creating, approving and rejecting pending spends to a response
+func pendingSpendErrorResponse(err error) HTTPResponse {
+	switch err {
+	case visor.ErrPendingSpendNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	case visor.ErrPendingSpendSelfApproval,
+		visor.ErrPendingSpendUnknownIdentity,
+		visor.ErrPendingSpendInvalidSignature:
+		return NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+	case fee.ErrTxnNoFee,
+		fee.ErrTxnInsufficientCoinHours:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	}
+
+	switch e := err.(type) {
+	case wallet.PolicyViolationError:
+		return policyViolationResponse(e)
+	case wallet.Error,
+		visor.UserError,
+		transaction.Error,
+		blockdb.ErrUnspentNotExist,
+		visor.ErrTxnViolatesUserConstraint,
+		visor.ErrTxnViolatesHardConstraint,
+		visor.ErrTxnViolatesSoftConstraint:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	}
+
+	if daemon.IsBroadcastFailure(err) {
+		return NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+	}
+
+	return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+}
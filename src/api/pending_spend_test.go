@@ -0,0 +1,678 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func makePendingSpend(t *testing.T, wltID, creator string) visor.PendingSpend {
+	txn := coin.Transaction{
+		Length:    100,
+		InnerHash: testutil.RandSHA256(t),
+		In:        []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   100,
+			},
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Head: coin.UxHead{
+					Time:  uint64(time.Now().UTC().Unix()),
+					BkSeq: 9999,
+				},
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 200,
+		},
+	}
+
+	now := time.Unix(time.Now().Unix(), 0).UTC()
+	return visor.PendingSpend{
+		WalletID:    wltID,
+		Creator:     creator,
+		Transaction: txn,
+		Inputs:      inputs,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+}
+
+func servePendingSpendRequest(t *testing.T, gateway *MockGatewayer, method, endpoint, contentType, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, endpoint, strings.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentType)
+
+	setCSRFParameters(t, tokenValid, req)
+
+	rr := httptest.NewRecorder()
+
+	cfg := defaultMuxConfig()
+	cfg.disableCSRF = false
+
+	handler := newServerMux(cfg, gateway)
+	handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// requirePendingSpendResponse checks the status and error of a response, and returns its data
+func requirePendingSpendResponse(t *testing.T, rr *httptest.ResponseRecorder, status int, httpResponse HTTPResponse) json.RawMessage {
+	require.Equal(t, status, rr.Code, "got `%v` want `%v`", rr.Code, status)
+
+	var rsp ReceivedHTTPResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+
+	require.Equal(t, httpResponse.Error, rsp.Error)
+
+	if rsp.Data == nil {
+		require.Nil(t, httpResponse.Data)
+	} else {
+		require.NotNil(t, httpResponse.Data)
+	}
+
+	return rsp.Data
+}
+
+func TestPendingSpends(t *testing.T) {
+	ps1 := makePendingSpend(t, "foo.wlt", "alice")
+	ps2 := makePendingSpend(t, "bar.wlt", "bob")
+
+	ps1Resp, err := NewPendingSpendResponse(&ps1)
+	require.NoError(t, err)
+	ps2Resp, err := NewPendingSpendResponse(&ps2)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		method          string
+		status          int
+		walletID        string
+		gatewayResult   []visor.PendingSpend
+		gatewayErr      error
+		httpResponse    HTTPResponse
+		pendingResponse []PendingSpendResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodPost,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "500 - gateway error",
+			method:       http.MethodGet,
+			status:       http.StatusInternalServerError,
+			gatewayErr:   errors.New("db error"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "db error"),
+		},
+		{
+			name:   "200 - no pending spends",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			httpResponse: HTTPResponse{
+				Data: []PendingSpendResponse{},
+			},
+			pendingResponse: []PendingSpendResponse{},
+		},
+		{
+			name:          "200 - all wallets",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			gatewayResult: []visor.PendingSpend{ps1, ps2},
+			httpResponse: HTTPResponse{
+				Data: []PendingSpendResponse{*ps1Resp, *ps2Resp},
+			},
+			pendingResponse: []PendingSpendResponse{*ps1Resp, *ps2Resp},
+		},
+		{
+			name:          "200 - one wallet",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			walletID:      "foo.wlt",
+			gatewayResult: []visor.PendingSpend{ps1},
+			httpResponse: HTTPResponse{
+				Data: []PendingSpendResponse{*ps1Resp},
+			},
+			pendingResponse: []PendingSpendResponse{*ps1Resp},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("GetPendingSpends", tc.walletID).Return(tc.gatewayResult, tc.gatewayErr)
+
+			endpoint := "/api/v2/wallet/spends"
+			if tc.walletID != "" {
+				v := url.Values{}
+				v.Add("wallet_id", tc.walletID)
+				endpoint += "?" + v.Encode()
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, endpoint, "", "")
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.pendingResponse != nil {
+				var rsp []PendingSpendResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.pendingResponse, rsp)
+			}
+		})
+	}
+}
+
+func TestPendingSpendCreate(t *testing.T) {
+	ps := makePendingSpend(t, "foo.wlt", "alice")
+	psResp, err := NewPendingSpendResponse(&ps)
+	require.NoError(t, err)
+
+	type rawPendingSpendCreateRequest struct {
+		rawCreateTxnRequest
+		WalletID string `json:"wallet_id"`
+		Creator  string `json:"creator"`
+	}
+
+	validBody := rawPendingSpendCreateRequest{
+		rawCreateTxnRequest: rawCreateTxnRequest{
+			HoursSelection: rawHoursSelection{
+				Type: transaction.HoursSelectionTypeManual,
+			},
+			To: []rawReceiver{
+				{
+					Address: testutil.MakeAddress().String(),
+					Coins:   "1",
+					Hours:   "100",
+				},
+			},
+		},
+		WalletID: "foo.wlt",
+		Creator:  "alice",
+	}
+
+	noCreator := validBody
+	noCreator.Creator = ""
+
+	noWalletID := validBody
+	noWalletID.WalletID = ""
+
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		body          *rawPendingSpendCreateRequest
+		rawBody       string
+		gatewayResult *visor.PendingSpend
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			body:         &validBody,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      "{",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "unexpected EOF"),
+		},
+		{
+			name:         "400 - missing wallet_id",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			body:         &noWalletID,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:         "400 - missing creator",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			body:         &noCreator,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "creator is required"),
+		},
+		{
+			name:         "404 - wallet not found",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusNotFound,
+			body:         &validBody,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:         "400 - already pending",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			body:         &validBody,
+			gatewayErr:   visor.ErrPendingSpendExists,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "pending spend already exists"),
+		},
+		{
+			name:          "200",
+			method:        http.MethodPost,
+			contentType:   ContentTypeJSON,
+			status:        http.StatusOK,
+			body:          &validBody,
+			gatewayResult: &ps,
+			httpResponse: HTTPResponse{
+				Data: *psResp,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+
+				var req pendingSpendCreateRequest
+				require.NoError(t, json.Unmarshal([]byte(body), &req))
+				gateway.On("CreatePendingSpend", req.WalletID, req.Creator, req.TransactionParams(), req.VisorParams()).Return(tc.gatewayResult, tc.gatewayErr)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/wallet/spends/create", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp PendingSpendResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(PendingSpendResponse), rsp)
+			}
+		})
+	}
+}
+
+func TestPendingSpendApprove(t *testing.T) {
+	ps := makePendingSpend(t, "foo.wlt", "alice")
+	signedTxn := ps.Transaction
+	signedTxn.Sigs = []cipher.Sig{testutil.RandSig(t)}
+
+	txnResp, err := NewCreateTransactionResponse(&signedTxn, ps.Inputs)
+	require.NoError(t, err)
+
+	id := ps.ID()
+	checkerSig := testutil.RandSig(t)
+	creatorSig := testutil.RandSig(t)
+
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		body          *PendingSpendApproveRequest
+		rawBody       string
+		gatewayResult *coin.Transaction
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - missing id",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"checker": "bob"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:         "400 - invalid id",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"id": "foo", "checker": "bob"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid id"),
+		},
+		{
+			name:         "400 - missing checker",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendApproveRequest{ID: id.Hex()}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "checker is required"),
+		},
+		{
+			name:         "400 - missing signature",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendApproveRequest{ID: id.Hex(), Checker: "bob"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "signature is required"),
+		},
+		{
+			name:         "400 - invalid signature",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendApproveRequest{ID: id.Hex(), Checker: "bob", Signature: "foo"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid signature"),
+		},
+		{
+			name:         "400 - missing creator signature",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendApproveRequest{ID: id.Hex(), Checker: "bob", Signature: checkerSig.Hex()}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "creator_signature is required"),
+		},
+		{
+			name:        "403 - checker not registered",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "dave",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendUnknownIdentity,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "creator or checker is not registered"),
+		},
+		{
+			name:        "403 - signature not verified",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendInvalidSignature,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "invalid pending spend signature"),
+		},
+		{
+			name:        "404 - pending spend not found",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusNotFound,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "pending spend does not exist"),
+		},
+		{
+			name:        "403 - self approval",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "alice",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendSelfApproval,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "pending spend can't be approved by its creator"),
+		},
+		{
+			name:        "400 - expired",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendExpired,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "pending spend has expired"),
+		},
+		{
+			name:        "400 - invalid password",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+				Password:         "foo",
+			},
+			gatewayErr:   wallet.ErrInvalidPassword,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:        "403 - spending policy violated",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+				Password:         "pwd",
+			},
+			gatewayErr: wallet.PolicyViolationError{
+				Rule:    wallet.RuleMaxOutputs,
+				Message: "transaction has 2 outputs, the maximum is 1",
+			},
+			httpResponse: policyViolationResponse(wallet.PolicyViolationError{
+				Rule:    wallet.RuleMaxOutputs,
+				Message: "transaction has 2 outputs, the maximum is 1",
+			}),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PendingSpendApproveRequest{
+				ID:               id.Hex(),
+				Checker:          "bob",
+				Signature:        checkerSig.Hex(),
+				CreatorSignature: creatorSig.Hex(),
+				Password:         "pwd",
+			},
+			gatewayResult: &signedTxn,
+			httpResponse: HTTPResponse{
+				Data: *txnResp,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+				gateway.On("ApprovePendingSpend", id, tc.body.Checker, checkerSig, creatorSig, []byte(tc.body.Password)).Return(tc.gatewayResult, ps.Inputs, tc.gatewayErr)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/wallet/spends/approve", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			switch expected := tc.httpResponse.Data.(type) {
+			case CreateTransactionResponse:
+				var rsp CreateTransactionResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, expected, rsp)
+			case SpendingPolicyViolation:
+				var rsp SpendingPolicyViolation
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, expected, rsp)
+			}
+		})
+	}
+}
+
+func TestPendingSpendReject(t *testing.T) {
+	ps := makePendingSpend(t, "foo.wlt", "alice")
+	psResp, err := NewPendingSpendResponse(&ps)
+	require.NoError(t, err)
+
+	id := ps.ID()
+	sig := testutil.RandSig(t)
+
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		body          *PendingSpendRejectRequest
+		rawBody       string
+		gatewayResult *visor.PendingSpend
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - missing checker",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendRejectRequest{ID: id.Hex()}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "checker is required"),
+		},
+		{
+			name:         "400 - missing signature",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      toJSON(t, PendingSpendRejectRequest{ID: id.Hex(), Checker: "bob"}),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "signature is required"),
+		},
+		{
+			name:        "403 - signature not verified",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PendingSpendRejectRequest{
+				ID:        id.Hex(),
+				Checker:   "bob",
+				Signature: sig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendInvalidSignature,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "invalid pending spend signature"),
+		},
+		{
+			name:        "404 - pending spend not found",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusNotFound,
+			body: &PendingSpendRejectRequest{
+				ID:        id.Hex(),
+				Checker:   "bob",
+				Signature: sig.Hex(),
+			},
+			gatewayErr:   visor.ErrPendingSpendNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "pending spend does not exist"),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PendingSpendRejectRequest{
+				ID:        id.Hex(),
+				Checker:   "bob",
+				Signature: sig.Hex(),
+			},
+			gatewayResult: &ps,
+			httpResponse: HTTPResponse{
+				Data: *psResp,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+				gateway.On("RejectPendingSpend", id, tc.body.Checker, sig).Return(tc.gatewayResult, tc.gatewayErr)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/wallet/spends/reject", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp PendingSpendResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(PendingSpendResponse), rsp)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/MDLlife/MDL/src/pst"
+	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/visor/blockdb"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// PSTCreateRequest is the request data for POST /api/v2/pst/create
+type PSTCreateRequest struct {
+	EncodedTransaction string `json:"encoded_transaction"`
+	WalletID           string `json:"wallet_id,omitempty"`
+}
+
+// PSTSignRequest is the request data for POST /api/v2/pst/sign
+type PSTSignRequest struct {
+	WalletID    string   `json:"wallet_id"`
+	Password    string   `json:"password"`
+	PST         *pst.PST `json:"pst"`
+	SignIndexes []int    `json:"sign_indexes,omitempty"`
+}
+
+// PSTCombineRequest is the request data for POST /api/v2/pst/combine
+type PSTCombineRequest struct {
+	PSTs []*pst.PST `json:"psts"`
+}
+
+// PSTFinalizeRequest is the request data for POST /api/v2/pst/finalize
+type PSTFinalizeRequest struct {
+	PST *pst.PST `json:"pst"`
+}
+
+// URI: /api/v2/pst/create
+// Method: POST
+// Args: JSON body
+// Creates a partially-signed transaction from an unsigned or partially signed transaction,
+// with the unspent outputs spent by its inputs and, if wallet_id is set, the bip44 derivation paths
+// of the inputs owned by the wallet
+func pstCreateHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PSTCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.EncodedTransaction == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "encoded_transaction is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txn, err := decodeTxn(req.EncodedTransaction)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("Decode transaction failed: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		p, err := gateway.CreatePST(*txn, req.WalletID)
+		if err != nil {
+			writeHTTPResponse(w, pstErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: p,
+		})
+	}
+}
+
+// URI: /api/v2/pst/sign
+// Method: POST
+// Args: JSON body
+// Signs the inputs of a partially-signed transaction owned by a wallet, all its unsigned inputs
+// owned by the wallet or those of sign_indexes. The transaction is verified with the unspent outputs
+// of the partially-signed transaction, the node doesn't need to be synced.
+func pstSignHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PSTSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.PST == nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "pst is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if err := gateway.WalletSignPST(req.WalletID, []byte(req.Password), req.PST, req.SignIndexes); err != nil {
+			writeHTTPResponse(w, pstErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: req.PST,
+		})
+	}
+}
+
+// URI: /api/v2/pst/combine
+// Method: POST
+// Args: JSON body
+// Merges the signatures of partially-signed transactions of the same transaction, signed separately
+func pstCombineHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PSTCombineRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		for _, p := range req.PSTs {
+			if p == nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "psts must not contain null")
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		p, err := pst.Combine(req.PSTs...)
+		if err != nil {
+			writeHTTPResponse(w, pstErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: p,
+		})
+	}
+}
+
+// URI: /api/v2/pst/finalize
+// Method: POST
+// Args: JSON body
+// Returns the transaction of a fully signed partially-signed transaction, once verified, ready to be injected
+func pstFinalizeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req PSTFinalizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.PST == nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "pst is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txn, err := req.PST.Finalize()
+		if err != nil {
+			writeHTTPResponse(w, pstErrorResponse(err))
+			return
+		}
+
+		inputs := make([]visor.TransactionInput, len(req.PST.Inputs))
+		for i, in := range req.PST.Inputs {
+			inputs[i] = visor.TransactionInput{
+				UxOut:           in.UxOut,
+				CalculatedHours: in.CalculatedHours,
+			}
+		}
+
+		txnResp, err := NewCreateTransactionResponse(txn, inputs)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: txnResp,
+		})
+	}
+}
+
+func pstErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+	}
+
+	switch e := err.(type) {
+	case wallet.PolicyViolationError:
+		return policyViolationResponse(e)
+	case pst.Error,
+		wallet.Error,
+		visor.UserError,
+		blockdb.ErrUnspentNotExist,
+		visor.ErrTxnViolatesUserConstraint,
+		visor.ErrTxnViolatesHardConstraint,
+		visor.ErrTxnViolatesSoftConstraint:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	}
+
+	return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+}
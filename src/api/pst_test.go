@@ -0,0 +1,488 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/pst"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// makePST creates an unsigned partially-signed transaction spending one output of each secret key
+func makePST(t *testing.T, keys ...cipher.SecKey) *pst.PST {
+	var txn coin.Transaction
+	uxOuts := make([]coin.UxOut, len(keys))
+	var coins uint64
+	for i, k := range keys {
+		uxOuts[i] = coin.UxOut{
+			Head: coin.UxHead{
+				Time:  100,
+				BkSeq: 2,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        cipher.MustAddressFromSecKey(k),
+				Coins:          2e6,
+				Hours:          100,
+			},
+		}
+		coins += uxOuts[i].Body.Coins
+		require.NoError(t, txn.PushInput(uxOuts[i].Hash()))
+	}
+
+	require.NoError(t, txn.PushOutput(testutil.MakeAddress(), coins, 25*uint64(len(keys))))
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	require.NoError(t, txn.UpdateHeader())
+
+	p, err := pst.New(txn, uxOuts, 3700)
+	require.NoError(t, err)
+
+	return p
+}
+
+// signPST returns a copy of a partially-signed transaction, signed with the secret keys
+func signPST(t *testing.T, p *pst.PST, keys ...cipher.SecKey) *pst.PST {
+	w, err := wallet.NewWallet("t.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	for _, k := range keys {
+		_, err := w.ImportSecretKey(k)
+		require.NoError(t, err)
+	}
+
+	c := copyPST(t, p)
+	require.NoError(t, c.Sign(w, nil))
+
+	return c
+}
+
+func copyPST(t *testing.T, p *pst.PST) *pst.PST {
+	var c pst.PST
+	require.NoError(t, json.Unmarshal([]byte(toJSON(t, p)), &c))
+	return &c
+}
+
+func TestPSTCreate(t *testing.T) {
+	_, s := cipher.GenerateKeyPair()
+	p := makePST(t, s)
+	rawTxn, err := p.Transaction.SerializeHex()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		body          *PSTCreateRequest
+		rawBody       string
+		gatewayResult *pst.PST
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - invalid json",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      "{",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "unexpected EOF"),
+		},
+		{
+			name:         "400 - missing encoded_transaction",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "encoded_transaction is required"),
+		},
+		{
+			name:         "400 - invalid transaction",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"encoded_transaction": "abcd"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Decode transaction failed: Invalid transaction: Not enough buffer data to deserialize"),
+		},
+		{
+			name:        "400 - already signed",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PSTCreateRequest{
+				EncodedTransaction: rawTxn,
+			},
+			gatewayErr:   visor.ErrTransactionAlreadySigned,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, visor.ErrTransactionAlreadySigned.Error()),
+		},
+		{
+			name:        "404 - wallet not found",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusNotFound,
+			body: &PSTCreateRequest{
+				EncodedTransaction: rawTxn,
+				WalletID:           "foo.wlt",
+			},
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PSTCreateRequest{
+				EncodedTransaction: rawTxn,
+				WalletID:           "foo.wlt",
+			},
+			gatewayResult: p,
+			httpResponse: HTTPResponse{
+				Data: *p,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+				gateway.On("CreatePST", p.Transaction, tc.body.WalletID).Return(tc.gatewayResult, tc.gatewayErr)
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/pst/create", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp pst.PST
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(pst.PST), rsp)
+			}
+		})
+	}
+}
+
+func TestPSTSign(t *testing.T) {
+	_, s := cipher.GenerateKeyPair()
+	p := makePST(t, s)
+	signed := signPST(t, p, s)
+
+	cases := []struct {
+		name         string
+		method       string
+		contentType  string
+		status       int
+		body         *PSTSignRequest
+		rawBody      string
+		gatewayErr   error
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - missing wallet_id",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+		{
+			name:         "400 - missing pst",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"wallet_id": "foo.wlt"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "pst is required"),
+		},
+		{
+			name:         "400 - invalid pst",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"wallet_id": "foo.wlt", "pst": {"version": 2}}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Unsupported partially-signed transaction version 2"),
+		},
+		{
+			name:        "400 - no inputs to sign",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PSTSignRequest{
+				WalletID: "foo.wlt",
+				PST:      p,
+			},
+			gatewayErr:   pst.ErrNoInputsToSign,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Wallet owns none of the unsigned inputs"),
+		},
+		{
+			name:        "403 - wallet api disabled",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			body: &PSTSignRequest{
+				WalletID: "foo.wlt",
+				PST:      p,
+			},
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, "wallet api is disabled"),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PSTSignRequest{
+				WalletID: "foo.wlt",
+				Password: "pwd",
+				PST:      p,
+			},
+			httpResponse: HTTPResponse{
+				Data: *signed,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+				gateway.On("WalletSignPST", tc.body.WalletID, []byte(tc.body.Password), mock.Anything, tc.body.SignIndexes).Return(func(_ string, _ []byte, p *pst.PST, _ []int) error {
+					if tc.gatewayErr != nil {
+						return tc.gatewayErr
+					}
+
+					*p = *signed
+					return nil
+				})
+			}
+
+			rr := servePendingSpendRequest(t, gateway, tc.method, "/api/v2/pst/sign", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp pst.PST
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(pst.PST), rsp)
+			}
+		})
+	}
+}
+
+func TestPSTCombine(t *testing.T) {
+	_, s1 := cipher.GenerateKeyPair()
+	_, s2 := cipher.GenerateKeyPair()
+	p := makePST(t, s1, s2)
+	p1 := signPST(t, p, s1)
+	p2 := signPST(t, p, s2)
+
+	combined, err := pst.Combine(p1, p2)
+	require.NoError(t, err)
+	require.True(t, combined.IsFullySigned())
+
+	cases := []struct {
+		name         string
+		method       string
+		contentType  string
+		status       int
+		body         *PSTCombineRequest
+		rawBody      string
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - null pst",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"psts": [null]}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "psts must not contain null"),
+		},
+		{
+			name:         "400 - nothing to combine",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      `{"psts": []}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, pst.ErrNothingToCombine.Error()),
+		},
+		{
+			name:        "400 - transaction mismatch",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PSTCombineRequest{
+				PSTs: []*pst.PST{p1, makePST(t, s1)},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, pst.ErrTransactionMismatch.Error()),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PSTCombineRequest{
+				PSTs: []*pst.PST{p1, p2},
+			},
+			httpResponse: HTTPResponse{
+				Data: *combined,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+			}
+
+			rr := servePendingSpendRequest(t, &MockGatewayer{}, tc.method, "/api/v2/pst/combine", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp pst.PST
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(pst.PST), rsp)
+			}
+		})
+	}
+}
+
+func TestPSTFinalize(t *testing.T) {
+	_, s := cipher.GenerateKeyPair()
+	p := makePST(t, s)
+	signed := signPST(t, p, s)
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut:           signed.Inputs[0].UxOut,
+			CalculatedHours: signed.Inputs[0].CalculatedHours,
+		},
+	}
+	txnResp, err := NewCreateTransactionResponse(&signed.Transaction, inputs)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		method       string
+		contentType  string
+		status       int
+		body         *PSTFinalizeRequest
+		rawBody      string
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:         "400 - missing pst",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			rawBody:      "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "pst is required"),
+		},
+		{
+			name:        "400 - not fully signed",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			body: &PSTFinalizeRequest{
+				PST: p,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, pst.ErrNotFullySigned.Error()),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			body: &PSTFinalizeRequest{
+				PST: signed,
+			},
+			httpResponse: HTTPResponse{
+				Data: *txnResp,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := tc.rawBody
+			if tc.body != nil {
+				body = toJSON(t, tc.body)
+			}
+
+			rr := servePendingSpendRequest(t, &MockGatewayer{}, tc.method, "/api/v2/pst/finalize", tc.contentType, body)
+
+			data := requirePendingSpendResponse(t, rr, tc.status, tc.httpResponse)
+			if tc.httpResponse.Data != nil {
+				var rsp CreateTransactionResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, tc.httpResponse.Data.(CreateTransactionResponse), rsp)
+			}
+		})
+	}
+}
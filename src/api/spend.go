@@ -11,7 +11,9 @@ import (
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/daemon"
 	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/paymenturi"
 	"github.com/MDLlife/MDL/src/transaction"
 	"github.com/MDLlife/MDL/src/util/droplet"
 	"github.com/MDLlife/MDL/src/util/fee"
@@ -263,11 +265,14 @@ func NewCreatedTransactionInput(out visor.TransactionInput) (*CreatedTransaction
 // createTransactionRequest is sent to POST /api/v2/transaction
 type createTransactionRequest struct {
 	IgnoreUnconfirmed bool           `json:"ignore_unconfirmed"`
+	UnconfirmedChange bool           `json:"unconfirmed_change"`
 	HoursSelection    hoursSelection `json:"hours_selection"`
 	ChangeAddress     *wh.Address    `json:"change_address,omitempty"`
 	To                []receiver     `json:"to"`
 	UxOuts            []wh.SHA256    `json:"unspents,omitempty"`
 	Addresses         []wh.Address   `json:"addresses,omitempty"`
+	// Replaceable signals that the transaction can be replaced by a transaction burning more coin hours
+	Replaceable bool `json:"replaceable"`
 }
 
 // hoursSelection defines options for hours distribution
@@ -275,6 +280,8 @@ type hoursSelection struct {
 	Type        string           `json:"type"`
 	Mode        string           `json:"mode"`
 	ShareFactor *decimal.Decimal `json:"share_factor,omitempty"`
+	// Confidence is the confidence level of the fee estimate for estimated mode, defaults to 0.8
+	Confidence *decimal.Decimal `json:"confidence,omitempty"`
 }
 
 // receiver specifies a spend destination
@@ -282,6 +289,46 @@ type receiver struct {
 	Address wh.Address `json:"address"`
 	Coins   wh.Coins   `json:"coins"`
 	Hours   *wh.Hours  `json:"hours,omitempty"`
+	// URI is a payment request URI, which sets the address, and the coins and hours if present
+	URI string `json:"uri,omitempty"`
+}
+
+// parseURIs sets the address, coins and hours of the receivers which have a payment request URI.
+// The coins and hours of a receiver can only be set if the URI doesn't have them.
+func (r *createTransactionRequest) parseURIs() error {
+	for i := range r.To {
+		to := &r.To[i]
+		if to.URI == "" {
+			continue
+		}
+
+		u, err := paymenturi.Parse(to.URI)
+		if err != nil {
+			return fmt.Errorf("to[%d].uri is invalid: %v", i, err)
+		}
+
+		if !to.Address.Null() {
+			return fmt.Errorf("to[%d].address cannot be combined with to[%d].uri", i, i)
+		}
+		to.Address = wh.Address{Address: u.Address}
+
+		if u.Coins != 0 {
+			if to.Coins != 0 {
+				return fmt.Errorf("to[%d].coins cannot be combined with the amount of to[%d].uri", i, i)
+			}
+			to.Coins = wh.Coins(u.Coins)
+		}
+
+		if u.Hours != nil {
+			if to.Hours != nil {
+				return fmt.Errorf("to[%d].hours cannot be combined with the hours of to[%d].uri", i, i)
+			}
+			hours := wh.Hours(*u.Hours)
+			to.Hours = &hours
+		}
+	}
+
+	return nil
 }
 
 // Validate validates createTransactionRequest data
@@ -299,7 +346,7 @@ func (r createTransactionRequest) Validate() error {
 		}
 
 		switch r.HoursSelection.Mode {
-		case transaction.HoursSelectionModeShare:
+		case transaction.HoursSelectionModeShare, transaction.HoursSelectionModeEstimated:
 		case "":
 			return errors.New("missing hours_selection.mode")
 		default:
@@ -323,13 +370,15 @@ func (r createTransactionRequest) Validate() error {
 		return errors.New("invalid hours_selection.type")
 	}
 
+	sharesHours := r.HoursSelection.Mode == transaction.HoursSelectionModeShare || r.HoursSelection.Mode == transaction.HoursSelectionModeEstimated
+
 	if r.HoursSelection.ShareFactor == nil {
-		if r.HoursSelection.Mode == transaction.HoursSelectionModeShare {
-			return errors.New("missing hours_selection.share_factor when hours_selection.mode is share")
+		if sharesHours {
+			return fmt.Errorf("missing hours_selection.share_factor when hours_selection.mode is %s", r.HoursSelection.Mode)
 		}
 	} else {
-		if r.HoursSelection.Mode != transaction.HoursSelectionModeShare {
-			return errors.New("hours_selection.share_factor can only be used when hours_selection.mode is share")
+		if !sharesHours {
+			return errors.New("hours_selection.share_factor can only be used when hours_selection.mode is share or estimated")
 		}
 
 		switch {
@@ -340,6 +389,16 @@ func (r createTransactionRequest) Validate() error {
 		}
 	}
 
+	if r.HoursSelection.Confidence != nil {
+		if r.HoursSelection.Mode != transaction.HoursSelectionModeEstimated {
+			return errors.New("hours_selection.confidence can only be used when hours_selection.mode is estimated")
+		}
+
+		if !r.HoursSelection.Confidence.GreaterThan(decimal.New(0, 0)) || r.HoursSelection.Confidence.GreaterThan(decimal.New(1, 0)) {
+			return errors.New("hours_selection.confidence must be > 0 and <= 1")
+		}
+	}
+
 	if len(r.UxOuts) != 0 && len(r.Addresses) != 0 {
 		return errors.New("unspents and addresses cannot be combined")
 	}
@@ -439,15 +498,18 @@ func (r createTransactionRequest) TransactionParams() transaction.Params {
 			Type:        r.HoursSelection.Type,
 			Mode:        r.HoursSelection.Mode,
 			ShareFactor: r.HoursSelection.ShareFactor,
+			Confidence:  r.HoursSelection.Confidence,
 		},
 		ChangeAddress: changeAddress,
 		To:            to,
+		Replaceable:   r.Replaceable,
 	}
 }
 
 func (r createTransactionRequest) VisorParams() visor.CreateTransactionParams {
 	return visor.CreateTransactionParams{
 		IgnoreUnconfirmed: r.IgnoreUnconfirmed,
+		UnconfirmedChange: r.UnconfirmedChange,
 		Addresses:         r.addresses(),
 		UxOuts:            r.uxOuts(),
 	}
@@ -500,6 +562,12 @@ func transactionHandlerV2(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if err := req.parseURIs(); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
 		if err := req.Validate(); err != nil {
 			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
 			writeHTTPResponse(w, resp)
@@ -591,6 +659,12 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		if err := req.parseURIs(); err != nil {
+			logger.WithError(err).Error("Invalid create transaction request")
+			wh.Error400(w, err.Error())
+			return
+		}
+
 		if err := req.Validate(); err != nil {
 			logger.WithError(err).Error("Invalid create transaction request")
 			wh.Error400(w, err.Error())
@@ -606,6 +680,8 @@ func walletCreateTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		}
 		if err != nil {
 			switch err.(type) {
+			case wallet.PolicyViolationError:
+				wh.Error403(w, err.Error())
 			case wallet.Error:
 				switch err {
 				case wallet.ErrWalletAPIDisabled:
@@ -724,7 +800,9 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		signedTxn, inputs, err := gateway.WalletSignTransaction(req.WalletID, []byte(req.Password), txn, req.SignIndexes)
 		if err != nil {
 			var resp HTTPResponse
-			switch err.(type) {
+			switch e := err.(type) {
+			case wallet.PolicyViolationError:
+				resp = policyViolationResponse(e)
 			case wallet.Error:
 				switch err {
 				case wallet.ErrWalletNotExist:
@@ -758,3 +836,107 @@ func walletSignTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 		})
 	}
 }
+
+// WalletBumpFeeRequest is the request body object for /api/v2/wallet/transaction/bump-fee
+type WalletBumpFeeRequest struct {
+	WalletID string    `json:"wallet_id"`
+	Password string    `json:"password"`
+	TxID     string    `json:"txid"`
+	Fee      *wh.Hours `json:"fee,omitempty"`
+}
+
+// walletBumpFeeHandler replaces an unconfirmed transaction of a wallet with a transaction burning more coin hours,
+// then injects and broadcasts it. Replace-by-fee must be enabled and the transaction must signal it.
+// Method: POST
+// URI: /api/v2/wallet/transaction/bump-fee
+// Args: JSON body
+func walletBumpFeeHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletBumpFeeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.WalletID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.TxID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "txid is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txid, err := cipher.SHA256FromHex(req.TxID)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid txid")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		// The minimum replacement fee is burned if the fee is not specified
+		var fee uint64
+		if req.Fee != nil {
+			fee = req.Fee.Value()
+		}
+
+		txn, inputs, err := gateway.BumpFee(req.WalletID, []byte(req.Password), txid, fee)
+		if err != nil {
+			var resp HTTPResponse
+			switch e := err.(type) {
+			case wallet.PolicyViolationError:
+				resp = policyViolationResponse(e)
+			case wallet.Error:
+				switch err {
+				case wallet.ErrWalletNotExist:
+					resp = NewHTTPErrorResponse(http.StatusNotFound, err.Error())
+				case wallet.ErrWalletAPIDisabled:
+					resp = NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				}
+			case visor.UserError,
+				visor.ErrTxnViolatesSoftConstraint,
+				visor.ErrTxnViolatesHardConstraint,
+				visor.ErrTxnViolatesUserConstraint,
+				blockdb.ErrUnspentNotExist:
+				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			default:
+				if daemon.IsBroadcastFailure(err) {
+					resp = NewHTTPErrorResponse(http.StatusServiceUnavailable, err.Error())
+				} else {
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		txnResp, err := NewCreateTransactionResponse(txn, inputs)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: txnResp,
+		})
+	}
+}
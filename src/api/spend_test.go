@@ -27,6 +27,7 @@ type rawHoursSelection struct {
 	Type        string  `json:"type"`
 	Mode        string  `json:"mode"`
 	ShareFactor *string `json:"share_factor,omitempty"`
+	Confidence  *string `json:"confidence,omitempty"`
 }
 
 type rawReceiver struct {
@@ -205,7 +206,7 @@ func TestCreateTransaction(t *testing.T) {
 				},
 			},
 			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor can only be used when hours_selection.mode is share"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor can only be used when hours_selection.mode is share or estimated"),
 		},
 
 		{
@@ -236,6 +237,49 @@ func TestCreateTransaction(t *testing.T) {
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.share_factor cannot be more than 1"),
 		},
 
+		{
+			name:   "400 - missing hours selection share factor for estimated mode",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type: transaction.HoursSelectionTypeAuto,
+					Mode: transaction.HoursSelectionModeEstimated,
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "missing hours_selection.share_factor when hours_selection.mode is estimated"),
+		},
+
+		{
+			name:   "400 - confidence set but mode is not estimated",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type:        transaction.HoursSelectionTypeAuto,
+					Mode:        transaction.HoursSelectionModeShare,
+					ShareFactor: newStrPtr("0.5"),
+					Confidence:  newStrPtr("0.5"),
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.confidence can only be used when hours_selection.mode is estimated"),
+		},
+
+		{
+			name:   "400 - confidence greater than 1",
+			method: http.MethodPost,
+			body: &rawCreateTxnRequest{
+				HoursSelection: rawHoursSelection{
+					Type:        transaction.HoursSelectionTypeAuto,
+					Mode:        transaction.HoursSelectionModeEstimated,
+					ShareFactor: newStrPtr("0.5"),
+					Confidence:  newStrPtr("1.1"),
+				},
+			},
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "hours_selection.confidence must be > 0 and <= 1"),
+		},
+
 		{
 			name:   "400 - empty sender address",
 			method: http.MethodPost,
@@ -723,6 +767,70 @@ func TestCreateTransaction(t *testing.T) {
 			},
 		},
 
+		{
+			name:         "400 - invalid to uri",
+			method:       http.MethodPost,
+			rawBody:      `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:foo?amount=1&hours=10"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].uri is invalid: Invalid URI address: Invalid address length"),
+		},
+
+		{
+			name:         "400 - to uri combined with address",
+			method:       http.MethodPost,
+			rawBody:      `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `?amount=1&hours=10", "address": "` + destinationAddress.String() + `"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].address cannot be combined with to[0].uri"),
+		},
+
+		{
+			name:         "400 - to uri amount combined with coins",
+			method:       http.MethodPost,
+			rawBody:      `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `?amount=1&hours=10", "coins": "2"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].coins cannot be combined with the amount of to[0].uri"),
+		},
+
+		{
+			name:         "400 - to uri hours combined with hours",
+			method:       http.MethodPost,
+			rawBody:      `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `?amount=1&hours=10", "hours": "2"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].hours cannot be combined with the hours of to[0].uri"),
+		},
+
+		{
+			name:         "400 - to uri without amount",
+			method:       http.MethodPost,
+			rawBody:      `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `?hours=10"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "to[0].coins must not be zero"),
+		},
+
+		{
+			name:                           "200 - to uri",
+			method:                         http.MethodPost,
+			rawBody:                        `{"hours_selection": {"type": "manual"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `?amount=1&hours=10&label=foo"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:                         http.StatusOK,
+			gatewayCreateTransactionResult: txn,
+			gatewayCreateTransactionInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: createTxnResponse,
+			},
+		},
+
+		{
+			name:                           "200 - to uri without amount, coins",
+			method:                         http.MethodPost,
+			rawBody:                        `{"hours_selection": {"type": "auto", "mode": "share", "share_factor": "0.5"}, "to": [{"uri": "mdl:` + destinationAddress.String() + `", "coins": "1"}], "unspents": ["` + walletInput.Hex() + `"]}`,
+			status:                         http.StatusOK,
+			gatewayCreateTransactionResult: txn,
+			gatewayCreateTransactionInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: createTxnResponse,
+			},
+		},
+
 		{
 			name:                           "200 - manual type nonzero hours - csrf disabled",
 			method:                         http.MethodPost,
@@ -803,24 +911,23 @@ func TestCreateTransaction(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
 
-			// If the rawRequestBody can be deserialized to CreateTransactionRequest, use it to mock gateway.WalletCreateTransaction
-			serializedBody, err := json.Marshal(tc.body)
-			require.NoError(t, err)
+			bodyText := []byte(tc.rawBody)
+			if len(bodyText) == 0 {
+				var err error
+				bodyText, err = json.Marshal(tc.body)
+				require.NoError(t, err)
+			}
+
+			// If the request body can be deserialized to CreateTransactionRequest, use it to mock gateway.WalletCreateTransaction
 			var body walletCreateTransactionRequest
-			err = json.Unmarshal(serializedBody, &body)
-			if err == nil {
+			err := json.Unmarshal(bodyText, &body)
+			if err == nil && body.parseURIs() == nil {
 				x := gateway.On("CreateTransaction", body.TransactionParams(), body.VisorParams())
 				x.Return(tc.gatewayCreateTransactionResult, tc.gatewayCreateTransactionInputs, tc.gatewayCreateTransactionErr)
 			}
 
 			endpoint := "/api/v2/transaction"
 
-			bodyText := []byte(tc.rawBody)
-			if len(bodyText) == 0 {
-				bodyText, err = json.Marshal(tc.body)
-				require.NoError(t, err)
-			}
-
 			req, err := http.NewRequest(tc.method, endpoint, bytes.NewBuffer(bodyText))
 			require.NoError(t, err)
 
@@ -1058,7 +1165,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 				WalletID: "foo.wlt",
 			},
 			status: http.StatusBadRequest,
-			err:    "400 Bad Request - hours_selection.share_factor can only be used when hours_selection.mode is share",
+			err:    "400 Bad Request - hours_selection.share_factor can only be used when hours_selection.mode is share or estimated",
 		},
 
 		{
@@ -1724,6 +1831,18 @@ func TestWalletCreateTransaction(t *testing.T) {
 			gatewayCreateTransactionErr: wallet.ErrWalletAPIDisabled,
 			err:                         "403 Forbidden",
 		},
+
+		{
+			name:   "403 - spending policy violated",
+			method: http.MethodPost,
+			body:   validBody,
+			status: http.StatusForbidden,
+			gatewayCreateTransactionErr: wallet.PolicyViolationError{
+				Rule:    wallet.RuleAllowlist,
+				Message: "address foo is not in the allowlist",
+			},
+			err: "403 Forbidden - spending policy rule allowlist violated: address foo is not in the allowlist",
+		},
 	}
 
 	cases := make([]testCase, len(baseCases)*2)
@@ -2059,6 +2178,26 @@ func TestWalletSignTransaction(t *testing.T) {
 			httpResponse:              NewHTTPErrorResponse(http.StatusForbidden, "wallet api is disabled"),
 		},
 
+		{
+			name:   "403 - spending policy violated",
+			method: http.MethodPost,
+			body:   validBody,
+			status: http.StatusForbidden,
+			gatewaySignTransactionErr: wallet.PolicyViolationError{
+				Rule:    wallet.RuleMaxOutputs,
+				Message: "transaction has 2 outputs, the maximum is 1",
+			},
+			httpResponse: HTTPResponse{
+				Error: &HTTPError{
+					Code:    http.StatusForbidden,
+					Message: "spending policy rule max_outputs violated: transaction has 2 outputs, the maximum is 1",
+				},
+				Data: SpendingPolicyViolation{
+					Rule: "max_outputs",
+				},
+			},
+		},
+
 		{
 			name:                         "200 - no password",
 			method:                       http.MethodPost,
@@ -2177,6 +2316,236 @@ func TestWalletSignTransaction(t *testing.T) {
 
 			require.Equal(t, tc.httpResponse.Error, rsp.Error)
 
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				switch d := tc.httpResponse.Data.(type) {
+				case SpendingPolicyViolation:
+					var v SpendingPolicyViolation
+					err := json.Unmarshal(rsp.Data, &v)
+					require.NoError(t, err)
+					require.Equal(t, d, v)
+				default:
+					var cRsp CreateTransactionResponse
+					err := json.Unmarshal(rsp.Data, &cRsp)
+					require.NoError(t, err)
+
+					require.Equal(t, tc.httpResponse.Data.(CreateTransactionResponse), cRsp)
+				}
+			}
+		})
+	}
+}
+
+func TestWalletBumpFee(t *testing.T) {
+	txn := coin.Transaction{
+		Length:    100,
+		Type:      0,
+		InnerHash: testutil.RandSHA256(t),
+		Sigs:      []cipher.Sig{testutil.RandSig(t)},
+		In:        []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   50,
+			},
+		},
+	}
+
+	inputs := []visor.TransactionInput{
+		{
+			UxOut: coin.UxOut{
+				Head: coin.UxHead{
+					Time:  uint64(time.Now().UTC().Unix()),
+					BkSeq: 9999,
+				},
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Address:        testutil.MakeAddress(),
+					Coins:          1e6,
+					Hours:          100,
+				},
+			},
+			CalculatedHours: 200,
+		},
+	}
+
+	txnResp, err := NewCreateTransactionResponse(&txn, inputs)
+	require.NoError(t, err)
+
+	txid := testutil.RandSHA256(t)
+	fee := uint64(120)
+
+	tt := []struct {
+		name          string
+		method        string
+		contentType   string
+		body          string
+		status        int
+		txid          cipher.SHA256
+		fee           uint64
+		gatewayResult *coin.Transaction
+		gatewayInputs []visor.TransactionInput
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+
+		{
+			name:         "400 invalid json",
+			method:       http.MethodPost,
+			body:         "{ca",
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid character 'c' looking for beginning of object key string"),
+		},
+
+		{
+			name:         "400 wallet ID required",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"txid": "%s"}`, txid.Hex()),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "wallet_id is required"),
+		},
+
+		{
+			name:         "400 txid required",
+			method:       http.MethodPost,
+			body:         `{"wallet_id": "foo.wlt"}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "txid is required"),
+		},
+
+		{
+			name:         "400 invalid txid",
+			method:       http.MethodPost,
+			body:         `{"wallet_id": "foo.wlt", "txid": "abc"}`,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid txid"),
+		},
+
+		{
+			name:         "400 invalid fee",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s", "fee": "1.5"}`, txid.Hex()),
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid hours value: strconv.ParseUint: parsing "1.5": invalid syntax`),
+		},
+
+		{
+			name:         "400 replace-by-fee disabled",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s"}`, txid.Hex()),
+			status:       http.StatusBadRequest,
+			txid:         txid,
+			gatewayErr:   visor.ErrReplaceByFeeDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Replace-by-fee is disabled"),
+		},
+
+		{
+			name:         "400 replacement rejected",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s"}`, txid.Hex()),
+			status:       http.StatusBadRequest,
+			txid:         txid,
+			gatewayErr:   visor.NewErrTxnViolatesSoftConstraint(errors.New("too few coin hours")),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "Transaction violates soft constraint: too few coin hours"),
+		},
+
+		{
+			name:         "404 wallet not found",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s"}`, txid.Hex()),
+			status:       http.StatusNotFound,
+			txid:         txid,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "wallet doesn't exist"),
+		},
+
+		{
+			name:         "500 misc error",
+			method:       http.MethodPost,
+			body:         fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s"}`, txid.Hex()),
+			status:       http.StatusInternalServerError,
+			txid:         txid,
+			gatewayErr:   errors.New("unhandled error"),
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "unhandled error"),
+		},
+
+		{
+			name:          "200 minimum fee",
+			method:        http.MethodPost,
+			body:          fmt.Sprintf(`{"wallet_id": "foo.wlt", "txid": "%s"}`, txid.Hex()),
+			status:        http.StatusOK,
+			txid:          txid,
+			gatewayResult: &txn,
+			gatewayInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: *txnResp,
+			},
+		},
+
+		{
+			name:          "200 fee",
+			method:        http.MethodPost,
+			body:          fmt.Sprintf(`{"wallet_id": "foo.wlt", "password": "pwd", "txid": "%s", "fee": "%d"}`, txid.Hex(), fee),
+			status:        http.StatusOK,
+			txid:          txid,
+			fee:           fee,
+			gatewayResult: &txn,
+			gatewayInputs: inputs,
+			httpResponse: HTTPResponse{
+				Data: *txnResp,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			var req WalletBumpFeeRequest
+			if err := json.Unmarshal([]byte(tc.body), &req); err == nil {
+				gateway.On("BumpFee", req.WalletID, []byte(req.Password), tc.txid, tc.fee).Return(tc.gatewayResult, tc.gatewayInputs, tc.gatewayErr)
+			}
+
+			r, err := http.NewRequest(tc.method, "/api/v2/wallet/transaction/bump-fee", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+			r.Header.Add("Content-Type", contentType)
+			setCSRFParameters(t, tokenValid, r)
+
+			rr := httptest.NewRecorder()
+			handler := newServerMux(defaultMuxConfig(), gateway)
+			handler.ServeHTTP(rr, r)
+
+			require.Equal(t, tc.status, rr.Code, "got `%v` want `%v`", rr.Code, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
 			if rsp.Data == nil {
 				require.Nil(t, tc.httpResponse.Data)
 			} else {
@@ -2185,7 +2554,6 @@ func TestWalletSignTransaction(t *testing.T) {
 				var cRsp CreateTransactionResponse
 				err := json.Unmarshal(rsp.Data, &cRsp)
 				require.NoError(t, err)
-
 				require.Equal(t, tc.httpResponse.Data.(CreateTransactionResponse), cRsp)
 			}
 		})
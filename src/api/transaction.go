@@ -17,6 +17,7 @@ import (
 	wh "github.com/MDLlife/MDL/src/util/http"
 	"github.com/MDLlife/MDL/src/util/mathutil"
 	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
 )
 
 // pendingTxnsHandler returns pending (unconfirmed) transactions
@@ -24,6 +25,7 @@ import (
 // URI: /api/v1/pendingTxs
 // Args:
 //	verbose: [bool] include verbose transaction input data
+//	evicted: [bool] return the transactions recently evicted from the pool, with the reason of their eviction
 func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -37,7 +39,26 @@ func pendingTxnsHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		if verbose {
+		evicted, err := parseBoolFlag(r.FormValue("evicted"))
+		if err != nil {
+			wh.Error400(w, "Invalid value for evicted")
+			return
+		}
+
+		if verbose && evicted {
+			wh.Error400(w, "verbose and evicted cannot be combined")
+			return
+		}
+
+		if evicted {
+			txns, err := gateway.GetEvictedUnconfirmedTransactions()
+			if err != nil {
+				wh.Error500(w, err.Error())
+				return
+			}
+
+			wh.SendJSONOr500(logger, w, readable.NewEvictedTransactions(txns))
+		} else if verbose {
 			txns, inputs, err := gateway.GetAllUnconfirmedTransactionsVerbose()
 			if err != nil {
 				wh.Error500(w, err.Error())
@@ -334,6 +355,7 @@ func transactionsHandler(gateway Gatewayer) http.HandlerFunc {
 // Response:
 //      200 - ok, returns the transaction hash in hex as string
 //      400 - bad transaction
+//      403 - the transaction exceeds the daily limit of the spending policy of a wallet
 //		500 - other error
 //      503 - network unavailable for broadcasting transaction
 func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
@@ -369,6 +391,8 @@ func injectTransactionHandler(gateway Gatewayer) http.HandlerFunc {
 				visor.ErrTxnViolatesHardConstraint,
 				visor.ErrTxnViolatesSoftConstraint:
 				wh.Error400(w, err.Error())
+			case wallet.PolicyViolationError:
+				wh.Error403(w, err.Error())
 			default:
 				if daemon.IsBroadcastFailure(err) {
 					wh.Error503(w, err.Error())
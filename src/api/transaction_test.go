@@ -24,6 +24,7 @@ import (
 	"github.com/MDLlife/MDL/src/readable"
 	"github.com/MDLlife/MDL/src/testutil"
 	"github.com/MDLlife/MDL/src/visor"
+	"github.com/MDLlife/MDL/src/wallet"
 )
 
 func createUnconfirmedTxn(t *testing.T) visor.UnconfirmedTransaction {
@@ -97,10 +98,14 @@ func TestGetPendingTxs(t *testing.T) {
 		err                                  string
 		verbose                              bool
 		verboseStr                           string
+		evicted                              bool
+		evictedStr                           string
 		getAllUnconfirmedTxnsResponse        []visor.UnconfirmedTransaction
 		getAllUnconfirmedTxnsErr             error
 		getAllUnconfirmedTxnsVerboseResponse verboseResult
 		getAllUnconfirmedTxnsVerboseErr      error
+		getEvictedTxnsResponse               []visor.EvictedTransaction
+		getEvictedTxnsErr                    error
 		httpResponse                         interface{}
 	}{
 		{
@@ -117,6 +122,21 @@ func TestGetPendingTxs(t *testing.T) {
 			err:        "400 Bad Request - Invalid value for verbose",
 			verboseStr: "foo",
 		},
+		{
+			name:       "400 - bad evicted",
+			method:     http.MethodGet,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - Invalid value for evicted",
+			evictedStr: "foo",
+		},
+		{
+			name:       "400 - verbose and evicted",
+			method:     http.MethodGet,
+			status:     http.StatusBadRequest,
+			err:        "400 Bad Request - verbose and evicted cannot be combined",
+			verboseStr: "1",
+			evictedStr: "1",
+		},
 		{
 			name:   "500 - bad unconfirmedTxn",
 			method: http.MethodGet,
@@ -161,6 +181,32 @@ func TestGetPendingTxs(t *testing.T) {
 			},
 			httpResponse: []readable.UnconfirmedTransactionVerbose{},
 		},
+		{
+			name:              "500 - get evicted error",
+			method:            http.MethodGet,
+			status:            http.StatusInternalServerError,
+			evictedStr:        "1",
+			evicted:           true,
+			err:               "500 Internal Server Error - GetEvictedUnconfirmedTransactions failed",
+			getEvictedTxnsErr: errors.New("GetEvictedUnconfirmedTransactions failed"),
+		},
+		{
+			name:       "200 evicted",
+			method:     http.MethodGet,
+			status:     http.StatusOK,
+			evictedStr: "1",
+			evicted:    true,
+			getEvictedTxnsResponse: []visor.EvictedTransaction{
+				{
+					Hash:    testutil.RandSHA256(t),
+					Reason:  visor.EvictionReasonPoolFull,
+					Evicted: time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC).UnixNano(),
+					Added:   time.Date(2019, 6, 1, 11, 0, 0, 0, time.UTC).UnixNano(),
+					Size:    220,
+					Fee:     10,
+				},
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -170,11 +216,15 @@ func TestGetPendingTxs(t *testing.T) {
 			gateway.On("GetAllUnconfirmedTransactions").Return(tc.getAllUnconfirmedTxnsResponse, tc.getAllUnconfirmedTxnsErr)
 			gateway.On("GetAllUnconfirmedTransactionsVerbose").Return(tc.getAllUnconfirmedTxnsVerboseResponse.Transactions,
 				tc.getAllUnconfirmedTxnsVerboseResponse.Inputs, tc.getAllUnconfirmedTxnsVerboseErr)
+			gateway.On("GetEvictedUnconfirmedTransactions").Return(tc.getEvictedTxnsResponse, tc.getEvictedTxnsErr)
 
 			v := url.Values{}
 			if tc.verboseStr != "" {
 				v.Add("verbose", tc.verboseStr)
 			}
+			if tc.evictedStr != "" {
+				v.Add("evicted", tc.evictedStr)
+			}
 			if len(v) > 0 {
 				endpoint += "?" + v.Encode()
 			}
@@ -199,7 +249,22 @@ func TestGetPendingTxs(t *testing.T) {
 				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
-				if tc.verbose {
+				if tc.evicted {
+					var msg []readable.EvictedTransaction
+					err = json.Unmarshal(rr.Body.Bytes(), &msg)
+					require.NoError(t, err)
+					e := tc.getEvictedTxnsResponse[0]
+					require.Equal(t, []readable.EvictedTransaction{
+						{
+							Hash:    e.Hash.Hex(),
+							Reason:  "pool_full",
+							Evicted: time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC),
+							Added:   time.Date(2019, 6, 1, 11, 0, 0, 0, time.UTC),
+							Size:    220,
+							Fee:     10,
+						},
+					}, msg, tc.name)
+				} else if tc.verbose {
 					var msg []readable.UnconfirmedTransactionVerbose
 					err = json.Unmarshal(rr.Body.Bytes(), &msg)
 					require.NoError(t, err)
@@ -716,6 +781,18 @@ func TestInjectTransaction(t *testing.T) {
 			injectTransactionArg:   validTransaction,
 			injectTransactionError: gnet.ErrPoolEmpty,
 		},
+		{
+			name:                 "403 - daily limit exceeded",
+			method:               http.MethodPost,
+			status:               http.StatusForbidden,
+			err:                  "403 Forbidden - spending policy rule max_coins_per_day violated: transaction spends 2 coins and 9 coins were spent in the last 24 hours, the maximum is 10",
+			httpBody:             string(validTxnBodyJSON),
+			injectTransactionArg: validTransaction,
+			injectTransactionError: wallet.PolicyViolationError{
+				Rule:    wallet.RuleMaxCoinsPerDay,
+				Message: "transaction spends 2 coins and 9 coins were spent in the last 24 hours, the maximum is 10",
+			},
+		},
 		{
 			name:                   "500 - other injectTransactionError",
 			method:                 http.MethodPost,
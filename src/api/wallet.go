@@ -8,10 +8,17 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/paymenturi"
 	"github.com/MDLlife/MDL/src/readable"
+	"github.com/MDLlife/MDL/src/util/droplet"
 	wh "github.com/MDLlife/MDL/src/util/http"
+	"github.com/MDLlife/MDL/src/util/mathutil"
+	"github.com/MDLlife/MDL/src/util/qrcode"
 	"github.com/MDLlife/MDL/src/wallet"
 )
 
@@ -28,7 +35,11 @@ type UnconfirmedTxnsVerboseResponse struct {
 // BalanceResponse address balance summary struct
 type BalanceResponse struct {
 	readable.BalancePair
-	Addresses readable.AddressBalances `json:"addresses"`
+	// Frozen is the confirmed balance of the frozen outputs of a wallet, only set by /wallet/balance
+	Frozen *readable.Balance `json:"frozen,omitempty"`
+	// FrozenOutputs are the hashes of the frozen outputs of a wallet which are unspent, only set by /wallet/balance
+	FrozenOutputs []string                 `json:"frozen_outputs,omitempty"`
+	Addresses     readable.AddressBalances `json:"addresses"`
 }
 
 // WalletResponse wallet response struct for http apis
@@ -47,6 +58,7 @@ func NewWalletResponse(w *wallet.Wallet) (*WalletResponse, error) {
 	wr.Meta.Type = w.Meta["type"]
 	wr.Meta.Version = w.Meta["version"]
 	wr.Meta.CryptoType = w.Meta["cryptoType"]
+	wr.Meta.Argon2Time, wr.Meta.Argon2Memory = w.Argon2Costs()
 
 	// Converts "encrypted" string to boolean if any
 	if encryptedStr, ok := w.Meta["encrypted"]; ok {
@@ -66,16 +78,52 @@ func NewWalletResponse(w *wallet.Wallet) (*WalletResponse, error) {
 		wr.Meta.Timestamp = tm
 	}
 
+	if w.Type() == wallet.WalletTypeBip44 {
+		bip44Coin := uint32(w.Bip44Coin())
+		bip44Account := w.Bip44Account()
+		wr.Meta.Bip44Coin = &bip44Coin
+		wr.Meta.Bip44Account = &bip44Account
+	}
+
+	hasChangeChain := w.HasChangeChain()
+	if hasChangeChain {
+		wr.Meta.XPub = w.XPub()
+	}
+
+	if w.IsRemote() {
+		wr.Meta.Signer = w.SignerURL()
+	}
+
 	for _, e := range w.Entries {
-		wr.Entries = append(wr.Entries, readable.WalletEntry{
-			Address: e.Address.String(),
-			Public:  e.Public.Hex(),
-		})
+		wr.Entries = append(wr.Entries, newReadableWalletEntry(e, hasChangeChain))
 	}
 
 	return &wr, nil
 }
 
+// newReadableWalletEntry creates a readable.WalletEntry from a wallet.Entry, without its secret key
+func newReadableWalletEntry(e wallet.Entry, hasChangeChain bool) readable.WalletEntry {
+	re := readable.WalletEntry{
+		Address: e.Address.String(),
+		Label:   e.Label,
+		Meta:    e.Meta,
+	}
+
+	// The entries of an addresses wallet have no public key
+	if !e.Public.Null() {
+		re.Public = e.Public.Hex()
+	}
+
+	if hasChangeChain {
+		childNumber := e.ChildNumber
+		change := e.Change
+		re.ChildNumber = &childNumber
+		re.Change = &change
+	}
+
+	return re
+}
+
 // Returns the wallet's balance, both confirmed and predicted.  The predicted
 // balance is the confirmed balance minus the pending spends.
 // URI: /api/v1/wallet/balance
@@ -108,10 +156,45 @@ func walletBalanceHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		wh.SendJSONOr500(logger, w, BalanceResponse{
+		frozenOutputs, err := gateway.GetWalletFrozenOutputs(wltID)
+		if err != nil {
+			logger.Errorf("Get wallet frozen outputs failed: %v", err)
+			switch err {
+			case wallet.ErrWalletNotExist:
+				wh.Error404(w, "")
+			case wallet.ErrWalletAPIDisabled:
+				wh.Error403(w, "")
+			default:
+				wh.Error500(w, err.Error())
+			}
+			return
+		}
+
+		rsp := BalanceResponse{
 			BalancePair: readable.NewBalancePair(walletBalance),
 			Addresses:   readable.NewAddressBalances(addressBalances),
-		})
+		}
+
+		if len(frozenOutputs) != 0 {
+			var frozen readable.Balance
+			rsp.FrozenOutputs = make([]string, len(frozenOutputs))
+			for i, o := range frozenOutputs {
+				frozen.Coins, err = mathutil.AddUint64(frozen.Coins, o.Body.Coins)
+				if err != nil {
+					wh.Error500(w, err.Error())
+					return
+				}
+				frozen.Hours, err = mathutil.AddUint64(frozen.Hours, o.CalculatedHours)
+				if err != nil {
+					wh.Error500(w, err.Error())
+					return
+				}
+				rsp.FrozenOutputs[i] = o.Hash().Hex()
+			}
+			rsp.Frozen = &frozen
+		}
+
+		wh.SendJSONOr500(logger, w, rsp)
 	}
 }
 
@@ -178,14 +261,24 @@ func balanceHandler(gateway Gatewayer) http.HandlerFunc {
 
 // Loads wallet from seed, will scan ahead N address and
 // load addresses till the last one that have coins.
+// Watch-only wallets are loaded from an xpub or a list of addresses instead of a seed.
+// Remote wallets are loaded from the public keys of a remote signer.
 // URI: /api/v1/wallet/create
 // Method: POST
 // Args:
-//     seed: wallet seed [required]
+//     seed: wallet seed [required, except for "xpub", "addresses", "collection" and "remote" wallets]
 //     label: wallet label [required]
-//     scan: the number of addresses to scan ahead for balances [optional, must be > 0]
-//     encrypt: bool value, whether encrypt the wallet [optional]
+//     scan: the number of addresses to scan ahead for balances [optional, must be > 0, not allowed for "addresses", "collection" and "remote" wallets]
+//     encrypt: bool value, whether encrypt the wallet [optional, not allowed for "xpub", "addresses" and "remote" wallets]
 //     password: password for encrypting wallet [optional, must be provided if "encrypt" is set]
+//     crypto-type: crypto type for encrypting the wallet [optional, only with "encrypt", defaults to the node's wallet crypto type]
+//     type: wallet type, "deterministic", "bip44", "xpub", "addresses", "collection" or "remote" [optional, defaults to "deterministic"]
+//     bip44-account: bip44 account number [optional, only for "bip44" wallets, defaults to 0]
+//     seed-passphrase: bip39 seed passphrase [optional, only for "bip44" wallets, requires "encrypt"]
+//     xpub: extended public key of a bip44 account [required for "xpub" wallets]
+//     addresses: comma separated list of addresses to watch [required for "addresses" wallets]
+//     signer: url of the remote signer, a loopback http url or a unix:// socket url [required for "remote" wallets]
+//     public-keys: comma separated list of the public keys held by the signer [optional, only for "remote" wallets, defaults to all the keys of the signer]
 func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -193,8 +286,26 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		walletType := r.FormValue("type")
+		if walletType != "" {
+			var err error
+			walletType, err = wallet.ResolveWalletType(walletType)
+			if err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+		}
+
+		hasSeed := walletType != wallet.WalletTypeXPub &&
+			walletType != wallet.WalletTypeAddresses &&
+			walletType != wallet.WalletTypeCollection &&
+			walletType != wallet.WalletTypeRemote
+		noScan := walletType == wallet.WalletTypeAddresses ||
+			walletType == wallet.WalletTypeCollection ||
+			walletType == wallet.WalletTypeRemote
+
 		seed := r.FormValue("seed")
-		if seed == "" {
+		if seed == "" && hasSeed {
 			wh.Error400(w, "missing seed")
 			return
 		}
@@ -231,9 +342,30 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
+		var cryptoType wallet.CryptoType
+		if cryptoTypeStr := r.FormValue("crypto-type"); cryptoTypeStr != "" {
+			if !encrypt {
+				wh.Error400(w, "crypto-type is only valid with encrypt")
+				return
+			}
+
+			var err error
+			cryptoType, err = wallet.CryptoTypeFromString(cryptoTypeStr)
+			if err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+		}
+
 		scanNStr := r.FormValue("scan")
 		var scanN uint64 = 1
-		if scanNStr != "" {
+		if noScan {
+			if scanNStr != "" {
+				wh.Error400(w, fmt.Sprintf("scan is not allowed for %s wallets", walletType))
+				return
+			}
+			scanN = 0
+		} else if scanNStr != "" {
 			var err error
 			scanN, err = strconv.ParseUint(scanNStr, 10, 64)
 			if err != nil {
@@ -242,17 +374,95 @@ func walletCreateHandler(gateway Gatewayer) http.HandlerFunc {
 			}
 		}
 
-		if scanN == 0 {
+		if scanN == 0 && !noScan {
 			wh.Error400(w, "scan must be > 0")
 			return
 		}
 
+		xpub := r.FormValue("xpub")
+		if xpub != "" && walletType != wallet.WalletTypeXPub {
+			wh.Error400(w, "xpub is only valid for xpub wallets")
+			return
+		}
+
+		var addrs []cipher.Addresser
+		addrsStr := r.FormValue("addresses")
+		if addrsStr != "" {
+			if walletType != wallet.WalletTypeAddresses {
+				wh.Error400(w, "addresses is only valid for addresses wallets")
+				return
+			}
+
+			mdlAddrs, err := parseAddressesFromStr(addrsStr)
+			if err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+
+			addrs = make([]cipher.Addresser, len(mdlAddrs))
+			for i, a := range mdlAddrs {
+				addrs[i] = a
+			}
+		}
+
+		signer := r.FormValue("signer")
+		if signer != "" && walletType != wallet.WalletTypeRemote {
+			wh.Error400(w, "signer is only valid for remote wallets")
+			return
+		}
+
+		var pubkeys []cipher.PubKey
+		if pubkeysStr := r.FormValue("public-keys"); pubkeysStr != "" {
+			if walletType != wallet.WalletTypeRemote {
+				wh.Error400(w, "public-keys is only valid for remote wallets")
+				return
+			}
+
+			for _, s := range strings.Split(pubkeysStr, ",") {
+				pk, err := cipher.PubKeyFromHex(strings.TrimSpace(s))
+				if err != nil {
+					wh.Error400(w, fmt.Sprintf("invalid public key %q: %v", s, err))
+					return
+				}
+				pubkeys = append(pubkeys, pk)
+			}
+		}
+
+		var bip44Account uint64
+		bip44AccountStr := r.FormValue("bip44-account")
+		if bip44AccountStr != "" {
+			if walletType != wallet.WalletTypeBip44 {
+				wh.Error400(w, "bip44-account is only valid for bip44 wallets")
+				return
+			}
+
+			var err error
+			bip44Account, err = strconv.ParseUint(bip44AccountStr, 10, 32)
+			if err != nil {
+				wh.Error400(w, "invalid bip44-account value")
+				return
+			}
+		}
+
+		seedPassphrase := r.FormValue("seed-passphrase")
+		defer func() {
+			seedPassphrase = ""
+		}()
+
 		wlt, err := gateway.CreateWallet("", wallet.Options{
-			Seed:     seed,
-			Label:    label,
-			Encrypt:  encrypt,
-			Password: []byte(password),
-			ScanN:    scanN,
+			Seed:           seed,
+			Label:          label,
+			Encrypt:        encrypt,
+			Password:       []byte(password),
+			CryptoType:     cryptoType,
+			ScanN:          scanN,
+			Type:           walletType,
+			Bip44Account:   uint32(bip44Account),
+			SeedPassphrase: seedPassphrase,
+			XPub:           xpub,
+			Addresses:      addrs,
+			Signer:         signer,
+			PublicKeys:     pubkeys,
 		}, gateway)
 		if err != nil {
 			switch err.(type) {
@@ -654,7 +864,9 @@ func walletSeedHandler(gateway Gatewayer) http.HandlerFunc {
 			switch err {
 			case wallet.ErrMissingPassword,
 				wallet.ErrWalletNotEncrypted,
-				wallet.ErrInvalidPassword:
+				wallet.ErrInvalidPassword,
+				wallet.ErrWalletWatchOnly,
+				wallet.ErrWalletNoSeed:
 				wh.Error400(w, err.Error())
 			case wallet.ErrWalletAPIDisabled, wallet.ErrSeedAPIDisabled:
 				wh.Error403(w, "")
@@ -676,6 +888,101 @@ func walletSeedHandler(gateway Gatewayer) http.HandlerFunc {
 	}
 }
 
+// WalletSplitSeedRequest is the request data for POST /api/v2/wallet/seed/split
+type WalletSplitSeedRequest struct {
+	ID        string `json:"id"`
+	Password  string `json:"password"`
+	Threshold int    `json:"threshold"`
+	Shares    int    `json:"shares"`
+}
+
+// WalletSplitSeedResponse is the response data for POST /api/v2/wallet/seed/split
+type WalletSplitSeedResponse struct {
+	Threshold int      `json:"threshold"`
+	Shares    []string `json:"shares"`
+}
+
+// URI: /api/v2/wallet/seed/split
+// Method: POST
+// Args:
+//	id: wallet id
+//	password: wallet password
+//	threshold: number of shares required to recover the seed
+//	shares: number of shares
+// Splits the seed of an encrypted wallet into share mnemonics, any threshold of which
+// recover the seed with POST /api/v2/wallet/recover
+func walletSplitSeedHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletSplitSeedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Threshold == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "threshold is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Shares == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "shares is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		shares, err := gateway.SplitWalletSeed(req.ID, []byte(req.Password), req.Threshold, req.Shares)
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case wallet.ErrWalletAPIDisabled, wallet.ErrSeedAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			case wallet.ErrWalletNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+			default:
+				switch err.(type) {
+				case wallet.Error:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletSplitSeedResponse{
+				Threshold: req.Threshold,
+				Shares:    shares,
+			},
+		})
+	}
+}
+
 // VerifySeedRequest is the request data for POST /api/v2/wallet/seed/verify
 type VerifySeedRequest struct {
 	Seed string `json:"seed"`
@@ -754,6 +1061,7 @@ func walletUnloadHandler(gateway Gatewayer) http.HandlerFunc {
 // Args:
 //     id: wallet id
 //     password: wallet password
+//     crypto-type: crypto type for encrypting the wallet [optional, defaults to the node's wallet crypto type]
 func walletEncryptHandler(gateway Gatewayer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -772,12 +1080,23 @@ func walletEncryptHandler(gateway Gatewayer) http.HandlerFunc {
 			password = ""
 		}()
 
-		wlt, err := gateway.EncryptWallet(id, []byte(password))
+		var cryptoType wallet.CryptoType
+		if cryptoTypeStr := r.FormValue("crypto-type"); cryptoTypeStr != "" {
+			var err error
+			cryptoType, err = wallet.CryptoTypeFromString(cryptoTypeStr)
+			if err != nil {
+				wh.Error400(w, err.Error())
+				return
+			}
+		}
+
+		wlt, err := gateway.EncryptWallet(id, []byte(password), cryptoType)
 		if err != nil {
 			switch err {
 			case wallet.ErrWalletEncrypted,
 				wallet.ErrMissingPassword,
-				wallet.ErrInvalidPassword:
+				wallet.ErrInvalidPassword,
+				wallet.ErrWalletWatchOnly:
 				wh.Error400(w, err.Error())
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
@@ -828,7 +1147,8 @@ func walletDecryptHandler(gateway Gatewayer) http.HandlerFunc {
 			switch err {
 			case wallet.ErrMissingPassword,
 				wallet.ErrWalletNotEncrypted,
-				wallet.ErrInvalidPassword:
+				wallet.ErrInvalidPassword,
+				wallet.ErrSeedPassphraseNotEncrypted:
 				wh.Error400(w, err.Error())
 			case wallet.ErrWalletAPIDisabled:
 				wh.Error403(w, "")
@@ -851,9 +1171,11 @@ func walletDecryptHandler(gateway Gatewayer) http.HandlerFunc {
 
 // WalletRecoverRequest is the request data for POST /api/v2/wallet/recover
 type WalletRecoverRequest struct {
-	ID       string `json:"id"`
-	Seed     string `json:"seed"`
-	Password string `json:"password"`
+	ID             string   `json:"id"`
+	Seed           string   `json:"seed"`
+	SeedShares     []string `json:"seed_shares,omitempty"`
+	SeedPassphrase string   `json:"seed_passphrase"`
+	Password       string   `json:"password"`
 }
 
 // URI: /api/v2/wallet/recover
@@ -861,8 +1183,10 @@ type WalletRecoverRequest struct {
 // Args:
 //	id: wallet id
 //  seed: wallet seed
+//  seed_shares: share mnemonics of the wallet seed, created with POST /api/v2/wallet/seed/split, instead of seed
+//  seed_passphrase: [optional] bip39 seed passphrase, only for bip44 wallets and requires password
 //  password: [optional] new password
-// Recovers an encrypted wallet by providing the seed.
+// Recovers an encrypted wallet by providing the seed, or enough shares of the seed.
 // The first address will be generated from seed and compared to the first address
 // of the specified wallet. If they match, the wallet will be regenerated
 // with an optional password.
@@ -894,12 +1218,18 @@ func walletRecoverHandler(gateway Gatewayer) http.HandlerFunc {
 			return
 		}
 
-		if req.Seed == "" {
+		if req.Seed == "" && len(req.SeedShares) == 0 {
 			resp := NewHTTPErrorResponse(http.StatusBadRequest, "seed is required")
 			writeHTTPResponse(w, resp)
 			return
 		}
 
+		if req.Seed != "" && len(req.SeedShares) != 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "seed and seed_shares cannot be combined")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
 		var password []byte
 		if req.Password != "" {
 			password = []byte(req.Password)
@@ -907,22 +1237,38 @@ func walletRecoverHandler(gateway Gatewayer) http.HandlerFunc {
 
 		defer func() {
 			req.Seed = ""
+			for i := range req.SeedShares {
+				req.SeedShares[i] = ""
+			}
+			req.SeedPassphrase = ""
 			req.Password = ""
 			password = nil
 		}()
 
-		wlt, err := gateway.RecoverWallet(req.ID, req.Seed, password)
+		var wlt *wallet.Wallet
+		var err error
+		if len(req.SeedShares) != 0 {
+			wlt, err = gateway.RecoverWalletFromSeedShares(req.ID, req.SeedShares, req.SeedPassphrase, password)
+		} else {
+			wlt, err = gateway.RecoverWallet(req.ID, req.Seed, req.SeedPassphrase, password)
+		}
 		if err != nil {
 			var resp HTTPResponse
 			switch err {
-			case wallet.ErrWalletNotEncrypted, wallet.ErrWalletRecoverSeedWrong:
+			case wallet.ErrWalletNotEncrypted, wallet.ErrWalletRecoverSeedWrong,
+				wallet.ErrSeedPassphraseNotAllowed, wallet.ErrSeedPassphraseNotEncrypted:
 				resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
 			case wallet.ErrWalletNotExist:
 				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
 			case wallet.ErrWalletAPIDisabled:
 				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
 			default:
-				resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				if _, ok := err.(wallet.Error); ok && len(req.SeedShares) != 0 {
+					// The seed shares are invalid
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				} else {
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
 			}
 			writeHTTPResponse(w, resp)
 			return
@@ -940,3 +1286,1180 @@ func walletRecoverHandler(gateway Gatewayer) http.HandlerFunc {
 		})
 	}
 }
+
+// WalletPasswordRequest is the request data for POST /api/v2/wallet/password
+type WalletPasswordRequest struct {
+	ID          string `json:"id"`
+	Password    string `json:"password"`
+	NewPassword string `json:"new_password"`
+	CryptoType  string `json:"crypto_type"`
+}
+
+// URI: /api/v2/wallet/password
+// Method: POST
+// Args:
+//	id: wallet id
+//  password: current wallet password
+//  new_password: [optional] new wallet password, the password is not changed if empty
+//  crypto_type: [optional] crypto type to migrate the wallet to, the crypto type is not changed if empty
+// Changes the password and/or the crypto type of an encrypted wallet.
+// The secrets are re-encrypted in one step, the wallet is never saved unencrypted.
+func walletPasswordHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+			req.NewPassword = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Password == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "password is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.NewPassword == "" && req.CryptoType == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "new_password or crypto_type is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var cryptoType wallet.CryptoType
+		if req.CryptoType != "" {
+			var err error
+			cryptoType, err = wallet.CryptoTypeFromString(req.CryptoType)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+		}
+
+		var wlt *wallet.Wallet
+		var err error
+		if req.NewPassword == "" {
+			wlt, err = gateway.MigrateCryptoType(req.ID, []byte(req.Password), cryptoType)
+		} else {
+			wlt, err = gateway.ChangePassword(req.ID, []byte(req.Password), []byte(req.NewPassword), cryptoType)
+		}
+		if err != nil {
+			var resp HTTPResponse
+			switch err {
+			case wallet.ErrWalletNotExist:
+				resp = NewHTTPErrorResponse(http.StatusNotFound, "")
+			case wallet.ErrWalletAPIDisabled:
+				resp = NewHTTPErrorResponse(http.StatusForbidden, "")
+			default:
+				switch err.(type) {
+				case wallet.Error:
+					resp = NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				default:
+					resp = NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				}
+			}
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		rlt, err := NewWalletResponse(wlt)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rlt,
+		})
+	}
+}
+
+// WalletImportKeysRequest is the request data for POST /api/v2/wallet/keys/import
+type WalletImportKeysRequest struct {
+	ID       string   `json:"id"`
+	Password string   `json:"password"`
+	Keys     []string `json:"keys"`
+}
+
+// WalletImportKeysResponse is the response data for POST /api/v2/wallet/keys/import
+type WalletImportKeysResponse struct {
+	Addresses []string `json:"addresses"`
+}
+
+// URI: /api/v2/wallet/keys/import
+// Method: POST
+// Args:
+//	id: wallet id
+//  keys: secret keys, hex encoded or in wallet import format
+//  password: [optional] wallet password, must be provided if the wallet is encrypted
+// Imports secret keys into a collection wallet and returns their addresses.
+func walletImportKeysHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletImportKeysRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			for i := range req.Keys {
+				req.Keys[i] = ""
+			}
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.Keys) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "keys is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		keys := make([]cipher.SecKey, len(req.Keys))
+		defer func() {
+			for i := range keys {
+				keys[i] = cipher.SecKey{}
+			}
+		}()
+		for i, k := range req.Keys {
+			sk, err := wallet.ParseSecretKey(k)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+			keys[i] = sk
+		}
+
+		var password []byte
+		if req.Password != "" {
+			password = []byte(req.Password)
+		}
+
+		addrs, err := gateway.ImportSecretKeys(req.ID, password, keys)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		rlt := WalletImportKeysResponse{
+			Addresses: make([]string, len(addrs)),
+		}
+		for i, a := range addrs {
+			rlt.Addresses[i] = a.String()
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rlt,
+		})
+	}
+}
+
+// WalletRemoveAddressesRequest is the request data for POST /api/v2/wallet/keys/remove
+type WalletRemoveAddressesRequest struct {
+	ID        string   `json:"id"`
+	Password  string   `json:"password"`
+	Addresses []string `json:"addresses"`
+}
+
+// URI: /api/v2/wallet/keys/remove
+// Method: POST
+// Args:
+//	id: wallet id
+//  addresses: addresses to remove
+//  password: [optional] wallet password, must be provided if the wallet is encrypted
+// Removes addresses and their secret keys from a collection wallet.
+func walletRemoveAddressesHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletRemoveAddressesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.Addresses) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrs := make([]cipher.Address, len(req.Addresses))
+		for i, a := range req.Addresses {
+			addr, err := cipher.DecodeBase58Address(a)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address %q: %v", a, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			addrs[i] = addr
+		}
+
+		var password []byte
+		if req.Password != "" {
+			password = []byte(req.Password)
+		}
+
+		if err := gateway.RemoveAddresses(req.ID, password, addrs); err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		wlt, err := gateway.GetWallet(req.ID)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		rlt, err := NewWalletResponse(wlt)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rlt,
+		})
+	}
+}
+
+// WalletBackupRequest is the request data for POST /api/v2/wallet/backup
+type WalletBackupRequest struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+}
+
+// URI: /api/v2/wallet/backup
+// Method: POST
+// Args:
+//	id: [optional] wallet id, all wallets are backed up if empty
+//  password: password of the backup archive
+// Creates an encrypted and checksummed backup archive of a wallet or of all wallets.
+// Encrypted wallets stay encrypted with their own password in the archive.
+func walletBackupHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletBackupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.Password == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "password is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		archive, err := gateway.Backup(req.ID, []byte(req.Password))
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: archive,
+		})
+	}
+}
+
+// WalletRestoreRequest is the request data for POST /api/v2/wallet/restore
+type WalletRestoreRequest struct {
+	Archive   *wallet.BackupArchive `json:"archive"`
+	Password  string                `json:"password"`
+	Overwrite bool                  `json:"overwrite"`
+}
+
+// WalletRestoreResponse is the response data for POST /api/v2/wallet/restore
+type WalletRestoreResponse struct {
+	Wallets []WalletResponse `json:"wallets"`
+}
+
+// URI: /api/v2/wallet/restore
+// Method: POST
+// Args:
+//	archive: backup archive, as returned by /api/v2/wallet/backup
+//  password: password of the backup archive
+//  overwrite: [optional] replace the loaded wallets of the archive
+// Restores the wallets of a backup archive. The archive is verified and every wallet
+// is validated before any wallet is written. Restoring a wallet that is already loaded
+// fails unless overwrite is true. A replaced wallet keeps its spending policy and daily spending.
+func walletRestoreHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletRestoreRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.Archive == nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "archive is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.Password == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "password is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wlts, err := gateway.Restore(req.Archive, []byte(req.Password), req.Overwrite)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		rlt := WalletRestoreResponse{
+			Wallets: make([]WalletResponse, len(wlts)),
+		}
+		for i, wlt := range wlts {
+			wr, err := NewWalletResponse(wlt)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+				writeHTTPResponse(w, resp)
+				return
+			}
+			rlt.Wallets[i] = *wr
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rlt,
+		})
+	}
+}
+
+// WalletArchiveRequest is the request data for POST /api/v2/wallet/archive
+type WalletArchiveRequest struct {
+	ID string `json:"id"`
+}
+
+// WalletArchiveResponse is the response data for POST /api/v2/wallet/archive
+type WalletArchiveResponse struct {
+	Filename string `json:"filename"`
+}
+
+// URI: /api/v2/wallet/archive
+// Method: POST
+// Args:
+//	id: wallet id
+// Unloads a wallet and moves its file into the "archive" subdirectory of the wallet directory.
+// Returns the path of the archived wallet file.
+func walletArchiveHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletArchiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		fn, err := gateway.ArchiveWallet(req.ID)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletArchiveResponse{
+				Filename: fn,
+			},
+		})
+	}
+}
+
+// WalletDeleteRequest is the request data for POST /api/v2/wallet/delete
+type WalletDeleteRequest struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+}
+
+// URI: /api/v2/wallet/delete
+// Method: POST
+// Args:
+//	id: wallet id
+//  password: [optional] wallet password, must be provided if the wallet is encrypted
+// Unloads a wallet and permanently deletes its file.
+func walletDeleteHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletDeleteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		defer func() {
+			req.Password = ""
+		}()
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var password []byte
+		if req.Password != "" {
+			password = []byte(req.Password)
+		}
+
+		if err := gateway.DeleteWallet(req.ID, password); err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{})
+	}
+}
+
+// WalletRenameRequest is the request data for POST /api/v2/wallet/rename
+type WalletRenameRequest struct {
+	ID    string `json:"id"`
+	NewID string `json:"new_id"`
+}
+
+// URI: /api/v2/wallet/rename
+// Method: POST
+// Args:
+//	id: wallet id
+//  new_id: new wallet id, a filename with the .wlt extension
+// Renames the file of a loaded wallet, which changes its id.
+func walletRenameHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletRenameRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.NewID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "new_id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wlt, err := gateway.RenameWallet(req.ID, req.NewID)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		rlt, err := NewWalletResponse(wlt)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: rlt,
+		})
+	}
+}
+
+// WalletFrozenOutputsRequest is the request data for POST /api/v2/wallet/outputs/freeze and /api/v2/wallet/outputs/unfreeze
+type WalletFrozenOutputsRequest struct {
+	ID     string   `json:"id"`
+	UxOuts []string `json:"uxouts"`
+}
+
+// WalletFrozenOutputsResponse is the response data for the frozen outputs endpoints
+type WalletFrozenOutputsResponse struct {
+	FrozenOutputs []string `json:"frozen_outputs"`
+}
+
+func newWalletFrozenOutputsResponse(hashes []cipher.SHA256) WalletFrozenOutputsResponse {
+	rsp := WalletFrozenOutputsResponse{
+		FrozenOutputs: make([]string, len(hashes)),
+	}
+	for i, h := range hashes {
+		rsp.FrozenOutputs[i] = h.Hex()
+	}
+	return rsp
+}
+
+// URI: /api/v2/wallet/outputs/freeze
+// Method: POST
+// Args:
+//	id: wallet id
+//	uxouts: unspent output hashes to freeze
+// Freezes unspent outputs in a wallet. Frozen outputs are not spent by transactions
+// created from the wallet, unless they are selected explicitly with "unspents".
+// Returns the frozen outputs of the wallet.
+func walletFreezeOutputsHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletUpdateFrozenOutputsHandler(gateway.FreezeOutputs)
+}
+
+// URI: /api/v2/wallet/outputs/unfreeze
+// Method: POST
+// Args:
+//	id: wallet id
+//	uxouts: unspent output hashes to unfreeze
+// Unfreezes unspent outputs of a wallet, outputs which are not frozen are ignored.
+// Returns the frozen outputs of the wallet.
+func walletUnfreezeOutputsHandler(gateway Gatewayer) http.HandlerFunc {
+	return walletUpdateFrozenOutputsHandler(gateway.UnfreezeOutputs)
+}
+
+func walletUpdateFrozenOutputsHandler(update func(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if r.Header.Get("Content-Type") != ContentTypeJSON {
+			resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		var req WalletFrozenOutputsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if req.ID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		if len(req.UxOuts) == 0 {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "uxouts is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		uxIDs := make([]cipher.SHA256, len(req.UxOuts))
+		for i, s := range req.UxOuts {
+			h, err := cipher.SHA256FromHex(s)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("SHA256 hash %q is invalid: %v", s, err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+			uxIDs[i] = h
+		}
+
+		frozen, err := update(req.ID, uxIDs)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: newWalletFrozenOutputsResponse(frozen),
+		})
+	}
+}
+
+// URI: /api/v2/wallet/outputs/frozen
+// Method: GET
+// Args:
+//	id: wallet id
+// Returns the frozen outputs of a wallet
+func walletFrozenOutputsHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		frozen, err := gateway.GetFrozenOutputs(wltID)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: newWalletFrozenOutputsResponse(frozen),
+		})
+	}
+}
+
+// WalletAddressRequest is the request data for POST /api/v2/wallet/address
+type WalletAddressRequest struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	// Label replaces the label of the address if set, an empty label removes it
+	Label *string `json:"label"`
+	// Meta replaces the metadata of the address if set, an empty object removes it
+	Meta map[string]string `json:"meta"`
+}
+
+// URI: /api/v2/wallet/address
+// Method: GET, POST
+// Looks up the addresses of a wallet by label, or updates the label and metadata of an address
+func walletAddressHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			getWalletAddressesHandler(w, r, gateway)
+		case http.MethodPost:
+			updateWalletAddressHandler(w, r, gateway)
+		default:
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+		}
+	}
+}
+
+// serves GET requests for /wallet/address
+// Args:
+//	id: wallet id
+//	label: address label
+// Returns the entries of the wallet which have the label
+func getWalletAddressesHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	wltID := r.FormValue("id")
+	if wltID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	label := r.FormValue("label")
+	if label == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "label is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	wlt, err := gateway.GetWallet(wltID)
+	if err != nil {
+		writeHTTPResponse(w, walletErrorResponse(err))
+		return
+	}
+
+	hasChangeChain := wlt.HasChangeChain()
+	entries := []readable.WalletEntry{}
+	for _, e := range wlt.EntriesByLabel(label) {
+		entries = append(entries, newReadableWalletEntry(e, hasChangeChain))
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: entries,
+	})
+}
+
+// serves POST requests for /wallet/address
+// Args:
+//	id: wallet id
+//	address: address of the wallet
+//	label: new label of the address, an empty label removes it [optional]
+//	meta: new key/value metadata of the address, an empty object removes it [optional]
+// Labels and metadata are not encrypted, encrypted wallets don't require the password.
+// Returns the updated entry.
+func updateWalletAddressHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	if r.Header.Get("Content-Type") != ContentTypeJSON {
+		resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var req WalletAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.ID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Address == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	addr, err := cipher.DecodeBase58Address(req.Address)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address: %v", err))
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Label == nil && req.Meta == nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "label or meta is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	wlt, err := gateway.UpdateEntry(req.ID, addr, wallet.EntryUpdate{
+		Label: req.Label,
+		Meta:  req.Meta,
+	})
+	if err != nil {
+		writeHTTPResponse(w, walletErrorResponse(err))
+		return
+	}
+
+	e, ok := wlt.GetEntry(addr)
+	if !ok {
+		resp := NewHTTPErrorResponse(http.StatusInternalServerError, "updated address is missing in the wallet")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: newReadableWalletEntry(e, wlt.HasChangeChain()),
+	})
+}
+
+// WalletAddressURIResponse is the response data for GET /api/v2/wallet/address/uri
+type WalletAddressURIResponse struct {
+	URI string `json:"uri"`
+	// QR is the QR code of the URI, as an SVG image
+	QR string `json:"qr"`
+}
+
+// URI: /api/v2/wallet/address/uri
+// Method: GET
+// Args:
+//	id: wallet id
+//	address: address of the wallet
+//	amount: coins to request [optional]
+//	hours: coin hours to request [optional]
+//	label: label of the address, defaults to the label of the wallet entry [optional]
+//	message: message describing the payment [optional]
+// Returns a payment request URI for an address of the wallet, with its QR code
+func walletAddressURIHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wltID := r.FormValue("id")
+		if wltID == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addrStr := r.FormValue("address")
+		if addrStr == "" {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, "address is required")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		addr, err := cipher.DecodeBase58Address(addrStr)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid address: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		u := paymenturi.URI{
+			Address: addr,
+			Message: r.FormValue("message"),
+		}
+
+		if amount := r.FormValue("amount"); amount != "" {
+			coins, err := droplet.FromString(amount)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("invalid amount: %v", err))
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			if coins == 0 {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "amount must not be zero")
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			u.Coins = coins
+		}
+
+		if hoursStr := r.FormValue("hours"); hoursStr != "" {
+			hours, err := strconv.ParseUint(hoursStr, 10, 64)
+			if err != nil {
+				resp := NewHTTPErrorResponse(http.StatusBadRequest, "invalid hours value")
+				writeHTTPResponse(w, resp)
+				return
+			}
+
+			u.Hours = &hours
+		}
+
+		if err := u.Validate(); err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		wlt, err := gateway.GetWallet(wltID)
+		if err != nil {
+			writeHTTPResponse(w, walletErrorResponse(err))
+			return
+		}
+
+		e, ok := wlt.GetEntry(addr)
+		if !ok {
+			resp := NewHTTPErrorResponse(http.StatusNotFound, "address not found in wallet")
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		u.Label = e.Label
+		if _, ok := r.Form["label"]; ok {
+			u.Label = r.FormValue("label")
+		}
+
+		uri := u.String()
+		code, err := qrcode.Encode([]byte(uri), qrcode.Medium)
+		if err != nil {
+			resp := NewHTTPErrorResponse(http.StatusBadRequest, fmt.Sprintf("URI is too long for a QR code: %v", err))
+			writeHTTPResponse(w, resp)
+			return
+		}
+
+		writeHTTPResponse(w, HTTPResponse{
+			Data: WalletAddressURIResponse{
+				URI: uri,
+				QR:  code.SVG(4),
+			},
+		})
+	}
+}
+
+// WalletSpendingPolicyRequest is the request data for POST /api/v2/wallet/policy
+type WalletSpendingPolicyRequest struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+	// Policy replaces the spending policy of the wallet, an empty policy removes it
+	Policy *readable.SpendingPolicy `json:"policy"`
+}
+
+// WalletSpendingPolicyResponse is the response data for /api/v2/wallet/policy
+type WalletSpendingPolicyResponse struct {
+	Policy readable.SpendingPolicy `json:"policy"`
+	// SpentToday are the coins spent in the last 24 hours, which count towards the daily limit
+	SpentToday string `json:"spent_today"`
+}
+
+func newWalletSpendingPolicyResponse(w *wallet.Wallet) (*WalletSpendingPolicyResponse, error) {
+	policy, err := readable.NewSpendingPolicy(w.SpendingPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	spentToday, err := droplet.ToString(w.SpentToday(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalletSpendingPolicyResponse{
+		Policy:     policy,
+		SpentToday: spentToday,
+	}, nil
+}
+
+// URI: /api/v2/wallet/policy
+// Method: GET, POST
+// Gets or sets the spending policy of a wallet
+func walletSpendingPolicyHandler(gateway Gatewayer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			getWalletSpendingPolicyHandler(w, r, gateway)
+		case http.MethodPost:
+			setWalletSpendingPolicyHandler(w, r, gateway)
+		default:
+			resp := NewHTTPErrorResponse(http.StatusMethodNotAllowed, "")
+			writeHTTPResponse(w, resp)
+		}
+	}
+}
+
+// serves GET requests for /wallet/policy
+// Args:
+//	id: wallet id
+// Returns the spending policy of the wallet and the coins spent in the last 24 hours
+func getWalletSpendingPolicyHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	wltID := r.FormValue("id")
+	if wltID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	wlt, err := gateway.GetWallet(wltID)
+	if err != nil {
+		writeHTTPResponse(w, walletErrorResponse(err))
+		return
+	}
+
+	rsp, err := newWalletSpendingPolicyResponse(wlt)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: rsp,
+	})
+}
+
+// serves POST requests for /wallet/policy
+// Args:
+//	id: wallet id
+//	password: wallet password
+//	policy: the new spending policy, an empty policy removes it
+// Only encrypted wallets can have a spending policy, the policy can't be changed without the password.
+// Returns the spending policy of the wallet and the coins spent in the last 24 hours
+func setWalletSpendingPolicyHandler(w http.ResponseWriter, r *http.Request, gateway Gatewayer) {
+	if r.Header.Get("Content-Type") != ContentTypeJSON {
+		resp := NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	var req WalletSpendingPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.ID == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "id is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Password == "" {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "password is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	if req.Policy == nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, "policy is required")
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	policy, err := req.Policy.ToWalletSpendingPolicy()
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	wlt, err := gateway.SetSpendingPolicy(req.ID, []byte(req.Password), policy)
+	if err != nil {
+		writeHTTPResponse(w, walletErrorResponse(err))
+		return
+	}
+
+	rsp, err := newWalletSpendingPolicyResponse(wlt)
+	if err != nil {
+		resp := NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+		writeHTTPResponse(w, resp)
+		return
+	}
+
+	writeHTTPResponse(w, HTTPResponse{
+		Data: rsp,
+	})
+}
+
+// SpendingPolicyViolation is the data of the error response of a transaction which violates a rule of the spending policy of the wallet
+type SpendingPolicyViolation struct {
+	Rule string `json:"rule"`
+}
+
+// policyViolationResponse returns a 403 response which names the violated rule
+func policyViolationResponse(err wallet.PolicyViolationError) HTTPResponse {
+	resp := NewHTTPErrorResponse(http.StatusForbidden, err.Error())
+	resp.Data = SpendingPolicyViolation{
+		Rule: string(err.Rule),
+	}
+	return resp
+}
+
+// walletErrorResponse maps the errors of wallet service updates to a response
+func walletErrorResponse(err error) HTTPResponse {
+	switch err {
+	case wallet.ErrWalletNotExist:
+		return NewHTTPErrorResponse(http.StatusNotFound, "")
+	case wallet.ErrWalletAPIDisabled:
+		return NewHTTPErrorResponse(http.StatusForbidden, "")
+	}
+
+	switch err.(type) {
+	case wallet.Error:
+		return NewHTTPErrorResponse(http.StatusBadRequest, err.Error())
+	default:
+		return NewHTTPErrorResponse(http.StatusInternalServerError, err.Error())
+	}
+}
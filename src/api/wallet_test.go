@@ -17,6 +17,7 @@ import (
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/shamir"
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/readable"
 	"github.com/MDLlife/MDL/src/testutil"
@@ -345,6 +346,27 @@ func TestWalletBalanceHandler(t *testing.T) {
 		Addresses   wallet.AddressBalances
 	}
 
+	frozenOutputs := []visor.UnspentOutput{
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Coins:          1e6,
+				},
+			},
+			CalculatedHours: 10,
+		},
+		{
+			UxOut: coin.UxOut{
+				Body: coin.UxBody{
+					SrcTransaction: testutil.RandSHA256(t),
+					Coins:          2e6,
+				},
+			},
+			CalculatedHours: 20,
+		},
+	}
+
 	tt := []struct {
 		name                          string
 		method                        string
@@ -354,7 +376,11 @@ func TestWalletBalanceHandler(t *testing.T) {
 		walletID                      string
 		gatewayGetWalletBalanceResult balanceResult
 		gatewayBalanceErr             error
+		gatewayFrozenOutputs          []visor.UnspentOutput
+		gatewayFrozenOutputsErr       error
 		result                        *readable.BalancePair
+		frozen                        *readable.Balance
+		frozenOutputs                 []string
 	}{
 		{
 			name:     "405",
@@ -414,6 +440,17 @@ func TestWalletBalanceHandler(t *testing.T) {
 			gatewayGetWalletBalanceResult: balanceResult{},
 			gatewayBalanceErr:             wallet.ErrWalletAPIDisabled,
 		},
+		{
+			name:   "500 - gw frozen outputs error",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			status:                  http.StatusInternalServerError,
+			err:                     "500 Internal Server Error - gatewayFrozenOutputsError",
+			walletID:                "foo",
+			gatewayFrozenOutputsErr: errors.New("gatewayFrozenOutputsError"),
+		},
 		{
 			name:   "200 - OK",
 			method: http.MethodGet,
@@ -425,6 +462,28 @@ func TestWalletBalanceHandler(t *testing.T) {
 			walletID: "foo",
 			result:   &readable.BalancePair{},
 		},
+		{
+			name:   "200 - OK frozen outputs",
+			method: http.MethodGet,
+			body: &httpBody{
+				WalletID: "foo",
+			},
+			status:   http.StatusOK,
+			walletID: "foo",
+			gatewayFrozenOutputs: []visor.UnspentOutput{
+				frozenOutputs[0],
+				frozenOutputs[1],
+			},
+			result: &readable.BalancePair{},
+			frozen: &readable.Balance{
+				Coins: 3e6,
+				Hours: 30,
+			},
+			frozenOutputs: []string{
+				frozenOutputs[0].Hash().Hex(),
+				frozenOutputs[1].Hash().Hex(),
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -432,6 +491,7 @@ func TestWalletBalanceHandler(t *testing.T) {
 			gateway := &MockGatewayer{}
 			gateway.On("GetWalletBalance", tc.walletID).Return(tc.gatewayGetWalletBalanceResult.BalancePair,
 				tc.gatewayGetWalletBalanceResult.Addresses, tc.gatewayBalanceErr)
+			gateway.On("GetWalletFrozenOutputs", tc.walletID).Return(tc.gatewayFrozenOutputs, tc.gatewayFrozenOutputsErr)
 
 			endpoint := "/api/v1/wallet/balance"
 
@@ -467,10 +527,12 @@ func TestWalletBalanceHandler(t *testing.T) {
 				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
 					strings.TrimSpace(rr.Body.String()), status, tc.err)
 			} else {
-				var msg readable.BalancePair
+				var msg BalanceResponse
 				err = json.Unmarshal(rr.Body.Bytes(), &msg)
 				require.NoError(t, err)
-				require.Equal(t, tc.result, &msg, tc.name)
+				require.Equal(t, tc.result, &msg.BalancePair, tc.name)
+				require.Equal(t, tc.frozen, msg.Frozen)
+				require.Equal(t, tc.frozenOutputs, msg.FrozenOutputs)
 			}
 		})
 	}
@@ -849,12 +911,24 @@ func TestWalletTransactionsHandler(t *testing.T) {
 func TestWalletCreateHandler(t *testing.T) {
 	entries, responseEntries := makeEntries([]byte("seed"), 5)
 	type httpBody struct {
-		Seed     string
-		Label    string
-		ScanN    string
-		Encrypt  bool
-		Password string
+		Seed           string
+		Label          string
+		ScanN          string
+		Encrypt        bool
+		Password       string
+		CryptoType     string
+		Type           string
+		Bip44Account   string
+		SeedPassphrase string
+		XPub           string
+		Addresses      string
+		Signer         string
+		PublicKeys     string
 	}
+	addr := testutil.MakeAddress()
+	pubkey := testutil.MakePubKey()
+	bip44Coin := uint32(8000)
+	bip44Account := uint32(1)
 	tt := []struct {
 		name                      string
 		method                    string
@@ -1066,6 +1140,72 @@ func TestWalletCreateHandler(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "200 - OK - Encrypted with crypto type",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:       "foo",
+				Label:      "bar",
+				Encrypt:    true,
+				Password:   "pwd",
+				CryptoType: string(wallet.CryptoTypeArgon2idChacha20poly1305),
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Label:      "bar",
+				Seed:       "foo",
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+				CryptoType: wallet.CryptoTypeArgon2idChacha20poly1305,
+				ScanN:      1,
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename":     "filename",
+					"label":        "bar",
+					"encrypted":    "true",
+					"cryptoType":   string(wallet.CryptoTypeArgon2idChacha20poly1305),
+					"argon2Time":   "2",
+					"argon2Memory": "1024",
+					"secrets":      "secrets",
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:     "filename",
+					Label:        "bar",
+					Encrypted:    true,
+					CryptoType:   string(wallet.CryptoTypeArgon2idChacha20poly1305),
+					Argon2Time:   2,
+					Argon2Memory: 1024,
+				},
+			},
+		},
+		{
+			name:   "400 - invalid crypto type",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:       "foo",
+				Label:      "bar",
+				Encrypt:    true,
+				Password:   "pwd",
+				CryptoType: "foo",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - unknown crypto type",
+		},
+		{
+			name:   "400 - crypto type without encrypt",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:       "foo",
+				Label:      "bar",
+				CryptoType: string(wallet.CryptoTypeSha256Xor),
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - crypto-type is only valid with encrypt",
+		},
 		{
 			name:   "400 Bad request - encrypt without password",
 			method: http.MethodPost,
@@ -1077,281 +1217,449 @@ func TestWalletCreateHandler(t *testing.T) {
 			status: http.StatusBadRequest,
 			err:    "400 Bad Request - missing password",
 		},
-	}
-
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-			if tc.options.ScanN == 0 {
-				tc.options.ScanN = 1
-			}
-			gateway.On("CreateWallet", "", tc.options, gateway).Return(&tc.gatewayCreateWalletResult, tc.gatewayCreateWalletErr)
-
-			endpoint := "/api/v1/wallet/create"
-
-			v := url.Values{}
-			if tc.body != nil {
-				if tc.body.Seed != "" {
-					v.Add("seed", tc.body.Seed)
-				}
-				if tc.body.Label != "" {
-					v.Add("label", tc.body.Label)
-				}
-				if tc.body.ScanN != "" {
-					v.Add("scan", tc.body.ScanN)
-				}
-
-				if tc.body.Encrypt {
-					v.Add("encrypt", strconv.FormatBool(tc.body.Encrypt))
-				}
-
-				if tc.body.Password != "" {
-					v.Add("password", tc.body.Password)
-				}
-			}
-
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
-			req.Header.Add("Content-Type", ContentTypeForm)
-			require.NoError(t, err)
-
-			if tc.csrfDisabled {
-				setCSRFParameters(t, tokenInvalid, req)
-			} else {
-				setCSRFParameters(t, tokenValid, req)
-			}
-
-			rr := httptest.NewRecorder()
-
-			cfg := defaultMuxConfig()
-			cfg.disableCSRF = tc.csrfDisabled
-
-			handler := newServerMux(cfg, gateway)
-			handler.ServeHTTP(rr, req)
-
-			status := rr.Code
-			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
-
-			if status != http.StatusOK {
-				body := strings.TrimSpace(rr.Body.String())
-				require.Equal(t, tc.err, body, "got `%v`| %d, want `%v`", body, status, tc.err)
-				return
-			}
-
-			var msg WalletResponse
-			err = json.Unmarshal(rr.Body.Bytes(), &msg)
-			require.NoError(t, err)
-			require.Equal(t, tc.responseBody, msg, tc.name)
-		})
-	}
-}
-
-func TestWalletNewSeed(t *testing.T) {
-	type httpBody struct {
-		Entropy string
-	}
-	tt := []struct {
-		name      string
-		method    string
-		body      *httpBody
-		status    int
-		err       string
-		entropy   string
-		resultLen int
-	}{
 		{
-			name:   "405",
-			method: http.MethodPut,
-			status: http.StatusMethodNotAllowed,
-			err:    "405 Method Not Allowed",
+			name:   "400 - invalid type",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:  "foo",
+				Label: "bar",
+				Type:  "foo",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid wallet type",
 		},
 		{
-			name:   "400 - invalid entropy type",
-			method: http.MethodGet,
+			name:   "400 - bip44-account for deterministic wallet",
+			method: http.MethodPost,
 			body: &httpBody{
-				Entropy: "xx",
+				Seed:         "foo",
+				Label:        "bar",
+				Type:         "deterministic",
+				Bip44Account: "1",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - bip44-account is only valid for bip44 wallets",
+		},
+		{
+			name:   "400 - invalid bip44-account",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:         "foo",
+				Label:        "bar",
+				Type:         "bip44",
+				Bip44Account: "2147483648000",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid bip44-account value",
+		},
+		{
+			name:   "400 - seed passphrase for deterministic wallet",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:           "foo",
+				Label:          "bar",
+				Encrypt:        true,
+				Password:       "pwd",
+				SeedPassphrase: "passphrase",
 			},
 			status:  http.StatusBadRequest,
-			err:     "400 Bad Request - invalid entropy",
-			entropy: "xx",
+			err:     "400 Bad Request - seed passphrase is only allowed for bip44 wallets",
+			wltName: "foo",
+			options: wallet.Options{
+				Label:          "bar",
+				Seed:           "foo",
+				Encrypt:        true,
+				Password:       []byte("pwd"),
+				SeedPassphrase: "passphrase",
+			},
+			gatewayCreateWalletErr: wallet.ErrSeedPassphraseNotAllowed,
 		},
 		{
-			name:   "400 - `wrong entropy length` error",
-			method: http.MethodGet,
+			name:   "400 - seed passphrase for unencrypted bip44 wallet",
+			method: http.MethodPost,
 			body: &httpBody{
-				Entropy: "200",
+				Seed:           "foo",
+				Label:          "bar",
+				Type:           "bip44",
+				SeedPassphrase: "passphrase",
 			},
 			status:  http.StatusBadRequest,
-			err:     "400 Bad Request - entropy length must be 128 or 256",
-			entropy: "200",
+			err:     "400 Bad Request - a wallet with a seed passphrase must be encrypted",
+			wltName: "foo",
+			options: wallet.Options{
+				Label:          "bar",
+				Seed:           "foo",
+				Password:       []byte{},
+				Type:           wallet.WalletTypeBip44,
+				SeedPassphrase: "passphrase",
+			},
+			gatewayCreateWalletErr: wallet.ErrSeedPassphraseNotEncrypted,
 		},
 		{
-			name:      "200 - OK with no entropy",
-			method:    http.MethodGet,
-			body:      &httpBody{},
-			status:    http.StatusOK,
-			entropy:   "128",
-			resultLen: 12,
+			name:   "400 - xpub for deterministic wallet",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:  "foo",
+				Label: "bar",
+				XPub:  "xpub",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - xpub is only valid for xpub wallets",
 		},
 		{
-			name:   "200 - OK | 12 word seed",
-			method: http.MethodGet,
+			name:   "400 - addresses for xpub wallet",
+			method: http.MethodPost,
 			body: &httpBody{
-				Entropy: "128",
+				Label:     "bar",
+				Type:      "xpub",
+				XPub:      "xpub",
+				Addresses: addr.String(),
 			},
-			status:    http.StatusOK,
-			entropy:   "128",
-			resultLen: 12,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - addresses is only valid for addresses wallets",
 		},
 		{
-			name:   "200 - OK | 24 word seed",
-			method: http.MethodGet,
+			name:   "400 - invalid addresses",
+			method: http.MethodPost,
 			body: &httpBody{
-				Entropy: "256",
+				Label:     "bar",
+				Type:      "addresses",
+				Addresses: "foo",
 			},
-			status:    http.StatusOK,
-			entropy:   "256",
-			resultLen: 24,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - address \"foo\" is invalid: Invalid address length",
 		},
-	}
-
-	// Loop over each test case
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			gateway := &MockGatewayer{}
-
-			endpoint := "/api/v1/wallet/newSeed"
-
-			// Add request parameters to url
-			v := url.Values{}
-			if tc.body != nil {
-				if tc.body.Entropy != "" {
-					v.Add("entropy", tc.body.Entropy)
-				}
-			}
-			if len(v) > 0 {
-				endpoint += "?" + v.Encode()
-			}
-
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
-			require.NoError(t, err)
-			req.Header.Add("Content-Type", ContentTypeForm)
-
-			setCSRFParameters(t, tokenValid, req)
-
-			rr := httptest.NewRecorder()
-
-			cfg := defaultMuxConfig()
-			cfg.disableCSRF = false
-
-			handler := newServerMux(cfg, gateway)
-			handler.ServeHTTP(rr, req)
-
-			status := rr.Code
-			require.Equal(t, tc.status, status, "got `%v` expected `%v`", tc.name, status, tc.status)
-			if status != tc.status {
-				t.Errorf("got `%v` want `%v`", status, tc.status)
-			}
-			if status != http.StatusOK {
-				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, expected `%v`",
-					strings.TrimSpace(rr.Body.String()), status, tc.err)
-			} else {
-				var msg struct {
-					Seed string `json:"seed"`
-				}
-				err = json.Unmarshal(rr.Body.Bytes(), &msg)
-				require.NoError(t, err)
-				// check that expected length is equal to response length
-				require.Equal(t, tc.resultLen, len(strings.Fields(msg.Seed)), tc.name)
-			}
-		})
-	}
-}
-
-func TestVerifySeed(t *testing.T) {
-	cases := []struct {
-		name         string
-		method       string
-		status       int
-		contentType  string
-		csrfDisabled bool
-		httpBody     string
-		httpResponse HTTPResponse
-	}{
 		{
-			name:         "405",
-			method:       http.MethodGet,
-			status:       http.StatusMethodNotAllowed,
-			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+			name:   "400 - scan for addresses wallet",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label:     "bar",
+				Type:      "addresses",
+				Addresses: addr.String(),
+				ScanN:     "2",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - scan is not allowed for addresses wallets",
 		},
-
 		{
-			name:         "415 - Unsupported Media Type",
-			method:       http.MethodPost,
-			contentType:  ContentTypeForm,
-			status:       http.StatusUnsupportedMediaType,
-			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+			name:   "400 - scan for collection wallet",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label: "bar",
+				Type:  "collection",
+				ScanN: "2",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - scan is not allowed for collection wallets",
 		},
-
 		{
-			name:         "400 - EOF",
-			method:       http.MethodPost,
-			contentType:  ContentTypeJSON,
-			status:       http.StatusBadRequest,
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			name:   "400 - encrypt watch-only wallet",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label:    "bar",
+				Type:     "xpub",
+				XPub:     "xpub",
+				Encrypt:  true,
+				Password: "pwd",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - wallet is watch-only and has no secret keys",
+			options: wallet.Options{
+				Label:    "bar",
+				Type:     wallet.WalletTypeXPub,
+				XPub:     "xpub",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			gatewayCreateWalletErr: wallet.ErrWalletWatchOnly,
 		},
 		{
-			name:         "400 - Missing Seed",
-			method:       http.MethodPost,
-			contentType:  ContentTypeJSON,
-			status:       http.StatusBadRequest,
-			httpBody:     "{}",
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed is required"),
+			name:   "200 - OK - xpub",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label: "bar",
+				Type:  "xpub",
+				XPub:  "xpub",
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Label:    "bar",
+				Password: []byte{},
+				Type:     wallet.WalletTypeXPub,
+				XPub:     "xpub",
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename": "filename",
+					"type":     "xpub",
+					"xpub":     "xpub",
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+					Type:     "xpub",
+					XPub:     "xpub",
+				},
+			},
 		},
 		{
-			name:        "400 - Invalid seed",
-			method:      http.MethodPost,
-			contentType: ContentTypeJSON,
-			status:      http.StatusUnprocessableEntity,
-			httpBody: toJSON(t, VerifySeedRequest{
-				Seed: "bag attitude butter flock slab desk ship brain famous scheme clerk",
-			}),
-			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, bip39.ErrInvalidNumberOfWords.Error()),
+			name:   "200 - OK - addresses",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label:     "bar",
+				Type:      "addresses",
+				Addresses: addr.String(),
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Label:     "bar",
+				Password:  []byte{},
+				Type:      wallet.WalletTypeAddresses,
+				Addresses: []cipher.Addresser{addr},
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename": "filename",
+					"type":     "addresses",
+				},
+				Entries: []wallet.Entry{
+					{
+						Address: addr,
+					},
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+					Type:     "addresses",
+				},
+				Entries: []readable.WalletEntry{
+					{
+						Address: addr.String(),
+					},
+				},
+			},
 		},
 		{
-			name:   "200",
+			name:   "200 - OK - collection",
 			method: http.MethodPost,
-			status: http.StatusOK,
-			httpBody: toJSON(t, VerifySeedRequest{
-				Seed: "chief stadium sniff exhibit ostrich exit fruit noodle good lava coin supply",
-			}),
-			httpResponse: HTTPResponse{Data: struct{}{}},
+			body: &httpBody{
+				Label: "bar",
+				Type:  "collection",
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Label:    "bar",
+				Password: []byte{},
+				Type:     wallet.WalletTypeCollection,
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename": "filename",
+					"type":     "collection",
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+					Type:     "collection",
+				},
+			},
 		},
 		{
-			name:   "200 - csrf disabled",
+			name:   "400 - signer for collection wallet",
 			method: http.MethodPost,
-			status: http.StatusOK,
-			httpBody: toJSON(t, VerifySeedRequest{
-				Seed: "chief stadium sniff exhibit ostrich exit fruit noodle good lava coin supply",
-			}),
-			httpResponse: HTTPResponse{Data: struct{}{}},
-			csrfDisabled: true,
+			body: &httpBody{
+				Label:  "bar",
+				Type:   "collection",
+				Signer: "http://127.0.0.1:6480",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - signer is only valid for remote wallets",
+		},
+		{
+			name:   "400 - invalid public-keys",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label:      "bar",
+				Type:       "remote",
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: "foo",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - invalid public key \"foo\": Invalid public key",
+		},
+		{
+			name:   "400 - missing signer",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label: "bar",
+				Type:  "remote",
+			},
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing signer url",
+			options: wallet.Options{
+				Label:    "bar",
+				Password: []byte{},
+				Type:     wallet.WalletTypeRemote,
+			},
+			gatewayCreateWalletErr: wallet.ErrMissingSigner,
+		},
+		{
+			name:   "200 - OK - remote",
+			method: http.MethodPost,
+			body: &httpBody{
+				Label:      "bar",
+				Type:       "remote",
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: pubkey.Hex(),
+			},
+			status:  http.StatusOK,
+			wltName: "filename",
+			options: wallet.Options{
+				Label:      "bar",
+				Password:   []byte{},
+				Type:       wallet.WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pubkey},
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename": "filename",
+					"type":     "remote",
+					"signer":   "http://127.0.0.1:6480",
+				},
+				Entries: []wallet.Entry{
+					{
+						Address: cipher.AddressFromPubKey(pubkey),
+						Public:  pubkey,
+					},
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename: "filename",
+					Type:     "remote",
+					Signer:   "http://127.0.0.1:6480",
+				},
+				Entries: []readable.WalletEntry{
+					{
+						Address: cipher.AddressFromPubKey(pubkey).String(),
+						Public:  pubkey.Hex(),
+					},
+				},
+			},
+		},
+		{
+			name:   "200 - OK - bip44",
+			method: http.MethodPost,
+			body: &httpBody{
+				Seed:         "foo",
+				Label:        "bar",
+				Type:         "bip44",
+				Bip44Account: "1",
+			},
+			status:  http.StatusOK,
+			err:     "",
+			wltName: "filename",
+			options: wallet.Options{
+				Label:        "bar",
+				Seed:         "foo",
+				Password:     []byte{},
+				Type:         wallet.WalletTypeBip44,
+				Bip44Account: 1,
+			},
+			gatewayCreateWalletResult: wallet.Wallet{
+				Meta: map[string]string{
+					"filename":     "filename",
+					"type":         "bip44",
+					"bip44Coin":    "8000",
+					"bip44Account": "1",
+					"xpub":         "xpub",
+				},
+			},
+			responseBody: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:     "filename",
+					Type:         "bip44",
+					Bip44Coin:    &bip44Coin,
+					Bip44Account: &bip44Account,
+					XPub:         "xpub",
+				},
+			},
 		},
 	}
 
-	for _, tc := range cases {
+	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			endpoint := "/api/v2/wallet/seed/verify"
 			gateway := &MockGatewayer{}
+			if tc.options.ScanN == 0 && tc.options.Type != wallet.WalletTypeAddresses && tc.options.Type != wallet.WalletTypeCollection && tc.options.Type != wallet.WalletTypeRemote {
+				tc.options.ScanN = 1
+			}
+			gateway.On("CreateWallet", "", tc.options, gateway).Return(&tc.gatewayCreateWalletResult, tc.gatewayCreateWalletErr)
 
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
-			require.NoError(t, err)
+			endpoint := "/api/v1/wallet/create"
 
-			contentType := tc.contentType
-			if contentType == "" {
-				contentType = ContentTypeJSON
+			v := url.Values{}
+			if tc.body != nil {
+				if tc.body.Seed != "" {
+					v.Add("seed", tc.body.Seed)
+				}
+				if tc.body.Label != "" {
+					v.Add("label", tc.body.Label)
+				}
+				if tc.body.ScanN != "" {
+					v.Add("scan", tc.body.ScanN)
+				}
+
+				if tc.body.Encrypt {
+					v.Add("encrypt", strconv.FormatBool(tc.body.Encrypt))
+				}
+
+				if tc.body.Password != "" {
+					v.Add("password", tc.body.Password)
+				}
+
+				if tc.body.CryptoType != "" {
+					v.Add("crypto-type", tc.body.CryptoType)
+				}
+
+				if tc.body.Type != "" {
+					v.Add("type", tc.body.Type)
+				}
+
+				if tc.body.Bip44Account != "" {
+					v.Add("bip44-account", tc.body.Bip44Account)
+				}
+
+				if tc.body.SeedPassphrase != "" {
+					v.Add("seed-passphrase", tc.body.SeedPassphrase)
+				}
+
+				if tc.body.XPub != "" {
+					v.Add("xpub", tc.body.XPub)
+				}
+
+				if tc.body.Addresses != "" {
+					v.Add("addresses", tc.body.Addresses)
+				}
+
+				if tc.body.Signer != "" {
+					v.Add("signer", tc.body.Signer)
+				}
+
+				if tc.body.PublicKeys != "" {
+					v.Add("public-keys", tc.body.PublicKeys)
+				}
 			}
 
-			req.Header.Set("Content-Type", contentType)
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			req.Header.Add("Content-Type", ContentTypeForm)
+			require.NoError(t, err)
 
 			if tc.csrfDisabled {
 				setCSRFParameters(t, tokenInvalid, req)
@@ -1370,9 +1678,248 @@ func TestVerifySeed(t *testing.T) {
 			status := rr.Code
 			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
 
-			var rsp ReceivedHTTPResponse
-			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
-			require.NoError(t, err)
+			if status != http.StatusOK {
+				body := strings.TrimSpace(rr.Body.String())
+				require.Equal(t, tc.err, body, "got `%v`| %d, want `%v`", body, status, tc.err)
+				return
+			}
+
+			var msg WalletResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.Equal(t, tc.responseBody, msg, tc.name)
+		})
+	}
+}
+
+func TestWalletNewSeed(t *testing.T) {
+	type httpBody struct {
+		Entropy string
+	}
+	tt := []struct {
+		name      string
+		method    string
+		body      *httpBody
+		status    int
+		err       string
+		entropy   string
+		resultLen int
+	}{
+		{
+			name:   "405",
+			method: http.MethodPut,
+			status: http.StatusMethodNotAllowed,
+			err:    "405 Method Not Allowed",
+		},
+		{
+			name:   "400 - invalid entropy type",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "xx",
+			},
+			status:  http.StatusBadRequest,
+			err:     "400 Bad Request - invalid entropy",
+			entropy: "xx",
+		},
+		{
+			name:   "400 - `wrong entropy length` error",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "200",
+			},
+			status:  http.StatusBadRequest,
+			err:     "400 Bad Request - entropy length must be 128 or 256",
+			entropy: "200",
+		},
+		{
+			name:      "200 - OK with no entropy",
+			method:    http.MethodGet,
+			body:      &httpBody{},
+			status:    http.StatusOK,
+			entropy:   "128",
+			resultLen: 12,
+		},
+		{
+			name:   "200 - OK | 12 word seed",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "128",
+			},
+			status:    http.StatusOK,
+			entropy:   "128",
+			resultLen: 12,
+		},
+		{
+			name:   "200 - OK | 24 word seed",
+			method: http.MethodGet,
+			body: &httpBody{
+				Entropy: "256",
+			},
+			status:    http.StatusOK,
+			entropy:   "256",
+			resultLen: 24,
+		},
+	}
+
+	// Loop over each test case
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			endpoint := "/api/v1/wallet/newSeed"
+
+			// Add request parameters to url
+			v := url.Values{}
+			if tc.body != nil {
+				if tc.body.Entropy != "" {
+					v.Add("entropy", tc.body.Entropy)
+				}
+			}
+			if len(v) > 0 {
+				endpoint += "?" + v.Encode()
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` expected `%v`", tc.name, status, tc.status)
+			if status != tc.status {
+				t.Errorf("got `%v` want `%v`", status, tc.status)
+			}
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, expected `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			} else {
+				var msg struct {
+					Seed string `json:"seed"`
+				}
+				err = json.Unmarshal(rr.Body.Bytes(), &msg)
+				require.NoError(t, err)
+				// check that expected length is equal to response length
+				require.Equal(t, tc.resultLen, len(strings.Fields(msg.Seed)), tc.name)
+			}
+		})
+	}
+}
+
+func TestVerifySeed(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		contentType  string
+		csrfDisabled bool
+		httpBody     string
+		httpResponse HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+
+		{
+			name:         "415 - Unsupported Media Type",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+
+		{
+			name:         "400 - EOF",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:         "400 - Missing Seed",
+			method:       http.MethodPost,
+			contentType:  ContentTypeJSON,
+			status:       http.StatusBadRequest,
+			httpBody:     "{}",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed is required"),
+		},
+		{
+			name:        "400 - Invalid seed",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusUnprocessableEntity,
+			httpBody: toJSON(t, VerifySeedRequest{
+				Seed: "bag attitude butter flock slab desk ship brain famous scheme clerk",
+			}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnprocessableEntity, bip39.ErrInvalidNumberOfWords.Error()),
+		},
+		{
+			name:   "200",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, VerifySeedRequest{
+				Seed: "chief stadium sniff exhibit ostrich exit fruit noodle good lava coin supply",
+			}),
+			httpResponse: HTTPResponse{Data: struct{}{}},
+		},
+		{
+			name:   "200 - csrf disabled",
+			method: http.MethodPost,
+			status: http.StatusOK,
+			httpBody: toJSON(t, VerifySeedRequest{
+				Seed: "chief stadium sniff exhibit ostrich exit fruit noodle good lava coin supply",
+			}),
+			httpResponse: HTTPResponse{Data: struct{}{}},
+			csrfDisabled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint := "/api/v2/wallet/seed/verify"
+			gateway := &MockGatewayer{}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			req.Header.Set("Content-Type", contentType)
+
+			if tc.csrfDisabled {
+				setCSRFParameters(t, tokenInvalid, req)
+			} else {
+				setCSRFParameters(t, tokenValid, req)
+			}
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = tc.csrfDisabled
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
 
 			require.Equal(t, tc.httpResponse.Error, rsp.Error)
 
@@ -1542,21 +2089,197 @@ func TestGetWalletSeed(t *testing.T) {
 	}
 }
 
-func TestWalletNewAddressesHandler(t *testing.T) {
-	type httpBody struct {
-		ID       string
-		Num      string
-		Password string
-	}
-	type Addresses struct {
-		Address []string `json:"addresses"`
-	}
-
-	var responseAddresses = Addresses{}
-	var responseEmptyAddresses = Addresses{}
+func TestWalletSplitSeed(t *testing.T) {
+	shares := []string{"foo", "bar", "baz"}
 
-	var emptyAddrs = make([]cipher.Address, 0)
-	var addrs = make([]cipher.Address, 3)
+	cases := []struct {
+		name          string
+		method        string
+		contentType   string
+		status        int
+		req           *WalletSplitSeedRequest
+		httpBody      string
+		gatewayResult []string
+		gatewayErr    error
+		httpResponse  HTTPResponse
+	}{
+		{
+			name:         "405",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, ""),
+		},
+		{
+			name:         "415",
+			method:       http.MethodPost,
+			contentType:  ContentTypeForm,
+			status:       http.StatusUnsupportedMediaType,
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, ""),
+		},
+		{
+			name:        "400 - missing id",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			req: &WalletSplitSeedRequest{
+				Threshold: 2,
+				Shares:    3,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "400 - missing threshold",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			req: &WalletSplitSeedRequest{
+				ID:     "foo.wlt",
+				Shares: 3,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "threshold is required"),
+		},
+		{
+			name:        "400 - missing shares",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Threshold: 2,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "shares is required"),
+		},
+		{
+			name:        "400 - invalid threshold",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 4,
+				Shares:    3,
+			},
+			gatewayErr:   wallet.NewError(shamir.ErrInvalidThreshold),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, shamir.ErrInvalidThreshold.Error()),
+		},
+		{
+			name:        "400 - invalid password",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusBadRequest,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayErr:   wallet.ErrInvalidPassword,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:        "403 - seed api disabled",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusForbidden,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayErr:   wallet.ErrSeedAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "404 - wallet not found",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusNotFound,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, ""),
+		},
+		{
+			name:        "200",
+			method:      http.MethodPost,
+			contentType: ContentTypeJSON,
+			status:      http.StatusOK,
+			req: &WalletSplitSeedRequest{
+				ID:        "foo.wlt",
+				Password:  "pwd",
+				Threshold: 2,
+				Shares:    3,
+			},
+			gatewayResult: shares,
+			httpResponse: HTTPResponse{
+				Data: WalletSplitSeedResponse{
+					Threshold: 2,
+					Shares:    shares,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+
+			body := tc.httpBody
+			if tc.req != nil {
+				body = toJSON(t, tc.req)
+				gateway.On("SplitWalletSeed", tc.req.ID, []byte(tc.req.Password), tc.req.Threshold, tc.req.Shares).Return(tc.gatewayResult, tc.gatewayErr)
+			}
+
+			req, err := http.NewRequest(tc.method, "/api/v2/wallet/seed/split", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var rsp ReceivedHTTPResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if tc.httpResponse.Data != nil {
+				var data WalletSplitSeedResponse
+				require.NoError(t, json.Unmarshal(rsp.Data, &data))
+				require.Equal(t, tc.httpResponse.Data, data)
+			}
+		})
+	}
+}
+
+func TestWalletNewAddressesHandler(t *testing.T) {
+	type httpBody struct {
+		ID       string
+		Num      string
+		Password string
+	}
+	type Addresses struct {
+		Address []string `json:"addresses"`
+	}
+
+	var responseAddresses = Addresses{}
+	var responseEmptyAddresses = Addresses{}
+
+	var emptyAddrs = make([]cipher.Address, 0)
+	var addrs = make([]cipher.Address, 3)
 
 	for i := 0; i < 3; i++ {
 		pub, _ := cipher.MustGenerateDeterministicKeyPair(cipher.RandByte(32))
@@ -1995,276 +2718,2964 @@ func TestGetWallets(t *testing.T) {
 						},
 					},
 				},
-				{
-					Meta: readable.WalletMeta{
-						Coin:       "foocoin",
-						Filename:   "foofilename",
-						Label:      "foolabel",
-						Type:       "footype",
-						Version:    "fooversion",
-						CryptoType: "foocryptotype",
-						Timestamp:  345678,
-						Encrypted:  true,
-					},
-					Entries: []readable.WalletEntry{
-						{
-							Address: addrs[0].String(),
-							Public:  pubkeys[0].Hex(),
-						},
-					},
+				{
+					Meta: readable.WalletMeta{
+						Coin:       "foocoin",
+						Filename:   "foofilename",
+						Label:      "foolabel",
+						Type:       "footype",
+						Version:    "fooversion",
+						CryptoType: "foocryptotype",
+						Timestamp:  345678,
+						Encrypted:  true,
+					},
+					Entries: []readable.WalletEntry{
+						{
+							Address: addrs[0].String(),
+							Public:  pubkeys[0].Hex(),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		gateway := &MockGatewayer{}
+		gateway.On("GetWallets").Return(tc.getWalletsResponse, tc.getWalletsErr)
+
+		endpoint := "/api/v1/wallets"
+
+		req, err := http.NewRequest(tc.method, endpoint, nil)
+		require.NoError(t, err)
+
+		setCSRFParameters(t, tokenValid, req)
+
+		rr := httptest.NewRecorder()
+
+		cfg := defaultMuxConfig()
+		cfg.disableCSRF = false
+
+		handler := newServerMux(cfg, gateway)
+
+		handler.ServeHTTP(rr, req)
+
+		status := rr.Code
+		require.Equal(t, tc.status, status, "got `%v` want `%v`",
+			tc.name, status, tc.status)
+
+		if status != http.StatusOK {
+			require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+				strings.TrimSpace(rr.Body.String()), status, tc.err)
+		} else {
+			var msg []*WalletResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &msg)
+			require.NoError(t, err)
+			require.NotNil(t, msg)
+			require.Equal(t, tc.httpResponse, msg, tc.name)
+		}
+	}
+}
+
+func TestWalletUnloadHandler(t *testing.T) {
+	tt := []struct {
+		name            string
+		method          string
+		status          int
+		err             string
+		walletID        string
+		unloadWalletErr error
+		csrfDisabled    bool
+	}{
+		{
+			name:     "405",
+			method:   http.MethodGet,
+			status:   http.StatusMethodNotAllowed,
+			err:      "405 Method Not Allowed",
+			walletID: "wallet.wlt",
+		},
+		{
+			name:   "400 - missing wallet id",
+			method: http.MethodPost,
+			status: http.StatusBadRequest,
+			err:    "400 Bad Request - missing wallet id",
+		},
+		{
+			name:            "403 - Forbidden - wallet API disabled",
+			method:          http.MethodPost,
+			status:          http.StatusForbidden,
+			err:             "403 Forbidden",
+			walletID:        "wallet.wlt",
+			unloadWalletErr: wallet.ErrWalletAPIDisabled,
+		},
+		{
+			name:     "200 - ok",
+			method:   http.MethodPost,
+			status:   http.StatusOK,
+			walletID: "wallet.wlt",
+		},
+		{
+			name:         "200 - ok, csrf disabled",
+			method:       http.MethodPost,
+			status:       http.StatusOK,
+			walletID:     "wallet.wlt",
+			csrfDisabled: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("UnloadWallet", tc.walletID).Return(tc.unloadWalletErr)
+
+			endpoint := "/api/v1/wallet/unload"
+			v := url.Values{}
+			v.Add("id", tc.walletID)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			if tc.csrfDisabled {
+				setCSRFParameters(t, tokenInvalid, req)
+			} else {
+				setCSRFParameters(t, tokenValid, req)
+			}
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = tc.csrfDisabled
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
+					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			}
+		})
+	}
+}
+
+func TestEncryptWallet(t *testing.T) {
+	entries, responseEntries := makeEntries([]byte("seed"), 5)
+	type gatewayReturnPair struct {
+		w   *wallet.Wallet
+		err error
+	}
+	tt := []struct {
+		name          string
+		method        string
+		wltID         string
+		password      string
+		cryptoType    string
+		gatewayReturn gatewayReturnPair
+		status        int
+		expectWallet  WalletResponse
+		expectErr     string
+	}{
+		{
+			name:     "200 - OK",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				w: &wallet.Wallet{
+					Meta: map[string]string{
+						"filename":  "wallet.wlt",
+						"seed":      "seed",
+						"lastSeed":  "lastSeed",
+						"secrets":   "secrets",
+						"encrypted": "true",
+					},
+					Entries: cloneEntries(entries),
+				},
+			},
+			status: http.StatusOK,
+			expectWallet: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:  "wallet.wlt",
+					Encrypted: true,
+				},
+				Entries: responseEntries,
+			},
+		},
+		{
+			name:       "200 - OK - crypto type",
+			method:     http.MethodPost,
+			wltID:      "wallet.wlt",
+			password:   "pwd",
+			cryptoType: string(wallet.CryptoTypeArgon2idChacha20poly1305),
+			gatewayReturn: gatewayReturnPair{
+				w: &wallet.Wallet{
+					Meta: map[string]string{
+						"filename":     "wallet.wlt",
+						"encrypted":    "true",
+						"cryptoType":   string(wallet.CryptoTypeArgon2idChacha20poly1305),
+						"argon2Time":   "1",
+						"argon2Memory": "65536",
+						"secrets":      "secrets",
+					},
+					Entries: cloneEntries(entries),
+				},
+			},
+			status: http.StatusOK,
+			expectWallet: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:     "wallet.wlt",
+					Encrypted:    true,
+					CryptoType:   string(wallet.CryptoTypeArgon2idChacha20poly1305),
+					Argon2Time:   1,
+					Argon2Memory: 65536,
+				},
+				Entries: responseEntries,
+			},
+		},
+		{
+			name:       "400 - Invalid Crypto Type",
+			method:     http.MethodPost,
+			wltID:      "wallet.wlt",
+			password:   "pwd",
+			cryptoType: "foo",
+			status:     http.StatusBadRequest,
+			expectErr:  "400 Bad Request - unknown crypto type",
+		},
+		{
+			name:     "403 Forbidden",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			status:    http.StatusForbidden,
+			expectErr: "403 Forbidden",
+		},
+		{
+			name:      "405 Method Not Allowed",
+			method:    http.MethodGet,
+			wltID:     "wallet.wlt",
+			password:  "pwd",
+			status:    http.StatusMethodNotAllowed,
+			expectErr: "405 Method Not Allowed",
+		},
+		{
+			name:     "400 - Missing Password",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrMissingPassword,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - missing password",
+		},
+		{
+			name:      "400 - Missing Wallet Id",
+			method:    http.MethodPost,
+			wltID:     "",
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - missing wallet id",
+		},
+		{
+			name:     "400 Bad Request - Invalid Password",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - invalid password",
+		},
+		{
+			name:     "404 - Wallet Not Found",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			status:    http.StatusNotFound,
+			expectErr: "404 Not Found",
+		},
+		{
+			name:     "400 - Wallet Is Encrypted",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletEncrypted,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - wallet is encrypted",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("EncryptWallet", tc.wltID, []byte(tc.password), wallet.CryptoType(tc.cryptoType)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+
+			endpoint := "/api/v1/wallet/encrypt"
+			v := url.Values{}
+			v.Add("id", tc.wltID)
+			v.Add("password", tc.password)
+			if tc.cryptoType != "" {
+				v.Add("crypto-type", tc.cryptoType)
+			}
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`, body: %v", status, tc.status, rr.Body.String())
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.expectErr, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var rsp WalletResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectWallet, rsp)
+		})
+	}
+}
+
+func TestDecryptWallet(t *testing.T) {
+	entries, responseEntries := makeEntries([]byte("seed"), 5)
+	type gatewayReturnPair struct {
+		w   *wallet.Wallet
+		err error
+	}
+
+	tt := []struct {
+		name          string
+		method        string
+		wltID         string
+		password      string
+		gatewayReturn gatewayReturnPair
+		status        int
+		expectWallet  WalletResponse
+		expectErr     string
+		csrfDisabled  bool
+	}{
+		{
+			name:     "200 OK",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				w: &wallet.Wallet{
+					Meta: map[string]string{
+						"filename":  "wallet",
+						"seed":      "seed",
+						"lastSeed":  "lastSeed",
+						"secrets":   "",
+						"encrypted": "false",
+					},
+					Entries: cloneEntries(entries),
+				},
+			},
+			status: http.StatusOK,
+			expectWallet: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:  "wallet",
+					Encrypted: false,
+				},
+				Entries: responseEntries,
+			},
+		},
+		{
+			name:     "200 OK CSRF disabled",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				w: &wallet.Wallet{
+					Meta: map[string]string{
+						"filename":  "wallet",
+						"seed":      "seed",
+						"lastSeed":  "lastSeed",
+						"secrets":   "",
+						"encrypted": "false",
+					},
+					Entries: cloneEntries(entries),
+				},
+			},
+			status: http.StatusOK,
+			expectWallet: WalletResponse{
+				Meta: readable.WalletMeta{
+					Filename:  "wallet",
+					Encrypted: false,
+				},
+				Entries: responseEntries,
+			},
+			csrfDisabled: true,
+		},
+
+		{
+			name:     "403 Forbidden",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			status:    http.StatusForbidden,
+			expectErr: "403 Forbidden",
+		},
+		{
+			name:      "405 Method Not Allowed",
+			method:    http.MethodGet,
+			status:    http.StatusMethodNotAllowed,
+			expectErr: "405 Method Not Allowed",
+		},
+		{
+			name:      "400 - Missing Wallet ID",
+			method:    http.MethodPost,
+			wltID:     "",
+			password:  "",
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - missing wallet id",
+		},
+		{
+			name:     "400 - Missing Password",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrMissingPassword,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - missing password",
+		},
+		{
+			name:     "400 - Wallet IS Not Encrypted",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotEncrypted,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - wallet is not encrypted",
+		},
+		{
+			name:     "400 Bad Request - Invalid Password",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			status:    http.StatusBadRequest,
+			expectErr: "400 Bad Request - invalid password",
+		},
+		{
+			name:     "404 - Wallet Does Not Exist",
+			method:   http.MethodPost,
+			wltID:    "wallet.wlt",
+			password: "pwd",
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			status:    http.StatusNotFound,
+			expectErr: "404 Not Found",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			gateway.On("DecryptWallet", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+
+			endpoint := "/api/v1/wallet/decrypt"
+			v := url.Values{}
+			v.Add("id", tc.wltID)
+			v.Add("password", tc.password)
+
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			require.NoError(t, err)
+			req.Header.Add("Content-Type", ContentTypeForm)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
+
+			if status != http.StatusOK {
+				require.Equal(t, tc.expectErr, strings.TrimSpace(rr.Body.String()))
+				return
+			}
+
+			var rsp WalletResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectWallet, rsp)
+		})
+	}
+}
+
+// makeEntries derives N wallet address entries from given seed
+// Returns set of wallet.Entry and wallet.ReadableEntry, the readable
+// entries' secrets are removed.
+func makeEntries(seed []byte, n int) ([]wallet.Entry, []readable.WalletEntry) { // nolint: unparam
+	seckeys := cipher.MustGenerateDeterministicKeyPairs(seed, n)
+	var entries []wallet.Entry
+	var responseEntries []readable.WalletEntry
+	for i, seckey := range seckeys {
+		pubkey := cipher.MustPubKeyFromSecKey(seckey)
+		entries = append(entries, wallet.Entry{
+			Address: cipher.AddressFromPubKey(pubkey),
+			Public:  pubkey,
+			Secret:  seckey,
+		})
+		responseEntries = append(responseEntries, readable.WalletEntry{
+			Address: entries[i].Address.String(),
+			Public:  entries[i].Public.Hex(),
+		})
+	}
+	return entries, responseEntries
+}
+
+func cloneEntries(es []wallet.Entry) []wallet.Entry {
+	var entries []wallet.Entry
+	entries = append(entries, es...)
+	return entries
+}
+
+func TestWalletRecover(t *testing.T) {
+	type gatewayReturnPair struct {
+		w   *wallet.Wallet
+		err error
+	}
+
+	okWalletUnencrypted, err := wallet.NewWallet("foo", wallet.Options{
+		Coin:      wallet.CoinTypeMDL,
+		Label:     "foolabel",
+		Seed:      "fooseed",
+		GenerateN: 10,
+	})
+	require.NoError(t, err)
+	okWalletUnencryptedResponse, err := NewWalletResponse(okWalletUnencrypted)
+	require.NoError(t, err)
+
+	okWalletEncrypted, err := wallet.NewWallet("foo", wallet.Options{
+		Coin:       wallet.CoinTypeMDL,
+		Label:      "foolabel",
+		Seed:       "fooseed",
+		Encrypt:    true,
+		Password:   []byte("foopassword"),
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		GenerateN:  10,
+	})
+	require.NoError(t, err)
+	okWalletEncryptedResponse, err := NewWalletResponse(okWalletEncrypted)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletRecoverRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletRecoverRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletRecoverRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:         "empty json body",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			httpBody:     "",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+		},
+		{
+			name:        "id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				Seed: "fooseed",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "seed missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed is required"),
+		},
+		{
+			name:        "wallet not encrypted",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotEncrypted,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotEncrypted.Error()),
+		},
+		{
+			name:        "wallet seed wrong",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletRecoverSeedWrong,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletRecoverSeedWrong.Error()),
+		},
+		{
+			name:        "seed passphrase without password",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:             "foo",
+				Seed:           "fooseed",
+				SeedPassphrase: "foopassphrase",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrSeedPassphraseNotEncrypted,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrSeedPassphraseNotEncrypted.Error()),
+		},
+		{
+			name:        "seed passphrase not allowed",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:             "foo",
+				Seed:           "fooseed",
+				SeedPassphrase: "foopassphrase",
+				Password:       "foopassword",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrSeedPassphraseNotAllowed,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrSeedPassphraseNotAllowed.Error()),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "wallet other error",
+			method:      http.MethodPost,
+			status:      http.StatusInternalServerError,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: errors.New("wallet error"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+		},
+		{
+			name:        "ok, no password",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:   "foo",
+				Seed: "fooseed",
+			},
+			gatewayReturn: gatewayReturnPair{
+				w: okWalletUnencrypted,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletUnencryptedResponse,
+			},
+		},
+		{
+			name:        "ok, password",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:       "foo",
+				Seed:     "fooseed",
+				Password: "foopassword",
+			},
+			gatewayReturn: gatewayReturnPair{
+				w: okWalletEncrypted,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletEncryptedResponse,
+			},
+		},
+		{
+			name:        "seed and seed shares",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:         "foo",
+				Seed:       "fooseed",
+				SeedShares: []string{"foo", "bar"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed and seed_shares cannot be combined"),
+		},
+		{
+			name:        "seed shares invalid",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:         "foo",
+				SeedShares: []string{"foo"},
+				Password:   "foopassword",
+			},
+			gatewayReturn: gatewayReturnPair{
+				err: wallet.NewError(shamir.ErrNotEnoughShares),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, shamir.ErrNotEnoughShares.Error()),
+		},
+		{
+			name:        "ok, seed shares",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRecoverRequest{
+				ID:         "foo",
+				SeedShares: []string{"foo", "bar"},
+				Password:   "foopassword",
+			},
+			gatewayReturn: gatewayReturnPair{
+				w: okWalletEncrypted,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletEncryptedResponse,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.req != nil {
+				var password []byte
+				if tc.req.Password != "" {
+					password = []byte(tc.req.Password)
+				}
+				gateway.On("RecoverWallet", tc.req.ID, tc.req.Seed, tc.req.SeedPassphrase, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+				gateway.On("RecoverWalletFromSeedShares", tc.req.ID, tc.req.SeedShares, tc.req.SeedPassphrase, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/recover"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			contentType := tc.contentType
+			if contentType == "" {
+				contentType = ContentTypeJSON
+			}
+
+			req.Header.Set("Content-Type", contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var wltRsp WalletResponse
+				err := json.Unmarshal(rsp.Data, &wltRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+			}
+		})
+	}
+}
+
+func TestWalletImportKeys(t *testing.T) {
+	type gatewayReturnPair struct {
+		addrs []cipher.Address
+		err   error
+	}
+
+	_, sk := cipher.GenerateKeyPair()
+	addr := cipher.MustAddressFromSecKey(sk)
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletImportKeysRequest
+		keys          []cipher.SecKey
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletImportKeysRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletImportKeysRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				Keys: []string{sk.Hex()},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "keys missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "keys is required"),
+		},
+		{
+			name:        "invalid key",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:   "foo",
+				Keys: []string{"foo"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidSecretKey.Error()),
+		},
+		{
+			name:        "wallet not collection",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:   "foo",
+				Keys: []string{sk.Hex()},
+			},
+			keys: []cipher.SecKey{sk},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotCollection,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotCollection.Error()),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:   "foo",
+				Keys: []string{sk.Hex()},
+			},
+			keys: []cipher.SecKey{sk},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:   "foo",
+				Keys: []string{sk.Hex()},
+			},
+			keys: []cipher.SecKey{sk},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "wallet other error",
+			method:      http.MethodPost,
+			status:      http.StatusInternalServerError,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:   "foo",
+				Keys: []string{sk.Hex()},
+			},
+			keys: []cipher.SecKey{sk},
+			gatewayReturn: &gatewayReturnPair{
+				err: errors.New("wallet error"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+		},
+		{
+			name:        "ok, wif key with password",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletImportKeysRequest{
+				ID:       "foo",
+				Keys:     []string{cipher.BitcoinWalletImportFormatFromSeckey(sk)},
+				Password: "pwd",
+			},
+			keys: []cipher.SecKey{sk},
+			gatewayReturn: &gatewayReturnPair{
+				addrs: []cipher.Address{addr},
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletImportKeysResponse{
+					Addresses: []string{addr.String()},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				var password []byte
+				if tc.req.Password != "" {
+					password = []byte(tc.req.Password)
+				}
+				gateway.On("ImportSecretKeys", tc.req.ID, password, tc.keys).Return(tc.gatewayReturn.addrs, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/keys/import"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var importRsp WalletImportKeysResponse
+				err := json.Unmarshal(rsp.Data, &importRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletImportKeysResponse), importRsp)
+			}
+		})
+	}
+}
+
+func TestWalletRemoveAddresses(t *testing.T) {
+	_, sk := cipher.GenerateKeyPair()
+	addr := cipher.MustAddressFromSecKey(sk)
+
+	okWallet, err := wallet.NewWallet("foo", wallet.Options{
+		Type:  wallet.WalletTypeCollection,
+		Label: "foolabel",
+	})
+	require.NoError(t, err)
+	okWalletResponse, err := NewWalletResponse(okWallet)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		contentType  string
+		req          *WalletRemoveAddressesRequest
+		httpBody     string
+		httpResponse HTTPResponse
+		removeErr    error
+		callRemove   bool
+		getWallet    *wallet.Wallet
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletRemoveAddressesRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletRemoveAddressesRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				Addresses: []string{addr.String()},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "addresses missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				ID: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "addresses is required"),
+		},
+		{
+			name:        "invalid address",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				ID:        "foo",
+				Addresses: []string{"foo"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid address "foo": Invalid address length`),
+		},
+		{
+			name:        "wallet not collection",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				ID:        "foo",
+				Addresses: []string{addr.String()},
+			},
+			callRemove:   true,
+			removeErr:    wallet.ErrWalletNotCollection,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotCollection.Error()),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				ID:        "foo",
+				Addresses: []string{addr.String()},
+			},
+			callRemove:   true,
+			removeErr:    wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRemoveAddressesRequest{
+				ID:        "foo",
+				Addresses: []string{addr.String()},
+				Password:  "pwd",
+			},
+			callRemove: true,
+			getWallet:  okWallet,
+			httpResponse: HTTPResponse{
+				Data: *okWalletResponse,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.callRemove {
+				var password []byte
+				if tc.req.Password != "" {
+					password = []byte(tc.req.Password)
+				}
+				gateway.On("RemoveAddresses", tc.req.ID, password, []cipher.Address{addr}).Return(tc.removeErr)
+			}
+			if tc.getWallet != nil {
+				gateway.On("GetWallet", tc.req.ID).Return(tc.getWallet, nil)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/keys/remove"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var wltRsp WalletResponse
+				err := json.Unmarshal(rsp.Data, &wltRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+			}
+		})
+	}
+}
+
+func TestWalletPassword(t *testing.T) {
+	type gatewayReturnPair struct {
+		w   *wallet.Wallet
+		err error
+	}
+
+	okWallet, err := wallet.NewWallet("foo", wallet.Options{
+		Coin:       wallet.CoinTypeMDL,
+		Label:      "foolabel",
+		Seed:       "fooseed",
+		Encrypt:    true,
+		Password:   []byte("newpassword"),
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+	})
+	require.NoError(t, err)
+	okWalletResponse, err := NewWalletResponse(okWallet)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletPasswordRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		cryptoType    wallet.CryptoType
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletPasswordRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletPasswordRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "password missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				NewPassword: "newpassword",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "password is required"),
+		},
+		{
+			name:        "new password and crypto type missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:       "foo",
+				Password: "password",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "new_password or crypto_type is required"),
+		},
+		{
+			name:        "invalid crypto type",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:         "foo",
+				Password:   "password",
+				CryptoType: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "unknown crypto type"),
+		},
+		{
+			name:        "invalid password",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidPassword.Error()),
+		},
+		{
+			name:        "wallet not encrypted",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotEncrypted,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotEncrypted.Error()),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "wallet other error",
+			method:      http.MethodPost,
+			status:      http.StatusInternalServerError,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: errors.New("wallet error"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+		},
+		{
+			name:        "ok, change password",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				w: okWallet,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletResponse,
+			},
+		},
+		{
+			name:        "ok, change password and crypto type",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:          "foo",
+				Password:    "password",
+				NewPassword: "newpassword",
+				CryptoType:  "scrypt-chacha20poly1305-insecure",
+			},
+			cryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+			gatewayReturn: &gatewayReturnPair{
+				w: okWallet,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletResponse,
+			},
+		},
+		{
+			name:        "ok, migrate crypto type",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletPasswordRequest{
+				ID:         "foo",
+				Password:   "newpassword",
+				CryptoType: "scrypt-chacha20poly1305-insecure",
+			},
+			cryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
+			gatewayReturn: &gatewayReturnPair{
+				w: okWallet,
+			},
+			httpResponse: HTTPResponse{
+				Data: *okWalletResponse,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				if tc.req.NewPassword == "" {
+					gateway.On("MigrateCryptoType", tc.req.ID, []byte(tc.req.Password), tc.cryptoType).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+				} else {
+					gateway.On("ChangePassword", tc.req.ID, []byte(tc.req.Password), []byte(tc.req.NewPassword), tc.cryptoType).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+				}
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/password"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var wltRsp WalletResponse
+				err := json.Unmarshal(rsp.Data, &wltRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+			}
+		})
+	}
+}
+
+func TestWalletBackup(t *testing.T) {
+	type gatewayReturnPair struct {
+		archive *wallet.BackupArchive
+		err     error
+	}
+
+	archive := &wallet.BackupArchive{
+		Version:    "0.1",
+		Timestamp:  1234,
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305,
+		Checksum:   "checksum",
+		Data:       "data",
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletBackupRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletBackupRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletBackupRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "password missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletBackupRequest{
+				ID: "foo",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "password is required"),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletBackupRequest{
+				ID:       "foo",
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "no wallets",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletBackupRequest{
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrBackupEmpty,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrBackupEmpty.Error()),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletBackupRequest{
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletBackupRequest{
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				archive: archive,
+			},
+			httpResponse: HTTPResponse{
+				Data: *archive,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("Backup", tc.req.ID, []byte(tc.req.Password)).Return(tc.gatewayReturn.archive, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/backup"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var archiveRsp wallet.BackupArchive
+				err := json.Unmarshal(rsp.Data, &archiveRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(wallet.BackupArchive), archiveRsp)
+			}
+		})
+	}
+}
+
+func TestWalletRestore(t *testing.T) {
+	type gatewayReturnPair struct {
+		wlts []*wallet.Wallet
+		err  error
+	}
+
+	archive := &wallet.BackupArchive{
+		Version:    "0.1",
+		Timestamp:  1234,
+		CryptoType: wallet.CryptoTypeScryptChacha20poly1305,
+		Checksum:   "checksum",
+		Data:       "data",
+	}
+
+	wlt, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Coin:  wallet.CoinTypeMDL,
+		Label: "foolabel",
+		Seed:  "fooseed",
+	})
+	require.NoError(t, err)
+	wltResponse, err := NewWalletResponse(wlt)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletRestoreRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletRestoreRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletRestoreRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "archive missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Password: "pwd",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "archive is required"),
+		},
+		{
+			name:        "password missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Archive: archive,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "password is required"),
+		},
+		{
+			name:        "checksum mismatch",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Archive:  archive,
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrBackupChecksumMismatch,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrBackupChecksumMismatch.Error()),
+		},
+		{
+			name:        "wallet exists",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Archive:  archive,
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrBackupWalletExists,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrBackupWalletExists.Error()),
+		},
+		{
+			name:        "wallet other error",
+			method:      http.MethodPost,
+			status:      http.StatusInternalServerError,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Archive:  archive,
+				Password: "pwd",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: errors.New("wallet error"),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+		},
+		{
+			name:        "ok, overwrite",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRestoreRequest{
+				Archive:   archive,
+				Password:  "pwd",
+				Overwrite: true,
+			},
+			gatewayReturn: &gatewayReturnPair{
+				wlts: []*wallet.Wallet{wlt},
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletRestoreResponse{
+					Wallets: []WalletResponse{*wltResponse},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("Restore", tc.req.Archive, []byte(tc.req.Password), tc.req.Overwrite).Return(tc.gatewayReturn.wlts, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/restore"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var restoreRsp WalletRestoreResponse
+				err := json.Unmarshal(rsp.Data, &restoreRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletRestoreResponse), restoreRsp)
+			}
+		})
+	}
+}
+
+func TestWalletArchive(t *testing.T) {
+	type gatewayReturnPair struct {
+		filename string
+		err      error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletArchiveRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletArchiveRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletArchiveRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:         "id missing",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			req:          &WalletArchiveRequest{},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletArchiveRequest{
+				ID: "foo.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletArchiveRequest{
+				ID: "foo.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletArchiveRequest{
+				ID: "foo.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				filename: "/wallets/archive/foo.wlt",
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletArchiveResponse{
+					Filename: "/wallets/archive/foo.wlt",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("ArchiveWallet", tc.req.ID).Return(tc.gatewayReturn.filename, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/archive"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var archiveRsp WalletArchiveResponse
+				err := json.Unmarshal(rsp.Data, &archiveRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletArchiveResponse), archiveRsp)
+			}
+		})
+	}
+}
+
+func TestWalletDelete(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		status       int
+		contentType  string
+		req          *WalletDeleteRequest
+		httpBody     string
+		httpResponse HTTPResponse
+		gatewayErr   error
+		callGateway  bool
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletDeleteRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletDeleteRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:         "id missing",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			req:          &WalletDeleteRequest{},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletDeleteRequest{
+				ID: "foo.wlt",
+			},
+			callGateway:  true,
+			gatewayErr:   wallet.ErrWalletNotExist,
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "missing password",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletDeleteRequest{
+				ID: "foo.wlt",
+			},
+			callGateway:  true,
+			gatewayErr:   wallet.ErrMissingPassword,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "missing password"),
+		},
+		{
+			name:        "invalid password",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletDeleteRequest{
+				ID:       "foo.wlt",
+				Password: "wrong",
+			},
+			callGateway:  true,
+			gatewayErr:   wallet.ErrInvalidPassword,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletDeleteRequest{
+				ID: "foo.wlt",
+			},
+			callGateway:  true,
+			gatewayErr:   wallet.ErrWalletAPIDisabled,
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletDeleteRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+			},
+			callGateway: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.callGateway {
+				var password []byte
+				if tc.req.Password != "" {
+					password = []byte(tc.req.Password)
+				}
+				gateway.On("DeleteWallet", tc.req.ID, password).Return(tc.gatewayErr)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/delete"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+			require.Nil(t, rsp.Data)
+		})
+	}
+}
+
+func TestWalletRename(t *testing.T) {
+	type gatewayReturnPair struct {
+		wlt *wallet.Wallet
+		err error
+	}
+
+	wlt, err := wallet.NewWallet("bar.wlt", wallet.Options{
+		Coin:  wallet.CoinTypeMDL,
+		Label: "foolabel",
+		Seed:  "fooseed",
+	})
+	require.NoError(t, err)
+	wltResponse, err := NewWalletResponse(wlt)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletRenameRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletRenameRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletRenameRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:        "id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				NewID: "bar.wlt",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "new_id missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID: "foo.wlt",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "new_id is required"),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID:    "foo.wlt",
+				NewID: "bar.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "invalid filename",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID:    "foo.wlt",
+				NewID: "../bar.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrInvalidWalletFilename,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrInvalidWalletFilename.Error()),
+		},
+		{
+			name:        "wallet file exists",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID:    "foo.wlt",
+				NewID: "bar.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletFileExists,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletFileExists.Error()),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID:    "foo.wlt",
+				NewID: "bar.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletRenameRequest{
+				ID:    "foo.wlt",
+				NewID: "bar.wlt",
+			},
+			gatewayReturn: &gatewayReturnPair{
+				wlt: wlt,
+			},
+			httpResponse: HTTPResponse{
+				Data: *wltResponse,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("RenameWallet", tc.req.ID, tc.req.NewID).Return(tc.gatewayReturn.wlt, tc.gatewayReturn.err)
+			}
+
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
+
+			endpoint := "/api/v2/wallet/rename"
+			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
+
+			rr := httptest.NewRecorder()
+
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
+
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
+
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var wltRsp WalletResponse
+				err := json.Unmarshal(rsp.Data, &wltRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+			}
+		})
+	}
+}
+
+func TestWalletFreezeOutputs(t *testing.T) {
+	hash := testutil.RandSHA256(t)
+
+	type gatewayReturnPair struct {
+		frozen []cipher.SHA256
+		err    error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletFrozenOutputsRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodGet,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletFrozenOutputsRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletFrozenOutputsRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+		},
+		{
+			name:         "id missing",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			req:          &WalletFrozenOutputsRequest{},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:        "uxouts missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletFrozenOutputsRequest{
+				ID: "foo.wlt",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "uxouts is required"),
+		},
+		{
+			name:        "invalid uxout",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletFrozenOutputsRequest{
+				ID:     "foo.wlt",
+				UxOuts: []string{"abcd"},
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `SHA256 hash "abcd" is invalid: Invalid hex length`),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletFrozenOutputsRequest{
+				ID:     "foo.wlt",
+				UxOuts: []string{hash.Hex()},
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletFrozenOutputsRequest{
+				ID:     "foo.wlt",
+				UxOuts: []string{hash.Hex()},
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletFrozenOutputsRequest{
+				ID:     "foo.wlt",
+				UxOuts: []string{hash.Hex()},
+			},
+			gatewayReturn: &gatewayReturnPair{
+				frozen: []cipher.SHA256{hash},
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletFrozenOutputsResponse{
+					FrozenOutputs: []string{hash.Hex()},
+				},
+			},
+		},
+	}
+
+	for _, endpoint := range []struct {
+		uri    string
+		method string
+	}{
+		{"/api/v2/wallet/outputs/freeze", "FreezeOutputs"},
+		{"/api/v2/wallet/outputs/unfreeze", "UnfreezeOutputs"},
+	} {
+		for _, tc := range cases {
+			t.Run(endpoint.method+" "+tc.name, func(t *testing.T) {
+				gateway := &MockGatewayer{}
+				if tc.gatewayReturn != nil {
+					gateway.On(endpoint.method, tc.req.ID, []cipher.SHA256{hash}).Return(tc.gatewayReturn.frozen, tc.gatewayReturn.err)
+				}
+
+				if tc.httpBody == "" && tc.req != nil {
+					tc.httpBody = toJSON(t, tc.req)
+				}
+
+				req, err := http.NewRequest(tc.method, endpoint.uri, strings.NewReader(tc.httpBody))
+				require.NoError(t, err)
+
+				req.Header.Set("Content-Type", tc.contentType)
+
+				setCSRFParameters(t, tokenValid, req)
+
+				rr := httptest.NewRecorder()
+
+				cfg := defaultMuxConfig()
+				cfg.disableCSRF = false
+
+				handler := newServerMux(cfg, gateway)
+				handler.ServeHTTP(rr, req)
+
+				status := rr.Code
+				require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
+
+				var rsp ReceivedHTTPResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+				if rsp.Data == nil {
+					require.Nil(t, tc.httpResponse.Data)
+				} else {
+					require.NotNil(t, tc.httpResponse.Data)
+
+					var frozenRsp WalletFrozenOutputsResponse
+					err := json.Unmarshal(rsp.Data, &frozenRsp)
+					require.NoError(t, err)
+
+					require.Equal(t, tc.httpResponse.Data.(WalletFrozenOutputsResponse), frozenRsp)
+				}
+			})
+		}
+	}
+}
+
+func TestWalletFrozenOutputs(t *testing.T) {
+	hashes := []cipher.SHA256{
+		testutil.RandSHA256(t),
+		testutil.RandSHA256(t),
+	}
+
+	type gatewayReturnPair struct {
+		frozen []cipher.SHA256
+		err    error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		id            string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
+	}{
+		{
+			name:         "method not allowed",
+			method:       http.MethodPost,
+			status:       http.StatusMethodNotAllowed,
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
+		},
+		{
+			name:         "id missing",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:   "wallet does not exist",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			id:     "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:   "wallet api disabled",
+			method: http.MethodGet,
+			status: http.StatusForbidden,
+			id:     "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:          "ok, no frozen outputs",
+			method:        http.MethodGet,
+			status:        http.StatusOK,
+			id:            "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{},
+			httpResponse: HTTPResponse{
+				Data: WalletFrozenOutputsResponse{
+					FrozenOutputs: []string{},
+				},
+			},
+		},
+		{
+			name:   "ok",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			id:     "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{
+				frozen: hashes,
+			},
+			httpResponse: HTTPResponse{
+				Data: WalletFrozenOutputsResponse{
+					FrozenOutputs: []string{hashes[0].Hex(), hashes[1].Hex()},
 				},
 			},
 		},
 	}
 
 	for _, tc := range cases {
-		gateway := &MockGatewayer{}
-		gateway.On("GetWallets").Return(tc.getWalletsResponse, tc.getWalletsErr)
+		t.Run(tc.name, func(t *testing.T) {
+			gateway := &MockGatewayer{}
+			if tc.gatewayReturn != nil {
+				gateway.On("GetFrozenOutputs", tc.id).Return(tc.gatewayReturn.frozen, tc.gatewayReturn.err)
+			}
 
-		endpoint := "/api/v1/wallets"
+			endpoint := "/api/v2/wallet/outputs/frozen"
+			if tc.id != "" {
+				endpoint += "?id=" + tc.id
+			}
+			req, err := http.NewRequest(tc.method, endpoint, nil)
+			require.NoError(t, err)
 
-		req, err := http.NewRequest(tc.method, endpoint, nil)
-		require.NoError(t, err)
+			setCSRFParameters(t, tokenValid, req)
 
-		setCSRFParameters(t, tokenValid, req)
+			rr := httptest.NewRecorder()
 
-		rr := httptest.NewRecorder()
+			cfg := defaultMuxConfig()
+			cfg.disableCSRF = false
 
-		cfg := defaultMuxConfig()
-		cfg.disableCSRF = false
+			handler := newServerMux(cfg, gateway)
+			handler.ServeHTTP(rr, req)
 
-		handler := newServerMux(cfg, gateway)
+			status := rr.Code
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
 
-		handler.ServeHTTP(rr, req)
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
 
-		status := rr.Code
-		require.Equal(t, tc.status, status, "got `%v` want `%v`",
-			tc.name, status, tc.status)
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
 
-		if status != http.StatusOK {
-			require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
-				strings.TrimSpace(rr.Body.String()), status, tc.err)
-		} else {
-			var msg []*WalletResponse
-			err := json.Unmarshal(rr.Body.Bytes(), &msg)
-			require.NoError(t, err)
-			require.NotNil(t, msg)
-			require.Equal(t, tc.httpResponse, msg, tc.name)
-		}
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var frozenRsp WalletFrozenOutputsResponse
+				err := json.Unmarshal(rsp.Data, &frozenRsp)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(WalletFrozenOutputsResponse), frozenRsp)
+			}
+		})
 	}
 }
 
-func TestWalletUnloadHandler(t *testing.T) {
-	tt := []struct {
-		name            string
-		method          string
-		status          int
-		err             string
-		walletID        string
-		unloadWalletErr error
-		csrfDisabled    bool
+func TestWalletUpdateAddress(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(1)
+	require.NoError(t, err)
+
+	addr := w.Entries[0].MDLAddress()
+	label := "customer-1"
+	meta := map[string]string{"invoice": "42"}
+	_, err = w.UpdateEntry(addr, wallet.EntryUpdate{
+		Label: &label,
+		Meta:  meta,
+	})
+	require.NoError(t, err)
+
+	type gatewayReturnPair struct {
+		wallet *wallet.Wallet
+		err    error
+	}
+
+	cases := []struct {
+		name          string
+		method        string
+		status        int
+		contentType   string
+		req           *WalletAddressRequest
+		httpBody      string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
 	}{
 		{
-			name:     "405",
-			method:   http.MethodGet,
-			status:   http.StatusMethodNotAllowed,
-			err:      "405 Method Not Allowed",
-			walletID: "wallet.wlt",
+			name:         "method not allowed",
+			method:       http.MethodPut,
+			status:       http.StatusMethodNotAllowed,
+			contentType:  ContentTypeJSON,
+			httpBody:     toJSON(t, WalletAddressRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
 		},
 		{
-			name:   "400 - missing wallet id",
-			method: http.MethodPost,
-			status: http.StatusBadRequest,
-			err:    "400 Bad Request - missing wallet id",
+			name:         "wrong content-type",
+			method:       http.MethodPost,
+			status:       http.StatusUnsupportedMediaType,
+			contentType:  ContentTypeForm,
+			httpBody:     toJSON(t, WalletAddressRequest{}),
+			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
 		},
 		{
-			name:            "403 - Forbidden - wallet API disabled",
-			method:          http.MethodPost,
-			status:          http.StatusForbidden,
-			err:             "403 Forbidden",
-			walletID:        "wallet.wlt",
-			unloadWalletErr: wallet.ErrWalletAPIDisabled,
+			name:         "invalid json",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			httpBody:     `{"meta": "foo"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "json: cannot unmarshal string into Go struct field WalletAddressRequest.meta of type map[string]string"),
 		},
 		{
-			name:     "200 - ok",
-			method:   http.MethodPost,
-			status:   http.StatusOK,
-			walletID: "wallet.wlt",
+			name:         "id missing",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			req:          &WalletAddressRequest{},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
 		},
 		{
-			name:         "200 - ok, csrf disabled",
-			method:       http.MethodPost,
-			status:       http.StatusOK,
-			walletID:     "wallet.wlt",
-			csrfDisabled: true,
+			name:        "address missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID: "foo.wlt",
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address is required"),
+		},
+		{
+			name:        "invalid address",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: "foo",
+				Label:   &label,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid address: Invalid address length"),
+		},
+		{
+			name:        "label and meta missing",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "label or meta is required"),
+		},
+		{
+			name:        "wallet does not exist",
+			method:      http.MethodPost,
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Label:   &label,
+				Meta:    meta,
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:        "address not in wallet",
+			method:      http.MethodPost,
+			status:      http.StatusBadRequest,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Label:   &label,
+				Meta:    meta,
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrUnknownAddress,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "address not found in wallet"),
+		},
+		{
+			name:        "wallet api disabled",
+			method:      http.MethodPost,
+			status:      http.StatusForbidden,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Label:   &label,
+				Meta:    meta,
+			},
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+		},
+		{
+			name:        "ok",
+			method:      http.MethodPost,
+			status:      http.StatusOK,
+			contentType: ContentTypeJSON,
+			req: &WalletAddressRequest{
+				ID:      "foo.wlt",
+				Address: addr.String(),
+				Label:   &label,
+				Meta:    meta,
+			},
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
+			},
+			httpResponse: HTTPResponse{
+				Data: readable.WalletEntry{
+					Address: addr.String(),
+					Public:  w.Entries[0].Public.Hex(),
+					Label:   label,
+					Meta:    meta,
+				},
+			},
 		},
 	}
 
-	for _, tc := range tt {
+	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
-			gateway.On("UnloadWallet", tc.walletID).Return(tc.unloadWalletErr)
+			if tc.gatewayReturn != nil {
+				gateway.On("UpdateEntry", tc.req.ID, addr, wallet.EntryUpdate{
+					Label: tc.req.Label,
+					Meta:  tc.req.Meta,
+				}).Return(tc.gatewayReturn.wallet, tc.gatewayReturn.err)
+			}
 
-			endpoint := "/api/v1/wallet/unload"
-			v := url.Values{}
-			v.Add("id", tc.walletID)
+			if tc.httpBody == "" && tc.req != nil {
+				tc.httpBody = toJSON(t, tc.req)
+			}
 
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			req, err := http.NewRequest(tc.method, "/api/v2/wallet/address", strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
-			req.Header.Add("Content-Type", ContentTypeForm)
 
-			if tc.csrfDisabled {
-				setCSRFParameters(t, tokenInvalid, req)
-			} else {
-				setCSRFParameters(t, tokenValid, req)
-			}
+			req.Header.Set("Content-Type", tc.contentType)
+
+			setCSRFParameters(t, tokenValid, req)
 
 			rr := httptest.NewRecorder()
 
 			cfg := defaultMuxConfig()
-			cfg.disableCSRF = tc.csrfDisabled
+			cfg.disableCSRF = false
 
 			handler := newServerMux(cfg, gateway)
 			handler.ServeHTTP(rr, req)
 
 			status := rr.Code
-			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
 
-			if status != http.StatusOK {
-				require.Equal(t, tc.err, strings.TrimSpace(rr.Body.String()), "got `%v`| %d, want `%v`",
-					strings.TrimSpace(rr.Body.String()), status, tc.err)
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var entry readable.WalletEntry
+				err := json.Unmarshal(rsp.Data, &entry)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.(readable.WalletEntry), entry)
 			}
 		})
 	}
-}
+}
+
+func TestWalletAddressesByLabel(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(3)
+	require.NoError(t, err)
+
+	label := "customer-1"
+	for _, i := range []int{0, 2} {
+		_, err = w.UpdateEntry(w.Entries[i].MDLAddress(), wallet.EntryUpdate{
+			Label: &label,
+		})
+		require.NoError(t, err)
+	}
 
-func TestEncryptWallet(t *testing.T) {
-	entries, responseEntries := makeEntries([]byte("seed"), 5)
 	type gatewayReturnPair struct {
-		w   *wallet.Wallet
-		err error
+		wallet *wallet.Wallet
+		err    error
 	}
-	tt := []struct {
+
+	cases := []struct {
 		name          string
-		method        string
-		wltID         string
-		password      string
-		gatewayReturn gatewayReturnPair
 		status        int
-		expectWallet  WalletResponse
-		expectErr     string
+		id            string
+		label         string
+		httpResponse  HTTPResponse
+		gatewayReturn *gatewayReturnPair
 	}{
 		{
-			name:     "200 - OK",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				w: &wallet.Wallet{
-					Meta: map[string]string{
-						"filename":  "wallet.wlt",
-						"seed":      "seed",
-						"lastSeed":  "lastSeed",
-						"secrets":   "secrets",
-						"encrypted": "true",
-					},
-					Entries: cloneEntries(entries),
-				},
-			},
-			status: http.StatusOK,
-			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet.wlt",
-					Encrypted: true,
-				},
-				Entries: responseEntries,
-			},
-		},
-		{
-			name:     "403 Forbidden",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletAPIDisabled,
-			},
-			status:    http.StatusForbidden,
-			expectErr: "403 Forbidden",
+			name:         "id missing",
+			status:       http.StatusBadRequest,
+			label:        label,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
 		},
 		{
-			name:      "405 Method Not Allowed",
-			method:    http.MethodGet,
-			wltID:     "wallet.wlt",
-			password:  "pwd",
-			status:    http.StatusMethodNotAllowed,
-			expectErr: "405 Method Not Allowed",
+			name:         "label missing",
+			status:       http.StatusBadRequest,
+			id:           "foo.wlt",
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "label is required"),
 		},
 		{
-			name:     "400 - Missing Password",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrMissingPassword,
+			name:   "wallet does not exist",
+			status: http.StatusNotFound,
+			id:     "foo.wlt",
+			label:  label,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - missing password",
-		},
-		{
-			name:      "400 - Missing Wallet Id",
-			method:    http.MethodPost,
-			wltID:     "",
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - missing wallet id",
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
 		},
 		{
-			name:     "400 Bad Request - Invalid Password",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrInvalidPassword,
+			name:   "no addresses with the label",
+			status: http.StatusOK,
+			id:     "foo.wlt",
+			label:  "unknown",
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - invalid password",
-		},
-		{
-			name:     "404 - Wallet Not Found",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotExist,
+			httpResponse: HTTPResponse{
+				Data: []readable.WalletEntry{},
 			},
-			status:    http.StatusNotFound,
-			expectErr: "404 Not Found",
 		},
 		{
-			name:     "400 - Wallet Is Encrypted",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletEncrypted,
+			name:   "ok",
+			status: http.StatusOK,
+			id:     "foo.wlt",
+			label:  label,
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
+			},
+			httpResponse: HTTPResponse{
+				Data: []readable.WalletEntry{
+					{
+						Address: w.Entries[0].Address.String(),
+						Public:  w.Entries[0].Public.Hex(),
+						Label:   label,
+					},
+					{
+						Address: w.Entries[2].Address.String(),
+						Public:  w.Entries[2].Public.Hex(),
+						Label:   label,
+					},
+				},
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - wallet is encrypted",
 		},
 	}
 
-	for _, tc := range tt {
+	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
-			gateway.On("EncryptWallet", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
+			if tc.gatewayReturn != nil {
+				gateway.On("GetWallet", tc.id).Return(tc.gatewayReturn.wallet, tc.gatewayReturn.err)
+			}
 
-			endpoint := "/api/v1/wallet/encrypt"
 			v := url.Values{}
-			v.Add("id", tc.wltID)
-			v.Add("password", tc.password)
+			if tc.id != "" {
+				v.Add("id", tc.id)
+			}
+			if tc.label != "" {
+				v.Add("label", tc.label)
+			}
 
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			req, err := http.NewRequest(http.MethodGet, "/api/v2/wallet/address?"+v.Encode(), nil)
 			require.NoError(t, err)
-			req.Header.Add("Content-Type", ContentTypeForm)
 
 			setCSRFParameters(t, tokenValid, req)
 
@@ -2277,177 +5688,169 @@ func TestEncryptWallet(t *testing.T) {
 			handler.ServeHTTP(rr, req)
 
 			status := rr.Code
-			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`, body: %v", status, tc.status, rr.Body.String())
-
-			if status != http.StatusOK {
-				require.Equal(t, tc.expectErr, strings.TrimSpace(rr.Body.String()))
-				return
-			}
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
 
-			var rsp WalletResponse
+			var rsp ReceivedHTTPResponse
 			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
 			require.NoError(t, err)
-			require.Equal(t, tc.expectWallet, rsp)
+
+			require.Equal(t, tc.httpResponse.Error, rsp.Error)
+
+			if rsp.Data == nil {
+				require.Nil(t, tc.httpResponse.Data)
+			} else {
+				require.NotNil(t, tc.httpResponse.Data)
+
+				var entries []readable.WalletEntry
+				err := json.Unmarshal(rsp.Data, &entries)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.httpResponse.Data.([]readable.WalletEntry), entries)
+			}
 		})
 	}
 }
 
-func TestDecryptWallet(t *testing.T) {
-	entries, responseEntries := makeEntries([]byte("seed"), 5)
+func TestWalletAddressURI(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(2)
+	require.NoError(t, err)
+
+	label := "customer 1"
+	_, err = w.UpdateEntry(w.Entries[1].MDLAddress(), wallet.EntryUpdate{
+		Label: &label,
+	})
+	require.NoError(t, err)
+
+	addr0 := w.Entries[0].Address.String()
+	addr1 := w.Entries[1].Address.String()
+
 	type gatewayReturnPair struct {
-		w   *wallet.Wallet
-		err error
+		wallet *wallet.Wallet
+		err    error
 	}
 
-	tt := []struct {
+	cases := []struct {
 		name          string
 		method        string
-		wltID         string
-		password      string
-		gatewayReturn gatewayReturnPair
 		status        int
-		expectWallet  WalletResponse
-		expectErr     string
-		csrfDisabled  bool
+		values        url.Values
+		err           string
+		uri           string
+		gatewayReturn *gatewayReturnPair
 	}{
 		{
-			name:     "200 OK",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				w: &wallet.Wallet{
-					Meta: map[string]string{
-						"filename":  "wallet",
-						"seed":      "seed",
-						"lastSeed":  "lastSeed",
-						"secrets":   "",
-						"encrypted": "false",
-					},
-					Entries: cloneEntries(entries),
-				},
-			},
-			status: http.StatusOK,
-			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet",
-					Encrypted: false,
-				},
-				Entries: responseEntries,
-			},
+			name:   "405",
+			method: http.MethodPost,
+			status: http.StatusMethodNotAllowed,
+			err:    "Method Not Allowed",
 		},
 		{
-			name:     "200 OK CSRF disabled",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				w: &wallet.Wallet{
-					Meta: map[string]string{
-						"filename":  "wallet",
-						"seed":      "seed",
-						"lastSeed":  "lastSeed",
-						"secrets":   "",
-						"encrypted": "false",
-					},
-					Entries: cloneEntries(entries),
-				},
-			},
-			status: http.StatusOK,
-			expectWallet: WalletResponse{
-				Meta: readable.WalletMeta{
-					Filename:  "wallet",
-					Encrypted: false,
-				},
-				Entries: responseEntries,
-			},
-			csrfDisabled: true,
+			name:   "id missing",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"address": {addr0}},
+			err:    "id is required",
 		},
-
 		{
-			name:     "403 Forbidden",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletAPIDisabled,
-			},
-			status:    http.StatusForbidden,
-			expectErr: "403 Forbidden",
+			name:   "address missing",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"id": {"foo.wlt"}},
+			err:    "address is required",
 		},
 		{
-			name:      "405 Method Not Allowed",
-			method:    http.MethodGet,
-			status:    http.StatusMethodNotAllowed,
-			expectErr: "405 Method Not Allowed",
+			name:   "invalid address",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"id": {"foo.wlt"}, "address": {"foo"}},
+			err:    "invalid address: Invalid address length",
 		},
 		{
-			name:      "400 - Missing Wallet ID",
-			method:    http.MethodPost,
-			wltID:     "",
-			password:  "",
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - missing wallet id",
+			name:   "invalid amount",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr0}, "amount": {"1.0001"}},
+			err:    "Invalid URI amount: invalid amount, too many decimal places",
+		},
+		{
+			name:   "zero amount",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr0}, "amount": {"0"}},
+			err:    "amount must not be zero",
+		},
+		{
+			name:   "invalid hours",
+			method: http.MethodGet,
+			status: http.StatusBadRequest,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr0}, "hours": {"-1"}},
+			err:    "invalid hours value",
+		},
+		{
+			name:   "wallet does not exist",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr0}},
+			err:    "Not Found",
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
 		},
 		{
-			name:     "400 - Missing Password",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrMissingPassword,
+			name:   "address not in wallet",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			values: url.Values{"id": {"foo.wlt"}, "address": {testutil.MakeAddress().String()}},
+			err:    "address not found in wallet",
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - missing password",
 		},
 		{
-			name:     "400 - Wallet IS Not Encrypted",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotEncrypted,
+			name:   "address",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr0}},
+			uri:    "mdl:" + addr0,
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - wallet is not encrypted",
 		},
 		{
-			name:     "400 Bad Request - Invalid Password",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrInvalidPassword,
+			name:   "entry label",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr1}, "amount": {"12.5"}, "hours": {"10"}, "message": {"invoice #12"}},
+			uri:    "mdl:" + addr1 + "?amount=12.5&hours=10&label=customer%201&message=invoice%20%2312",
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
-			status:    http.StatusBadRequest,
-			expectErr: "400 Bad Request - invalid password",
 		},
 		{
-			name:     "404 - Wallet Does Not Exist",
-			method:   http.MethodPost,
-			wltID:    "wallet.wlt",
-			password: "pwd",
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotExist,
+			name:   "label replaces the entry label",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			values: url.Values{"id": {"foo.wlt"}, "address": {addr1}, "label": {""}},
+			uri:    "mdl:" + addr1,
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
-			status:    http.StatusNotFound,
-			expectErr: "404 Not Found",
 		},
 	}
 
-	for _, tc := range tt {
+	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
-			gateway.On("DecryptWallet", tc.wltID, []byte(tc.password)).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
-
-			endpoint := "/api/v1/wallet/decrypt"
-			v := url.Values{}
-			v.Add("id", tc.wltID)
-			v.Add("password", tc.password)
+			if tc.gatewayReturn != nil {
+				gateway.On("GetWallet", "foo.wlt").Return(tc.gatewayReturn.wallet, tc.gatewayReturn.err)
+			}
 
-			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(v.Encode()))
+			req, err := http.NewRequest(tc.method, "/api/v2/wallet/address/uri?"+tc.values.Encode(), nil)
 			require.NoError(t, err)
-			req.Header.Add("Content-Type", ContentTypeForm)
 
 			setCSRFParameters(t, tokenValid, req)
 
@@ -2460,233 +5863,244 @@ func TestDecryptWallet(t *testing.T) {
 			handler.ServeHTTP(rr, req)
 
 			status := rr.Code
-			require.Equal(t, tc.status, status, "wrong status code: got `%v` want `%v`", status, tc.status)
+			require.Equal(t, tc.status, status, "got `%v` want `%v`", status, tc.status)
 
-			if status != http.StatusOK {
-				require.Equal(t, tc.expectErr, strings.TrimSpace(rr.Body.String()))
+			var rsp ReceivedHTTPResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.NoError(t, err)
+
+			if tc.err != "" {
+				require.NotNil(t, rsp.Error)
+				require.Equal(t, tc.err, rsp.Error.Message)
+				require.Nil(t, rsp.Data)
 				return
 			}
 
-			var rsp WalletResponse
-			err = json.Unmarshal(rr.Body.Bytes(), &rsp)
+			require.Nil(t, rsp.Error)
+
+			var data WalletAddressURIResponse
+			err = json.Unmarshal(rsp.Data, &data)
 			require.NoError(t, err)
-			require.Equal(t, tc.expectWallet, rsp)
-		})
-	}
-}
 
-// makeEntries derives N wallet address entries from given seed
-// Returns set of wallet.Entry and wallet.ReadableEntry, the readable
-// entries' secrets are removed.
-func makeEntries(seed []byte, n int) ([]wallet.Entry, []readable.WalletEntry) { // nolint: unparam
-	seckeys := cipher.MustGenerateDeterministicKeyPairs(seed, n)
-	var entries []wallet.Entry
-	var responseEntries []readable.WalletEntry
-	for i, seckey := range seckeys {
-		pubkey := cipher.MustPubKeyFromSecKey(seckey)
-		entries = append(entries, wallet.Entry{
-			Address: cipher.AddressFromPubKey(pubkey),
-			Public:  pubkey,
-			Secret:  seckey,
-		})
-		responseEntries = append(responseEntries, readable.WalletEntry{
-			Address: entries[i].Address.String(),
-			Public:  entries[i].Public.Hex(),
+			require.Equal(t, tc.uri, data.URI)
+			require.True(t, strings.HasPrefix(data.QR, "<svg"))
 		})
 	}
-	return entries, responseEntries
-}
-
-func cloneEntries(es []wallet.Entry) []wallet.Entry {
-	var entries []wallet.Entry
-	entries = append(entries, es...)
-	return entries
 }
 
-func TestWalletRecover(t *testing.T) {
-	type gatewayReturnPair struct {
-		w   *wallet.Wallet
-		err error
-	}
-
-	okWalletUnencrypted, err := wallet.NewWallet("foo", wallet.Options{
-		Coin:      wallet.CoinTypeMDL,
-		Label:     "foolabel",
-		Seed:      "fooseed",
-		GenerateN: 10,
+func TestWalletSpendingPolicy(t *testing.T) {
+	w, err := wallet.NewWallet("foo.wlt", wallet.Options{
+		Seed: "seed",
 	})
 	require.NoError(t, err)
-	okWalletUnencryptedResponse, err := NewWalletResponse(okWalletUnencrypted)
-	require.NoError(t, err)
 
-	okWalletEncrypted, err := wallet.NewWallet("foo", wallet.Options{
-		Coin:       wallet.CoinTypeMDL,
-		Label:      "foolabel",
-		Seed:       "fooseed",
-		Encrypt:    true,
-		Password:   []byte("foopassword"),
-		CryptoType: wallet.CryptoTypeScryptChacha20poly1305Insecure,
-		GenerateN:  10,
-	})
-	require.NoError(t, err)
-	okWalletEncryptedResponse, err := NewWalletResponse(okWalletEncrypted)
-	require.NoError(t, err)
+	allowed := testutil.MakeAddress()
+	policy := wallet.SpendingPolicy{
+		MaxCoinsPerTransaction: 10e6,
+		MaxCoinsPerDay:         100e6,
+		Allowlist:              []cipher.Address{allowed},
+		MaxOutputs:             2,
+		MinChange:              1e3,
+	}
+	require.NoError(t, w.SetSpendingPolicy(policy))
+
+	policyResponse := WalletSpendingPolicyResponse{
+		Policy: readable.SpendingPolicy{
+			MaxCoinsPerTransaction: "10.000000",
+			MaxCoinsPerDay:         "100.000000",
+			Allowlist:              []string{allowed.String()},
+			MaxOutputs:             2,
+			MinChange:              "0.001000",
+		},
+		SpentToday: "0.000000",
+	}
+
+	type gatewayReturnPair struct {
+		wallet *wallet.Wallet
+		err    error
+	}
 
 	cases := []struct {
 		name          string
 		method        string
 		status        int
 		contentType   string
-		req           *WalletRecoverRequest
+		query         string
+		req           *WalletSpendingPolicyRequest
 		httpBody      string
 		httpResponse  HTTPResponse
-		gatewayReturn gatewayReturnPair
+		gatewayReturn *gatewayReturnPair
 	}{
 		{
 			name:         "method not allowed",
-			method:       http.MethodGet,
+			method:       http.MethodPut,
 			status:       http.StatusMethodNotAllowed,
 			contentType:  ContentTypeJSON,
-			httpBody:     toJSON(t, WalletRecoverRequest{}),
+			httpBody:     toJSON(t, WalletSpendingPolicyRequest{}),
 			httpResponse: NewHTTPErrorResponse(http.StatusMethodNotAllowed, "Method Not Allowed"),
 		},
+		{
+			name:         "get id missing",
+			method:       http.MethodGet,
+			status:       http.StatusBadRequest,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
+		},
+		{
+			name:   "get wallet does not exist",
+			method: http.MethodGet,
+			status: http.StatusNotFound,
+			query:  "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletNotExist,
+			},
+			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+		},
+		{
+			name:   "get ok",
+			method: http.MethodGet,
+			status: http.StatusOK,
+			query:  "foo.wlt",
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
+			},
+			httpResponse: HTTPResponse{
+				Data: policyResponse,
+			},
+		},
 		{
 			name:         "wrong content-type",
 			method:       http.MethodPost,
 			status:       http.StatusUnsupportedMediaType,
 			contentType:  ContentTypeForm,
-			httpBody:     toJSON(t, WalletRecoverRequest{}),
+			httpBody:     toJSON(t, WalletSpendingPolicyRequest{}),
 			httpResponse: NewHTTPErrorResponse(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
 		},
 		{
-			name:         "empty json body",
+			name:         "invalid json",
 			method:       http.MethodPost,
 			status:       http.StatusBadRequest,
 			contentType:  ContentTypeJSON,
-			httpBody:     "",
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "EOF"),
+			httpBody:     `{"policy": "foo"}`,
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "json: cannot unmarshal string into Go struct field WalletSpendingPolicyRequest.policy of type readable.SpendingPolicy"),
 		},
 		{
-			name:        "id missing",
-			method:      http.MethodPost,
-			status:      http.StatusBadRequest,
-			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				Seed: "fooseed",
-			},
+			name:         "id missing",
+			method:       http.MethodPost,
+			status:       http.StatusBadRequest,
+			contentType:  ContentTypeJSON,
+			req:          &WalletSpendingPolicyRequest{},
 			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "id is required"),
 		},
 		{
-			name:        "seed missing",
+			name:        "password missing",
 			method:      http.MethodPost,
 			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID: "foo",
+			req: &WalletSpendingPolicyRequest{
+				ID:     "foo.wlt",
+				Policy: &policyResponse.Policy,
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "seed is required"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "password is required"),
 		},
 		{
-			name:        "wallet not encrypted",
+			name:        "policy missing",
 			method:      http.MethodPost,
 			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
-			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotEncrypted,
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletNotEncrypted.Error()),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "policy is required"),
 		},
 		{
-			name:        "wallet seed wrong",
+			name:        "invalid amount",
 			method:      http.MethodPost,
 			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
-			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletRecoverSeedWrong,
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy: &readable.SpendingPolicy{
+					MaxCoinsPerDay: "foo",
+				},
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, wallet.ErrWalletRecoverSeedWrong.Error()),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid max_coins_per_day: can't convert foo to decimal"),
 		},
 		{
-			name:        "wallet does not exist",
+			name:        "invalid allowlist address",
 			method:      http.MethodPost,
-			status:      http.StatusNotFound,
+			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
-			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletNotExist,
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy: &readable.SpendingPolicy{
+					Allowlist: []string{"foo"},
+				},
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusNotFound, "Not Found"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, `invalid allowlist address "foo": Invalid address length`),
 		},
 		{
-			name:        "wallet api disabled",
+			name:        "wallet not encrypted",
 			method:      http.MethodPost,
-			status:      http.StatusForbidden,
+			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy:   &policyResponse.Policy,
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: wallet.ErrWalletAPIDisabled,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrSpendingPolicyNotEncrypted,
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "spending policies can only be set on encrypted wallets"),
 		},
 		{
-			name:        "wallet other error",
+			name:        "invalid password",
 			method:      http.MethodPost,
-			status:      http.StatusInternalServerError,
+			status:      http.StatusBadRequest,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy:   &policyResponse.Policy,
 			},
-			gatewayReturn: gatewayReturnPair{
-				err: errors.New("wallet error"),
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrInvalidPassword,
 			},
-			httpResponse: NewHTTPErrorResponse(http.StatusInternalServerError, "wallet error"),
+			httpResponse: NewHTTPErrorResponse(http.StatusBadRequest, "invalid password"),
 		},
 		{
-			name:        "ok, no password",
+			name:        "wallet api disabled",
 			method:      http.MethodPost,
-			status:      http.StatusOK,
+			status:      http.StatusForbidden,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:   "foo",
-				Seed: "fooseed",
-			},
-			gatewayReturn: gatewayReturnPair{
-				w: okWalletUnencrypted,
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy:   &policyResponse.Policy,
 			},
-			httpResponse: HTTPResponse{
-				Data: *okWalletUnencryptedResponse,
+			gatewayReturn: &gatewayReturnPair{
+				err: wallet.ErrWalletAPIDisabled,
 			},
+			httpResponse: NewHTTPErrorResponse(http.StatusForbidden, ""),
 		},
 		{
-			name:        "ok, password",
+			name:        "post ok",
 			method:      http.MethodPost,
 			status:      http.StatusOK,
 			contentType: ContentTypeJSON,
-			req: &WalletRecoverRequest{
-				ID:       "foo",
-				Seed:     "fooseed",
-				Password: "foopassword",
+			req: &WalletSpendingPolicyRequest{
+				ID:       "foo.wlt",
+				Password: "pwd",
+				Policy:   &policyResponse.Policy,
 			},
-			gatewayReturn: gatewayReturnPair{
-				w: okWalletEncrypted,
+			gatewayReturn: &gatewayReturnPair{
+				wallet: w,
 			},
 			httpResponse: HTTPResponse{
-				Data: *okWalletEncryptedResponse,
+				Data: policyResponse,
 			},
 		},
 	}
@@ -2694,28 +6108,29 @@ func TestWalletRecover(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			gateway := &MockGatewayer{}
-			if tc.req != nil {
-				var password []byte
-				if tc.req.Password != "" {
-					password = []byte(tc.req.Password)
+			if tc.gatewayReturn != nil {
+				if tc.method == http.MethodGet {
+					gateway.On("GetWallet", tc.query).Return(tc.gatewayReturn.wallet, tc.gatewayReturn.err)
+				} else {
+					gateway.On("SetSpendingPolicy", tc.req.ID, []byte(tc.req.Password), policy).Return(tc.gatewayReturn.wallet, tc.gatewayReturn.err)
 				}
-				gateway.On("RecoverWallet", tc.req.ID, tc.req.Seed, password).Return(tc.gatewayReturn.w, tc.gatewayReturn.err)
 			}
 
 			if tc.httpBody == "" && tc.req != nil {
 				tc.httpBody = toJSON(t, tc.req)
 			}
 
-			endpoint := "/api/v2/wallet/recover"
+			endpoint := "/api/v2/wallet/policy"
+			if tc.query != "" {
+				v := url.Values{}
+				v.Add("id", tc.query)
+				endpoint += "?" + v.Encode()
+			}
+
 			req, err := http.NewRequest(tc.method, endpoint, strings.NewReader(tc.httpBody))
 			require.NoError(t, err)
 
-			contentType := tc.contentType
-			if contentType == "" {
-				contentType = ContentTypeJSON
-			}
-
-			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Content-Type", tc.contentType)
 
 			setCSRFParameters(t, tokenValid, req)
 
@@ -2741,11 +6156,11 @@ func TestWalletRecover(t *testing.T) {
 			} else {
 				require.NotNil(t, tc.httpResponse.Data)
 
-				var wltRsp WalletResponse
-				err := json.Unmarshal(rsp.Data, &wltRsp)
+				var policyRsp WalletSpendingPolicyResponse
+				err := json.Unmarshal(rsp.Data, &policyRsp)
 				require.NoError(t, err)
 
-				require.Equal(t, tc.httpResponse.Data.(WalletResponse), wltRsp)
+				require.Equal(t, tc.httpResponse.Data.(WalletSpendingPolicyResponse), policyRsp)
 			}
 		})
 	}
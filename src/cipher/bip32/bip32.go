@@ -535,6 +535,24 @@ func DeserializePublicKey(data []byte) (*PublicKey, error) {
 	}, nil
 }
 
+// DeserializeEncodedPrivateKey deserializes a base58 xprv key to a PrivateKey
+func DeserializeEncodedPrivateKey(xprv string) (*PrivateKey, error) {
+	b, err := base58.Decode(xprv)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializePrivateKey(b)
+}
+
+// DeserializeEncodedPublicKey deserializes a base58 xpub key to a PublicKey
+func DeserializeEncodedPublicKey(xpub string) (*PublicKey, error) {
+	b, err := base58.Decode(xpub)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializePublicKey(b)
+}
+
 // deserialize a byte slice into a Key.
 // If the Key.Key length is 32 bytes it is a private key, otherwise it is a public key.
 func deserialize(data []byte, wantPrivate bool) (*key, error) {
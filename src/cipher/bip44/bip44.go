@@ -0,0 +1,105 @@
+/*
+Package bip44 implements the bip44 spec https://github.com/bitcoin/bips/blob/master/bip-0044.mediawiki
+
+Keys are derived along the path m / purpose' / coin_type' / account' / change / address_index
+*/
+package bip44
+
+import (
+	"errors"
+
+	"github.com/MDLlife/MDL/src/cipher/bip32"
+)
+
+// CoinType is the coin_type part of the bip44 path
+type CoinType uint32
+
+const (
+	// CoinTypeBitcoin is the coin type for Bitcoin
+	CoinTypeBitcoin CoinType = 0
+	// CoinTypeBitcoinTestnet is the coin type for Bitcoin testnet
+	CoinTypeBitcoinTestnet CoinType = 1
+	// CoinTypeMDL is the coin type for MDL.
+	// MDL inherits the coin type registered for Skycoin, which it is forked from
+	// and shares the address format with.
+	CoinTypeMDL CoinType = 8000
+
+	// ExternalChainIndex is the index of the external chain, used for receiving addresses
+	ExternalChainIndex = uint32(0)
+	// ChangeChainIndex is the index of the change chain, used for change addresses
+	ChangeChainIndex = uint32(1)
+
+	// purpose is the bip44 purpose constant, 44'
+	purpose = 44
+)
+
+var (
+	// ErrInvalidAccount is returned if the account number is >= 2^31
+	ErrInvalidAccount = errors.New("Account number must be less than 2^31")
+	// ErrInvalidCoinType is returned if the coin type is >= 2^31
+	ErrInvalidCoinType = errors.New("Coin type must be less than 2^31")
+)
+
+// Coin is a bip32 node at the coin_type level of a bip44 path, m/44'/coin_type'
+type Coin struct {
+	*bip32.PrivateKey
+}
+
+// NewCoin creates a bip32 node at m/44'/coin_type' from a seed
+func NewCoin(seed []byte, coinType CoinType) (*Coin, error) {
+	if uint32(coinType) >= bip32.FirstHardenedChild {
+		return nil, ErrInvalidCoinType
+	}
+
+	mk, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := mk.NewPrivateChildKey(purpose + bip32.FirstHardenedChild)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := p.NewPrivateChildKey(uint32(coinType) + bip32.FirstHardenedChild)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Coin{
+		PrivateKey: c,
+	}, nil
+}
+
+// Account creates a bip32 node at m/44'/coin_type'/account'.
+// The account number must be less than 2^31, it will be hardened.
+// This method can return an ImpossibleChild error.
+func (c *Coin) Account(account uint32) (*Account, error) {
+	if account >= bip32.FirstHardenedChild {
+		return nil, ErrInvalidAccount
+	}
+
+	k, err := c.NewPrivateChildKey(account + bip32.FirstHardenedChild)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{
+		PrivateKey: k,
+	}, nil
+}
+
+// Account is a bip32 node at the account level of a bip44 path, m/44'/coin_type'/account'
+type Account struct {
+	*bip32.PrivateKey
+}
+
+// External returns the external chain node of the account, m/44'/coin_type'/account'/0
+func (a *Account) External() (*bip32.PrivateKey, error) {
+	return a.NewPrivateChildKey(ExternalChainIndex)
+}
+
+// Change returns the change chain node of the account, m/44'/coin_type'/account'/1
+func (a *Account) Change() (*bip32.PrivateKey, error) {
+	return a.NewPrivateChildKey(ChangeChainIndex)
+}
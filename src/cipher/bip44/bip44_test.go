@@ -0,0 +1,55 @@
+package bip44
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip32"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+)
+
+func TestNewCoin(t *testing.T) {
+	// Widely published vector for the "abandon ... about" mnemonic at m/44'/0'/0'
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, err := bip39.NewSeed(mnemonic, "")
+	require.NoError(t, err)
+
+	c, err := NewCoin(seed, CoinTypeBitcoin)
+	require.NoError(t, err)
+
+	acct, err := c.Account(0)
+	require.NoError(t, err)
+	require.Equal(t, "xprv9xpXFhFpqdQK3TmytPBqXtGSwS3DLjojFhTGht8gwAAii8py5X6pxeBnQ6ehJiyJ6nDjWGJfZ95WxByFXVkDxHXrqu53WCRGypk2ttuqncb", acct.String())
+	require.Equal(t, "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj", acct.PublicKey().String())
+
+	external, err := acct.External()
+	require.NoError(t, err)
+
+	k, err := external.NewPrivateChildKey(0)
+	require.NoError(t, err)
+	sk := cipher.MustNewSecKey(k.Key)
+	require.Equal(t, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA", cipher.MustBitcoinAddressFromSecKey(sk).String())
+
+	change, err := acct.Change()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), change.ChildNumber())
+	require.Equal(t, byte(4), change.Depth)
+
+	// The external chain can be derived from the account's public key
+	xpub, err := bip32.DeserializeEncodedPublicKey(acct.PublicKey().String())
+	require.NoError(t, err)
+	externalPub, err := xpub.NewPublicChildKey(ExternalChainIndex)
+	require.NoError(t, err)
+	require.Equal(t, external.PublicKey().String(), externalPub.String())
+
+	_, err = NewCoin(seed, CoinType(bip32.FirstHardenedChild))
+	require.Equal(t, ErrInvalidCoinType, err)
+
+	_, err = c.Account(bip32.FirstHardenedChild)
+	require.Equal(t, ErrInvalidAccount, err)
+
+	_, err = NewCoin([]byte("short"), CoinTypeMDL)
+	require.Equal(t, bip32.ErrInvalidSeedLength, err)
+}
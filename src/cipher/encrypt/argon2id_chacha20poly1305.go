@@ -0,0 +1,187 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/argon2"
+	"github.com/MDLlife/MDL/src/cipher/chacha20poly1305"
+)
+
+const (
+	argon2idChacha20MetaLengthSize = 2  // meta data length field size in bytes
+	argon2idChacha20SaltSize       = 32 // salt bytes number
+)
+
+// Default argon2id paramenters
+const (
+	// Argon2Time: argon2id time cost, the number of passes over the memory.
+	Argon2Time = 1
+	// Argon2Memory: argon2id memory cost in KiB. 64 MiB is the recommended value of the argon2 draft RFC for one pass.
+	Argon2Memory = 64 * 1024
+	// Argon2Threads: argon2id parallelism, the number of threads used to fill the memory.
+	Argon2Threads = 4
+	// Argon2KeyLen: The length of returned byte slice that can be used as cryptographic key.
+	Argon2KeyLen = 32
+)
+
+// DefaultArgon2idChacha20poly1305 default Argon2idChacha20poly1305 encryptor
+var DefaultArgon2idChacha20poly1305 = Argon2idChacha20poly1305{
+	Time:    Argon2Time,
+	Memory:  Argon2Memory,
+	Threads: Argon2Threads,
+	KeyLen:  Argon2KeyLen,
+}
+
+// Argon2idChacha20poly1305 provides methods for encryption/decryption with argon2id and chacha20poly1305
+type Argon2idChacha20poly1305 struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+}
+
+type argon2Meta struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	KeyLen  uint32 `json:"keyLen"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+}
+
+func (m argon2Meta) validate() error {
+	if m.Time == 0 {
+		return errors.New("invalid argon2 time cost")
+	}
+
+	if m.Memory < 8*uint32(m.Threads) {
+		return errors.New("invalid argon2 memory cost")
+	}
+
+	if m.Threads == 0 {
+		return errors.New("invalid argon2 threads")
+	}
+
+	if m.KeyLen != chacha20poly1305.KeySize {
+		return errors.New("invalid argon2 key length")
+	}
+
+	return nil
+}
+
+// Encrypt encrypts data with password,
+// 1. Argon2id derives the key from password
+// 2. Chacha20poly1305 generates AEAD from the derived key
+// 3. Puts argon2id paramenters, salt and nonce into metadata, json serialize it and get the serialized metadata length
+// 4. AEAD.Seal encrypts the data, and use [length][metadata] as additional data
+// 5. Final format: base64([[length][metadata]][ciphertext]), length is 2 bytes.
+func (a Argon2idChacha20poly1305) Encrypt(data, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("missing password")
+	}
+
+	// Prepare metadata
+	m := argon2Meta{
+		Time:    a.Time,
+		Memory:  a.Memory,
+		Threads: a.Threads,
+		KeyLen:  a.KeyLen,
+		Salt:    cipher.RandByte(argon2idChacha20SaltSize),
+		Nonce:   cipher.RandByte(chacha20poly1305.NonceSize),
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	// Argon2id derives key from password
+	dk := argon2.IDKey(password, m.Salt, m.Time, m.Memory, m.Threads, m.KeyLen)
+
+	// json serialize the metadata
+	ms, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ms) > math.MaxUint16 {
+		return nil, errors.New("metadata length beyond the math.MaxUint16")
+	}
+
+	length := make([]byte, argon2idChacha20MetaLengthSize)
+	binary.LittleEndian.PutUint16(length, uint16(len(ms)))
+
+	// Additional data for AEAD
+	ad := append(length, ms...)
+	aead, err := chacha20poly1305.New(dk)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := aead.Seal(nil, m.Nonce, data, ad)
+
+	// Base64 encode the [[length][metadata]][ciphertext]
+	rawData := append(ad, ciphertext...)
+	enc := base64.StdEncoding
+	buf := make([]byte, enc.EncodedLen(len(rawData)))
+	enc.Encode(buf, rawData)
+	return buf, nil
+}
+
+// Decrypt decrypts the data with password
+// 1. Base64 decodes the data
+// 2. Reads the first [metaLengthSize] bytes data to get the metadata length, and reads out the metadata.
+// 3. Argon2id derives key from password and paramenters in metadata
+// 4. Chacha20poly1305 geneates AEAD
+// 5. AEAD decrypts ciphertext with nonce in metadata and [length][metadata] as additional data.
+func (a Argon2idChacha20poly1305) Decrypt(data, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("missing password")
+	}
+
+	enc := base64.StdEncoding
+	encData := make([]byte, enc.DecodedLen(len(data)))
+	n, err := enc.Decode(encData, data)
+	if err != nil {
+		return nil, err
+	}
+	encData = encData[:n]
+
+	if len(encData) < argon2idChacha20MetaLengthSize {
+		return nil, errors.New("invalid data length")
+	}
+
+	length := argon2idChacha20MetaLengthSize + int(binary.LittleEndian.Uint16(encData[:argon2idChacha20MetaLengthSize]))
+	if length > len(encData) {
+		return nil, errors.New("invalid metadata length")
+	}
+
+	var m argon2Meta
+	if err := json.Unmarshal(encData[argon2idChacha20MetaLengthSize:length], &m); err != nil {
+		return nil, err
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(m.Nonce) != chacha20poly1305.NonceSize {
+		return nil, errors.New("invalid nonce length")
+	}
+
+	ad := encData[:length]
+	// Argon2id derives key
+	dk := argon2.IDKey(password, m.Salt, m.Time, m.Memory, m.Threads, m.KeyLen)
+
+	// Geneates AEAD
+	aead, err := chacha20poly1305.New(dk)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, m.Nonce, encData[length:], ad)
+}
@@ -0,0 +1,107 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgon2idChacha20poly1305Encrypt(t *testing.T) {
+	for _, c := range []struct {
+		time   uint32
+		memory uint32
+	}{
+		{1, 64},
+		{2, 1024},
+		{3, 4096},
+	} {
+		name := fmt.Sprintf("time=%v memory=%v threads=%v keyLen=%v", c.time, c.memory, 4, 32)
+		t.Run(name, func(t *testing.T) {
+			crypto := Argon2idChacha20poly1305{Time: c.time, Memory: c.memory, Threads: 4, KeyLen: 32}
+			encData, err := crypto.Encrypt([]byte("plaintext"), []byte("password"))
+			require.NoError(t, err)
+
+			data, err := base64.StdEncoding.DecodeString(string(encData))
+			require.NoError(t, err)
+			// Checks the prefix
+			ml := binary.LittleEndian.Uint16(data[:argon2idChacha20MetaLengthSize])
+			require.True(t, int(argon2idChacha20MetaLengthSize+ml) <= len(data))
+			var m argon2Meta
+			require.NoError(t, json.Unmarshal(data[argon2idChacha20MetaLengthSize:argon2idChacha20MetaLengthSize+ml], &m))
+			require.Equal(t, c.time, m.Time)
+			require.Equal(t, c.memory, m.Memory)
+			require.Equal(t, uint8(4), m.Threads)
+			require.Equal(t, uint32(32), m.KeyLen)
+
+			// Decryption uses the paramenters in the metadata
+			plaintext, err := Argon2idChacha20poly1305{}.Decrypt(encData, []byte("password"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("plaintext"), plaintext)
+		})
+	}
+
+	_, err := Argon2idChacha20poly1305{Time: 0, Memory: 64, Threads: 4, KeyLen: 32}.Encrypt([]byte("plaintext"), []byte("password"))
+	require.Equal(t, errors.New("invalid argon2 time cost"), err)
+
+	_, err = Argon2idChacha20poly1305{Time: 1, Memory: 16, Threads: 4, KeyLen: 32}.Encrypt([]byte("plaintext"), []byte("password"))
+	require.Equal(t, errors.New("invalid argon2 memory cost"), err)
+
+	_, err = Argon2idChacha20poly1305{Time: 1, Memory: 64, Threads: 4, KeyLen: 16}.Encrypt([]byte("plaintext"), []byte("password"))
+	require.Equal(t, errors.New("invalid argon2 key length"), err)
+}
+
+func TestArgon2idChacha20poly1305Decrypt(t *testing.T) {
+	encData := []byte("fwB7InRpbWUiOjEsIm1lbW9yeSI6NjQsInRocmVhZHMiOjQsImtleUxlbiI6MzIsInNhbHQiOiI3SjJqeDQrSnBDaTQ5Ulh3Y1BLeXMvQ1B1YTBoOUcwTEdCLzhGQVZsWi9ZPSIsIm5vbmNlIjoiV0loclBmcnRoOFpUNFlGbiJ92YymLd9PF+QpdcmabtAv9MpJsXBsEZMPvQ==")
+
+	tt := []struct {
+		name    string
+		data    []byte
+		encData []byte
+		decPwd  []byte
+		err     error
+	}{
+		{
+			name:    "ok",
+			data:    []byte("plaintext"),
+			encData: encData,
+			decPwd:  []byte("pwd"),
+		},
+		{
+			name:    "invalid password",
+			encData: encData,
+			decPwd:  []byte("wrong password"),
+			err:     errors.New("chacha20poly1305: message authentication failed"),
+		},
+		{
+			name:    "missing password",
+			encData: encData,
+			decPwd:  nil,
+			err:     errors.New("missing password"),
+		},
+		{
+			name:    "invalid metadata length",
+			encData: []byte(base64.StdEncoding.EncodeToString([]byte{0xff, 0xff, 0x01})),
+			decPwd:  []byte("pwd"),
+			err:     errors.New("invalid metadata length"),
+		},
+	}
+
+	for _, tc := range tt {
+		name := fmt.Sprintf("time=1 memory=64 threads=4 keyLen=32 %v", tc.name)
+		t.Run(name, func(t *testing.T) {
+			crypto := Argon2idChacha20poly1305{}
+			data, err := crypto.Decrypt(tc.encData, tc.decPwd)
+			require.Equal(t, tc.err, err)
+			if err != nil {
+				return
+			}
+
+			require.Equal(t, tc.data, data)
+		})
+	}
+}
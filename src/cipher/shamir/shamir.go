@@ -0,0 +1,331 @@
+/*
+Package shamir implements Shamir's secret sharing over GF(256), with shares encoded as mnemonics.
+
+A secret is split into n shares, any threshold of which recover the secret.
+Fewer shares than the threshold reveal nothing about the secret.
+
+Each share is encoded as words of the bip39 english word list, 11 bits per word.
+The encoded share is:
+
+	id (2 bytes) | threshold (1 byte) | index (1 byte) | length (2 bytes) | value | checksum (4 bytes)
+
+The id is random and is the same for all shares of a split. The checksum is the first 4 bytes of the
+SHA256 of the other fields, so that mistyped share words are detected. The value is the share of the
+secret followed by the first 4 bytes of its SHA256, which detects combining shares which don't recover the secret.
+*/
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip39/wordlists"
+)
+
+const (
+	// MaxShares is the maximum number of shares of a secret
+	MaxShares = 255
+	// MaxSecretLength is the maximum length of a secret
+	MaxSecretLength = 1024
+
+	headerLength   = 6
+	checksumLength = 4
+	bitsPerWord    = 11
+)
+
+var (
+	// ErrInvalidThreshold is returned if the threshold is less than 2 or greater than the number of shares
+	ErrInvalidThreshold = errors.New("Threshold must be at least 2 and at most the number of shares")
+	// ErrTooManyShares is returned if more than MaxShares shares are requested
+	ErrTooManyShares = fmt.Errorf("Number of shares must be at most %d", MaxShares)
+	// ErrEmptySecret is returned if the secret is empty
+	ErrEmptySecret = errors.New("Secret is empty")
+	// ErrSecretTooLong is returned if the secret is longer than MaxSecretLength
+	ErrSecretTooLong = fmt.Errorf("Secret must be at most %d bytes", MaxSecretLength)
+	// ErrInvalidShareLength is returned if the number of words of a share doesn't match its encoded length
+	ErrInvalidShareLength = errors.New("Invalid share length")
+	// ErrInvalidShareChecksum is returned if the checksum of a share is wrong
+	ErrInvalidShareChecksum = errors.New("Invalid share checksum")
+	// ErrNotEnoughShares is returned if fewer shares than the threshold are combined
+	ErrNotEnoughShares = errors.New("Not enough shares to recover the secret")
+	// ErrSharesMismatch is returned if shares of different splits are combined
+	ErrSharesMismatch = errors.New("Shares are not from the same split")
+	// ErrDuplicateShare is returned if the same share is combined twice
+	ErrDuplicateShare = errors.New("Duplicate share")
+	// ErrSecretChecksum is returned if the recovered secret doesn't match its checksum
+	ErrSecretChecksum = errors.New("Recovered secret checksum is wrong, the shares are corrupted")
+)
+
+var (
+	expTable [255]byte
+	logTable [256]byte
+	wordMap  map[string]int
+)
+
+func init() {
+	// Generate the exp and log tables of GF(256) with the AES polynomial x^8 + x^4 + x^3 + x + 1,
+	// using the generator 3
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		// x *= 3
+		x ^= xtime(x)
+	}
+
+	wordMap = make(map[string]int, len(wordlists.English))
+	for i, w := range wordlists.English {
+		wordMap[w] = i
+	}
+}
+
+// xtime multiplies by x in GF(256)
+func xtime(b byte) byte {
+	if b&0x80 != 0 {
+		return (b << 1) ^ 0x1b
+	}
+	return b << 1
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if b == 0 {
+		panic("shamir: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+255-int(logTable[b]))%255]
+}
+
+// share is a decoded share
+type share struct {
+	id        uint16
+	threshold byte
+	index     byte
+	value     []byte
+}
+
+// Split splits a secret into n share mnemonics, any threshold of which recover the secret
+func Split(secret []byte, threshold, n int) ([]string, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if len(secret) > MaxSecretLength {
+		return nil, ErrSecretTooLong
+	}
+	if n > MaxShares {
+		return nil, ErrTooManyShares
+	}
+	if threshold < 2 || threshold > n {
+		return nil, ErrInvalidThreshold
+	}
+
+	sum := sha256.Sum256(secret)
+	s := make([]byte, 0, len(secret)+checksumLength)
+	s = append(s, secret...)
+	s = append(s, sum[:checksumLength]...)
+
+	id := binary.BigEndian.Uint16(cipher.RandByte(2))
+
+	shares := make([]share, n)
+	for i := range shares {
+		shares[i] = share{
+			id:        id,
+			threshold: byte(threshold),
+			index:     byte(i + 1),
+			value:     make([]byte, len(s)),
+		}
+	}
+
+	// Each byte of the secret is the constant term of a random polynomial of degree threshold-1,
+	// evaluated at the index of each share
+	for j, b := range s {
+		coeffs := cipher.RandByte(threshold - 1)
+		for i := range shares {
+			x := shares[i].index
+			var y byte
+			for k := len(coeffs) - 1; k >= 0; k-- {
+				y = gfMul(y^coeffs[k], x)
+			}
+			shares[i].value[j] = y ^ b
+		}
+
+		for k := range coeffs {
+			coeffs[k] = 0
+		}
+	}
+
+	mnemonics := make([]string, n)
+	for i, sh := range shares {
+		mnemonics[i] = sh.mnemonic()
+	}
+
+	return mnemonics, nil
+}
+
+// Combine recovers the secret of share mnemonics
+func Combine(mnemonics []string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+
+	shares := make([]share, len(mnemonics))
+	for i, m := range mnemonics {
+		sh, err := parseShare(m)
+		if err != nil {
+			return nil, fmt.Errorf("Share %d: %v", i, err)
+		}
+		shares[i] = sh
+	}
+
+	first := shares[0]
+	indexes := make(map[byte]struct{}, len(shares))
+	for _, sh := range shares {
+		if sh.id != first.id || sh.threshold != first.threshold || len(sh.value) != len(first.value) {
+			return nil, ErrSharesMismatch
+		}
+
+		if _, ok := indexes[sh.index]; ok {
+			return nil, ErrDuplicateShare
+		}
+		indexes[sh.index] = struct{}{}
+	}
+
+	if len(shares) < int(first.threshold) {
+		return nil, ErrNotEnoughShares
+	}
+
+	// Lagrange interpolation at x = 0
+	s := make([]byte, len(first.value))
+	for i, sh := range shares {
+		var num, den byte = 1, 1
+		for k, o := range shares {
+			if k == i {
+				continue
+			}
+			num = gfMul(num, o.index)
+			den = gfMul(den, sh.index^o.index)
+		}
+		l := gfDiv(num, den)
+
+		for j, y := range sh.value {
+			s[j] ^= gfMul(y, l)
+		}
+	}
+
+	secret := s[:len(s)-checksumLength]
+	sum := sha256.Sum256(secret)
+	if !bytes.Equal(sum[:checksumLength], s[len(secret):]) {
+		return nil, ErrSecretChecksum
+	}
+
+	return secret, nil
+}
+
+// Threshold returns the number of shares required to recover the secret of a share mnemonic
+func Threshold(mnemonic string) (int, error) {
+	sh, err := parseShare(mnemonic)
+	if err != nil {
+		return 0, err
+	}
+	return int(sh.threshold), nil
+}
+
+func (sh share) mnemonic() string {
+	b := make([]byte, headerLength, headerLength+len(sh.value)+checksumLength)
+	binary.BigEndian.PutUint16(b[0:2], sh.id)
+	b[2] = sh.threshold
+	b[3] = sh.index
+	binary.BigEndian.PutUint16(b[4:6], uint16(len(sh.value)))
+	b = append(b, sh.value...)
+
+	sum := sha256.Sum256(b)
+	b = append(b, sum[:checksumLength]...)
+
+	nWords := (len(b)*8 + bitsPerWord - 1) / bitsPerWord
+	words := make([]string, nWords)
+	for i := range words {
+		var w int
+		for k := 0; k < bitsPerWord; k++ {
+			w = (w << 1) | bit(b, i*bitsPerWord+k)
+		}
+		words[i] = wordlists.English[w]
+	}
+
+	return strings.Join(words, " ")
+}
+
+func parseShare(mnemonic string) (share, error) {
+	words := strings.Fields(mnemonic)
+
+	nBytes := len(words) * bitsPerWord / 8
+	if nBytes < headerLength+checksumLength+1 {
+		return share{}, ErrInvalidShareLength
+	}
+
+	b := make([]byte, (len(words)*bitsPerWord+7)/8)
+	for i, w := range words {
+		n, ok := wordMap[strings.ToLower(w)]
+		if !ok {
+			return share{}, fmt.Errorf("Invalid share word %q", w)
+		}
+
+		for k := 0; k < bitsPerWord; k++ {
+			if n&(1<<uint(bitsPerWord-1-k)) != 0 {
+				pos := i*bitsPerWord + k
+				b[pos/8] |= 0x80 >> uint(pos%8)
+			}
+		}
+	}
+
+	length := int(binary.BigEndian.Uint16(b[4:6]))
+	total := headerLength + length + checksumLength
+	if length <= checksumLength || (total*8+bitsPerWord-1)/bitsPerWord != len(words) {
+		return share{}, ErrInvalidShareLength
+	}
+
+	// The padding bits must be zero
+	for pos := total * 8; pos < len(words)*bitsPerWord; pos++ {
+		if bit(b, pos) != 0 {
+			return share{}, ErrInvalidShareChecksum
+		}
+	}
+
+	sum := sha256.Sum256(b[:total-checksumLength])
+	if !bytes.Equal(sum[:checksumLength], b[total-checksumLength:total]) {
+		return share{}, ErrInvalidShareChecksum
+	}
+
+	sh := share{
+		id:        binary.BigEndian.Uint16(b[0:2]),
+		threshold: b[2],
+		index:     b[3],
+		value:     b[headerLength : headerLength+length],
+	}
+
+	if sh.threshold < 2 || sh.index == 0 {
+		return share{}, ErrInvalidShareChecksum
+	}
+
+	return sh, nil
+}
+
+// bit returns the bit at pos of b, most significant bit first, or 0 if pos is beyond b
+func bit(b []byte, pos int) int {
+	if pos/8 >= len(b) {
+		return 0
+	}
+	return int(b[pos/8]>>uint(7-pos%8)) & 1
+}
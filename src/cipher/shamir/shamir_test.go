@@ -0,0 +1,255 @@
+package shamir
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/bip39/wordlists"
+)
+
+func TestGF256(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			p := gfMul(byte(a), byte(b))
+			require.Equal(t, byte(a), gfDiv(p, byte(b)))
+		}
+	}
+
+	// 0x57 * 0x83 = 0xc1, from FIPS-197
+	require.Equal(t, byte(0xc1), gfMul(0x57, 0x83))
+	require.Equal(t, byte(0), gfMul(0, 0x83))
+}
+
+func TestSplitCombine(t *testing.T) {
+	mnemonic := bip39.MustNewDefaultMnemonic()
+
+	cases := []struct {
+		name      string
+		secret    []byte
+		threshold int
+		n         int
+	}{
+		{
+			name:      "2-of-3 mnemonic",
+			secret:    []byte(mnemonic),
+			threshold: 2,
+			n:         3,
+		},
+		{
+			name:      "3-of-5 mnemonic",
+			secret:    []byte(mnemonic),
+			threshold: 3,
+			n:         5,
+		},
+		{
+			name:      "5-of-5 seed",
+			secret:    []byte("foo"),
+			threshold: 5,
+			n:         5,
+		},
+		{
+			name:      "1 byte",
+			secret:    []byte{0},
+			threshold: 2,
+			n:         2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			shares, err := Split(tc.secret, tc.threshold, tc.n)
+			require.NoError(t, err)
+			require.Len(t, shares, tc.n)
+
+			for _, s := range shares {
+				m, err := Threshold(s)
+				require.NoError(t, err)
+				require.Equal(t, tc.threshold, m)
+
+				for _, w := range strings.Fields(s) {
+					require.Contains(t, wordlists.English, w)
+				}
+			}
+
+			// Any threshold of the shares recover the secret
+			for i := 0; i+tc.threshold <= tc.n; i++ {
+				secret, err := Combine(shares[i : i+tc.threshold])
+				require.NoError(t, err)
+				require.Equal(t, tc.secret, secret)
+			}
+
+			// More shares than the threshold also recover the secret
+			secret, err := Combine(shares)
+			require.NoError(t, err)
+			require.Equal(t, tc.secret, secret)
+
+			// The order of the shares doesn't matter
+			reversed := make([]string, tc.threshold)
+			for i := range reversed {
+				reversed[i] = shares[tc.threshold-1-i]
+			}
+			secret, err = Combine(reversed)
+			require.NoError(t, err)
+			require.Equal(t, tc.secret, secret)
+
+			_, err = Combine(shares[:tc.threshold-1])
+			require.Equal(t, ErrNotEnoughShares, err)
+		})
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	cases := []struct {
+		name      string
+		secret    []byte
+		threshold int
+		n         int
+		err       error
+	}{
+		{
+			name:      "empty secret",
+			threshold: 2,
+			n:         3,
+			err:       ErrEmptySecret,
+		},
+		{
+			name:      "secret too long",
+			secret:    make([]byte, MaxSecretLength+1),
+			threshold: 2,
+			n:         3,
+			err:       ErrSecretTooLong,
+		},
+		{
+			name:      "threshold 1",
+			secret:    []byte("foo"),
+			threshold: 1,
+			n:         3,
+			err:       ErrInvalidThreshold,
+		},
+		{
+			name:      "threshold greater than n",
+			secret:    []byte("foo"),
+			threshold: 4,
+			n:         3,
+			err:       ErrInvalidThreshold,
+		},
+		{
+			name:      "too many shares",
+			secret:    []byte("foo"),
+			threshold: 2,
+			n:         MaxShares + 1,
+			err:       ErrTooManyShares,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Split(tc.secret, tc.threshold, tc.n)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	secret := []byte(bip39.MustNewDefaultMnemonic())
+	shares, err := Split(secret, 2, 3)
+	require.NoError(t, err)
+
+	sh, err := parseShare(shares[0])
+	require.NoError(t, err)
+
+	// The ids of the splits must differ
+	var otherShares []string
+	for {
+		otherShares, err = Split(secret, 2, 3)
+		require.NoError(t, err)
+		o, err := parseShare(otherShares[0])
+		require.NoError(t, err)
+		if o.id != sh.id {
+			break
+		}
+	}
+
+	// Replace a word of the value of a share
+	words := strings.Fields(shares[1])
+	if words[6] == "abandon" {
+		words[6] = "ability"
+	} else {
+		words[6] = "abandon"
+	}
+	mistyped := strings.Join(words, " ")
+
+	words = strings.Fields(shares[1])
+	words[2] = "foo"
+	unknownWord := strings.Join(words, " ")
+
+	words = strings.Fields(shares[1])
+	truncated := strings.Join(words[:len(words)-1], " ")
+
+	cases := []struct {
+		name   string
+		shares []string
+		err    error
+	}{
+		{
+			name: "no shares",
+			err:  ErrNotEnoughShares,
+		},
+		{
+			name:   "mistyped word",
+			shares: []string{shares[0], mistyped},
+			err:    errors.New("Share 1: Invalid share checksum"),
+		},
+		{
+			name:   "unknown word",
+			shares: []string{shares[0], unknownWord},
+			err:    errors.New(`Share 1: Invalid share word "foo"`),
+		},
+		{
+			name:   "truncated",
+			shares: []string{shares[0], truncated},
+			err:    errors.New("Share 1: Invalid share length"),
+		},
+		{
+			name:   "duplicate share",
+			shares: []string{shares[0], shares[0]},
+			err:    ErrDuplicateShare,
+		},
+		{
+			name:   "shares of another split",
+			shares: []string{shares[0], otherShares[1]},
+			err:    ErrSharesMismatch,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Combine(tc.shares)
+			require.Equal(t, tc.err, err)
+		})
+	}
+
+	// Case and whitespace of the words are ignored
+	secret2, err := Combine([]string{strings.ToUpper(shares[0]), "  " + strings.Replace(shares[2], " ", "\n", -1)})
+	require.NoError(t, err)
+	require.Equal(t, secret, secret2)
+}
+
+func TestCombineCorrupted(t *testing.T) {
+	secret := []byte("foo")
+	shares, err := Split(secret, 2, 2)
+	require.NoError(t, err)
+
+	// Forge a share with a valid encoding but a different value
+	sh, err := parseShare(shares[1])
+	require.NoError(t, err)
+	sh.value = append([]byte{}, sh.value...)
+	sh.value[0] ^= 1
+
+	_, err = Combine([]string{shares[0], sh.mnemonic()})
+	require.Equal(t, ErrSecretChecksum, err)
+}
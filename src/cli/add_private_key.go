@@ -1,23 +1,30 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/wallet"
 )
 
+// ErrWalletNotCollection is returned when adding a private key to a wallet which is not a collection wallet
+var ErrWalletNotCollection = errors.New(`keys can only be added to collection wallets, so that seed wallets can always be recovered from their seed. Create a collection wallet with "walletCreate -t collection"`)
+
 func addPrivateKeyCmd() *cobra.Command {
 	addPrivateKeyCmd := &cobra.Command{
-		Short: "Add a private key to specific wallet",
+		Short: "Add a private key to specific collection wallet",
 		Use:   "addPrivateKey [flags] [private key]",
-		Long: fmt.Sprintf(`Add a private key to specific wallet, the default
+		Long: fmt.Sprintf(`Add a private key to specific collection wallet, the default
     wallet (%s) will be
     used if the wallet file or path is not specified
 
+    The private key can be a hex string or in Bitcoin wallet import format (WIF).
+    Keys can only be added to collection wallets, create one with
+    "walletCreate -t collection".
+
     Use caution when using the "-p" command. If you have command
     history enabled your wallet encryption password can be recovered from the
     history log. If you do not include the "-p" option you will be prompted to
@@ -70,27 +77,15 @@ func addPrivateKeyCmd() *cobra.Command {
 	return addPrivateKeyCmd
 }
 
-// AddPrivateKey adds a private key to a *wallet.Wallet. Caller should save the wallet afterwards
+// AddPrivateKey adds a private key to a collection *wallet.Wallet. Caller should save the wallet afterwards
 func AddPrivateKey(wlt *wallet.Wallet, key string) error {
-	sk, err := cipher.SecKeyFromHex(key)
-	if err != nil {
-		return fmt.Errorf("invalid private key: %s, must be a hex string of length 64", key)
-	}
-
-	pk, err := cipher.PubKeyFromSecKey(sk)
+	sk, err := wallet.ParseSecretKey(key)
 	if err != nil {
 		return err
 	}
 
-	addr := cipher.AddressFromPubKey(pk)
-
-	entry := wallet.Entry{
-		Address: addr,
-		Public:  pk,
-		Secret:  sk,
-	}
-
-	return wlt.AddEntry(entry)
+	_, err = wlt.ImportSecretKey(sk)
+	return err
 }
 
 // AddPrivateKeyToFile adds a private key to a wallet based on filename.  Will save the wallet after modifying.
@@ -100,6 +95,11 @@ func AddPrivateKeyToFile(walletFile, key string, pr PasswordReader) error {
 		return WalletLoadError{err}
 	}
 
+	// Checked before asking for the password of an encrypted wallet
+	if wlt.Type() != wallet.WalletTypeCollection {
+		return ErrWalletNotCollection
+	}
+
 	switch pr.(type) {
 	case nil:
 		if wlt.IsEncrypted() {
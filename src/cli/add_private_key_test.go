@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestAddPrivateKeyToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, sk := cipher.GenerateKeyPair()
+
+	// Keys can't be added to seed wallets, the password is not asked for
+	makeTestWallet(t, dir, "a.wlt", "seed a", []byte("pwd"), wallet.CryptoTypeSha256Xor)
+	err = AddPrivateKeyToFile(filepath.Join(dir, "a.wlt"), sk.Hex(), nil)
+	require.Equal(t, ErrWalletNotCollection, err)
+
+	w, err := wallet.NewWallet("b.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Save(dir))
+
+	fn := filepath.Join(dir, "b.wlt")
+	err = AddPrivateKeyToFile(fn, sk.Hex(), nil)
+	require.NoError(t, err)
+
+	w, err = wallet.Load(fn)
+	require.NoError(t, err)
+	require.True(t, w.HasEntry(cipher.MustAddressFromSecKey(sk)))
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// WalletPasswordResult is the result of changing the password or crypto type of a wallet in the wallet directory
+type WalletPasswordResult struct {
+	Name       string `json:"name"`
+	CryptoType string `json:"crypto_type,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Status values of WalletPasswordResult
+const (
+	walletPasswordChanged = "changed"
+	walletPasswordSkipped = "skipped"
+	walletPasswordFailed  = "failed"
+)
+
+func changeWalletPasswordCmd() *gcli.Command {
+	changeWalletPasswordCmd := &gcli.Command{
+		Short: "Change the password or crypto type of an encrypted wallet",
+		Use:   "changeWalletPassword",
+		Long: fmt.Sprintf(`Re-encrypts an encrypted wallet with a new password and/or crypto type.
+    The wallet is never saved unencrypted. The default wallet (%s) will be
+    used if no wallet was specified.
+
+    If "-x" is set and "-n" is not set, only the crypto type is changed and
+    the password is kept. Otherwise the password is changed, and the crypto
+    type is kept unless "-x" is set.
+
+    With "-a", every encrypted wallet in the wallet directory is re-encrypted
+    with the same password. Wallets which are unencrypted, or which already use
+    the crypto type when only migrating the crypto type, are skipped.
+
+    Use caution when using the "-p" and "-n" options. If you have command
+    history enabled your wallet encryption passwords can be recovered from the
+    history log. If you do not include the "-p" or "-n" option you will be
+    prompted to enter your password after you enter your command.`, cliConfig.FullWalletPath()),
+		SilenceUsage: true,
+		RunE:         changeWalletPasswordHandler,
+	}
+
+	changeWalletPasswordCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+	changeWalletPasswordCmd.Flags().StringP("password", "p", "", "Current wallet password")
+	changeWalletPasswordCmd.Flags().StringP("new-password", "n", "", "New wallet password")
+	changeWalletPasswordCmd.Flags().StringP("crypto-type", "x", "", "The crypto type to re-encrypt the wallet with, can be scrypt-chacha20poly1305, argon2id-chacha20poly1305 or sha256-xor. The crypto type is kept if not set")
+	changeWalletPasswordCmd.Flags().BoolP("all", "a", false, "Re-encrypt every encrypted wallet in the wallet directory")
+	changeWalletPasswordCmd.Flags().StringP("wallet-dir", "d", "", "Wallet directory used with -a. If not set, the default wallet directory will be used.")
+
+	return changeWalletPasswordCmd
+}
+
+func changeWalletPasswordHandler(c *gcli.Command, _ []string) error {
+	walletFile, err := c.Flags().GetString("wallet-file")
+	if err != nil {
+		return err
+	}
+
+	all, err := c.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+
+	walletDir, err := c.Flags().GetString("wallet-dir")
+	if err != nil {
+		return err
+	}
+
+	if all && walletFile != "" {
+		return errors.New("-f and -a can't be used together")
+	}
+
+	if !all && walletDir != "" {
+		return errors.New("-d can only be used with -a")
+	}
+
+	var cryptoType wallet.CryptoType
+	if s := c.Flag("crypto-type").Value.String(); s != "" {
+		cryptoType, err = wallet.CryptoTypeFromString(s)
+		if err != nil {
+			printHelp(c)
+			return err
+		}
+	}
+
+	password, err := NewPasswordReader([]byte(c.Flag("password").Value.String())).Password()
+	if err != nil {
+		return err
+	}
+
+	// Only the crypto type is migrated if a crypto type is given without a new password
+	newPassword := []byte(c.Flag("new-password").Value.String())
+	if len(newPassword) == 0 && cryptoType == "" {
+		newPassword, err = readNewPasswordFromTerminal()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !all {
+		w, err := resolveWalletPath(cliConfig, walletFile)
+		if err != nil {
+			return err
+		}
+
+		wlt, err := changeWalletPassword(w, password, newPassword, cryptoType)
+		switch err.(type) {
+		case nil:
+		case WalletLoadError:
+			printHelp(c)
+			return err
+		default:
+			return err
+		}
+
+		return printJSON(wallet.NewReadableWallet(wlt))
+	}
+
+	if walletDir == "" {
+		walletDir = cliConfig.WalletDir
+	}
+
+	results, err := changeWalletPasswords(walletDir, password, newPassword, cryptoType)
+	if err != nil {
+		return err
+	}
+
+	if err := printJSON(struct {
+		Wallets []WalletPasswordResult `json:"wallets"`
+	}{
+		Wallets: results,
+	}); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Status == walletPasswordFailed {
+			return errors.New("some wallets could not be re-encrypted")
+		}
+	}
+
+	return nil
+}
+
+// readNewPasswordFromTerminal prompts for the new password twice and checks that they match
+func readNewPasswordFromTerminal() ([]byte, error) {
+	p, err := readPasswordFromTerminalWithPrompt("enter new password:")
+	if err != nil {
+		return nil, err
+	}
+
+	p2, err := readPasswordFromTerminalWithPrompt("confirm new password:")
+	if err != nil {
+		return nil, err
+	}
+
+	if string(p) != string(p2) {
+		return nil, errors.New("new passwords do not match")
+	}
+
+	return p, nil
+}
+
+// changeWalletPassword re-encrypts an encrypted wallet file with a new password and crypto type.
+// An empty newPassword keeps the password, an empty cryptoType keeps the crypto type.
+func changeWalletPassword(walletFile string, password, newPassword []byte, cryptoType wallet.CryptoType) (*wallet.Wallet, error) {
+	wlt, err := wallet.Load(walletFile)
+	if err != nil {
+		return nil, WalletLoadError{err}
+	}
+
+	if !wlt.IsEncrypted() {
+		return nil, wallet.ErrWalletNotEncrypted
+	}
+
+	if len(newPassword) == 0 {
+		newPassword = password
+	}
+
+	if err := wlt.Reencrypt(password, newPassword, cryptoType); err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(walletFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wlt.Save(dir); err != nil {
+		return nil, WalletSaveError{err}
+	}
+
+	return wlt, nil
+}
+
+// changeWalletPasswords re-encrypts every encrypted wallet in a directory with a new password and crypto type.
+// Wallets which fail to be re-encrypted are reported in the results and don't stop the other wallets.
+func changeWalletPasswords(dir string, password, newPassword []byte, cryptoType wallet.CryptoType) ([]WalletPasswordResult, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []WalletPasswordResult{}
+	for _, e := range entries {
+		if !e.Mode().IsRegular() || !strings.HasSuffix(e.Name(), walletExt) {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		wlt, err := wallet.Load(path)
+		if err != nil {
+			results = append(results, WalletPasswordResult{
+				Name:   e.Name(),
+				Status: walletPasswordFailed,
+				Error:  WalletLoadError{err}.Error(),
+			})
+			continue
+		}
+
+		currentCryptoType := wlt.CryptoType()
+		if !wlt.IsEncrypted() || (len(newPassword) == 0 && currentCryptoType == cryptoType) {
+			results = append(results, WalletPasswordResult{
+				Name:       e.Name(),
+				CryptoType: string(currentCryptoType),
+				Status:     walletPasswordSkipped,
+			})
+			continue
+		}
+
+		wlt, err = changeWalletPassword(path, password, newPassword, cryptoType)
+		if err != nil {
+			results = append(results, WalletPasswordResult{
+				Name:       e.Name(),
+				CryptoType: string(currentCryptoType),
+				Status:     walletPasswordFailed,
+				Error:      err.Error(),
+			})
+			continue
+		}
+
+		results = append(results, WalletPasswordResult{
+			Name:       e.Name(),
+			CryptoType: string(wlt.CryptoType()),
+			Status:     walletPasswordChanged,
+		})
+	}
+
+	return results, nil
+}
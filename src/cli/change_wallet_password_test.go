@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func makeTestWallet(t *testing.T, dir, name, seed string, password []byte, cryptoType wallet.CryptoType) {
+	w, err := wallet.NewWallet(name, wallet.Options{
+		Seed:       seed,
+		Encrypt:    len(password) != 0,
+		Password:   password,
+		CryptoType: cryptoType,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Save(dir))
+}
+
+func TestChangeWalletPassword(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	makeTestWallet(t, dir, "a.wlt", "seed a", []byte("pwd"), wallet.CryptoTypeSha256Xor)
+	makeTestWallet(t, dir, "b.wlt", "seed b", nil, "")
+	fn := filepath.Join(dir, "a.wlt")
+
+	_, err = changeWalletPassword(fn, []byte("wrong"), []byte("new pwd"), "")
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+
+	_, err = changeWalletPassword(filepath.Join(dir, "b.wlt"), []byte("pwd"), []byte("new pwd"), "")
+	require.Equal(t, wallet.ErrWalletNotEncrypted, err)
+
+	_, err = changeWalletPassword(filepath.Join(dir, "c.wlt"), []byte("pwd"), []byte("new pwd"), "")
+	require.IsType(t, WalletLoadError{}, err)
+
+	// Change the password, keeping the crypto type
+	w, err := changeWalletPassword(fn, []byte("pwd"), []byte("new pwd"), "")
+	require.NoError(t, err)
+	require.Equal(t, wallet.CryptoTypeSha256Xor, w.CryptoType())
+
+	w, err = wallet.Load(fn)
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	_, err = w.Unlock([]byte("pwd"))
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+	_, err = w.Unlock([]byte("new pwd"))
+	require.NoError(t, err)
+
+	// Migrate the crypto type, keeping the password
+	_, err = changeWalletPassword(fn, []byte("new pwd"), nil, wallet.CryptoTypeScryptChacha20poly1305Insecure)
+	require.NoError(t, err)
+
+	w, err = wallet.Load(fn)
+	require.NoError(t, err)
+	require.Equal(t, wallet.CryptoTypeScryptChacha20poly1305Insecure, w.CryptoType())
+	_, err = w.Unlock([]byte("new pwd"))
+	require.NoError(t, err)
+}
+
+func TestChangeWalletPasswords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	makeTestWallet(t, dir, "a.wlt", "seed a", []byte("pwd"), wallet.CryptoTypeSha256Xor)
+	makeTestWallet(t, dir, "b.wlt", "seed b", nil, "")
+	makeTestWallet(t, dir, "c.wlt", "seed c", []byte("other pwd"), wallet.CryptoTypeSha256Xor)
+	makeTestWallet(t, dir, "d.wlt", "seed d", []byte("pwd"), wallet.CryptoTypeScryptChacha20poly1305Insecure)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0600))
+
+	results, err := changeWalletPasswords(dir, []byte("pwd"), nil, wallet.CryptoTypeScryptChacha20poly1305Insecure)
+	require.NoError(t, err)
+	require.Equal(t, []WalletPasswordResult{
+		{
+			Name:       "a.wlt",
+			CryptoType: string(wallet.CryptoTypeScryptChacha20poly1305Insecure),
+			Status:     walletPasswordChanged,
+		},
+		{
+			Name:   "b.wlt",
+			Status: walletPasswordSkipped,
+		},
+		{
+			Name:       "c.wlt",
+			CryptoType: string(wallet.CryptoTypeSha256Xor),
+			Status:     walletPasswordFailed,
+			Error:      wallet.ErrInvalidPassword.Error(),
+		},
+		{
+			Name:       "d.wlt",
+			CryptoType: string(wallet.CryptoTypeScryptChacha20poly1305Insecure),
+			Status:     walletPasswordSkipped,
+		},
+	}, results)
+
+	w, err := wallet.Load(filepath.Join(dir, "a.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, wallet.CryptoTypeScryptChacha20poly1305Insecure, w.CryptoType())
+	_, err = w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+
+	// The failed wallet is unchanged
+	w, err = wallet.Load(filepath.Join(dir, "c.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, wallet.CryptoTypeSha256Xor, w.CryptoType())
+	_, err = w.Unlock([]byte("other pwd"))
+	require.NoError(t, err)
+
+	// Changing the password re-encrypts wallets already using the crypto type
+	results, err = changeWalletPasswords(dir, []byte("pwd"), []byte("new pwd"), "")
+	require.NoError(t, err)
+	require.Equal(t, walletPasswordChanged, results[3].Status)
+
+	w, err = wallet.Load(filepath.Join(dir, "d.wlt"))
+	require.NoError(t, err)
+	_, err = w.Unlock([]byte("new pwd"))
+	require.NoError(t, err)
+}
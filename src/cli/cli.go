@@ -226,9 +226,12 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		addressBalanceCmd(),
 		addressGenCmd(),
 		fiberAddressGenCmd(),
+		paperWalletCmd(),
+		paperWalletDecryptCmd(),
 		addressOutputsCmd(),
 		blocksCmd(),
 		broadcastTxCmd(),
+		changeWalletPasswordCmd(),
 		checkDBCmd(),
 		checkDBEncodingCmd(),
 		createRawTxnCmd(),
@@ -241,20 +244,33 @@ func NewCLI(cfg Config) (*cobra.Command, error) {
 		sendCmd(),
 		showConfigCmd(),
 		showSeedCmd(),
+		signMessageCmd(),
 		statusCmd(),
 		transactionCmd(),
 		verifyTransactionCmd(),
 		verifyAddressCmd(),
+		verifyMessageCmd(),
 		versionCmd(),
 		walletCreateCmd(),
 		walletAddAddressesCmd(),
+		walletBackupCmd(),
 		walletBalanceCmd(),
 		walletDirCmd(),
+		walletFreezeOutputsCmd(),
+		walletFrozenOutputsCmd(),
 		walletHisCmd(),
 		walletOutputsCmd(),
+		walletRestoreCmd(),
+		walletSplitSeedCmd(),
+		walletCombineSeedCmd(),
+		walletUnfreezeOutputsCmd(),
 		richlistCmd(),
 		addressTransactionsCmd(),
 		pendingTransactionsCmd(),
+		pstCreateCmd(),
+		pstSignCmd(),
+		pstCombineCmd(),
+		pstFinalizeCmd(),
 		addresscountCmd(),
 	}
 
@@ -294,8 +310,13 @@ func printJSON(obj interface{}) error {
 
 // readPasswordFromTerminal promotes user to enter password and read it.
 func readPasswordFromTerminal() ([]byte, error) {
+	return readPasswordFromTerminalWithPrompt("enter password:")
+}
+
+// readPasswordFromTerminalWithPrompt promotes user to enter a password with the given prompt and read it.
+func readPasswordFromTerminalWithPrompt(prompt string) ([]byte, error) {
 	// Promotes to enter the wallet password
-	fmt.Fprint(os.Stdout, "enter password:")
+	fmt.Fprint(os.Stdout, prompt)
 	bp, err := terminal.ReadPassword(int(syscall.Stdin)) // nolint: unconvert
 	if err != nil {
 		return nil, err
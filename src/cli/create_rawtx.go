@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/paymenturi"
 	"github.com/MDLlife/MDL/src/readable"
 	"github.com/MDLlife/MDL/src/transaction"
 	"github.com/MDLlife/MDL/src/util/droplet"
@@ -51,6 +52,10 @@ func createRawTxnCmd() *cobra.Command {
     from all addresses within the wallet starting with the first address until
     the amount of the transaction is met.
 
+    The [to address] argument can be a payment request URI,
+    mdl:<address>?amount=<coins>. The [amount] argument is then only
+    required if the URI has no amount.
+
     Use caution when using the "-p" command. If you have command history enabled
     your wallet encryption password can be recovered from the history log. If you
     do not include the "-p" option you will be prompted to enter your password
@@ -198,6 +203,10 @@ func getToAddresses(c *cobra.Command, args []string) ([]SendAmount, error) {
 		return parseSendAmountsFromCSV(fields)
 	}
 
+	if len(args) > 0 && paymenturi.IsURI(args[0]) {
+		return getURISendAmount(args)
+	}
+
 	if len(args) < 2 {
 		return nil, fmt.Errorf("requires at least 2 arg(s), only received %d", len(args))
 	}
@@ -219,6 +228,42 @@ func getToAddresses(c *cobra.Command, args []string) ([]SendAmount, error) {
 	}}, nil
 }
 
+// getURISendAmount returns the destination of a payment request URI. The amount argument
+// is required if the URI has no amount.
+func getURISendAmount(args []string) ([]SendAmount, error) {
+	u, err := paymenturi.Parse(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Coin hours are distributed to the outputs automatically
+	if u.Hours != nil {
+		return nil, errors.New("the hours of a payment request URI are not supported, send with the /api/v1/wallet/transaction API")
+	}
+
+	coins := u.Coins
+	switch {
+	case len(args) > 2:
+		return nil, fmt.Errorf("accepts at most 2 arg(s) with a payment request URI, received %d", len(args))
+	case len(args) == 2:
+		if coins != 0 {
+			return nil, errors.New("the amount argument can't be used with a payment request URI which has an amount")
+		}
+
+		coins, err = getAmount(args)
+		if err != nil {
+			return nil, err
+		}
+	case coins == 0:
+		return nil, errors.New("the payment request URI has no amount, add the amount argument")
+	}
+
+	return []SendAmount{{
+		Addr:  u.Address.String(),
+		Coins: coins,
+	}}, nil
+}
+
 func openCSV(csvFile string) ([][]string, error) {
 	f, err := os.Open(csvFile)
 	if err != nil {
@@ -515,6 +560,9 @@ func CreateRawTxn(c GetOutputser, wlt *wallet.Wallet, inAddrs []string, chgAddr
 		return nil, err
 	}
 
+	// The outputs frozen in the wallet are not spent
+	outputs = removeFrozenOutputs(outputs, wlt)
+
 	inUxs, err := outputs.SpendableOutputs().ToUxArray()
 	if err != nil {
 		return nil, err
@@ -598,3 +598,65 @@ func TestParseSendAmountsFromCSV(t *testing.T) {
 		})
 	}
 }
+
+func TestGetURISendAmount(t *testing.T) {
+	addr := "2Niqzo12tZ9ioZq5vwPHMVR4g7UVpp9TCmP"
+
+	cases := []struct {
+		name string
+		args []string
+		amts []SendAmount
+		err  error
+	}{
+		{
+			name: "uri with amount",
+			args: []string{"mdl:" + addr + "?amount=12.5&label=foo"},
+			amts: []SendAmount{{Addr: addr, Coins: 12500000}},
+		},
+		{
+			name: "uri without amount, amount argument",
+			args: []string{"mdl:" + addr, "1.5"},
+			amts: []SendAmount{{Addr: addr, Coins: 1500000}},
+		},
+		{
+			name: "uri without amount",
+			args: []string{"mdl:" + addr},
+			err:  errors.New("the payment request URI has no amount, add the amount argument"),
+		},
+		{
+			name: "uri with amount, amount argument",
+			args: []string{"mdl:" + addr + "?amount=1", "2"},
+			err:  errors.New("the amount argument can't be used with a payment request URI which has an amount"),
+		},
+		{
+			name: "uri with hours",
+			args: []string{"mdl:" + addr + "?amount=1&hours=10"},
+			err:  errors.New("the hours of a payment request URI are not supported, send with the /api/v1/wallet/transaction API"),
+		},
+		{
+			name: "too many args",
+			args: []string{"mdl:" + addr, "1", "2"},
+			err:  errors.New("accepts at most 2 arg(s) with a payment request URI, received 3"),
+		},
+		{
+			name: "invalid uri",
+			args: []string{"mdl:xxx?amount=1"},
+			err:  errors.New("Invalid URI address: Invalid address length"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			amts, err := getURISendAmount(tc.args)
+
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				require.Nil(t, amts)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.amts, amts)
+		})
+	}
+}
@@ -46,8 +46,12 @@ If no wallet name is specified a generic name will be selected.`)
 	walletCreateCmd.Flags().StringP("label", "l", "", "Label used to idetify your wallet.")
 	walletCreateCmd.Flags().BoolP("encrypt", "e", false, "Create encrypted wallet.")
 	walletCreateCmd.Flags().StringP("crypto-type", "x", string(wallet.CryptoTypeScryptChacha20poly1305),
-		"The crypto type for wallet encryption, can be scrypt-chacha20poly1305 or sha256-xor")
+		"The crypto type for wallet encryption, can be scrypt-chacha20poly1305, argon2id-chacha20poly1305 or sha256-xor")
 	walletCreateCmd.Flags().StringP("password", "p", "", "Wallet password")
+	walletCreateCmd.Flags().StringP("type", "t", wallet.WalletTypeDeterministic, "Wallet type, can be deterministic, bip44 or collection. A bip44 wallet requires a bip39 mnemonic seed. A collection wallet has no seed, use addPrivateKey to import keys into it")
+	walletCreateCmd.Flags().Uint32("bip44-account", 0, "The bip44 account number, only for bip44 wallets")
+	walletCreateCmd.Flags().Bool("seed-passphrase", false, `Prompt for a bip39 seed passphrase, only for bip44 wallets and requires -e.
+The passphrase is stored encrypted in the wallet, losing it makes the wallet unrecoverable from the seed`)
 
 	return walletCreateCmd
 }
@@ -112,16 +116,58 @@ func generateWalletHandler(c *gcli.Command, _ []string) error {
 		return err
 	}
 
-	sd, err := makeSeed(s, random, mnemonic)
+	walletType, err := wallet.ResolveWalletType(c.Flag("type").Value.String())
 	if err != nil {
 		return err
 	}
 
+	var sd string
+	if walletType == wallet.WalletTypeCollection {
+		if s != "" || random || mnemonic {
+			return errors.New("collection wallets have no seed, -s, -r and -m must not be used")
+		}
+		if c.Flags().Changed("num") {
+			return errors.New("collection wallets are created empty, -n must not be used")
+		}
+	} else {
+		sd, err = makeSeed(s, random, mnemonic)
+		if err != nil {
+			return err
+		}
+	}
+
 	cryptoType, err := wallet.CryptoTypeFromString(c.Flag("crypto-type").Value.String())
 	if err != nil {
 		return err
 	}
 
+	bip44Account, err := c.Flags().GetUint32("bip44-account")
+	if err != nil {
+		return err
+	}
+
+	if c.Flags().Changed("bip44-account") && walletType != wallet.WalletTypeBip44 {
+		return errors.New("--bip44-account is only valid for bip44 wallets")
+	}
+
+	if walletType == wallet.WalletTypeBip44 && random {
+		return errors.New("bip44 wallets require a mnemonic seed, -r must not be used")
+	}
+
+	useSeedPassphrase, err := c.Flags().GetBool("seed-passphrase")
+	if err != nil {
+		return err
+	}
+
+	if useSeedPassphrase {
+		if walletType != wallet.WalletTypeBip44 {
+			return errors.New("--seed-passphrase is only valid for bip44 wallets")
+		}
+		if !encrypt {
+			return errors.New("--seed-passphrase requires an encrypted wallet, use -e")
+		}
+	}
+
 	pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
 	switch pr.(type) {
 	case PasswordFromBytes:
@@ -144,12 +190,26 @@ func generateWalletHandler(c *gcli.Command, _ []string) error {
 		}
 	}
 
+	var seedPassphrase []byte
+	if useSeedPassphrase {
+		seedPassphrase, err = readPasswordFromTerminalWithPrompt("enter seed passphrase:")
+		if err != nil {
+			return err
+		}
+		if len(seedPassphrase) == 0 {
+			return errors.New("seed passphrase is empty")
+		}
+	}
+
 	opts := wallet.Options{
-		Label:      label,
-		Seed:       sd,
-		Encrypt:    encrypt,
-		CryptoType: cryptoType,
-		Password:   password,
+		Label:          label,
+		Seed:           sd,
+		Encrypt:        encrypt,
+		CryptoType:     cryptoType,
+		Password:       password,
+		Type:           walletType,
+		Bip44Account:   bip44Account,
+		SeedPassphrase: string(seedPassphrase),
 	}
 
 	wlt, err := GenerateWallet(wltName, opts, num)
@@ -195,32 +255,27 @@ func makeSeed(s string, r, m bool) (string, error) {
 func GenerateWallet(walletFile string, opts wallet.Options, numAddrs uint64) (*wallet.Wallet, error) {
 	walletFile = filepath.Base(walletFile)
 
+	if !opts.Encrypt && len(opts.Password) != 0 {
+		return nil, wallet.ErrWalletNotEncrypted
+	}
+
+	// The wallet is encrypted on creation, a wallet with a seed passphrase is never unencrypted
 	wlt, err := wallet.NewWallet(walletFile, wallet.Options{
-		Seed:  opts.Seed,
-		Label: opts.Label,
+		Seed:           opts.Seed,
+		Label:          opts.Label,
+		Type:           opts.Type,
+		Bip44Coin:      opts.Bip44Coin,
+		Bip44Account:   opts.Bip44Account,
+		SeedPassphrase: opts.SeedPassphrase,
+		Encrypt:        opts.Encrypt,
+		Password:       opts.Password,
+		CryptoType:     opts.CryptoType,
+		GenerateN:      numAddrs,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if numAddrs > 1 {
-		if _, err := wlt.GenerateAddresses(numAddrs - 1); err != nil {
-			return nil, err
-		}
-	}
-
-	if !opts.Encrypt {
-		if len(opts.Password) != 0 {
-			return nil, wallet.ErrWalletNotEncrypted
-		}
-
-		return wlt, nil
-	}
-
-	if err := wlt.Lock(opts.Password, opts.CryptoType); err != nil {
-		return nil, err
-	}
-
 	return wlt, nil
 }
 
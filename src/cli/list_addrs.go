@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"fmt"
-
 	"github.com/MDLlife/MDL/src/wallet"
 
 	gcli "github.com/spf13/cobra"
@@ -10,7 +8,7 @@ import (
 
 func listAddressesCmd() *gcli.Command {
 	return &gcli.Command{
-		Short:                 "Lists all addresses in a given wallet",
+		Short:                 "Lists all addresses in a given wallet, with their labels",
 		Use:                   "listAddresses [walletName]",
 		Args:                  gcli.MaximumNArgs(1),
 		DisableFlagsInUseLine: true,
@@ -36,14 +34,40 @@ func listAddresses(_ *gcli.Command, args []string) error {
 		return WalletLoadError{err}
 	}
 
-	addrs := wlt.GetAddresses()
+	return printJSON(newAddressesResult(wlt))
+}
 
-	s, err := FormatAddressesAsJSON(addrs)
-	if err != nil {
-		return err
+// AddressesResult is the result of listAddresses
+type AddressesResult struct {
+	Addresses []string `json:"addresses"`
+	// Labels are the labels and metadata of the labelled addresses, by address
+	Labels map[string]AddressLabel `json:"labels,omitempty"`
+}
+
+// AddressLabel is the label and metadata of a wallet address
+type AddressLabel struct {
+	Label string            `json:"label,omitempty"`
+	Meta  map[string]string `json:"meta,omitempty"`
+}
+
+func newAddressesResult(wlt *wallet.Wallet) AddressesResult {
+	result := AddressesResult{
+		Addresses: AddressesToStrings(wlt.GetAddresses()),
 	}
 
-	fmt.Println(s)
+	for _, e := range wlt.Entries {
+		if e.Label == "" && len(e.Meta) == 0 {
+			continue
+		}
+
+		if result.Labels == nil {
+			result.Labels = make(map[string]AddressLabel)
+		}
+		result.Labels[e.Address.String()] = AddressLabel{
+			Label: e.Label,
+			Meta:  e.Meta,
+		}
+	}
 
-	return nil
+	return result
 }
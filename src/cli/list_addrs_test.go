@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestNewAddressesResult(t *testing.T) {
+	w, err := wallet.NewWallet("a.wlt", wallet.Options{
+		Seed: "seed a",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(3)
+	require.NoError(t, err)
+
+	addrs := AddressesToStrings(w.GetAddresses())
+
+	// Wallets without labels only list the addresses
+	require.Equal(t, AddressesResult{
+		Addresses: addrs,
+	}, newAddressesResult(w))
+
+	label := "cold storage"
+	_, err = w.UpdateEntry(w.Entries[0].MDLAddress(), wallet.EntryUpdate{
+		Label: &label,
+	})
+	require.NoError(t, err)
+	_, err = w.UpdateEntry(w.Entries[2].MDLAddress(), wallet.EntryUpdate{
+		Meta: map[string]string{"invoice": "42"},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, AddressesResult{
+		Addresses: addrs,
+		Labels: map[string]AddressLabel{
+			addrs[0]: {
+				Label: label,
+			},
+			addrs[2]: {
+				Meta: map[string]string{"invoice": "42"},
+			},
+		},
+	}, newAddressesResult(w))
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func signMessageCmd() *gcli.Command {
+	signMessageCmd := &gcli.Command{
+		Short: "Sign a message with the secret key of a wallet address",
+		Use:   "signMessage [address] [message]",
+		Long: fmt.Sprintf(`Signs a message with the secret key of an address of a wallet, to prove
+    the control of the address without a transaction. The address is a MDL or
+    a Bitcoin address, depending on the coin type of the wallet. The signature
+    is verified with verifyMessage. The default wallet (%s) will be used if
+    the wallet file or path is not specified.
+
+    It doesn't connect to a node, so messages can be signed on an offline
+    machine.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your wallet encryption password can be recovered from the history
+    log. If you do not include the "-p" option you will be prompted to enter
+    your password after you enter your command.`, cliConfig.FullWalletPath()),
+		Args:         gcli.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			walletFile, err := c.Flags().GetString("wallet-file")
+			if err != nil {
+				return err
+			}
+
+			w, err := resolveWalletPath(cliConfig, walletFile)
+			if err != nil {
+				return err
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			addr, err := wallet.DecodeMessageAddress(args[0])
+			if err != nil {
+				return err
+			}
+
+			wlt, err := wallet.Load(w)
+			if err != nil {
+				printHelp(c)
+				return WalletLoadError{err}
+			}
+
+			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			sig, err := SignMessage(wlt, addr, args[1], pr)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(struct {
+					Address   string `json:"address"`
+					Signature string `json:"signature"`
+				}{
+					Address:   addr.String(),
+					Signature: sig.Hex(),
+				})
+			}
+
+			fmt.Println(sig.Hex())
+			return nil
+		},
+	}
+
+	signMessageCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+	signMessageCmd.Flags().StringP("password", "p", "", "Wallet password")
+	signMessageCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return signMessageCmd
+}
+
+func verifyMessageCmd() *gcli.Command {
+	return &gcli.Command{
+		Short: "Verify a message signed by the secret key of an address",
+		Use:   "verifyMessage [address] [signature] [message]",
+		Long: `Verifies that a message was signed by the secret key of a MDL or a Bitcoin
+    address, with signMessage or POST /api/v2/wallet/sign-message. It returns an
+    error if the signature is not valid. It doesn't connect to a node.`,
+		Args:                  gcli.ExactArgs(3),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(_ *gcli.Command, args []string) error {
+			addr, err := wallet.DecodeMessageAddress(args[0])
+			if err != nil {
+				return err
+			}
+
+			sig, err := cipher.SigFromHex(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid signature: %v", err)
+			}
+
+			return wallet.VerifyMessage(addr, sig, args[2])
+		},
+	}
+}
+
+// SignMessage signs a message with the secret key of an address of a wallet, decrypting the wallet if it is encrypted
+func SignMessage(wlt *wallet.Wallet, addr cipher.Addresser, msg string, pr PasswordReader) (cipher.Sig, error) {
+	switch pr.(type) {
+	case nil:
+		if wlt.IsEncrypted() {
+			return cipher.Sig{}, wallet.ErrWalletEncrypted
+		}
+	case PasswordFromBytes:
+		p, err := pr.Password()
+		if err != nil {
+			return cipher.Sig{}, err
+		}
+
+		if !wlt.IsEncrypted() && len(p) != 0 {
+			return cipher.Sig{}, wallet.ErrWalletNotEncrypted
+		}
+	}
+
+	if !wlt.IsEncrypted() {
+		return wlt.SignMessage(addr, msg)
+	}
+
+	password, err := pr.Password()
+	if err != nil {
+		return cipher.Sig{}, err
+	}
+
+	var sig cipher.Sig
+	if err := wlt.GuardView(password, func(w *wallet.Wallet) error {
+		var err error
+		sig, err = w.SignMessage(addr, msg)
+		return err
+	}); err != nil {
+		return cipher.Sig{}, err
+	}
+
+	return sig, nil
+}
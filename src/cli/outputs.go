@@ -96,7 +96,8 @@ func GetWalletOutputsFromFile(c GetOutputser, walletFile string) (*readable.Unsp
 	return GetWalletOutputs(c, wlt)
 }
 
-// GetWalletOutputs returns unspent outputs associated with all addresses in a wallet.Wallet
+// GetWalletOutputs returns unspent outputs associated with all addresses in a wallet.Wallet.
+// The outputs frozen in the wallet are marked as frozen.
 func GetWalletOutputs(c GetOutputser, wlt *wallet.Wallet) (*readable.UnspentOutputsSummary, error) {
 	cipherAddrs := wlt.GetAddresses()
 	addrs := make([]string, len(cipherAddrs))
@@ -104,5 +105,12 @@ func GetWalletOutputs(c GetOutputser, wlt *wallet.Wallet) (*readable.UnspentOutp
 		addrs[i] = cipherAddrs[i].String()
 	}
 
-	return c.OutputsForAddresses(addrs)
+	outputs, err := c.OutputsForAddresses(addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	markFrozenOutputs(outputs, wlt)
+
+	return outputs, nil
 }
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/paperwallet"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func paperWalletCmd() *gcli.Command {
+	paperWalletCmd := &gcli.Command{
+		Short:   "Generate a printable paper wallet with QR codes",
+		Use:     "paperWallet",
+		Aliases: []string{"paper-wallet"},
+		Long: `Generates keypairs, or a seed and its addresses with "--seed", and renders
+    them as a printable HTML or SVG page, with QR codes of the addresses and
+    secrets. The page is written to stdout, unless "-o" is set.
+
+    A secret is printed with its checksum, the first 4 bytes of its SHA256 hash,
+    to verify it after typing or decrypting it. With "-x", the secrets are
+    encrypted with a passphrase, read from the terminal, and decrypted with
+    paperWalletDecrypt.
+
+    The page contains the secrets. Print it from a computer that is offline, and
+    don't keep the file.`,
+		Args:         gcli.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, _ []string) error {
+			num, err := c.Flags().GetInt("num")
+			if err != nil {
+				return err
+			}
+
+			coinName, err := c.Flags().GetString("coin")
+			if err != nil {
+				return err
+			}
+
+			coinType, err := wallet.ResolveCoinType(coinName)
+			if err != nil {
+				return err
+			}
+
+			label, err := c.Flags().GetString("label")
+			if err != nil {
+				return err
+			}
+
+			seed, err := c.Flags().GetBool("seed")
+			if err != nil {
+				return err
+			}
+
+			entropy, err := c.Flags().GetInt("entropy")
+			if err != nil {
+				return err
+			}
+
+			format, err := c.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			switch strings.ToLower(format) {
+			case "html", "svg":
+			default:
+				return errors.New("format must be html or svg")
+			}
+
+			output, err := c.Flags().GetString("output")
+			if err != nil {
+				return err
+			}
+
+			encrypt, err := c.Flags().GetBool("encrypt")
+			if err != nil {
+				return err
+			}
+
+			var password []byte
+			if encrypt {
+				password, err = readNewPasswordFromTerminal()
+				if err != nil {
+					return err
+				}
+			}
+
+			pw, err := paperwallet.New(paperwallet.Options{
+				Coin:        coinType,
+				Label:       label,
+				N:           num,
+				Seed:        seed,
+				SeedEntropy: entropy,
+				Password:    password,
+			})
+			if err != nil {
+				return err
+			}
+
+			var page []byte
+			if strings.ToLower(format) == "svg" {
+				page, err = pw.SVG()
+			} else {
+				page, err = pw.HTML()
+			}
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err := os.Stdout.Write(page)
+				return err
+			}
+
+			return ioutil.WriteFile(output, page, 0600)
+		},
+	}
+
+	paperWalletCmd.Flags().IntP("num", "n", 1, "Number of keypairs, or number of addresses of the seed")
+	paperWalletCmd.Flags().StringP("coin", "c", "mdl", "Coin type. Must be mdl or bitcoin. If bitcoin, secret keys are in Wallet Import Format instead of hex.")
+	paperWalletCmd.Flags().StringP("label", "l", "", "Label printed on the page")
+	paperWalletCmd.Flags().BoolP("seed", "s", false, "Generate a bip39 seed and the addresses of its deterministic wallet, instead of unrelated keypairs")
+	paperWalletCmd.Flags().IntP("entropy", "e", 128, "Entropy of the bip39 seed. Can be 128 or 256")
+	paperWalletCmd.Flags().StringP("format", "f", "html", "Page format. Must be html or svg")
+	paperWalletCmd.Flags().StringP("output", "o", "", "File to write the page to")
+	paperWalletCmd.Flags().BoolP("encrypt", "x", false, "Encrypt the secrets with a passphrase read from the terminal")
+
+	return paperWalletCmd
+}
+
+func paperWalletDecryptCmd() *gcli.Command {
+	paperWalletDecryptCmd := &gcli.Command{
+		Short: "Decrypt a secret of an encrypted paper wallet",
+		Use:   "paperWalletDecrypt [encrypted secret]",
+		Long: `Decrypts a secret key or seed of a paper wallet created by paperWallet
+    with "-x", and prints it with its checksum. Compare the checksum with the
+    checksum printed on the paper wallet.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your passphrase can be recovered from the history log. If you do
+    not include the "-p" option you will be prompted to enter your passphrase
+    after you enter your command.`,
+		Args:         gcli.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			password, err := pr.Password()
+			if err != nil {
+				return err
+			}
+
+			secret, err := paperwallet.DecryptSecret(strings.TrimSpace(args[0]), password)
+			if err != nil {
+				return err
+			}
+
+			checksum := paperwallet.Checksum(secret)
+
+			if jsonOutput {
+				return printJSON(struct {
+					Secret   string `json:"secret"`
+					Checksum string `json:"checksum"`
+				}{
+					Secret:   secret,
+					Checksum: checksum,
+				})
+			}
+
+			fmt.Println(secret)
+			fmt.Println("checksum:", checksum)
+			return nil
+		},
+	}
+
+	paperWalletDecryptCmd.Flags().StringP("password", "p", "", "Paper wallet passphrase")
+	paperWalletDecryptCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return paperWalletDecryptCmd
+}
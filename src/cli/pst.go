@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/pst"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func pstCreateCmd() *gcli.Command {
+	pstCreateCmd := &gcli.Command{
+		Short: "Create a partially-signed transaction for offline signing",
+		Use:   "pstCreate [raw transaction]",
+		Long: `Creates a partially-signed transaction from an unsigned or partially
+    signed raw transaction, such as one created with createRawTransaction
+    --unsigned or with the /api/v1/wallet/transaction endpoint. The node adds
+    the unspent outputs spent by the inputs, so that the transaction can be
+    checked and signed offline with pstSign.
+
+    If a wallet file is given with "-f", the bip44 derivation paths of the
+    inputs owned by the wallet are added.`,
+		Args:         gcli.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			p, err := apiClient.CreatePST(args[0], "")
+			if err != nil {
+				return err
+			}
+
+			walletFile, err := c.Flags().GetString("wallet-file")
+			if err != nil {
+				return err
+			}
+
+			if walletFile != "" {
+				w, err := resolveWalletPath(cliConfig, walletFile)
+				if err != nil {
+					return err
+				}
+
+				wlt, err := wallet.Load(w)
+				if err != nil {
+					printHelp(c)
+					return WalletLoadError{err}
+				}
+
+				p.SetPaths(wlt)
+			}
+
+			return writePST(c, p)
+		},
+	}
+
+	pstCreateCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path of the bip44 wallet owning the inputs")
+	pstCreateCmd.Flags().StringP("output", "o", "", "partially-signed transaction file. If not set, it is printed")
+
+	return pstCreateCmd
+}
+
+func pstSignCmd() *gcli.Command {
+	pstSignCmd := &gcli.Command{
+		Short: "Sign a partially-signed transaction with a local wallet",
+		Use:   "pstSign [pst file]",
+		Long: fmt.Sprintf(`Signs the unsigned inputs of a partially-signed transaction owned by
+    a wallet, or only the inputs given with "-i". The default wallet (%s)
+    will be used if the wallet file or path is not specified.
+
+    The transaction is verified with the unspent outputs of the partially-signed
+    transaction, without connecting to a node, so it can be signed on an
+    offline machine. Check the inputs, outputs and fee of the partially-signed
+    transaction before signing it.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your wallet encryption password can be recovered from the history
+    log. If you do not include the "-p" option you will be prompted to enter
+    your password after you enter your command.`, cliConfig.FullWalletPath()),
+		Args:         gcli.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			walletFile, err := c.Flags().GetString("wallet-file")
+			if err != nil {
+				return err
+			}
+
+			w, err := resolveWalletPath(cliConfig, walletFile)
+			if err != nil {
+				return err
+			}
+
+			wlt, err := wallet.Load(w)
+			if err != nil {
+				printHelp(c)
+				return WalletLoadError{err}
+			}
+
+			signIndexes, err := parseSignIndexes(c.Flag("sign-indexes").Value.String())
+			if err != nil {
+				return err
+			}
+
+			p, err := readPSTFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			if err := SignPST(p, wlt, signIndexes, pr); err != nil {
+				return err
+			}
+
+			return writePST(c, p)
+		},
+	}
+
+	pstSignCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+	pstSignCmd.Flags().StringP("password", "p", "", "Wallet password")
+	pstSignCmd.Flags().StringP("sign-indexes", "i", "", "comma separated indexes of the inputs to sign. If not set, every unsigned input owned by the wallet is signed")
+	pstSignCmd.Flags().StringP("output", "o", "", "partially-signed transaction file. If not set, it is printed")
+
+	return pstSignCmd
+}
+
+func pstCombineCmd() *gcli.Command {
+	pstCombineCmd := &gcli.Command{
+		Short: "Combine the signatures of partially-signed transactions",
+		Use:   "pstCombine [pst files]",
+		Long: `Merges the signatures of copies of the same partially-signed transaction,
+    signed separately by different signers. It doesn't connect to a node.`,
+		Args:         gcli.MinimumNArgs(2),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			psts := make([]*pst.PST, len(args))
+			for i, f := range args {
+				p, err := readPSTFile(f)
+				if err != nil {
+					return err
+				}
+				psts[i] = p
+			}
+
+			p, err := pst.Combine(psts...)
+			if err != nil {
+				return err
+			}
+
+			return writePST(c, p)
+		},
+	}
+
+	pstCombineCmd.Flags().StringP("output", "o", "", "partially-signed transaction file. If not set, it is printed")
+
+	return pstCombineCmd
+}
+
+func pstFinalizeCmd() *gcli.Command {
+	pstFinalizeCmd := &gcli.Command{
+		Short: "Extract the raw transaction of a fully signed partially-signed transaction",
+		Use:   "pstFinalize [pst file]",
+		Long: `Verifies a fully signed partially-signed transaction and prints its raw
+    transaction, to be broadcast with broadcastTransaction. It doesn't connect
+    to a node.`,
+		Args:         gcli.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			p, err := readPSTFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			txn, err := p.Finalize()
+			if err != nil {
+				return err
+			}
+
+			rawTxn, err := txn.SerializeHex()
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(struct {
+					RawTx string `json:"rawtx"`
+				}{
+					RawTx: rawTxn,
+				})
+			}
+
+			fmt.Println(rawTxn)
+
+			return nil
+		},
+	}
+
+	pstFinalizeCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return pstFinalizeCmd
+}
+
+// SignPST signs the inputs of a partially-signed transaction owned by a wallet, decrypting the wallet if it is encrypted
+func SignPST(p *pst.PST, wlt *wallet.Wallet, signIndexes []int, pr PasswordReader) error {
+	sign := func(w *wallet.Wallet) error {
+		if err := p.Sign(w, signIndexes); err != nil {
+			return err
+		}
+
+		p.SetPaths(w)
+		return nil
+	}
+
+	switch pr.(type) {
+	case nil:
+		if wlt.IsEncrypted() {
+			return wallet.ErrWalletEncrypted
+		}
+	case PasswordFromBytes:
+		p, err := pr.Password()
+		if err != nil {
+			return err
+		}
+
+		if !wlt.IsEncrypted() && len(p) != 0 {
+			return wallet.ErrWalletNotEncrypted
+		}
+	}
+
+	if !wlt.IsEncrypted() {
+		return sign(wlt)
+	}
+
+	password, err := pr.Password()
+	if err != nil {
+		return err
+	}
+
+	return wlt.GuardView(password, sign)
+}
+
+func parseSignIndexes(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var signIndexes []int
+	for _, x := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(x))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sign index %q", x)
+		}
+		signIndexes = append(signIndexes, i)
+	}
+
+	return signIndexes, nil
+}
+
+func readPSTFile(filename string) (*pst.PST, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var p pst.PST
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("invalid partially-signed transaction %s: %v", filename, err)
+	}
+
+	return &p, nil
+}
+
+func writePST(c *gcli.Command, p *pst.PST) error {
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return printJSON(p)
+	}
+
+	d, err := formatJSON(p)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(output, append(d, '\n'), 0600)
+}
@@ -15,6 +15,10 @@ func sendCmd() *gcli.Command {
     If you are sending from a wallet without specifying an address,
     the transaction will use one or more of the addresses within the wallet.
 
+    The [to address] argument can be a payment request URI,
+    mdl:<address>?amount=<coins>. The [amount] argument is then only
+    required if the URI has no amount.
+
     Use caution when using the “-p” command. If you have command history enabled
     your wallet encryption password can be recovered from the history log.
     If you do not include the “-p” option you will be prompted to enter your password
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// WalletBackupResult is the result of backing up or restoring wallets
+type WalletBackupResult struct {
+	Filename string   `json:"filename,omitempty"`
+	Wallets  []string `json:"wallets"`
+}
+
+func walletBackupCmd() *gcli.Command {
+	walletBackupCmd := &gcli.Command{
+		Short: "Create an encrypted backup archive of wallets",
+		Use:   "walletBackup",
+		Long: fmt.Sprintf(`Writes a checksummed backup archive of the wallets in the wallet
+    directory (%s), or of a single wallet with "-f". The archive is encrypted
+    with its own password, encrypted wallets also stay encrypted with their
+    wallet password. The archive is restored with walletRestore.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your archive password can be recovered from the history log. If
+    you do not include the "-p" option you will be prompted to enter your
+    password after you enter your command.`, cliConfig.WalletDir),
+		SilenceUsage: true,
+		RunE:         walletBackupHandler,
+	}
+
+	walletBackupCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path to back up. If not set, every wallet in the wallet directory is backed up.")
+	walletBackupCmd.Flags().StringP("wallet-dir", "d", "", "Wallet directory. If not set, the default wallet directory will be used.")
+	walletBackupCmd.Flags().StringP("output", "o", "", `Backup archive file. Defaults to "wallets_<timestamp>.wbk" in the current directory.`)
+	walletBackupCmd.Flags().StringP("password", "p", "", "Backup archive password")
+	walletBackupCmd.Flags().StringP("crypto-type", "x", string(wallet.CryptoTypeScryptChacha20poly1305),
+		"The crypto type for the archive encryption, can be scrypt-chacha20poly1305, argon2id-chacha20poly1305 or sha256-xor")
+
+	return walletBackupCmd
+}
+
+func walletBackupHandler(c *gcli.Command, _ []string) error {
+	walletFile, err := c.Flags().GetString("wallet-file")
+	if err != nil {
+		return err
+	}
+
+	walletDir, err := c.Flags().GetString("wallet-dir")
+	if err != nil {
+		return err
+	}
+
+	if walletFile != "" && walletDir != "" {
+		return errors.New("-f and -d can't be used together")
+	}
+
+	cryptoType, err := wallet.CryptoTypeFromString(c.Flag("crypto-type").Value.String())
+	if err != nil {
+		printHelp(c)
+		return err
+	}
+
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = wallet.BackupFilename(time.Now())
+	}
+
+	if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("%s already exists", output)
+	}
+
+	password := []byte(c.Flag("password").Value.String())
+	if len(password) == 0 {
+		password, err = readNewPasswordFromTerminal()
+		if err != nil {
+			return err
+		}
+	}
+
+	var wlts []*wallet.Wallet
+	if walletFile != "" {
+		w, err := resolveWalletPath(cliConfig, walletFile)
+		if err != nil {
+			return err
+		}
+
+		wlt, err := wallet.Load(w)
+		if err != nil {
+			printHelp(c)
+			return WalletLoadError{err}
+		}
+		wlts = append(wlts, wlt)
+	} else {
+		if walletDir == "" {
+			walletDir = cliConfig.WalletDir
+		}
+
+		wlts, err = loadWalletDir(walletDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := backupWallets(output, wlts, password, cryptoType)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(result)
+}
+
+func walletRestoreCmd() *gcli.Command {
+	walletRestoreCmd := &gcli.Command{
+		Short: "Restore the wallets of a backup archive",
+		Use:   "walletRestore [archive]",
+		Long: fmt.Sprintf(`Restores the wallets of a backup archive created with walletBackup
+    or the /api/v2/wallet/backup endpoint into the wallet directory (%s).
+
+    The archive checksum is verified and every wallet is validated before any
+    wallet is written. A wallet which already exists in the wallet directory
+    is only replaced with "--overwrite".
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your archive password can be recovered from the history log. If
+    you do not include the "-p" option you will be prompted to enter your
+    password after you enter your command.`, cliConfig.WalletDir),
+		Args:         gcli.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         walletRestoreHandler,
+	}
+
+	walletRestoreCmd.Flags().StringP("wallet-dir", "d", "", "Wallet directory. If not set, the default wallet directory will be used.")
+	walletRestoreCmd.Flags().StringP("password", "p", "", "Backup archive password")
+	walletRestoreCmd.Flags().Bool("overwrite", false, "Replace the wallets which already exist in the wallet directory")
+
+	return walletRestoreCmd
+}
+
+func walletRestoreHandler(c *gcli.Command, args []string) error {
+	walletDir, err := c.Flags().GetString("wallet-dir")
+	if err != nil {
+		return err
+	}
+	if walletDir == "" {
+		walletDir = cliConfig.WalletDir
+	}
+
+	overwrite, err := c.Flags().GetBool("overwrite")
+	if err != nil {
+		return err
+	}
+
+	archive, err := wallet.LoadBackupArchive(args[0])
+	if err != nil {
+		return err
+	}
+
+	password, err := NewPasswordReader([]byte(c.Flag("password").Value.String())).Password()
+	if err != nil {
+		return err
+	}
+
+	result, err := restoreWallets(walletDir, archive, password, overwrite)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(result)
+}
+
+// loadWalletDir loads the wallets of a directory, sorted by filename
+func loadWalletDir(dir string) ([]*wallet.Wallet, error) {
+	wltMap, err := wallet.LoadWallets(dir)
+	if err != nil {
+		return nil, WalletLoadError{err}
+	}
+
+	wlts := make([]*wallet.Wallet, 0, len(wltMap))
+	for _, w := range wltMap {
+		wlts = append(wlts, w)
+	}
+
+	sort.Slice(wlts, func(i, j int) bool {
+		return wlts[i].Filename() < wlts[j].Filename()
+	})
+
+	return wlts, nil
+}
+
+// backupWallets writes a backup archive of the wallets to the file output
+func backupWallets(output string, wlts []*wallet.Wallet, password []byte, cryptoType wallet.CryptoType) (*WalletBackupResult, error) {
+	archive, err := wallet.NewBackupArchive(wlts, password, cryptoType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := archive.Save(output); err != nil {
+		return nil, err
+	}
+
+	result := &WalletBackupResult{
+		Filename: output,
+		Wallets:  make([]string, len(wlts)),
+	}
+	for i, w := range wlts {
+		result.Wallets[i] = w.Filename()
+	}
+
+	return result, nil
+}
+
+// restoreWallets restores the wallets of a backup archive into dir. All wallets are
+// validated and checked for conflicts with the wallets of dir before any of them is written.
+func restoreWallets(dir string, archive *wallet.BackupArchive, password []byte, overwrite bool) (*WalletBackupResult, error) {
+	wlts, err := archive.Wallets(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	existing, err := loadWalletDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Key: first address of a seed wallet; Value: wallet filename
+	firstAddrs := make(map[string]string, len(existing))
+	for _, w := range existing {
+		if hasSeed(w) {
+			firstAddrs[w.Entries[0].Address.String()] = w.Filename()
+		}
+	}
+
+	for _, w := range wlts {
+		if _, err := os.Stat(filepath.Join(dir, w.Filename())); err == nil && !overwrite {
+			return nil, fmt.Errorf("%s already exists, use --overwrite to replace it", w.Filename())
+		}
+
+		if hasSeed(w) {
+			if fn, ok := firstAddrs[w.Entries[0].Address.String()]; ok && fn != w.Filename() {
+				return nil, fmt.Errorf("the seed of %s is already used by %s", w.Filename(), fn)
+			}
+		}
+	}
+
+	result := &WalletBackupResult{
+		Wallets: make([]string, len(wlts)),
+	}
+	for i, w := range wlts {
+		if err := w.Save(dir); err != nil {
+			return nil, WalletSaveError{err}
+		}
+		result.Wallets[i] = w.Filename()
+	}
+
+	return result, nil
+}
+
+// hasSeed returns true if the wallet is generated from a seed
+func hasSeed(w *wallet.Wallet) bool {
+	switch w.Type() {
+	case wallet.WalletTypeDeterministic, wallet.WalletTypeBip44:
+		return len(w.Entries) > 0
+	default:
+		return false
+	}
+}
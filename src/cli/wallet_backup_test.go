@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestBackupRestoreWallets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	walletDir := filepath.Join(dir, "wallets")
+	require.NoError(t, os.Mkdir(walletDir, 0700))
+	makeTestWallet(t, walletDir, "a.wlt", "seed a", nil, "")
+	makeTestWallet(t, walletDir, "b.wlt", "seed b", []byte("pwd"), wallet.CryptoTypeSha256Xor)
+
+	wlts, err := loadWalletDir(walletDir)
+	require.NoError(t, err)
+	require.Len(t, wlts, 2)
+
+	output := filepath.Join(dir, "backup.wbk")
+	result, err := backupWallets(output, wlts, []byte("backup pwd"), wallet.CryptoTypeScryptChacha20poly1305Insecure)
+	require.NoError(t, err)
+	require.Equal(t, &WalletBackupResult{
+		Filename: output,
+		Wallets:  []string{"a.wlt", "b.wlt"},
+	}, result)
+
+	archive, err := wallet.LoadBackupArchive(output)
+	require.NoError(t, err)
+
+	// Restores into an empty directory
+	restoreDir := filepath.Join(dir, "restored")
+	result, err = restoreWallets(restoreDir, archive, []byte("backup pwd"), false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.wlt", "b.wlt"}, result.Wallets)
+
+	w, err := wallet.Load(filepath.Join(restoreDir, "b.wlt"))
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	_, err = w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+
+	_, err = restoreWallets(restoreDir, archive, []byte("wrong"), false)
+	require.Equal(t, wallet.ErrInvalidPassword, err)
+
+	// Existing wallets are only replaced with overwrite
+	_, err = restoreWallets(restoreDir, archive, []byte("backup pwd"), false)
+	require.EqualError(t, err, "a.wlt already exists, use --overwrite to replace it")
+	_, err = restoreWallets(restoreDir, archive, []byte("backup pwd"), true)
+	require.NoError(t, err)
+
+	// A seed used by another wallet is not restored
+	require.NoError(t, os.Rename(filepath.Join(restoreDir, "a.wlt"), filepath.Join(restoreDir, "c.wlt")))
+	_, err = restoreWallets(restoreDir, archive, []byte("backup pwd"), true)
+	require.EqualError(t, err, "the seed of a.wlt is already used by c.wlt")
+	_, err = os.Stat(filepath.Join(restoreDir, "a.wlt"))
+	require.True(t, os.IsNotExist(err))
+}
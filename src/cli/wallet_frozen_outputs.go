@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/readable"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// FrozenOutputsResult is the result of the frozen outputs commands
+type FrozenOutputsResult struct {
+	FrozenOutputs []string `json:"frozen_outputs"`
+}
+
+func walletFreezeOutputsCmd() *gcli.Command {
+	walletFreezeOutputsCmd := &gcli.Command{
+		Short: "Freeze unspent outputs of a wallet",
+		Use:   "walletFreezeOutputs [flags] [uxout hashes]",
+		Long: fmt.Sprintf(`Freezes unspent outputs in a wallet, the default wallet (%s)
+    will be used if the wallet file or path is not specified.
+
+    Frozen outputs are not spent by transactions created from the wallet,
+    unless they are selected explicitly. The frozen outputs are saved in the
+    wallet file, encrypted wallets don't require the password.`, cliConfig.FullWalletPath()),
+		Args:                  gcli.MinimumNArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(c *gcli.Command, args []string) error {
+			return updateFrozenOutputsHandler(c, args, FreezeOutputsInFile)
+		},
+	}
+
+	walletFreezeOutputsCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+
+	return walletFreezeOutputsCmd
+}
+
+func walletUnfreezeOutputsCmd() *gcli.Command {
+	walletUnfreezeOutputsCmd := &gcli.Command{
+		Short: "Unfreeze unspent outputs of a wallet",
+		Use:   "walletUnfreezeOutputs [flags] [uxout hashes]",
+		Long: fmt.Sprintf(`Unfreezes unspent outputs of a wallet, the default wallet (%s)
+    will be used if the wallet file or path is not specified.
+    Outputs which are not frozen are ignored.`, cliConfig.FullWalletPath()),
+		Args:                  gcli.MinimumNArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(c *gcli.Command, args []string) error {
+			return updateFrozenOutputsHandler(c, args, UnfreezeOutputsInFile)
+		},
+	}
+
+	walletUnfreezeOutputsCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+
+	return walletUnfreezeOutputsCmd
+}
+
+func walletFrozenOutputsCmd() *gcli.Command {
+	return &gcli.Command{
+		Short: "List the frozen outputs of a wallet",
+		Use:   "walletFrozenOutputs [wallet file]",
+		Long: fmt.Sprintf(`Lists the frozen unspent outputs of a wallet, the default wallet (%s)
+    will be used if no wallet was specified.`, cliConfig.FullWalletPath()),
+		Args:                  gcli.MaximumNArgs(1),
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		RunE: func(c *gcli.Command, args []string) error {
+			var wltPath string
+			if len(args) == 1 {
+				wltPath = args[0]
+			}
+
+			w, err := resolveWalletPath(cliConfig, wltPath)
+			if err != nil {
+				return err
+			}
+
+			wlt, err := wallet.Load(w)
+			if err != nil {
+				printHelp(c)
+				return WalletLoadError{err}
+			}
+
+			return printJSON(newFrozenOutputsResult(wlt))
+		},
+	}
+}
+
+func updateFrozenOutputsHandler(c *gcli.Command, args []string, update func(walletFile string, hashes []string) (*FrozenOutputsResult, error)) error {
+	walletFile, err := c.Flags().GetString("wallet-file")
+	if err != nil {
+		return err
+	}
+
+	w, err := resolveWalletPath(cliConfig, walletFile)
+	if err != nil {
+		return err
+	}
+
+	result, err := update(w, args)
+	switch err.(type) {
+	case nil:
+		return printJSON(result)
+	case WalletLoadError:
+		printHelp(c)
+		return err
+	default:
+		return err
+	}
+}
+
+// FreezeOutputsInFile freezes unspent outputs in a wallet file. Will save the wallet after modifying.
+func FreezeOutputsInFile(walletFile string, hashes []string) (*FrozenOutputsResult, error) {
+	return updateFrozenOutputsInFile(walletFile, hashes, func(w *wallet.Wallet, uxIDs []cipher.SHA256) {
+		w.FreezeOutputs(uxIDs)
+	})
+}
+
+// UnfreezeOutputsInFile unfreezes unspent outputs of a wallet file. Will save the wallet after modifying.
+func UnfreezeOutputsInFile(walletFile string, hashes []string) (*FrozenOutputsResult, error) {
+	return updateFrozenOutputsInFile(walletFile, hashes, func(w *wallet.Wallet, uxIDs []cipher.SHA256) {
+		w.UnfreezeOutputs(uxIDs)
+	})
+}
+
+func updateFrozenOutputsInFile(walletFile string, hashes []string, f func(*wallet.Wallet, []cipher.SHA256)) (*FrozenOutputsResult, error) {
+	uxIDs := make([]cipher.SHA256, len(hashes))
+	for i, s := range hashes {
+		h, err := cipher.SHA256FromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uxout hash %q: %v", s, err)
+		}
+		uxIDs[i] = h
+	}
+
+	wlt, err := wallet.Load(walletFile)
+	if err != nil {
+		return nil, WalletLoadError{err}
+	}
+
+	f(wlt, uxIDs)
+
+	dir, err := filepath.Abs(filepath.Dir(walletFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wlt.Save(dir); err != nil {
+		return nil, WalletSaveError{err}
+	}
+
+	return newFrozenOutputsResult(wlt), nil
+}
+
+func newFrozenOutputsResult(wlt *wallet.Wallet) *FrozenOutputsResult {
+	frozen := wlt.FrozenOutputs()
+	result := &FrozenOutputsResult{
+		FrozenOutputs: make([]string, len(frozen)),
+	}
+	for i, h := range frozen {
+		result.FrozenOutputs[i] = h.Hex()
+	}
+	return result
+}
+
+// markFrozenOutputs sets the frozen flag of the outputs which are frozen in the wallet
+func markFrozenOutputs(outputs *readable.UnspentOutputsSummary, wlt *wallet.Wallet) {
+	for _, outs := range []readable.UnspentOutputs{outputs.HeadOutputs, outputs.OutgoingOutputs, outputs.IncomingOutputs} {
+		for i := range outs {
+			h, err := cipher.SHA256FromHex(outs[i].Hash)
+			if err != nil {
+				continue
+			}
+			if wlt.IsFrozenOutput(h) {
+				outs[i].Frozen = true
+			}
+		}
+	}
+}
+
+// removeFrozenOutputs returns a copy of the outputs without the outputs which are frozen in the wallet
+func removeFrozenOutputs(outputs *readable.UnspentOutputsSummary, wlt *wallet.Wallet) *readable.UnspentOutputsSummary {
+	filter := func(outs readable.UnspentOutputs) readable.UnspentOutputs {
+		filtered := make(readable.UnspentOutputs, 0, len(outs))
+		for _, o := range outs {
+			h, err := cipher.SHA256FromHex(o.Hash)
+			if err == nil && wlt.IsFrozenOutput(h) {
+				continue
+			}
+			filtered = append(filtered, o)
+		}
+		return filtered
+	}
+
+	return &readable.UnspentOutputsSummary{
+		Head:            outputs.Head,
+		HeadOutputs:     filter(outputs.HeadOutputs),
+		OutgoingOutputs: filter(outputs.OutgoingOutputs),
+		IncomingOutputs: filter(outputs.IncomingOutputs),
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/readable"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestFreezeOutputsInFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wallets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	makeTestWallet(t, dir, "a.wlt", "seed a", []byte("pwd"), wallet.CryptoTypeSha256Xor)
+	fn := filepath.Join(dir, "a.wlt")
+
+	a := cipher.SumSHA256([]byte("a"))
+	b := cipher.SumSHA256([]byte("b"))
+
+	_, err = FreezeOutputsInFile(fn, []string{"abcd"})
+	require.EqualError(t, err, `invalid uxout hash "abcd": Invalid hex length`)
+
+	_, err = FreezeOutputsInFile(filepath.Join(dir, "b.wlt"), []string{a.Hex()})
+	require.IsType(t, WalletLoadError{}, err)
+
+	// Encrypted wallets don't require the password
+	result, err := FreezeOutputsInFile(fn, []string{a.Hex(), b.Hex()})
+	require.NoError(t, err)
+	require.Len(t, result.FrozenOutputs, 2)
+
+	result, err = UnfreezeOutputsInFile(fn, []string{b.Hex()})
+	require.NoError(t, err)
+	require.Equal(t, &FrozenOutputsResult{
+		FrozenOutputs: []string{a.Hex()},
+	}, result)
+
+	w, err := wallet.Load(fn)
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{a}, w.FrozenOutputs())
+	require.True(t, w.IsEncrypted())
+}
+
+func TestFrozenOutputsSummary(t *testing.T) {
+	w, err := wallet.NewWallet("a.wlt", wallet.Options{
+		Seed: "seed a",
+	})
+	require.NoError(t, err)
+
+	a := cipher.SumSHA256([]byte("a"))
+	b := cipher.SumSHA256([]byte("b"))
+	c := cipher.SumSHA256([]byte("c"))
+	w.FreezeOutputs([]cipher.SHA256{a, c})
+
+	outputs := &readable.UnspentOutputsSummary{
+		HeadOutputs:     readable.UnspentOutputs{{Hash: a.Hex()}, {Hash: b.Hex()}},
+		IncomingOutputs: readable.UnspentOutputs{{Hash: c.Hex()}},
+	}
+
+	filtered := removeFrozenOutputs(outputs, w)
+	require.Equal(t, readable.UnspentOutputs{{Hash: b.Hex()}}, filtered.HeadOutputs)
+	require.Empty(t, filtered.IncomingOutputs)
+	require.Len(t, outputs.HeadOutputs, 2)
+
+	markFrozenOutputs(outputs, w)
+	require.Equal(t, readable.UnspentOutputs{{Hash: a.Hex(), Frozen: true}, {Hash: b.Hex()}}, outputs.HeadOutputs)
+	require.Equal(t, readable.UnspentOutputs{{Hash: c.Hex(), Frozen: true}}, outputs.IncomingOutputs)
+}
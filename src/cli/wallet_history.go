@@ -21,6 +21,7 @@ type AddrHistory struct {
 	BlockSeq  uint64    `json:"-"`
 	Txid      string    `json:"txid"`
 	Address   string    `json:"address"`
+	Label     string    `json:"label,omitempty"`
 	Amount    string    `json:"amount"`
 	Timestamp time.Time `json:"timestamp"`
 	Status    int       `json:"status"`
@@ -67,19 +68,19 @@ func walletHistoryAction(c *cobra.Command, _ []string) error {
 		return err
 	}
 
-	// Get all addresses in the wallet
-	addrs, err := getAddresses(w)
+	wlt, err := wallet.Load(w)
 	if err != nil {
 		return err
 	}
 
-	if len(addrs) == 0 {
+	if len(wlt.Entries) == 0 {
 		return errors.New("Wallet is empty")
 	}
 
 	// Get all the addresses' historical uxouts
 	totalAddrHis := []AddrHistory{}
-	for _, addr := range addrs {
+	for _, e := range wlt.Entries {
+		addr := e.Address.String()
 		uxouts, err := apiClient.AddressUxOuts(addr)
 		if err != nil {
 			return err
@@ -89,6 +90,12 @@ func walletHistoryAction(c *cobra.Command, _ []string) error {
 		if err != nil {
 			return err
 		}
+
+		// Labels the history with the label of the address
+		for i := range addrHis {
+			addrHis[i].Label = e.Label
+		}
+
 		totalAddrHis = append(totalAddrHis, addrHis...)
 	}
 
@@ -241,16 +248,3 @@ func createBlkTimeFinder(c *api.Client, ss []uint64) (func(uint64) int64, error)
 		panic("block not found")
 	}, nil
 }
-
-func getAddresses(f string) ([]string, error) {
-	wlt, err := wallet.Load(f)
-	if err != nil {
-		return nil, err
-	}
-
-	addrs := make([]string, len(wlt.Entries))
-	for i, entry := range wlt.Entries {
-		addrs[i] = entry.Address.String()
-	}
-	return addrs, nil
-}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	gcli "github.com/spf13/cobra"
+
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// SeedSharesResult is the result of walletSplitSeed
+type SeedSharesResult struct {
+	Threshold int      `json:"threshold"`
+	Shares    []string `json:"shares"`
+}
+
+func walletSplitSeedCmd() *gcli.Command {
+	walletSplitSeedCmd := &gcli.Command{
+		Short: "Split a wallet seed into share mnemonics",
+		Use:   "walletSplitSeed",
+		Long: fmt.Sprintf(`Splits the seed of a wallet into "-n" share mnemonics, any "-m" of which
+    recover the seed with walletCombineSeed. Fewer shares reveal nothing about
+    the seed. Give each share to a different custodian. The seed passphrase of
+    a bip44 wallet is not part of the shares. The default wallet (%s) will be
+    used if the wallet file or path is not specified.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your wallet encryption password can be recovered from the history
+    log. If you do not include the "-p" option you will be prompted to enter
+    your password after you enter your command.`, cliConfig.FullWalletPath()),
+		Args:         gcli.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, _ []string) error {
+			walletFile, err := c.Flags().GetString("wallet-file")
+			if err != nil {
+				return err
+			}
+
+			w, err := resolveWalletPath(cliConfig, walletFile)
+			if err != nil {
+				return err
+			}
+
+			threshold, err := c.Flags().GetInt("threshold")
+			if err != nil {
+				return err
+			}
+
+			n, err := c.Flags().GetInt("shares")
+			if err != nil {
+				return err
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			pr := NewPasswordReader([]byte(c.Flag("password").Value.String()))
+			seed, err := getSeed(w, pr)
+			switch err.(type) {
+			case nil:
+			case WalletLoadError:
+				printHelp(c)
+				return err
+			default:
+				return err
+			}
+
+			shares, err := wallet.SplitSeed(seed, threshold, n)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(SeedSharesResult{
+					Threshold: threshold,
+					Shares:    shares,
+				})
+			}
+
+			for _, s := range shares {
+				fmt.Println(s)
+			}
+
+			return nil
+		},
+	}
+
+	walletSplitSeedCmd.Flags().StringP("wallet-file", "f", "", "wallet file or path. If no path is specified your default wallet path will be used.")
+	walletSplitSeedCmd.Flags().StringP("password", "p", "", "Wallet password")
+	walletSplitSeedCmd.Flags().IntP("threshold", "m", 2, "Number of shares required to recover the seed")
+	walletSplitSeedCmd.Flags().IntP("shares", "n", 3, "Number of shares")
+	walletSplitSeedCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletSplitSeedCmd
+}
+
+func walletCombineSeedCmd() *gcli.Command {
+	walletCombineSeedCmd := &gcli.Command{
+		Short: "Recover a wallet seed from share mnemonics",
+		Use:   "walletCombineSeed [share mnemonics]",
+		Long: `Recovers a wallet seed from share mnemonics created with walletSplitSeed.
+    Each share is a quoted argument, or a line of the file given with "-i".
+    The seed is printed, unless "-w" is set.
+
+    If "-w" is set, the shares are sent to the node, which recovers the
+    encrypted wallet of this id with the seed, encrypted with the new password.
+
+    Use caution when using the "-p" option. If you have command history
+    enabled your wallet encryption password can be recovered from the history
+    log. If you do not include the "-p" option you will be prompted to enter
+    your password after you enter your command.`,
+		SilenceUsage: true,
+		RunE: func(c *gcli.Command, args []string) error {
+			input, err := c.Flags().GetString("input")
+			if err != nil {
+				return err
+			}
+
+			walletID, err := c.Flags().GetString("wallet-id")
+			if err != nil {
+				return err
+			}
+
+			jsonOutput, err := c.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			shares := args
+			if input != "" {
+				if len(args) != 0 {
+					return errors.New("share arguments and -i can't be used together")
+				}
+
+				shares, err = readSeedShares(input)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(shares) == 0 {
+				printHelp(c)
+				return errors.New("no share mnemonics")
+			}
+
+			if walletID == "" {
+				seed, err := wallet.CombineSeedShares(shares)
+				if err != nil {
+					return err
+				}
+
+				if jsonOutput {
+					return printJSON(struct {
+						Seed string `json:"seed"`
+					}{
+						Seed: seed,
+					})
+				}
+
+				fmt.Println(seed)
+				return nil
+			}
+
+			password := []byte(c.Flag("password").Value.String())
+			if len(password) == 0 {
+				password, err = readNewPasswordFromTerminal()
+				if err != nil {
+					return err
+				}
+			}
+
+			seedPassphrase, err := c.Flags().GetString("seed-passphrase")
+			if err != nil {
+				return err
+			}
+
+			wlt, err := apiClient.RecoverWalletFromSeedShares(walletID, shares, seedPassphrase, string(password))
+			if err != nil {
+				return err
+			}
+
+			return printJSON(wlt)
+		},
+	}
+
+	walletCombineSeedCmd.Flags().StringP("input", "i", "", "file with one share mnemonic per line")
+	walletCombineSeedCmd.Flags().StringP("wallet-id", "w", "", "id of the wallet of the node to recover with the seed")
+	walletCombineSeedCmd.Flags().StringP("password", "p", "", "New password of the recovered wallet")
+	walletCombineSeedCmd.Flags().StringP("seed-passphrase", "s", "", "bip39 seed passphrase of the recovered bip44 wallet")
+	walletCombineSeedCmd.Flags().BoolP("json", "j", false, "Returns the results in JSON format.")
+
+	return walletCombineSeedCmd
+}
+
+// readSeedShares reads the share mnemonics of a file, one per line
+func readSeedShares(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var shares []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if s := strings.TrimSpace(scanner.Text()); s != "" {
+			shares = append(shares, s)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
@@ -559,6 +559,45 @@ func (txn *Transaction) OutputHours() (uint64, error) {
 	return hours, nil
 }
 
+const (
+	// ReplaceableHoursDivisor is the divisor of the coin hours of the last output of a transaction signalling replace-by-fee
+	ReplaceableHoursDivisor = 100
+	// ReplaceableHoursRemainder is the remainder of the coin hours of the last output of a transaction signalling replace-by-fee
+	ReplaceableHoursRemainder = 1
+)
+
+// ErrReplaceableNoHours is returned when signalling replace-by-fee in a transaction whose last output has no coin hours
+var ErrReplaceableNoHours = errors.New("The last output has no coin hours to signal replace-by-fee")
+
+// IsReplaceable returns true if the transaction signals that it can be replaced by a conflicting transaction
+// burning more coin hours. A transaction signals replace-by-fee when the coin hours of its last output are
+// ReplaceableHoursRemainder modulo ReplaceableHoursDivisor.
+// The transaction type must be 0, so the signal is carried by the outputs. Like the rest of the outputs, it is covered
+// by the signatures and the hash of the transaction, and can't be added or removed by anyone but the signers.
+func (txn *Transaction) IsReplaceable() bool {
+	if len(txn.Out) == 0 {
+		return false
+	}
+	return txn.Out[len(txn.Out)-1].Hours%ReplaceableHoursDivisor == ReplaceableHoursRemainder
+}
+
+// SignalReplaceable reduces the coin hours of the last output to the highest value signalling replace-by-fee,
+// see IsReplaceable. The coin hours removed from the output are burned, at most ReplaceableHoursDivisor-1 coin hours.
+// UpdateHeader must be called afterwards.
+func (txn *Transaction) SignalReplaceable() error {
+	if len(txn.Out) == 0 {
+		return errors.New("No outputs")
+	}
+
+	o := &txn.Out[len(txn.Out)-1]
+	if o.Hours < ReplaceableHoursRemainder {
+		return ErrReplaceableNoHours
+	}
+
+	o.Hours -= (o.Hours - ReplaceableHoursRemainder) % ReplaceableHoursDivisor
+	return nil
+}
+
 // Transactions transaction slice
 type Transactions []Transaction
 
@@ -530,6 +530,78 @@ func TestTransactionOutputHours(t *testing.T) {
 	testutil.RequireError(t, err, "Transaction output hours overflow")
 }
 
+func TestTransactionSignalReplaceable(t *testing.T) {
+	cases := []struct {
+		name     string
+		hours    uint64
+		expected uint64
+		err      error
+	}{
+		{
+			name:  "no hours",
+			hours: 0,
+			err:   ErrReplaceableNoHours,
+		},
+		{
+			name:     "already signalling",
+			hours:    1,
+			expected: 1,
+		},
+		{
+			name:     "less than divisor",
+			hours:    99,
+			expected: 1,
+		},
+		{
+			name:     "multiple of divisor",
+			hours:    1000,
+			expected: 901,
+		},
+		{
+			name:     "signalling above divisor",
+			hours:    1001,
+			expected: 1001,
+		},
+		{
+			name:     "max hours",
+			hours:    math.MaxUint64,
+			expected: math.MaxUint64 - 14,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txn := Transaction{}
+			err := txn.PushOutput(makeAddress(), 1e6, 50)
+			require.NoError(t, err)
+			err = txn.PushOutput(makeAddress(), 1e6, tc.hours)
+			require.NoError(t, err)
+
+			err = txn.SignalReplaceable()
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				require.False(t, txn.IsReplaceable())
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, txn.IsReplaceable())
+			require.Equal(t, tc.expected, txn.Out[1].Hours)
+			require.Equal(t, uint64(50), txn.Out[0].Hours)
+		})
+	}
+
+	// Only the last output signals replace-by-fee
+	txn := Transaction{}
+	err := txn.PushOutput(makeAddress(), 1e6, 101)
+	require.NoError(t, err)
+	err = txn.PushOutput(makeAddress(), 1e6, 100)
+	require.NoError(t, err)
+	require.False(t, txn.IsReplaceable())
+
+	require.False(t, (&Transaction{}).IsReplaceable())
+}
+
 func TestTransactionsSize(t *testing.T) {
 	txns := makeTransactions(t, 10)
 	var size uint32
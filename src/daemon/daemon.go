@@ -664,6 +664,10 @@ loop:
 			if len(removedTxns) > 0 {
 				logger.Infof("Remove %d txns from pool that began violating hard constraints", len(removedTxns))
 			}
+			// Evict transactions that expired or that don't fit in the pool limits
+			if _, err := dm.visor.EvictUnconfirmed(); err != nil {
+				logger.WithError(err).Error("dm.Visor.EvictUnconfirmed failed")
+			}
 
 		case <-blocksRequestTicker.C:
 			elapser.Register("blocksRequestTicker")
@@ -1784,3 +1788,49 @@ func (dm *Daemon) InjectBroadcastTransaction(txn coin.Transaction) error {
 		return nil
 	})
 }
+
+// BumpFee creates and signs a transaction of a wallet replacing an unconfirmed transaction, which burns fee coin hours,
+// then injects it to the unconfirmed pool and broadcasts it. If fee is 0, the minimum replacement fee is burned.
+// Replace-by-fee must be enabled and the unconfirmed transaction must signal it.
+func (dm *Daemon) BumpFee(wltID string, password []byte, txid cipher.SHA256, fee uint64) (*coin.Transaction, []visor.TransactionInput, error) {
+	signedTxn, inputs, err := dm.visor.WalletBumpFee(wltID, password, txid, fee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := dm.InjectBroadcastTransaction(*signedTxn); err != nil {
+		return nil, nil, err
+	}
+
+	return signedTxn, inputs, nil
+}
+
+// ApprovePendingSpend signs the transaction of a pending wallet spend approved by checker,
+// then injects it to the unconfirmed pool and broadcasts it.
+// The signatures of the checker and of the creator are verified by visor.SignPendingSpend.
+// The pending spend is removed once its transaction is injected.
+func (dm *Daemon) ApprovePendingSpend(id cipher.SHA256, checker string, checkerSig, creatorSig cipher.Sig, password []byte) (*coin.Transaction, []visor.TransactionInput, error) {
+	signedTxn, inputs, err := dm.visor.SignPendingSpend(id, checker, checkerSig, creatorSig, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := dm.visor.WithUpdateTx("daemon.ApprovePendingSpend", func(tx *dbutil.Tx) error {
+		_, head, uxIn, err := dm.visor.InjectUserTransactionTx(tx, *signedTxn)
+		if err != nil {
+			logger.WithError(err).Error("InjectUserTransactionTx failed")
+			return err
+		}
+
+		if err := dm.BroadcastUserTransaction(*signedTxn, head, uxIn); err != nil {
+			logger.WithError(err).Error("BroadcastUserTransaction failed")
+			return err
+		}
+
+		return dm.visor.RemovePendingSpendTx(tx, id)
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return signedTxn, inputs, nil
+}
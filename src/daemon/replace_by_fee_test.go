@@ -0,0 +1,186 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/daemon/gnet"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor"
+)
+
+// relayRecorder is a daemon which records the messages it broadcasts instead of sending them
+type relayRecorder struct {
+	*Daemon
+	broadcast []gnet.Message
+}
+
+func (r *relayRecorder) broadcastMessage(msg gnet.Message) ([]uint64, error) {
+	r.broadcast = append(r.broadcast, msg)
+	return []uint64{1}, nil
+}
+
+// announced returns the hashes of the transactions announced since the last call
+func (r *relayRecorder) announced() []cipher.SHA256 {
+	var hashes []cipher.SHA256
+	for _, m := range r.broadcast {
+		if am, ok := m.(*AnnounceTxnsMessage); ok {
+			hashes = append(hashes, am.Transactions...)
+		}
+	}
+	r.broadcast = nil
+	return hashes
+}
+
+// newRelayTestDaemon returns a daemon with a visor whose genesis output can be spent with the returned key
+func newRelayTestDaemon(t *testing.T, replaceByFee bool) (*relayRecorder, coin.UxOut, cipher.SecKey, func()) {
+	db, shutdown := testutil.PrepareDB(t)
+
+	pubkey, seckey := cipher.GenerateKeyPair()
+
+	vc := visor.NewConfig()
+	vc.IsBlockPublisher = true
+	vc.BlockchainPubkey = pubkey
+	vc.BlockchainSeckey = seckey
+	vc.GenesisAddress = cipher.AddressFromPubKey(pubkey)
+	vc.GenesisCoinVolume = 100e12
+	vc.GenesisTimestamp = uint64(time.Now().Unix())
+	vc.ReplaceByFee = replaceByFee
+
+	v, err := visor.New(vc, db, nil)
+	require.NoError(t, err)
+	require.NoError(t, v.Init())
+
+	gb, err := v.GetSignedBlockBySeq(0)
+	require.NoError(t, err)
+	ux := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])[0]
+
+	dm := &Daemon{
+		config: NewDaemonConfig(),
+		visor:  v,
+	}
+
+	return &relayRecorder{Daemon: dm}, ux, seckey, shutdown
+}
+
+// makeSpendTxn spends all of the coins of ux, keeping hours coin hours and burning the others
+func makeSpendTxn(t *testing.T, ux coin.UxOut, key cipher.SecKey, hours uint64) coin.Transaction {
+	txn := coin.Transaction{}
+	err := txn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(testutil.MakeAddress(), ux.Body.Coins, hours)
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{key})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	return txn
+}
+
+// makeReplaceableSpendTxn is makeSpendTxn, with the coin hours of the output reduced to signal replace-by-fee
+func makeReplaceableSpendTxn(t *testing.T, ux coin.UxOut, key cipher.SecKey, hours uint64) coin.Transaction {
+	txn := makeSpendTxn(t, ux, key, hours)
+	err := txn.SignalReplaceable()
+	require.NoError(t, err)
+	txn.Sigs = nil
+	txn.SignInputs([]cipher.SecKey{key})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	return txn
+}
+
+func requirePool(t *testing.T, v *visor.Visor, txns ...coin.Transaction) {
+	utxns, err := v.GetAllUnconfirmedTransactions()
+	require.NoError(t, err)
+
+	hashes := make(map[cipher.SHA256]struct{}, len(utxns))
+	for _, utxn := range utxns {
+		hashes[utxn.Transaction.Hash()] = struct{}{}
+	}
+
+	expected := make(map[cipher.SHA256]struct{}, len(txns))
+	for _, txn := range txns {
+		expected[txn.Hash()] = struct{}{}
+	}
+
+	require.Equal(t, expected, hashes)
+}
+
+func TestRelayReplacement(t *testing.T) {
+	d, ux, key, shutdown := newRelayTestDaemon(t, true)
+	defer shutdown()
+
+	original := makeReplaceableSpendTxn(t, ux, key, ux.Body.Hours/4)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{original}}).process(d)
+	require.Equal(t, []cipher.SHA256{original.Hash()}, d.announced())
+	requirePool(t, d.visor, original)
+
+	// A conflicting transaction which doesn't burn enough additional coin hours is rejected and not relayed
+	cheap := makeSpendTxn(t, ux, key, original.Out[0].Hours-1)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{cheap}}).process(d)
+	require.Empty(t, d.announced())
+	requirePool(t, d.visor, original)
+
+	// A replacement removes the original transaction and is relayed to the peers
+	replacement := makeReplaceableSpendTxn(t, ux, key, ux.Body.Hours/10)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{replacement}}).process(d)
+	require.Equal(t, []cipher.SHA256{replacement.Hash()}, d.announced())
+	requirePool(t, d.visor, replacement)
+
+	evicted, err := d.visor.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	require.Equal(t, original.Hash(), evicted[0].Hash)
+	require.Equal(t, visor.EvictionReasonReplaced, evicted[0].Reason)
+
+	// The replaced transaction is rejected if a peer sends it again
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{original}}).process(d)
+	require.Empty(t, d.announced())
+	requirePool(t, d.visor, replacement)
+}
+
+func TestRelayReplacementNotSignalled(t *testing.T) {
+	d, ux, key, shutdown := newRelayTestDaemon(t, true)
+	defer shutdown()
+
+	original := makeSpendTxn(t, ux, key, ux.Body.Hours/4-1)
+	require.False(t, original.IsReplaceable())
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{original}}).process(d)
+	require.Equal(t, []cipher.SHA256{original.Hash()}, d.announced())
+
+	// A conflicting transaction burning more coin hours doesn't replace the original transaction, both are kept
+	replacement := makeSpendTxn(t, ux, key, ux.Body.Hours/10)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{replacement}}).process(d)
+	requirePool(t, d.visor, original, replacement)
+
+	evicted, err := d.visor.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+}
+
+func TestRelayReplacementDisabled(t *testing.T) {
+	d, ux, key, shutdown := newRelayTestDaemon(t, false)
+	defer shutdown()
+
+	original := makeReplaceableSpendTxn(t, ux, key, ux.Body.Hours/4)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{original}}).process(d)
+	require.Equal(t, []cipher.SHA256{original.Hash()}, d.announced())
+
+	// The node refuses to bump the fee of a transaction
+	_, _, err := d.BumpFee("foo.wlt", nil, original.Hash(), 0)
+	require.Equal(t, visor.ErrReplaceByFeeDisabled, err)
+	requirePool(t, d.visor, original)
+
+	// A conflicting transaction received from a peer doesn't replace the original transaction.
+	// Both are kept, like before replace-by-fee, and only one of them can be included in a block.
+	replacement := makeSpendTxn(t, ux, key, ux.Body.Hours/10)
+	(&GiveTxnsMessage{Transactions: []coin.Transaction{replacement}}).process(d)
+	requirePool(t, d.visor, original, replacement)
+
+	evicted, err := d.visor.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
@@ -18,11 +19,13 @@ import (
 
 	"github.com/MDLlife/MDL/src/api"
 	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/encrypt"
 	"github.com/MDLlife/MDL/src/params"
 	"github.com/MDLlife/MDL/src/readable"
 	"github.com/MDLlife/MDL/src/util/droplet"
 	"github.com/MDLlife/MDL/src/util/file"
 	"github.com/MDLlife/MDL/src/util/useragent"
+	"github.com/MDLlife/MDL/src/visor"
 	"github.com/MDLlife/MDL/src/wallet"
 )
 
@@ -152,6 +155,18 @@ type NodeConfig struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum total size of transactions in a block
 	MaxBlockTransactionsSize uint32
+	// Order in which unconfirmed transactions are included when creating blocks, fee-rate or fifo
+	BlockTxnOrdering string
+	// Maximum number of transactions in the unconfirmed pool, 0 for no limit
+	MaxUnconfirmedTransactions uint64
+	// Maximum size of the transactions in the unconfirmed pool, 0 for no limit
+	MaxUnconfirmedSize uint64
+	// Maximum time a transaction is kept in the unconfirmed pool, 0 for no limit
+	MaxUnconfirmedAge time.Duration
+	// Allow unconfirmed transactions which signal replace-by-fee to be replaced by conflicting transactions burning more coin hours
+	ReplaceByFee bool
+	// Minimum additional coin hours per KB burned by a replacement transaction
+	ReplaceByFeeIncrement uint64
 
 	unconfirmedBurnFactor          uint64
 	maxUnconfirmedTransactionSize  uint64
@@ -166,6 +181,27 @@ type NodeConfig struct {
 	WalletDirectory string
 	// Wallet crypto type
 	WalletCryptoType string
+	// Argon2id time and memory (KiB) costs of wallets encrypted with argon2id-chacha20poly1305
+	WalletArgon2Time   uint
+	WalletArgon2Memory uint
+	// Timed wallet backups, disabled if the interval is 0
+	// Defaults to ${DataDirectory}/wallet-backups/
+	WalletBackupDirectory string
+	WalletBackupInterval  time.Duration
+	// Number of timed wallet backups kept, 0 keeps all of them
+	WalletBackupRetention int
+	// File containing the password of the timed wallet backups
+	WalletBackupPasswordFile string
+	walletBackupPassword     []byte
+
+	// Time after which a pending wallet spend can no longer be approved
+	PendingSpendExpiry time.Duration
+	// Comma separated name:pubkey list of the identities which create, approve and reject pending wallet spends
+	PendingSpendKeys string
+	pendingSpendKeys map[string]cipher.PubKey
+
+	// Number of recent blocks sampled to estimate coin hour fees
+	FeeEstimateBlocks uint64
 
 	// Key-value storage
 	// Default to ${DataDirectory}/data
@@ -293,10 +329,27 @@ func NewNodeConfig(mode string, node NodeParameters) NodeConfig {
 		UnconfirmedVerifyTxn:     params.UserVerifyTxn,
 		CreateBlockVerifyTxn:     params.UserVerifyTxn,
 		MaxBlockTransactionsSize: params.UserVerifyTxn.MaxTransactionSize,
+		BlockTxnOrdering:         visor.TxnOrderingFeeRate,
+
+		MaxUnconfirmedTransactions: 0,
+		MaxUnconfirmedSize:         0,
+		MaxUnconfirmedAge:          0,
+		ReplaceByFee:               false,
+		ReplaceByFeeIncrement:      10,
 
 		// Wallets
-		WalletDirectory:  "",
-		WalletCryptoType: string(wallet.CryptoTypeScryptChacha20poly1305),
+		WalletDirectory:    "",
+		WalletCryptoType:   string(wallet.CryptoTypeScryptChacha20poly1305),
+		WalletArgon2Time:   encrypt.Argon2Time,
+		WalletArgon2Memory: encrypt.Argon2Memory,
+
+		WalletBackupDirectory: "",
+		WalletBackupInterval:  0,
+		WalletBackupRetention: 10,
+
+		PendingSpendExpiry: 24 * time.Hour,
+
+		FeeEstimateBlocks: 100,
 
 		// Key-value storage
 		KVStorageDirectory: "",
@@ -390,6 +443,12 @@ func (c *Config) postProcess() error {
 		c.Node.WalletDirectory = replaceHome(c.Node.WalletDirectory, home)
 	}
 
+	if c.Node.WalletBackupDirectory == "" {
+		c.Node.WalletBackupDirectory = filepath.Join(c.Node.DataDirectory, "wallet-backups")
+	} else {
+		c.Node.WalletBackupDirectory = replaceHome(c.Node.WalletBackupDirectory, home)
+	}
+
 	if c.Node.KVStorageDirectory == "" {
 		c.Node.KVStorageDirectory = filepath.Join(c.Node.DataDirectory, "data")
 	} else {
@@ -452,6 +511,25 @@ func (c *Config) postProcess() error {
 		c.Node.hostWhitelist = strings.Split(c.Node.HostWhitelist, ",")
 	}
 
+	if c.Node.PendingSpendKeys != "" {
+		c.Node.pendingSpendKeys = make(map[string]cipher.PubKey)
+		for _, k := range strings.Split(c.Node.PendingSpendKeys, ",") {
+			pair := strings.Split(k, ":")
+			if len(pair) != 2 || pair[0] == "" {
+				return fmt.Errorf("-pending-spend-keys entry %q must be name:pubkey", k)
+			}
+			if _, ok := c.Node.pendingSpendKeys[pair[0]]; ok {
+				return fmt.Errorf("-pending-spend-keys has a duplicate name %q", pair[0])
+			}
+
+			pubkey, err := cipher.PubKeyFromHex(pair[1])
+			if err != nil {
+				return fmt.Errorf("-pending-spend-keys key of %q is invalid: %v", pair[0], err)
+			}
+			c.Node.pendingSpendKeys[pair[0]] = pubkey
+		}
+	}
+
 	httpAuthEnabled := c.Node.WebInterfaceUsername != "" || c.Node.WebInterfacePassword != ""
 	if httpAuthEnabled && !c.Node.WebInterfaceHTTPS && !c.Node.WebInterfacePlaintextAuth {
 		return errors.New("Web interface auth enabled but HTTPS is not enabled. Use -web-interface-plaintext-auth=true if this is desired")
@@ -485,6 +563,41 @@ func (c *Config) postProcess() error {
 		return errors.New("-max-decimals-create-block exceeds MaxUint8")
 	}
 
+	if c.Node.WalletArgon2Time == 0 || c.Node.WalletArgon2Time > math.MaxUint32 {
+		return errors.New("-wallet-argon2-time must be > 0 and can't exceed MaxUint32")
+	}
+	if c.Node.WalletArgon2Memory < 8*encrypt.Argon2Threads || c.Node.WalletArgon2Memory > math.MaxUint32 {
+		return fmt.Errorf("-wallet-argon2-memory must be >= %d and can't exceed MaxUint32", 8*encrypt.Argon2Threads)
+	}
+
+	if c.Node.WalletBackupInterval < 0 {
+		return errors.New("-wallet-backup-interval can't be negative")
+	}
+	if c.Node.WalletBackupRetention < 0 {
+		return errors.New("-wallet-backup-retention can't be negative")
+	}
+	if c.Node.PendingSpendExpiry <= 0 {
+		return errors.New("-pending-spend-expiry must be > 0")
+	}
+	if c.Node.FeeEstimateBlocks == 0 {
+		return errors.New("-fee-estimate-blocks must be > 0")
+	}
+	if c.Node.WalletBackupInterval > 0 {
+		if c.Node.WalletBackupPasswordFile == "" {
+			return errors.New("-wallet-backup-password-file is required for timed wallet backups")
+		}
+
+		password, err := ioutil.ReadFile(replaceHome(c.Node.WalletBackupPasswordFile, home))
+		if err != nil {
+			return fmt.Errorf("read -wallet-backup-password-file failed: %v", err)
+		}
+
+		c.Node.walletBackupPassword = []byte(strings.TrimRight(string(password), "\r\n"))
+		if len(c.Node.walletBackupPassword) == 0 {
+			return errors.New("-wallet-backup-password-file is empty")
+		}
+	}
+
 	c.Node.UnconfirmedVerifyTxn.BurnFactor = uint32(c.Node.unconfirmedBurnFactor)
 	c.Node.UnconfirmedVerifyTxn.MaxTransactionSize = uint32(c.Node.maxUnconfirmedTransactionSize)
 	c.Node.UnconfirmedVerifyTxn.MaxDropletPrecision = uint8(c.Node.unconfirmedMaxDropletPrecision)
@@ -518,6 +631,15 @@ func (c *Config) postProcess() error {
 	if c.Node.MaxBlockTransactionsSize < c.Node.CreateBlockVerifyTxn.MaxTransactionSize {
 		return errors.New("-max-block-size must be >= -max-txn-size-create-block")
 	}
+	if _, err := visor.NewTxnOrderingPolicy(c.Node.BlockTxnOrdering); err != nil {
+		return fmt.Errorf("-block-txn-ordering must be %s or %s", visor.TxnOrderingFeeRate, visor.TxnOrderingFIFO)
+	}
+	if c.Node.MaxUnconfirmedSize != 0 && c.Node.MaxUnconfirmedSize < uint64(c.Node.UnconfirmedVerifyTxn.MaxTransactionSize) {
+		return errors.New("-max-unconfirmed-size must be 0 or >= -max-txn-size-unconfirmed")
+	}
+	if c.Node.MaxUnconfirmedAge < 0 {
+		return errors.New("-max-unconfirmed-age must be >= 0")
+	}
 
 	if c.Node.UnconfirmedVerifyTxn.BurnFactor < params.MinBurnFactor {
 		return fmt.Errorf("-burn-factor-unconfirmed must be >= params.MinBurnFactor (%d)", params.MinBurnFactor)
@@ -691,6 +813,12 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.Uint64Var(&c.createBlockMaxTransactionSize, "max-txn-size-create-block", uint64(c.CreateBlockVerifyTxn.MaxTransactionSize), "maximum size of a transaction applied when creating blocks")
 	flag.Uint64Var(&c.createBlockMaxDropletPrecision, "max-decimals-create-block", uint64(c.CreateBlockVerifyTxn.MaxDropletPrecision), "max number of decimal places applied when creating blocks")
 	flag.Uint64Var(&c.maxBlockSize, "max-block-size", uint64(c.MaxBlockTransactionsSize), "maximum total size of transactions in a block")
+	flag.StringVar(&c.BlockTxnOrdering, "block-txn-ordering", c.BlockTxnOrdering, "order in which unconfirmed transactions are included when creating blocks. Can be fee-rate or fifo, the order in which transactions were first received")
+	flag.Uint64Var(&c.MaxUnconfirmedTransactions, "max-unconfirmed-txns", c.MaxUnconfirmedTransactions, "maximum number of transactions in the unconfirmed pool, 0 for no limit. The transactions burning the fewest coin hours per byte are evicted first")
+	flag.Uint64Var(&c.MaxUnconfirmedSize, "max-unconfirmed-size", c.MaxUnconfirmedSize, "maximum size of the transactions in the unconfirmed pool in bytes, 0 for no limit")
+	flag.DurationVar(&c.MaxUnconfirmedAge, "max-unconfirmed-age", c.MaxUnconfirmedAge, "maximum time a transaction received from peers is kept in the unconfirmed pool, 0 for no limit")
+	flag.BoolVar(&c.ReplaceByFee, "replace-by-fee", c.ReplaceByFee, "allow unconfirmed transactions which signal replace-by-fee to be replaced by conflicting transactions burning more coin hours")
+	flag.Uint64Var(&c.ReplaceByFeeIncrement, "replace-by-fee-increment", c.ReplaceByFeeIncrement, "minimum additional coin hours per KB that a replacement transaction must burn")
 
 	flag.BoolVar(&c.RunBlockPublisher, "block-publisher", c.RunBlockPublisher, "run the daemon as a block publisher")
 	flag.StringVar(&c.BlockchainPubkeyStr, "blockchain-public-key", c.BlockchainPubkeyStr, "public key of the blockchain")
@@ -711,7 +839,16 @@ func (c *NodeConfig) RegisterFlags() {
 	flag.IntVar(&c.MaxIncomingMessageLength, "max-in-msg-len", c.MaxIncomingMessageLength, "Maximum length of incoming wire messages")
 	flag.BoolVar(&c.LocalhostOnly, "localhost-only", c.LocalhostOnly, "Run on localhost and only connect to localhost peers")
 	flag.BoolVar(&c.Arbitrating, "arbitrating", c.Arbitrating, "Run node in arbitrating mode")
-	flag.StringVar(&c.WalletCryptoType, "wallet-crypto-type", c.WalletCryptoType, "wallet crypto type. Can be sha256-xor or scrypt-chacha20poly1305")
+	flag.StringVar(&c.WalletCryptoType, "wallet-crypto-type", c.WalletCryptoType, "wallet crypto type. Can be sha256-xor, scrypt-chacha20poly1305 or argon2id-chacha20poly1305")
+	flag.UintVar(&c.WalletArgon2Time, "wallet-argon2-time", c.WalletArgon2Time, "argon2id time cost of wallets encrypted with argon2id-chacha20poly1305")
+	flag.UintVar(&c.WalletArgon2Memory, "wallet-argon2-memory", c.WalletArgon2Memory, "argon2id memory cost in KiB of wallets encrypted with argon2id-chacha20poly1305")
+	flag.StringVar(&c.WalletBackupDirectory, "wallet-backup-dir", c.WalletBackupDirectory, "location of the timed wallet backups. Defaults to ~/.mdl/wallet-backups/")
+	flag.DurationVar(&c.WalletBackupInterval, "wallet-backup-interval", c.WalletBackupInterval, "interval of the timed wallet backups. 0 disables them")
+	flag.IntVar(&c.WalletBackupRetention, "wallet-backup-retention", c.WalletBackupRetention, "number of timed wallet backups kept. 0 keeps all of them")
+	flag.StringVar(&c.WalletBackupPasswordFile, "wallet-backup-password-file", c.WalletBackupPasswordFile, "file containing the password of the timed wallet backups. Required if -wallet-backup-interval is set")
+	flag.DurationVar(&c.PendingSpendExpiry, "pending-spend-expiry", c.PendingSpendExpiry, "time after which a pending wallet spend can no longer be approved")
+	flag.StringVar(&c.PendingSpendKeys, "pending-spend-keys", c.PendingSpendKeys, "comma separated name:pubkey list of the creators and checkers of pending wallet spends, which sign their approvals")
+	flag.Uint64Var(&c.FeeEstimateBlocks, "fee-estimate-blocks", c.FeeEstimateBlocks, "number of recent blocks sampled to estimate coin hour fees")
 	flag.BoolVar(&c.Version, "version", false, "show node version")
 }
 
@@ -122,6 +122,7 @@ func (c *Coin) Run() error {
 	var wg sync.WaitGroup
 
 	quit := make(chan struct{})
+	stopBackups := make(chan struct{})
 
 	// Catch SIGINT (CTRL-C) (closes the quit channel)
 	go apputil.CatchInterrupt(quit)
@@ -278,6 +279,12 @@ func (c *Coin) Run() error {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.RunBackups(stopBackups)
+	}()
+
 	if c.config.Node.WebInterface {
 		cancelLaunchBrowser := make(chan struct{})
 
@@ -322,6 +329,9 @@ func (c *Coin) Run() error {
 		webInterface.Shutdown()
 	}
 
+	c.logger.Info("Stopping wallet backups")
+	close(stopBackups)
+
 	c.logger.Info("Closing daemon")
 	d.Shutdown()
 
@@ -391,12 +401,28 @@ func (c *Coin) ConfigureVisor() visor.Config {
 	vc.CreateBlockVerifyTxn = c.config.Node.CreateBlockVerifyTxn
 	vc.MaxBlockTransactionsSize = c.config.Node.MaxBlockTransactionsSize
 
+	blockTxnOrdering, err := visor.NewTxnOrderingPolicy(c.config.Node.BlockTxnOrdering)
+	if err != nil {
+		log.Panic(err)
+	}
+	vc.BlockTxnOrdering = blockTxnOrdering
+
+	vc.MaxUnconfirmedTransactions = c.config.Node.MaxUnconfirmedTransactions
+	vc.MaxUnconfirmedSize = c.config.Node.MaxUnconfirmedSize
+	vc.MaxUnconfirmedAge = c.config.Node.MaxUnconfirmedAge
+	vc.ReplaceByFee = c.config.Node.ReplaceByFee
+	vc.ReplaceByFeeIncrement = c.config.Node.ReplaceByFeeIncrement
+
 	vc.GenesisAddress = c.config.Node.genesisAddress
 	vc.GenesisSignature = c.config.Node.genesisSignature
 	vc.GenesisTimestamp = c.config.Node.GenesisTimestamp
 	vc.GenesisCoinVolume = c.config.Node.GenesisCoinVolume
 	vc.Arbitrating = c.config.Node.Arbitrating
 
+	vc.PendingSpendExpiry = c.config.Node.PendingSpendExpiry
+	vc.PendingSpendKeys = c.config.Node.pendingSpendKeys
+	vc.FeeEstimateBlocks = c.config.Node.FeeEstimateBlocks
+
 	return vc
 }
 
@@ -415,6 +441,13 @@ func (c *Coin) ConfigureWallet() wallet.Config {
 	}
 
 	wc.CryptoType = cryptoType
+	wc.Argon2Time = uint32(c.config.Node.WalletArgon2Time)
+	wc.Argon2Memory = uint32(c.config.Node.WalletArgon2Memory)
+
+	wc.BackupDir = c.config.Node.WalletBackupDirectory
+	wc.BackupInterval = c.config.Node.WalletBackupInterval
+	wc.BackupRetention = c.config.Node.WalletBackupRetention
+	wc.BackupPassword = c.config.Node.walletBackupPassword
 
 	return wc
 }
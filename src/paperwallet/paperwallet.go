@@ -0,0 +1,254 @@
+/*
+Package paperwallet generates keypairs or a seed and renders them as a printable page with QR codes
+*/
+package paperwallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/encrypt"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// MaxEntries is the maximum number of keypairs or addresses of a paper wallet
+const MaxEntries = 100
+
+var (
+	// ErrInvalidNumEntries the number of keypairs or addresses is not in [1, MaxEntries]
+	ErrInvalidNumEntries = fmt.Errorf("Number of keypairs or addresses must be between 1 and %d", MaxEntries)
+	// ErrInvalidSeedEntropy the seed entropy is not 128 or 256
+	ErrInvalidSeedEntropy = errors.New("Seed entropy must be 128 or 256")
+	// ErrNotEncrypted the paper wallet secrets are not encrypted
+	ErrNotEncrypted = errors.New("Paper wallet is not encrypted")
+	// ErrInvalidPassword decrypting a secret failed
+	ErrInvalidPassword = errors.New("Invalid password")
+
+	// encryptor encrypts the secrets. Tests use cheaper scrypt parameters.
+	encryptor = encrypt.DefaultScryptChacha20poly1305
+)
+
+// Options are the options of New
+type Options struct {
+	// Coin is the coin type of the addresses and secret keys, mdl if empty.
+	// Bitcoin secret keys are in Wallet Import Format instead of hex.
+	Coin wallet.CoinType
+	// Label is printed on the page
+	Label string
+	// N is the number of keypairs, or the number of addresses of the seed
+	N int
+	// Seed generates a bip39 seed and the first N addresses of its deterministic wallet,
+	// instead of N unrelated keypairs
+	Seed bool
+	// SeedEntropy is the entropy of the seed, 128 or 256. Defaults to 128.
+	SeedEntropy int
+	// Password encrypts the secret keys and the seed, if set
+	Password []byte
+}
+
+// Entry is an address of a paper wallet, with its secret key in keypair mode
+type Entry struct {
+	Address string `json:"address"`
+	// Secret is the secret key, encrypted if the paper wallet is encrypted.
+	// It is empty in seed mode.
+	Secret string `json:"secret,omitempty"`
+	// Checksum is the checksum of the unencrypted secret key
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// PaperWallet is a set of keypairs, or a seed and its addresses, to be printed
+type PaperWallet struct {
+	Coin      wallet.CoinType `json:"coin"`
+	Label     string          `json:"label,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Encrypted bool            `json:"encrypted"`
+	// Seed is the seed, encrypted if the paper wallet is encrypted. It is empty in keypair mode.
+	Seed string `json:"seed,omitempty"`
+	// SeedChecksum is the checksum of the unencrypted seed
+	SeedChecksum string  `json:"seed_checksum,omitempty"`
+	Entries      []Entry `json:"entries"`
+}
+
+// New generates a paper wallet
+func New(opts Options) (*PaperWallet, error) {
+	if opts.N < 1 || opts.N > MaxEntries {
+		return nil, ErrInvalidNumEntries
+	}
+
+	coin := opts.Coin
+	if coin == "" {
+		coin = wallet.CoinTypeMDL
+	}
+
+	w, seed, err := generate(coin, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Erase()
+
+	pw := &PaperWallet{
+		Coin:      coin,
+		Label:     opts.Label,
+		CreatedAt: time.Now().UTC(),
+		Encrypted: len(opts.Password) != 0,
+	}
+
+	encryptSecret := func(secret string) (string, error) {
+		if !pw.Encrypted {
+			return secret, nil
+		}
+
+		b, err := encryptor.Encrypt([]byte(secret), opts.Password)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	if opts.Seed {
+		pw.SeedChecksum = Checksum(seed)
+		pw.Seed, err = encryptSecret(seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range w.Entries {
+		re := wallet.NewReadableEntry(coin, w.Type(), e)
+		entry := Entry{
+			Address: re.Address,
+		}
+
+		if !opts.Seed {
+			entry.Checksum = Checksum(re.Secret)
+			entry.Secret, err = encryptSecret(re.Secret)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		pw.Entries = append(pw.Entries, entry)
+	}
+
+	return pw, nil
+}
+
+// generate creates an unsaved wallet with the keypairs, or the seed and its addresses
+func generate(coin wallet.CoinType, opts Options) (*wallet.Wallet, string, error) {
+	if !opts.Seed {
+		w, err := wallet.NewWallet(wallet.NewWalletFilename(), wallet.Options{
+			Coin: coin,
+			Type: wallet.WalletTypeCollection,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i := 0; i < opts.N; i++ {
+			_, s := cipher.GenerateKeyPair()
+			if _, err := w.ImportSecretKey(s); err != nil {
+				return nil, "", err
+			}
+		}
+
+		return w, "", nil
+	}
+
+	entropy := opts.SeedEntropy
+	if entropy == 0 {
+		entropy = 128
+	}
+
+	switch entropy {
+	case 128, 256:
+	default:
+		return nil, "", ErrInvalidSeedEntropy
+	}
+
+	e, err := bip39.NewEntropy(entropy)
+	if err != nil {
+		return nil, "", err
+	}
+
+	seed, err := bip39.NewMnemonic(e)
+	if err != nil {
+		return nil, "", err
+	}
+
+	w, err := wallet.NewWallet(wallet.NewWalletFilename(), wallet.Options{
+		Coin:      coin,
+		Type:      wallet.WalletTypeDeterministic,
+		Seed:      seed,
+		GenerateN: uint64(opts.N),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return w, seed, nil
+}
+
+// Checksum returns the checksum of an unencrypted secret key or seed,
+// the hex encoded first 4 bytes of its SHA256 hash. It verifies a secret after typing or decrypting it.
+func Checksum(secret string) string {
+	h := cipher.SumSHA256([]byte(secret))
+	return hex.EncodeToString(h[:4])
+}
+
+// DecryptSecret decrypts a secret key or seed of an encrypted paper wallet
+func DecryptSecret(secret string, password []byte) (string, error) {
+	b, err := encryptor.Decrypt([]byte(secret), password)
+	if err != nil {
+		return "", ErrInvalidPassword
+	}
+	return string(b), nil
+}
+
+// Decrypt returns a copy of an encrypted paper wallet with the secret keys and the seed decrypted
+func (pw *PaperWallet) Decrypt(password []byte) (*PaperWallet, error) {
+	if !pw.Encrypted {
+		return nil, ErrNotEncrypted
+	}
+
+	decryptSecret := func(secret, checksum string) (string, error) {
+		s, err := DecryptSecret(secret, password)
+		if err != nil {
+			return "", err
+		}
+
+		if Checksum(s) != checksum {
+			return "", fmt.Errorf("Checksum %s of the decrypted secret doesn't match %s", Checksum(s), checksum)
+		}
+
+		return s, nil
+	}
+
+	c := *pw
+	c.Encrypted = false
+	c.Entries = make([]Entry, len(pw.Entries))
+
+	if pw.Seed != "" {
+		seed, err := decryptSecret(pw.Seed, pw.SeedChecksum)
+		if err != nil {
+			return nil, err
+		}
+		c.Seed = seed
+	}
+
+	for i, e := range pw.Entries {
+		if e.Secret != "" {
+			s, err := decryptSecret(e.Secret, e.Checksum)
+			if err != nil {
+				return nil, err
+			}
+			e.Secret = s
+		}
+		c.Entries[i] = e
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,224 @@
+package paperwallet
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/encrypt"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func init() {
+	// Cheap scrypt parameters, the default parameters take seconds per secret
+	encryptor = encrypt.ScryptChacha20poly1305{
+		N:      1 << 4,
+		R:      encrypt.ScryptR,
+		P:      encrypt.ScryptP,
+		KeyLen: encrypt.ScryptKeyLen,
+	}
+}
+
+func TestNewKeypairs(t *testing.T) {
+	for _, coin := range []wallet.CoinType{wallet.CoinTypeMDL, wallet.CoinTypeBitcoin} {
+		t.Run(string(coin), func(t *testing.T) {
+			pw, err := New(Options{
+				Coin:  coin,
+				Label: "foo",
+				N:     3,
+			})
+			require.NoError(t, err)
+			require.Equal(t, coin, pw.Coin)
+			require.Equal(t, "foo", pw.Label)
+			require.False(t, pw.Encrypted)
+			require.Empty(t, pw.Seed)
+			require.Len(t, pw.Entries, 3)
+
+			for _, e := range pw.Entries {
+				require.Equal(t, Checksum(e.Secret), e.Checksum)
+
+				switch coin {
+				case wallet.CoinTypeMDL:
+					s := cipher.MustSecKeyFromHex(e.Secret)
+					require.Equal(t, cipher.MustAddressFromSecKey(s).String(), e.Address)
+				case wallet.CoinTypeBitcoin:
+					s := cipher.MustSecKeyFromBitcoinWalletImportFormat(e.Secret)
+					require.Equal(t, cipher.MustBitcoinAddressFromSecKey(s).String(), e.Address)
+				}
+			}
+
+			require.NotEqual(t, pw.Entries[0].Secret, pw.Entries[1].Secret)
+		})
+	}
+}
+
+func TestNewSeed(t *testing.T) {
+	for _, entropy := range []int{0, 128, 256} {
+		t.Run(fmt.Sprint(entropy), func(t *testing.T) {
+			pw, err := New(Options{
+				N:           2,
+				Seed:        true,
+				SeedEntropy: entropy,
+			})
+			require.NoError(t, err)
+			require.Equal(t, wallet.CoinTypeMDL, pw.Coin)
+			require.NoError(t, bip39.ValidateMnemonic(pw.Seed))
+			require.Equal(t, Checksum(pw.Seed), pw.SeedChecksum)
+
+			words := 12
+			if entropy == 256 {
+				words = 24
+			}
+			require.Len(t, strings.Fields(pw.Seed), words)
+
+			// The addresses are those of a deterministic wallet of the seed
+			w, err := wallet.NewWallet("t.wlt", wallet.Options{
+				Seed:      pw.Seed,
+				GenerateN: 2,
+			})
+			require.NoError(t, err)
+
+			require.Len(t, pw.Entries, 2)
+			for i, e := range pw.Entries {
+				require.Equal(t, w.Entries[i].Address.String(), e.Address)
+				require.Empty(t, e.Secret)
+				require.Empty(t, e.Checksum)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(Options{})
+	require.Equal(t, ErrInvalidNumEntries, err)
+
+	_, err = New(Options{N: MaxEntries + 1})
+	require.Equal(t, ErrInvalidNumEntries, err)
+
+	_, err = New(Options{N: 1, Seed: true, SeedEntropy: 64})
+	require.Equal(t, ErrInvalidSeedEntropy, err)
+
+	_, err = New(Options{N: 1, Coin: "foo"})
+	require.Error(t, err)
+}
+
+func TestEncrypt(t *testing.T) {
+	for _, seed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("seed=%v", seed), func(t *testing.T) {
+			pw, err := New(Options{
+				N:        2,
+				Seed:     seed,
+				Password: []byte("pwd"),
+			})
+			require.NoError(t, err)
+			require.True(t, pw.Encrypted)
+
+			_, err = pw.Decrypt([]byte("wrong"))
+			require.Equal(t, ErrInvalidPassword, err)
+
+			dpw, err := pw.Decrypt([]byte("pwd"))
+			require.NoError(t, err)
+			require.False(t, dpw.Encrypted)
+			require.True(t, pw.Encrypted)
+
+			if seed {
+				require.NotEqual(t, pw.Seed, dpw.Seed)
+				require.NoError(t, bip39.ValidateMnemonic(dpw.Seed))
+				require.Equal(t, Checksum(dpw.Seed), dpw.SeedChecksum)
+
+				s, err := DecryptSecret(pw.Seed, []byte("pwd"))
+				require.NoError(t, err)
+				require.Equal(t, dpw.Seed, s)
+			}
+
+			for i, e := range dpw.Entries {
+				require.Equal(t, pw.Entries[i].Address, e.Address)
+				if !seed {
+					require.NotEqual(t, pw.Entries[i].Secret, e.Secret)
+					require.Equal(t, cipher.MustAddressFromSecKey(cipher.MustSecKeyFromHex(e.Secret)).String(), e.Address)
+				}
+			}
+
+			_, err = dpw.Decrypt([]byte("pwd"))
+			require.Equal(t, ErrNotEncrypted, err)
+		})
+	}
+}
+
+func TestDecryptChecksumMismatch(t *testing.T) {
+	pw, err := New(Options{
+		N:        1,
+		Password: []byte("pwd"),
+	})
+	require.NoError(t, err)
+
+	pw.Entries[0].Checksum = "00000000"
+	_, err = pw.Decrypt([]byte("pwd"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "doesn't match 00000000")
+}
+
+func TestRender(t *testing.T) {
+	pw, err := New(Options{
+		N:        2,
+		Label:    "<foo & bar>",
+		Password: []byte("pwd"),
+	})
+	require.NoError(t, err)
+
+	svg, err := pw.SVG()
+	require.NoError(t, err)
+
+	// The page is well-formed XML
+	d := xml.NewDecoder(strings.NewReader(string(svg)))
+	for {
+		_, err := d.Token()
+		if err != nil {
+			require.Equal(t, "EOF", err.Error())
+			break
+		}
+	}
+
+	page, err := pw.HTML()
+	require.NoError(t, err)
+
+	for _, b := range [][]byte{svg, page} {
+		s := html.UnescapeString(string(b))
+		require.Contains(t, s, "MDL paper wallet")
+		require.Contains(t, string(b), "&lt;foo &amp; bar&gt;")
+		require.Contains(t, s, "encrypted with a passphrase")
+		for _, e := range pw.Entries {
+			require.Contains(t, s, e.Address)
+			require.Contains(t, s, "Checksum: "+e.Checksum)
+			for _, l := range wrap(e.Secret, wrapWidth) {
+				require.Contains(t, s, l)
+			}
+		}
+		// Two QR codes per keypair
+		require.Equal(t, 4, strings.Count(s, `shape-rendering="crispEdges"`))
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		s     string
+		width int
+		lines []string
+	}{
+		{"", 5, nil},
+		{"abc", 5, []string{"abc"}},
+		{"abcdefghijk", 5, []string{"abcde", "fghij", "k"}},
+		{"ab cd ef", 5, []string{"ab cd", "ef"}},
+		{"ab cdefghij k", 5, []string{"ab", "cdefg", "hij k"}},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.lines, wrap(tc.s, tc.width), tc.s)
+	}
+}
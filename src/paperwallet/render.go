@@ -0,0 +1,283 @@
+package paperwallet
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/MDLlife/MDL/src/util/qrcode"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+const (
+	// pageWidth is the width of an A4 page at 96 dpi
+	pageWidth  = 794
+	pageMargin = 40
+	qrSize     = 180
+	// wrapWidth is the number of monospace characters per line of the secrets
+	wrapWidth  = 44
+	lineHeight = 14
+)
+
+// qrLevel is the error correction level of the QR codes. Medium tolerates some wear of the paper.
+const qrLevel = qrcode.Medium
+
+// section is an address or secret printed with its QR code
+type section struct {
+	Title    string
+	Text     string
+	Checksum string
+	code     *qrcode.Code
+}
+
+// QR returns the QR code as an inline SVG image
+func (s section) QR() template.HTML {
+	return template.HTML(s.code.SVG(4)) // nolint: gosec
+}
+
+// Lines returns the text wrapped to the width of the section
+func (s section) Lines() []string {
+	return wrap(s.Text, wrapWidth)
+}
+
+// row is printed as a line of the page: an address and its secret key, or the seed
+type row struct {
+	Left  *section
+	Right *section
+}
+
+type page struct {
+	Title    string
+	Subtitle string
+	Notes    []string
+	Rows     []row
+	QRSize   int
+}
+
+func newSection(title, text, checksum string) (*section, error) {
+	c, err := qrcode.Encode([]byte(text), qrLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &section{
+		Title:    title,
+		Text:     text,
+		Checksum: checksum,
+		code:     c,
+	}, nil
+}
+
+func (pw *PaperWallet) page() (*page, error) {
+	coinName := "MDL"
+	if pw.Coin == wallet.CoinTypeBitcoin {
+		coinName = "Bitcoin"
+	}
+
+	p := &page{
+		Title:    fmt.Sprintf("%s paper wallet", coinName),
+		Subtitle: fmt.Sprintf("Created %s", pw.CreatedAt.Format("2006-01-02 15:04:05 MST")),
+		QRSize:   qrSize,
+	}
+	if pw.Label != "" {
+		p.Subtitle = fmt.Sprintf("%s, %s", pw.Label, p.Subtitle)
+	}
+
+	if pw.Encrypted {
+		p.Notes = append(p.Notes, "The secrets are encrypted with a passphrase. Decrypt them with the passphrase before use.")
+	}
+	p.Notes = append(p.Notes, "Verify a secret after typing or decrypting it by its checksum, the first 4 bytes of its SHA256 hash.")
+
+	if pw.Seed != "" {
+		s, err := newSection("Seed", pw.Seed, pw.SeedChecksum)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Notes = append(p.Notes, "Keep the seed secret. It recovers all the addresses of the wallet.")
+		p.Rows = append(p.Rows, row{Left: s})
+	}
+
+	for i, e := range pw.Entries {
+		var r row
+		var err error
+		r.Left, err = newSection(fmt.Sprintf("Address %d", i+1), e.Address, "")
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Secret != "" {
+			r.Right, err = newSection(fmt.Sprintf("Secret key %d", i+1), e.Secret, e.Checksum)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		p.Rows = append(p.Rows, r)
+	}
+
+	return p, nil
+}
+
+// SVG renders the paper wallet as a printable SVG image
+func (pw *PaperWallet) SVG() ([]byte, error) {
+	p, err := pw.page()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	y := pageMargin
+
+	text := func(x, y, size int, font, s string) {
+		fmt.Fprintf(&body, `<text x="%d" y="%d" font-family="%s" font-size="%d">%s</text>`+"\n", x, y, font, size, html.EscapeString(s))
+	}
+
+	y += 24
+	text(pageMargin, y, 24, "sans-serif", p.Title)
+	y += 22
+	text(pageMargin, y, 13, "sans-serif", p.Subtitle)
+	for _, n := range p.Notes {
+		y += 18
+		text(pageMargin, y, 11, "sans-serif", n)
+	}
+	y += 20
+
+	drawSection := func(x, y int, s *section) int {
+		text(x, y+12, 13, "sans-serif", s.Title)
+		y += 16
+
+		n := s.code.Size + qrcode.QuietZone*2
+		fmt.Fprintf(&body, `<svg x="%d" y="%d" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%s" fill="#000000"/></svg>`+"\n",
+			x, y, qrSize, qrSize, n, n, s.code.SVGPath())
+		y += qrSize
+
+		for _, l := range s.Lines() {
+			y += lineHeight
+			text(x, y, 10, "monospace", l)
+		}
+
+		if s.Checksum != "" {
+			y += lineHeight
+			text(x, y, 10, "monospace", "Checksum: "+s.Checksum)
+		}
+
+		return y
+	}
+
+	for _, r := range p.Rows {
+		// Separator between the rows, to cut the page
+		fmt.Fprintf(&body, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999999" stroke-dasharray="4,4"/>`+"\n", pageMargin, y, pageWidth-pageMargin, y)
+		y += 10
+
+		bottom := y
+		if r.Left != nil {
+			bottom = drawSection(pageMargin, y, r.Left)
+		}
+		if r.Right != nil {
+			if b := drawSection(pageWidth/2, y, r.Right); b > bottom {
+				bottom = b
+			}
+		}
+
+		y = bottom + 20
+	}
+
+	y += pageMargin - 20
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%[1]d" height="%[2]d" viewBox="0 0 %[1]d %[2]d">`+"\n"+
+		`<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n", pageWidth, y)
+	b.Write(body.Bytes())
+	b.WriteString("</svg>\n")
+
+	return b.Bytes(), nil
+}
+
+var htmlTemplate = template.Must(template.New("paperwallet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 40px; color: #000000; background: #ffffff; }
+h1 { font-size: 24px; margin: 0 0 8px 0; }
+.subtitle { font-size: 13px; }
+.note { font-size: 11px; margin: 4px 0; }
+.row { display: flex; border-top: 1px dashed #999999; padding: 10px 0 20px 0; page-break-inside: avoid; break-inside: avoid; }
+.section { width: 50%; }
+.title { font-size: 13px; }
+.qr svg { width: {{.QRSize}}px; height: {{.QRSize}}px; display: block; }
+.text { font-family: monospace; font-size: 10px; white-space: pre; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="subtitle">{{.Subtitle}}</div>
+{{range .Notes}}<p class="note">{{.}}</p>
+{{end}}
+{{- range .Rows}}
+<div class="row">
+<div class="section">{{with .Left}}{{template "section" .}}{{end}}</div>
+<div class="section">{{with .Right}}{{template "section" .}}{{end}}</div>
+</div>
+{{- end}}
+</body>
+</html>
+{{define "section"}}
+<div class="title">{{.Title}}</div>
+<div class="qr">{{.QR}}</div>
+<div class="text">{{range .Lines}}{{.}}
+{{end}}{{if .Checksum}}Checksum: {{.Checksum}}{{end}}</div>
+{{end}}`))
+
+// HTML renders the paper wallet as a printable HTML page
+func (pw *PaperWallet) HTML() ([]byte, error) {
+	p, err := pw.page()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, p); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// wrap splits a text into lines of at most width characters, between words if possible
+func wrap(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, w := range strings.Fields(s) {
+		for len(w) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, w[:width])
+			w = w[width:]
+		}
+
+		switch {
+		case line == "":
+			line = w
+		case len(line)+1+len(w) <= width:
+			line += " " + w
+		default:
+			lines = append(lines, line)
+			line = w
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
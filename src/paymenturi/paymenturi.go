@@ -0,0 +1,183 @@
+/*
+Package paymenturi parses and encodes payment request URIs.
+
+A payment request URI names an address to pay and, optionally, the coins and coin hours to send,
+a label for the address and a message describing the payment:
+
+	mdl:<address>?amount=<coins>&hours=<coin hours>&label=<label>&message=<message>
+
+The amount is in coins, with at most the decimal places allowed by the droplet precision rules.
+The label and message are percent-encoded. Other parameters are ignored, unless their name starts
+with "req-", which marks a parameter that must be understood to make the payment.
+*/
+package paymenturi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/util/droplet"
+)
+
+// Scheme is the scheme of payment request URIs
+const Scheme = "mdl"
+
+var (
+	// ErrInvalidScheme the URI scheme is not mdl
+	ErrInvalidScheme = errors.New("URI scheme must be mdl")
+	// ErrMissingAddress the URI has no address
+	ErrMissingAddress = errors.New("URI address is missing")
+	// ErrZeroAmount the URI amount is zero
+	ErrZeroAmount = errors.New("URI amount must not be zero")
+)
+
+// URI is a payment request
+type URI struct {
+	Address cipher.Address
+	// Coins is the amount to send in droplets, zero if the URI has no amount
+	Coins uint64
+	// Hours are the coin hours to send, nil if the URI has no hours
+	Hours *uint64
+	// Label is a label of the address, such as the name of the payee
+	Label string
+	// Message describes the payment
+	Message string
+}
+
+// IsURI returns whether s has the payment request URI scheme, so that it should be parsed with Parse
+// instead of as an address
+func IsURI(s string) bool {
+	return len(s) > len(Scheme) && strings.EqualFold(s[:len(Scheme)+1], Scheme+":")
+}
+
+// Parse parses a payment request URI
+func Parse(s string) (*URI, error) {
+	s = strings.TrimSpace(s)
+	if !IsURI(s) {
+		return nil, ErrInvalidScheme
+	}
+
+	rest := s[len(Scheme)+1:]
+	addrStr := rest
+	var query string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		addrStr = rest[:i]
+		query = rest[i+1:]
+	}
+
+	if addrStr == "" {
+		return nil, ErrMissingAddress
+	}
+
+	addr, err := cipher.DecodeBase58Address(addrStr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid URI address: %v", err)
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid URI parameters: %v", err)
+	}
+
+	u := &URI{
+		Address: addr,
+	}
+
+	for k, v := range values {
+		if len(v) > 1 {
+			return nil, fmt.Errorf("URI parameter %q is duplicated", k)
+		}
+
+		switch k {
+		case "amount":
+			coins, err := droplet.FromString(v[0])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid URI amount: %v", err)
+			}
+
+			if coins == 0 {
+				return nil, ErrZeroAmount
+			}
+
+			if err := params.DropletPrecisionCheck(params.UserVerifyTxn.MaxDropletPrecision, coins); err != nil {
+				return nil, fmt.Errorf("Invalid URI amount: %v", err)
+			}
+
+			u.Coins = coins
+		case "hours":
+			hours, err := strconv.ParseUint(v[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid URI hours: %v", err)
+			}
+
+			u.Hours = &hours
+		case "label":
+			u.Label = v[0]
+		case "message":
+			u.Message = v[0]
+		default:
+			if strings.HasPrefix(k, "req-") {
+				return nil, fmt.Errorf("Unsupported required URI parameter %q", k)
+			}
+		}
+	}
+
+	return u, nil
+}
+
+// String encodes the payment request as a URI. It doesn't validate the payment request.
+func (u URI) String() string {
+	var query []string
+	if u.Coins != 0 {
+		coins := decimal.NewFromBigInt(new(big.Int).SetUint64(u.Coins), -droplet.Exponent)
+		query = append(query, "amount="+coins.String())
+	}
+	if u.Hours != nil {
+		query = append(query, "hours="+strconv.FormatUint(*u.Hours, 10))
+	}
+	if u.Label != "" {
+		query = append(query, "label="+escape(u.Label))
+	}
+	if u.Message != "" {
+		query = append(query, "message="+escape(u.Message))
+	}
+
+	s := Scheme + ":" + u.Address.String()
+	if len(query) != 0 {
+		s += "?" + strings.Join(query, "&")
+	}
+
+	return s
+}
+
+// Validate validates the payment request, like Parse
+func (u URI) Validate() error {
+	if u.Address.Null() {
+		return ErrMissingAddress
+	}
+
+	if u.Coins > 0 {
+		if _, err := droplet.ToString(u.Coins); err != nil {
+			return fmt.Errorf("Invalid URI amount: %v", err)
+		}
+
+		if err := params.DropletPrecisionCheck(params.UserVerifyTxn.MaxDropletPrecision, u.Coins); err != nil {
+			return fmt.Errorf("Invalid URI amount: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// escape percent-encodes a parameter value, with spaces as %20 rather than +
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
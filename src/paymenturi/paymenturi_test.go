@@ -0,0 +1,207 @@
+package paymenturi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/testutil"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestParse(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	cases := []struct {
+		name string
+		uri  string
+		exp  *URI
+		err  error
+	}{
+		{
+			name: "address",
+			uri:  "mdl:" + addr.String(),
+			exp:  &URI{Address: addr},
+		},
+		{
+			name: "all parameters",
+			uri:  "mdl:" + addr.String() + "?amount=12.5&hours=100&label=Luke%20Jr&message=Donation+for+project%20xyz",
+			exp: &URI{
+				Address: addr,
+				Coins:   12500000,
+				Hours:   uint64Ptr(100),
+				Label:   "Luke Jr",
+				Message: "Donation for project xyz",
+			},
+		},
+		{
+			name: "scheme is case insensitive, spaces trimmed",
+			uri:  " MDL:" + addr.String() + "?amount=1 ",
+			exp: &URI{
+				Address: addr,
+				Coins:   1000000,
+			},
+		},
+		{
+			name: "zero hours",
+			uri:  "mdl:" + addr.String() + "?hours=0",
+			exp: &URI{
+				Address: addr,
+				Hours:   uint64Ptr(0),
+			},
+		},
+		{
+			name: "unknown parameter is ignored",
+			uri:  "mdl:" + addr.String() + "?foo=bar",
+			exp:  &URI{Address: addr},
+		},
+		{
+			name: "unknown required parameter",
+			uri:  "mdl:" + addr.String() + "?req-foo=bar",
+			err:  errors.New(`Unsupported required URI parameter "req-foo"`),
+		},
+		{
+			name: "invalid scheme",
+			uri:  "bitcoin:" + addr.String(),
+			err:  ErrInvalidScheme,
+		},
+		{
+			name: "address only",
+			uri:  addr.String(),
+			err:  ErrInvalidScheme,
+		},
+		{
+			name: "missing address",
+			uri:  "mdl:?amount=1",
+			err:  ErrMissingAddress,
+		},
+		{
+			name: "invalid address",
+			uri:  "mdl:foo",
+			err:  errors.New("Invalid URI address: Invalid address length"),
+		},
+		{
+			name: "invalid amount",
+			uri:  "mdl:" + addr.String() + "?amount=foo",
+			err:  errors.New("Invalid URI amount: can't convert foo to decimal"),
+		},
+		{
+			name: "negative amount",
+			uri:  "mdl:" + addr.String() + "?amount=-1",
+			err:  errors.New("Invalid URI amount: Droplet string conversion failed: Negative balance"),
+		},
+		{
+			name: "zero amount",
+			uri:  "mdl:" + addr.String() + "?amount=0",
+			err:  ErrZeroAmount,
+		},
+		{
+			name: "amount with too many decimals for droplets",
+			uri:  "mdl:" + addr.String() + "?amount=1.0000001",
+			err:  errors.New("Invalid URI amount: Droplet string conversion failed: Too many decimal places"),
+		},
+		{
+			name: "amount with too many decimals for the precision rules",
+			uri:  "mdl:" + addr.String() + "?amount=1.0001",
+			err:  errors.New("Invalid URI amount: invalid amount, too many decimal places"),
+		},
+		{
+			name: "invalid hours",
+			uri:  "mdl:" + addr.String() + "?hours=1.5",
+			err:  errors.New(`Invalid URI hours: strconv.ParseUint: parsing "1.5": invalid syntax`),
+		},
+		{
+			name: "duplicate parameter",
+			uri:  "mdl:" + addr.String() + "?label=a&label=b",
+			err:  errors.New(`URI parameter "label" is duplicated`),
+		},
+		{
+			name: "invalid escape",
+			uri:  "mdl:" + addr.String() + "?label=%zz",
+			err:  errors.New(`Invalid URI parameters: invalid URL escape "%zz"`),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := Parse(tc.uri)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, u)
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	addr := cipher.MustDecodeBase58Address("2f4Ar19ENNj8NBDVW7NuVkMwUQHCaqCXPD9")
+
+	cases := []struct {
+		name string
+		uri  URI
+		exp  string
+	}{
+		{
+			name: "address",
+			uri:  URI{Address: addr},
+			exp:  "mdl:2f4Ar19ENNj8NBDVW7NuVkMwUQHCaqCXPD9",
+		},
+		{
+			name: "all parameters",
+			uri: URI{
+				Address: addr,
+				Coins:   12500000,
+				Hours:   uint64Ptr(0),
+				Label:   "Luke Jr",
+				Message: "Donation for project xyz & co",
+			},
+			exp: "mdl:2f4Ar19ENNj8NBDVW7NuVkMwUQHCaqCXPD9?amount=12.5&hours=0&label=Luke%20Jr&message=Donation%20for%20project%20xyz%20%26%20co",
+		},
+		{
+			name: "whole coins",
+			uri: URI{
+				Address: addr,
+				Coins:   3000000,
+			},
+			exp: "mdl:2f4Ar19ENNj8NBDVW7NuVkMwUQHCaqCXPD9?amount=3",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.uri.String()
+			require.Equal(t, tc.exp, s)
+
+			// The URI is parsed back to the payment request
+			u, err := Parse(s)
+			require.NoError(t, err)
+			require.Equal(t, tc.uri, *u)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	addr := testutil.MakeAddress()
+
+	require.NoError(t, URI{Address: addr}.Validate())
+	require.NoError(t, URI{Address: addr, Coins: 1000}.Validate())
+	require.Equal(t, ErrMissingAddress, URI{Coins: 1000}.Validate())
+	require.Equal(t, errors.New("Invalid URI amount: invalid amount, too many decimal places"), URI{Address: addr, Coins: 1}.Validate())
+	require.Equal(t, errors.New("Invalid URI amount: Droplet string conversion failed: Value is too large"), URI{Address: addr, Coins: 1 << 63}.Validate())
+}
+
+func TestIsURI(t *testing.T) {
+	require.True(t, IsURI("mdl:foo"))
+	require.True(t, IsURI("Mdl:foo"))
+	require.False(t, IsURI("mdl"))
+	require.False(t, IsURI("2f4Ar19ENNj8NBDVW7NuVkMwUQHCaqCXPD9"))
+	require.False(t, IsURI("bitcoin:foo"))
+}
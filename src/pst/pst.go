@@ -0,0 +1,540 @@
+/*
+Package pst implements partially-signed transactions, a portable container for offline signing.
+
+A partially-signed transaction holds an unsigned or partially signed transaction together with
+the unspent outputs spent by its inputs, so that an offline signer can check the coins and hours
+being spent, the owning addresses and the fee without querying a node.
+
+The unspent output bodies (source transaction, address, coins and hours) are authenticated by
+the transaction itself, since each input of a transaction is the hash of the body of the output it spends.
+The block time of each output and the head time are not authenticated, they are only used to
+calculate the coin hours earned by the inputs and thus the fee.
+
+The signatures made separately by several signers on copies of the same partially-signed
+transaction are merged with Combine. A fully signed transaction is extracted with Finalize.
+*/
+package pst
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/util/droplet"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/util/mathutil"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// Version is the version of the partially-signed transaction format
+const Version = 1
+
+// Error wraps errors of invalid partially-signed transactions.
+// It wraps errors caused by user input, but not errors caused by programmer input or internal issues.
+type Error struct {
+	error
+}
+
+// NewError creates an Error
+func NewError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return Error{err}
+}
+
+var (
+	// ErrNotFullySigned is returned when finalizing a transaction with unsigned inputs
+	ErrNotFullySigned = NewError(errors.New("Transaction is not fully signed"))
+	// ErrFullySigned is returned when signing a fully signed transaction
+	ErrFullySigned = NewError(errors.New("Transaction is already fully signed"))
+	// ErrTransactionMismatch is returned when combining partially-signed transactions of different transactions
+	ErrTransactionMismatch = NewError(errors.New("Partially-signed transactions are not for the same transaction"))
+	// ErrNothingToCombine is returned when combining no partially-signed transaction
+	ErrNothingToCombine = NewError(errors.New("No partially-signed transaction to combine"))
+	// ErrNoInputsToSign is returned when signing with a wallet which owns none of the unsigned inputs
+	ErrNoInputsToSign = NewError(errors.New("Wallet owns none of the unsigned inputs"))
+)
+
+// Input is a transaction input with the unspent output it spends
+type Input struct {
+	UxOut coin.UxOut
+	// CalculatedHours is the number of coin hours of the output at the head time
+	CalculatedHours uint64
+	// Path is the bip44 derivation path of the key of the owning address, if it is known
+	Path string
+}
+
+// PST is a partially-signed transaction
+type PST struct {
+	Transaction coin.Transaction
+	Inputs      []Input
+	// HeadTime is the time of the head block of the blockchain when the transaction was created,
+	// used to calculate the coin hours of the inputs
+	HeadTime uint64
+}
+
+// New creates a partially-signed transaction from an unsigned or partially signed transaction,
+// the unspent outputs spent by its inputs, in the same order, and the head time used to calculate the
+// coin hours of the inputs
+func New(txn coin.Transaction, uxOuts []coin.UxOut, headTime uint64) (*PST, error) {
+	if len(uxOuts) != len(txn.In) {
+		return nil, errors.New("len(uxOuts) != len(txn.In)")
+	}
+
+	if len(txn.Sigs) == 0 {
+		txn.Sigs = make([]cipher.Sig, len(txn.In))
+	}
+
+	inputs := make([]Input, len(uxOuts))
+	for i, ux := range uxOuts {
+		hours, err := ux.CoinHours(headTime)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs[i] = Input{
+			UxOut:           ux,
+			CalculatedHours: hours,
+		}
+	}
+
+	p := &PST{
+		Transaction: copyTransaction(txn),
+		Inputs:      inputs,
+		HeadTime:    headTime,
+	}
+
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// UxOuts returns the unspent outputs spent by the inputs of the transaction
+func (p *PST) UxOuts() coin.UxArray {
+	uxOuts := make(coin.UxArray, len(p.Inputs))
+	for i, in := range p.Inputs {
+		uxOuts[i] = in.UxOut
+	}
+	return uxOuts
+}
+
+// IsFullySigned returns true if every input of the transaction is signed
+func (p *PST) IsFullySigned() bool {
+	return p.Transaction.IsFullySigned()
+}
+
+// Fee returns the coin hours fee of the transaction, the coin hours of the inputs at the head time
+// less the coin hours of the outputs
+func (p *PST) Fee() (uint64, error) {
+	var hoursIn uint64
+	for _, in := range p.Inputs {
+		var err error
+		hoursIn, err = mathutil.AddUint64(hoursIn, in.CalculatedHours)
+		if err != nil {
+			return 0, errors.New("Transaction input hours overflow")
+		}
+	}
+
+	hoursOut, err := p.Transaction.OutputHours()
+	if err != nil {
+		return 0, err
+	}
+
+	if hoursIn < hoursOut {
+		return 0, fee.ErrTxnInsufficientCoinHours
+	}
+
+	return hoursIn - hoursOut, nil
+}
+
+// Verify checks that the partially-signed transaction is consistent and that its transaction is valid,
+// without access to the blockchain: the transaction is well formed, the unspent outputs are the outputs spent
+// by the inputs, the coins are not created or destroyed, the fee is sufficient and the signatures of the
+// signed inputs are valid
+func (p *PST) Verify() error {
+	return NewError(p.verify())
+}
+
+func (p *PST) verify() error {
+	txn := &p.Transaction
+
+	if len(p.Inputs) != len(txn.In) {
+		return errors.New("Number of inputs does not match the transaction inputs")
+	}
+
+	uxOuts := p.UxOuts()
+	for i, in := range p.Inputs {
+		if txn.In[i] != in.UxOut.Hash() {
+			return fmt.Errorf("Unspent output of input %d does not match the transaction input", i)
+		}
+
+		hours, err := in.UxOut.CoinHours(p.HeadTime)
+		if err != nil {
+			return err
+		}
+
+		if hours != in.CalculatedHours {
+			return fmt.Errorf("Calculated hours of input %d do not match its unspent output", i)
+		}
+	}
+
+	if txn.IsFullySigned() {
+		if err := txn.Verify(); err != nil {
+			return err
+		}
+	} else if err := txn.VerifyUnsigned(); err != nil {
+		return err
+	}
+
+	if err := txn.VerifyPartialInputSignatures(uxOuts); err != nil {
+		return err
+	}
+
+	if err := coin.VerifyTransactionCoinsSpending(uxOuts, coin.CreateUnspents(coin.BlockHeader{}, *txn)); err != nil {
+		return err
+	}
+
+	f, err := p.Fee()
+	if err != nil {
+		return err
+	}
+
+	return fee.VerifyTransactionFee(txn, f, params.UserVerifyTxn.BurnFactor)
+}
+
+// SetPaths sets the bip44 derivation path of the inputs owned by the addresses of a bip44 wallet
+func (p *PST) SetPaths(w *wallet.Wallet) {
+	if w.Type() != wallet.WalletTypeBip44 {
+		return
+	}
+
+	for i, in := range p.Inputs {
+		e, ok := w.GetEntry(in.UxOut.Body.Address)
+		if !ok {
+			continue
+		}
+
+		p.Inputs[i].Path = fmt.Sprintf("m/44'/%d'/%d'/%d/%d", w.Bip44Coin(), w.Bip44Account(), e.Change, e.ChildNumber)
+	}
+}
+
+// Sign signs the inputs of the transaction owned by the wallet with the unspent outputs of the
+// partially-signed transaction. Specific inputs may be signed by specifying signIndexes,
+// if signIndexes is empty, all the unsigned inputs owned by the wallet are signed.
+// The wallet must be unencrypted or unlocked.
+func (p *PST) Sign(w *wallet.Wallet, signIndexes []int) error {
+	if p.IsFullySigned() {
+		return ErrFullySigned
+	}
+
+	if err := p.Verify(); err != nil {
+		return err
+	}
+
+	if len(signIndexes) == 0 {
+		for i, in := range p.Inputs {
+			if p.Transaction.Sigs[i].Null() && w.HasEntry(in.UxOut.Body.Address) {
+				signIndexes = append(signIndexes, i)
+			}
+		}
+
+		if len(signIndexes) == 0 {
+			return ErrNoInputsToSign
+		}
+	}
+
+	signedTxn, err := w.SignTransaction(&p.Transaction, signIndexes, p.UxOuts())
+	if err != nil {
+		return err
+	}
+
+	if err := signedTxn.VerifyPartialInputSignatures(p.UxOuts()); err != nil {
+		return NewError(err)
+	}
+
+	p.Transaction = *signedTxn
+	return nil
+}
+
+// Combine merges the signatures of partially-signed transactions of the same transaction,
+// signed separately by different signers
+func Combine(psts ...*PST) (*PST, error) {
+	if len(psts) == 0 {
+		return nil, ErrNothingToCombine
+	}
+
+	for i, p := range psts {
+		if err := p.Verify(); err != nil {
+			return nil, NewError(fmt.Errorf("Partially-signed transaction %d is invalid: %v", i, err))
+		}
+	}
+
+	c := psts[0].clone()
+
+	for _, p := range psts[1:] {
+		if p.Transaction.InnerHash != c.Transaction.InnerHash || p.HeadTime != c.HeadTime {
+			return nil, ErrTransactionMismatch
+		}
+
+		for i, in := range p.Inputs {
+			if in.UxOut != c.Inputs[i].UxOut {
+				return nil, ErrTransactionMismatch
+			}
+
+			if c.Inputs[i].Path == "" {
+				c.Inputs[i].Path = in.Path
+			}
+
+			// Signatures are checked by Verify, the signatures of an input made by different signers may differ
+			if sig := p.Transaction.Sigs[i]; !sig.Null() && c.Transaction.Sigs[i].Null() {
+				c.Transaction.Sigs[i] = sig
+			}
+		}
+	}
+
+	if err := c.Verify(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Finalize checks that the transaction is fully signed and valid and returns it, ready to be broadcast
+func (p *PST) Finalize() (*coin.Transaction, error) {
+	if !p.IsFullySigned() {
+		return nil, ErrNotFullySigned
+	}
+
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Transaction.VerifyInputSignatures(p.UxOuts()); err != nil {
+		return nil, NewError(err)
+	}
+
+	txn := copyTransaction(p.Transaction)
+	return &txn, nil
+}
+
+func (p *PST) clone() *PST {
+	c := &PST{
+		Transaction: copyTransaction(p.Transaction),
+		Inputs:      make([]Input, len(p.Inputs)),
+		HeadTime:    p.HeadTime,
+	}
+	copy(c.Inputs, p.Inputs)
+	return c
+}
+
+// copyTransaction returns a copy of a transaction which doesn't share its slices
+func copyTransaction(txn coin.Transaction) coin.Transaction {
+	txn.Sigs = append([]cipher.Sig{}, txn.Sigs...)
+	txn.In = append([]cipher.SHA256{}, txn.In...)
+	txn.Out = append([]coin.TransactionOutput{}, txn.Out...)
+	return txn
+}
+
+// jsonPST is the portable JSON encoding of a PST.
+// The transaction is serialized in hex, the inputs and outputs are repeated in readable form
+// for inspection and must match the transaction.
+type jsonPST struct {
+	Version     int          `json:"version"`
+	Transaction string       `json:"transaction"`
+	InnerHash   string       `json:"inner_hash"`
+	HeadTime    uint64       `json:"head_time"`
+	Fee         uint64       `json:"fee"`
+	Inputs      []jsonInput  `json:"inputs"`
+	Outputs     []jsonOutput `json:"outputs"`
+}
+
+type jsonInput struct {
+	UxID            string `json:"uxid"`
+	Address         string `json:"address"`
+	Coins           string `json:"coins"`
+	Hours           uint64 `json:"hours"`
+	CalculatedHours uint64 `json:"calculated_hours"`
+	BlockTime       uint64 `json:"block_time"`
+	BlockSeq        uint64 `json:"block_seq"`
+	SrcTransaction  string `json:"src_txn"`
+	Path            string `json:"path,omitempty"`
+	Signed          bool   `json:"signed"`
+}
+
+type jsonOutput struct {
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   uint64 `json:"hours"`
+}
+
+// MarshalJSON encodes the partially-signed transaction in its portable JSON format
+func (p PST) MarshalJSON() ([]byte, error) {
+	txnHex, err := p.Transaction.SerializeHex()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := p.Fee()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Transaction.Sigs) != len(p.Inputs) {
+		return nil, errors.New("Number of signatures does not match the inputs")
+	}
+
+	inputs := make([]jsonInput, len(p.Inputs))
+	for i, in := range p.Inputs {
+		coins, err := droplet.ToString(in.UxOut.Body.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs[i] = jsonInput{
+			UxID:            in.UxOut.Hash().Hex(),
+			Address:         in.UxOut.Body.Address.String(),
+			Coins:           coins,
+			Hours:           in.UxOut.Body.Hours,
+			CalculatedHours: in.CalculatedHours,
+			BlockTime:       in.UxOut.Head.Time,
+			BlockSeq:        in.UxOut.Head.BkSeq,
+			SrcTransaction:  in.UxOut.Body.SrcTransaction.Hex(),
+			Path:            in.Path,
+			Signed:          !p.Transaction.Sigs[i].Null(),
+		}
+	}
+
+	outputs := make([]jsonOutput, len(p.Transaction.Out))
+	for i, o := range p.Transaction.Out {
+		coins, err := droplet.ToString(o.Coins)
+		if err != nil {
+			return nil, err
+		}
+
+		outputs[i] = jsonOutput{
+			Address: o.Address.String(),
+			Coins:   coins,
+			Hours:   o.Hours,
+		}
+	}
+
+	return json.Marshal(jsonPST{
+		Version:     Version,
+		Transaction: txnHex,
+		InnerHash:   p.Transaction.InnerHash.Hex(),
+		HeadTime:    p.HeadTime,
+		Fee:         f,
+		Inputs:      inputs,
+		Outputs:     outputs,
+	})
+}
+
+// UnmarshalJSON decodes a partially-signed transaction from its portable JSON format.
+// The readable inputs and outputs must match the transaction.
+func (p *PST) UnmarshalJSON(b []byte) error {
+	var j jsonPST
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	if j.Version != Version {
+		return fmt.Errorf("Unsupported partially-signed transaction version %d", j.Version)
+	}
+
+	txn, err := coin.DeserializeTransactionHex(j.Transaction)
+	if err != nil {
+		return fmt.Errorf("Invalid transaction: %v", err)
+	}
+
+	if j.InnerHash != txn.InnerHash.Hex() {
+		return errors.New("inner_hash does not match the transaction")
+	}
+
+	if len(j.Inputs) != len(txn.In) || len(txn.Sigs) != len(txn.In) {
+		return errors.New("inputs do not match the transaction inputs")
+	}
+
+	inputs := make([]Input, len(j.Inputs))
+	for i, in := range j.Inputs {
+		addr, err := cipher.DecodeBase58Address(in.Address)
+		if err != nil {
+			return fmt.Errorf("Invalid address of input %d: %v", i, err)
+		}
+
+		coins, err := droplet.FromString(in.Coins)
+		if err != nil {
+			return fmt.Errorf("Invalid coins of input %d: %v", i, err)
+		}
+
+		srcTxn, err := cipher.SHA256FromHex(in.SrcTransaction)
+		if err != nil {
+			return fmt.Errorf("Invalid src_txn of input %d: %v", i, err)
+		}
+
+		ux := coin.UxOut{
+			Head: coin.UxHead{
+				Time:  in.BlockTime,
+				BkSeq: in.BlockSeq,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: srcTxn,
+				Address:        addr,
+				Coins:          coins,
+				Hours:          in.Hours,
+			},
+		}
+
+		if ux.Hash().Hex() != in.UxID || txn.In[i] != ux.Hash() {
+			return fmt.Errorf("Input %d does not match the transaction input", i)
+		}
+
+		if in.Signed == txn.Sigs[i].Null() {
+			return fmt.Errorf("signed of input %d does not match the transaction signature", i)
+		}
+
+		inputs[i] = Input{
+			UxOut:           ux,
+			CalculatedHours: in.CalculatedHours,
+			Path:            in.Path,
+		}
+	}
+
+	if len(j.Outputs) != len(txn.Out) {
+		return errors.New("outputs do not match the transaction outputs")
+	}
+
+	for i, o := range j.Outputs {
+		coins, err := droplet.FromString(o.Coins)
+		if err != nil {
+			return fmt.Errorf("Invalid coins of output %d: %v", i, err)
+		}
+
+		if o.Address != txn.Out[i].Address.String() || coins != txn.Out[i].Coins || o.Hours != txn.Out[i].Hours {
+			return fmt.Errorf("Output %d does not match the transaction output", i)
+		}
+	}
+
+	d := PST{
+		Transaction: txn,
+		Inputs:      inputs,
+		HeadTime:    j.HeadTime,
+	}
+
+	f, err := d.Fee()
+	if err != nil {
+		return err
+	}
+
+	if f != j.Fee {
+		return errors.New("fee does not match the transaction")
+	}
+
+	*p = d
+	return nil
+}
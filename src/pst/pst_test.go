@@ -0,0 +1,282 @@
+package pst
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+const testHeadTime = 3700
+
+// makeUnsignedTransaction creates an unsigned transaction spending one output of each secret key
+func makeUnsignedTransaction(t *testing.T, keys []cipher.SecKey) (coin.Transaction, []coin.UxOut) {
+	var txn coin.Transaction
+	uxOuts := make([]coin.UxOut, len(keys))
+	var coins uint64
+	for i, k := range keys {
+		uxOuts[i] = coin.UxOut{
+			Head: coin.UxHead{
+				Time:  100,
+				BkSeq: 2,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: testutil.RandSHA256(t),
+				Address:        cipher.MustAddressFromSecKey(k),
+				Coins:          2e6,
+				Hours:          100,
+			},
+		}
+		coins += uxOuts[i].Body.Coins
+		require.NoError(t, txn.PushInput(uxOuts[i].Hash()))
+	}
+
+	hours := 25 * uint64(len(keys))
+	require.NoError(t, txn.PushOutput(testutil.MakeAddress(), coins-1e6, hours))
+	require.NoError(t, txn.PushOutput(uxOuts[0].Body.Address, 1e6, hours))
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	require.NoError(t, txn.UpdateHeader())
+
+	return txn, uxOuts
+}
+
+func makeCollectionWallet(t *testing.T, keys ...cipher.SecKey) *wallet.Wallet {
+	w, err := wallet.NewWallet("t.wlt", wallet.Options{
+		Type: wallet.WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	for _, k := range keys {
+		_, err := w.ImportSecretKey(k)
+		require.NoError(t, err)
+	}
+
+	return w
+}
+
+func TestNew(t *testing.T) {
+	_, s1 := cipher.GenerateKeyPair()
+	_, s2 := cipher.GenerateKeyPair()
+	txn, uxOuts := makeUnsignedTransaction(t, []cipher.SecKey{s1, s2})
+
+	p, err := New(txn, uxOuts, testHeadTime)
+	require.NoError(t, err)
+	require.False(t, p.IsFullySigned())
+	require.Equal(t, coin.UxArray(uxOuts), p.UxOuts())
+	for _, in := range p.Inputs {
+		// 100 hours and 2 coins for one hour
+		require.Equal(t, uint64(102), in.CalculatedHours)
+	}
+
+	f, err := p.Fee()
+	require.NoError(t, err)
+	require.Equal(t, uint64(204-100), f)
+
+	// The unspent outputs must be the outputs spent by the inputs
+	_, err = New(txn, []coin.UxOut{uxOuts[1], uxOuts[0]}, testHeadTime)
+	require.Equal(t, NewError(errors.New("Unspent output of input 0 does not match the transaction input")), err)
+
+	// The coins can't be created
+	badUxOuts := append([]coin.UxOut{}, uxOuts...)
+	badUxOuts[1].Body.Coins = 1e6
+	badTxn := txn
+	badTxn.In = []cipher.SHA256{uxOuts[0].Hash(), badUxOuts[1].Hash()}
+	require.NoError(t, badTxn.UpdateHeader())
+	_, err = New(badTxn, badUxOuts, testHeadTime)
+	require.Equal(t, NewError(errors.New("Insufficient coins")), err)
+
+	// The fee must be sufficient
+	noFeeTxn := txn
+	noFeeTxn.Out = append([]coin.TransactionOutput{}, txn.Out...)
+	noFeeTxn.Out[0].Hours = 154
+	require.NoError(t, noFeeTxn.UpdateHeader())
+	_, err = New(noFeeTxn, uxOuts, testHeadTime)
+	require.Equal(t, NewError(fee.ErrTxnNoFee), err)
+}
+
+func TestSignCombineFinalize(t *testing.T) {
+	_, s1 := cipher.GenerateKeyPair()
+	_, s2 := cipher.GenerateKeyPair()
+	txn, uxOuts := makeUnsignedTransaction(t, []cipher.SecKey{s1, s2})
+
+	p, err := New(txn, uxOuts, testHeadTime)
+	require.NoError(t, err)
+
+	_, err = p.Finalize()
+	require.Equal(t, ErrNotFullySigned, err)
+
+	// Each signer signs a copy of the partially-signed transaction
+	p1 := p.clone()
+	require.NoError(t, p1.Sign(makeCollectionWallet(t, s1), nil))
+	require.False(t, p1.Transaction.Sigs[0].Null())
+	require.True(t, p1.Transaction.Sigs[1].Null())
+
+	p2 := p.clone()
+	require.NoError(t, p2.Sign(makeCollectionWallet(t, s2), nil))
+	require.True(t, p2.Transaction.Sigs[0].Null())
+	require.False(t, p2.Transaction.Sigs[1].Null())
+
+	// The original is not modified by signing its copies
+	require.True(t, p.Transaction.IsFullyUnsigned())
+
+	// A wallet owning none of the unsigned inputs can't sign
+	require.Equal(t, ErrNoInputsToSign, p1.Sign(makeCollectionWallet(t, s1), nil))
+
+	c, err := Combine(p, p1, p2)
+	require.NoError(t, err)
+	require.True(t, c.IsFullySigned())
+	require.Equal(t, p1.Transaction.Sigs[0], c.Transaction.Sigs[0])
+	require.Equal(t, p2.Transaction.Sigs[1], c.Transaction.Sigs[1])
+
+	require.Equal(t, ErrFullySigned, c.Sign(makeCollectionWallet(t, s1), nil))
+
+	signedTxn, err := c.Finalize()
+	require.NoError(t, err)
+	require.NoError(t, signedTxn.Verify())
+	require.NoError(t, signedTxn.VerifyInputSignatures(uxOuts))
+	require.Equal(t, txn.InnerHash, signedTxn.InnerHash)
+
+	// Partially-signed transactions of another transaction can't be combined
+	_, s3 := cipher.GenerateKeyPair()
+	otherTxn, otherUxOuts := makeUnsignedTransaction(t, []cipher.SecKey{s3})
+	o, err := New(otherTxn, otherUxOuts, testHeadTime)
+	require.NoError(t, err)
+	_, err = Combine(p1, o)
+	require.Equal(t, ErrTransactionMismatch, err)
+
+	_, err = Combine()
+	require.Equal(t, ErrNothingToCombine, err)
+
+	// An invalid signature is refused
+	bad := p1.clone()
+	bad.Transaction.Sigs[1] = p1.Transaction.Sigs[0]
+	_, err = Combine(p1, bad)
+	require.Equal(t, NewError(errors.New("Partially-signed transaction 1 is invalid: Signature not valid for output being spent")), err)
+}
+
+func TestSetPaths(t *testing.T) {
+	w, err := wallet.NewWallet("bip44.wlt", wallet.Options{
+		Type:      wallet.WalletTypeBip44,
+		Seed:      bip39.MustNewDefaultMnemonic(),
+		GenerateN: 2,
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateChangeAddresses(1)
+	require.NoError(t, err)
+
+	txn, uxOuts := makeUnsignedTransaction(t, []cipher.SecKey{w.Entries[1].Secret, w.ChangeEntries()[0].Secret})
+	p, err := New(txn, uxOuts, testHeadTime)
+	require.NoError(t, err)
+
+	p.SetPaths(w)
+	require.Equal(t, "m/44'/8000'/0'/0/1", p.Inputs[0].Path)
+	require.Equal(t, "m/44'/8000'/0'/1/0", p.Inputs[1].Path)
+
+	require.NoError(t, p.Sign(w, nil))
+	require.True(t, p.IsFullySigned())
+}
+
+func TestJSON(t *testing.T) {
+	_, s1 := cipher.GenerateKeyPair()
+	_, s2 := cipher.GenerateKeyPair()
+	txn, uxOuts := makeUnsignedTransaction(t, []cipher.SecKey{s1, s2})
+
+	p, err := New(txn, uxOuts, testHeadTime)
+	require.NoError(t, err)
+	require.NoError(t, p.Sign(makeCollectionWallet(t, s1), nil))
+	p.Inputs[0].Path = "m/44'/8000'/0'/0/1"
+
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	var d PST
+	require.NoError(t, json.Unmarshal(b, &d))
+	require.Equal(t, *p, d)
+	require.NoError(t, d.Verify())
+
+	var j map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &j))
+	require.Equal(t, float64(Version), j["version"])
+	require.Equal(t, float64(104), j["fee"])
+	inputs := j["inputs"].([]interface{})
+	require.Len(t, inputs, 2)
+	in := inputs[0].(map[string]interface{})
+	require.Equal(t, uxOuts[0].Body.Address.String(), in["address"])
+	require.Equal(t, "2.000000", in["coins"])
+	require.Equal(t, float64(102), in["calculated_hours"])
+	require.Equal(t, true, in["signed"])
+	require.Equal(t, false, inputs[1].(map[string]interface{})["signed"])
+
+	// The readable inputs and outputs must match the transaction
+	tt := []struct {
+		name   string
+		modify func(j map[string]interface{})
+		err    string
+	}{
+		{
+			name: "input coins",
+			modify: func(j map[string]interface{}) {
+				j["inputs"].([]interface{})[1].(map[string]interface{})["coins"] = "20.000000"
+			},
+			err: "Input 1 does not match the transaction input",
+		},
+		{
+			name: "input address",
+			modify: func(j map[string]interface{}) {
+				j["inputs"].([]interface{})[0].(map[string]interface{})["address"] = testutil.MakeAddress().String()
+			},
+			err: "Input 0 does not match the transaction input",
+		},
+		{
+			name: "output hours",
+			modify: func(j map[string]interface{}) {
+				j["outputs"].([]interface{})[0].(map[string]interface{})["hours"] = 10
+			},
+			err: "Output 0 does not match the transaction output",
+		},
+		{
+			name: "signed",
+			modify: func(j map[string]interface{}) {
+				j["inputs"].([]interface{})[1].(map[string]interface{})["signed"] = true
+			},
+			err: "signed of input 1 does not match the transaction signature",
+		},
+		{
+			name: "fee",
+			modify: func(j map[string]interface{}) {
+				j["fee"] = 1
+			},
+			err: "fee does not match the transaction",
+		},
+		{
+			name: "version",
+			modify: func(j map[string]interface{}) {
+				j["version"] = 2
+			},
+			err: "Unsupported partially-signed transaction version 2",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var j map[string]interface{}
+			require.NoError(t, json.Unmarshal(b, &j))
+			tc.modify(j)
+			mb, err := json.Marshal(j)
+			require.NoError(t, err)
+
+			var d PST
+			err = json.Unmarshal(mb, &d)
+			require.Error(t, err)
+			require.Equal(t, tc.err, err.Error())
+		})
+	}
+}
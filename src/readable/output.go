@@ -26,6 +26,8 @@ type UnspentOutput struct {
 	Coins             string `json:"coins"`
 	Hours             uint64 `json:"hours"`
 	CalculatedHours   uint64 `json:"calculated_hours"`
+	// Frozen is true if the output is frozen in a wallet, it is only set by the node's /outputs API
+	Frozen bool `json:"frozen,omitempty"`
 }
 
 // NewUnspentOutput creates a readable output
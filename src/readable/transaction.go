@@ -193,6 +193,32 @@ func NewUnconfirmedTransactions(txns []visor.UnconfirmedTransaction) ([]Unconfir
 	return rut, nil
 }
 
+// EvictedTransaction represents a transaction evicted from the unconfirmed pool
+type EvictedTransaction struct {
+	Hash    string    `json:"txid"`
+	Reason  string    `json:"reason"`
+	Evicted time.Time `json:"evicted"`
+	Added   time.Time `json:"added"`
+	Size    uint32    `json:"size"`
+	Fee     uint64    `json:"fee"`
+}
+
+// NewEvictedTransactions converts []visor.EvictedTransaction to []EvictedTransaction
+func NewEvictedTransactions(evicted []visor.EvictedTransaction) []EvictedTransaction {
+	ret := make([]EvictedTransaction, len(evicted))
+	for i, e := range evicted {
+		ret[i] = EvictedTransaction{
+			Hash:    e.Hash.Hex(),
+			Reason:  string(e.Reason),
+			Evicted: timeutil.NanoToTime(e.Evicted),
+			Added:   timeutil.NanoToTime(e.Added),
+			Size:    e.Size,
+			Fee:     e.Fee,
+		}
+	}
+	return ret
+}
+
 // TransactionWithStatus represents transaction result
 type TransactionWithStatus struct {
 	Status      TransactionStatus `json:"status"`
@@ -1,6 +1,12 @@
 package readable
 
-import "github.com/MDLlife/MDL/src/wallet"
+import (
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/util/droplet"
+	"github.com/MDLlife/MDL/src/wallet"
+)
 
 // Balance has coins and hours
 type Balance struct {
@@ -44,8 +50,12 @@ func NewAddressBalances(wab wallet.AddressBalances) AddressBalances {
 
 // WalletEntry the wallet entry struct
 type WalletEntry struct {
-	Address string `json:"address"`
-	Public  string `json:"public_key"`
+	Address     string            `json:"address"`
+	Public      string            `json:"public_key"`
+	ChildNumber *uint32           `json:"child_number,omitempty"` // For bip44 wallets
+	Change      *uint32           `json:"change,omitempty"`       // For bip44 wallets
+	Label       string            `json:"label,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
 }
 
 // WalletMeta the wallet meta struct
@@ -58,4 +68,92 @@ type WalletMeta struct {
 	CryptoType string `json:"crypto_type"`
 	Timestamp  int64  `json:"timestamp"`
 	Encrypted  bool   `json:"encrypted"`
+
+	Bip44Coin    *uint32 `json:"bip44_coin,omitempty"`    // For bip44 wallets
+	Bip44Account *uint32 `json:"bip44_account,omitempty"` // For bip44 wallets
+	XPub         string  `json:"xpub,omitempty"`          // For bip44 wallets
+
+	Signer string `json:"signer,omitempty"` // For remote wallets
+
+	Argon2Time   uint32 `json:"argon2_time,omitempty"`   // For wallets encrypted with argon2id-chacha20poly1305
+	Argon2Memory uint32 `json:"argon2_memory,omitempty"` // For wallets encrypted with argon2id-chacha20poly1305
+}
+
+// SpendingPolicy is the spending policy of a wallet, coin amounts are decimal strings.
+// Empty fields disable a rule.
+type SpendingPolicy struct {
+	MaxCoinsPerTransaction string   `json:"max_coins_per_transaction,omitempty"`
+	MaxCoinsPerDay         string   `json:"max_coins_per_day,omitempty"`
+	Allowlist              []string `json:"allowlist,omitempty"`
+	MaxOutputs             uint64   `json:"max_outputs,omitempty"`
+	MinChange              string   `json:"min_change,omitempty"`
+}
+
+// NewSpendingPolicy creates a readable spending policy from a wallet.SpendingPolicy
+func NewSpendingPolicy(p wallet.SpendingPolicy) (SpendingPolicy, error) {
+	rp := SpendingPolicy{
+		MaxOutputs: p.MaxOutputs,
+	}
+
+	for _, x := range []struct {
+		droplets uint64
+		coins    *string
+	}{
+		{p.MaxCoinsPerTransaction, &rp.MaxCoinsPerTransaction},
+		{p.MaxCoinsPerDay, &rp.MaxCoinsPerDay},
+		{p.MinChange, &rp.MinChange},
+	} {
+		if x.droplets == 0 {
+			continue
+		}
+
+		coins, err := droplet.ToString(x.droplets)
+		if err != nil {
+			return SpendingPolicy{}, err
+		}
+		*x.coins = coins
+	}
+
+	for _, a := range p.Allowlist {
+		rp.Allowlist = append(rp.Allowlist, a.String())
+	}
+
+	return rp, nil
+}
+
+// ToWalletSpendingPolicy converts the readable spending policy to a wallet.SpendingPolicy
+func (rp SpendingPolicy) ToWalletSpendingPolicy() (wallet.SpendingPolicy, error) {
+	p := wallet.SpendingPolicy{
+		MaxOutputs: rp.MaxOutputs,
+	}
+
+	for _, x := range []struct {
+		name     string
+		coins    string
+		droplets *uint64
+	}{
+		{"max_coins_per_transaction", rp.MaxCoinsPerTransaction, &p.MaxCoinsPerTransaction},
+		{"max_coins_per_day", rp.MaxCoinsPerDay, &p.MaxCoinsPerDay},
+		{"min_change", rp.MinChange, &p.MinChange},
+	} {
+		if x.coins == "" {
+			continue
+		}
+
+		droplets, err := droplet.FromString(x.coins)
+		if err != nil {
+			return wallet.SpendingPolicy{}, fmt.Errorf("invalid %s: %v", x.name, err)
+		}
+		*x.droplets = droplets
+	}
+
+	for _, as := range rp.Allowlist {
+		a, err := cipher.DecodeBase58Address(as)
+		if err != nil {
+			return wallet.SpendingPolicy{}, fmt.Errorf("invalid allowlist address %q: %v", as, err)
+		}
+		p.Allowlist = append(p.Allowlist, a)
+	}
+
+	return p, nil
 }
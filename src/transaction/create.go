@@ -37,6 +37,8 @@ var (
 //     if the coinhour cost of adding that output is less than the coinhours that would be lost as change
 // If receiving hours are not explicitly specified, hours are allocated amongst the receiving outputs proportional to the number of coins being sent to them.
 // If the change address is not specified, the address whose bytes are lexically sorted first is chosen from the owners of the outputs being spent.
+// If Params.Replaceable is true, the coin hours of the change output are reduced to signal replace-by-fee, otherwise they are
+// reduced by one coin hour if they signal it by accident.
 func Create(p Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []UxBalance, error) {
 	return create(p, auxs, headTime, 0)
 }
@@ -108,7 +110,11 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		}
 	}
 
-	feeHours := fee.RequiredFee(totalInputHours, params.UserVerifyTxn.BurnFactor)
+	// The size of the transaction is estimated with a change output
+	feeHours, err := p.requiredFee(totalInputHours, len(txn.In), len(p.To)+1)
+	if err != nil {
+		return nil, nil, err
+	}
 	if feeHours == 0 {
 		// feeHours can only be 0 if totalInputHours is 0, and if totalInputHours was 0
 		// then ChooseSpendsMinimizeUxOuts should have already returned an error
@@ -126,7 +132,7 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		var addrHours []uint64
 
 		switch p.HoursSelection.Mode {
-		case HoursSelectionModeShare:
+		case HoursSelectionModeShare, HoursSelectionModeEstimated:
 			// multiply remaining hours after fee burn with share factor
 			hours, err := mathutil.Uint64ToInt64(remainingHours)
 			if err != nil {
@@ -209,7 +215,10 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 			}
 
 			// Calculate the new fee for this new amount of hours
-			newFee := fee.RequiredFee(newTotalHours, params.UserVerifyTxn.BurnFactor)
+			newFee, err := p.requiredFee(newTotalHours, len(txn.In)+1, len(p.To)+1)
+			if err != nil {
+				return nil, nil, err
+			}
 			if newFee < feeHours {
 				err := errors.New("updated fee after adding extra input for change is unexpectedly less than it was initially")
 				logger.WithError(err).Error()
@@ -223,14 +232,14 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 				logger.Debug("Change hours can be recovered by forcing an extra input")
 				changeCoins = extra.Coins
 
-				if extra.Hours < additionalFee {
+				// With estimated mode, the fee rate of the size of the extra input can be higher than its hours
+				if extra.Hours < additionalFee && p.HoursSelection.Mode != HoursSelectionModeEstimated {
 					err := errors.New("calculated additional fee is unexpectedly higher than the extra input's hours")
 					logger.WithError(err).Error()
 					return nil, nil, err
 				}
 
-				additionalHours := extra.Hours - additionalFee
-				changeHours, err = mathutil.AddUint64(changeHours, additionalHours)
+				changeHours, err = mathutil.AddUint64(changeHours-additionalFee, extra.Hours)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -251,7 +260,8 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 
 	// With auto share mode, if there are leftover hours and change couldn't be force-added,
 	// recalculate that share ratio at 100%
-	if changeCoins == 0 && changeHours > 0 && p.HoursSelection.Type == HoursSelectionTypeAuto && p.HoursSelection.Mode == HoursSelectionModeShare {
+	if changeCoins == 0 && changeHours > 0 && p.HoursSelection.Type == HoursSelectionTypeAuto &&
+		(p.HoursSelection.Mode == HoursSelectionModeShare || p.HoursSelection.Mode == HoursSelectionModeEstimated) {
 		logger.Debug("Recalculating share factor at 1.0 to avoid burning change hours")
 		oneDecimal := decimal.New(1, 0)
 
@@ -273,6 +283,10 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 		return create(p, auxs, headTime, 1)
 	}
 
+	if p.Replaceable && (changeCoins == 0 || changeHours == 0) {
+		return nil, nil, ErrReplaceableNoChangeHours
+	}
+
 	if changeCoins > 0 {
 		var changeAddress cipher.Address
 		if p.ChangeAddress != nil {
@@ -306,6 +320,17 @@ func create(p Params, auxs coin.AddressUxOuts, headTime uint64, callCount int) (
 			logger.Critical().WithError(err).Error("PushOutput failed")
 			return nil, nil, err
 		}
+
+		// The change output is the last output, its coin hours signal replace-by-fee.
+		// If the transaction is not replaceable, one more coin hour is burned so that it doesn't signal by accident.
+		if p.Replaceable {
+			if err := txn.SignalReplaceable(); err != nil {
+				logger.Critical().WithError(err).Error("SignalReplaceable failed")
+				return nil, nil, err
+			}
+		} else if txn.IsReplaceable() {
+			txn.Out[len(txn.Out)-1].Hours--
+		}
 	}
 
 	// Initialize unsigned transaction
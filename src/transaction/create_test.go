@@ -304,6 +304,75 @@ func TestCreate(t *testing.T) {
 			},
 		},
 
+		{
+			name: "manual, 1 output, change, replaceable",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeManual,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Hours:   130,
+						Coins:   2e6 + 1,
+					},
+				},
+				Replaceable: true,
+			},
+			unspents:       uxouts,
+			chosenUnspents: []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			changeOutput: &coin.TransactionOutput{
+				Address: changeAddress,
+				Hours:   1,
+				Coins:   2e6 - 1,
+			},
+		},
+
+		{
+			name: "manual, 1 output, change signalling replace-by-fee by accident",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeManual,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Hours:   179,
+						Coins:   2e6 + 1,
+					},
+				},
+			},
+			unspents:       uxouts,
+			chosenUnspents: []coin.UxOut{originalUxouts[0], originalUxouts[1]},
+			changeOutput: &coin.TransactionOutput{
+				Address: changeAddress,
+				Hours:   0,
+				Coins:   2e6 - 1,
+			},
+		},
+
+		{
+			name: "manual, 1 output, no change, replaceable",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeManual,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Hours:   90,
+						Coins:   2e6,
+					},
+				},
+				Replaceable: true,
+			},
+			unspents: uxouts,
+			err:      ErrReplaceableNoChangeHours,
+		},
+
 		{
 			name: "manual, 1 output, change, unspecified change address",
 			params: Params{
@@ -620,6 +689,55 @@ func TestCreate(t *testing.T) {
 			toExpectedHours: []uint64{55, 108, 108, 1},
 		},
 
+		{
+			name: "auto, single output, estimated fee rate",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeEstimated,
+					ShareFactor: newShareFactor("0.5"),
+					FeeRate:     100,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   1e6,
+					},
+				},
+			},
+			unspents:       uxouts,
+			chosenUnspents: []coin.UxOut{originalUxouts[0]},
+			changeOutput: &coin.TransactionOutput{
+				Address: changeAddress,
+				Hours:   39,
+				Coins:   1e6,
+			},
+			// The fee is 22 coin hours for a size of 220 bytes, instead of 10 coin hours with the burn factor
+			toExpectedHours: []uint64{39},
+		},
+
+		{
+			name: "auto, estimated fee rate higher than the input hours",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeEstimated,
+					ShareFactor: newShareFactor("0.5"),
+					FeeRate:     1e6,
+				},
+				To: []coin.TransactionOutput{
+					{
+						Address: addrs[0],
+						Coins:   1e6,
+					},
+				},
+			},
+			unspents: uxouts,
+			err:      ErrInsufficientHoursEstimatedFee,
+		},
+
 		{
 			name:     "no coin hours in inputs",
 			unspents: uxoutsNoHours[:],
@@ -7,6 +7,9 @@ import (
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/util/mathutil"
 )
 
 // Error wraps transaction creation-related errors.
@@ -31,6 +34,8 @@ const (
 
 	// HoursSelectionModeShare will distribute coin hours equally amongst destinations
 	HoursSelectionModeShare = "share"
+	// HoursSelectionModeEstimated will burn the estimated coin hour fee, and distribute the remaining coin hours like HoursSelectionModeShare
+	HoursSelectionModeEstimated = "estimated"
 )
 
 var (
@@ -54,12 +59,22 @@ var (
 	ErrInvalidHoursSelectionModeManual = NewError(errors.New("HoursSelection.Mode cannot be used for manual type hours selection"))
 	// ErrInvalidHoursSelectionType Invalid HoursSelection.Type
 	ErrInvalidHoursSelectionType = NewError(errors.New("Invalid HoursSelection.Type"))
-	// ErrMissingShareFactor HoursSelection.ShareFactor must be set for share mode
-	ErrMissingShareFactor = NewError(errors.New("HoursSelection.ShareFactor must be set for share mode"))
-	// ErrInvalidShareFactor HoursSelection.ShareFactor can only be used for share mode
-	ErrInvalidShareFactor = NewError(errors.New("HoursSelection.ShareFactor can only be used for share mode"))
+	// ErrMissingShareFactor HoursSelection.ShareFactor must be set for share and estimated modes
+	ErrMissingShareFactor = NewError(errors.New("HoursSelection.ShareFactor must be set for share and estimated modes"))
+	// ErrInvalidShareFactor HoursSelection.ShareFactor can only be used for share and estimated modes
+	ErrInvalidShareFactor = NewError(errors.New("HoursSelection.ShareFactor can only be used for share and estimated modes"))
 	// ErrShareFactorOutOfRange HoursSelection.ShareFactor must be >= 0 and <= 1
 	ErrShareFactorOutOfRange = NewError(errors.New("HoursSelection.ShareFactor must be >= 0 and <= 1"))
+	// ErrInvalidConfidence HoursSelection.Confidence can only be used for estimated mode
+	ErrInvalidConfidence = NewError(errors.New("HoursSelection.Confidence can only be used for estimated mode"))
+	// ErrConfidenceOutOfRange HoursSelection.Confidence must be > 0 and <= 1
+	ErrConfidenceOutOfRange = NewError(errors.New("HoursSelection.Confidence must be > 0 and <= 1"))
+	// ErrInvalidFeeRate HoursSelection.FeeRate can only be used for estimated mode
+	ErrInvalidFeeRate = NewError(errors.New("HoursSelection.FeeRate can only be used for estimated mode"))
+	// ErrInsufficientHoursEstimatedFee the spent outputs don't have enough coin hours for the estimated fee
+	ErrInsufficientHoursEstimatedFee = NewError(errors.New("Insufficient coin hours for the estimated fee"))
+	// ErrReplaceableNoChangeHours a replaceable transaction has no change output with coin hours to signal replace-by-fee
+	ErrReplaceableNoChangeHours = NewError(errors.New("A replaceable transaction needs a change output with coin hours to signal replace-by-fee"))
 )
 
 // HoursSelection defines options for hours distribution
@@ -67,6 +82,12 @@ type HoursSelection struct {
 	Type        string
 	Mode        string
 	ShareFactor *decimal.Decimal
+	// Confidence is the target confidence of the fee estimate for estimated mode.
+	// It is not used by Create, the caller sets FeeRate from it.
+	Confidence *decimal.Decimal
+	// FeeRate is the number of coin hours to burn per kilobyte of the transaction for estimated mode.
+	// The minimum fee of the burn factor is burned if it is higher.
+	FeeRate uint64
 }
 
 // Params defines control parameters for transaction construction
@@ -74,6 +95,9 @@ type Params struct {
 	HoursSelection HoursSelection
 	To             []coin.TransactionOutput
 	ChangeAddress  *cipher.Address
+	// Replaceable if true, the transaction signals that it can be replaced by a conflicting transaction burning
+	// more coin hours, with the coin hours of its change output, see coin.Transaction.IsReplaceable
+	Replaceable bool
 }
 
 // Validate validates Params
@@ -119,7 +143,7 @@ func (c Params) Validate() error {
 		}
 
 		switch c.HoursSelection.Mode {
-		case HoursSelectionModeShare:
+		case HoursSelectionModeShare, HoursSelectionModeEstimated:
 		case "":
 			return ErrMissingHoursSelectionModeAuto
 		default:
@@ -135,12 +159,14 @@ func (c Params) Validate() error {
 		return ErrInvalidHoursSelectionType
 	}
 
+	sharesHours := c.HoursSelection.Mode == HoursSelectionModeShare || c.HoursSelection.Mode == HoursSelectionModeEstimated
+
 	if c.HoursSelection.ShareFactor == nil {
-		if c.HoursSelection.Mode == HoursSelectionModeShare {
+		if sharesHours {
 			return ErrMissingShareFactor
 		}
 	} else {
-		if c.HoursSelection.Mode != HoursSelectionModeShare {
+		if !sharesHours {
 			return ErrInvalidShareFactor
 		}
 
@@ -151,5 +177,66 @@ func (c Params) Validate() error {
 		}
 	}
 
+	if c.HoursSelection.Confidence != nil {
+		if c.HoursSelection.Mode != HoursSelectionModeEstimated {
+			return ErrInvalidConfidence
+		}
+
+		if !c.HoursSelection.Confidence.GreaterThan(decimal.New(0, 0)) || c.HoursSelection.Confidence.GreaterThan(decimal.New(1, 0)) {
+			return ErrConfidenceOutOfRange
+		}
+	}
+
+	if c.HoursSelection.FeeRate != 0 && c.HoursSelection.Mode != HoursSelectionModeEstimated {
+		return ErrInvalidFeeRate
+	}
+
 	return nil
 }
+
+// requiredFee returns the coin hours to burn for a transaction spending inputHours, with nInputs inputs and nOutputs outputs.
+// It is the minimum fee of the burn factor, or for estimated mode, the fee of HoursSelection.FeeRate if it is higher.
+func (c Params) requiredFee(inputHours uint64, nInputs, nOutputs int) (uint64, error) {
+	feeHours := fee.RequiredFee(inputHours, params.UserVerifyTxn.BurnFactor)
+
+	if c.HoursSelection.Mode != HoursSelectionModeEstimated || c.HoursSelection.FeeRate == 0 {
+		return feeHours, nil
+	}
+
+	size, err := EstimateSize(nInputs, nOutputs)
+	if err != nil {
+		return 0, err
+	}
+
+	rateFee, err := mathutil.MultUint64(c.HoursSelection.FeeRate, uint64(size))
+	if err != nil {
+		return 0, ErrInsufficientHoursEstimatedFee
+	}
+
+	// Round up, so that the fee per kilobyte is not lower than the fee rate
+	if rateFee%1024 == 0 {
+		rateFee /= 1024
+	} else {
+		rateFee = rateFee/1024 + 1
+	}
+	if rateFee > feeHours {
+		feeHours = rateFee
+	}
+
+	if feeHours > inputHours {
+		return 0, ErrInsufficientHoursEstimatedFee
+	}
+
+	return feeHours, nil
+}
+
+// EstimateSize returns the size of a signed transaction with nInputs inputs and nOutputs outputs
+func EstimateSize(nInputs, nOutputs int) (uint32, error) {
+	txn := coin.Transaction{
+		Sigs: make([]cipher.Sig, nInputs),
+		In:   make([]cipher.SHA256, nInputs),
+		Out:  make([]coin.TransactionOutput, nOutputs),
+	}
+
+	return txn.Size()
+}
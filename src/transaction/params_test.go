@@ -39,6 +39,7 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 		},
 	}
 
+	zero := decimal.New(0, 0)
 	one := decimal.New(1, 0)
 	negativeOne := decimal.New(-1, 0)
 	onePointOne := decimal.New(11, -1)
@@ -177,7 +178,7 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 					Mode: HoursSelectionModeShare,
 				},
 			},
-			err: "HoursSelection.ShareFactor must be set for share mode",
+			err: "HoursSelection.ShareFactor must be set for share and estimated modes",
 		},
 
 		{
@@ -190,7 +191,7 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 					ShareFactor: &one,
 				},
 			},
-			err: "HoursSelection.ShareFactor can only be used for share mode",
+			err: "HoursSelection.ShareFactor can only be used for share and estimated modes",
 		},
 
 		{
@@ -221,6 +222,79 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 			err: "HoursSelection.ShareFactor must be >= 0 and <= 1",
 		},
 
+		{
+			name: "share factor not set for estimated mode",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type: HoursSelectionTypeAuto,
+					Mode: HoursSelectionModeEstimated,
+				},
+			},
+			err: "HoursSelection.ShareFactor must be set for share and estimated modes",
+		},
+
+		{
+			name: "confidence set but not estimated mode",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeShare,
+					ShareFactor: &one,
+					Confidence:  &one,
+				},
+			},
+			err: "HoursSelection.Confidence can only be used for estimated mode",
+		},
+
+		{
+			name: "confidence 0",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeEstimated,
+					ShareFactor: &one,
+					Confidence:  &zero,
+				},
+			},
+			err: "HoursSelection.Confidence must be > 0 and <= 1",
+		},
+
+		{
+			name: "confidence greater than 1",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeEstimated,
+					ShareFactor: &one,
+					Confidence:  &onePointOne,
+				},
+			},
+			err: "HoursSelection.Confidence must be > 0 and <= 1",
+		},
+
+		{
+			name: "fee rate set but not estimated mode",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeShare,
+					ShareFactor: &one,
+					FeeRate:     10,
+				},
+			},
+			err: "HoursSelection.FeeRate can only be used for estimated mode",
+		},
+
 		{
 			name: "duplicate output when manual",
 			params: Params{
@@ -260,6 +334,21 @@ func TestCreateWalletParamsVerify(t *testing.T) {
 			},
 		},
 
+		{
+			name: "valid auto estimated",
+			params: Params{
+				ChangeAddress: &changeAddress,
+				To:            toAuto,
+				HoursSelection: HoursSelection{
+					Type:        HoursSelectionTypeAuto,
+					Mode:        HoursSelectionModeEstimated,
+					ShareFactor: &pointOneOne,
+					Confidence:  &one,
+					FeeRate:     10,
+				},
+			},
+		},
+
 		{
 			name: "valid manual",
 			params: Params{
@@ -0,0 +1,671 @@
+/*
+Package qrcode implements a QR code encoder (ISO/IEC 18004), so that codes can be rendered without network access or external dependencies.
+
+Data is encoded in byte mode, in the smallest version that fits the data at the error correction level.
+*/
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is the error correction level of a QR code
+type Level int
+
+const (
+	// Low recovers about 7% of the codewords
+	Low Level = iota
+	// Medium recovers about 15% of the codewords
+	Medium
+	// Quartile recovers about 25% of the codewords
+	Quartile
+	// High recovers about 30% of the codewords
+	High
+)
+
+const (
+	// MinVersion is the smallest QR code version
+	MinVersion = 1
+	// MaxVersion is the largest QR code version
+	MaxVersion = 40
+
+	// QuietZone is the width in modules of the light border required around a QR code
+	QuietZone = 4
+)
+
+var (
+	// ErrDataTooLong the data doesn't fit in the largest QR code at the error correction level
+	ErrDataTooLong = errors.New("Data too long for a QR code")
+	// ErrInvalidLevel invalid error correction level
+	ErrInvalidLevel = errors.New("Invalid error correction level")
+)
+
+// Number of error correction codewords per block, indexed by level and version
+var eccCodewordsPerBlock = [4][MaxVersion + 1]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// Number of error correction blocks, indexed by level and version
+var numErrorCorrectionBlocks = [4][MaxVersion + 1]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// formatLevelBits are the bits of the error correction levels in the format information
+var formatLevelBits = [4]int{1, 0, 3, 2}
+
+// Code is an encoded QR code
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	// Size is the width and height in modules
+	Size int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode encodes data in byte mode in the smallest QR code that fits it at the error correction level
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, ErrInvalidLevel
+	}
+
+	version := MinVersion
+	for ; version <= MaxVersion; version++ {
+		if dataBits(version, len(data)) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > MaxVersion {
+		return nil, ErrDataTooLong
+	}
+
+	c := newCode(version, level)
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.addErrorCorrection(c.dataCodewords(data)))
+
+	// Select the mask with the lowest penalty
+	c.Mask = 0
+	minPenalty := -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); minPenalty < 0 || p < minPenalty {
+			c.Mask = mask
+			minPenalty = p
+		}
+		c.applyMask(mask)
+	}
+
+	c.applyMask(c.Mask)
+	c.drawFormatBits(c.Mask)
+
+	return c, nil
+}
+
+// MustEncode encodes data like Encode, panics on error
+func MustEncode(data []byte, level Level) *Code {
+	c, err := Encode(data, level)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17
+	c := &Code{
+		Version:    version,
+		Level:      level,
+		Size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.isFunction[i] = make([]bool, size)
+	}
+
+	return c
+}
+
+// Dark returns whether the module at column x and row y is dark.
+// Modules outside of the code, in the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// SVG renders the QR code as an SVG image with a quiet zone, scale pixels per module
+func (c *Code) SVG(scale int) string {
+	if scale < 1 {
+		scale = 1
+	}
+
+	width := (c.Size + QuietZone*2) * scale
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%[1]d" height="%[1]d" viewBox="0 0 %[2]d %[2]d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%[3]s" fill="#000000"/></svg>`,
+		width, c.Size+QuietZone*2, c.SVGPath())
+}
+
+// SVGPath returns the SVG path data of the dark modules, one unit per module, offset by the quiet zone
+func (c *Code) SVGPath() string {
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	return path.String()
+}
+
+// String renders the QR code as text, two characters per module, for terminals with a dark background
+func (c *Code) String() string {
+	var b strings.Builder
+	for y := -QuietZone; y < c.Size+QuietZone; y++ {
+		for x := -QuietZone; x < c.Size+QuietZone; x++ {
+			if c.Dark(x, y) {
+				b.WriteString("  ")
+			} else {
+				b.WriteString("██")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// dataBits returns the number of bits of n bytes encoded in byte mode
+func dataBits(version, n int) int {
+	countBits := 8
+	if version > 9 {
+		countBits = 16
+	}
+
+	if n >= 1<<uint(countBits) {
+		return 1 << 30
+	}
+
+	return 4 + countBits + n*8
+}
+
+// numRawDataModules returns the number of modules of a version that store data or error correction codewords
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		a := version/7 + 2
+		n -= (25*a-10)*a - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// numDataCodewords returns the number of data codewords of a version and level
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// dataCodewords encodes data in byte mode, with the terminator and padding
+func (c *Code) dataCodewords(data []byte) []byte {
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	if c.Version > 9 {
+		bb.append(len(data), 16)
+	} else {
+		bb.append(len(data), 8)
+	}
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+
+	capacity := numDataCodewords(c.Version, c.Level) * 8
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+
+	for pad := 0xec; len(bb) < capacity; pad ^= 0xec ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	return bb.bytes()
+}
+
+// addErrorCorrection splits the data codewords in blocks, appends the error correction codewords
+// of each block and interleaves the blocks
+func (c *Code) addErrorCorrection(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockEccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := numRawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockEccLen)
+
+	// The short blocks are padded by one byte before their ecc, so that all blocks have the same length
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortBlockLen - blockEccLen
+		if i >= numShortBlocks {
+			n++
+		}
+
+		dat := data[k : k+n]
+		k += n
+
+		block := make([]byte, 0, shortBlockLen+1)
+		block = append(block, dat...)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		block = append(block, reedSolomonRemainder(dat, divisor)...)
+		blocks[i] = block
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i < shortBlockLen+1; i++ {
+		for j, block := range blocks {
+			// Skip the padding byte of the short blocks
+			if i != shortBlockLen-blockEccLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	return result
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns draws the finder, timing, alignment patterns and version information,
+// and reserves the format information modules
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	pos := alignmentPatternPositions(c.Version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			// The corners of the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignmentPattern(pos[i], pos[j])
+		}
+	}
+
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinderPattern draws a finder pattern and its separator, centered at x, y
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			dist := maxInt(absInt(dx), absInt(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern draws an alignment pattern centered at x, y
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions returns the row and column positions of the alignment patterns of a version
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+10; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+
+	return pos
+}
+
+// formatBits returns the 15 bits of the format information of a level and mask
+func formatBits(level Level, mask int) int {
+	data := formatLevelBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits returns the 18 bits of the version information
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	return version<<12 | rem
+}
+
+// drawFormatBits draws both copies of the format information
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(c.Level, mask)
+
+	// Around the top left finder pattern
+	for i := 0; i < 6; i++ {
+		c.setFunction(8, i, getBit(bits, i))
+	}
+	c.setFunction(8, 7, getBit(bits, 6))
+	c.setFunction(8, 8, getBit(bits, 7))
+	c.setFunction(7, 8, getBit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, getBit(bits, i))
+	}
+
+	// Next to the top right and bottom left finder patterns
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, getBit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, getBit(bits, i))
+	}
+
+	// The dark module
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawVersion draws both copies of the version information, of versions 7 and above
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+
+	bits := versionBits(c.Version)
+	for i := 0; i < 18; i++ {
+		a := c.Size - 11 + i%3
+		b := i / 3
+		c.setFunction(a, b, getBit(bits, i))
+		c.setFunction(b, a, getBit(bits, i))
+	}
+}
+
+// drawCodewords draws the codewords in the zigzag order, in column pairs from the bottom right
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		// Skip the vertical timing pattern
+		if right == 6 {
+			right = 5
+		}
+
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+
+				// The remainder bits are left light
+				if !c.isFunction[y][x] && i < len(data)*8 {
+					c.modules[y][x] = getBit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules of the mask pattern. Applying the same mask twice undoes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.isFunction[y][x] && maskBit(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	case 7:
+		return ((x+y)%2+x*y%3)%2 == 0
+	default:
+		panic("invalid mask")
+	}
+}
+
+// penalty scores the modules by the rules of the mask pattern selection. Lower is better.
+func (c *Code) penalty() int {
+	const (
+		n1 = 3
+		n2 = 3
+		n3 = 40
+		n4 = 10
+	)
+
+	penalty := 0
+
+	// Runs of five or more modules of the same color, in rows and columns
+	for i := 0; i < c.Size; i++ {
+		rowRun, colRun := 1, 1
+		for j := 1; j < c.Size; j++ {
+			if c.modules[i][j] == c.modules[i][j-1] {
+				rowRun++
+			} else {
+				rowRun = 1
+			}
+			if rowRun == 5 {
+				penalty += n1
+			} else if rowRun > 5 {
+				penalty++
+			}
+
+			if c.modules[j][i] == c.modules[j-1][i] {
+				colRun++
+			} else {
+				colRun = 1
+			}
+			if colRun == 5 {
+				penalty += n1
+			} else if colRun > 5 {
+				penalty++
+			}
+		}
+	}
+
+	// 2x2 blocks of the same color
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			m := c.modules[y][x]
+			if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+				penalty += n2
+			}
+		}
+	}
+
+	// Finder-like patterns 1:1:3:1:1 with four light modules before or after, in rows and columns
+	finder := [7]bool{true, false, true, true, true, false, true}
+	for i := 0; i < c.Size; i++ {
+		for j := 0; j+7 <= c.Size; j++ {
+			row, col := true, true
+			for k := range finder {
+				row = row && c.modules[i][j+k] == finder[k]
+				col = col && c.modules[j+k][i] == finder[k]
+			}
+
+			if row && (c.lightRun(j-4, i, 1, 0) || c.lightRun(j+7, i, 1, 0)) {
+				penalty += n3
+			}
+			if col && (c.lightRun(i, j-4, 0, 1) || c.lightRun(i, j+7, 0, 1)) {
+				penalty += n3
+			}
+		}
+	}
+
+	// Proportion of dark modules, in steps of 5% away from 50%
+	dark := 0
+	for _, row := range c.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (absInt(dark*20-total*10)+total-1)/total - 1
+	penalty += k * n4
+
+	return penalty
+}
+
+// lightRun returns whether the four modules from x, y in the direction dx, dy are light.
+// The quiet zone is light.
+func (c *Code) lightRun(x, y, dx, dy int) bool {
+	for i := 0; i < 4; i++ {
+		if c.Dark(x+dx*i, y+dy*i) {
+			return false
+		}
+	}
+	return true
+}
+
+// reedSolomonDivisor returns the generator polynomial of degree n, without its leading coefficient,
+// from the highest to the lowest power
+func reedSolomonDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+
+	// Multiply by (x - r^i), for i in [0, n), with r = 0x02 the generator of the field
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+
+	return result
+}
+
+// reedSolomonRemainder returns the remainder of data divided by the generator polynomial
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(256) with the reducing polynomial x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11d)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, getBit(v, i))
+	}
+}
+
+func (bb bitBuffer) bytes() []byte {
+	b := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			b[i>>3] |= 1 << uint(7-(i&7))
+		}
+	}
+	return b
+}
+
+func getBit(v, i int) bool {
+	return (v>>uint(i))&1 != 0
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,143 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	// Generated by an independent encoder, with the same mask
+	golden := []string{
+		"11111110100111001000001111111",
+		"10000010001110001011101000001",
+		"10111010001100100110101011101",
+		"10111010111110110010101011101",
+		"10111010100011010010001011101",
+		"10000010101011101101101000001",
+		"11111110101010101010101111111",
+		"00000000100010010100000000000",
+		"10001011111111010100011111001",
+		"11011001110001111010101111011",
+		"01000111010011111000010000111",
+		"10001100000010100111000110011",
+		"11001110111101100000100100011",
+		"10000100100110101100011111101",
+		"11111010111011110100010010101",
+		"00101000101001011101001011011",
+		"10011111001011000000010001110",
+		"10100101010111101000010110101",
+		"00110110011100011010011001101",
+		"00010000000110110101110101001",
+		"11101111010111110001111111011",
+		"00000000101100001100100010101",
+		"11111110101101111100101011101",
+		"10000010000101001101100011000",
+		"10111010101101011001111111110",
+		"10111010010011101010010100101",
+		"10111010010100110001010000111",
+		"10000010001001100101110111011",
+		"11111110110100001110101110010",
+	}
+
+	c, err := Encode([]byte("mdl:2GgFvqoyk9RjwVzj8tqfcXVXB7ZLAFm7WPG"), Medium)
+	require.NoError(t, err)
+	require.Equal(t, 3, c.Version)
+	require.Equal(t, Medium, c.Level)
+	require.Equal(t, 4, c.Mask)
+	require.Equal(t, 29, c.Size)
+
+	for y, row := range golden {
+		for x, m := range row {
+			require.Equal(t, m == '1', c.Dark(x, y), "module %d,%d", x, y)
+		}
+	}
+
+	// The quiet zone is light
+	require.False(t, c.Dark(-1, 0))
+	require.False(t, c.Dark(0, c.Size))
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	cases := []struct {
+		level  Level
+		minLen int
+		maxLen int
+	}{
+		{Low, 17, 2953},
+		{Medium, 14, 2331},
+		{Quartile, 11, 1663},
+		{High, 7, 1273},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.level), func(t *testing.T) {
+			c, err := Encode(bytes.Repeat([]byte{'a'}, tc.minLen), tc.level)
+			require.NoError(t, err)
+			require.Equal(t, 1, c.Version)
+			require.Equal(t, 21, c.Size)
+
+			c, err = Encode(bytes.Repeat([]byte{'a'}, tc.minLen+1), tc.level)
+			require.NoError(t, err)
+			require.Equal(t, 2, c.Version)
+
+			c, err = Encode(bytes.Repeat([]byte{0xff}, tc.maxLen), tc.level)
+			require.NoError(t, err)
+			require.Equal(t, MaxVersion, c.Version)
+			require.Equal(t, 177, c.Size)
+
+			_, err = Encode(bytes.Repeat([]byte{'a'}, tc.maxLen+1), tc.level)
+			require.Equal(t, ErrDataTooLong, err)
+		})
+	}
+
+	c, err := Encode(nil, Low)
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Version)
+
+	_, err = Encode([]byte("a"), Level(4))
+	require.Equal(t, ErrInvalidLevel, err)
+}
+
+func TestFormatBits(t *testing.T) {
+	// Values of the format information table of the standard
+	require.Equal(t, 0x77c4, formatBits(Low, 0))
+	require.Equal(t, 0x5412, formatBits(Medium, 0))
+	require.Equal(t, 0x355f, formatBits(Quartile, 0))
+	require.Equal(t, 0x1689, formatBits(High, 0))
+	require.Equal(t, 0x6976, formatBits(Low, 7))
+	require.Equal(t, 0x083b, formatBits(High, 7))
+}
+
+func TestVersionBits(t *testing.T) {
+	// Values of the version information table of the standard
+	require.Equal(t, 0x07c94, versionBits(7))
+	require.Equal(t, 0x085bc, versionBits(8))
+	require.Equal(t, 0x28c69, versionBits(40))
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	require.Empty(t, alignmentPatternPositions(1))
+	require.Equal(t, []int{6, 18}, alignmentPatternPositions(2))
+	require.Equal(t, []int{6, 22, 38}, alignmentPatternPositions(7))
+	require.Equal(t, []int{6, 34, 60, 86, 112, 138}, alignmentPatternPositions(32))
+	require.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, alignmentPatternPositions(40))
+}
+
+func TestSVG(t *testing.T) {
+	c := MustEncode([]byte("foo"), High)
+	svg := c.SVG(4)
+
+	width := (c.Size + QuietZone*2) * 4
+	require.True(t, strings.HasPrefix(svg, "<svg "))
+	require.Contains(t, svg, fmt.Sprintf(`width="%d" height="%d"`, width, width))
+	require.Contains(t, svg, fmt.Sprintf(`viewBox="0 0 %d %d"`, c.Size+QuietZone*2, c.Size+QuietZone*2))
+	// The top left module of the finder pattern
+	require.Contains(t, svg, fmt.Sprintf("M%d,%dh1v1h-1z", QuietZone, QuietZone))
+
+	lines := strings.Split(strings.TrimSuffix(c.String(), "\n"), "\n")
+	require.Len(t, lines, c.Size+QuietZone*2)
+}
@@ -0,0 +1,388 @@
+package visor
+
+// This file contains the wallet balance cache.
+//
+// Computing the balance of a wallet from the database reads the unspent outputs of every address of the wallet,
+// which is slow for wallets with thousands of addresses. The cache keeps the unspent outputs of the addresses
+// whose balance was requested, and the inputs and outputs of the unconfirmed transactions.
+//
+// The cache is updated incrementally in the database transactions which execute blocks, and which inject or
+// remove unconfirmed transactions. It is reset if such a database transaction is rolled back.
+// Addresses are loaded into the cache only if no database transaction updated it in the meantime,
+// otherwise the balances are read from the database. The balances are also read from the database while
+// a database transaction which updated the cache is in progress, since it may still be rolled back.
+// The least recently requested addresses are removed from the cache when it holds too many addresses.
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// balanceCacheMaxAddrs is the maximum number of addresses whose unspent outputs are cached
+const balanceCacheMaxAddrs = 100000
+
+// cachedTxn is an unconfirmed transaction in the balance cache
+type cachedTxn struct {
+	inputs  []cipher.SHA256
+	outputs coin.UxArray
+}
+
+// balanceCache caches the unspent outputs of addresses and the unconfirmed transactions, to compute balances
+// without reading the database. A nil *balanceCache caches nothing.
+type balanceCache struct {
+	sync.Mutex
+
+	// loaded is true once the head block and the unconfirmed transactions are loaded
+	loaded   bool
+	headSeq  uint64
+	headTime uint64
+
+	// unspents are the confirmed unspent outputs of the cached addresses
+	unspents map[cipher.Address]map[cipher.SHA256]coin.UxOut
+	// owners maps the hashes of the unspents to their address
+	owners map[cipher.SHA256]cipher.Address
+	// used is the value of clock when the balance of a cached address was last requested
+	used  map[cipher.Address]uint64
+	clock uint64
+	// maxAddrs is the maximum number of cached addresses
+	maxAddrs int
+
+	// txns are the unconfirmed transactions, by hash
+	txns map[cipher.SHA256]cachedTxn
+	// spending counts the unconfirmed transactions which spend an output
+	spending map[cipher.SHA256]int
+	// incoming are the outputs of the unconfirmed transactions, by address
+	incoming map[cipher.Address]map[cipher.SHA256]coin.UxOut
+
+	// gen is incremented on every change of the cache
+	gen uint64
+	// pending is the number of database transactions which changed the cache and are not committed or rolled back yet
+	pending int
+}
+
+// newBalanceCache creates an empty balanceCache
+func newBalanceCache() *balanceCache {
+	c := &balanceCache{
+		maxAddrs: balanceCacheMaxAddrs,
+	}
+	c.clear()
+	return c
+}
+
+func (c *balanceCache) clear() {
+	c.loaded = false
+	c.headSeq = 0
+	c.headTime = 0
+	c.unspents = make(map[cipher.Address]map[cipher.SHA256]coin.UxOut)
+	c.owners = make(map[cipher.SHA256]cipher.Address)
+	c.used = make(map[cipher.Address]uint64)
+	c.txns = make(map[cipher.SHA256]cachedTxn)
+	c.spending = make(map[cipher.SHA256]int)
+	c.incoming = make(map[cipher.Address]map[cipher.SHA256]coin.UxOut)
+}
+
+// reset empties the cache
+func (c *balanceCache) reset() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.clear()
+	c.gen++
+}
+
+// update applies a change to the cache in the database transaction which makes the change.
+// The change is applied immediately, so that it is ordered like the database transactions,
+// and the cache is reset if the database transaction is rolled back.
+func (c *balanceCache) update(tx *dbutil.Tx, f func()) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.gen++
+	c.pending++
+
+	tx.OnCommit(c.done)
+	tx.OnRollback(func() {
+		c.reset()
+		c.done()
+	})
+
+	if c.loaded {
+		f()
+	}
+}
+
+func (c *balanceCache) done() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.gen++
+	c.pending--
+}
+
+// executeBlock updates the cache with a block executed in a database transaction,
+// after the transactions of the block are removed from the unconfirmed pool
+func (c *balanceCache) executeBlock(tx *dbutil.Tx, b coin.Block) {
+	c.update(tx, func() {
+		c.headSeq = b.Head.BkSeq
+		c.headTime = b.Head.Time
+
+		for _, txn := range b.Body.Transactions {
+			for _, h := range txn.In {
+				if addr, ok := c.owners[h]; ok {
+					delete(c.unspents[addr], h)
+					delete(c.owners, h)
+				}
+			}
+
+			for _, ux := range coin.CreateUnspents(b.Head, txn) {
+				if uxs, ok := c.unspents[ux.Body.Address]; ok {
+					h := ux.Hash()
+					uxs[h] = ux
+					c.owners[h] = ux.Body.Address
+				}
+			}
+
+			c.removeTxn(txn.Hash())
+		}
+	})
+}
+
+// injectTransaction updates the cache with a transaction injected to the unconfirmed pool in a database transaction
+func (c *balanceCache) injectTransaction(tx *dbutil.Tx, txn coin.Transaction) {
+	c.update(tx, func() {
+		c.addTxn(txn)
+	})
+}
+
+// removeTransactions updates the cache with transactions removed from the unconfirmed pool in a database transaction
+func (c *balanceCache) removeTransactions(tx *dbutil.Tx, hashes []cipher.SHA256) {
+	if len(hashes) == 0 {
+		return
+	}
+
+	c.update(tx, func() {
+		for _, h := range hashes {
+			c.removeTxn(h)
+		}
+	})
+}
+
+func (c *balanceCache) addTxn(txn coin.Transaction) {
+	txnHash := txn.Hash()
+	if _, ok := c.txns[txnHash]; ok {
+		return
+	}
+
+	// The outputs are created with an empty block header, which does not change their hashes,
+	// so that they match the inputs of the unconfirmed transactions spending them.
+	// coin.CreateUnspents can't be used, it sets a null SrcTransaction for the block 0.
+	// The head block is set when the balances are computed.
+	ctxn := cachedTxn{
+		inputs:  txn.In,
+		outputs: make(coin.UxArray, len(txn.Out)),
+	}
+	for i := range txn.Out {
+		ctxn.outputs[i] = unconfirmedOutput(coin.BlockHeader{}, txnHash, txn, i)
+	}
+	c.txns[txnHash] = ctxn
+
+	for _, h := range ctxn.inputs {
+		c.spending[h]++
+	}
+
+	for _, ux := range ctxn.outputs {
+		uxs, ok := c.incoming[ux.Body.Address]
+		if !ok {
+			uxs = make(map[cipher.SHA256]coin.UxOut)
+			c.incoming[ux.Body.Address] = uxs
+		}
+		uxs[ux.Hash()] = ux
+	}
+}
+
+func (c *balanceCache) removeTxn(txnHash cipher.SHA256) {
+	ctxn, ok := c.txns[txnHash]
+	if !ok {
+		return
+	}
+	delete(c.txns, txnHash)
+
+	for _, h := range ctxn.inputs {
+		c.spending[h]--
+		if c.spending[h] <= 0 {
+			delete(c.spending, h)
+		}
+	}
+
+	for _, ux := range ctxn.outputs {
+		uxs := c.incoming[ux.Body.Address]
+		delete(uxs, ux.Hash())
+		if len(uxs) == 0 {
+			delete(c.incoming, ux.Body.Address)
+		}
+	}
+}
+
+// missing returns the addresses which are not cached, whether the head block and the unconfirmed
+// transactions are loaded, and the generation of the cache to pass to load.
+// Returns false if the cache can't be loaded, because a database transaction which changed it is in progress.
+func (c *balanceCache) missing(addrs []cipher.Address) ([]cipher.Address, bool, uint64, bool) {
+	if c == nil {
+		return nil, false, 0, false
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.pending != 0 {
+		return nil, false, 0, false
+	}
+
+	var missing []cipher.Address
+	for _, addr := range addrs {
+		if _, ok := c.unspents[addr]; !ok || !c.loaded {
+			missing = append(missing, addr)
+		}
+	}
+
+	return missing, c.loaded, c.gen, true
+}
+
+// load adds data read from the database to the cache, unless the cache changed since the generation gen.
+// txns are ignored if the unconfirmed transactions are loaded already.
+func (c *balanceCache) load(gen uint64, head coin.BlockHeader, txns coin.Transactions, auxs coin.AddressUxOuts) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.gen != gen || c.pending != 0 {
+		return false
+	}
+
+	if !c.loaded {
+		c.clear()
+		c.loaded = true
+		c.headSeq = head.BkSeq
+		c.headTime = head.Time
+		for _, txn := range txns {
+			c.addTxn(txn)
+		}
+	}
+
+	c.clock++
+	for addr, uxa := range auxs {
+		uxs := make(map[cipher.SHA256]coin.UxOut, len(uxa))
+		for _, ux := range uxa {
+			h := ux.Hash()
+			uxs[h] = ux
+			c.owners[h] = addr
+		}
+		c.unspents[addr] = uxs
+		c.used[addr] = c.clock
+	}
+
+	c.evict()
+
+	c.gen++
+
+	return true
+}
+
+// evict removes the least recently requested addresses from the cache, if it holds more than maxAddrs addresses.
+// The addresses loaded last are kept.
+func (c *balanceCache) evict() {
+	n := len(c.unspents) - c.maxAddrs
+	if n <= 0 {
+		return
+	}
+
+	addrs := make([]cipher.Address, 0, len(c.unspents))
+	for addr := range c.unspents {
+		if c.used[addr] != c.clock {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return c.used[addrs[i]] < c.used[addrs[j]]
+	})
+
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+
+	for _, addr := range addrs[:n] {
+		for h := range c.unspents[addr] {
+			delete(c.owners, h)
+		}
+		delete(c.unspents, addr)
+		delete(c.used, addr)
+	}
+}
+
+// balanceOfAddrs returns the balances of addresses.
+// Returns false if an address is not cached, or if a database transaction which changed the cache
+// is in progress, since its changes may be rolled back.
+func (c *balanceCache) balanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair, bool, error) {
+	if c == nil {
+		return nil, false, nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.loaded || c.pending != 0 {
+		return nil, false, nil
+	}
+
+	c.clock++
+	for _, addr := range addrs {
+		if _, ok := c.unspents[addr]; ok {
+			c.used[addr] = c.clock
+		}
+	}
+
+	bps := make([]wallet.BalancePair, 0, len(addrs))
+	for _, addr := range addrs {
+		unspents, ok := c.unspents[addr]
+		if !ok {
+			return nil, false, nil
+		}
+
+		uxs := make(coin.UxArray, 0, len(unspents))
+		predictedUxs := make(coin.UxArray, 0, len(unspents)+len(c.incoming[addr]))
+		for h, ux := range unspents {
+			uxs = append(uxs, ux)
+			if c.spending[h] == 0 {
+				predictedUxs = append(predictedUxs, ux)
+			}
+		}
+
+		// The outputs spent by other unconfirmed transactions are not predicted to be unspent
+		for h, ux := range c.incoming[addr] {
+			if c.spending[h] != 0 {
+				continue
+			}
+			ux.Head.BkSeq = c.headSeq
+			ux.Head.Time = c.headTime
+			predictedUxs = append(predictedUxs, ux)
+		}
+
+		bp, err := newBalancePair(uxs, predictedUxs, c.headTime)
+		if err != nil {
+			return nil, false, err
+		}
+
+		bps = append(bps, bp)
+	}
+
+	return bps, true, nil
+}
@@ -0,0 +1,237 @@
+package visor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+)
+
+func requireCachedBalances(t *testing.T, v *Visor, addrs []cipher.Address) {
+	expected, err := v.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+
+	bps, ok, err := v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, expected, bps)
+}
+
+func TestBalanceCache(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		balances:    newBalanceCache(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	toAddr := testutil.MakeAddress()
+	addrs := []cipher.Address{genAddress, toAddr}
+
+	// Nothing is cached before the first request
+	_, ok, err := v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	bps, err := v.getCachedBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.Equal(t, genCoins, bps[0].Confirmed.Coins)
+	requireCachedBalances(t, v, addrs)
+
+	// Injecting transactions updates the predicted balances
+	var coins uint64 = 10e6
+	txn1 := makeSpendTxn(t, uxs, []cipher.SecKey{genSecret}, toAddr, coins)
+	_, _, err = v.InjectForeignTransaction(txn1)
+	require.NoError(t, err)
+	requireCachedBalances(t, v, addrs)
+
+	bps, ok, err = v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), bps[1].Confirmed.Coins)
+	require.Equal(t, coins, bps[1].Predicted.Coins)
+
+	txn2 := makeSpendTxWithFee(t, uxs, []cipher.SecKey{genSecret}, toAddr, coins*2, 1)
+	_, _, _, err = v.InjectUserTransaction(txn2)
+	require.NoError(t, err)
+
+	// Executing a block updates the confirmed balances.
+	// txn2 is included in the block because it has a higher fee, txn1 becomes a double spend.
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Equal(t, txn2.Hash(), sb.Body.Transactions[0].Hash())
+
+	bps, ok, err = v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, coins*2, bps[1].Confirmed.Coins)
+	require.Equal(t, coins*3, bps[1].Predicted.Coins)
+
+	// Removing the invalid transaction removes its predicted outputs
+	removed, err := v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{txn1.Hash()}, removed)
+	requireCachedBalances(t, v, addrs)
+
+	bps, err = v.getCachedBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.Equal(t, coins*2, bps[1].Predicted.Coins)
+
+	// Addresses which are not cached are loaded on request
+	otherAddrs := append(addrs, testutil.MakeAddress())
+	_, ok, err = v.balances.balanceOfAddrs(otherAddrs)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = v.getCachedBalanceOfAddrs(otherAddrs)
+	require.NoError(t, err)
+	requireCachedBalances(t, v, otherAddrs)
+
+	// The cache is reset if a transaction which updated it is rolled back
+	errRollback := errors.New("rollback")
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		v.balances.injectTransaction(tx, txn1)
+		return errRollback
+	})
+	require.Equal(t, errRollback, err)
+
+	_, ok, err = v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = v.getCachedBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	requireCachedBalances(t, v, addrs)
+
+	bps, ok, err = v.balances.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, coins*2, bps[1].Predicted.Coins)
+}
+
+func TestBalanceCacheLoad(t *testing.T) {
+	c := newBalanceCache()
+	addrs := []cipher.Address{testutil.MakeAddress()}
+
+	missing, loaded, gen, ok := c.missing(addrs)
+	require.True(t, ok)
+	require.False(t, loaded)
+	require.Equal(t, addrs, missing)
+
+	// Data read before a change of the cache is not loaded
+	c.reset()
+	require.False(t, c.load(gen, coin.BlockHeader{}, nil, coin.AddressUxOuts{addrs[0]: nil}))
+
+	// Data is not loaded while a database transaction which changed the cache is in progress
+	_, _, gen, ok = c.missing(addrs)
+	require.True(t, ok)
+	c.pending++
+	_, _, _, ok = c.missing(addrs)
+	require.False(t, ok)
+	require.False(t, c.load(gen, coin.BlockHeader{}, nil, coin.AddressUxOuts{addrs[0]: nil}))
+	c.done()
+
+	_, _, gen, ok = c.missing(addrs)
+	require.True(t, ok)
+	require.True(t, c.load(gen, coin.BlockHeader{}, nil, coin.AddressUxOuts{addrs[0]: nil}))
+
+	missing, loaded, _, ok = c.missing(addrs)
+	require.True(t, ok)
+	require.True(t, loaded)
+	require.Empty(t, missing)
+
+	bps, ok, err := c.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, bps, 1)
+
+	// The balances are not read from the cache while a database transaction which changed it is in progress,
+	// since the changes may be rolled back
+	c.pending++
+	_, ok, err = c.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.False(t, ok)
+	c.done()
+
+	_, ok, err = c.balanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestBalanceCacheEvict(t *testing.T) {
+	c := newBalanceCache()
+	c.maxAddrs = 2
+
+	a := testutil.MakeAddress()
+	b := testutil.MakeAddress()
+	d := testutil.MakeAddress()
+	ux := coin.UxOut{
+		Body: coin.UxBody{
+			Address: a,
+			Coins:   1e6,
+		},
+	}
+
+	load := func(auxs coin.AddressUxOuts) {
+		_, _, gen, ok := c.missing(nil)
+		require.True(t, ok)
+		require.True(t, c.load(gen, coin.BlockHeader{}, nil, auxs))
+	}
+
+	load(coin.AddressUxOuts{a: coin.UxArray{ux}})
+	load(coin.AddressUxOuts{b: nil})
+
+	// a is requested after b, so b is the least recently requested address
+	_, ok, err := c.balanceOfAddrs([]cipher.Address{a})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	load(coin.AddressUxOuts{d: nil})
+	require.Len(t, c.unspents, 2)
+
+	missing, _, _, ok := c.missing([]cipher.Address{a, b, d})
+	require.True(t, ok)
+	require.Equal(t, []cipher.Address{b}, missing)
+
+	// The outputs of an evicted address are removed
+	load(coin.AddressUxOuts{b: nil})
+	missing, _, _, ok = c.missing([]cipher.Address{a, b, d})
+	require.True(t, ok)
+	require.Equal(t, []cipher.Address{a}, missing)
+	require.Empty(t, c.owners)
+	require.Len(t, c.used, 2)
+
+	// The addresses loaded together are kept even if there are more than the maximum
+	load(coin.AddressUxOuts{a: nil, b: nil, d: nil})
+	require.Len(t, c.unspents, 3)
+}
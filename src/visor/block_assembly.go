@@ -0,0 +1,156 @@
+package visor
+
+// This file contains the selection of the unconfirmed transactions included in a new block.
+//
+// The candidates are ordered by a TxnOrderingPolicy, with ties broken by the transaction hash,
+// so that the same pool always produces the same block. The candidates are then packed in that order:
+// a candidate which doesn't fit in the remaining space of the block is skipped and smaller candidates
+// after it are still considered. A candidate which spends an output of another unconfirmed transaction
+// is deferred to a later block, because a block can only spend confirmed outputs. A candidate which
+// spends an output spent by a candidate already included is skipped, because it would be rejected
+// as a double spend when the block is executed.
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+)
+
+const (
+	// TxnOrderingFeeRate includes the transactions which burn the most coin hours per byte first
+	TxnOrderingFeeRate = "fee-rate"
+	// TxnOrderingFIFO includes the transactions in the order they were first received
+	TxnOrderingFIFO = "fifo"
+)
+
+// BlockCandidate is an unconfirmed transaction considered for a new block
+type BlockCandidate struct {
+	Transaction coin.Transaction
+	Hash        cipher.SHA256
+	// Size is the size of the transaction in bytes
+	Size uint32
+	// Fee is the number of coin hours burned by the transaction
+	Fee uint64
+	// Received is the time the transaction was first received, in nanoseconds.
+	// It doesn't change if the transaction is received again while it is unconfirmed.
+	Received int64
+}
+
+// TxnOrderingPolicy orders the unconfirmed transactions considered for a new block.
+// Transactions which come first are included first when the block fills up.
+// Custom policies can be set in Config.BlockTxnOrdering.
+type TxnOrderingPolicy interface {
+	// Name returns the name of the policy, for logging
+	Name() string
+	// Less returns true if a must be included before b.
+	// If neither a nor b must be included first, the transaction with the lowest hash is included first.
+	Less(a, b *BlockCandidate) bool
+}
+
+var (
+	// FeeRateTxnOrdering orders transactions by the coin hours burned per byte, highest first
+	FeeRateTxnOrdering TxnOrderingPolicy = feeRateTxnOrdering{}
+	// FIFOTxnOrdering orders transactions by the time they were first received, oldest first
+	FIFOTxnOrdering TxnOrderingPolicy = fifoTxnOrdering{}
+)
+
+// NewTxnOrderingPolicy returns the TxnOrderingPolicy of a name, TxnOrderingFeeRate or TxnOrderingFIFO
+func NewTxnOrderingPolicy(name string) (TxnOrderingPolicy, error) {
+	switch name {
+	case TxnOrderingFeeRate:
+		return FeeRateTxnOrdering, nil
+	case TxnOrderingFIFO:
+		return FIFOTxnOrdering, nil
+	default:
+		return nil, fmt.Errorf("Invalid transaction ordering policy %q", name)
+	}
+}
+
+type feeRateTxnOrdering struct{}
+
+func (feeRateTxnOrdering) Name() string {
+	return TxnOrderingFeeRate
+}
+
+// Less compares a.Fee/a.Size with b.Fee/b.Size exactly, by comparing the 128-bit products a.Fee*b.Size and b.Fee*a.Size
+func (feeRateTxnOrdering) Less(a, b *BlockCandidate) bool {
+	aHi, aLo := bits.Mul64(a.Fee, uint64(b.Size))
+	bHi, bLo := bits.Mul64(b.Fee, uint64(a.Size))
+	if aHi != bHi {
+		return aHi > bHi
+	}
+	return aLo > bLo
+}
+
+type fifoTxnOrdering struct{}
+
+func (fifoTxnOrdering) Name() string {
+	return TxnOrderingFIFO
+}
+
+func (fifoTxnOrdering) Less(a, b *BlockCandidate) bool {
+	return a.Received < b.Received
+}
+
+// sortBlockCandidates sorts candidates with a TxnOrderingPolicy, and by lowest hash if tied
+func sortBlockCandidates(candidates []BlockCandidate, policy TxnOrderingPolicy) {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if policy.Less(a, b) {
+			return true
+		}
+		if policy.Less(b, a) {
+			return false
+		}
+		return bytes.Compare(a.Hash[:], b.Hash[:]) < 0
+	})
+}
+
+// assembleBlock returns the transactions to include in a block of at most maxSize bytes and maxTxns transactions,
+// from candidates sorted by sortBlockCandidates
+func assembleBlock(candidates []BlockCandidate, maxSize uint32, maxTxns int) coin.Transactions {
+	// Outputs of the candidates, which can't be spent in the same block
+	unconfirmedOutputs := make(map[cipher.SHA256]struct{})
+	for _, c := range candidates {
+		for _, o := range c.Transaction.Out {
+			unconfirmedOutputs[o.UxID(c.Hash)] = struct{}{}
+		}
+	}
+
+	spent := make(map[cipher.SHA256]struct{})
+	var txns coin.Transactions
+	var size uint64
+
+candidates:
+	for _, c := range candidates {
+		if len(txns) >= maxTxns {
+			break
+		}
+
+		if size+uint64(c.Size) > uint64(maxSize) {
+			continue
+		}
+
+		for _, h := range c.Transaction.In {
+			if _, ok := unconfirmedOutputs[h]; ok {
+				continue candidates
+			}
+			if _, ok := spent[h]; ok {
+				continue candidates
+			}
+		}
+
+		for _, h := range c.Transaction.In {
+			spent[h] = struct{}{}
+		}
+
+		txns = append(txns, c.Transaction)
+		size += uint64(c.Size)
+	}
+
+	return txns
+}
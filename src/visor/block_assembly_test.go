@@ -0,0 +1,295 @@
+package visor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+)
+
+func makeBlockCandidate(t *testing.T, in []cipher.SHA256, fee uint64, received int64) BlockCandidate {
+	txn := coin.Transaction{
+		In: in,
+	}
+	err := txn.PushOutput(testutil.MakeAddress(), 1e6, 1)
+	require.NoError(t, err)
+	txn.Sigs = make([]cipher.Sig, len(in))
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	size, hash, err := txn.SizeHash()
+	require.NoError(t, err)
+
+	return BlockCandidate{
+		Transaction: txn,
+		Hash:        hash,
+		Size:        size,
+		Fee:         fee,
+		Received:    received,
+	}
+}
+
+func candidateHashes(candidates []BlockCandidate) []cipher.SHA256 {
+	hashes := make([]cipher.SHA256, len(candidates))
+	for i, c := range candidates {
+		hashes[i] = c.Hash
+	}
+	return hashes
+}
+
+// reverseFIFOTxnOrdering is a custom policy which includes the newest transactions first
+type reverseFIFOTxnOrdering struct{}
+
+func (reverseFIFOTxnOrdering) Name() string {
+	return "reverse-fifo"
+}
+
+func (reverseFIFOTxnOrdering) Less(a, b *BlockCandidate) bool {
+	return a.Received > b.Received
+}
+
+func TestNewTxnOrderingPolicy(t *testing.T) {
+	p, err := NewTxnOrderingPolicy(TxnOrderingFeeRate)
+	require.NoError(t, err)
+	require.Equal(t, FeeRateTxnOrdering, p)
+
+	p, err = NewTxnOrderingPolicy(TxnOrderingFIFO)
+	require.NoError(t, err)
+	require.Equal(t, FIFOTxnOrdering, p)
+
+	_, err = NewTxnOrderingPolicy("foo")
+	testutil.RequireError(t, err, `Invalid transaction ordering policy "foo"`)
+}
+
+func TestSortBlockCandidates(t *testing.T) {
+	a := makeBlockCandidate(t, []cipher.SHA256{testutil.RandSHA256(t)}, 10, 3)
+	b := makeBlockCandidate(t, []cipher.SHA256{testutil.RandSHA256(t)}, 30, 2)
+	c := makeBlockCandidate(t, []cipher.SHA256{testutil.RandSHA256(t)}, 20, 1)
+
+	// d burns as much per byte as b, it is ordered with b by hash
+	d := makeBlockCandidate(t, []cipher.SHA256{testutil.RandSHA256(t)}, b.Fee*2, 1)
+	d.Size = b.Size * 2
+
+	bd := []BlockCandidate{b, d}
+	if string(d.Hash[:]) < string(b.Hash[:]) {
+		bd = []BlockCandidate{d, b}
+	}
+
+	cases := []struct {
+		name     string
+		policy   TxnOrderingPolicy
+		expected []BlockCandidate
+	}{
+		{
+			name:     "fee rate",
+			policy:   FeeRateTxnOrdering,
+			expected: []BlockCandidate{bd[0], bd[1], c, a},
+		},
+		{
+			name:   "fifo",
+			policy: FIFOTxnOrdering,
+		},
+		{
+			name:   "custom",
+			policy: reverseFIFOTxnOrdering{},
+		},
+	}
+
+	cd := []BlockCandidate{c, d}
+	if string(d.Hash[:]) < string(c.Hash[:]) {
+		cd = []BlockCandidate{d, c}
+	}
+	cases[1].expected = []BlockCandidate{cd[0], cd[1], b, a}
+	cases[2].expected = []BlockCandidate{a, b, cd[0], cd[1]}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The order doesn't depend on the order of the pool
+			for _, candidates := range [][]BlockCandidate{
+				{a, b, c, d},
+				{d, c, b, a},
+				{b, d, a, c},
+			} {
+				sortBlockCandidates(candidates, tc.policy)
+				require.Equal(t, candidateHashes(tc.expected), candidateHashes(candidates))
+			}
+		})
+	}
+}
+
+func TestFeeRateTxnOrderingOverflow(t *testing.T) {
+	// The fee rates are compared exactly, even if fee*size overflows 64 bits
+	a := &BlockCandidate{
+		Fee:  math.MaxUint64,
+		Size: 1001,
+	}
+	b := &BlockCandidate{
+		Fee:  math.MaxUint64 - 1,
+		Size: 1001,
+	}
+	require.True(t, FeeRateTxnOrdering.Less(a, b))
+	require.False(t, FeeRateTxnOrdering.Less(b, a))
+	require.False(t, FeeRateTxnOrdering.Less(a, a))
+}
+
+func TestAssembleBlock(t *testing.T) {
+	ux1 := testutil.RandSHA256(t)
+	ux2 := testutil.RandSHA256(t)
+	ux3 := testutil.RandSHA256(t)
+	ux4 := testutil.RandSHA256(t)
+
+	a := makeBlockCandidate(t, []cipher.SHA256{ux1}, 100, 0)
+	// b spends the same output as a
+	b := makeBlockCandidate(t, []cipher.SHA256{ux1, ux2}, 90, 0)
+	// c is larger than the remaining space after a
+	c := makeBlockCandidate(t, []cipher.SHA256{ux2, ux3}, 80, 0)
+	// d spends an output of a, which is unconfirmed
+	d := makeBlockCandidate(t, []cipher.SHA256{a.Transaction.Out[0].UxID(a.Hash)}, 70, 0)
+	e := makeBlockCandidate(t, []cipher.SHA256{ux4}, 60, 0)
+
+	candidates := []BlockCandidate{a, b, c, d, e}
+
+	// Smaller transactions after a transaction which doesn't fit are still included
+	maxSize := a.Size + e.Size + 1
+	require.True(t, maxSize < a.Size+c.Size)
+	txns := assembleBlock(candidates, maxSize, coin.MaxBlockTransactions)
+	require.Equal(t, coin.Transactions{a.Transaction, e.Transaction}, txns)
+
+	// b is skipped as a double spend of a, d is deferred until a is confirmed
+	txns = assembleBlock(candidates, math.MaxUint32, coin.MaxBlockTransactions)
+	require.Equal(t, coin.Transactions{a.Transaction, c.Transaction, e.Transaction}, txns)
+
+	// The number of transactions is limited
+	txns = assembleBlock(candidates, math.MaxUint32, 2)
+	require.Equal(t, coin.Transactions{a.Transaction, c.Transaction}, txns)
+
+	// Without a, b is included and c is skipped as a double spend of b.
+	// d is not checked against the confirmed outputs, which is done when verifying the block.
+	txns = assembleBlock(candidates[1:], math.MaxUint32, coin.MaxBlockTransactions)
+	require.Equal(t, coin.Transactions{b.Transaction, d.Transaction, e.Transaction}, txns)
+}
+
+// splitGenesisOutput executes a block splitting the genesis output into n outputs with different coin hours, and a change output
+func splitGenesisOutput(t *testing.T, v *Visor, gb *coin.SignedBlock, n int) (*coin.SignedBlock, coin.UxArray) {
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	splitTxn := coin.Transaction{}
+	err := splitTxn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		err = splitTxn.PushOutput(genAddress, 10e6, uxs[0].Body.Hours/uint64(2*n)+uint64(i))
+		require.NoError(t, err)
+	}
+	err = splitTxn.PushOutput(genAddress, uxs[0].Body.Coins-uint64(n)*10e6, 0)
+	require.NoError(t, err)
+	splitTxn.SignInputs([]cipher.SecKey{genSecret})
+	err = splitTxn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.InjectForeignTransaction(splitTxn)
+	require.NoError(t, err)
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Equal(t, coin.Transactions{splitTxn}, sb.Body.Transactions)
+
+	return &sb, coin.CreateUnspents(sb.Head, splitTxn)
+}
+
+func TestCreateBlockTxnOrdering(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	sb, splitUxs := splitGenesisOutput(t, v, gb, 2)
+
+	// The older transaction burns less coin hours
+	txn1 := makeSpendTxWithFee(t, splitUxs[:1], []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6, 1)
+	txn2 := makeSpendTxWithFee(t, splitUxs[1:2], []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6, 100)
+
+	_, _, err = v.InjectForeignTransaction(txn1)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txn2)
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		utxn, err := v.unconfirmed.Get(tx, txn1.Hash())
+		require.NoError(t, err)
+		utxn2, err := v.unconfirmed.Get(tx, txn2.Hash())
+		require.NoError(t, err)
+		require.True(t, utxn.Received < utxn2.Received)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Blocks can only hold one of the transactions
+	size, err := txn2.Size()
+	require.NoError(t, err)
+	v.Config.MaxBlockTransactionsSize = size
+
+	for _, tc := range []struct {
+		policy   TxnOrderingPolicy
+		expected coin.Transaction
+	}{
+		{FeeRateTxnOrdering, txn2},
+		{FIFOTxnOrdering, txn1},
+	} {
+		v.Config.BlockTxnOrdering = tc.policy
+		err = db.View("", func(tx *dbutil.Tx) error {
+			b, err := v.createBlock(tx, sb.Head.Time+100)
+			require.NoError(t, err)
+			require.Equal(t, coin.Transactions{tc.expected}, b.Body.Transactions, tc.policy.Name())
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// A transaction received again keeps its position in the FIFO order,
+	// although the time it was last received is updated
+	_, _, err = v.InjectForeignTransaction(txn1)
+	require.NoError(t, err)
+
+	v.Config.BlockTxnOrdering = FIFOTxnOrdering
+	err = db.View("", func(tx *dbutil.Tx) error {
+		utxn, err := v.unconfirmed.Get(tx, txn1.Hash())
+		require.NoError(t, err)
+		utxn2, err := v.unconfirmed.Get(tx, txn2.Hash())
+		require.NoError(t, err)
+		require.True(t, utxn.Received > utxn2.Received)
+
+		b, err := v.createBlock(tx, sb.Head.Time+100)
+		require.NoError(t, err)
+		require.Equal(t, coin.Transactions{txn1}, b.Body.Transactions)
+		return nil
+	})
+	require.NoError(t, err)
+}
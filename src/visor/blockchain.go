@@ -98,6 +98,11 @@ func CreateBuckets(db *dbutil.DB) error {
 		return dbutil.CreateBuckets(tx, [][]byte{
 			UnconfirmedTxnsBkt,
 			UnconfirmedUnspentsBkt,
+			UnconfirmedMetaBkt,
+			UnconfirmedFeeRateBkt,
+			UnconfirmedEvictedBkt,
+			UnconfirmedOutputsBkt,
+			PendingSpendsBkt,
 		})
 	})
 }
@@ -3,6 +3,7 @@ package visor
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/params"
@@ -25,6 +26,20 @@ type Config struct {
 	CreateBlockVerifyTxn params.VerifyTxn
 	// Maximum size of a block, in bytes for creating blocks
 	MaxBlockTransactionsSize uint32
+	// Order in which unconfirmed transactions are included when creating blocks
+	BlockTxnOrdering TxnOrderingPolicy
+
+	// Maximum number of transactions in the unconfirmed pool, 0 for no limit
+	MaxUnconfirmedTransactions uint64
+	// Maximum size of the transactions stored in the unconfirmed pool, in bytes, 0 for no limit
+	MaxUnconfirmedSize uint64
+	// Maximum time since a transaction was first added to the unconfirmed pool, 0 for no limit
+	MaxUnconfirmedAge time.Duration
+	// Allow transactions in the unconfirmed pool to be replaced by conflicting transactions burning more coin hours.
+	// Only the transactions which signal replace-by-fee can be replaced, see coin.Transaction.IsReplaceable.
+	ReplaceByFee bool
+	// Minimum coin hours per KB of the replacement that a replacement must burn in addition to the replaced transactions
+	ReplaceByFeeIncrement uint64
 
 	// Where the blockchain is saved
 	BlockchainFile string
@@ -41,6 +56,14 @@ type Config struct {
 	GenesisCoinVolume uint64
 	// enable arbitrating mode
 	Arbitrating bool
+
+	// Time after which a pending wallet spend can no longer be approved
+	PendingSpendExpiry time.Duration
+	// Public keys of the identities which create, approve and reject pending wallet spends, by name
+	PendingSpendKeys map[string]cipher.PubKey
+
+	// Number of recent blocks sampled to estimate coin hour fees
+	FeeEstimateBlocks uint64
 }
 
 // NewConfig creates Config
@@ -54,11 +77,22 @@ func NewConfig() Config {
 		UnconfirmedVerifyTxn:     params.UserVerifyTxn,
 		CreateBlockVerifyTxn:     params.UserVerifyTxn,
 		MaxBlockTransactionsSize: params.UserVerifyTxn.MaxTransactionSize,
+		BlockTxnOrdering:         FeeRateTxnOrdering,
+
+		MaxUnconfirmedTransactions: 0,
+		MaxUnconfirmedSize:         0,
+		MaxUnconfirmedAge:          0,
+		ReplaceByFee:               false,
+		ReplaceByFeeIncrement:      10,
 
 		GenesisAddress:    cipher.Address{},
 		GenesisSignature:  cipher.Sig{},
 		GenesisTimestamp:  0,
 		GenesisCoinVolume: 0, //100e12, 100e6 * 10e6
+
+		PendingSpendExpiry: 24 * time.Hour,
+
+		FeeEstimateBlocks: 100,
 	}
 
 	return c
@@ -108,5 +142,34 @@ func (c Config) Verify() error {
 		return errors.New("MaxBlockTransactionsSize must be >= CreateBlockVerifyTxn.MaxTransactionSize")
 	}
 
+	if c.BlockTxnOrdering == nil {
+		return errors.New("BlockTxnOrdering must be set")
+	}
+
+	if c.MaxUnconfirmedSize != 0 && c.MaxUnconfirmedSize < uint64(c.UnconfirmedVerifyTxn.MaxTransactionSize) {
+		return errors.New("MaxUnconfirmedSize must be 0 or >= UnconfirmedVerifyTxn.MaxTransactionSize")
+	}
+
+	if c.MaxUnconfirmedAge < 0 {
+		return errors.New("MaxUnconfirmedAge must be >= 0")
+	}
+
+	if c.PendingSpendExpiry <= 0 {
+		return errors.New("PendingSpendExpiry must be > 0")
+	}
+
+	for name, pubkey := range c.PendingSpendKeys {
+		if name == "" {
+			return errors.New("PendingSpendKeys names can't be empty")
+		}
+		if err := pubkey.Verify(); err != nil {
+			return fmt.Errorf("PendingSpendKeys key of %q is invalid: %v", name, err)
+		}
+	}
+
+	if c.FeeEstimateBlocks == 0 {
+		return errors.New("FeeEstimateBlocks must be > 0")
+	}
+
 	return nil
 }
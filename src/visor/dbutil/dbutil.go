@@ -31,6 +31,8 @@ var (
 // Tx wraps a Tx
 type Tx struct {
 	*bolt.Tx
+
+	rollbackHandlers []func()
 }
 
 // String is implemented to prevent a panic when mocking methods with *Tx arguments.
@@ -40,6 +42,12 @@ func (tx *Tx) String() string {
 	return fmt.Sprintf("%v", tx.Tx)
 }
 
+// OnRollback adds a handler function to be executed after an Update transaction is rolled back.
+// Use OnCommit for a handler to be executed after the transaction is committed.
+func (tx *Tx) OnRollback(fn func()) {
+	tx.rollbackHandlers = append(tx.rollbackHandlers, fn)
+}
+
 // DB wraps a bolt.DB to add logging
 type DB struct {
 	ViewLog                    bool
@@ -89,7 +97,7 @@ func (db *DB) View(name string, f func(*Tx) error) error {
 	t0 := time.Now()
 
 	err := db.DB.View(func(tx *bolt.Tx) error {
-		return f(&Tx{tx})
+		return f(&Tx{Tx: tx})
 	})
 
 	t1 := time.Now()
@@ -116,10 +124,18 @@ func (db *DB) Update(name string, f func(*Tx) error) error {
 
 	t0 := time.Now()
 
+	var t *Tx
 	err := db.DB.Update(func(tx *bolt.Tx) error {
-		return f(&Tx{tx})
+		t = &Tx{Tx: tx}
+		return f(t)
 	})
 
+	if err != nil && t != nil {
+		for _, fn := range t.rollbackHandlers {
+			fn()
+		}
+	}
+
 	t1 := time.Now()
 	delta := t1.Sub(t0)
 	if db.DurationLog && delta > db.DurationReportingThreshold {
@@ -0,0 +1,348 @@
+package visor
+
+// This file contains the coin hour fee estimator.
+//
+// Blocks are created with the unconfirmed transactions which burn the most coin hours per kilobyte,
+// up to the maximum block size. A transaction burning more than the minimum fee is only needed when
+// there are more unconfirmed transactions than a block can hold.
+//
+// The fee rate of a block is the lowest fee rate of its transactions if the block is full, or zero otherwise.
+// The estimate for a confidence level c is the lowest fee rate which is at least the fee rate of a fraction c
+// of the recent blocks, and which is higher than the fee rate of the first unconfirmed transaction which
+// doesn't fit in the next block. A fee rate of zero means that the minimum fee of the burn factor is enough.
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+var (
+	// DefaultFeeEstimateConfidences are the confidence levels of the fee estimates if none are requested
+	DefaultFeeEstimateConfidences = []decimal.Decimal{
+		decimal.New(5, -1),
+		decimal.New(8, -1),
+		decimal.New(95, -2),
+	}
+
+	// DefaultFeeEstimateConfidence is the confidence level used to create transactions with the estimated hours selection mode,
+	// if none is specified
+	DefaultFeeEstimateConfidence = decimal.New(8, -1)
+
+	// ErrInvalidFeeEstimateConfidence a fee estimate confidence level is not in the range (0, 1]
+	ErrInvalidFeeEstimateConfidence = NewUserError(errors.New("Fee estimate confidence must be > 0 and <= 1"))
+)
+
+// FeeEstimate is the estimated coin hour fee rate for a confidence level
+type FeeEstimate struct {
+	// Confidence is the target probability that a transaction burning FeeRate is included in the next block
+	Confidence decimal.Decimal
+	// FeeRate is the number of coin hours to burn per kilobyte of the transaction.
+	// The minimum fee of the burn factor must be burned if it is higher.
+	FeeRate uint64
+}
+
+// FeeEstimates are the coin hour fee estimates, with the data they are computed from
+type FeeEstimates struct {
+	BurnFactor   uint32
+	MaxBlockSize uint32
+	// Blocks is the number of recent blocks sampled
+	Blocks uint64
+	// FullBlocks is the number of sampled blocks which could not hold another transaction
+	FullBlocks uint64
+	// UnconfirmedTransactions is the number of unconfirmed transactions
+	UnconfirmedTransactions uint64
+	// UnconfirmedSize is the total size of the unconfirmed transactions, in bytes
+	UnconfirmedSize uint64
+	Estimates       []FeeEstimate
+}
+
+// blockFees are the fee statistics of a block
+type blockFees struct {
+	// minFeeRate is the lowest fee rate of the transactions of the block, in coin hours per kilobyte
+	minFeeRate uint64
+	// full is true if the block could not hold another transaction
+	full bool
+}
+
+// feeRate returns the fee rate which a transaction needed to be included in the block
+func (b blockFees) feeRate() uint64 {
+	if !b.full {
+		return 0
+	}
+	return b.minFeeRate
+}
+
+// feeEstimator caches the fee statistics of the recent blocks, which don't change once the blocks are executed.
+// A nil *feeEstimator caches nothing.
+type feeEstimator struct {
+	sync.Mutex
+	blocks map[uint64]blockFees
+}
+
+// newFeeEstimator creates an empty feeEstimator
+func newFeeEstimator() *feeEstimator {
+	return &feeEstimator{
+		blocks: make(map[uint64]blockFees),
+	}
+}
+
+func (e *feeEstimator) get(seq uint64) (blockFees, bool) {
+	if e == nil {
+		return blockFees{}, false
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	b, ok := e.blocks[seq]
+	return b, ok
+}
+
+func (e *feeEstimator) set(seq uint64, b blockFees) {
+	if e == nil {
+		return
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	e.blocks[seq] = b
+}
+
+// prune removes the blocks older than minSeq, which are no longer sampled
+func (e *feeEstimator) prune(minSeq uint64) {
+	if e == nil {
+		return
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	for s := range e.blocks {
+		if s < minSeq {
+			delete(e.blocks, s)
+		}
+	}
+}
+
+// EstimateFees returns the coin hour fee rates to burn for transactions to be included in the next block,
+// for confidence levels in the range (0, 1]. DefaultFeeEstimateConfidences are used if confidences is empty.
+func (vs *Visor) EstimateFees(confidences []decimal.Decimal) (*FeeEstimates, error) {
+	if len(confidences) == 0 {
+		confidences = DefaultFeeEstimateConfidences
+	}
+
+	for _, c := range confidences {
+		if !validFeeEstimateConfidence(c) {
+			return nil, ErrInvalidFeeEstimateConfidence
+		}
+	}
+
+	var estimates *FeeEstimates
+	if err := vs.db.View("EstimateFees", func(tx *dbutil.Tx) error {
+		var err error
+		estimates, err = vs.estimateFees(tx, confidences)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return estimates, nil
+}
+
+func validFeeEstimateConfidence(c decimal.Decimal) bool {
+	return c.GreaterThan(decimal.New(0, 0)) && !c.GreaterThan(decimal.New(1, 0))
+}
+
+func (vs *Visor) estimateFees(tx *dbutil.Tx, confidences []decimal.Decimal) (*FeeEstimates, error) {
+	estimates := &FeeEstimates{
+		BurnFactor:   vs.Config.UnconfirmedVerifyTxn.BurnFactor,
+		MaxBlockSize: vs.Config.MaxBlockTransactionsSize,
+	}
+
+	head, err := vs.blockchain.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sample the recent blocks, excluding the genesis block
+	var minSeq uint64 = 1
+	if head.Seq() > vs.Config.FeeEstimateBlocks {
+		minSeq = head.Seq() - vs.Config.FeeEstimateBlocks + 1
+	}
+	vs.fees.prune(minSeq)
+
+	var blockRates []uint64
+	for seq := minSeq; seq <= head.Seq(); seq++ {
+		b, err := vs.getBlockFees(tx, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		estimates.Blocks++
+		if b.full {
+			estimates.FullBlocks++
+		}
+		blockRates = append(blockRates, b.feeRate())
+	}
+
+	sort.Slice(blockRates, func(i, j int) bool {
+		return blockRates[i] < blockRates[j]
+	})
+
+	// Find the fee rate needed to be included in the next block, from the unconfirmed transactions
+	txns, err := vs.unconfirmed.AllRawTransactions(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := coin.NewSortableTransactions(txns, vs.blockchain.TransactionFee(tx, head.Time()))
+	if err != nil {
+		return nil, err
+	}
+	sorted.Sort()
+
+	var poolRate uint64
+	var blockSize uint64
+	for i, txn := range sorted.Transactions {
+		size, err := txn.Size()
+		if err != nil {
+			return nil, err
+		}
+
+		estimates.UnconfirmedTransactions++
+		estimates.UnconfirmedSize += uint64(size)
+
+		blockSize += uint64(size)
+		if poolRate == 0 && (blockSize > uint64(vs.Config.MaxBlockTransactionsSize) || i >= coin.MaxBlockTransactions) {
+			poolRate = sorted.Fees[i]
+			if poolRate < math.MaxUint64 {
+				poolRate++
+			}
+		}
+	}
+
+	for _, c := range confidences {
+		var rate uint64
+		if len(blockRates) != 0 {
+			i := c.Mul(decimal.New(int64(len(blockRates)), 0)).Ceil().IntPart() - 1
+			if i < 0 {
+				i = 0
+			}
+			rate = blockRates[i]
+		}
+
+		if poolRate > rate {
+			rate = poolRate
+		}
+
+		estimates.Estimates = append(estimates.Estimates, FeeEstimate{
+			Confidence: c,
+			FeeRate:    rate,
+		})
+	}
+
+	return estimates, nil
+}
+
+// getBlockFees returns the fee statistics of the block seq, from the cache or from the database
+func (vs *Visor) getBlockFees(tx *dbutil.Tx, seq uint64) (blockFees, error) {
+	if b, ok := vs.fees.get(seq); ok {
+		return b, nil
+	}
+
+	b, err := vs.blockchain.GetSignedBlockBySeq(tx, seq)
+	if err != nil {
+		return blockFees{}, err
+	}
+	if b == nil {
+		return blockFees{}, errors.New("getBlockFees: block not found")
+	}
+
+	// The fees of the transactions of a block are calculated with the time of the previous block
+	prev, err := vs.blockchain.GetSignedBlockBySeq(tx, seq-1)
+	if err != nil {
+		return blockFees{}, err
+	}
+	if prev == nil {
+		return blockFees{}, errors.New("getBlockFees: previous block not found")
+	}
+
+	sorted, err := coin.NewSortableTransactions(b.Body.Transactions, func(txn *coin.Transaction) (uint64, error) {
+		uxs, err := vs.history.GetUxOuts(tx, txn.In)
+		if err != nil {
+			return 0, err
+		}
+
+		inUxs := make(coin.UxArray, len(uxs))
+		for i, ux := range uxs {
+			inUxs[i] = ux.Out
+		}
+
+		return fee.TransactionFee(txn, prev.Head.Time, inUxs)
+	})
+	if err != nil {
+		return blockFees{}, err
+	}
+
+	size, err := b.Body.Transactions.Size()
+	if err != nil {
+		return blockFees{}, err
+	}
+
+	// The block is full if the smallest transaction did not fit in it
+	minTxnSize, err := transaction.EstimateSize(1, 1)
+	if err != nil {
+		return blockFees{}, err
+	}
+
+	bf := blockFees{
+		minFeeRate: math.MaxUint64,
+		full:       uint64(size)+uint64(minTxnSize) > uint64(vs.Config.MaxBlockTransactionsSize) || len(b.Body.Transactions) >= coin.MaxBlockTransactions,
+	}
+
+	for _, r := range sorted.Fees {
+		if r < bf.minFeeRate {
+			bf.minFeeRate = r
+		}
+	}
+
+	if len(sorted.Fees) == 0 {
+		bf.minFeeRate = 0
+	}
+
+	vs.fees.set(seq, bf)
+
+	return bf, nil
+}
+
+// setEstimatedFeeRate sets the fee rate of transaction parameters with the estimated hours selection mode,
+// from the fee estimate for their confidence level
+func (vs *Visor) setEstimatedFeeRate(tx *dbutil.Tx, p *transaction.Params) error {
+	if p.HoursSelection.Mode != transaction.HoursSelectionModeEstimated {
+		return nil
+	}
+
+	confidence := DefaultFeeEstimateConfidence
+	if p.HoursSelection.Confidence != nil {
+		confidence = *p.HoursSelection.Confidence
+	}
+
+	estimates, err := vs.estimateFees(tx, []decimal.Decimal{confidence})
+	if err != nil {
+		return err
+	}
+
+	p.HoursSelection.FeeRate = estimates.Estimates[0].FeeRate
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+)
+
+func requireFeeRates(t *testing.T, e *FeeEstimates, rates ...uint64) {
+	require.Len(t, e.Estimates, len(rates))
+	for i, r := range rates {
+		require.Equal(t, r, e.Estimates[i].FeeRate, "confidence %s", e.Estimates[i].Confidence)
+	}
+}
+
+func TestEstimateFees(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		fees:        newFeeEstimator(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	// The genesis block is not sampled
+	e, err := v.EstimateFees(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), e.Blocks)
+	require.Equal(t, uint64(0), e.UnconfirmedTransactions)
+	require.Equal(t, cfg.UnconfirmedVerifyTxn.BurnFactor, e.BurnFactor)
+	require.Equal(t, DefaultFeeEstimateConfidences[1], e.Estimates[1].Confidence)
+	requireFeeRates(t, e, 0, 0, 0)
+
+	_, err = v.EstimateFees([]decimal.Decimal{decimal.New(0, 0)})
+	require.Equal(t, ErrInvalidFeeEstimateConfidence, err)
+	_, err = v.EstimateFees([]decimal.Decimal{decimal.New(11, -1)})
+	require.Equal(t, ErrInvalidFeeEstimateConfidence, err)
+
+	// Blocks can only hold one transaction
+	txn1 := makeSpendTxWithFee(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6, 1)
+	txn2 := makeSpendTxWithFee(t, uxs, []cipher.SecKey{genSecret}, genAddress, 10e6, 2)
+	size, err := txn2.Size()
+	require.NoError(t, err)
+	v.Config.MaxBlockTransactionsSize = size
+
+	_, _, err = v.InjectForeignTransaction(txn1)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txn2)
+	require.NoError(t, err)
+
+	var sorted *coin.SortableTransactions
+	err = db.View("", func(tx *dbutil.Tx) error {
+		var err error
+		sorted, err = coin.NewSortableTransactions(coin.Transactions{txn2, txn1}, v.blockchain.TransactionFee(tx, gb.Head.Time))
+		return err
+	})
+	require.NoError(t, err)
+	require.True(t, sorted.Fees[0] > sorted.Fees[1])
+
+	// txn1 doesn't fit in the next block, a transaction must burn more than it
+	e, err = v.EstimateFees(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), e.UnconfirmedTransactions)
+	require.Equal(t, uint64(size)*2, e.UnconfirmedSize)
+	requireFeeRates(t, e, sorted.Fees[1]+1, sorted.Fees[1]+1, sorted.Fees[1]+1)
+
+	// The block is full, a transaction must burn as much as txn2
+	sb, err := v.CreateAndExecuteBlock()
+	require.NoError(t, err)
+	require.Equal(t, coin.Transactions{txn2}, sb.Body.Transactions)
+
+	_, err = v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+
+	e, err = v.EstimateFees(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), e.Blocks)
+	require.Equal(t, uint64(1), e.FullBlocks)
+	require.Equal(t, uint64(0), e.UnconfirmedTransactions)
+	requireFeeRates(t, e, sorted.Fees[0], sorted.Fees[0], sorted.Fees[0])
+
+	// Blocks which are not full don't need more than the minimum fee
+	v.Config.MaxBlockTransactionsSize = cfg.MaxBlockTransactionsSize
+	uxs = coin.CreateUnspents(sb.Head, sb.Body.Transactions[0])
+	txn3 := makeSpendTxWithFee(t, uxs[:1], []cipher.SecKey{genSecret}, testutil.MakeAddress(), 1e6, 1)
+	_, _, err = v.InjectForeignTransaction(txn3)
+	require.NoError(t, err)
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		sb, err := v.createBlock(tx, sb.Head.Time+100)
+		if err != nil {
+			return err
+		}
+		return v.executeSignedBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	e, err = v.EstimateFees([]decimal.Decimal{decimal.New(5, -1), decimal.New(1, 0)})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), e.Blocks)
+	require.Equal(t, uint64(1), e.FullBlocks)
+	requireFeeRates(t, e, 0, sorted.Fees[0])
+
+	// Only the last FeeEstimateBlocks blocks are sampled
+	v.Config.FeeEstimateBlocks = 1
+	e, err = v.EstimateFees(nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), e.Blocks)
+	require.Equal(t, uint64(0), e.FullBlocks)
+	requireFeeRates(t, e, 0, 0, 0)
+	require.Len(t, v.fees.blocks, 1)
+}
+
+func TestSetEstimatedFeeRate(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	uxs := coin.CreateUnspents(gb.Head, gb.Body.Transactions[0])
+
+	txn1 := makeSpendTxWithFee(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6, 1)
+	txn2 := makeSpendTxWithFee(t, uxs, []cipher.SecKey{genSecret}, testutil.MakeAddress(), 10e6, 2)
+	size, err := txn2.Size()
+	require.NoError(t, err)
+	v.Config.MaxBlockTransactionsSize = size
+
+	_, _, err = v.InjectForeignTransaction(txn1)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txn2)
+	require.NoError(t, err)
+
+	e, err := v.EstimateFees([]decimal.Decimal{DefaultFeeEstimateConfidence})
+	require.NoError(t, err)
+	require.NotEqual(t, uint64(0), e.Estimates[0].FeeRate)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		// Params of other modes are not changed
+		p := transaction.Params{
+			HoursSelection: transaction.HoursSelection{
+				Type: transaction.HoursSelectionTypeManual,
+			},
+		}
+		require.NoError(t, v.setEstimatedFeeRate(tx, &p))
+		require.Equal(t, uint64(0), p.HoursSelection.FeeRate)
+
+		p = transaction.Params{
+			HoursSelection: transaction.HoursSelection{
+				Type: transaction.HoursSelectionTypeAuto,
+				Mode: transaction.HoursSelectionModeEstimated,
+			},
+		}
+		require.NoError(t, v.setEstimatedFeeRate(tx, &p))
+		require.Equal(t, e.Estimates[0].FeeRate, p.HoursSelection.FeeRate)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
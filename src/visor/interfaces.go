@@ -1,6 +1,8 @@
 package visor
 
 import (
+	"time"
+
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/params"
@@ -66,4 +68,15 @@ type UnconfirmedTransactionPooler interface {
 	ForEach(tx *dbutil.Tx, f func(cipher.SHA256, UnconfirmedTransaction) error) error
 	GetUnspentsOfAddr(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error)
 	Len(tx *dbutil.Tx) (uint64, error)
+	Protect(tx *dbutil.Tx, hash cipher.SHA256) error
+	Evict(tx *dbutil.Tx, bc Blockchainer, limits UnconfirmedPoolLimits, now time.Time) ([]EvictedTransaction, error)
+	GetEvicted(tx *dbutil.Tx) ([]EvictedTransaction, error)
+	GetAddedTimes(tx *dbutil.Tx) (map[cipher.SHA256]int64, error)
+	GetConflicts(tx *dbutil.Tx, txn coin.Transaction) ([]UnconfirmedTransaction, error)
+	Replace(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, increment uint64, now time.Time) ([]EvictedTransaction, error)
+	GetOutput(tx *dbutil.Tx, bh coin.BlockHeader, uxID cipher.SHA256) (*coin.UxOut, error)
+	SpendsUnconfirmed(tx *dbutil.Tx, txn coin.Transaction) (bool, error)
+	VerifyTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, signed TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error)
+	TransactionFee(tx *dbutil.Tx, bc Blockchainer, bh coin.BlockHeader) coin.FeeCalculator
+	GetDescendants(tx *dbutil.Tx, hashes []cipher.SHA256) ([]UnconfirmedTransaction, error)
 }
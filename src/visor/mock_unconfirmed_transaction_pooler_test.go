@@ -7,6 +7,7 @@ import coin "github.com/MDLlife/MDL/src/coin"
 import dbutil "github.com/MDLlife/MDL/src/visor/dbutil"
 import mock "github.com/stretchr/testify/mock"
 import params "github.com/MDLlife/MDL/src/params"
+import time "time"
 
 // MockUnconfirmedTransactionPooler is an autogenerated mock type for the UnconfirmedTransactionPooler type
 type MockUnconfirmedTransactionPooler struct {
@@ -36,6 +37,29 @@ func (_m *MockUnconfirmedTransactionPooler) AllRawTransactions(tx *dbutil.Tx) (c
 	return r0, r1
 }
 
+// Evict provides a mock function with given fields: tx, bc, limits, now
+func (_m *MockUnconfirmedTransactionPooler) Evict(tx *dbutil.Tx, bc Blockchainer, limits UnconfirmedPoolLimits, now time.Time) ([]EvictedTransaction, error) {
+	ret := _m.Called(tx, bc, limits, now)
+
+	var r0 []EvictedTransaction
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, UnconfirmedPoolLimits, time.Time) []EvictedTransaction); ok {
+		r0 = rf(tx, bc, limits, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]EvictedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, UnconfirmedPoolLimits, time.Time) error); ok {
+		r1 = rf(tx, bc, limits, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FilterKnown provides a mock function with given fields: tx, txns
 func (_m *MockUnconfirmedTransactionPooler) FilterKnown(tx *dbutil.Tx, txns []cipher.SHA256) ([]cipher.SHA256, error) {
 	ret := _m.Called(tx, txns)
@@ -96,6 +120,98 @@ func (_m *MockUnconfirmedTransactionPooler) Get(tx *dbutil.Tx, hash cipher.SHA25
 	return r0, r1
 }
 
+// GetConflicts provides a mock function with given fields: tx, txn
+func (_m *MockUnconfirmedTransactionPooler) GetConflicts(tx *dbutil.Tx, txn coin.Transaction) ([]UnconfirmedTransaction, error) {
+	ret := _m.Called(tx, txn)
+
+	var r0 []UnconfirmedTransaction
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Transaction) []UnconfirmedTransaction); ok {
+		r0 = rf(tx, txn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]UnconfirmedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, coin.Transaction) error); ok {
+		r1 = rf(tx, txn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDescendants provides a mock function with given fields: tx, hashes
+func (_m *MockUnconfirmedTransactionPooler) GetDescendants(tx *dbutil.Tx, hashes []cipher.SHA256) ([]UnconfirmedTransaction, error) {
+	ret := _m.Called(tx, hashes)
+
+	var r0 []UnconfirmedTransaction
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, []cipher.SHA256) []UnconfirmedTransaction); ok {
+		r0 = rf(tx, hashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]UnconfirmedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, []cipher.SHA256) error); ok {
+		r1 = rf(tx, hashes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAddedTimes provides a mock function with given fields: tx
+func (_m *MockUnconfirmedTransactionPooler) GetAddedTimes(tx *dbutil.Tx) (map[cipher.SHA256]int64, error) {
+	ret := _m.Called(tx)
+
+	var r0 map[cipher.SHA256]int64
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) map[cipher.SHA256]int64); ok {
+		r0 = rf(tx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[cipher.SHA256]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEvicted provides a mock function with given fields: tx
+func (_m *MockUnconfirmedTransactionPooler) GetEvicted(tx *dbutil.Tx) ([]EvictedTransaction, error) {
+	ret := _m.Called(tx)
+
+	var r0 []EvictedTransaction
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx) []EvictedTransaction); ok {
+		r0 = rf(tx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]EvictedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx) error); ok {
+		r1 = rf(tx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFiltered provides a mock function with given fields: tx, filter
 func (_m *MockUnconfirmedTransactionPooler) GetFiltered(tx *dbutil.Tx, filter func(UnconfirmedTransaction) bool) ([]UnconfirmedTransaction, error) {
 	ret := _m.Called(tx, filter)
@@ -188,6 +304,29 @@ func (_m *MockUnconfirmedTransactionPooler) GetKnown(tx *dbutil.Tx, txns []ciphe
 	return r0, r1
 }
 
+// GetOutput provides a mock function with given fields: tx, bh, uxID
+func (_m *MockUnconfirmedTransactionPooler) GetOutput(tx *dbutil.Tx, bh coin.BlockHeader, uxID cipher.SHA256) (*coin.UxOut, error) {
+	ret := _m.Called(tx, bh, uxID)
+
+	var r0 *coin.UxOut
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.BlockHeader, cipher.SHA256) *coin.UxOut); ok {
+		r0 = rf(tx, bh, uxID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.UxOut)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, coin.BlockHeader, cipher.SHA256) error); ok {
+		r1 = rf(tx, bh, uxID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUnspentsOfAddr provides a mock function with given fields: tx, addr
 func (_m *MockUnconfirmedTransactionPooler) GetUnspentsOfAddr(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error) {
 	ret := _m.Called(tx, addr)
@@ -262,6 +401,20 @@ func (_m *MockUnconfirmedTransactionPooler) Len(tx *dbutil.Tx) (uint64, error) {
 	return r0, r1
 }
 
+// Protect provides a mock function with given fields: tx, hash
+func (_m *MockUnconfirmedTransactionPooler) Protect(tx *dbutil.Tx, hash cipher.SHA256) error {
+	ret := _m.Called(tx, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, cipher.SHA256) error); ok {
+		r0 = rf(tx, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RecvOfAddresses provides a mock function with given fields: tx, bh, addrs
 func (_m *MockUnconfirmedTransactionPooler) RecvOfAddresses(tx *dbutil.Tx, bh coin.BlockHeader, addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	ret := _m.Called(tx, bh, addrs)
@@ -345,6 +498,29 @@ func (_m *MockUnconfirmedTransactionPooler) RemoveTransactions(tx *dbutil.Tx, tx
 	return r0
 }
 
+// Replace provides a mock function with given fields: tx, bc, txn, verifyParams, increment, now
+func (_m *MockUnconfirmedTransactionPooler) Replace(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, increment uint64, now time.Time) ([]EvictedTransaction, error) {
+	ret := _m.Called(tx, bc, txn, verifyParams, increment, now)
+
+	var r0 []EvictedTransaction
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.VerifyTxn, uint64, time.Time) []EvictedTransaction); ok {
+		r0 = rf(tx, bc, txn, verifyParams, increment, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]EvictedTransaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.VerifyTxn, uint64, time.Time) error); ok {
+		r1 = rf(tx, bc, txn, verifyParams, increment, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetTransactionsAnnounced provides a mock function with given fields: tx, hashes
 func (_m *MockUnconfirmedTransactionPooler) SetTransactionsAnnounced(tx *dbutil.Tx, hashes map[cipher.SHA256]int64) error {
 	ret := _m.Called(tx, hashes)
@@ -358,3 +534,72 @@ func (_m *MockUnconfirmedTransactionPooler) SetTransactionsAnnounced(tx *dbutil.
 
 	return r0
 }
+
+// SpendsUnconfirmed provides a mock function with given fields: tx, txn
+func (_m *MockUnconfirmedTransactionPooler) SpendsUnconfirmed(tx *dbutil.Tx, txn coin.Transaction) (bool, error) {
+	ret := _m.Called(tx, txn)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, coin.Transaction) bool); ok {
+		r0 = rf(tx, txn)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, coin.Transaction) error); ok {
+		r1 = rf(tx, txn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransactionFee provides a mock function with given fields: tx, bc, bh
+func (_m *MockUnconfirmedTransactionPooler) TransactionFee(tx *dbutil.Tx, bc Blockchainer, bh coin.BlockHeader) coin.FeeCalculator {
+	ret := _m.Called(tx, bc, bh)
+
+	var r0 coin.FeeCalculator
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, coin.BlockHeader) coin.FeeCalculator); ok {
+		r0 = rf(tx, bc, bh)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(coin.FeeCalculator)
+		}
+	}
+
+	return r0
+}
+
+// VerifyTransaction provides a mock function with given fields: tx, bc, txn, verifyParams, signed
+func (_m *MockUnconfirmedTransactionPooler) VerifyTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, signed TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error) {
+	ret := _m.Called(tx, bc, txn, verifyParams, signed)
+
+	var r0 *coin.SignedBlock
+	if rf, ok := ret.Get(0).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.VerifyTxn, TxnSignedFlag) *coin.SignedBlock); ok {
+		r0 = rf(tx, bc, txn, verifyParams, signed)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coin.SignedBlock)
+		}
+	}
+
+	var r1 coin.UxArray
+	if rf, ok := ret.Get(1).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.VerifyTxn, TxnSignedFlag) coin.UxArray); ok {
+		r1 = rf(tx, bc, txn, verifyParams, signed)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(coin.UxArray)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*dbutil.Tx, Blockchainer, coin.Transaction, params.VerifyTxn, TxnSignedFlag) error); ok {
+		r2 = rf(tx, bc, txn, verifyParams, signed)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
@@ -0,0 +1,301 @@
+package visor
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+var (
+	// PendingSpendsBkt stores the wallet spends waiting for approval, keyed by the inner hash of their transaction
+	PendingSpendsBkt = []byte("pending_spends")
+
+	// ErrPendingSpendNotExist is returned if a pending spend does not exist
+	ErrPendingSpendNotExist = NewUserError(errors.New("pending spend does not exist"))
+	// ErrPendingSpendExists is returned when creating a pending spend for a transaction which is already pending
+	ErrPendingSpendExists = NewUserError(errors.New("pending spend already exists"))
+	// ErrPendingSpendExpired is returned when approving a pending spend which has expired
+	ErrPendingSpendExpired = NewUserError(errors.New("pending spend has expired"))
+	// ErrPendingSpendSelfApproval is returned when the creator of a pending spend tries to approve it
+	ErrPendingSpendSelfApproval = NewUserError(errors.New("pending spend can't be approved by its creator"))
+	// ErrPendingSpendCreatorRequired is returned when creating a pending spend without a creator
+	ErrPendingSpendCreatorRequired = NewUserError(errors.New("creator is required"))
+	// ErrPendingSpendCheckerRequired is returned when approving or rejecting a pending spend without a checker
+	ErrPendingSpendCheckerRequired = NewUserError(errors.New("checker is required"))
+	// ErrPendingSpendUnknownIdentity is returned if a creator or checker has no key in Config.PendingSpendKeys
+	ErrPendingSpendUnknownIdentity = NewUserError(errors.New("creator or checker is not registered"))
+	// ErrPendingSpendInvalidSignature is returned if the signature of a creator or checker is invalid
+	ErrPendingSpendInvalidSignature = NewUserError(errors.New("invalid pending spend signature"))
+)
+
+// PendingSpendAction is an action on a pending spend which its creator or a checker signs
+type PendingSpendAction string
+
+const (
+	// PendingSpendCreate is signed by the creator of a pending spend, to confirm the transaction it created
+	PendingSpendCreate PendingSpendAction = "create"
+	// PendingSpendApprove is signed by the checker which approves a pending spend
+	PendingSpendApprove PendingSpendAction = "approve"
+	// PendingSpendReject is signed by the checker which rejects a pending spend
+	PendingSpendReject PendingSpendAction = "reject"
+)
+
+// PendingSpendHash returns the hash signed by a creator or checker to authorize an action on a pending spend
+func PendingSpendHash(action PendingSpendAction, id cipher.SHA256) cipher.SHA256 {
+	return cipher.SumSHA256([]byte("mdl pending spend " + string(action) + " " + id.Hex()))
+}
+
+// PendingSpend is an unsigned transaction created from a wallet, which is signed and injected
+// once it is approved by a checker other than its creator.
+// The creator and the checkers are identities registered with a public key in Config.PendingSpendKeys.
+// An approval is verified with the signatures of both the creator and the checker, so that two keys are
+// needed to approve a spend.
+type PendingSpend struct {
+	WalletID    string             `json:"wallet_id"`
+	Creator     string             `json:"creator"`
+	Transaction coin.Transaction   `json:"transaction"`
+	Inputs      []TransactionInput `json:"inputs"`
+	CreatedAt   time.Time          `json:"created_at"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+}
+
+// ID returns the ID of the pending spend, the inner hash of its transaction.
+// The transaction ID changes when the transaction is signed, the inner hash doesn't.
+func (ps PendingSpend) ID() cipher.SHA256 {
+	return ps.Transaction.InnerHash
+}
+
+// Expired returns true if the pending spend can no longer be approved
+func (ps PendingSpend) Expired(now time.Time) bool {
+	return !now.Before(ps.ExpiresAt)
+}
+
+// CreatePendingSpend creates an unsigned transaction from a wallet and adds it to the pending spends.
+// The pending spend expires after Config.PendingSpendExpiry. Expired pending spends are removed.
+func (vs *Visor) CreatePendingSpend(wltID, creator string, p transaction.Params, wp CreateTransactionParams) (*PendingSpend, error) {
+	if creator == "" {
+		return nil, ErrPendingSpendCreatorRequired
+	}
+
+	if _, ok := vs.Config.PendingSpendKeys[creator]; !ok {
+		return nil, ErrPendingSpendUnknownIdentity
+	}
+
+	txn, inputs, err := vs.WalletCreateTransaction(wltID, p, wp)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	ps := &PendingSpend{
+		WalletID:    wltID,
+		Creator:     creator,
+		Transaction: *txn,
+		Inputs:      inputs,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(vs.Config.PendingSpendExpiry),
+	}
+
+	if err := vs.db.Update("CreatePendingSpend", func(tx *dbutil.Tx) error {
+		return addPendingSpend(tx, ps, now)
+	}); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Pending spend %s of wallet %s created by %q", ps.ID().Hex(), wltID, creator)
+
+	return ps, nil
+}
+
+// GetPendingSpends returns the pending spends which have not expired, ordered by creation time.
+// If wltID is not empty, only the pending spends of the wallet are returned.
+func (vs *Visor) GetPendingSpends(wltID string) ([]PendingSpend, error) {
+	now := time.Now()
+
+	var spends []PendingSpend
+	if err := vs.db.View("GetPendingSpends", func(tx *dbutil.Tx) error {
+		return forEachPendingSpend(tx, func(ps PendingSpend) error {
+			if ps.Expired(now) {
+				return nil
+			}
+			if wltID != "" && ps.WalletID != wltID {
+				return nil
+			}
+			spends = append(spends, ps)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(spends, func(i, j int) bool {
+		return spends[i].CreatedAt.Before(spends[j].CreatedAt)
+	})
+
+	return spends, nil
+}
+
+// RejectPendingSpend removes a pending spend without signing it.
+// sig is the signature of the PendingSpendReject hash by the key of checker.
+func (vs *Visor) RejectPendingSpend(id cipher.SHA256, checker string, sig cipher.Sig) (*PendingSpend, error) {
+	if checker == "" {
+		return nil, ErrPendingSpendCheckerRequired
+	}
+
+	if err := vs.verifyPendingSpendSig(checker, PendingSpendReject, id, sig); err != nil {
+		return nil, err
+	}
+
+	var ps *PendingSpend
+	if err := vs.db.Update("RejectPendingSpend", func(tx *dbutil.Tx) error {
+		var err error
+		ps, err = getPendingSpend(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return vs.RemovePendingSpendTx(tx, id)
+	}); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Pending spend %s of wallet %s rejected by %q", id.Hex(), ps.WalletID, checker)
+
+	return ps, nil
+}
+
+// SignPendingSpend signs the transaction of a pending spend which is approved by checker.
+// checkerSig is the signature of the PendingSpendApprove hash by the key of checker, and creatorSig
+// the signature of the PendingSpendCreate hash by the key of the creator of the pending spend.
+// The checker can't be the creator, nor have the same key.
+// An expired pending spend is removed.
+// The pending spend is not removed, the caller must remove it with RemovePendingSpendTx once the transaction is injected.
+func (vs *Visor) SignPendingSpend(id cipher.SHA256, checker string, checkerSig, creatorSig cipher.Sig, password []byte) (*coin.Transaction, []TransactionInput, error) {
+	if checker == "" {
+		return nil, nil, ErrPendingSpendCheckerRequired
+	}
+
+	var ps *PendingSpend
+	if err := vs.db.View("SignPendingSpend", func(tx *dbutil.Tx) error {
+		var err error
+		ps, err = getPendingSpend(tx, id)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if ps.Expired(time.Now()) {
+		if err := vs.db.Update("SignPendingSpend", func(tx *dbutil.Tx) error {
+			return vs.RemovePendingSpendTx(tx, id)
+		}); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrPendingSpendExpired
+	}
+
+	if err := vs.verifyPendingSpendSig(checker, PendingSpendApprove, id, checkerSig); err != nil {
+		return nil, nil, err
+	}
+
+	if ps.Creator == checker || vs.Config.PendingSpendKeys[ps.Creator] == vs.Config.PendingSpendKeys[checker] {
+		return nil, nil, ErrPendingSpendSelfApproval
+	}
+
+	if err := vs.verifyPendingSpendSig(ps.Creator, PendingSpendCreate, id, creatorSig); err != nil {
+		return nil, nil, err
+	}
+
+	signedTxn, inputs, err := vs.WalletSignTransaction(ps.WalletID, password, &ps.Transaction, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Infof("Pending spend %s of wallet %s approved by %q", id.Hex(), ps.WalletID, checker)
+
+	return signedTxn, inputs, nil
+}
+
+// verifyPendingSpendSig verifies the signature of an action on a pending spend by the key of a creator or checker
+func (vs *Visor) verifyPendingSpendSig(identity string, action PendingSpendAction, id cipher.SHA256, sig cipher.Sig) error {
+	pubkey, ok := vs.Config.PendingSpendKeys[identity]
+	if !ok {
+		return ErrPendingSpendUnknownIdentity
+	}
+
+	if err := cipher.VerifyPubKeySignedHash(pubkey, sig, PendingSpendHash(action, id)); err != nil {
+		return ErrPendingSpendInvalidSignature
+	}
+
+	return nil
+}
+
+// RemovePendingSpendTx removes a pending spend, if it exists.
+// This is exported for use by the daemon's ApprovePendingSpend method.
+func (vs *Visor) RemovePendingSpendTx(tx *dbutil.Tx, id cipher.SHA256) error {
+	return dbutil.Delete(tx, PendingSpendsBkt, []byte(id.Hex()))
+}
+
+// addPendingSpend adds a pending spend to the bucket, and removes the pending spends expired at now
+func addPendingSpend(tx *dbutil.Tx, ps *PendingSpend, now time.Time) error {
+	var expired []cipher.SHA256
+	if err := forEachPendingSpend(tx, func(p PendingSpend) error {
+		if p.Expired(now) {
+			expired = append(expired, p.ID())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, id := range expired {
+		if err := dbutil.Delete(tx, PendingSpendsBkt, []byte(id.Hex())); err != nil {
+			return err
+		}
+	}
+
+	key := []byte(ps.ID().Hex())
+	if ok, err := dbutil.BucketHasKey(tx, PendingSpendsBkt, key); err != nil {
+		return err
+	} else if ok {
+		return ErrPendingSpendExists
+	}
+
+	b, err := json.Marshal(ps)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, PendingSpendsBkt, key, b)
+}
+
+func getPendingSpend(tx *dbutil.Tx, id cipher.SHA256) (*PendingSpend, error) {
+	var ps PendingSpend
+	if ok, err := dbutil.GetBucketObjectJSON(tx, PendingSpendsBkt, []byte(id.Hex()), &ps); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrPendingSpendNotExist
+	}
+
+	return &ps, nil
+}
+
+func forEachPendingSpend(tx *dbutil.Tx, f func(PendingSpend) error) error {
+	// The bucket is missing from a database opened read-only before it was added
+	if !dbutil.Exists(tx, PendingSpendsBkt) {
+		return nil
+	}
+
+	return dbutil.ForEach(tx, PendingSpendsBkt, func(_, v []byte) error {
+		var ps PendingSpend
+		if err := json.Unmarshal(v, &ps); err != nil {
+			return err
+		}
+		return f(ps)
+	})
+}
@@ -0,0 +1,252 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+func makePendingSpend(t *testing.T, wltID, creator string, createdAt time.Time) *PendingSpend {
+	txn := coin.Transaction{
+		In: []cipher.SHA256{testutil.RandSHA256(t)},
+		Out: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   10,
+			},
+		},
+	}
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	require.NoError(t, txn.UpdateHeader())
+
+	return &PendingSpend{
+		WalletID:    wltID,
+		Creator:     creator,
+		Transaction: txn,
+		Inputs: []TransactionInput{
+			{
+				UxOut: coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: testutil.RandSHA256(t),
+						Address:        testutil.MakeAddress(),
+						Coins:          1e6,
+						Hours:          20,
+					},
+				},
+				CalculatedHours: 20,
+			},
+		},
+		CreatedAt: createdAt.UTC(),
+		ExpiresAt: createdAt.Add(time.Hour).UTC(),
+	}
+}
+
+// pendingSpendKeys registers the keys of alice, bob and carol in the config of a visor
+func pendingSpendKeys(v *Visor) map[string]cipher.SecKey {
+	seckeys := make(map[string]cipher.SecKey)
+	v.Config.PendingSpendKeys = make(map[string]cipher.PubKey)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		pubkey, seckey := cipher.GenerateKeyPair()
+		v.Config.PendingSpendKeys[name] = pubkey
+		seckeys[name] = seckey
+	}
+	return seckeys
+}
+
+func signPendingSpend(t *testing.T, seckey cipher.SecKey, action PendingSpendAction, id cipher.SHA256) cipher.Sig {
+	sig, err := cipher.SignHash(PendingSpendHash(action, id), seckey)
+	require.NoError(t, err)
+	return sig
+}
+
+func TestPendingSpends(t *testing.T) {
+	db, shutdown := testutil.PrepareDB(t)
+	defer shutdown()
+
+	require.NoError(t, CreateBuckets(db))
+
+	v := &Visor{
+		Config: NewConfig(),
+		db:     db,
+	}
+	keys := pendingSpendKeys(v)
+
+	now := time.Now()
+	expired := makePendingSpend(t, "a.wlt", "alice", now.Add(-2*time.Hour))
+	ps1 := makePendingSpend(t, "a.wlt", "alice", now.Add(-time.Minute))
+	ps2 := makePendingSpend(t, "b.wlt", "bob", now.Add(-2*time.Minute))
+
+	// Adding a pending spend removes the expired ones, so the expired one is added last
+	for _, ps := range []*PendingSpend{ps1, ps2, expired} {
+		err := db.Update("", func(tx *dbutil.Tx) error {
+			return addPendingSpend(tx, ps, ps.CreatedAt)
+		})
+		require.NoError(t, err)
+	}
+
+	// The same transaction can't be pending twice
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		return addPendingSpend(tx, ps1, now)
+	})
+	require.Equal(t, ErrPendingSpendExists, err)
+
+	// Expired pending spends are not listed
+	spends, err := v.GetPendingSpends("")
+	require.NoError(t, err)
+	require.Equal(t, []PendingSpend{*ps2, *ps1}, spends)
+
+	spends, err = v.GetPendingSpends("a.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []PendingSpend{*ps1}, spends)
+
+	spends, err = v.GetPendingSpends("c.wlt")
+	require.NoError(t, err)
+	require.Empty(t, spends)
+
+	// The expired pending spend is removed when another pending spend is added
+	err = db.View("", func(tx *dbutil.Tx) error {
+		_, err := getPendingSpend(tx, expired.ID())
+		return err
+	})
+	require.NoError(t, err)
+
+	ps3 := makePendingSpend(t, "a.wlt", "carol", now)
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return addPendingSpend(tx, ps3, now)
+	})
+	require.NoError(t, err)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		_, err := getPendingSpend(tx, expired.ID())
+		return err
+	})
+	require.Equal(t, ErrPendingSpendNotExist, err)
+
+	// Rejected pending spends are removed, the rejection must be signed by the checker
+	sig := signPendingSpend(t, keys["alice"], PendingSpendReject, ps3.ID())
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "", sig)
+	require.Equal(t, ErrPendingSpendCheckerRequired, err)
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "dave", sig)
+	require.Equal(t, ErrPendingSpendUnknownIdentity, err)
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "bob", sig)
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "alice", signPendingSpend(t, keys["alice"], PendingSpendApprove, ps3.ID()))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "alice", signPendingSpend(t, keys["alice"], PendingSpendReject, ps2.ID()))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	rejected, err := v.RejectPendingSpend(ps3.ID(), "alice", sig)
+	require.NoError(t, err)
+	require.Equal(t, ps3, rejected)
+
+	_, err = v.RejectPendingSpend(ps3.ID(), "alice", sig)
+	require.Equal(t, ErrPendingSpendNotExist, err)
+
+	spends, err = v.GetPendingSpends("")
+	require.NoError(t, err)
+	require.Equal(t, []PendingSpend{*ps2, *ps1}, spends)
+}
+
+func TestSignPendingSpend(t *testing.T) {
+	db, shutdown := testutil.PrepareDB(t)
+	defer shutdown()
+
+	require.NoError(t, CreateBuckets(db))
+
+	v := &Visor{
+		Config: NewConfig(),
+		db:     db,
+	}
+	keys := pendingSpendKeys(v)
+
+	now := time.Now()
+	ps := makePendingSpend(t, "a.wlt", "alice", now)
+	expired := makePendingSpend(t, "a.wlt", "alice", now.Add(-2*time.Hour))
+
+	err := db.Update("", func(tx *dbutil.Tx) error {
+		if err := addPendingSpend(tx, ps, now); err != nil {
+			return err
+		}
+		return addPendingSpend(tx, expired, expired.CreatedAt)
+	})
+	require.NoError(t, err)
+
+	id := ps.ID()
+	creatorSig := signPendingSpend(t, keys["alice"], PendingSpendCreate, id)
+	checkerSig := signPendingSpend(t, keys["bob"], PendingSpendApprove, id)
+
+	_, _, err = v.SignPendingSpend(id, "", checkerSig, creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendCheckerRequired, err)
+
+	_, _, err = v.SignPendingSpend(testutil.RandSHA256(t), "bob", checkerSig, creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendNotExist, err)
+
+	// The checker must be registered and sign the approval of this pending spend
+	_, _, err = v.SignPendingSpend(id, "dave", checkerSig, creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendUnknownIdentity, err)
+
+	_, _, err = v.SignPendingSpend(id, "carol", checkerSig, creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	_, _, err = v.SignPendingSpend(id, "bob", signPendingSpend(t, keys["bob"], PendingSpendReject, id), creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	// The creator can't approve the pending spend, even under another name with the same key
+	_, _, err = v.SignPendingSpend(id, "alice", signPendingSpend(t, keys["alice"], PendingSpendApprove, id), creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendSelfApproval, err)
+
+	v.Config.PendingSpendKeys["alice2"] = v.Config.PendingSpendKeys["alice"]
+	_, _, err = v.SignPendingSpend(id, "alice2", signPendingSpend(t, keys["alice"], PendingSpendApprove, id), creatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendSelfApproval, err)
+
+	// The creator must sign the pending spend it created, so that a checker can't create and approve a spend alone
+	_, _, err = v.SignPendingSpend(id, "bob", checkerSig, signPendingSpend(t, keys["bob"], PendingSpendCreate, id), []byte("pwd"))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	_, _, err = v.SignPendingSpend(id, "bob", checkerSig, signPendingSpend(t, keys["alice"], PendingSpendApprove, id), []byte("pwd"))
+	require.Equal(t, ErrPendingSpendInvalidSignature, err)
+
+	// An expired pending spend can't be approved, and is removed
+	expiredCreatorSig := signPendingSpend(t, keys["alice"], PendingSpendCreate, expired.ID())
+	expiredCheckerSig := signPendingSpend(t, keys["bob"], PendingSpendApprove, expired.ID())
+	_, _, err = v.SignPendingSpend(expired.ID(), "bob", expiredCheckerSig, expiredCreatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendExpired, err)
+
+	_, _, err = v.SignPendingSpend(expired.ID(), "bob", expiredCheckerSig, expiredCreatorSig, []byte("pwd"))
+	require.Equal(t, ErrPendingSpendNotExist, err)
+
+	// The pending spend is kept until its transaction is injected
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		return v.RemovePendingSpendTx(tx, ps.ID())
+	})
+	require.NoError(t, err)
+
+	spends, err := v.GetPendingSpends("")
+	require.NoError(t, err)
+	require.Empty(t, spends)
+}
+
+func TestCreatePendingSpendCreatorRequired(t *testing.T) {
+	v := &Visor{
+		Config: NewConfig(),
+	}
+	_, err := v.CreatePendingSpend("a.wlt", "", transaction.Params{}, CreateTransactionParams{})
+	require.Equal(t, ErrPendingSpendCreatorRequired, err)
+
+	// The creator must have a registered key
+	_, err = v.CreatePendingSpend("a.wlt", "alice", transaction.Params{}, CreateTransactionParams{})
+	require.Equal(t, ErrPendingSpendUnknownIdentity, err)
+}
@@ -0,0 +1,232 @@
+package visor
+
+// This file contains the replacement of unconfirmed transactions by fee.
+//
+// Replace-by-fee is enabled per node, and each transaction opts in to it by signalling it with the coin hours
+// of its last output, see coin.Transaction.IsReplaceable. When it is enabled, a transaction which spends any of
+// the inputs of transactions in the unconfirmed pool replaces them if they all signal replace-by-fee and it burns
+// more coin hours, otherwise it is rejected. The replacement doesn't have to signal replace-by-fee itself.
+// To prevent peers from flooding the network with cheap replacements, a replacement must:
+//
+//   - be valid, a transaction which only satisfies the hard constraints can't replace other transactions
+//   - replace at most MaxReplacedTransactions transactions
+//   - burn at least as many coin hours per byte as each of the conflicting transactions
+//   - burn the coin hours of all of the replaced transactions, plus Config.ReplaceByFeeIncrement coin hours
+//     per KB of its own size, and at least one more coin hour
+//   - not spend the outputs of the replaced transactions
+//
+// The transactions spending the outputs of the conflicting transactions are replaced too.
+// The replaced transactions are recorded as evicted.
+// When replace-by-fee is disabled, or when any of the conflicting transactions doesn't signal replace-by-fee,
+// conflicting transactions are kept in the pool and only one of them can be included in a block. Receivers of
+// transactions which don't signal replace-by-fee can rely on them not being replaced.
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/util/mathutil"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+// MaxReplacedTransactions is the maximum number of unconfirmed transactions replaced by a transaction
+const MaxReplacedTransactions = 100
+
+// ErrReplaceByFeeDisabled is returned when bumping the fee of a transaction if replace-by-fee is disabled
+var ErrReplaceByFeeDisabled = NewUserError(errors.New("Replace-by-fee is disabled"))
+
+// replacementFee returns the minimum coin hours that a transaction of size bytes must burn
+// to replace transactions burning replacedFee coin hours
+func replacementFee(replacedFee uint64, size uint32, increment uint64) (uint64, error) {
+	kb := (uint64(size) + 1023) / 1024
+	extra, err := mathutil.MultUint64(increment, kb)
+	if err != nil {
+		return 0, err
+	}
+	if extra == 0 {
+		extra = 1
+	}
+
+	return mathutil.AddUint64(replacedFee, extra)
+}
+
+// Replace removes the transactions in the pool which spend any of the inputs of txn, and the transactions
+// spending their outputs, if txn satisfies the replace-by-fee rules. txn must not be in the pool.
+// If any of the transactions spending the inputs of txn doesn't signal replace-by-fee, nothing is replaced.
+// If txn doesn't satisfy the rules, an ErrTxnViolatesSoftConstraint is returned and the pool is not changed.
+// The replaced transactions are recorded as evicted and returned.
+func (utp *UnconfirmedTransactionPool) Replace(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, increment uint64, now time.Time) ([]EvictedTransaction, error) {
+	conflicts, err := utp.GetConflicts(tx, txn)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+
+	for _, utxn := range conflicts {
+		if !utxn.Transaction.IsReplaceable() {
+			return nil, nil
+		}
+	}
+
+	conflictHashes := make([]cipher.SHA256, len(conflicts))
+	for i, utxn := range conflicts {
+		conflictHashes[i] = utxn.Transaction.Hash()
+	}
+
+	descendants, err := utp.GetDescendants(tx, conflictHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conflicts)+len(descendants) > MaxReplacedTransactions {
+		return nil, NewErrTxnViolatesSoftConstraint(fmt.Errorf("Transaction replaces more than %d unconfirmed transactions", MaxReplacedTransactions))
+	}
+
+	head, _, err := utp.VerifyTransaction(tx, bc, txn, verifyParams, TxnSigned)
+	if err != nil {
+		return nil, err
+	}
+	feeCalc := utp.TransactionFee(tx, bc, head.Head)
+
+	size, hash, err := txn.SizeHash()
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := feeCalc(&txn)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := &BlockCandidate{
+		Hash: hash,
+		Size: size,
+		Fee:  fee,
+	}
+
+	var replacedFee uint64
+	replaced := make([]EvictedTransaction, 0, len(conflicts)+len(descendants))
+	replacedSet := make(map[cipher.SHA256]struct{}, len(conflicts)+len(descendants))
+	for i, utxn := range append(conflicts, descendants...) {
+		h := utxn.Transaction.Hash()
+
+		s, err := utxn.Transaction.Size()
+		if err != nil {
+			return nil, err
+		}
+
+		// The fee of a transaction whose inputs are no longer unspent can't be computed, it is replaced by any valid transaction
+		f, err := feeCalc(&utxn.Transaction)
+		if err != nil {
+			f = 0
+		}
+
+		reason := EvictionReasonParentEvicted
+		if i < len(conflicts) {
+			reason = EvictionReasonReplaced
+
+			if FeeRateTxnOrdering.Less(&BlockCandidate{
+				Hash: h,
+				Size: s,
+				Fee:  f,
+			}, replacement) {
+				return nil, NewErrTxnViolatesSoftConstraint(fmt.Errorf("Transaction burns fewer coin hours per byte than the unconfirmed transaction %s it replaces", h.Hex()))
+			}
+		}
+
+		replacedFee, err = mathutil.AddUint64(replacedFee, f)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := utp.meta.get(tx, h)
+		if err != nil {
+			return nil, err
+		}
+		added := utxn.Received
+		if m != nil {
+			added = m.Added
+		}
+
+		replaced = append(replaced, EvictedTransaction{
+			Hash:    h,
+			Reason:  reason,
+			Evicted: now.UnixNano(),
+			Added:   added,
+			Size:    s,
+			Fee:     f,
+		})
+		replacedSet[h] = struct{}{}
+	}
+
+	// The replacement can't depend on the transactions it replaces
+	for _, h := range txn.In {
+		if creator, err := utp.outputs.get(tx, h); err != nil {
+			return nil, err
+		} else if creator != nil {
+			if _, ok := replacedSet[*creator]; ok {
+				return nil, NewErrTxnViolatesSoftConstraint(fmt.Errorf("Transaction spends an output of the unconfirmed transaction %s it replaces", creator.Hex()))
+			}
+		}
+	}
+
+	minFee, err := replacementFee(replacedFee, size, increment)
+	if err != nil {
+		return nil, err
+	}
+
+	if fee < minFee {
+		return nil, NewErrTxnViolatesSoftConstraint(fmt.Errorf("Transaction burns %d coin hours, at least %d coin hours must be burned to replace %d unconfirmed transactions", fee, minFee, len(replaced)))
+	}
+
+	if err := utp.RemoveEvicted(tx, replaced); err != nil {
+		return nil, err
+	}
+
+	return replaced, nil
+}
+
+// replaceUnconfirmed removes the unconfirmed transactions replaced by txn, if replace-by-fee is enabled
+// and the transactions signal it.
+// Returns an error if txn conflicts with transactions in the pool and doesn't replace them.
+func (vs *Visor) replaceUnconfirmed(tx *dbutil.Tx, txn coin.Transaction, verifyParams params.VerifyTxn) error {
+	if !vs.Config.ReplaceByFee {
+		return nil
+	}
+
+	hash := txn.Hash()
+	known, err := vs.unconfirmed.Get(tx, hash)
+	if err != nil {
+		return err
+	}
+	if known != nil {
+		return nil
+	}
+
+	replaced, err := vs.unconfirmed.Replace(tx, vs.blockchain, txn, verifyParams, vs.Config.ReplaceByFeeIncrement, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if len(replaced) == 0 {
+		return nil
+	}
+
+	hashes := make([]cipher.SHA256, len(replaced))
+	for i, e := range replaced {
+		hashes[i] = e.Hash
+	}
+
+	vs.balances.removeTransactions(tx, hashes)
+
+	logger.Infof("Transaction %s replaced %d unconfirmed transactions", hash.Hex(), len(replaced))
+
+	return nil
+}
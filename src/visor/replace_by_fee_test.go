@@ -0,0 +1,415 @@
+package visor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+func TestReplacementFee(t *testing.T) {
+	cases := []struct {
+		replacedFee uint64
+		size        uint32
+		increment   uint64
+		fee         uint64
+	}{
+		{100, 200, 10, 110},
+		{100, 1024, 10, 110},
+		{100, 1025, 10, 120},
+		{100, 200, 0, 101},
+	}
+
+	for _, tc := range cases {
+		fee, err := replacementFee(tc.replacedFee, tc.size, tc.increment)
+		require.NoError(t, err)
+		require.Equal(t, tc.fee, fee)
+	}
+
+	_, err := replacementFee(1<<64-1, 200, 10)
+	testutil.RequireError(t, err, "uint64 addition overflow")
+}
+
+// makeReplaceableSpendTxn creates a transaction spending ux which burns fee coin hours, like makeSpendTxWithFee.
+// It signals replace-by-fee with a change output of coin.ReplaceableHoursRemainder coin hours.
+func makeReplaceableSpendTxn(t *testing.T, ux coin.UxOut, keys []cipher.SecKey, toAddr cipher.Address, coins, fee uint64) coin.Transaction {
+	require.True(t, coins < ux.Body.Coins)
+	require.True(t, fee+coin.ReplaceableHoursRemainder <= ux.Body.Hours/2)
+
+	txn := coin.Transaction{}
+	err := txn.PushInput(ux.Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(toAddr, coins, ux.Body.Hours/2-fee-coin.ReplaceableHoursRemainder)
+	require.NoError(t, err)
+	err = txn.PushOutput(ux.Body.Address, ux.Body.Coins-coins, coin.ReplaceableHoursRemainder)
+	require.NoError(t, err)
+	require.True(t, txn.IsReplaceable())
+	txn.SignInputs(keys)
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+	return txn
+}
+
+func TestReplaceByFee(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 3)
+
+	keys := []cipher.SecKey{genSecret}
+
+	// Without replace-by-fee, conflicting transactions are kept in the pool, even if they signal replace-by-fee
+	txnA0 := makeReplaceableSpendTxn(t, uxs[0], keys, testutil.MakeAddress(), 1e6, 1)
+	txnB0 := makeReplaceableSpendTxn(t, uxs[0], keys, testutil.MakeAddress(), 1e6, 100)
+	_, _, err = v.InjectForeignTransaction(txnA0)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txnB0)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0)
+
+	v.Config.ReplaceByFee = true
+
+	txnA1 := makeReplaceableSpendTxn(t, uxs[1], keys, testutil.MakeAddress(), 1e6, 1)
+	_, _, err = v.InjectForeignTransaction(txnA1)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnA1)
+
+	// A conflicting transaction which doesn't burn enough additional coin hours is rejected
+	txnC1 := makeSpendTxWithFee(t, uxs[1:2], keys, testutil.MakeAddress(), 1e6, 5)
+	_, _, err = v.InjectForeignTransaction(txnC1)
+	require.Error(t, err)
+	require.IsType(t, ErrTxnViolatesSoftConstraint{}, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnA1)
+	requireEvicted(t, v, nil)
+
+	// A conflicting transaction which burns enough additional coin hours replaces it
+	txnB1 := makeReplaceableSpendTxn(t, uxs[1], keys, testutil.MakeAddress(), 1e6, 100)
+	_, _, _, err = v.InjectUserTransaction(txnB1)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnB1)
+	requireEvicted(t, v, map[cipher.SHA256]EvictionReason{
+		txnA1.Hash(): EvictionReasonReplaced,
+	}, txnA1.Hash())
+
+	// The replaced transaction is rejected if it is received again
+	_, _, err = v.InjectForeignTransaction(txnA1)
+	require.Error(t, err)
+	require.IsType(t, ErrTxnViolatesSoftConstraint{}, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnB1)
+
+	// A replacement must burn the coin hours of all of the transactions it replaces
+	txnC0 := makeSpendTxWithFee(t, uxs[0:1], keys, testutil.MakeAddress(), 1e6, uxs[0].Body.Hours/2)
+	_, _, err = v.InjectForeignTransaction(txnC0)
+	require.Error(t, err)
+	require.IsType(t, ErrTxnViolatesSoftConstraint{}, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnB1)
+
+	// A transaction which doesn't signal replace-by-fee is not replaced,
+	// the conflicting transactions are kept in the pool as if replace-by-fee was disabled
+	txnA2 := makeSpendTxWithFee(t, uxs[2:3], keys, testutil.MakeAddress(), 1e6, 1)
+	require.False(t, txnA2.IsReplaceable())
+	txnB2 := makeSpendTxWithFee(t, uxs[2:3], keys, testutil.MakeAddress(), 1e6, 100)
+	_, _, err = v.InjectForeignTransaction(txnA2)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(txnB2)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnA0, txnB0, txnB1, txnA2, txnB2)
+	requireEvicted(t, v, map[cipher.SHA256]EvictionReason{
+		txnA1.Hash(): EvictionReasonReplaced,
+	}, txnA1.Hash())
+}
+
+func TestWalletBumpFee(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	wltID := "foo.wlt"
+	_, err = ws.CreateWallet(wltID, wallet.Options{
+		Coin: wallet.CoinTypeMDL,
+		Type: wallet.WalletTypeCollection,
+	}, nil)
+	require.NoError(t, err)
+	walletPubkey, walletSecret := cipher.GenerateKeyPair()
+	walletAddr := cipher.AddressFromPubKey(walletPubkey)
+	_, err = ws.ImportSecretKeys(wltID, nil, []cipher.SecKey{genSecret, walletSecret})
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 4)
+
+	// The transaction sends coin hours back to the wallet in its change output, which signals replace-by-fee
+	toAddr := testutil.MakeAddress()
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(toAddr, 1e6, 10)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-1e6, uxs[0].Body.Hours/2-100)
+	require.NoError(t, err)
+	err = txn.SignalReplaceable()
+	require.NoError(t, err)
+	txn.SignInputs([]cipher.SecKey{genSecret})
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, err = v.WalletBumpFee(wltID, nil, txn.Hash(), 0)
+	require.Equal(t, ErrReplaceByFeeDisabled, err)
+
+	v.Config.ReplaceByFee = true
+
+	_, _, err = v.WalletBumpFee(wltID, nil, txn.Hash(), 0)
+	require.Equal(t, ErrTxnNotUnconfirmed, err)
+
+	_, _, _, err = v.InjectUserTransaction(txn)
+	require.NoError(t, err)
+
+	oldFee := uxs[0].Body.Hours - 10 - txn.Out[1].Hours
+	minFee := oldFee + v.Config.ReplaceByFeeIncrement
+
+	_, _, err = v.WalletBumpFee(wltID, nil, txn.Hash(), minFee-1)
+	require.IsType(t, UserError{}, err)
+
+	_, _, err = v.WalletBumpFee(wltID, nil, txn.Hash(), oldFee+txn.Out[1].Hours+1)
+	require.Equal(t, ErrBumpFeeInsufficientHours, err)
+
+	// The minimum replacement fee is taken from the change output,
+	// then its coin hours are reduced further so that the replacement signals replace-by-fee too
+	bumped, inputs, err := v.WalletBumpFee(wltID, nil, txn.Hash(), 0)
+	require.NoError(t, err)
+	require.Len(t, inputs, 1)
+	require.Equal(t, txn.In, bumped.In)
+	require.Equal(t, txn.Out[0], bumped.Out[0])
+	require.Equal(t, txn.Out[1].Hours-coin.ReplaceableHoursDivisor, bumped.Out[1].Hours)
+	require.True(t, bumped.IsReplaceable())
+	require.True(t, bumped.IsFullySigned())
+	require.NoError(t, bumped.Verify())
+
+	// The original transaction is not changed until the replacement is injected
+	requireUnconfirmedPool(t, v, txn)
+
+	_, _, _, err = v.InjectUserTransaction(*bumped)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, *bumped)
+	requireEvicted(t, v, map[cipher.SHA256]EvictionReason{
+		txn.Hash(): EvictionReasonReplaced,
+	}, txn.Hash())
+
+	// The fee can be bumped again
+	bumped2, _, err := v.WalletBumpFee(wltID, nil, bumped.Hash(), 0)
+	require.NoError(t, err)
+	require.Equal(t, txn.Out[1].Hours-2*coin.ReplaceableHoursDivisor, bumped2.Out[1].Hours)
+	require.True(t, bumped2.IsReplaceable())
+
+	// The fee is taken from the change output before the outputs to other addresses of the wallet,
+	// even if the recipient is an address of the wallet and its output comes last
+	toWallet := coin.Transaction{}
+	err = toWallet.PushInput(uxs[2].Hash())
+	require.NoError(t, err)
+	err = toWallet.PushOutput(genAddress, uxs[2].Body.Coins-1e6, 20)
+	require.NoError(t, err)
+	err = toWallet.PushOutput(walletAddr, 1e6, uxs[2].Body.Hours/2-100)
+	require.NoError(t, err)
+	err = toWallet.SignalReplaceable()
+	require.NoError(t, err)
+	toWallet.SignInputs([]cipher.SecKey{genSecret})
+	err = toWallet.UpdateHeader()
+	require.NoError(t, err)
+
+	_, _, _, err = v.InjectUserTransaction(toWallet)
+	require.NoError(t, err)
+
+	toWalletFee := uxs[2].Body.Hours - 20 - toWallet.Out[1].Hours
+
+	bumped3, _, err := v.WalletBumpFee(wltID, nil, toWallet.Hash(), toWalletFee+15)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), bumped3.Out[0].Hours)
+	require.Equal(t, toWallet.Out[1], bumped3.Out[1])
+
+	// The other outputs to the wallet are reduced only once the change output has no coin hours left.
+	// The last output signals replace-by-fee again once its coin hours are reduced.
+	bumped4, _, err := v.WalletBumpFee(wltID, nil, toWallet.Hash(), toWalletFee+50)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), bumped4.Out[0].Hours)
+	require.Equal(t, toWallet.Out[1].Hours-coin.ReplaceableHoursDivisor, bumped4.Out[1].Hours)
+	require.True(t, bumped4.IsReplaceable())
+
+	// The outputs to the wallet must have enough coin hours
+	lowChange := makeReplaceableSpendTxn(t, uxs[1], []cipher.SecKey{genSecret}, toAddr, 1e6, 1)
+	_, _, _, err = v.InjectUserTransaction(lowChange)
+	require.NoError(t, err)
+	_, _, err = v.WalletBumpFee(wltID, nil, lowChange.Hash(), 0)
+	require.Equal(t, ErrBumpFeeInsufficientHours, err)
+
+	// The fee of a transaction which doesn't signal replace-by-fee can't be bumped
+	notReplaceable := makeSpendTxWithFee(t, uxs[3:4], []cipher.SecKey{genSecret}, toAddr, 1e6, 1)
+	_, _, _, err = v.InjectUserTransaction(notReplaceable)
+	require.NoError(t, err)
+	_, _, err = v.WalletBumpFee(wltID, nil, notReplaceable.Hash(), 0)
+	require.Equal(t, ErrTxnNotReplaceable, err)
+}
+
+func TestWalletBumpFeeSpendingPolicy(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	wltID := "foo.wlt"
+	password := []byte("pwd")
+	_, err = ws.CreateWallet(wltID, wallet.Options{
+		Coin:     wallet.CoinTypeMDL,
+		Type:     wallet.WalletTypeCollection,
+		Encrypt:  true,
+		Password: password,
+	}, nil)
+	require.NoError(t, err)
+	_, err = ws.ImportSecretKeys(wltID, password, []cipher.SecKey{genSecret})
+	require.NoError(t, err)
+	_, err = ws.SetSpendingPolicy(wltID, password, wallet.SpendingPolicy{
+		MaxCoinsPerDay: 15e5,
+	})
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+	cfg.ReplaceByFee = true
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 2)
+
+	requireSpentToday := func(coins uint64) {
+		w, err := ws.GetWallet(wltID)
+		require.NoError(t, err)
+		require.Equal(t, coins, w.SpentToday(time.Now()))
+	}
+
+	toAddr := testutil.MakeAddress()
+	txn := coin.Transaction{}
+	err = txn.PushInput(uxs[0].Hash())
+	require.NoError(t, err)
+	err = txn.PushOutput(toAddr, 1e6, 10)
+	require.NoError(t, err)
+	err = txn.PushOutput(genAddress, uxs[0].Body.Coins-1e6, uxs[0].Body.Hours/2-100)
+	require.NoError(t, err)
+	err = txn.SignalReplaceable()
+	require.NoError(t, err)
+	txn.Sigs = make([]cipher.Sig, len(txn.In))
+	err = txn.UpdateHeader()
+	require.NoError(t, err)
+
+	// Signing a transaction doesn't count towards the daily limit
+	signed, _, err := v.WalletSignTransaction(wltID, password, &txn, nil)
+	require.NoError(t, err)
+	requireSpentToday(0)
+
+	// Injecting it does
+	_, _, _, err = v.InjectUserTransaction(*signed)
+	require.NoError(t, err)
+	requireSpentToday(1e6)
+
+	// The replacement counts once, instead of adding to the replaced transaction
+	bumped, _, err := v.WalletBumpFee(wltID, password, signed.Hash(), 0)
+	require.NoError(t, err)
+	_, _, _, err = v.InjectUserTransaction(*bumped)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, *bumped)
+	requireSpentToday(1e6)
+
+	// A transaction signed elsewhere is refused when it is injected
+	other := makeSpendTxWithFee(t, uxs[1:2], []cipher.SecKey{genSecret}, toAddr, 1e6, 1)
+	_, _, _, err = v.InjectUserTransaction(other)
+	require.Error(t, err)
+	require.Equal(t, wallet.RuleMaxCoinsPerDay, err.(wallet.PolicyViolationError).Rule)
+	requireUnconfirmedPool(t, v, *bumped)
+	requireSpentToday(1e6)
+}
@@ -174,6 +174,12 @@ type UnconfirmedTransactionPool struct {
 	// our future balance and avoid double spending our own coins
 	// Maps from Transaction.Hash() to UxArray.
 	unspent *txnUnspents
+	// Time each txn was first added, and whether it is protected from eviction
+	meta *unconfirmedMetas
+	// Records of the recently evicted txns
+	evicted *evictedTxns
+	// Maps the outputs of txns to the txns creating them, to find the txns spent by other txns
+	outputs *unconfirmedOutputs
 }
 
 // NewUnconfirmedTransactionPool creates an UnconfirmedTransactionPool instance
@@ -190,11 +196,22 @@ func NewUnconfirmedTransactionPool(db *dbutil.DB) (*UnconfirmedTransactionPool,
 		return nil, err
 	}
 
-	return &UnconfirmedTransactionPool{
+	utp := &UnconfirmedTransactionPool{
 		db:      db,
 		txns:    &unconfirmedTxns{},
 		unspent: &txnUnspents{},
-	}, nil
+		meta:    &unconfirmedMetas{},
+		evicted: &evictedTxns{},
+		outputs: &unconfirmedOutputs{},
+	}
+
+	if !db.IsReadOnly() {
+		if err := db.Update("Index unconfirmed txn outputs", utp.maybeIndexOutputs); err != nil {
+			return nil, err
+		}
+	}
+
+	return utp, nil
 }
 
 // SetTransactionsAnnounced updates announced time of specific tx
@@ -231,11 +248,12 @@ func (utp *UnconfirmedTransactionPool) SetTransactionsAnnounced(tx *dbutil.Tx, h
 // existed in the pool.
 // If the transaction violates hard constraints, it is rejected.
 // Soft constraints violations mark a txn as invalid, but the txn is inserted. The soft violation is returned.
+// The txn can spend the outputs of other txns in the pool.
 func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn) (bool, *ErrTxnViolatesSoftConstraint, error) {
 	var isValid int8 = 1
 	var softErr *ErrTxnViolatesSoftConstraint
-	if _, _, err := bc.VerifySingleTxnSoftHardConstraints(tx, txn, verifyParams, TxnSigned); err != nil {
-		logger.Warningf("bc.VerifySingleTxnSoftHardConstraints failed for txn %s: %v", txn.Hash().Hex(), err)
+	if _, _, err := utp.VerifyTransaction(tx, bc, txn, verifyParams, TxnSigned); err != nil {
+		logger.Warningf("VerifyTransaction failed for txn %s: %v", txn.Hash().Hex(), err)
 		switch e := err.(type) {
 		case ErrTxnViolatesSoftConstraint:
 			softErr = &e
@@ -279,12 +297,29 @@ func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Block
 		return false, nil, err
 	}
 
+	// The txn is no longer evicted if it is received again
+	if err := utp.evicted.delete(tx, hash); err != nil {
+		logger.Errorf("InjectTransaction delete eviction record failed: %v", err)
+		return false, nil, err
+	}
+
 	head, err := bc.Head(tx)
 	if err != nil {
 		logger.Errorf("InjectTransaction bc.Head() failed: %v", err)
 		return false, nil, err
 	}
 
+	meta, err := utp.newMeta(tx, txn, utx.Received, utp.TransactionFee(tx, bc, head.Head))
+	if err != nil {
+		logger.Errorf("InjectTransaction new unconfirmed txn metadata failed: %v", err)
+		return false, nil, err
+	}
+
+	if err := utp.meta.put(tx, hash, meta); err != nil {
+		logger.Errorf("InjectTransaction put new unconfirmed txn metadata failed: %v", err)
+		return false, nil, err
+	}
+
 	// update unconfirmed unspent
 	createdUnspents := coin.CreateUnspents(head.Head, txn)
 	if err := utp.unspent.put(tx, hash, createdUnspents); err != nil {
@@ -292,6 +327,11 @@ func (utp *UnconfirmedTransactionPool) InjectTransaction(tx *dbutil.Tx, bc Block
 		return false, nil, err
 	}
 
+	if err := utp.indexOutputs(tx, hash, txn); err != nil {
+		logger.Errorf("InjectTransaction index new unspent outputs: %v", err)
+		return false, nil, err
+	}
+
 	return false, softErr, nil
 }
 
@@ -311,14 +351,33 @@ func (utp *UnconfirmedTransactionPool) AllRawTransactions(tx *dbutil.Tx) (coin.T
 
 // Remove a single txn by hash
 func (utp *UnconfirmedTransactionPool) removeTransaction(tx *dbutil.Tx, txHash cipher.SHA256) error {
+	utxn, err := utp.txns.get(tx, txHash)
+	if err != nil {
+		return err
+	}
+
+	if utxn != nil {
+		for _, o := range utxn.Transaction.Out {
+			if err := utp.outputs.delete(tx, o.UxID(txHash)); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err := utp.txns.delete(tx, txHash); err != nil {
 		return err
 	}
 
+	if err := utp.meta.delete(tx, txHash); err != nil {
+		return err
+	}
+
 	return utp.unspent.delete(tx, txHash)
 }
 
-// RemoveTransactions remove transactions with dbutil.Tx
+// RemoveTransactions remove transactions with dbutil.Tx.
+// The transactions spending their outputs are not removed, they spend confirmed outputs
+// once the removed transactions are included in a block.
 func (utp *UnconfirmedTransactionPool) RemoveTransactions(tx *dbutil.Tx, txHashes []cipher.SHA256) error {
 	for i := range txHashes {
 		if err := utp.removeTransaction(tx, txHashes[i]); err != nil {
@@ -344,7 +403,7 @@ func (utp *UnconfirmedTransactionPool) Refresh(tx *dbutil.Tx, bc Blockchainer, v
 	for _, utxn := range utxns {
 		utxn.Checked = now.UnixNano()
 
-		_, _, err := bc.VerifySingleTxnSoftHardConstraints(tx, utxn.Transaction, verifyParams, TxnSigned)
+		_, _, err := utp.VerifyTransaction(tx, bc, utxn.Transaction, verifyParams, TxnSigned)
 
 		switch err.(type) {
 		case ErrTxnViolatesSoftConstraint, ErrTxnViolatesHardConstraint:
@@ -367,7 +426,8 @@ func (utp *UnconfirmedTransactionPool) Refresh(tx *dbutil.Tx, bc Blockchainer, v
 }
 
 // RemoveInvalid checks all unconfirmed txns against the blockchain.
-// If a transaction violates hard constraints it is removed from the pool.
+// If a transaction violates hard constraints it is removed from the pool,
+// with the transactions spending its outputs.
 // The transactions that were removed are returned.
 func (utp *UnconfirmedTransactionPool) RemoveInvalid(tx *dbutil.Tx, bc Blockchainer) ([]cipher.SHA256, error) {
 	var removeUtxns []cipher.SHA256
@@ -378,7 +438,7 @@ func (utp *UnconfirmedTransactionPool) RemoveInvalid(tx *dbutil.Tx, bc Blockchai
 	}
 
 	for _, utxn := range utxns {
-		err := bc.VerifySingleTxnHardConstraints(tx, utxn.Transaction, TxnSigned)
+		err := utp.verifyHardConstraints(tx, bc, utxn.Transaction, TxnSigned)
 		if err != nil {
 			switch err.(type) {
 			case ErrTxnViolatesHardConstraint:
@@ -389,6 +449,18 @@ func (utp *UnconfirmedTransactionPool) RemoveInvalid(tx *dbutil.Tx, bc Blockchai
 		}
 	}
 
+	if len(removeUtxns) != 0 {
+		g, err := utp.getGraph(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range g.descendants(removeUtxns...) {
+			logger.Infof("Removing txn %s spending the outputs of an invalid txn", h.Hex())
+			removeUtxns = append(removeUtxns, h)
+		}
+	}
+
 	if err := utp.RemoveTransactions(tx, removeUtxns); err != nil {
 		return nil, err
 	}
@@ -483,16 +555,25 @@ func txnOutputsForAddrs(bh coin.BlockHeader, addrs []cipher.Address, txns []coin
 }
 
 // GetIncomingOutputs returns all predicted incoming outputs.
+// The outputs spent by other unconfirmed transactions are excluded.
 func (utp *UnconfirmedTransactionPool) GetIncomingOutputs(tx *dbutil.Tx, bh coin.BlockHeader) (coin.UxArray, error) {
-	var outs coin.UxArray
-
-	if err := utp.txns.forEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
-		outs = append(outs, coin.CreateUnspents(bh, txn.Transaction)...)
-		return nil
-	}); err != nil {
+	txns, err := utp.AllRawTransactions(tx)
+	if err != nil {
 		return nil, err
 	}
 
+	chained := chainedOutputs(txns)
+
+	var outs coin.UxArray
+	for _, txn := range txns {
+		hash := txn.Hash()
+		for i, ux := range coin.CreateUnspents(bh, txn) {
+			if _, ok := chained[txn.Out[i].UxID(hash)]; !ok {
+				outs = append(outs, ux)
+			}
+		}
+	}
+
 	return outs, nil
 }
 
@@ -540,6 +621,35 @@ func (utp *UnconfirmedTransactionPool) ForEach(tx *dbutil.Tx, f func(cipher.SHA2
 	return utp.txns.forEach(tx, f)
 }
 
+// GetConflicts returns the transactions in the pool, other than txn, which spend any of the inputs of txn
+func (utp *UnconfirmedTransactionPool) GetConflicts(tx *dbutil.Tx, txn coin.Transaction) ([]UnconfirmedTransaction, error) {
+	hash := txn.Hash()
+	inputs := make(map[cipher.SHA256]struct{}, len(txn.In))
+	for _, in := range txn.In {
+		inputs[in] = struct{}{}
+	}
+
+	var conflicts []UnconfirmedTransaction
+	if err := utp.txns.forEach(tx, func(h cipher.SHA256, utxn UnconfirmedTransaction) error {
+		if h == hash {
+			return nil
+		}
+
+		for _, in := range utxn.Transaction.In {
+			if _, ok := inputs[in]; ok {
+				conflicts = append(conflicts, utxn)
+				return nil
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
 // GetUnspentsOfAddr returns unspent outputs of given address in unspent tx pool
 func (utp *UnconfirmedTransactionPool) GetUnspentsOfAddr(tx *dbutil.Tx, addr cipher.Address) (coin.UxArray, error) {
 	return utp.unspent.getByAddr(tx, addr)
@@ -0,0 +1,403 @@
+package visor
+
+// This file contains the chains of unconfirmed transactions.
+//
+// A transaction in the unconfirmed pool can spend the outputs created by other transactions in the pool,
+// so that wallets can spend their unconfirmed change. The outputs created by the transactions in the pool
+// are indexed, to find the transaction creating an output.
+//
+// The outputs of unconfirmed transactions are verified as if they were created in the head block,
+// so they don't earn coin hours before they are confirmed. A block can't include a transaction which spends
+// an output created in the same block, so a transaction is included in a block after the blocks including
+// the transactions it depends on.
+//
+// When a transaction is removed from the pool because it is invalid, evicted or replaced, the transactions
+// depending on it are removed too. When it is removed because it is included in a block, the transactions
+// depending on it spend confirmed outputs from then on.
+
+import (
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/util/fee"
+	"github.com/MDLlife/MDL/src/visor/blockdb"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+var (
+	// UnconfirmedOutputsBkt maps the outputs created by unconfirmed transactions to the transactions creating them
+	UnconfirmedOutputsBkt = []byte("unconfirmed_outputs")
+)
+
+// unconfirmedOutputs unconfirmed transaction outputs bucket
+type unconfirmedOutputs struct{}
+
+func (uo *unconfirmedOutputs) get(tx *dbutil.Tx, uxID cipher.SHA256) (*cipher.SHA256, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedOutputsBkt, []byte(uxID.Hex()))
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, nil
+	}
+
+	hash, err := cipher.SHA256FromBytes(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hash, nil
+}
+
+func (uo *unconfirmedOutputs) put(tx *dbutil.Tx, uxID, txnHash cipher.SHA256) error {
+	return dbutil.PutBucketValue(tx, UnconfirmedOutputsBkt, []byte(uxID.Hex()), txnHash[:])
+}
+
+func (uo *unconfirmedOutputs) delete(tx *dbutil.Tx, uxID cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedOutputsBkt, []byte(uxID.Hex()))
+}
+
+// indexOutputs adds the outputs of an unconfirmed transaction to the outputs index
+func (utp *UnconfirmedTransactionPool) indexOutputs(tx *dbutil.Tx, hash cipher.SHA256, txn coin.Transaction) error {
+	for _, o := range txn.Out {
+		if err := utp.outputs.put(tx, o.UxID(hash), hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maybeIndexOutputs indexes the outputs of the transactions in the pool, if the pool was created
+// before the outputs were indexed
+func (utp *UnconfirmedTransactionPool) maybeIndexOutputs(tx *dbutil.Tx) error {
+	empty, err := dbutil.IsEmpty(tx, UnconfirmedOutputsBkt)
+	if err != nil || !empty {
+		return err
+	}
+
+	return utp.txns.forEach(tx, func(hash cipher.SHA256, utxn UnconfirmedTransaction) error {
+		return utp.indexOutputs(tx, hash, utxn.Transaction)
+	})
+}
+
+// unconfirmedOutput returns the output at index i of an unconfirmed transaction, as if it was created in the block bh
+func unconfirmedOutput(bh coin.BlockHeader, hash cipher.SHA256, txn coin.Transaction, i int) coin.UxOut {
+	return coin.UxOut{
+		Head: coin.UxHead{
+			Time:  bh.Time,
+			BkSeq: bh.BkSeq,
+		},
+		Body: coin.UxBody{
+			SrcTransaction: hash,
+			Address:        txn.Out[i].Address,
+			Coins:          txn.Out[i].Coins,
+			Hours:          txn.Out[i].Hours,
+		},
+	}
+}
+
+// GetOutput returns the output created by a transaction in the pool, as if it was created in the block bh.
+// Returns nil if no transaction in the pool creates the output.
+func (utp *UnconfirmedTransactionPool) GetOutput(tx *dbutil.Tx, bh coin.BlockHeader, uxID cipher.SHA256) (*coin.UxOut, error) {
+	hash, err := utp.outputs.get(tx, uxID)
+	if err != nil || hash == nil {
+		return nil, err
+	}
+
+	utxn, err := utp.txns.get(tx, *hash)
+	if err != nil || utxn == nil {
+		return nil, err
+	}
+
+	for i, o := range utxn.Transaction.Out {
+		if o.UxID(*hash) == uxID {
+			ux := unconfirmedOutput(bh, *hash, utxn.Transaction, i)
+			return &ux, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SpendsUnconfirmed returns true if txn spends outputs created by transactions in the pool
+func (utp *UnconfirmedTransactionPool) SpendsUnconfirmed(tx *dbutil.Tx, txn coin.Transaction) (bool, error) {
+	for _, h := range txn.In {
+		hash, err := utp.outputs.get(tx, h)
+		if err != nil {
+			return false, err
+		}
+		if hash != nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getInputs returns the outputs spent by txn, from the confirmed unspent outputs or from the outputs
+// of the transactions in the pool, created in the block bh.
+// Returns blockdb.ErrUnspentNotExist if an output is not found.
+func (utp *UnconfirmedTransactionPool) getInputs(tx *dbutil.Tx, bc Blockchainer, bh coin.BlockHeader, txn coin.Transaction) (coin.UxArray, error) {
+	uxIn := make(coin.UxArray, len(txn.In))
+	for i, h := range txn.In {
+		ux, err := bc.Unspent().Get(tx, h)
+		if err != nil {
+			return nil, err
+		}
+
+		if ux == nil {
+			ux, err = utp.GetOutput(tx, bh, h)
+			if err != nil {
+				return nil, err
+			}
+			if ux == nil {
+				return nil, blockdb.NewErrUnspentNotExist(h.Hex())
+			}
+		}
+
+		uxIn[i] = *ux
+	}
+
+	return uxIn, nil
+}
+
+// VerifyTransaction checks that txn does not violate hard or soft constraints, like
+// Blockchain.VerifySingleTxnSoftHardConstraints, except that txn can spend the outputs of the transactions in the pool.
+// Hard constraints are checked before soft constraints.
+// Returns the head block and the outputs spent by txn.
+func (utp *UnconfirmedTransactionPool) VerifyTransaction(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, verifyParams params.VerifyTxn, signed TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error) {
+	chained, err := utp.SpendsUnconfirmed(tx, txn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !chained {
+		return bc.VerifySingleTxnSoftHardConstraints(tx, txn, verifyParams, signed)
+	}
+
+	head, uxIn, err := utp.verifyTransactionHardConstraints(tx, bc, txn, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := VerifySingleTxnSoftConstraints(txn, head.Time(), uxIn, verifyParams); err != nil {
+		return nil, nil, err
+	}
+
+	return head, uxIn, nil
+}
+
+// verifyTransactionHardConstraints checks that txn, which spends the outputs of transactions in the pool,
+// does not violate hard constraints. Returns the head block and the outputs spent by txn.
+func (utp *UnconfirmedTransactionPool) verifyTransactionHardConstraints(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, signed TxnSignedFlag) (*coin.SignedBlock, coin.UxArray, error) {
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uxIn, err := utp.getInputs(tx, bc, head.Head, txn)
+	if err != nil {
+		switch err.(type) {
+		case blockdb.ErrUnspentNotExist:
+			return nil, nil, NewErrTxnViolatesHardConstraint(err)
+		default:
+			return nil, nil, err
+		}
+	}
+
+	if err := VerifySingleTxnHardConstraints(txn, head.Head, uxIn, signed); err != nil {
+		return nil, nil, err
+	}
+
+	return head, uxIn, nil
+}
+
+// verifyHardConstraints checks that txn does not violate hard constraints, like Blockchain.VerifySingleTxnHardConstraints,
+// except that txn can spend the outputs of the transactions in the pool
+func (utp *UnconfirmedTransactionPool) verifyHardConstraints(tx *dbutil.Tx, bc Blockchainer, txn coin.Transaction, signed TxnSignedFlag) error {
+	chained, err := utp.SpendsUnconfirmed(tx, txn)
+	if err != nil {
+		return err
+	}
+
+	if !chained {
+		return bc.VerifySingleTxnHardConstraints(tx, txn, signed)
+	}
+
+	_, _, err = utp.verifyTransactionHardConstraints(tx, bc, txn, signed)
+	return err
+}
+
+// TransactionFee returns a coin.FeeCalculator like Blockchain.TransactionFee, which also finds
+// the outputs of the transactions in the pool. The fees are calculated at the time of the block bh.
+func (utp *UnconfirmedTransactionPool) TransactionFee(tx *dbutil.Tx, bc Blockchainer, bh coin.BlockHeader) coin.FeeCalculator {
+	feeCalc := bc.TransactionFee(tx, bh.Time)
+	return func(txn *coin.Transaction) (uint64, error) {
+		chained, err := utp.SpendsUnconfirmed(tx, *txn)
+		if err != nil {
+			return 0, err
+		}
+
+		if !chained {
+			return feeCalc(txn)
+		}
+
+		uxIn, err := utp.getInputs(tx, bc, bh, *txn)
+		if err != nil {
+			return 0, err
+		}
+
+		return fee.TransactionFee(txn, bh.Time, uxIn)
+	}
+}
+
+// txnGraph is the dependency graph of the transactions in the pool
+type txnGraph struct {
+	txns map[cipher.SHA256]UnconfirmedTransaction
+	// creators maps the outputs of the transactions to the transactions creating them
+	creators map[cipher.SHA256]cipher.SHA256
+	// spenders maps the outputs spent by the transactions to the transactions spending them
+	spenders map[cipher.SHA256][]cipher.SHA256
+}
+
+// getGraph returns the dependency graph of the transactions in the pool
+func (utp *UnconfirmedTransactionPool) getGraph(tx *dbutil.Tx) (*txnGraph, error) {
+	g := &txnGraph{
+		txns:     make(map[cipher.SHA256]UnconfirmedTransaction),
+		creators: make(map[cipher.SHA256]cipher.SHA256),
+		spenders: make(map[cipher.SHA256][]cipher.SHA256),
+	}
+
+	if err := utp.txns.forEach(tx, func(hash cipher.SHA256, utxn UnconfirmedTransaction) error {
+		g.txns[hash] = utxn
+
+		for _, o := range utxn.Transaction.Out {
+			g.creators[o.UxID(hash)] = hash
+		}
+
+		for _, h := range utxn.Transaction.In {
+			g.spenders[h] = append(g.spenders[h], hash)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// descendants returns the transactions which spend the outputs of the transactions with hashes,
+// directly or through other transactions, parents first
+func (g *txnGraph) descendants(hashes ...cipher.SHA256) []cipher.SHA256 {
+	return g.walk(hashes, func(hash cipher.SHA256) []cipher.SHA256 {
+		utxn := g.txns[hash]
+
+		var children []cipher.SHA256
+		for _, o := range utxn.Transaction.Out {
+			children = append(children, g.spenders[o.UxID(hash)]...)
+		}
+		return children
+	})
+}
+
+// ancestors returns the transactions which create the outputs spent by the transactions with hashes,
+// directly or through other transactions, children first
+func (g *txnGraph) ancestors(hashes ...cipher.SHA256) []cipher.SHA256 {
+	return g.walk(hashes, func(hash cipher.SHA256) []cipher.SHA256 {
+		utxn := g.txns[hash]
+
+		var parents []cipher.SHA256
+		for _, h := range utxn.Transaction.In {
+			if parent, ok := g.creators[h]; ok {
+				parents = append(parents, parent)
+			}
+		}
+		return parents
+	})
+}
+
+// walk returns the transactions reached from the transactions with hashes, excluding them, in breadth first order
+func (g *txnGraph) walk(hashes []cipher.SHA256, next func(cipher.SHA256) []cipher.SHA256) []cipher.SHA256 {
+	seen := make(map[cipher.SHA256]struct{}, len(hashes))
+	for _, h := range hashes {
+		seen[h] = struct{}{}
+	}
+
+	var reached []cipher.SHA256
+	queue := append([]cipher.SHA256{}, hashes...)
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		for _, n := range next(h) {
+			if _, ok := seen[n]; ok {
+				continue
+			}
+			seen[n] = struct{}{}
+			reached = append(reached, n)
+			queue = append(queue, n)
+		}
+	}
+
+	return reached
+}
+
+// GetDescendants returns the transactions in the pool which spend the outputs of the transactions with hashes,
+// directly or through other transactions, parents first
+func (utp *UnconfirmedTransactionPool) GetDescendants(tx *dbutil.Tx, hashes []cipher.SHA256) ([]UnconfirmedTransaction, error) {
+	g, err := utp.getGraph(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	descendants := g.descendants(hashes...)
+	if len(descendants) == 0 {
+		return nil, nil
+	}
+
+	txns := make([]UnconfirmedTransaction, len(descendants))
+	for i, h := range descendants {
+		txns[i] = g.txns[h]
+	}
+
+	return txns, nil
+}
+
+// chainedOutputs returns the outputs created by txns which are spent by other transactions in txns
+func chainedOutputs(txns coin.Transactions) map[cipher.SHA256]struct{} {
+	outputs := make(map[cipher.SHA256]struct{})
+	for _, txn := range txns {
+		hash := txn.Hash()
+		for _, o := range txn.Out {
+			outputs[o.UxID(hash)] = struct{}{}
+		}
+	}
+
+	chained := make(map[cipher.SHA256]struct{})
+	for _, txn := range txns {
+		for _, h := range txn.In {
+			if _, ok := outputs[h]; ok {
+				chained[h] = struct{}{}
+			}
+		}
+	}
+
+	return chained
+}
+
+// confirmedInputs returns the inputs of txns which are not created by other transactions in txns
+func confirmedInputs(txns coin.Transactions) []cipher.SHA256 {
+	chained := chainedOutputs(txns)
+
+	var inputs []cipher.SHA256
+	for _, txn := range txns {
+		for _, h := range txn.In {
+			if _, ok := chained[h]; !ok {
+				inputs = append(inputs, h)
+			}
+		}
+	}
+
+	return inputs
+}
@@ -0,0 +1,335 @@
+package visor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+	"github.com/MDLlife/MDL/src/wallet"
+)
+
+// makeChildTxn creates a transaction spending the first output of the unconfirmed transaction parent
+func makeChildTxn(t *testing.T, parent coin.Transaction, toAddr cipher.Address, coins, fee uint64) coin.Transaction {
+	ux := unconfirmedOutput(coin.BlockHeader{}, parent.Hash(), parent, 0)
+	return makeSpendTxWithFee(t, coin.UxArray{ux}, []cipher.SecKey{genSecret}, toAddr, coins, fee)
+}
+
+// executeNextBlock creates and executes a block one second after the head block
+func executeNextBlock(t *testing.T, v *Visor) coin.SignedBlock {
+	var sb coin.SignedBlock
+	err := v.db.Update("", func(tx *dbutil.Tx) error {
+		head, err := v.blockchain.Head(tx)
+		require.NoError(t, err)
+
+		sb, err = v.createBlock(tx, head.Time()+1)
+		if err != nil {
+			return err
+		}
+
+		return v.executeSignedBlock(tx, sb)
+	})
+	require.NoError(t, err)
+
+	return sb
+}
+
+func requireEvictedReasons(t *testing.T, v *Visor, reasons map[cipher.SHA256]EvictionReason) {
+	evicted, err := v.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+
+	actual := make(map[cipher.SHA256]EvictionReason, len(evicted))
+	for _, e := range evicted {
+		actual[e.Hash] = e.Reason
+	}
+	require.Equal(t, reasons, actual)
+}
+
+func TestUnconfirmedChains(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		balances:    newBalanceCache(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 1)
+
+	toAddr := testutil.MakeAddress()
+	addrs := []cipher.Address{genAddress, toAddr}
+	_, err = v.getCachedBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+
+	// A transaction can spend the outputs of an unconfirmed transaction
+	parent := makeSpendTxWithFee(t, uxs[0:1], []cipher.SecKey{genSecret}, genAddress, 1e6, 1)
+	child := makeChildTxn(t, parent, toAddr, 1e5, 1)
+	_, _, err = v.InjectForeignTransaction(parent)
+	require.NoError(t, err)
+	_, _, err = v.InjectForeignTransaction(child)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, parent, child)
+
+	// A transaction spending an output which is neither unspent nor in the pool is rejected
+	orphan := makeChildTxn(t, makeSpendTxWithFee(t, uxs[0:1], []cipher.SecKey{genSecret}, genAddress, 2e6, 1), toAddr, 1e5, 1)
+	_, _, err = v.InjectForeignTransaction(orphan)
+	require.IsType(t, ErrTxnViolatesHardConstraint{}, err)
+
+	// The outputs spent by the child are not predicted to be unspent
+	requireCachedBalances(t, v, addrs)
+	bps, err := v.GetBalanceOfAddrs(addrs)
+	require.NoError(t, err)
+	require.Equal(t, genCoins-1e5, bps[0].Predicted.Coins)
+	require.Equal(t, uint64(1e5), bps[1].Predicted.Coins)
+
+	// The child is included in the block after its parent
+	sb := executeNextBlock(t, v)
+	require.Equal(t, coin.Transactions{parent}, sb.Body.Transactions)
+	requireUnconfirmedPool(t, v, child)
+	requireCachedBalances(t, v, addrs)
+
+	sb = executeNextBlock(t, v)
+	require.Equal(t, coin.Transactions{child}, sb.Body.Transactions)
+	requireUnconfirmedPool(t, v)
+	requireCachedBalances(t, v, addrs)
+
+	// The outputs index is emptied with the pool
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, UnconfirmedOutputsBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), n)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUnconfirmedChainsRemoved(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 4)
+
+	keys := []cipher.SecKey{genSecret}
+
+	// The descendants of an invalid transaction are removed with it
+	parent := makeSpendTxWithFee(t, uxs[0:1], keys, genAddress, 1e6, 1)
+	child := makeChildTxn(t, parent, testutil.MakeAddress(), 1e5, 1)
+	conflict := makeSpendTxWithFee(t, uxs[0:1], keys, testutil.MakeAddress(), 1e6, 100)
+	for _, txn := range []coin.Transaction{parent, child, conflict} {
+		_, _, err = v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	sb := executeNextBlock(t, v)
+	require.Equal(t, coin.Transactions{conflict}, sb.Body.Transactions)
+	requireUnconfirmedPool(t, v, parent, child)
+
+	removed, err := v.RemoveInvalidUnconfirmed()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{parent.Hash(), child.Hash()}, removed)
+	requireUnconfirmedPool(t, v)
+
+	// The descendants of an evicted transaction are evicted with it.
+	// The parent burns fewer coin hours per byte than its child.
+	parent = makeSpendTxWithHoursBurned(t, uxs[1:2], keys, genAddress, 1e6, uxs[1].Body.Hours/5)
+	child = makeChildTxn(t, parent, testutil.MakeAddress(), 1e5, 1)
+	for _, txn := range []coin.Transaction{parent, child} {
+		_, _, err = v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	v.Config.MaxUnconfirmedTransactions = 2
+	high := makeSpendTxWithFee(t, uxs[2:3], keys, testutil.MakeAddress(), 1e6, 100)
+	_, _, err = v.InjectForeignTransaction(high)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, high)
+	reasons := map[cipher.SHA256]EvictionReason{
+		parent.Hash(): EvictionReasonPoolFull,
+		child.Hash():  EvictionReasonParentEvicted,
+	}
+	requireEvictedReasons(t, v, reasons)
+	v.Config.MaxUnconfirmedTransactions = 0
+
+	// The descendants of a replaced transaction are replaced with it
+	v.Config.ReplaceByFee = true
+	parent = makeReplaceableSpendTxn(t, uxs[3], keys, genAddress, 1e6, 1)
+	child = makeChildTxn(t, parent, testutil.MakeAddress(), 1e5, 1)
+	for _, txn := range []coin.Transaction{parent, child} {
+		_, _, err = v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	// A replacement can't spend the outputs of the transactions it replaces
+	invalid := makeSpendTxWithHoursBurned(t, coin.UxArray{uxs[3], unconfirmedOutput(coin.BlockHeader{}, parent.Hash(), parent, 1)}, []cipher.SecKey{genSecret, genSecret}, genAddress, 1e6, uxs[3].Body.Hours)
+	_, _, err = v.InjectForeignTransaction(invalid)
+	require.IsType(t, ErrTxnViolatesSoftConstraint{}, err)
+	requireUnconfirmedPool(t, v, high, parent, child)
+
+	replacement := makeSpendTxWithHoursBurned(t, uxs[3:4], keys, testutil.MakeAddress(), 1e6, uxs[3].Body.Hours)
+	_, _, err = v.InjectForeignTransaction(replacement)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, high, replacement)
+	reasons[parent.Hash()] = EvictionReasonReplaced
+	reasons[child.Hash()] = EvictionReasonParentEvicted
+	requireEvictedReasons(t, v, reasons)
+}
+
+func TestWalletCreateTransactionUnconfirmedChange(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	wltID := "foo.wlt"
+	_, err = ws.CreateWallet(wltID, wallet.Options{
+		Coin: wallet.CoinTypeMDL,
+		Type: wallet.WalletTypeCollection,
+	}, nil)
+	require.NoError(t, err)
+	_, err = ws.ImportSecretKeys(wltID, nil, []cipher.SecKey{genSecret})
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 2)
+
+	// Fund an address which is not in the wallet
+	otherPubkey, otherSeckey := cipher.GenerateKeyPair()
+	otherAddr := cipher.AddressFromPubKey(otherPubkey)
+	fund := makeSpendTxWithFee(t, uxs[1:2], []cipher.SecKey{genSecret}, otherAddr, uxs[1].Body.Coins, 1)
+	_, _, err = v.InjectForeignTransaction(fund)
+	require.NoError(t, err)
+	sb := executeNextBlock(t, v)
+	otherUxs := coin.CreateUnspents(sb.Head, fund)
+
+	// The wallet spends all of its confirmed outputs, sending change back to itself
+	parent := makeSpendTxWithFee(t, coin.UxArray{uxs[0], uxs[2]}, []cipher.SecKey{genSecret, genSecret}, genAddress, 1e6, 1)
+	_, _, _, err = v.InjectUserTransaction(parent)
+	require.NoError(t, err)
+
+	// The outputs sent to the wallet by a transaction which spends outputs of other addresses are not used
+	foreign := makeSpendTxWithFee(t, otherUxs, []cipher.SecKey{otherSeckey}, genAddress, 1e6, 1)
+	_, _, err = v.InjectForeignTransaction(foreign)
+	require.NoError(t, err)
+
+	p := transaction.Params{
+		HoursSelection: transaction.HoursSelection{
+			Type: transaction.HoursSelectionTypeManual,
+		},
+		To: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e5,
+				Hours:   1,
+			},
+		},
+	}
+
+	_, _, err = v.WalletCreateTransaction(wltID, p, CreateTransactionParams{})
+	require.Equal(t, ErrSpendingUnconfirmed, err)
+
+	_, _, err = v.WalletCreateTransaction(wltID, p, CreateTransactionParams{
+		UxOuts:            []cipher.SHA256{uxs[0].Hash()},
+		UnconfirmedChange: true,
+	})
+	require.Equal(t, ErrUnconfirmedChangeUxOuts, err)
+
+	txn, inputs, err := v.WalletCreateTransactionSigned(wltID, nil, p, CreateTransactionParams{
+		UnconfirmedChange: true,
+	})
+	require.NoError(t, err)
+
+	parentOutputs := map[cipher.SHA256]struct{}{
+		parent.Out[0].UxID(parent.Hash()): {},
+		parent.Out[1].UxID(parent.Hash()): {},
+	}
+	for _, h := range txn.In {
+		_, ok := parentOutputs[h]
+		require.True(t, ok)
+	}
+	require.Len(t, inputs, len(txn.In))
+
+	_, _, _, err = v.InjectUserTransaction(*txn)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, parent, foreign, *txn)
+}
@@ -0,0 +1,676 @@
+package visor
+
+// This file contains the limits of the unconfirmed transaction pool.
+//
+// The pool is limited by the number of transactions, by the size of the transactions stored in the database,
+// and by the time since a transaction was first added. Transactions which were first added longer than the
+// maximum age ago are evicted. When the pool is over its count or size limit, the transactions which burn
+// the fewest coin hours per byte are evicted first, with ties broken by the transaction hash.
+//
+// Transactions injected by this node's user, which include the transactions created by its wallets,
+// are protected: they are never evicted, so that a peer can't push them out by flooding the pool.
+// They still count towards the limits.
+//
+// The fee rate of a transaction is computed when it is added to the pool and indexed in its metadata,
+// so that the pool is evicted in fee rate order without decoding and sorting the transactions.
+//
+// A record of the recently evicted transactions is kept, with the reason of the eviction.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+)
+
+var (
+	// UnconfirmedMetaBkt holds the time an unconfirmed transaction was first added to the pool,
+	// and whether it is protected from eviction
+	UnconfirmedMetaBkt = []byte("unconfirmed_meta")
+	// UnconfirmedFeeRateBkt indexes the metadata of the unconfirmed transactions by fee rate, lowest first
+	UnconfirmedFeeRateBkt = []byte("unconfirmed_fee_rate")
+	// UnconfirmedEvictedBkt holds the records of the transactions recently evicted from the unconfirmed pool
+	UnconfirmedEvictedBkt = []byte("unconfirmed_evicted")
+
+	// ErrTxnEvicted is returned when a transaction received over the network is evicted as soon as it is added,
+	// because the pool is full and it burns fewer coin hours per byte than the other transactions
+	ErrTxnEvicted = errors.New("Transaction was evicted from the full unconfirmed pool, its fee rate is too low")
+)
+
+// MaxEvictedTransactions is the number of eviction records kept
+const MaxEvictedTransactions = 1000
+
+// EvictionReason is the reason a transaction was evicted from the unconfirmed pool
+type EvictionReason string
+
+const (
+	// EvictionReasonExpired the transaction was first added longer than the maximum age ago
+	EvictionReasonExpired EvictionReason = "expired"
+	// EvictionReasonPoolFull the pool was full and the transaction burns fewer coin hours per byte than the others
+	EvictionReasonPoolFull EvictionReason = "pool_full"
+	// EvictionReasonReplaced the transaction was replaced by a conflicting transaction burning more coin hours
+	EvictionReasonReplaced EvictionReason = "replaced"
+	// EvictionReasonParentEvicted the transaction spent outputs of an evicted or replaced transaction
+	EvictionReasonParentEvicted EvictionReason = "parent_evicted"
+)
+
+// UnconfirmedPoolLimits are the limits of the unconfirmed pool. Zero limits are not enforced.
+type UnconfirmedPoolLimits struct {
+	// Maximum number of transactions
+	MaxTransactions uint64
+	// Maximum size of the transactions stored in the database, in bytes
+	MaxSize uint64
+	// Maximum time since a transaction was first added
+	MaxAge time.Duration
+}
+
+// EvictedTransaction records a transaction evicted from the unconfirmed pool
+type EvictedTransaction struct {
+	Hash   cipher.SHA256  `json:"-"`
+	Reason EvictionReason `json:"reason"`
+	// Time the transaction was evicted, in nanoseconds
+	Evicted int64 `json:"evicted"`
+	// Time the transaction was first added to the pool, in nanoseconds
+	Added int64 `json:"added"`
+	// Size of the transaction in bytes
+	Size uint32 `json:"size"`
+	// Coin hours burned by the transaction
+	Fee uint64 `json:"fee"`
+}
+
+// unconfirmedMeta is the metadata of an unconfirmed transaction kept in UnconfirmedMetaBkt, encoded as
+// the time the transaction was first added, a flags byte, the fee and the size of the transaction
+type unconfirmedMeta struct {
+	Added     int64
+	Protected bool
+	// Chained is whether the transaction spent the outputs of other transactions in the pool when it was added
+	Chained bool
+	// Fee is the coin hours burned by the transaction when it was added
+	Fee uint64
+	// Size of the transaction in bytes, 0 if the metadata was kept before the fee rate was indexed
+	Size uint32
+}
+
+const (
+	unconfirmedMetaProtected = 1 << iota
+	unconfirmedMetaChained
+)
+
+// indexed returns whether the transaction is in the fee rate index
+func (m unconfirmedMeta) indexed() bool {
+	return m.Size != 0
+}
+
+// feeRateKey returns the key of a transaction in UnconfirmedFeeRateBkt, the coin hours it burns per byte
+// as a 128-bit fixed point number with 32 fractional bits, followed by its hash
+func (m unconfirmedMeta) feeRateKey(hash cipher.SHA256) []byte {
+	hi, r := bits.Div64(0, m.Fee>>32, uint64(m.Size))
+	lo, _ := bits.Div64(r, m.Fee<<32, uint64(m.Size))
+
+	k := make([]byte, 16+len(hash))
+	binary.BigEndian.PutUint64(k[:8], hi)
+	binary.BigEndian.PutUint64(k[8:16], lo)
+	copy(k[16:], hash[:])
+	return k
+}
+
+// unconfirmedMetas unconfirmed transaction metadata bucket
+type unconfirmedMetas struct{}
+
+func (um *unconfirmedMetas) get(tx *dbutil.Tx, hash cipher.SHA256) (*unconfirmedMeta, error) {
+	v, err := dbutil.GetBucketValueNoCopy(tx, UnconfirmedMetaBkt, []byte(hash.Hex()))
+	if err != nil {
+		return nil, err
+	} else if v == nil {
+		return nil, nil
+	}
+
+	return decodeUnconfirmedMeta(v)
+}
+
+// put puts the metadata of a transaction and updates the fee rate index
+func (um *unconfirmedMetas) put(tx *dbutil.Tx, hash cipher.SHA256, m unconfirmedMeta) error {
+	if err := um.unindex(tx, hash); err != nil {
+		return err
+	}
+
+	var flags byte
+	if m.Protected {
+		flags |= unconfirmedMetaProtected
+	}
+	if m.Chained {
+		flags |= unconfirmedMetaChained
+	}
+
+	v := make([]byte, 21)
+	binary.BigEndian.PutUint64(v[:8], uint64(m.Added))
+	v[8] = flags
+	binary.BigEndian.PutUint64(v[9:17], m.Fee)
+	binary.BigEndian.PutUint32(v[17:21], m.Size)
+
+	if err := dbutil.PutBucketValue(tx, UnconfirmedMetaBkt, []byte(hash.Hex()), v); err != nil {
+		return err
+	}
+
+	if !m.indexed() {
+		return nil
+	}
+
+	return dbutil.PutBucketValue(tx, UnconfirmedFeeRateBkt, m.feeRateKey(hash), []byte{})
+}
+
+func (um *unconfirmedMetas) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	if err := um.unindex(tx, hash); err != nil {
+		return err
+	}
+
+	return dbutil.Delete(tx, UnconfirmedMetaBkt, []byte(hash.Hex()))
+}
+
+// unindex removes a transaction from the fee rate index
+func (um *unconfirmedMetas) unindex(tx *dbutil.Tx, hash cipher.SHA256) error {
+	m, err := um.get(tx, hash)
+	if err != nil {
+		return err
+	}
+
+	if m == nil || !m.indexed() {
+		return nil
+	}
+
+	return dbutil.Delete(tx, UnconfirmedFeeRateBkt, m.feeRateKey(hash))
+}
+
+func (um *unconfirmedMetas) getAll(tx *dbutil.Tx) (map[cipher.SHA256]unconfirmedMeta, error) {
+	metas := make(map[cipher.SHA256]unconfirmedMeta)
+
+	if err := dbutil.ForEach(tx, UnconfirmedMetaBkt, func(k, v []byte) error {
+		hash, err := cipher.SHA256FromHex(string(k))
+		if err != nil {
+			return err
+		}
+
+		m, err := decodeUnconfirmedMeta(v)
+		if err != nil {
+			return err
+		}
+
+		metas[hash] = *m
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return metas, nil
+}
+
+// forEachByFeeRate calls f with the hashes of the indexed transactions, lowest fee rate first, until f returns false
+func (um *unconfirmedMetas) forEachByFeeRate(tx *dbutil.Tx, f func(hash cipher.SHA256) (bool, error)) error {
+	bkt := tx.Bucket(UnconfirmedFeeRateBkt)
+	if bkt == nil {
+		return dbutil.NewErrBucketNotExist(UnconfirmedFeeRateBkt)
+	}
+
+	c := bkt.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		hash, err := cipher.SHA256FromBytes(k[16:])
+		if err != nil {
+			return err
+		}
+
+		next, err := f(hash)
+		if err != nil {
+			return err
+		}
+		if !next {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// decodeUnconfirmedMeta decodes the metadata of a transaction. The metadata kept before the fee rate was
+// indexed is the time the transaction was first added followed by a protected flag byte.
+func decodeUnconfirmedMeta(v []byte) (*unconfirmedMeta, error) {
+	switch len(v) {
+	case 9:
+		return &unconfirmedMeta{
+			Added:     int64(dbutil.Btoi(v[:8])),
+			Protected: v[8] == 1,
+		}, nil
+	case 21:
+		return &unconfirmedMeta{
+			Added:     int64(dbutil.Btoi(v[:8])),
+			Protected: v[8]&unconfirmedMetaProtected != 0,
+			Chained:   v[8]&unconfirmedMetaChained != 0,
+			Fee:       dbutil.Btoi(v[9:17]),
+			Size:      binary.BigEndian.Uint32(v[17:21]),
+		}, nil
+	default:
+		return nil, errors.New("Invalid unconfirmed transaction metadata length")
+	}
+}
+
+// newMeta returns the metadata of a transaction first added to the pool at time added, with its fee calculated by feeCalc
+func (utp *UnconfirmedTransactionPool) newMeta(tx *dbutil.Tx, txn coin.Transaction, added int64, feeCalc coin.FeeCalculator) (unconfirmedMeta, error) {
+	size, err := txn.Size()
+	if err != nil {
+		return unconfirmedMeta{}, err
+	}
+
+	chained, err := utp.SpendsUnconfirmed(tx, txn)
+	if err != nil {
+		return unconfirmedMeta{}, err
+	}
+
+	// Transactions whose fee can't be computed, because their inputs are not unspent, are evicted first
+	fee, err := feeCalc(&txn)
+	if err != nil {
+		fee = 0
+	}
+
+	return unconfirmedMeta{
+		Added:   added,
+		Chained: chained,
+		Fee:     fee,
+		Size:    size,
+	}, nil
+}
+
+// evictedTxns evicted transaction records bucket
+type evictedTxns struct{}
+
+func (et *evictedTxns) put(tx *dbutil.Tx, e EvictedTransaction) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return dbutil.PutBucketValue(tx, UnconfirmedEvictedBkt, []byte(e.Hash.Hex()), v)
+}
+
+func (et *evictedTxns) delete(tx *dbutil.Tx, hash cipher.SHA256) error {
+	return dbutil.Delete(tx, UnconfirmedEvictedBkt, []byte(hash.Hex()))
+}
+
+func (et *evictedTxns) getAll(tx *dbutil.Tx) ([]EvictedTransaction, error) {
+	var records []EvictedTransaction
+
+	if err := dbutil.ForEach(tx, UnconfirmedEvictedBkt, func(k, v []byte) error {
+		hash, err := cipher.SHA256FromHex(string(k))
+		if err != nil {
+			return err
+		}
+
+		var e EvictedTransaction
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		e.Hash = hash
+
+		records = append(records, e)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Protect protects an unconfirmed transaction from eviction
+func (utp *UnconfirmedTransactionPool) Protect(tx *dbutil.Tx, hash cipher.SHA256) error {
+	m, err := utp.meta.get(tx, hash)
+	if err != nil {
+		return err
+	}
+
+	if m == nil {
+		txn, err := utp.txns.get(tx, hash)
+		if err != nil {
+			return err
+		}
+		if txn == nil {
+			return errUpdateObjectDoesNotExist
+		}
+
+		m = &unconfirmedMeta{
+			Added: txn.Received,
+		}
+	}
+
+	m.Protected = true
+	return utp.meta.put(tx, hash, *m)
+}
+
+// evictionEntry is an unconfirmed transaction considered for eviction
+type evictionEntry struct {
+	hash cipher.SHA256
+	// size of the transaction stored in the database
+	storedSize uint64
+	meta       unconfirmedMeta
+}
+
+// Evict removes the transactions which were first added longer than limits.MaxAge ago,
+// then removes the transactions which burn the fewest coin hours per byte until the pool is within
+// limits.MaxTransactions and limits.MaxSize. The transactions spending the outputs of a removed transaction
+// are removed with it. Protected transactions, and the transactions they spend the outputs of, are not removed.
+// The evicted transactions are recorded and returned.
+func (utp *UnconfirmedTransactionPool) Evict(tx *dbutil.Tx, bc Blockchainer, limits UnconfirmedPoolLimits, now time.Time) ([]EvictedTransaction, error) {
+	if limits == (UnconfirmedPoolLimits{}) {
+		return nil, nil
+	}
+
+	metas, err := utp.meta.getAll(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The transactions added before their fee rate was indexed are decoded once, to index them
+	var unindexed []cipher.SHA256
+
+	// Read the stored sizes without decoding the transactions, to check the limits cheaply
+	var entries []evictionEntry
+	var count, size uint64
+	if err := dbutil.ForEach(tx, UnconfirmedTxnsBkt, func(k, v []byte) error {
+		hash, err := cipher.SHA256FromHex(string(k))
+		if err != nil {
+			return err
+		}
+
+		m, ok := metas[hash]
+		if !ok || !m.indexed() {
+			unindexed = append(unindexed, hash)
+		}
+
+		entries = append(entries, evictionEntry{
+			hash:       hash,
+			storedSize: uint64(len(v)),
+			meta:       m,
+		})
+		count++
+		size += uint64(len(v))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(unindexed) != 0 {
+		indexed, err := utp.indexFeeRates(tx, bc, unindexed, metas)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, e := range entries {
+			if m, ok := indexed[e.hash]; ok {
+				entries[i].meta = m
+			}
+		}
+	}
+
+	hasChained := false
+	for _, e := range entries {
+		if e.meta.Chained {
+			hasChained = true
+			break
+		}
+	}
+
+	overLimits := func() bool {
+		return (limits.MaxTransactions != 0 && count > limits.MaxTransactions) ||
+			(limits.MaxSize != 0 && size > limits.MaxSize)
+	}
+
+	isExpired := func(e evictionEntry) bool {
+		return limits.MaxAge != 0 && now.Sub(time.Unix(0, e.meta.Added)) > limits.MaxAge
+	}
+
+	hasExpired := false
+	for _, e := range entries {
+		if !e.meta.Protected && isExpired(e) {
+			hasExpired = true
+			break
+		}
+	}
+
+	if !hasExpired && !overLimits() {
+		return nil, nil
+	}
+
+	// The dependencies between the transactions are only needed if a transaction spends the outputs of another one
+	var graph *txnGraph
+	if hasChained {
+		graph, err = utp.getGraph(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The transactions which protected transactions depend on are protected too
+	var protected []cipher.SHA256
+	for _, e := range entries {
+		if e.meta.Protected {
+			protected = append(protected, e.hash)
+		}
+	}
+	if graph != nil {
+		protected = append(protected, graph.ancestors(protected...)...)
+	}
+	protectedSet := make(map[cipher.SHA256]struct{}, len(protected))
+	for _, h := range protected {
+		protectedSet[h] = struct{}{}
+	}
+
+	entriesByHash := make(map[cipher.SHA256]evictionEntry, len(entries))
+	var expired []evictionEntry
+	for _, e := range entries {
+		entriesByHash[e.hash] = e
+
+		if _, ok := protectedSet[e.hash]; ok {
+			continue
+		}
+
+		if isExpired(e) {
+			expired = append(expired, e)
+		}
+	}
+
+	newEvicted := func(e evictionEntry, reason EvictionReason) EvictedTransaction {
+		return EvictedTransaction{
+			Hash:    e.hash,
+			Reason:  reason,
+			Evicted: now.UnixNano(),
+			Added:   e.meta.Added,
+			Size:    e.meta.Size,
+			Fee:     e.meta.Fee,
+		}
+	}
+
+	var evicted []EvictedTransaction
+	evictedSet := make(map[cipher.SHA256]struct{})
+
+	// evict evicts an entry, and the transactions spending its outputs
+	evict := func(e evictionEntry, reason EvictionReason) {
+		if _, ok := evictedSet[e.hash]; ok {
+			return
+		}
+		evictedSet[e.hash] = struct{}{}
+		evicted = append(evicted, newEvicted(e, reason))
+		count--
+		size -= e.storedSize
+
+		if graph == nil {
+			return
+		}
+
+		for _, h := range graph.descendants(e.hash) {
+			if _, ok := evictedSet[h]; ok {
+				continue
+			}
+
+			d := entriesByHash[h]
+			evictedSet[h] = struct{}{}
+			evicted = append(evicted, newEvicted(d, EvictionReasonParentEvicted))
+			count--
+			size -= d.storedSize
+		}
+	}
+
+	for _, e := range expired {
+		evict(e, EvictionReasonExpired)
+	}
+
+	if overLimits() {
+		// Evict from the lowest fee rate, like the transactions are included in blocks from the highest
+		if err := utp.meta.forEachByFeeRate(tx, func(hash cipher.SHA256) (bool, error) {
+			if _, ok := protectedSet[hash]; ok {
+				return true, nil
+			}
+
+			if e, ok := entriesByHash[hash]; ok {
+				evict(e, EvictionReasonPoolFull)
+			}
+
+			return overLimits(), nil
+		}); err != nil {
+			return nil, err
+		}
+
+		if overLimits() {
+			logger.Warningf("Unconfirmed pool is over its limits with %d protected transactions, %d bytes", count, size)
+		}
+	}
+
+	if len(evicted) == 0 {
+		return nil, nil
+	}
+
+	if err := utp.RemoveEvicted(tx, evicted); err != nil {
+		return nil, err
+	}
+
+	return evicted, nil
+}
+
+// indexFeeRates indexes the fee rates of the transactions added before their fee rate was indexed.
+// The transactions without metadata are aged from the time they were last received.
+// Returns the new metadata of the transactions.
+func (utp *UnconfirmedTransactionPool) indexFeeRates(tx *dbutil.Tx, bc Blockchainer, hashes []cipher.SHA256, metas map[cipher.SHA256]unconfirmedMeta) (map[cipher.SHA256]unconfirmedMeta, error) {
+	head, err := bc.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+	feeCalc := utp.TransactionFee(tx, bc, head.Head)
+
+	indexed := make(map[cipher.SHA256]unconfirmedMeta, len(hashes))
+	for _, h := range hashes {
+		utxn, err := utp.txns.get(tx, h)
+		if err != nil {
+			return nil, err
+		}
+
+		old, ok := metas[h]
+		if !ok {
+			old.Added = utxn.Received
+		}
+
+		m, err := utp.newMeta(tx, utxn.Transaction, old.Added, feeCalc)
+		if err != nil {
+			return nil, err
+		}
+		m.Protected = old.Protected
+
+		if err := utp.meta.put(tx, h, m); err != nil {
+			return nil, err
+		}
+		indexed[h] = m
+	}
+
+	return indexed, nil
+}
+
+// RemoveEvicted removes the evicted transactions from the pool and records their eviction
+func (utp *UnconfirmedTransactionPool) RemoveEvicted(tx *dbutil.Tx, evicted []EvictedTransaction) error {
+	hashes := make([]cipher.SHA256, len(evicted))
+	for i, e := range evicted {
+		hashes[i] = e.Hash
+	}
+
+	if err := utp.RemoveTransactions(tx, hashes); err != nil {
+		return err
+	}
+
+	for _, e := range evicted {
+		if err := utp.evicted.put(tx, e); err != nil {
+			return err
+		}
+	}
+
+	return utp.pruneEvicted(tx)
+}
+
+// pruneEvicted removes the oldest eviction records beyond MaxEvictedTransactions
+func (utp *UnconfirmedTransactionPool) pruneEvicted(tx *dbutil.Tx) error {
+	n, err := dbutil.Len(tx, UnconfirmedEvictedBkt)
+	if err != nil {
+		return err
+	}
+
+	if n <= MaxEvictedTransactions {
+		return nil
+	}
+
+	records, err := utp.GetEvicted(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range records[MaxEvictedTransactions:] {
+		if err := utp.evicted.delete(tx, e.Hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetEvicted returns the records of the recently evicted transactions, most recent first
+func (utp *UnconfirmedTransactionPool) GetEvicted(tx *dbutil.Tx) ([]EvictedTransaction, error) {
+	records, err := utp.evicted.getAll(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Evicted != records[j].Evicted {
+			return records[i].Evicted > records[j].Evicted
+		}
+		return bytes.Compare(records[i].Hash[:], records[j].Hash[:]) < 0
+	})
+
+	return records, nil
+}
+
+// GetAddedTimes returns the times the transactions of the pool were first added, in nanoseconds.
+// Unlike UnconfirmedTransaction.Received, the time doesn't change when a transaction is received again.
+// The transactions added before the metadata was kept have no time.
+func (utp *UnconfirmedTransactionPool) GetAddedTimes(tx *dbutil.Tx) (map[cipher.SHA256]int64, error) {
+	metas, err := utp.meta.getAll(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	added := make(map[cipher.SHA256]int64, len(metas))
+	for hash, m := range metas {
+		added[hash] = m.Added
+	}
+
+	return added, nil
+}
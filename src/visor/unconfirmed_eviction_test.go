@@ -0,0 +1,294 @@
+package visor
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
+)
+
+func requireUnconfirmedPool(t *testing.T, v *Visor, txns ...coin.Transaction) {
+	utxns, err := v.GetAllUnconfirmedTransactions()
+	require.NoError(t, err)
+
+	var hashes, expected []cipher.SHA256
+	for _, utxn := range utxns {
+		hashes = append(hashes, utxn.Transaction.Hash())
+	}
+	for _, txn := range txns {
+		expected = append(expected, txn.Hash())
+	}
+	sortHashes := func(hashes []cipher.SHA256) {
+		sort.Slice(hashes, func(i, j int) bool {
+			return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+		})
+	}
+	sortHashes(hashes)
+	sortHashes(expected)
+	require.Equal(t, expected, hashes)
+}
+
+func requireEvicted(t *testing.T, v *Visor, reasons map[cipher.SHA256]EvictionReason, order ...cipher.SHA256) {
+	evicted, err := v.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+	require.Len(t, evicted, len(order))
+	for i, h := range order {
+		require.Equal(t, h, evicted[i].Hash)
+		require.Equal(t, reasons[h], evicted[i].Reason)
+	}
+}
+
+func TestEvictUnconfirmed(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 4)
+
+	keys := []cipher.SecKey{genSecret}
+	txnLow := makeSpendTxWithFee(t, uxs[0:1], keys, testutil.MakeAddress(), 1e6, 1)
+	txnHigh := makeSpendTxWithFee(t, uxs[1:2], keys, testutil.MakeAddress(), 1e6, 100)
+	txnUser := makeSpendTxWithFee(t, uxs[2:3], keys, testutil.MakeAddress(), 1e6, 0)
+	txnMid := makeSpendTxWithFee(t, uxs[3:4], keys, testutil.MakeAddress(), 1e6, 50)
+
+	reasons := map[cipher.SHA256]EvictionReason{
+		txnLow.Hash(): EvictionReasonPoolFull,
+		txnMid.Hash(): EvictionReasonPoolFull,
+	}
+
+	v.Config.MaxUnconfirmedTransactions = 2
+
+	_, _, err = v.InjectForeignTransaction(txnLow)
+	require.NoError(t, err)
+	_, _, _, err = v.InjectUserTransaction(txnUser)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnLow, txnUser)
+	requireEvicted(t, v, reasons)
+
+	// The pool is full, the transaction burning the fewest coin hours per byte is evicted.
+	// txnUser burns fewer coin hours than txnLow, but it is protected.
+	_, _, err = v.InjectForeignTransaction(txnHigh)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnHigh, txnUser)
+	requireEvicted(t, v, reasons, txnLow.Hash())
+
+	evicted, err := v.GetEvictedUnconfirmedTransactions()
+	require.NoError(t, err)
+	size, err := txnLow.Size()
+	require.NoError(t, err)
+	require.Equal(t, size, evicted[0].Size)
+	require.NotEqual(t, uint64(0), evicted[0].Fee)
+	require.True(t, evicted[0].Added <= evicted[0].Evicted)
+
+	// A transaction which burns fewer coin hours per byte than the others is evicted as soon as it is injected
+	_, _, err = v.InjectForeignTransaction(txnMid)
+	require.Equal(t, ErrTxnEvicted, err)
+	requireUnconfirmedPool(t, v, txnHigh, txnUser)
+	requireEvicted(t, v, reasons, txnMid.Hash(), txnLow.Hash())
+
+	// A transaction received again after its eviction is no longer recorded as evicted
+	v.Config.MaxUnconfirmedTransactions = 0
+	_, _, err = v.InjectForeignTransaction(txnLow)
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnHigh, txnUser, txnLow)
+	requireEvicted(t, v, reasons, txnMid.Hash())
+
+	// The pool is limited by the size of the transactions stored in the database
+	var maxSize uint64
+	err = db.View("", func(tx *dbutil.Tx) error {
+		for _, txn := range []coin.Transaction{txnHigh, txnUser} {
+			v, err := dbutil.GetBucketValue(tx, UnconfirmedTxnsBkt, []byte(txn.Hash().Hex()))
+			require.NoError(t, err)
+			maxSize += uint64(len(v))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	v.Config.MaxUnconfirmedSize = maxSize
+	evicted, err = v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	require.Equal(t, txnLow.Hash(), evicted[0].Hash)
+	requireUnconfirmedPool(t, v, txnHigh, txnUser)
+
+	evicted, err = v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Empty(t, evicted)
+
+	// Transactions first added longer than the maximum age ago are evicted, except protected transactions
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		limits := UnconfirmedPoolLimits{
+			MaxAge: time.Hour,
+		}
+
+		evicted, err := v.unconfirmed.Evict(tx, v.blockchain, limits, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Empty(t, evicted)
+
+		evicted, err = v.unconfirmed.Evict(tx, v.blockchain, limits, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, evicted, 1)
+		require.Equal(t, txnHigh.Hash(), evicted[0].Hash)
+		require.Equal(t, EvictionReasonExpired, evicted[0].Reason)
+		return nil
+	})
+	require.NoError(t, err)
+	requireUnconfirmedPool(t, v, txnUser)
+
+	// Removed transactions don't leave metadata behind
+	err = db.View("", func(tx *dbutil.Tx) error {
+		n, err := dbutil.Len(tx, UnconfirmedMetaBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), n)
+		n, err = dbutil.Len(tx, UnconfirmedFeeRateBkt)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), n)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUnconfirmedFeeRateIndex(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	cfg := NewConfig()
+	cfg.IsBlockPublisher = true
+	cfg.Arbitrating = true
+	cfg.BlockchainPubkey = genPublic
+	cfg.GenesisAddress = genAddress
+	cfg.BlockchainSeckey = genSecret
+
+	v := &Visor{
+		Config:      cfg,
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+	}
+
+	gb := addGenesisBlockToVisor(t, v)
+	_, uxs := splitGenesisOutput(t, v, gb, 3)
+
+	keys := []cipher.SecKey{genSecret}
+	txnHigh := makeSpendTxWithFee(t, uxs[0:1], keys, testutil.MakeAddress(), 1e6, 100)
+	txnLow := makeSpendTxWithFee(t, uxs[1:2], keys, testutil.MakeAddress(), 1e6, 1)
+	txnMid := makeSpendTxWithFee(t, uxs[2:3], keys, testutil.MakeAddress(), 1e6, 50)
+
+	for _, txn := range []coin.Transaction{txnHigh, txnLow, txnMid} {
+		_, _, err := v.InjectForeignTransaction(txn)
+		require.NoError(t, err)
+	}
+
+	requireIndex := func(txns ...coin.Transaction) {
+		var expected []cipher.SHA256
+		for _, txn := range txns {
+			expected = append(expected, txn.Hash())
+		}
+
+		err := db.View("", func(tx *dbutil.Tx) error {
+			var hashes []cipher.SHA256
+			err := unconfirmed.meta.forEachByFeeRate(tx, func(hash cipher.SHA256) (bool, error) {
+				hashes = append(hashes, hash)
+				return true, nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, expected, hashes)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// The transactions are indexed by fee rate, lowest first
+	requireIndex(txnLow, txnMid, txnHigh)
+
+	err = db.View("", func(tx *dbutil.Tx) error {
+		m, err := unconfirmed.meta.get(tx, txnMid.Hash())
+		require.NoError(t, err)
+		size, err := txnMid.Size()
+		require.NoError(t, err)
+		require.Equal(t, size, m.Size)
+		require.NotEqual(t, uint64(0), m.Fee)
+		require.False(t, m.Chained)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The transactions added without metadata, or with the metadata kept before the fee rates were indexed,
+	// are indexed by the next eviction
+	err = db.Update("", func(tx *dbutil.Tx) error {
+		require.NoError(t, unconfirmed.meta.delete(tx, txnLow.Hash()))
+		require.NoError(t, unconfirmed.meta.delete(tx, txnMid.Hash()))
+		legacy := append(dbutil.Itob(uint64(time.Now().UnixNano())), 1)
+		require.NoError(t, dbutil.PutBucketValue(tx, UnconfirmedMetaBkt, []byte(txnMid.Hash().Hex()), legacy))
+		return nil
+	})
+	require.NoError(t, err)
+	requireIndex(txnHigh)
+
+	v.Config.MaxUnconfirmedTransactions = 2
+	evicted, err := v.EvictUnconfirmed()
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	require.Equal(t, txnLow.Hash(), evicted[0].Hash)
+	require.Equal(t, EvictionReasonPoolFull, evicted[0].Reason)
+	requireIndex(txnMid, txnHigh)
+
+	// txnMid is still protected
+	requireUnconfirmedPool(t, v, txnMid, txnHigh)
+	err = db.View("", func(tx *dbutil.Tx) error {
+		m, err := unconfirmed.meta.get(tx, txnMid.Hash())
+		require.NoError(t, err)
+		require.True(t, m.Protected)
+		require.True(t, m.indexed())
+		return nil
+	})
+	require.NoError(t, err)
+
+	v.Config.MaxUnconfirmedTransactions = 0
+	_, _, err = v.InjectForeignTransaction(txnLow)
+	require.NoError(t, err)
+	requireIndex(txnLow, txnMid, txnHigh)
+}
@@ -43,6 +43,8 @@ type Visor struct {
 	blockchain  Blockchainer
 	history     Historyer
 	wallets     *wallet.Service
+	balances    *balanceCache
+	fees        *feeEstimator
 }
 
 // New creates a Visor for managing the blockchain database
@@ -111,6 +113,8 @@ func New(c Config, db *dbutil.DB, wltServ *wallet.Service) (*Visor, error) {
 		unconfirmed: utp,
 		history:     history,
 		wallets:     wltServ,
+		balances:    newBalanceCache(),
+		fees:        newFeeEstimator(),
 	}
 
 	return v, nil
@@ -134,6 +138,7 @@ func (vs *Visor) Init() error {
 			return err
 		}
 		logger.Infof("Removed %d invalid txns from pool", len(removed))
+		vs.balances.removeTransactions(tx, removed)
 
 		return nil
 	})
@@ -267,7 +272,12 @@ func (vs *Visor) RemoveInvalidUnconfirmed() ([]cipher.SHA256, error) {
 	if err := vs.db.Update("RemoveInvalidUnconfirmed", func(tx *dbutil.Tx) error {
 		var err error
 		hashes, err = vs.unconfirmed.RemoveInvalid(tx, vs.blockchain)
-		return err
+		if err != nil {
+			return err
+		}
+
+		vs.balances.removeTransactions(tx, hashes)
+		return nil
 	}); err != nil {
 		return nil, err
 	}
@@ -275,6 +285,69 @@ func (vs *Visor) RemoveInvalidUnconfirmed() ([]cipher.SHA256, error) {
 	return hashes, nil
 }
 
+// unconfirmedPoolLimits returns the limits of the unconfirmed pool
+func (vs *Visor) unconfirmedPoolLimits() UnconfirmedPoolLimits {
+	return UnconfirmedPoolLimits{
+		MaxTransactions: vs.Config.MaxUnconfirmedTransactions,
+		MaxSize:         vs.Config.MaxUnconfirmedSize,
+		MaxAge:          vs.Config.MaxUnconfirmedAge,
+	}
+}
+
+// evictUnconfirmed evicts transactions from the unconfirmed pool until it is within its limits
+func (vs *Visor) evictUnconfirmed(tx *dbutil.Tx) ([]EvictedTransaction, error) {
+	evicted, err := vs.unconfirmed.Evict(tx, vs.blockchain, vs.unconfirmedPoolLimits(), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(evicted) == 0 {
+		return nil, nil
+	}
+
+	hashes := make([]cipher.SHA256, len(evicted))
+	for i, e := range evicted {
+		hashes[i] = e.Hash
+	}
+
+	vs.balances.removeTransactions(tx, hashes)
+
+	logger.Infof("Evicted %d transactions from the unconfirmed pool", len(evicted))
+
+	return evicted, nil
+}
+
+// EvictUnconfirmed evicts the expired transactions from the unconfirmed pool, and the transactions
+// which burn the fewest coin hours per byte if the pool is over its limits.
+// Returns the evicted transactions.
+func (vs *Visor) EvictUnconfirmed() ([]EvictedTransaction, error) {
+	var evicted []EvictedTransaction
+	if err := vs.db.Update("EvictUnconfirmed", func(tx *dbutil.Tx) error {
+		var err error
+		evicted, err = vs.evictUnconfirmed(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return evicted, nil
+}
+
+// GetEvictedUnconfirmedTransactions returns the records of the transactions recently evicted
+// from the unconfirmed pool, most recent first
+func (vs *Visor) GetEvictedUnconfirmedTransactions() ([]EvictedTransaction, error) {
+	var evicted []EvictedTransaction
+	if err := vs.db.View("GetEvictedUnconfirmedTransactions", func(tx *dbutil.Tx) error {
+		var err error
+		evicted, err = vs.unconfirmed.GetEvicted(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return evicted, nil
+}
+
 // CreateBlock creates a SignedBlock from pending transactions
 func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, error) {
 	if !vs.Config.IsBlockPublisher {
@@ -282,20 +355,34 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 	}
 
 	// Gather all unconfirmed transactions
-	txns, err := vs.unconfirmed.AllRawTransactions(tx)
+	utxns, err := vs.unconfirmed.GetFiltered(tx, All)
 	if err != nil {
 		return coin.SignedBlock{}, err
 	}
 
-	if len(txns) == 0 {
+	if len(utxns) == 0 {
 		return coin.SignedBlock{}, errors.New("No transactions")
 	}
 
-	logger.Infof("unconfirmed pool has %d transactions pending", len(txns))
+	logger.Infof("unconfirmed pool has %d transactions pending", len(utxns))
+
+	// Filter transactions that violate all constraints.
+	// Transactions spending the outputs of other unconfirmed transactions are included in a later block,
+	// after the transactions they depend on.
+	var filteredTxns []UnconfirmedTransaction
+	var nDeferred int
+	for _, utxn := range utxns {
+		txn := utxn.Transaction
+
+		chained, err := vs.unconfirmed.SpendsUnconfirmed(tx, txn)
+		if err != nil {
+			return coin.SignedBlock{}, err
+		}
+		if chained {
+			nDeferred++
+			continue
+		}
 
-	// Filter transactions that violate all constraints
-	var filteredTxns coin.Transactions
-	for _, txn := range txns {
 		if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, vs.Config.CreateBlockVerifyTxn, TxnSigned); err != nil {
 			switch err.(type) {
 			case ErrTxnViolatesHardConstraint, ErrTxnViolatesSoftConstraint:
@@ -304,18 +391,20 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 				return coin.SignedBlock{}, err
 			}
 		} else {
-			filteredTxns = append(filteredTxns, txn)
+			filteredTxns = append(filteredTxns, utxn)
 		}
 	}
 
-	nRemoved := len(txns) - len(filteredTxns)
+	if nDeferred > 0 {
+		logger.Infof("CreateBlock deferred %d transactions spending unconfirmed outputs", nDeferred)
+	}
+
+	nRemoved := len(utxns) - len(filteredTxns) - nDeferred
 	if nRemoved > 0 {
 		logger.Infof("CreateBlock ignored %d transactions violating constraints", nRemoved)
 	}
 
-	txns = filteredTxns
-
-	if len(txns) == 0 {
+	if len(filteredTxns) == 0 {
 		logger.Info("No transactions after filtering for constraint violations")
 		return coin.SignedBlock{}, errors.New("No transactions after filtering for constraint violations")
 	}
@@ -325,29 +414,53 @@ func (vs *Visor) createBlock(tx *dbutil.Tx, when uint64) (coin.SignedBlock, erro
 		return coin.SignedBlock{}, err
 	}
 
-	// Sort them by highest fee per kilobyte
-	txns, err = coin.SortTransactions(txns, vs.blockchain.TransactionFee(tx, head.Time()))
+	// Order the transactions with the ordering policy, and pack them in the block
+	added, err := vs.unconfirmed.GetAddedTimes(tx)
 	if err != nil {
-		logger.Critical().WithError(err).Error("SortTransactions failed, no block can be made until the offending transaction is removed")
 		return coin.SignedBlock{}, err
 	}
 
-	// Apply block size transaction limit
-	txns, err = txns.TruncateBytesTo(vs.Config.MaxBlockTransactionsSize)
-	if err != nil {
-		logger.Critical().WithError(err).Error("TruncateBytesTo failed, no block can be made until the offending transaction is removed")
-		return coin.SignedBlock{}, err
-	}
+	feeCalc := vs.blockchain.TransactionFee(tx, head.Time())
+	candidates := make([]BlockCandidate, 0, len(filteredTxns))
+	for _, utxn := range filteredTxns {
+		txn := utxn.Transaction
+		size, hash, err := txn.SizeHash()
+		if err != nil {
+			logger.Critical().WithError(err).Error("txn.SizeHash failed, no block can be made until the offending transaction is removed")
+			return coin.SignedBlock{}, err
+		}
 
-	if len(txns) > coin.MaxBlockTransactions {
-		txns = txns[:coin.MaxBlockTransactions]
+		fee, err := feeCalc(&txn)
+		if err != nil {
+			logger.Warningf("Transaction %s fee can't be computed: %v", hash.Hex(), err)
+			continue
+		}
+
+		// The time a transaction was first received is kept in its metadata,
+		// utxn.Received changes whenever the transaction is received again
+		received, ok := added[hash]
+		if !ok {
+			received = utxn.Received
+		}
+
+		candidates = append(candidates, BlockCandidate{
+			Transaction: txn,
+			Hash:        hash,
+			Size:        size,
+			Fee:         fee,
+			Received:    received,
+		})
 	}
 
+	sortBlockCandidates(candidates, vs.Config.BlockTxnOrdering)
+	txns := assembleBlock(candidates, vs.Config.MaxBlockTransactionsSize, coin.MaxBlockTransactions)
+
 	if len(txns) == 0 {
-		logger.Panic("TruncateBytesTo removed all transactions")
+		logger.Info("No transactions fit in the block")
+		return coin.SignedBlock{}, errors.New("No transactions fit in the block")
 	}
 
-	logger.Infof("Creating new block with %d transactions, head time %d", len(txns), when)
+	logger.Infof("Creating new block with %d transactions, ordered by %s, head time %d", len(txns), vs.Config.BlockTxnOrdering.Name(), when)
 
 	b, err := vs.blockchain.NewBlock(tx, txns, when)
 	if err != nil {
@@ -404,6 +517,8 @@ func (vs *Visor) executeSignedBlock(tx *dbutil.Tx, b coin.SignedBlock) error {
 		return err
 	}
 
+	vs.balances.executeBlock(tx, b.Block)
+
 	// Update the HistoryDB
 	return vs.history.ParseBlock(tx, b.Block)
 }
@@ -476,12 +591,8 @@ func (vs *Visor) unconfirmedOutgoingOutputs(tx *dbutil.Tx) (coin.UxArray, error)
 		return nil, err
 	}
 
-	var inputs []cipher.SHA256
-	for _, txn := range txns {
-		inputs = append(inputs, txn.In...)
-	}
-
-	return vs.blockchain.Unspent().GetArray(tx, inputs)
+	// The outputs created and spent by unconfirmed transactions are neither incoming nor outgoing
+	return vs.blockchain.Unspent().GetArray(tx, confirmedInputs(txns))
 }
 
 // UnconfirmedIncomingOutputs returns all outputs that would be created by unconfirmed transactions
@@ -744,19 +855,46 @@ func (vs *Visor) getBlocksVerbose(tx *dbutil.Tx, getBlocks func(*dbutil.Tx) ([]c
 // The bool return value is whether or not the transaction was already in the pool.
 // If the transaction violates hard constraints, it is rejected, and error will not be nil.
 // If the transaction only violates soft constraints, it is still injected, and the soft constraint violation is returned.
+// If the unconfirmed pool is full and the transaction is evicted as soon as it is injected, ErrTxnEvicted is returned.
+// If replace-by-fee is enabled, the transactions it replaces are removed, and if it conflicts with
+// transactions in the pool without replacing them, it is rejected.
 // This method is intended for transactions received over the network.
 func (vs *Visor) InjectForeignTransaction(txn coin.Transaction) (bool, *ErrTxnViolatesSoftConstraint, error) {
 	var known bool
 	var softErr *ErrTxnViolatesSoftConstraint
+	var evicted []EvictedTransaction
 
 	if err := vs.db.Update("InjectForeignTransaction", func(tx *dbutil.Tx) error {
+		if err := vs.replaceUnconfirmed(tx, txn, vs.Config.UnconfirmedVerifyTxn); err != nil {
+			return err
+		}
+
 		var err error
 		known, softErr, err = vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, vs.Config.UnconfirmedVerifyTxn)
+		if err != nil {
+			return err
+		}
+
+		vs.balances.injectTransaction(tx, txn)
+
+		if known {
+			return nil
+		}
+
+		evicted, err = vs.evictUnconfirmed(tx)
 		return err
 	}); err != nil {
 		return false, nil, err
 	}
 
+	// The transaction is not rejected, so that its eviction is recorded, but it must not be propagated
+	hash := txn.Hash()
+	for _, e := range evicted {
+		if e.Hash == hash {
+			return false, nil, ErrTxnEvicted
+		}
+	}
+
 	return known, softErr, nil
 }
 
@@ -784,23 +922,74 @@ func (vs *Visor) InjectUserTransaction(txn coin.Transaction) (bool, *coin.Signed
 // already in the blockchain.
 // The bool return value is whether or not the transaction was already in the pool.
 // If the transaction violates hard or soft constraints, it is rejected, and error will not be nil.
+// The transaction can spend the outputs of unconfirmed transactions.
+// The transaction is protected from eviction from the unconfirmed pool.
+// If replace-by-fee is enabled, the transactions it replaces are removed, and if it conflicts with
+// transactions in the pool without replacing them, it is rejected.
+// The transaction counts towards the daily limit of the spending policies of the wallets which own its inputs,
+// and a wallet.PolicyViolationError is returned if it exceeds one.
 // This method is only exported for use by the daemon gateway's InjectBroadcastTransaction method.
 func (vs *Visor) InjectUserTransactionTx(tx *dbutil.Tx, txn coin.Transaction) (bool, *coin.SignedBlock, coin.UxArray, error) {
 	if err := VerifySingleTxnUserConstraints(txn); err != nil {
 		return false, nil, nil, err
 	}
 
-	head, inputs, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, txn, params.UserVerifyTxn, TxnSigned)
+	head, inputs, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, txn, params.UserVerifyTxn, TxnSigned)
 	if err != nil {
 		return false, nil, nil, err
 	}
 
+	if err := vs.replaceUnconfirmed(tx, txn, params.UserVerifyTxn); err != nil {
+		return false, nil, nil, err
+	}
+
 	known, softErr, err := vs.unconfirmed.InjectTransaction(tx, vs.blockchain, txn, params.UserVerifyTxn)
 	if softErr != nil {
 		logger.WithError(softErr).Warning("InjectUserTransaction vs.unconfirmed.InjectTransaction returned a softErr unexpectedly")
 	}
+	if err != nil {
+		return known, head, inputs, err
+	}
 
-	return known, head, inputs, err
+	vs.balances.injectTransaction(tx, txn)
+
+	// Transactions injected by the user are never evicted from the pool
+	if err := vs.unconfirmed.Protect(tx, txn.Hash()); err != nil {
+		return known, head, inputs, err
+	}
+
+	if !known {
+		if _, err := vs.evictUnconfirmed(tx); err != nil {
+			return known, head, inputs, err
+		}
+	}
+
+	if err := vs.recordWalletSpend(tx, txn, inputs); err != nil {
+		return known, head, inputs, err
+	}
+
+	return known, head, inputs, nil
+}
+
+// recordWalletSpend records an injected transaction for the daily limit of the spending policies
+// of the wallets which own its inputs. The recording is undone if the database transaction is rolled back.
+func (vs *Visor) recordWalletSpend(tx *dbutil.Tx, txn coin.Transaction, inputs coin.UxArray) error {
+	if vs.wallets == nil {
+		return nil
+	}
+
+	addrs := make([]cipher.Address, len(inputs))
+	for i, in := range inputs {
+		addrs[i] = in.Body.Address
+	}
+
+	undo, err := vs.wallets.RecordSpend(&txn, addrs, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tx.OnRollback(undo)
+	return nil
 }
 
 // GetTransactionsForAddress returns the Transactions whose unspents give coins to a cipher.Address.
@@ -1610,15 +1799,15 @@ func (vs *Visor) getTransactionInputs(tx *dbutil.Tx, feeCalcTime uint64, inputs
 		return nil, err
 	}
 
-	uxOuts, err := vs.history.GetUxOuts(tx, inputs)
+	uxOuts, err := vs.getTransactionInputUxOuts(tx, inputs)
 	if err != nil {
-		logger.WithError(err).Error("getTransactionInputs GetUxOuts failed")
+		logger.WithError(err).Error("getTransactionInputs getTransactionInputUxOuts failed")
 		return nil, err
 	}
 
 	ret := make([]TransactionInput, len(inputs))
 	for i, o := range uxOuts {
-		r, err := NewTransactionInput(o.Out, feeCalcTime)
+		r, err := NewTransactionInput(o, feeCalcTime)
 		if err != nil {
 			logger.WithError(err).Error("getTransactionInputs NewTransactionInput failed")
 			return nil, err
@@ -1629,6 +1818,52 @@ func (vs *Visor) getTransactionInputs(tx *dbutil.Tx, feeCalcTime uint64, inputs
 	return ret, nil
 }
 
+// getTransactionInputUxOuts returns the outputs spent by inputs from the historydb,
+// or from the outputs of the unconfirmed transactions if they are not confirmed yet
+func (vs *Visor) getTransactionInputUxOuts(tx *dbutil.Tx, inputs []cipher.SHA256) (coin.UxArray, error) {
+	uxOuts, err := vs.history.GetUxOuts(tx, inputs)
+	switch err.(type) {
+	case nil:
+		uxa := make(coin.UxArray, len(uxOuts))
+		for i, o := range uxOuts {
+			uxa[i] = o.Out
+		}
+		return uxa, nil
+	case historydb.ErrUxOutNotExist:
+	default:
+		return nil, err
+	}
+
+	head, err := vs.blockchain.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	uxa := make(coin.UxArray, len(inputs))
+	for i, h := range inputs {
+		uxOuts, histErr := vs.history.GetUxOuts(tx, []cipher.SHA256{h})
+		switch histErr.(type) {
+		case nil:
+			uxa[i] = uxOuts[0].Out
+			continue
+		case historydb.ErrUxOutNotExist:
+		default:
+			return nil, histErr
+		}
+
+		ux, err := vs.unconfirmed.GetOutput(tx, head.Head, h)
+		if err != nil {
+			return nil, err
+		}
+		if ux == nil {
+			return nil, histErr
+		}
+		uxa[i] = *ux
+	}
+
+	return uxa, nil
+}
+
 // GetHeadBlock gets head block.
 func (vs Visor) GetHeadBlock() (*coin.SignedBlock, error) {
 	var b *coin.SignedBlock
@@ -1719,6 +1954,40 @@ func (vs *Visor) RecvOfAddresses(addrs []cipher.Address) (coin.AddressUxOuts, er
 	return uxouts, nil
 }
 
+// AddressesActivity returns whether or not each address has received any outputs,
+// either in the blockchain or in the unconfirmed transaction pool
+func (vs *Visor) AddressesActivity(addrs []cipher.Address) ([]bool, error) {
+	active := make([]bool, len(addrs))
+	addrsMap := make(map[cipher.Address]int, len(addrs))
+	for i, a := range addrs {
+		addrsMap[a] = i
+	}
+
+	if err := vs.db.View("AddressesActivity", func(tx *dbutil.Tx) error {
+		for i, a := range addrs {
+			uxs, err := vs.history.GetOutputsForAddress(tx, a)
+			if err != nil {
+				return err
+			}
+
+			active[i] = len(uxs) > 0
+		}
+
+		return vs.unconfirmed.ForEach(tx, func(_ cipher.SHA256, txn UnconfirmedTransaction) error {
+			for _, o := range txn.Transaction.Out {
+				if i, ok := addrsMap[o.Address]; ok {
+					active[i] = true
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return active, nil
+}
+
 // GetIncomingOutputs returns all predicted outputs that are in pending tx pool
 func (vs *Visor) GetIncomingOutputs() (coin.UxArray, error) {
 	var uxa coin.UxArray
@@ -1798,19 +2067,15 @@ func (vs *Visor) UnconfirmedSpendsOfAddresses(addrs []cipher.Address) (coin.Addr
 	return outs, nil
 }
 
-// unconfirmedSpendsOfAddresses returns all unconfirmed coin.UxOut spends of addresses
+// unconfirmedSpendsOfAddresses returns all unconfirmed coin.UxOut spends of addresses.
+// The spends of outputs created by unconfirmed transactions are excluded.
 func (vs *Visor) unconfirmedSpendsOfAddresses(tx *dbutil.Tx, addrs []cipher.Address) (coin.AddressUxOuts, error) {
 	txns, err := vs.unconfirmed.AllRawTransactions(tx)
 	if err != nil {
 		return nil, err
 	}
 
-	var inputs []cipher.SHA256
-	for _, txn := range txns {
-		inputs = append(inputs, txn.In...)
-	}
-
-	uxa, err := vs.blockchain.Unspent().GetArray(tx, inputs)
+	uxa, err := vs.blockchain.Unspent().GetArray(tx, confirmedInputs(txns))
 	if err != nil {
 		return nil, err
 	}
@@ -1866,19 +2131,26 @@ func (vs Visor) GetBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair,
 			return err
 		}
 
-		// Create predicted unspent outputs from the unconfirmed transactions
+		// Create predicted unspent outputs from the unconfirmed transactions,
+		// except the outputs spent by other unconfirmed transactions
 		recvUxs, err = txnOutputsForAddrs(head.Head, addrs, txns)
 		if err != nil {
 			return err
 		}
 
-		var inputs []cipher.SHA256
-		for _, txn := range txns {
-			inputs = append(inputs, txn.In...)
+		chained := chainedOutputs(txns)
+		for addr, uxs := range recvUxs {
+			unspent := uxs[:0]
+			for _, ux := range uxs {
+				if _, ok := chained[ux.Hash()]; !ok {
+					unspent = append(unspent, ux)
+				}
+			}
+			recvUxs[addr] = unspent
 		}
 
 		// Get unspents for the inputs being spent
-		uxa, err = vs.blockchain.Unspent().GetArray(tx, inputs)
+		uxa, err = vs.blockchain.Unspent().GetArray(tx, confirmedInputs(txns))
 		if err != nil {
 			return fmt.Errorf("GetArray failed when checking addresses balance: %v", err)
 		}
@@ -1922,51 +2194,60 @@ func (vs Visor) GetBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair,
 		inUxs := recvUxs[addr]
 		predictedUxs := uxs.Sub(outUxs).Add(inUxs)
 
-		coins, err := uxs.Coins()
+		bp, err := newBalancePair(uxs, predictedUxs, headTime)
 		if err != nil {
-			return nil, fmt.Errorf("uxs.Coins failed: %v", err)
+			return nil, err
 		}
 
-		coinHours, err := uxs.CoinHours(headTime)
-		if err != nil {
-			switch err {
-			case coin.ErrAddEarnedCoinHoursAdditionOverflow:
-				coinHours = 0
-			default:
-				return nil, fmt.Errorf("uxs.CoinHours failed: %v", err)
-			}
-		}
+		bps = append(bps, bp)
+	}
 
-		pcoins, err := predictedUxs.Coins()
-		if err != nil {
-			return nil, fmt.Errorf("predictedUxs.Coins failed: %v", err)
-		}
+	return bps, nil
+}
 
-		pcoinHours, err := predictedUxs.CoinHours(headTime)
-		if err != nil {
-			switch err {
-			case coin.ErrAddEarnedCoinHoursAdditionOverflow:
-				coinHours = 0
-			default:
-				return nil, fmt.Errorf("predictedUxs.CoinHours failed: %v", err)
-			}
-		}
+// newBalancePair returns the balance pair of the confirmed unspent outputs of an address and of
+// its predicted unspent outputs, after the unconfirmed transactions are confirmed
+func newBalancePair(uxs, predictedUxs coin.UxArray, headTime uint64) (wallet.BalancePair, error) {
+	coins, err := uxs.Coins()
+	if err != nil {
+		return wallet.BalancePair{}, fmt.Errorf("uxs.Coins failed: %v", err)
+	}
 
-		bp := wallet.BalancePair{
-			Confirmed: wallet.Balance{
-				Coins: coins,
-				Hours: coinHours,
-			},
-			Predicted: wallet.Balance{
-				Coins: pcoins,
-				Hours: pcoinHours,
-			},
+	coinHours, err := uxs.CoinHours(headTime)
+	if err != nil {
+		switch err {
+		case coin.ErrAddEarnedCoinHoursAdditionOverflow:
+			coinHours = 0
+		default:
+			return wallet.BalancePair{}, fmt.Errorf("uxs.CoinHours failed: %v", err)
 		}
+	}
 
-		bps = append(bps, bp)
+	pcoins, err := predictedUxs.Coins()
+	if err != nil {
+		return wallet.BalancePair{}, fmt.Errorf("predictedUxs.Coins failed: %v", err)
 	}
 
-	return bps, nil
+	pcoinHours, err := predictedUxs.CoinHours(headTime)
+	if err != nil {
+		switch err {
+		case coin.ErrAddEarnedCoinHoursAdditionOverflow:
+			coinHours = 0
+		default:
+			return wallet.BalancePair{}, fmt.Errorf("predictedUxs.CoinHours failed: %v", err)
+		}
+	}
+
+	return wallet.BalancePair{
+		Confirmed: wallet.Balance{
+			Coins: coins,
+			Hours: coinHours,
+		},
+		Predicted: wallet.Balance{
+			Coins: pcoins,
+			Hours: pcoinHours,
+		},
+	}, nil
 }
 
 // GetUnspentsOfAddrs returns unspent outputs of multiple addresses
@@ -2342,7 +2623,7 @@ func (vs *Visor) GetRichlist(includeDistribution bool) (Richlist, error) {
 }
 
 // WithUpdateTx executes a function inside of a db.Update transaction.
-// This is exported for use by the daemon gateway's InjectBroadcastTransaction method.
+// This is exported for use by the daemon gateway's InjectBroadcastTransaction and ApprovePendingSpend methods.
 // Do not use it for other purposes.
 func (vs *Visor) WithUpdateTx(name string, f func(tx *dbutil.Tx) error) error {
 	return vs.db.Update(name, func(tx *dbutil.Tx) error {
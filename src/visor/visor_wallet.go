@@ -4,10 +4,12 @@ package visor
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
 	"github.com/MDLlife/MDL/src/params"
+	"github.com/MDLlife/MDL/src/pst"
 	"github.com/MDLlife/MDL/src/transaction"
 	"github.com/MDLlife/MDL/src/util/mathutil"
 	"github.com/MDLlife/MDL/src/visor/dbutil"
@@ -46,9 +48,21 @@ var (
 	ErrUxOutsOrAddressesRequired = NewUserError(errors.New("UxOuts or Addresses must not be empty"))
 	// ErrNoSpendableOutputs after filtering unconfirmed spend outputs, there are no remaining outputs available for transaction creation
 	ErrNoSpendableOutputs = NewUserError(errors.New("All selected outputs are unavailable for spending"))
+	// ErrTxnNotUnconfirmed the transaction is not in the unconfirmed pool
+	ErrTxnNotUnconfirmed = NewUserError(errors.New("Transaction is not in the unconfirmed pool"))
+	// ErrTxnNotReplaceable the transaction doesn't signal replace-by-fee
+	ErrTxnNotReplaceable = NewUserError(errors.New("Transaction doesn't signal replace-by-fee"))
+	// ErrBumpFeeInputsNotOwned the transaction spends outputs not owned by the wallet
+	ErrBumpFeeInputsNotOwned = NewUserError(errors.New("Transaction spends outputs not owned by the wallet"))
+	// ErrBumpFeeInsufficientHours the transaction's outputs to the wallet don't have enough coin hours to burn the fee
+	ErrBumpFeeInsufficientHours = NewUserError(errors.New("Transaction outputs to the wallet don't have enough coin hours to burn the fee"))
+	// ErrUnconfirmedChangeUxOuts UnconfirmedChange and UxOuts cannot be combined
+	ErrUnconfirmedChangeUxOuts = NewUserError(errors.New("UnconfirmedChange and UxOuts cannot be combined"))
 )
 
-// GetWalletBalance returns balance pairs of specific wallet
+// GetWalletBalance returns balance pairs of specific wallet.
+// The balances are read from the balance cache, which is updated incrementally as blocks are executed
+// and unconfirmed transactions are injected or removed.
 func (vs *Visor) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.AddressBalances, error) {
 	var addressBalances wallet.AddressBalances
 	var walletBalance wallet.BalancePair
@@ -62,7 +76,7 @@ func (vs *Visor) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.Addr
 			return err
 		}
 
-		addrsBalanceList, err = vs.GetBalanceOfAddrs(addrs)
+		addrsBalanceList, err = vs.getCachedBalanceOfAddrs(addrs)
 		return err
 	}); err != nil {
 		return walletBalance, addressBalances, err
@@ -101,6 +115,95 @@ func (vs *Visor) GetWalletBalance(wltID string) (wallet.BalancePair, wallet.Addr
 	return walletBalance, addressBalances, nil
 }
 
+// getCachedBalanceOfAddrs returns balance pairs of given addresses from the balance cache.
+// The addresses which are not cached are loaded from the database. If the cache can't be loaded
+// because it is being updated, the balances are computed by GetBalanceOfAddrs.
+func (vs *Visor) getCachedBalanceOfAddrs(addrs []cipher.Address) ([]wallet.BalancePair, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	if bps, ok, err := vs.balances.balanceOfAddrs(addrs); err != nil || ok {
+		return bps, err
+	}
+
+	missing, loaded, gen, ok := vs.balances.missing(addrs)
+	if ok {
+		var head *coin.SignedBlock
+		var txns coin.Transactions
+		var auxs coin.AddressUxOuts
+
+		if err := vs.db.View("getCachedBalanceOfAddrs", func(tx *dbutil.Tx) error {
+			var err error
+			head, err = vs.blockchain.Head(tx)
+			if err != nil {
+				return err
+			}
+
+			if !loaded {
+				txns, err = vs.unconfirmed.AllRawTransactions(tx)
+				if err != nil {
+					return err
+				}
+			}
+
+			auxs, err = vs.blockchain.Unspent().GetUnspentsOfAddrs(tx, missing)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		if vs.balances.load(gen, head.Head, txns, auxs) {
+			if bps, ok, err := vs.balances.balanceOfAddrs(addrs); err != nil || ok {
+				return bps, err
+			}
+		}
+	}
+
+	return vs.GetBalanceOfAddrs(addrs)
+}
+
+// GetWalletFrozenOutputs returns the frozen outputs of a wallet which are unspent.
+// Frozen outputs which have been spent, or are not known, are omitted.
+func (vs *Visor) GetWalletFrozenOutputs(wltID string) ([]UnspentOutput, error) {
+	var hashes []cipher.SHA256
+	if err := vs.wallets.View(wltID, func(w *wallet.Wallet) error {
+		hashes = w.FrozenOutputs()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+
+	var uxa coin.UxArray
+	var head *coin.SignedBlock
+	if err := vs.db.View("GetWalletFrozenOutputs", func(tx *dbutil.Tx) error {
+		var err error
+		head, err = vs.blockchain.Head(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, h := range hashes {
+			ux, err := vs.blockchain.Unspent().Get(tx, h)
+			if err != nil {
+				return err
+			}
+			if ux != nil {
+				uxa = append(uxa, *ux)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return NewUnspentOutputs(uxa, head.Time())
+}
+
 // GetWalletUnconfirmedTransactions returns all unconfirmed transactions in given wallet
 func (vs *Visor) GetWalletUnconfirmedTransactions(wltID string) ([]UnconfirmedTransaction, error) {
 	var txns []UnconfirmedTransaction
@@ -150,13 +253,14 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 		return nil, nil, ErrTransactionAlreadySigned
 	}
 
+	// The spending policy of the wallet is checked by wallet.SignTransaction before signing
 	if err := vs.wallets.ViewSecrets(wltID, password, func(w *wallet.Wallet) error {
 		return vs.db.View("WalletSignTransaction", func(tx *dbutil.Tx) error {
 			// Verify the transaction before signing
 			if err := VerifySingleTxnUserConstraints(*txn); err != nil {
 				return err
 			}
-			if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *txn, params.UserVerifyTxn, TxnUnsigned); err != nil {
+			if _, _, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, *txn, params.UserVerifyTxn, TxnUnsigned); err != nil {
 				return err
 			}
 
@@ -193,7 +297,7 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 				return err
 			}
 
-			if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *signedTxn, params.UserVerifyTxn, signed); err != nil {
+			if _, _, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, *signedTxn, params.UserVerifyTxn, signed); err != nil {
 				// This shouldn't happen since we verified in the beginning; if it does, then wallet.SignTransaction has a bug
 				logger.Critical().WithError(err).Error("Signed transaction violates transaction constraints")
 				return err
@@ -208,6 +312,235 @@ func (vs *Visor) WalletSignTransaction(wltID string, password []byte, txn *coin.
 	return signedTxn, inputs, nil
 }
 
+// WalletBumpFee creates and signs a transaction replacing an unconfirmed transaction of the wallet, which burns fee coin hours.
+// The replacement spends the same inputs and has the same outputs, except that the additional coin hours burned are
+// taken from the change outputs first, then from the other outputs to the wallet's addresses.
+// If fee is 0, the minimum fee for the replacement to be accepted by the unconfirmed pool is used.
+// The unconfirmed transaction must signal replace-by-fee. The replacement signals it too, so that its fee can be bumped
+// again, unless its last output is left without coin hours. This may burn up to coin.ReplaceableHoursDivisor-1 coin hours
+// more than fee.
+// The replacement is not injected.
+func (vs *Visor) WalletBumpFee(wltID string, password []byte, txid cipher.SHA256, fee uint64) (*coin.Transaction, []TransactionInput, error) {
+	if !vs.Config.ReplaceByFee {
+		return nil, nil, ErrReplaceByFeeDisabled
+	}
+
+	var txn coin.Transaction
+	if err := vs.wallets.View(wltID, func(w *wallet.Wallet) error {
+		return vs.db.View("WalletBumpFee", func(tx *dbutil.Tx) error {
+			utxn, err := vs.unconfirmed.Get(tx, txid)
+			if err != nil {
+				return err
+			}
+			if utxn == nil {
+				return ErrTxnNotUnconfirmed
+			}
+			if !utxn.Transaction.IsReplaceable() {
+				return ErrTxnNotReplaceable
+			}
+
+			head, err := vs.blockchain.Head(tx)
+			if err != nil {
+				logger.WithError(err).Error("blockchain.Head failed")
+				return err
+			}
+
+			inputs, err := vs.getTransactionInputs(tx, head.Time(), utxn.Transaction.In)
+			if err != nil {
+				return err
+			}
+
+			for _, in := range inputs {
+				if !w.HasEntry(in.UxOut.Body.Address) {
+					return ErrBumpFeeInputsNotOwned
+				}
+			}
+
+			feeCalc := vs.unconfirmed.TransactionFee(tx, vs.blockchain, head.Head)
+			oldFee, err := feeCalc(&utxn.Transaction)
+			if err != nil {
+				return err
+			}
+
+			// The replacement also replaces the transactions which conflict with the original transaction,
+			// and the transactions spending their outputs
+			conflicts, err := vs.unconfirmed.GetConflicts(tx, utxn.Transaction)
+			if err != nil {
+				return err
+			}
+
+			hashes := []cipher.SHA256{txid}
+			for _, c := range conflicts {
+				hashes = append(hashes, c.Transaction.Hash())
+			}
+
+			descendants, err := vs.unconfirmed.GetDescendants(tx, hashes)
+			if err != nil {
+				return err
+			}
+
+			replacedFee := oldFee
+			for _, c := range append(conflicts, descendants...) {
+				f, err := feeCalc(&c.Transaction)
+				if err != nil {
+					continue
+				}
+				replacedFee, err = mathutil.AddUint64(replacedFee, f)
+				if err != nil {
+					return err
+				}
+			}
+
+			size, err := utxn.Transaction.Size()
+			if err != nil {
+				return err
+			}
+
+			minFee, err := replacementFee(replacedFee, size, vs.Config.ReplaceByFeeIncrement)
+			if err != nil {
+				return err
+			}
+
+			if fee == 0 {
+				fee = minFee
+			} else if fee < minFee {
+				return NewUserError(fmt.Errorf("Fee must be at least %d coin hours to replace the transaction", minFee))
+			}
+
+			txn = utxn.Transaction
+			txn.In = append([]cipher.SHA256{}, utxn.Transaction.In...)
+			txn.Out = append([]coin.TransactionOutput{}, utxn.Transaction.Out...)
+			txn.Sigs = make([]cipher.Sig, len(txn.In))
+
+			extra := fee - oldFee
+			for _, i := range bumpFeeOutputs(w, txn, inputs) {
+				if extra == 0 {
+					break
+				}
+
+				hours := txn.Out[i].Hours
+				if hours > extra {
+					hours = extra
+				}
+				txn.Out[i].Hours -= hours
+				extra -= hours
+			}
+
+			if extra > 0 {
+				return ErrBumpFeeInsufficientHours
+			}
+
+			// Only the last output can have lost the signal, if it is an output to the wallet
+			if !txn.IsReplaceable() {
+				if err := txn.SignalReplaceable(); err != nil && err != coin.ErrReplaceableNoHours {
+					return err
+				}
+			}
+
+			return txn.UpdateHeader()
+		})
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return vs.WalletSignTransaction(wltID, password, &txn, nil)
+}
+
+// bumpFeeOutputs returns the indexes of the outputs of txn which the additional coin hours burned by a
+// replacement can be taken from. The change outputs come first, they are the outputs to the wallet's change chain
+// and to the addresses of the inputs, where the change is sent by wallets without a change chain.
+// The other outputs to the wallet's addresses follow, so that the coin hours sent to a recipient which is an address
+// of the wallet are only reduced if the change is insufficient. Each group starts from the last output.
+func bumpFeeOutputs(w *wallet.Wallet, txn coin.Transaction, inputs []TransactionInput) []int {
+	changeAddrs := make(map[cipher.Address]struct{}, len(inputs))
+	for _, in := range inputs {
+		changeAddrs[in.UxOut.Body.Address] = struct{}{}
+	}
+	for _, e := range w.ChangeEntries() {
+		changeAddrs[e.MDLAddress()] = struct{}{}
+	}
+
+	var change, other []int
+	for i := len(txn.Out) - 1; i >= 0; i-- {
+		addr := txn.Out[i].Address
+		if _, ok := changeAddrs[addr]; ok {
+			change = append(change, i)
+		} else if w.HasEntry(addr) {
+			other = append(other, i)
+		}
+	}
+
+	return append(change, other...)
+}
+
+// CreatePST creates a partially-signed transaction from an unsigned or partially signed transaction, with the
+// unspent outputs spent by its inputs. The transaction must be valid and spendable.
+// If wltID is not empty, the bip44 derivation paths of the inputs owned by the wallet are added.
+func (vs *Visor) CreatePST(txn coin.Transaction, wltID string) (*pst.PST, error) {
+	if txn.IsFullySigned() {
+		return nil, ErrTransactionAlreadySigned
+	}
+
+	var p *pst.PST
+	if err := vs.db.View("CreatePST", func(tx *dbutil.Tx) error {
+		if err := VerifySingleTxnUserConstraints(txn); err != nil {
+			return err
+		}
+		if _, _, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, txn, params.UserVerifyTxn, TxnUnsigned); err != nil {
+			return err
+		}
+
+		headTime, err := vs.blockchain.Time(tx)
+		if err != nil {
+			logger.WithError(err).Error("blockchain.Time failed")
+			return err
+		}
+
+		inputs, err := vs.getTransactionInputs(tx, headTime, txn.In)
+		if err != nil {
+			return err
+		}
+
+		uxOuts := make([]coin.UxOut, len(inputs))
+		for i, in := range inputs {
+			uxOuts[i] = in.UxOut
+		}
+
+		p, err = pst.New(txn, uxOuts, headTime)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if wltID != "" {
+		if err := vs.wallets.View(wltID, func(w *wallet.Wallet) error {
+			p.SetPaths(w)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// WalletSignPST signs the inputs of a partially-signed transaction owned by a wallet.
+// Specific inputs may be signed by specifying signIndexes, if signIndexes is empty,
+// all the unsigned inputs owned by the wallet are signed.
+// The transaction is verified with the unspent outputs of the partially-signed transaction only,
+// without access to the blockchain, so that it can be signed by an offline node.
+func (vs *Visor) WalletSignPST(wltID string, password []byte, p *pst.PST, signIndexes []int) error {
+	// The spending policy of the wallet is checked by wallet.SignTransaction before signing
+	return vs.wallets.ViewSecrets(wltID, password, func(w *wallet.Wallet) error {
+		if err := p.Sign(w, signIndexes); err != nil {
+			return err
+		}
+
+		p.SetPaths(w)
+		return nil
+	})
+}
+
 // CreateTransactionParams parameters for transaction creation
 type CreateTransactionParams struct {
 	UxOuts    []cipher.SHA256
@@ -215,6 +548,11 @@ type CreateTransactionParams struct {
 	// IgnoreUnconfirmed if true, outputs matching Addresses or UxOuts spent by
 	// an unconfirmed transactions will be ignored, otherwise an error will be returned
 	IgnoreUnconfirmed bool
+	// UnconfirmedChange if true, the outputs sent to Addresses by unconfirmed transactions can be spent,
+	// if the unconfirmed transactions and the transactions they depend on only spend outputs of the wallet,
+	// or of Addresses when the transaction is not created by a wallet.
+	// Outputs spent by unconfirmed transactions are ignored, as with IgnoreUnconfirmed.
+	UnconfirmedChange bool
 }
 
 // Validate validates params
@@ -223,6 +561,10 @@ func (p CreateTransactionParams) Validate() error {
 		return ErrCreateTransactionParamsConflict
 	}
 
+	if len(p.UxOuts) != 0 && p.UnconfirmedChange {
+		return ErrUnconfirmedChangeUxOuts
+	}
+
 	// Check for duplicate addresses
 	addressMap := make(map[cipher.Address]struct{}, len(p.Addresses))
 	for _, a := range p.Addresses {
@@ -259,9 +601,28 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 		return nil, nil, err
 	}
 
+	// Refuse watch-only wallets before a change address is added to the wallet
+	if err := vs.wallets.View(wltID, func(w *wallet.Wallet) error {
+		if w.IsWatchOnly() {
+			return wallet.ErrWalletWatchOnly
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if p.ChangeAddress == nil {
+		changeAddr, err := vs.walletChangeAddress(wltID)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.ChangeAddress = changeAddr
+	}
+
 	var txn *coin.Transaction
 	var inputs []TransactionInput
 
+	// The spending policy of the wallet is checked by wallet.CreateTransactionSigned before signing
 	if err := vs.wallets.ViewSecrets(wltID, password, func(w *wallet.Wallet) error {
 		var err error
 		txn, inputs, err = vs.walletCreateTransaction("WalletCreateTransactionSigned", w, p, wp, TxnSigned)
@@ -273,7 +634,8 @@ func (vs *Visor) WalletCreateTransactionSigned(wltID string, password []byte, p
 	return txn, inputs, nil
 }
 
-// WalletCreateTransaction creates a transaction based upon the parameters in CreateTransactionParams
+// WalletCreateTransaction creates a transaction based upon the parameters in CreateTransactionParams.
+// For wallets with a change chain, the change address may be added to the wallet, see walletChangeAddress.
 func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp CreateTransactionParams) (*coin.Transaction, []TransactionInput, error) {
 	// Validate params before opening wallet
 	if err := p.Validate(); err != nil {
@@ -283,6 +645,14 @@ func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp
 		return nil, nil, err
 	}
 
+	if p.ChangeAddress == nil {
+		changeAddr, err := vs.walletChangeAddress(wltID)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.ChangeAddress = changeAddr
+	}
+
 	var txn *coin.Transaction
 	var inputs []TransactionInput
 
@@ -297,6 +667,60 @@ func (vs *Visor) WalletCreateTransaction(wltID string, p transaction.Params, wp
 	return txn, inputs, nil
 }
 
+// walletChangeAddress returns the change address to use for a transaction created by a wallet
+// with a bip44 change chain (bip44 and xpub wallets).
+// The first change address without any activity is used. If all change addresses have been used,
+// a new address is added to the wallet's change chain. The address stays the first unused change
+// address until it receives coins, so creating several transactions without broadcasting them
+// adds at most one change address to the wallet.
+// Returns nil for other wallet types, which send change to one of the spending addresses.
+func (vs *Visor) walletChangeAddress(wltID string) (*cipher.Address, error) {
+	var changeAddr cipher.Address
+	var hasChangeChain, isNew bool
+	if err := vs.wallets.View(wltID, func(w *wallet.Wallet) error {
+		if !w.HasChangeChain() {
+			return nil
+		}
+		hasChangeChain = true
+
+		var err error
+		changeAddr, isNew, err = w.PeekChangeAddress(vs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if !hasChangeChain {
+		return nil, nil
+	}
+
+	if !isNew {
+		return &changeAddr, nil
+	}
+
+	// The change address is peeked again while the wallet is locked for writing,
+	// since another transaction may have added a change address after the first peek
+	if err := vs.wallets.Update(wltID, func(w *wallet.Wallet) error {
+		var err error
+		changeAddr, isNew, err = w.PeekChangeAddress(vs)
+		if err != nil || !isNew {
+			return err
+		}
+
+		addrs, err := w.GenerateChangeAddresses(1)
+		if err != nil {
+			return err
+		}
+
+		changeAddr = addrs[0].(cipher.Address)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &changeAddr, nil
+}
+
 func (vs *Visor) walletCreateTransaction(methodName string, w *wallet.Wallet, p transaction.Params, wp CreateTransactionParams, signed TxnSignedFlag) (*coin.Transaction, []TransactionInput, error) {
 	if err := p.Validate(); err != nil {
 		return nil, nil, err
@@ -373,12 +797,21 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 		}
 	} else {
 		var err error
-		auxs, err = vs.getCreateTransactionAuxsAddress(tx, addrs, wp.IgnoreUnconfirmed)
+		// Frozen outputs are only spent when they are requested in wp.UxOuts
+		if wp.UnconfirmedChange {
+			auxs, err = vs.getCreateTransactionAuxsChange(tx, addrs, w.FrozenOutputs(), walletAddressesMap)
+		} else {
+			auxs, err = vs.getCreateTransactionAuxsAddress(tx, addrs, wp.IgnoreUnconfirmed, w.FrozenOutputs())
+		}
 		if err != nil {
 			return nil, nil, err
 		}
 	}
 
+	if err := vs.setEstimatedFeeRate(tx, &p); err != nil {
+		return nil, nil, err
+	}
+
 	// Create and sign transaction
 	var txn *coin.Transaction
 	var uxb []transaction.UxBalance
@@ -405,7 +838,7 @@ func (vs *Visor) walletCreateTransactionTx(tx *dbutil.Tx, methodName string,
 	// because the wallet is not aware of visor-level constraints.
 	// Check that the transaction is valid before returning it to the caller.
 	// TODO -- decimal restriction was moved to params/ package so the wallet can verify now. Move visor/verify to new package?
-	if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *txn, params.UserVerifyTxn, signed); err != nil {
+	if _, _, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, *txn, params.UserVerifyTxn, signed); err != nil {
 		logger.WithError(err).Error("Created transaction violates transaction soft/hard constraints")
 		return nil, nil, err
 	}
@@ -454,13 +887,23 @@ func (vs *Visor) createTransactionTx(tx *dbutil.Tx, p transaction.Params, wp Cre
 	var auxs coin.AddressUxOuts
 	if len(wp.UxOuts) != 0 {
 		auxs, err = vs.getCreateTransactionAuxsUxOut(tx, wp.UxOuts, wp.IgnoreUnconfirmed)
+	} else if wp.UnconfirmedChange {
+		owners := make(map[cipher.Address]struct{}, len(wp.Addresses))
+		for _, a := range wp.Addresses {
+			owners[a] = struct{}{}
+		}
+		auxs, err = vs.getCreateTransactionAuxsChange(tx, wp.Addresses, nil, owners)
 	} else {
-		auxs, err = vs.getCreateTransactionAuxsAddress(tx, wp.Addresses, wp.IgnoreUnconfirmed)
+		auxs, err = vs.getCreateTransactionAuxsAddress(tx, wp.Addresses, wp.IgnoreUnconfirmed, nil)
 	}
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if err := vs.setEstimatedFeeRate(tx, &p); err != nil {
+		return nil, nil, err
+	}
+
 	txn, uxb, err := transaction.Create(p, auxs, head.Time())
 	if err != nil {
 		return nil, nil, err
@@ -475,7 +918,7 @@ func (vs *Visor) createTransactionTx(tx *dbutil.Tx, p transaction.Params, wp Cre
 	// because the wallet is not aware of visor-level constraints.
 	// Check that the transaction is valid before returning it to the caller.
 	// TODO -- decimal restriction was moved to params/ package so the wallet can verify now. Move visor/verify to new package?
-	if _, _, err := vs.blockchain.VerifySingleTxnSoftHardConstraints(tx, *txn, params.UserVerifyTxn, TxnUnsigned); err != nil {
+	if _, _, err := vs.unconfirmed.VerifyTransaction(tx, vs.blockchain, *txn, params.UserVerifyTxn, TxnUnsigned); err != nil {
 		logger.WithError(err).Error("Created transaction violates transaction soft/hard constraints")
 		return nil, nil, err
 	}
@@ -542,8 +985,9 @@ func (vs *Visor) getCreateTransactionAuxsUxOut(tx *dbutil.Tx, uxOutHashes []ciph
 }
 
 // getCreateTransactionAuxsAddress returns a map of the addresses to their unspent outputs,
-// filtering or erroring on unconfirmed outputs depending on the value of ignoreUnconfirmed
-func (vs *Visor) getCreateTransactionAuxsAddress(tx *dbutil.Tx, addrs []cipher.Address, ignoreUnconfirmed bool) (coin.AddressUxOuts, error) {
+// filtering or erroring on unconfirmed outputs depending on the value of ignoreUnconfirmed.
+// The frozen outputs are excluded.
+func (vs *Visor) getCreateTransactionAuxsAddress(tx *dbutil.Tx, addrs []cipher.Address, ignoreUnconfirmed bool, frozen []cipher.SHA256) (coin.AddressUxOuts, error) {
 	// Get all address unspent hashes
 	addrHashes, err := vs.blockchain.Unspent().GetUnspentHashesOfAddrs(tx, addrs)
 	if err != nil {
@@ -555,5 +999,163 @@ func (vs *Visor) getCreateTransactionAuxsAddress(tx *dbutil.Tx, addrs []cipher.A
 		return nil, transaction.ErrNoUnspents
 	}
 
+	if len(frozen) != 0 {
+		frozenMap := make(map[cipher.SHA256]struct{}, len(frozen))
+		for _, h := range frozen {
+			frozenMap[h] = struct{}{}
+		}
+
+		spendable := hashes[:0]
+		for _, h := range hashes {
+			if _, ok := frozenMap[h]; !ok {
+				spendable = append(spendable, h)
+			}
+		}
+
+		if len(spendable) == 0 {
+			return nil, ErrNoSpendableOutputs
+		}
+		hashes = spendable
+	}
+
 	return vs.getCreateTransactionAuxsUxOut(tx, hashes, ignoreUnconfirmed)
 }
+
+// getCreateTransactionAuxsChange returns a map of the addresses to their unspent outputs, like
+// getCreateTransactionAuxsAddress with ignoreUnconfirmed, and to the outputs sent to them by unconfirmed transactions
+// which only depend on outputs of owners. The frozen outputs are excluded.
+func (vs *Visor) getCreateTransactionAuxsChange(tx *dbutil.Tx, addrs []cipher.Address, frozen []cipher.SHA256, owners map[cipher.Address]struct{}) (coin.AddressUxOuts, error) {
+	auxs, err := vs.getCreateTransactionAuxsAddress(tx, addrs, true, frozen)
+	switch err {
+	case nil, transaction.ErrNoUnspents, ErrNoSpendableOutputs:
+	default:
+		return nil, err
+	}
+
+	change, changeErr := vs.getUnconfirmedChange(tx, addrs, frozen, owners)
+	if changeErr != nil {
+		return nil, changeErr
+	}
+
+	if len(change) == 0 {
+		return auxs, err
+	}
+
+	if auxs == nil {
+		auxs = make(coin.AddressUxOuts, len(change))
+	}
+	for a, uxa := range change {
+		auxs[a] = append(auxs[a], uxa...)
+	}
+
+	return auxs, nil
+}
+
+// getUnconfirmedChange returns the outputs sent to addrs by unconfirmed transactions, if the transactions
+// and the unconfirmed transactions they depend on only spend outputs of owners.
+// The outputs spent by unconfirmed transactions and the frozen outputs are excluded.
+// The outputs are created in the head block, so they have not earned coin hours.
+func (vs *Visor) getUnconfirmedChange(tx *dbutil.Tx, addrs []cipher.Address, frozen []cipher.SHA256, owners map[cipher.Address]struct{}) (coin.AddressUxOuts, error) {
+	head, err := vs.blockchain.Head(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txns, err := vs.unconfirmed.AllRawTransactions(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	type creator struct {
+		hash    cipher.SHA256
+		address cipher.Address
+	}
+
+	txnsMap := make(map[cipher.SHA256]coin.Transaction, len(txns))
+	creators := make(map[cipher.SHA256]creator)
+	excluded := make(map[cipher.SHA256]struct{}, len(frozen))
+	for _, h := range frozen {
+		excluded[h] = struct{}{}
+	}
+	for _, txn := range txns {
+		hash := txn.Hash()
+		txnsMap[hash] = txn
+		for _, o := range txn.Out {
+			creators[o.UxID(hash)] = creator{
+				hash:    hash,
+				address: o.Address,
+			}
+		}
+		for _, h := range txn.In {
+			excluded[h] = struct{}{}
+		}
+	}
+
+	// owned returns true if the transaction and its unconfirmed ancestors only spend outputs of owners
+	ownedTxns := make(map[cipher.SHA256]bool)
+	var owned func(hash cipher.SHA256) (bool, error)
+	owned = func(hash cipher.SHA256) (bool, error) {
+		if ok, known := ownedTxns[hash]; known {
+			return ok, nil
+		}
+		ownedTxns[hash] = false
+
+		for _, h := range txnsMap[hash].In {
+			if c, ok := creators[h]; ok {
+				if _, ok := owners[c.address]; !ok {
+					return false, nil
+				}
+
+				spendable, err := owned(c.hash)
+				if err != nil || !spendable {
+					return false, err
+				}
+				continue
+			}
+
+			ux, err := vs.blockchain.Unspent().Get(tx, h)
+			if err != nil {
+				return false, err
+			}
+			if ux == nil {
+				return false, nil
+			}
+			if _, ok := owners[ux.Body.Address]; !ok {
+				return false, nil
+			}
+		}
+
+		ownedTxns[hash] = true
+		return true, nil
+	}
+
+	addrsMap := make(map[cipher.Address]struct{}, len(addrs))
+	for _, a := range addrs {
+		addrsMap[a] = struct{}{}
+	}
+
+	auxs := make(coin.AddressUxOuts)
+	for _, txn := range txns {
+		hash := txn.Hash()
+		for i, o := range txn.Out {
+			if _, ok := addrsMap[o.Address]; !ok {
+				continue
+			}
+			if _, ok := excluded[o.UxID(hash)]; ok {
+				continue
+			}
+
+			spendable, err := owned(hash)
+			if err != nil {
+				return nil, err
+			}
+			if !spendable {
+				break
+			}
+
+			auxs[o.Address] = append(auxs[o.Address], unconfirmedOutput(head.Head, hash, txn, i))
+		}
+	}
+
+	return auxs, nil
+}
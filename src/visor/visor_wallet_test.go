@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/MDLlife/MDL/src/transaction"
 	"github.com/MDLlife/MDL/src/visor/blockdb"
 	"github.com/MDLlife/MDL/src/visor/dbutil"
+	"github.com/MDLlife/MDL/src/visor/historydb"
 	"github.com/MDLlife/MDL/src/wallet"
 )
 
@@ -308,7 +310,7 @@ func TestCreateTransaction(t *testing.T) {
 			b.On("Unspent").Return(up)
 
 			if tc.txn != nil {
-				b.On("VerifySingleTxnSoftHardConstraints", matchDBTx, *tc.txn, params.UserVerifyTxn, TxnUnsigned).Return(nil, nil, tc.verifyErr)
+				ut.On("VerifyTransaction", matchDBTx, b, *tc.txn, params.UserVerifyTxn, TxnUnsigned).Return(nil, nil, tc.verifyErr)
 			}
 
 			db, shutdown := prepareDB(t)
@@ -707,7 +709,7 @@ func TestWalletCreateTransaction(t *testing.T) {
 			})
 
 			if tc.txn != nil {
-				b.On("VerifySingleTxnSoftHardConstraints", matchDBTx, matchTxnIgnoreSigs, params.UserVerifyTxn, tc.signed).Return(nil, nil, tc.verifyErr)
+				ut.On("VerifyTransaction", matchDBTx, b, matchTxnIgnoreSigs, params.UserVerifyTxn, tc.signed).Return(nil, nil, tc.verifyErr)
 			}
 
 			db, shutdown := prepareDB(t)
@@ -1123,6 +1125,7 @@ func TestGetCreateTransactionAuxsAddress(t *testing.T) {
 		name              string
 		ignoreUnconfirmed bool
 		addrs             []cipher.Address
+		frozen            []cipher.SHA256
 		expectedAuxs      coin.AddressUxOuts
 		err               error
 
@@ -1187,6 +1190,60 @@ func TestGetCreateTransactionAuxsAddress(t *testing.T) {
 			},
 		},
 
+		{
+			name:           "frozen outputs excluded",
+			addrs:          allAddrs,
+			frozen:         []cipher.SHA256{hashes[1], hashes[10]},
+			getArrayInputs: []cipher.SHA256{hashes[0], hashes[2], hashes[3]},
+			getArray: coin.UxArray{
+				coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: srcTxns[5],
+						Address:        allAddrs[1],
+					},
+				},
+				coin.UxOut{
+					Body: coin.UxBody{
+						SrcTransaction: srcTxns[6],
+						Address:        allAddrs[3],
+					},
+				},
+			},
+			getUnspentHashesOfAddrs: blockdb.AddressHashes{
+				allAddrs[1]: hashes[0:2],
+				allAddrs[3]: hashes[2:4],
+			},
+			expectedAuxs: coin.AddressUxOuts{
+				allAddrs[1]: []coin.UxOut{
+					coin.UxOut{
+						Body: coin.UxBody{
+							SrcTransaction: srcTxns[5],
+							Address:        allAddrs[1],
+						},
+					},
+				},
+				allAddrs[3]: []coin.UxOut{
+					coin.UxOut{
+						Body: coin.UxBody{
+							SrcTransaction: srcTxns[6],
+							Address:        allAddrs[3],
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name:   "err, all outputs frozen",
+			addrs:  allAddrs,
+			frozen: hashes[0:4],
+			err:    ErrNoSpendableOutputs,
+			getUnspentHashesOfAddrs: blockdb.AddressHashes{
+				allAddrs[1]: hashes[0:2],
+				allAddrs[3]: hashes[2:4],
+			},
+		},
+
 		{
 			name:       "err, unconfirmed spends",
 			addrs:      allAddrs,
@@ -1290,7 +1347,7 @@ func TestGetCreateTransactionAuxsAddress(t *testing.T) {
 			var auxs coin.AddressUxOuts
 			err := v.db.View("", func(tx *dbutil.Tx) error {
 				var err error
-				auxs, err = v.getCreateTransactionAuxsAddress(tx, tc.addrs, tc.ignoreUnconfirmed)
+				auxs, err = v.getCreateTransactionAuxsAddress(tx, tc.addrs, tc.ignoreUnconfirmed, tc.frozen)
 				return err
 			})
 
@@ -1372,3 +1429,76 @@ func unconfirmedForEachMockRun(t *testing.T, unconfirmedTxns []coin.Transaction,
 		}
 	}
 }
+
+func TestWalletChangeAddress(t *testing.T) {
+	db, shutdown := prepareDB(t)
+	defer shutdown()
+
+	bc, err := NewBlockchain(db, BlockchainConfig{
+		Pubkey:      genPublic,
+		Arbitrating: true,
+	})
+	require.NoError(t, err)
+
+	unconfirmed, err := NewUnconfirmedTransactionPool(db)
+	require.NoError(t, err)
+
+	ws, err := wallet.NewService(wallet.Config{
+		EnableWalletAPI: true,
+		CryptoType:      wallet.CryptoTypeScryptChacha20poly1305Insecure,
+		WalletDir:       prepareWltDir(),
+	})
+	require.NoError(t, err)
+
+	wltID := "foo.wlt"
+	_, err = ws.CreateWallet(wltID, wallet.Options{
+		Coin: wallet.CoinTypeMDL,
+		Type: wallet.WalletTypeBip44,
+		Seed: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+	}, nil)
+	require.NoError(t, err)
+
+	v := &Visor{
+		Config:      NewConfig(),
+		unconfirmed: unconfirmed,
+		blockchain:  bc,
+		db:          db,
+		history:     historydb.New(),
+		wallets:     ws,
+	}
+
+	// Concurrent transactions from the same wallet use the same new change address
+	n := 10
+	addrs := make([]*cipher.Address, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			addrs[i], errs[i] = v.walletChangeAddress(wltID)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, errs[i])
+		require.NotNil(t, addrs[i])
+		require.Equal(t, *addrs[0], *addrs[i])
+	}
+
+	w, err := ws.GetWallet(wltID)
+	require.NoError(t, err)
+	entries := w.ChangeEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, *addrs[0], entries[0].MDLAddress())
+
+	// The unused change address is reused
+	addr, err := v.walletChangeAddress(wltID)
+	require.NoError(t, err)
+	require.Equal(t, *addrs[0], *addr)
+
+	w, err = ws.GetWallet(wltID)
+	require.NoError(t, err)
+	require.Len(t, w.ChangeEntries(), 1)
+}
@@ -0,0 +1,238 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/util/file"
+)
+
+const (
+	// BackupExt wallet backup archive file extension
+	BackupExt = ".wbk"
+
+	// backupVersion is the version of the backup archive format
+	backupVersion = "0.1"
+)
+
+var (
+	// ErrBackupEmpty is returned when creating a backup archive without wallets, or restoring an archive that has no wallets
+	ErrBackupEmpty = NewError(errors.New("no wallets in the backup archive"))
+	// ErrBackupChecksumMismatch is returned when the checksum of a backup archive doesn't match its data
+	ErrBackupChecksumMismatch = NewError(errors.New("backup archive checksum mismatch, the archive is corrupted"))
+	// ErrBackupVersionUnsupported is returned when restoring a backup archive of an unknown version
+	ErrBackupVersionUnsupported = NewError(errors.New("unsupported backup archive version"))
+	// ErrBackupWalletExists is returned when restoring a wallet that is already loaded, without overwriting it
+	ErrBackupWalletExists = NewError(errors.New("a wallet of the backup archive already exists"))
+)
+
+// BackupArchive is an encrypted archive of one or more wallets.
+// The wallets are serialized like wallet files, encrypted with the password of the archive,
+// and checksummed so that a corrupted archive is detected before it is decrypted.
+// Wallets that are encrypted stay encrypted with their own password in the archive.
+type BackupArchive struct {
+	Version    string     `json:"version"`
+	Timestamp  int64      `json:"timestamp"`
+	CryptoType CryptoType `json:"crypto_type"`
+	// Checksum is the hex encoded SHA256 hash of Data
+	Checksum string `json:"checksum"`
+	// Data is the encrypted list of wallets
+	Data string `json:"data"`
+}
+
+// backupPayload is the content of the encrypted data of a BackupArchive
+type backupPayload struct {
+	Wallets []*ReadableWallet `json:"wallets"`
+}
+
+// NewBackupArchive creates a backup archive of the wallets, encrypted with password and cryptoType
+func NewBackupArchive(wlts []*Wallet, password []byte, cryptoType CryptoType) (*BackupArchive, error) {
+	c, err := getCrypto(cryptoType)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBackupArchive(wlts, password, cryptoType, c)
+}
+
+func newBackupArchive(wlts []*Wallet, password []byte, cryptoType CryptoType, c cryptor) (*BackupArchive, error) {
+	if len(wlts) == 0 {
+		return nil, ErrBackupEmpty
+	}
+
+	if len(password) == 0 {
+		return nil, ErrMissingPassword
+	}
+
+	payload := backupPayload{
+		Wallets: make([]*ReadableWallet, len(wlts)),
+	}
+	for i, w := range wlts {
+		payload.Wallets[i] = NewReadableWallet(w)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		for i := range data {
+			data[i] = 0
+		}
+	}()
+
+	encData, err := c.Encrypt(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BackupArchive{
+		Version:    backupVersion,
+		Timestamp:  time.Now().UTC().Unix(),
+		CryptoType: cryptoType,
+		Checksum:   backupChecksum(encData),
+		Data:       string(encData),
+	}, nil
+}
+
+func backupChecksum(data []byte) string {
+	h := cipher.SumSHA256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// LoadBackupArchive loads a backup archive from a file
+func LoadBackupArchive(filename string) (*BackupArchive, error) {
+	var a BackupArchive
+	if err := file.LoadJSON(filename, &a); err != nil {
+		return nil, fmt.Errorf("load backup archive %s failed: %v", filename, err)
+	}
+	return &a, nil
+}
+
+// Save saves the backup archive to filename
+func (a *BackupArchive) Save(filename string) error {
+	return file.SaveJSON(filename, a, 0600)
+}
+
+// Wallets verifies the checksum of the archive, decrypts it with password
+// and returns its wallets. Every wallet is validated, an error is returned
+// if any of them is invalid.
+func (a *BackupArchive) Wallets(password []byte) ([]*Wallet, error) {
+	if a.Version != backupVersion {
+		return nil, ErrBackupVersionUnsupported
+	}
+
+	if len(password) == 0 {
+		return nil, ErrMissingPassword
+	}
+
+	if backupChecksum([]byte(a.Data)) != a.Checksum {
+		return nil, ErrBackupChecksumMismatch
+	}
+
+	c, err := getCrypto(a.CryptoType)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	data, err := c.Decrypt([]byte(a.Data), password)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	defer func() {
+		for i := range data {
+			data[i] = 0
+		}
+	}()
+
+	var payload backupPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, NewError(fmt.Errorf("invalid backup archive data: %v", err))
+	}
+
+	if len(payload.Wallets) == 0 {
+		return nil, ErrBackupEmpty
+	}
+
+	wlts := make([]*Wallet, 0, len(payload.Wallets))
+	names := make(map[string]struct{}, len(payload.Wallets))
+	for _, rw := range payload.Wallets {
+		w, err := newWalletFromBackup(rw)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[w.Filename()]; ok {
+			return nil, NewError(fmt.Errorf("duplicate wallet %s in the backup archive", w.Filename()))
+		}
+		names[w.Filename()] = struct{}{}
+
+		wlts = append(wlts, w)
+	}
+
+	return wlts, nil
+}
+
+// newWalletFromBackup converts and validates a wallet of a backup archive
+func newWalletFromBackup(rw *ReadableWallet) (*Wallet, error) {
+	fn := rw.filename()
+	if !isWalletFilename(fn) {
+		return nil, NewError(fmt.Errorf("invalid wallet filename %q in the backup archive", fn))
+	}
+
+	w, err := rw.ToWallet()
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, NewError(fmt.Errorf("invalid wallet %s: %v", fn, err))
+	}
+
+	if coinType := w.coin(); coinType != CoinTypeMDL {
+		return nil, NewError(fmt.Errorf("only mdl wallets can be restored, %s is a %s wallet", fn, coinType))
+	}
+
+	if hasSeedType(w.Type()) && len(w.Entries) == 0 {
+		return nil, NewError(fmt.Errorf("empty wallet %s in the backup archive", fn))
+	}
+
+	return w, nil
+}
+
+// BackupFilename returns the filename of a backup archive created at t
+func BackupFilename(t time.Time) string {
+	return fmt.Sprintf("wallets_%s%s", t.UTC().Format("2006_01_02_150405"), BackupExt)
+}
+
+// removeOldBackups removes the oldest backup archives in dir, keeping the latest keep archives.
+// The archives are ordered by filename, which is their creation time.
+func removeOldBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	fs, err := filterDir(dir, BackupExt)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(fs)
+
+	for len(fs) > keep {
+		if err := os.Remove(fs[0]); err != nil {
+			return err
+		}
+		fs = fs[1:]
+	}
+
+	return nil
+}
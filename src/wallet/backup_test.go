@@ -0,0 +1,311 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+func TestBackupArchive(t *testing.T) {
+	w1, err := NewWallet("a.wlt", Options{
+		Seed:      "seed a",
+		GenerateN: 3,
+	})
+	require.NoError(t, err)
+
+	w2, err := NewWallet("b.wlt", Options{
+		Seed:       "seed b",
+		Encrypt:    true,
+		Password:   []byte("wallet pwd"),
+		CryptoType: CryptoTypeSha256Xor,
+	})
+	require.NoError(t, err)
+
+	_, err = NewBackupArchive(nil, []byte("pwd"), CryptoTypeSha256Xor)
+	require.Equal(t, ErrBackupEmpty, err)
+
+	_, err = NewBackupArchive([]*Wallet{w1}, nil, CryptoTypeSha256Xor)
+	require.Equal(t, ErrMissingPassword, err)
+
+	a, err := NewBackupArchive([]*Wallet{w1, w2}, []byte("pwd"), CryptoTypeScryptChacha20poly1305Insecure)
+	require.NoError(t, err)
+	require.Equal(t, backupVersion, a.Version)
+	require.Equal(t, CryptoTypeScryptChacha20poly1305Insecure, a.CryptoType)
+	require.NotContains(t, a.Data, "seed a")
+
+	// The archive is saved and loaded
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, BackupFilename(time.Unix(a.Timestamp, 0)))
+	require.NoError(t, a.Save(fn))
+	a, err = LoadBackupArchive(fn)
+	require.NoError(t, err)
+
+	wlts, err := a.Wallets([]byte("pwd"))
+	require.NoError(t, err)
+	require.Len(t, wlts, 2)
+	require.Equal(t, w1, wlts[0])
+	require.Equal(t, w2, wlts[1])
+
+	// Encrypted wallets stay encrypted with their own password
+	require.True(t, wlts[1].IsEncrypted())
+	_, err = wlts[1].Unlock([]byte("wallet pwd"))
+	require.NoError(t, err)
+
+	_, err = a.Wallets([]byte("wrong"))
+	require.Equal(t, ErrInvalidPassword, err)
+
+	_, err = a.Wallets(nil)
+	require.Equal(t, ErrMissingPassword, err)
+
+	corrupted := *a
+	corrupted.Data = corrupted.Data[1:]
+	_, err = corrupted.Wallets([]byte("pwd"))
+	require.Equal(t, ErrBackupChecksumMismatch, err)
+
+	unknown := *a
+	unknown.Version = "9.9"
+	_, err = unknown.Wallets([]byte("pwd"))
+	require.Equal(t, ErrBackupVersionUnsupported, err)
+
+	// An invalid wallet fails the validation
+	invalid := w1.clone()
+	invalid.Meta[metaType] = "foo"
+	a, err = NewBackupArchive([]*Wallet{invalid}, []byte("pwd"), CryptoTypeSha256Xor)
+	require.NoError(t, err)
+	_, err = a.Wallets([]byte("pwd"))
+	require.Equal(t, NewError(errors.New("invalid wallet a.wlt: wallet type invalid")), err)
+
+	// Wallet filenames must not be paths
+	invalid = w1.clone()
+	invalid.setFilename("../a.wlt")
+	a, err = NewBackupArchive([]*Wallet{invalid}, []byte("pwd"), CryptoTypeSha256Xor)
+	require.NoError(t, err)
+	_, err = a.Wallets([]byte("pwd"))
+	require.Equal(t, NewError(errors.New(`invalid wallet filename "../a.wlt" in the backup archive`)), err)
+}
+
+func TestServiceBackupRestore(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.Backup("", []byte("pwd"))
+	require.Equal(t, ErrBackupEmpty, err)
+
+	w1, err := s.CreateWallet("a.wlt", Options{
+		Seed: "seed a",
+	}, nil)
+	require.NoError(t, err)
+	w2, err := s.CreateWallet("b.wlt", Options{
+		Seed:     "seed b",
+		Encrypt:  true,
+		Password: []byte("wallet pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.Backup("c.wlt", []byte("pwd"))
+	require.Equal(t, ErrWalletNotExist, err)
+
+	one, err := s.Backup("a.wlt", []byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeScryptChacha20poly1305Insecure, one.CryptoType)
+	all, err := s.Backup("", []byte("pwd"))
+	require.NoError(t, err)
+
+	// Restoring loaded wallets requires overwrite
+	_, err = s.Restore(all, []byte("pwd"), false)
+	require.Equal(t, ErrBackupWalletExists, err)
+
+	// The wallets are changed, then overwritten by the backup
+	_, err = s.NewAddresses("a.wlt", nil, 2)
+	require.NoError(t, err)
+	wlts, err := s.Restore(all, []byte("pwd"), true)
+	require.NoError(t, err)
+	require.Equal(t, []*Wallet{w1, w2}, wlts)
+	w, err := s.GetWallet("a.wlt")
+	require.NoError(t, err)
+	require.Len(t, w.Entries, 1)
+
+	// Restores a wallet that was removed
+	require.NoError(t, s.UnloadWallet("a.wlt"))
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.wlt")))
+	wlts, err = s.Restore(one, []byte("pwd"), false)
+	require.NoError(t, err)
+	require.Equal(t, []*Wallet{w1}, wlts)
+	w, err = Load(filepath.Join(dir, "a.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, w1.Entries, w.Entries)
+
+	// The seed of the backup can't be restored under another filename
+	renamed := w1.clone()
+	renamed.setFilename("c.wlt")
+	a, err := NewBackupArchive([]*Wallet{renamed}, []byte("pwd"), CryptoTypeSha256Xor)
+	require.NoError(t, err)
+	_, err = s.Restore(a, []byte("pwd"), true)
+	require.Equal(t, ErrSeedUsed, err)
+
+	// Nothing is restored if any wallet is invalid
+	valid, err := NewWallet("d.wlt", Options{
+		Seed: "seed d",
+	})
+	require.NoError(t, err)
+	invalid := w1.clone()
+	invalid.setFilename("e.wlt")
+	invalid.Meta[metaType] = "foo"
+	a, err = NewBackupArchive([]*Wallet{valid, invalid}, []byte("pwd"), CryptoTypeSha256Xor)
+	require.NoError(t, err)
+	_, err = s.Restore(a, []byte("pwd"), true)
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "d.wlt"))
+	require.True(t, os.IsNotExist(err))
+	_, err = s.GetWallet("d.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+
+	s.config.EnableWalletAPI = false
+	_, err = s.Backup("", []byte("pwd"))
+	require.Equal(t, ErrWalletAPIDisabled, err)
+	_, err = s.Restore(all, []byte("pwd"), true)
+	require.Equal(t, ErrWalletAPIDisabled, err)
+}
+
+func TestServiceRestoreSpendingPolicy(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	// The backup is made before the spending policy is set
+	a, err := s.Backup("t.wlt", []byte("backup pwd"))
+	require.NoError(t, err)
+
+	policy := SpendingPolicy{
+		MaxCoinsPerDay: 10e6,
+	}
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), policy)
+	require.NoError(t, err)
+
+	txn, _, _ := makeTransaction(t, 1)
+	now := time.Now()
+	_, err = s.RecordSpend(&txn, []cipher.Address{w.Entries[0].MDLAddress()}, now)
+	require.NoError(t, err)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	spent := w.SpentToday(now)
+	require.NotEqual(t, uint64(0), spent)
+
+	// Restoring the backup keeps the spending policy and the daily spending of the replaced wallet
+	_, err = s.Restore(a, []byte("backup pwd"), true)
+	require.NoError(t, err)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, policy, w.SpendingPolicy())
+	require.Equal(t, spent, w.SpentToday(now))
+
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, policy, w.SpendingPolicy())
+	require.Equal(t, spent, w.SpentToday(now))
+
+	// The spending policy can still be removed with the password
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), SpendingPolicy{})
+	require.NoError(t, err)
+	_, err = s.Restore(a, []byte("backup pwd"), true)
+	require.NoError(t, err)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.True(t, w.SpendingPolicy().IsEmpty())
+}
+
+func TestServiceTimedBackups(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	backupDir := filepath.Join(dir, "backups")
+
+	_, err := NewService(Config{
+		WalletDir:       dir,
+		EnableWalletAPI: true,
+		BackupInterval:  time.Hour,
+		BackupPassword:  []byte("pwd"),
+	})
+	require.Equal(t, errors.New("timed wallet backups require a backup directory"), err)
+
+	_, err = NewService(Config{
+		WalletDir:       dir,
+		EnableWalletAPI: true,
+		BackupDir:       backupDir,
+		BackupInterval:  time.Hour,
+	})
+	require.Equal(t, errors.New("timed wallet backups require a backup password"), err)
+
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+		BackupDir:       backupDir,
+		BackupInterval:  time.Hour,
+		BackupRetention: 2,
+		BackupPassword:  []byte("pwd"),
+	})
+	require.NoError(t, err)
+
+	// Nothing is written without wallets
+	fn, err := s.backupToDir()
+	require.NoError(t, err)
+	require.Empty(t, fn)
+
+	_, err = s.CreateWallet("a.wlt", Options{
+		Seed: "seed a",
+	}, nil)
+	require.NoError(t, err)
+
+	fn, err = s.backupToDir()
+	require.NoError(t, err)
+	a, err := LoadBackupArchive(fn)
+	require.NoError(t, err)
+	wlts, err := a.Wallets([]byte("pwd"))
+	require.NoError(t, err)
+	require.Len(t, wlts, 1)
+
+	// Only the latest archives are kept
+	for _, name := range []string{"wallets_2000_01_01_000000.wbk", "wallets_2000_01_02_000000.wbk"} {
+		require.NoError(t, a.Save(filepath.Join(backupDir, name)))
+	}
+	fn, err = s.backupToDir()
+	require.NoError(t, err)
+	fs, err := filterDir(backupDir, BackupExt)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(backupDir, "wallets_2000_01_02_000000.wbk"), fn}, fs)
+
+	// RunBackups returns when quit is closed
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunBackups(quit)
+	}()
+	close(quit)
+	<-done
+}
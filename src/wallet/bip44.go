@@ -0,0 +1,381 @@
+package wallet
+
+// This file contains the bip44 hierarchical deterministic wallet type support.
+// Addresses of a bip44 wallet are derived from the path m/44'/coin'/account'/chain/index,
+// where chain is 0 for the external chain and 1 for the change chain.
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip32"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/bip44"
+)
+
+// TransactionsFinder interface for checking whether addresses have any transaction activity
+type TransactionsFinder interface {
+	AddressesActivity(addrs []cipher.Address) ([]bool, error)
+}
+
+// hasBip44Chains returns true if the entries of a wallet type are derived along
+// the external and change chains of a bip44 account
+func hasBip44Chains(walletType string) bool {
+	switch walletType {
+	case WalletTypeBip44, WalletTypeXPub:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasChangeChain returns true if the wallet generates change addresses on the change chain of a bip44 account
+func (w *Wallet) HasChangeChain() bool {
+	return hasBip44Chains(w.Type())
+}
+
+// defaultBip44CoinType returns the registered bip44 coin type of a wallet coin type
+func defaultBip44CoinType(coin CoinType) bip44.CoinType {
+	switch coin {
+	case CoinTypeMDL:
+		return bip44.CoinTypeMDL
+	case CoinTypeBitcoin:
+		return bip44.CoinTypeBitcoin
+	default:
+		logger.Panicf("Invalid wallet coin type %q", coin)
+		return 0
+	}
+}
+
+// initBip44 sets the bip44 meta fields of a new wallet
+func (w *Wallet) initBip44(coinType *bip44.CoinType, account uint32) error {
+	if err := bip39.ValidateMnemonic(w.seed()); err != nil {
+		return NewError(fmt.Errorf("bip44 wallet seed must be a valid bip39 mnemonic: %v", err))
+	}
+
+	ct := defaultBip44CoinType(w.coin())
+	if coinType != nil {
+		ct = *coinType
+	}
+
+	w.Meta[metaBip44Coin] = strconv.FormatUint(uint64(ct), 10)
+	w.Meta[metaBip44Account] = strconv.FormatUint(uint64(account), 10)
+	w.setLastSeed("")
+
+	acct, err := w.bip44AccountKey()
+	if err != nil {
+		return NewError(err)
+	}
+
+	w.Meta[metaXPub] = acct.PublicKey().String()
+	return nil
+}
+
+// validateBip44 validates the bip44 meta fields
+func (w *Wallet) validateBip44() error {
+	if _, err := strconv.ParseUint(w.Meta[metaBip44Coin], 10, 32); err != nil {
+		return errors.New("invalid bip44 coin type")
+	}
+
+	if _, err := strconv.ParseUint(w.Meta[metaBip44Account], 10, 32); err != nil {
+		return errors.New("invalid bip44 account")
+	}
+
+	if _, err := bip32.DeserializeEncodedPublicKey(w.XPub()); err != nil {
+		return fmt.Errorf("invalid xpub: %v", err)
+	}
+
+	return nil
+}
+
+// Bip44Coin returns the bip44 coin type of a bip44 wallet
+func (w *Wallet) Bip44Coin() bip44.CoinType {
+	// The value is checked by Validate
+	x, _ := strconv.ParseUint(w.Meta[metaBip44Coin], 10, 32) // nolint: errcheck
+	return bip44.CoinType(x)
+}
+
+// Bip44Account returns the bip44 account number of a bip44 wallet
+func (w *Wallet) Bip44Account() uint32 {
+	// The value is checked by Validate
+	x, _ := strconv.ParseUint(w.Meta[metaBip44Account], 10, 32) // nolint: errcheck
+	return uint32(x)
+}
+
+// XPub returns the extended public key of the bip44 account of a bip44 or xpub wallet
+func (w *Wallet) XPub() string {
+	return w.Meta[metaXPub]
+}
+
+// bip44AccountKey derives the private key of the bip44 account from the wallet's mnemonic seed and seed passphrase
+func (w *Wallet) bip44AccountKey() (*bip44.Account, error) {
+	seed, err := bip39.NewSeed(w.seed(), w.passphrase())
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := bip44.NewCoin(seed, w.Bip44Coin())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Account(w.Bip44Account())
+}
+
+// bip44ChainKey derives the private key of a chain of the bip44 account
+func (w *Wallet) bip44ChainKey(chain uint32) (*bip32.PrivateKey, error) {
+	acct, err := w.bip44AccountKey()
+	if err != nil {
+		return nil, err
+	}
+
+	switch chain {
+	case bip44.ExternalChainIndex:
+		return acct.External()
+	case bip44.ChangeChainIndex:
+		return acct.Change()
+	default:
+		return nil, fmt.Errorf("invalid bip44 chain %d", chain)
+	}
+}
+
+// bip44PublicChainKey derives the public key of a chain of the bip44 account from the wallet's xpub
+func (w *Wallet) bip44PublicChainKey(chain uint32) (*bip32.PublicKey, error) {
+	xpub, err := bip32.DeserializeEncodedPublicKey(w.XPub())
+	if err != nil {
+		return nil, err
+	}
+
+	return xpub.NewPublicChildKey(chain)
+}
+
+// nextChildNumber returns the child number of the next address on a chain
+func (w *Wallet) nextChildNumber(chain uint32) uint32 {
+	var n uint32
+	for _, e := range w.Entries {
+		if e.Change == chain && e.ChildNumber >= n {
+			n = e.ChildNumber + 1
+		}
+	}
+	return n
+}
+
+// generateBip44Addresses generates addresses on a chain of an unencrypted bip44 wallet
+func (w *Wallet) generateBip44Addresses(num uint64, chain uint32) ([]cipher.Addresser, error) {
+	if w.IsEncrypted() {
+		return nil, ErrWalletEncrypted
+	}
+
+	chainKey, err := w.bip44ChainKey(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	makeAddress := w.addressConstructor()
+	childNumber := w.nextChildNumber(chain)
+	addrs := make([]cipher.Addresser, 0, num)
+	for uint64(len(addrs)) < num {
+		if childNumber >= bip32.FirstHardenedChild {
+			return nil, errors.New("maximum bip44 child number reached")
+		}
+
+		k, err := chainKey.NewPrivateChildKey(childNumber)
+		if err != nil {
+			// Skip child numbers that can't produce a valid key, as required by bip32
+			if bip32.IsImpossibleChildError(err) {
+				childNumber++
+				continue
+			}
+			return nil, err
+		}
+
+		s, err := cipher.NewSecKey(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		p := cipher.MustPubKeyFromSecKey(s)
+		a := makeAddress(p)
+		addrs = append(addrs, a)
+		w.Entries = append(w.Entries, Entry{
+			Address:     a,
+			Public:      p,
+			Secret:      s,
+			ChildNumber: childNumber,
+			Change:      chain,
+		})
+		childNumber++
+	}
+
+	return addrs, nil
+}
+
+// generateBip44PublicAddresses generates addresses without secret keys on a chain
+// of a bip44 wallet, derived from the wallet's xpub
+func (w *Wallet) generateBip44PublicAddresses(num uint64, chain uint32) ([]cipher.Addresser, error) {
+	chainKey, err := w.bip44PublicChainKey(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	makeAddress := w.addressConstructor()
+	childNumber := w.nextChildNumber(chain)
+	addrs := make([]cipher.Addresser, 0, num)
+	for uint64(len(addrs)) < num {
+		if childNumber >= bip32.FirstHardenedChild {
+			return nil, errors.New("maximum bip44 child number reached")
+		}
+
+		k, err := chainKey.NewPublicChildKey(childNumber)
+		if err != nil {
+			if bip32.IsImpossibleChildError(err) {
+				childNumber++
+				continue
+			}
+			return nil, err
+		}
+
+		p, err := cipher.NewPubKey(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		a := makeAddress(p)
+		addrs = append(addrs, a)
+		w.Entries = append(w.Entries, Entry{
+			Address:     a,
+			Public:      p,
+			ChildNumber: childNumber,
+			Change:      chain,
+		})
+		childNumber++
+	}
+
+	return addrs, nil
+}
+
+// GenerateChangeAddresses generates addresses on the change chain of a bip44 or xpub wallet.
+// Change addresses can be generated for an encrypted wallet, they are derived from the xpub
+// and their secret keys are derived from the seed when the wallet is unlocked.
+func (w *Wallet) GenerateChangeAddresses(num uint64) ([]cipher.Addresser, error) {
+	if !w.HasChangeChain() {
+		return nil, ErrWalletNotBip44
+	}
+
+	if num == 0 {
+		return nil, nil
+	}
+
+	if w.IsEncrypted() || w.IsWatchOnly() {
+		return w.generateBip44PublicAddresses(num, bip44.ChangeChainIndex)
+	}
+
+	return w.generateBip44Addresses(num, bip44.ChangeChainIndex)
+}
+
+// ChangeEntries returns the entries on the change chain of a bip44 or xpub wallet, in order of generation
+func (w *Wallet) ChangeEntries() []Entry {
+	if !w.HasChangeChain() {
+		return nil
+	}
+
+	var entries []Entry
+	for _, e := range w.Entries {
+		if e.Change == bip44.ChangeChainIndex {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ExternalEntries returns the entries on the external chain of a bip44 or xpub wallet, in order of generation.
+// For other wallet types, all entries are returned.
+func (w *Wallet) ExternalEntries() []Entry {
+	if !w.HasChangeChain() {
+		return w.Entries
+	}
+
+	var entries []Entry
+	for _, e := range w.Entries {
+		if e.Change == bip44.ExternalChainIndex {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// PeekChangeAddress returns the first change address of a bip44 or xpub wallet that has no transaction activity.
+// If all change addresses have been used, the next address on the change chain is returned,
+// but it is not added to the wallet; the returned bool is true in that case.
+func (w *Wallet) PeekChangeAddress(tf TransactionsFinder) (cipher.Address, bool, error) {
+	if !w.HasChangeChain() {
+		return cipher.Address{}, false, ErrWalletNotBip44
+	}
+
+	if w.coin() != CoinTypeMDL {
+		return cipher.Address{}, false, errors.New("PeekChangeAddress called for non-mdl wallet")
+	}
+
+	entries := w.ChangeEntries()
+	if len(entries) != 0 {
+		addrs := make([]cipher.Address, len(entries))
+		for i, e := range entries {
+			addrs[i] = e.MDLAddress()
+		}
+
+		active, err := tf.AddressesActivity(addrs)
+		if err != nil {
+			return cipher.Address{}, false, err
+		}
+
+		for i, a := range active {
+			if !a {
+				return addrs[i], false, nil
+			}
+		}
+	}
+
+	w2 := w.clone()
+	addrs, err := w2.generateBip44PublicAddresses(1, bip44.ChangeChainIndex)
+	if err != nil {
+		return cipher.Address{}, false, err
+	}
+
+	return addrs[0].(cipher.Address), true, nil
+}
+
+// deriveBip44Secrets derives the secret keys of the entries at the given indexes from the seed
+func (w *Wallet) deriveBip44Secrets(indexes []int) error {
+	chainKeys := make(map[uint32]*bip32.PrivateKey, 2)
+	for _, i := range indexes {
+		e := &w.Entries[i]
+
+		chainKey, ok := chainKeys[e.Change]
+		if !ok {
+			var err error
+			chainKey, err = w.bip44ChainKey(e.Change)
+			if err != nil {
+				return err
+			}
+			chainKeys[e.Change] = chainKey
+		}
+
+		k, err := chainKey.NewPrivateChildKey(e.ChildNumber)
+		if err != nil {
+			return err
+		}
+
+		s, err := cipher.NewSecKey(k.Key)
+		if err != nil {
+			return err
+		}
+
+		if cipher.MustPubKeyFromSecKey(s) != e.Public {
+			return fmt.Errorf("derived secret key does not match public key of address %s", e.Address)
+		}
+
+		e.Secret = s
+	}
+
+	return nil
+}
@@ -0,0 +1,492 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/bip44"
+)
+
+const testBip44Seed = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+type fakeTransactionsFinder struct {
+	active map[cipher.Address]bool
+	err    error
+}
+
+func (f fakeTransactionsFinder) AddressesActivity(addrs []cipher.Address) ([]bool, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	active := make([]bool, len(addrs))
+	for i, a := range addrs {
+		active[i] = f.active[a]
+	}
+	return active, nil
+}
+
+func TestNewBip44Wallet(t *testing.T) {
+	bip44CoinBitcoin := bip44.CoinTypeBitcoin
+
+	tt := []struct {
+		name    string
+		opts    Options
+		coin    bip44.CoinType
+		addr    string
+		account uint32
+		err     error
+	}{
+		{
+			name: "mdl",
+			opts: Options{
+				Type: WalletTypeBip44,
+				Seed: testBip44Seed,
+			},
+			coin: bip44.CoinTypeMDL,
+		},
+		{
+			name: "mdl account 1",
+			opts: Options{
+				Type:         WalletTypeBip44,
+				Seed:         testBip44Seed,
+				Bip44Account: 1,
+			},
+			coin:    bip44.CoinTypeMDL,
+			account: 1,
+		},
+		{
+			name: "bitcoin",
+			opts: Options{
+				Type: WalletTypeBip44,
+				Coin: CoinTypeBitcoin,
+				Seed: testBip44Seed,
+			},
+			coin: bip44.CoinTypeBitcoin,
+			addr: "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+		},
+		{
+			name: "mdl coin with bitcoin bip44 coin type",
+			opts: Options{
+				Type:      WalletTypeBip44,
+				Seed:      testBip44Seed,
+				Bip44Coin: &bip44CoinBitcoin,
+			},
+			coin: bip44.CoinTypeBitcoin,
+			addr: "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA",
+		},
+		{
+			name: "seed is not a mnemonic",
+			opts: Options{
+				Type: WalletTypeBip44,
+				Seed: "seed",
+			},
+			err: NewError(fmt.Errorf("bip44 wallet seed must be a valid bip39 mnemonic: %v", bip39.ErrInvalidNumberOfWords)),
+		},
+		{
+			name: "bip44 account for deterministic wallet",
+			opts: Options{
+				Seed:         testBip44Seed,
+				Bip44Account: 1,
+			},
+			err: ErrBip44OptionsNotAllowed,
+		},
+		{
+			name: "invalid type",
+			opts: Options{
+				Type: "foo",
+				Seed: testBip44Seed,
+			},
+			err: ErrInvalidWalletType,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", tc.opts)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, WalletTypeBip44, w.Type())
+			require.Equal(t, tc.coin, w.Bip44Coin())
+			require.Equal(t, tc.account, w.Bip44Account())
+			require.NotEmpty(t, w.XPub())
+			require.Empty(t, w.lastSeed())
+			require.NoError(t, w.Validate())
+
+			require.Len(t, w.Entries, 1)
+			require.Equal(t, uint32(0), w.Entries[0].ChildNumber)
+			require.Equal(t, bip44.ExternalChainIndex, w.Entries[0].Change)
+			if tc.addr != "" {
+				require.Equal(t, tc.addr, cipher.BitcoinAddressFromPubKey(w.Entries[0].Public).String())
+			}
+
+			addrs, err := w.GenerateAddresses(2)
+			require.NoError(t, err)
+			require.Len(t, addrs, 2)
+			require.Len(t, w.ExternalEntries(), 3)
+			require.Empty(t, w.ChangeEntries())
+			for i, e := range w.Entries {
+				require.Equal(t, uint32(i), e.ChildNumber)
+				require.Equal(t, cipher.MustPubKeyFromSecKey(e.Secret), e.Public)
+			}
+		})
+	}
+}
+
+func TestBip44WalletAccountsDiffer(t *testing.T) {
+	w0, err := NewWallet("t0.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	})
+	require.NoError(t, err)
+
+	w1, err := NewWallet("t1.wlt", Options{
+		Type:         WalletTypeBip44,
+		Seed:         testBip44Seed,
+		Bip44Account: 1,
+	})
+	require.NoError(t, err)
+
+	require.NotEqual(t, w0.XPub(), w1.XPub())
+	require.NotEqual(t, w0.Entries[0].Address, w1.Entries[0].Address)
+}
+
+func TestBip44WalletChangeAddresses(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	})
+	require.NoError(t, err)
+
+	changeAddrs, err := w.GenerateChangeAddresses(2)
+	require.NoError(t, err)
+	require.Len(t, changeAddrs, 2)
+
+	changeEntries := w.ChangeEntries()
+	require.Len(t, changeEntries, 2)
+	for i, e := range changeEntries {
+		require.Equal(t, bip44.ChangeChainIndex, e.Change)
+		require.Equal(t, uint32(i), e.ChildNumber)
+		require.False(t, e.Secret.Null())
+		require.Equal(t, changeAddrs[i], e.Address)
+	}
+
+	// The external chain is independent of the change chain
+	_, err = w.GenerateAddresses(1)
+	require.NoError(t, err)
+	require.Len(t, w.ExternalEntries(), 2)
+	require.Equal(t, uint32(1), w.ExternalEntries()[1].ChildNumber)
+
+	// Change addresses can be generated for an encrypted wallet, from the xpub
+	unencrypted := w.clone()
+	require.NoError(t, w.Lock([]byte("pwd"), CryptoTypeSha256Xor))
+
+	changeAddrs2, err := w.GenerateChangeAddresses(1)
+	require.NoError(t, err)
+	require.Len(t, changeAddrs2, 1)
+
+	e := w.ChangeEntries()[2]
+	require.True(t, e.Secret.Null())
+	require.Equal(t, uint32(2), e.ChildNumber)
+
+	_, err = unencrypted.GenerateChangeAddresses(1)
+	require.NoError(t, err)
+	require.Equal(t, unencrypted.ChangeEntries()[2].Address, e.Address)
+
+	// Unlocking derives the secret keys missing from the secrets
+	uw, err := w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, unencrypted.ChangeEntries()[2].Secret, uw.ChangeEntries()[2].Secret)
+	for _, e := range uw.Entries {
+		require.Equal(t, cipher.MustPubKeyFromSecKey(e.Secret), e.Public)
+	}
+
+	// Non-bip44 wallets have no change chain
+	dw, err := NewWallet("d.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = dw.GenerateChangeAddresses(1)
+	require.Equal(t, ErrWalletNotBip44, err)
+	require.Empty(t, dw.ChangeEntries())
+	require.Len(t, dw.ExternalEntries(), 1)
+}
+
+func TestBip44WalletPeekChangeAddress(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	})
+	require.NoError(t, err)
+
+	// No change addresses, the next address is derived but not added
+	addr, isNew, err := w.PeekChangeAddress(fakeTransactionsFinder{})
+	require.NoError(t, err)
+	require.True(t, isNew)
+	require.Empty(t, w.ChangeEntries())
+
+	changeAddrs, err := w.GenerateChangeAddresses(2)
+	require.NoError(t, err)
+	require.Equal(t, changeAddrs[0], addr)
+
+	// The first unused change address is returned
+	addr, isNew, err = w.PeekChangeAddress(fakeTransactionsFinder{
+		active: map[cipher.Address]bool{
+			changeAddrs[0].(cipher.Address): true,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, isNew)
+	require.Equal(t, changeAddrs[1], addr)
+
+	// All change addresses are used
+	addr, isNew, err = w.PeekChangeAddress(fakeTransactionsFinder{
+		active: map[cipher.Address]bool{
+			changeAddrs[0].(cipher.Address): true,
+			changeAddrs[1].(cipher.Address): true,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, isNew)
+	require.Len(t, w.ChangeEntries(), 2)
+
+	changeAddrs, err = w.GenerateChangeAddresses(1)
+	require.NoError(t, err)
+	require.Equal(t, changeAddrs[0], addr)
+
+	testErr := errors.New("finder failed")
+	_, _, err = w.PeekChangeAddress(fakeTransactionsFinder{
+		err: testErr,
+	})
+	require.Equal(t, testErr, err)
+}
+
+func TestServiceRecoverBip44Wallet(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Type:         WalletTypeBip44,
+		Seed:         testBip44Seed,
+		Bip44Account: 2,
+		Encrypt:      true,
+		Password:     []byte("pwd"),
+		CryptoType:   CryptoTypeSha256Xor,
+		GenerateN:    3,
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+
+	err = s.Update("t.wlt", func(w *Wallet) error {
+		_, err := w.GenerateChangeAddresses(2)
+		return err
+	})
+	require.NoError(t, err)
+
+	// The seed must match
+	_, err = s.RecoverWallet("t.wlt", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", nil)
+	require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+	w2, err := s.RecoverWallet("t.wlt", testBip44Seed, "", []byte("pwd2"))
+	require.NoError(t, err)
+	require.True(t, w2.IsEncrypted())
+	require.Equal(t, w.Bip44Account(), w2.Bip44Account())
+	require.Equal(t, w.XPub(), w2.XPub())
+	require.Len(t, w2.ExternalEntries(), 3)
+	require.Len(t, w2.ChangeEntries(), 2)
+
+	w3, err := s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	uw, err := w3.Unlock([]byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, testBip44Seed, uw.seed())
+	for _, e := range uw.Entries {
+		require.Equal(t, cipher.MustPubKeyFromSecKey(e.Secret), e.Public)
+	}
+}
+
+func TestBip44WalletSeedPassphrase(t *testing.T) {
+	_, err := NewWallet("t.wlt", Options{
+		Type:           WalletTypeBip44,
+		Seed:           testBip44Seed,
+		SeedPassphrase: "TREZOR",
+	})
+	require.Equal(t, ErrSeedPassphraseNotEncrypted, err)
+
+	_, err = NewWallet("t.wlt", Options{
+		Seed:           "seed",
+		SeedPassphrase: "TREZOR",
+		Encrypt:        true,
+		Password:       []byte("pwd"),
+		CryptoType:     CryptoTypeSha256Xor,
+	})
+	require.Equal(t, ErrSeedPassphraseNotAllowed, err)
+
+	w, err := NewWallet("t.wlt", Options{
+		Type:           WalletTypeBip44,
+		Seed:           testBip44Seed,
+		SeedPassphrase: "TREZOR",
+		Encrypt:        true,
+		Password:       []byte("pwd"),
+		CryptoType:     CryptoTypeSha256Xor,
+	})
+	require.NoError(t, err)
+	require.Empty(t, w.passphrase())
+
+	// The addresses are derived from the bip39 seed of the mnemonic and passphrase
+	seed, err := bip39.NewSeed(testBip44Seed, "TREZOR")
+	require.NoError(t, err)
+	c, err := bip44.NewCoin(seed, bip44.CoinTypeMDL)
+	require.NoError(t, err)
+	acct, err := c.Account(0)
+	require.NoError(t, err)
+	require.Equal(t, acct.PublicKey().String(), w.XPub())
+
+	w2, err := NewWallet("t2.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, w2.Entries[0].Address, w.Entries[0].Address)
+
+	// The passphrase is stored in the secrets
+	uw, err := w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, "TREZOR", uw.passphrase())
+	require.Equal(t, ErrSeedPassphraseNotEncrypted, uw.Save(prepareWltDir()))
+
+	ss := make(secrets)
+	crypto, err := getCrypto(w.cryptoType())
+	require.NoError(t, err)
+	sb, err := crypto.Decrypt([]byte(w.secrets()), []byte("pwd"))
+	require.NoError(t, err)
+	require.NoError(t, ss.deserialize(sb))
+	p, ok := ss.get(secretPassphrase)
+	require.True(t, ok)
+	require.Equal(t, "TREZOR", p)
+
+	// Addresses generated in an update use the passphrase
+	require.NoError(t, w.GuardUpdate([]byte("pwd"), func(w *Wallet) error {
+		_, err := w.GenerateAddresses(1)
+		return err
+	}))
+	k, err := acct.External()
+	require.NoError(t, err)
+	k, err = k.NewPrivateChildKey(1)
+	require.NoError(t, err)
+	require.Equal(t, cipher.MustAddressFromSecKey(cipher.MustNewSecKey(k.Key)), w.Entries[1].Address)
+	require.Empty(t, w.passphrase())
+}
+
+func TestServiceBip44WalletSeedPassphrase(t *testing.T) {
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	// Wallets with different passphrases are separate wallets of the same mnemonic
+	w1, err := s.CreateWallet("t1.wlt", Options{
+		Type:      WalletTypeBip44,
+		Seed:      testBip44Seed,
+		Encrypt:   true,
+		Password:  []byte("pwd"),
+		GenerateN: 2,
+	}, nil)
+	require.NoError(t, err)
+
+	w2, err := s.CreateWallet("t2.wlt", Options{
+		Type:           WalletTypeBip44,
+		Seed:           testBip44Seed,
+		SeedPassphrase: "hidden",
+		Encrypt:        true,
+		Password:       []byte("pwd"),
+		GenerateN:      2,
+	}, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, w1.Entries[0].Address, w2.Entries[0].Address)
+
+	_, err = s.CreateWallet("t3.wlt", Options{
+		Type:           WalletTypeBip44,
+		Seed:           testBip44Seed,
+		SeedPassphrase: "hidden",
+		Encrypt:        true,
+		Password:       []byte("pwd"),
+	}, nil)
+	require.Equal(t, ErrSeedUsed, err)
+
+	// The wallet can't be decrypted, the passphrase would be saved in plaintext
+	_, err = s.DecryptWallet("t2.wlt", []byte("pwd"))
+	require.Equal(t, ErrSeedPassphraseNotEncrypted, err)
+
+	w, err := s.GetWallet("t2.wlt")
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+
+	_, err = s.RecoverWallet("t2.wlt", testBip44Seed, "", []byte("pwd2"))
+	require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+	_, err = s.RecoverWallet("t2.wlt", testBip44Seed, "wrong", []byte("pwd2"))
+	require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+	_, err = s.RecoverWallet("t2.wlt", testBip44Seed, "hidden", nil)
+	require.Equal(t, ErrSeedPassphraseNotEncrypted, err)
+
+	_, err = s.RecoverWallet("t1.wlt", testBip44Seed, "hidden", []byte("pwd2"))
+	require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+	err = s.Update("t2.wlt", func(w *Wallet) error {
+		_, err := w.GenerateChangeAddresses(1)
+		return err
+	})
+	require.NoError(t, err)
+
+	w, err = s.RecoverWallet("t2.wlt", testBip44Seed, "hidden", []byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, w2.XPub(), w.XPub())
+	require.Len(t, w.ExternalEntries(), 2)
+	require.Len(t, w.ChangeEntries(), 1)
+
+	// The recovered wallet is loaded from disk, and its secret keys are derived with the passphrase
+	s2, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+	w, err = s2.GetWallet("t2.wlt")
+	require.NoError(t, err)
+	uw, err := w.Unlock([]byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, "hidden", uw.passphrase())
+	for _, e := range uw.Entries {
+		require.Equal(t, cipher.MustPubKeyFromSecKey(e.Secret), e.Public)
+	}
+
+	// The seed passphrase is only allowed for bip44 wallets
+	_, err = s.CreateWallet("d.wlt", Options{
+		Seed: "seed",
+	}, nil)
+	require.NoError(t, err)
+	_, err = s.EncryptWallet("d.wlt", []byte("pwd"), "")
+	require.NoError(t, err)
+	_, err = s.RecoverWallet("d.wlt", "seed", "hidden", []byte("pwd"))
+	require.Equal(t, ErrSeedPassphraseNotAllowed, err)
+}
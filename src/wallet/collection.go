@@ -0,0 +1,94 @@
+package wallet
+
+// This file contains the collection wallet type support.
+// A collection wallet has no seed, it holds individually imported secret keys.
+// Keeping imported keys out of deterministic and bip44 wallets means that those wallets
+// can always be recovered from their seed.
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+// ErrInvalidSecretKey is returned if a secret key string can't be parsed
+var ErrInvalidSecretKey = NewError(errors.New("invalid secret key, must be a hex string of length 64 or a wallet import format string"))
+
+// ParseSecretKey parses a secret key from a hex string or a Bitcoin wallet import format string
+func ParseSecretKey(s string) (cipher.SecKey, error) {
+	if len(s) == len(cipher.SecKey{})*2 {
+		sk, err := cipher.SecKeyFromHex(s)
+		if err != nil {
+			return cipher.SecKey{}, ErrInvalidSecretKey
+		}
+		return sk, nil
+	}
+
+	sk, err := cipher.SecKeyFromBitcoinWalletImportFormat(s)
+	if err != nil {
+		return cipher.SecKey{}, ErrInvalidSecretKey
+	}
+	return sk, nil
+}
+
+// initCollection initializes a new, empty collection wallet
+func (w *Wallet) initCollection(opts Options) error {
+	if opts.GenerateN != 0 || opts.ScanN != 0 {
+		return NewError(errors.New("generate and scan options are not allowed for collection wallets"))
+	}
+
+	w.setLastSeed("")
+	return nil
+}
+
+// ImportSecretKey adds an entry for a secret key to an unencrypted collection wallet
+func (w *Wallet) ImportSecretKey(sk cipher.SecKey) (cipher.Addresser, error) {
+	if w.Type() != WalletTypeCollection {
+		return nil, ErrWalletNotCollection
+	}
+
+	if w.IsEncrypted() {
+		return nil, ErrWalletEncrypted
+	}
+
+	pk, err := cipher.PubKeyFromSecKey(sk)
+	if err != nil {
+		return nil, ErrInvalidSecretKey
+	}
+
+	a := w.addressConstructor()(pk)
+	for _, e := range w.Entries {
+		if e.Address == a {
+			return nil, NewError(fmt.Errorf("duplicate address %s", a))
+		}
+	}
+
+	w.Entries = append(w.Entries, Entry{
+		Address: a,
+		Public:  pk,
+		Secret:  sk,
+	})
+	return a, nil
+}
+
+// RemoveEntry removes the entry of an address from an unencrypted collection wallet
+func (w *Wallet) RemoveEntry(a cipher.Addresser) error {
+	if w.Type() != WalletTypeCollection {
+		return ErrWalletNotCollection
+	}
+
+	if w.IsEncrypted() {
+		return ErrWalletEncrypted
+	}
+
+	for i, e := range w.Entries {
+		if e.Address == a {
+			w.Entries[i].Secret = cipher.SecKey{}
+			w.Entries = append(w.Entries[:i], w.Entries[i+1:]...)
+			return nil
+		}
+	}
+
+	return NewError(fmt.Errorf("address %s not found in wallet", a))
+}
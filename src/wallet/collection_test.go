@@ -0,0 +1,302 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+func TestParseSecretKey(t *testing.T) {
+	_, sk := cipher.GenerateKeyPair()
+
+	tt := []struct {
+		name string
+		s    string
+		sk   cipher.SecKey
+		err  error
+	}{
+		{
+			name: "hex",
+			s:    sk.Hex(),
+			sk:   sk,
+		},
+		{
+			name: "wif",
+			s:    cipher.BitcoinWalletImportFormatFromSeckey(sk),
+			sk:   sk,
+		},
+		{
+			name: "invalid hex",
+			s:    sk.Hex()[:62] + "zz",
+			err:  ErrInvalidSecretKey,
+		},
+		{
+			name: "invalid wif",
+			s:    "foo",
+			err:  ErrInvalidSecretKey,
+		},
+		{
+			name: "empty",
+			s:    "",
+			err:  ErrInvalidSecretKey,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sk, err := ParseSecretKey(tc.s)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.sk, sk)
+		})
+	}
+}
+
+func TestNewCollectionWallet(t *testing.T) {
+	tt := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{
+			name: "ok",
+			opts: Options{
+				Type: WalletTypeCollection,
+			},
+		},
+		{
+			name: "encrypted",
+			opts: Options{
+				Type:       WalletTypeCollection,
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+				CryptoType: CryptoTypeSha256Xor,
+			},
+		},
+		{
+			name: "seed not allowed",
+			opts: Options{
+				Type: WalletTypeCollection,
+				Seed: "seed",
+			},
+			err: ErrSeedNotAllowed,
+		},
+		{
+			name: "generate not allowed",
+			opts: Options{
+				Type:      WalletTypeCollection,
+				GenerateN: 1,
+			},
+			err: NewError(errors.New("generate and scan options are not allowed for collection wallets")),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", tc.opts)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, WalletTypeCollection, w.Type())
+			require.False(t, w.IsWatchOnly())
+			require.Equal(t, tc.opts.Encrypt, w.IsEncrypted())
+			require.Empty(t, w.Entries)
+			require.NoError(t, w.Validate())
+
+			_, err = w.GenerateAddresses(1)
+			require.Equal(t, ErrWalletCantGenerateAddresses, err)
+
+			// The empty wallet can be saved and loaded
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			require.NoError(t, w.Save(dir))
+			lw, err := Load(dir + "/t.wlt")
+			require.NoError(t, err)
+			require.Empty(t, lw.Entries)
+			require.Equal(t, WalletTypeCollection, lw.Type())
+		})
+	}
+}
+
+func TestCollectionWalletImportSecretKey(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Type: WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	pk1, sk1 := cipher.GenerateKeyPair()
+	pk2, sk2 := cipher.GenerateKeyPair()
+
+	a1, err := w.ImportSecretKey(sk1)
+	require.NoError(t, err)
+	require.Equal(t, cipher.AddressFromPubKey(pk1), a1)
+
+	a2, err := w.ImportSecretKey(sk2)
+	require.NoError(t, err)
+	require.Equal(t, cipher.AddressFromPubKey(pk2), a2)
+
+	_, err = w.ImportSecretKey(sk1)
+	require.Equal(t, NewError(fmt.Errorf("duplicate address %s", a1)), err)
+
+	_, err = w.ImportSecretKey(cipher.SecKey{})
+	require.Equal(t, ErrInvalidSecretKey, err)
+
+	require.Len(t, w.Entries, 2)
+	require.Equal(t, sk1, w.Entries[0].Secret)
+	require.Equal(t, pk2, w.Entries[1].Public)
+	require.NoError(t, w.Validate())
+
+	// The secret keys are encrypted with the wallet
+	require.NoError(t, w.Lock([]byte("pwd"), CryptoTypeSha256Xor))
+	require.NoError(t, w.Validate())
+	for _, e := range w.Entries {
+		require.True(t, e.Secret.Null())
+	}
+
+	_, err = w.ImportSecretKey(sk1)
+	require.Equal(t, ErrWalletEncrypted, err)
+	require.Equal(t, ErrWalletEncrypted, w.RemoveEntry(a1))
+
+	uw, err := w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, sk1, uw.Entries[0].Secret)
+	require.Equal(t, sk2, uw.Entries[1].Secret)
+	require.Empty(t, uw.seed())
+
+	// Removing an entry wipes its secret key
+	require.NoError(t, uw.RemoveEntry(a1))
+	require.Len(t, uw.Entries, 1)
+	require.Equal(t, a2, uw.Entries[0].Address)
+	require.Equal(t, NewError(fmt.Errorf("address %s not found in wallet", a1)), uw.RemoveEntry(a1))
+
+	// Keys can't be imported into wallets with a seed
+	dw, err := NewWallet("d.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = dw.ImportSecretKey(sk1)
+	require.Equal(t, ErrWalletNotCollection, err)
+	require.Equal(t, ErrWalletNotCollection, dw.RemoveEntry(dw.Entries[0].Address))
+}
+
+func TestServiceCollectionWallet(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+		EnableSeedAPI:   true,
+	})
+	require.NoError(t, err)
+
+	// Several empty collection wallets can be created
+	_, err = s.CreateWallet("c.wlt", Options{
+		Type:     WalletTypeCollection,
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("c2.wlt", Options{
+		Type: WalletTypeCollection,
+	}, nil)
+	require.NoError(t, err)
+
+	dw, err := s.CreateWallet("d.wlt", Options{
+		Seed: "seed",
+	}, nil)
+	require.NoError(t, err)
+
+	_, sk1 := cipher.GenerateKeyPair()
+	_, sk2 := cipher.GenerateKeyPair()
+
+	_, err = s.ImportSecretKeys("c.wlt", nil, []cipher.SecKey{sk1})
+	require.Equal(t, ErrMissingPassword, err)
+
+	_, err = s.ImportSecretKeys("c.wlt", []byte("wrong"), []cipher.SecKey{sk1})
+	require.Equal(t, ErrInvalidPassword, err)
+
+	_, err = s.ImportSecretKeys("c2.wlt", []byte("pwd"), []cipher.SecKey{sk1})
+	require.Equal(t, ErrWalletNotEncrypted, err)
+
+	_, err = s.ImportSecretKeys("d.wlt", nil, []cipher.SecKey{sk1})
+	require.Equal(t, ErrWalletNotCollection, err)
+
+	_, err = s.ImportSecretKeys("x.wlt", nil, []cipher.SecKey{sk1})
+	require.Equal(t, ErrWalletNotExist, err)
+
+	addrs, err := s.ImportSecretKeys("c.wlt", []byte("pwd"), []cipher.SecKey{sk1, sk2})
+	require.NoError(t, err)
+	require.Equal(t, []cipher.Address{cipher.MustAddressFromSecKey(sk1), cipher.MustAddressFromSecKey(sk2)}, addrs)
+
+	// A failed import doesn't change the wallet
+	_, err = s.ImportSecretKeys("c.wlt", []byte("pwd"), []cipher.SecKey{sk1})
+	require.Error(t, err)
+
+	w, err := s.GetWallet("c.wlt")
+	require.NoError(t, err)
+	require.True(t, w.IsEncrypted())
+	require.Len(t, w.Entries, 2)
+
+	// A key of another wallet can be imported
+	_, err = s.ImportSecretKeys("c2.wlt", nil, []cipher.SecKey{sk1})
+	require.NoError(t, err)
+
+	_, err = s.GetWalletSeed("c.wlt", []byte("pwd"))
+	require.Equal(t, ErrWalletNoSeed, err)
+
+	// The removed secret key is dropped from the encrypted secrets
+	err = s.RemoveAddresses("c.wlt", []byte("pwd"), []cipher.Address{addrs[0]})
+	require.NoError(t, err)
+
+	err = s.RemoveAddresses("c.wlt", []byte("pwd"), []cipher.Address{addrs[0]})
+	require.Equal(t, NewError(fmt.Errorf("address %s not found in wallet", addrs[0])), err)
+
+	err = s.RemoveAddresses("d.wlt", nil, []cipher.Address{dw.Entries[0].Address.(cipher.Address)})
+	require.Equal(t, ErrWalletNotCollection, err)
+
+	w, err = s.GetWallet("c.wlt")
+	require.NoError(t, err)
+	require.Len(t, w.Entries, 1)
+	uw, err := w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, sk2, uw.Entries[0].Secret)
+
+	ss := make(secrets)
+	crypto, err := getCrypto(w.cryptoType())
+	require.NoError(t, err)
+	sb, err := crypto.Decrypt([]byte(w.secrets()), []byte("pwd"))
+	require.NoError(t, err)
+	require.NoError(t, ss.deserialize(sb))
+	_, ok := ss.get(addrs[0].String())
+	require.False(t, ok)
+
+	// Remove the last entry of the unencrypted wallet, empty collection wallets are loaded from disk
+	err = s.RemoveAddresses("c2.wlt", nil, []cipher.Address{addrs[0]})
+	require.NoError(t, err)
+
+	s2, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+	wlts, err := s2.GetWallets()
+	require.NoError(t, err)
+	require.Len(t, wlts, 3)
+	require.Empty(t, wlts["c2.wlt"].Entries)
+	require.Len(t, wlts["c.wlt"].Entries, 1)
+}
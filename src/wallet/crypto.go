@@ -24,6 +24,8 @@ func CryptoTypeFromString(s string) (CryptoType, error) {
 		return CryptoTypeScryptChacha20poly1305, nil
 	case CryptoTypeScryptChacha20poly1305Insecure:
 		return CryptoTypeScryptChacha20poly1305Insecure, nil
+	case CryptoTypeArgon2idChacha20poly1305:
+		return CryptoTypeArgon2idChacha20poly1305, nil
 	default:
 		return "", errors.New("unknown crypto type")
 	}
@@ -37,6 +39,8 @@ const (
 	CryptoTypeScryptChacha20poly1305 = CryptoType("scrypt-chacha20poly1305")
 	// CryptoTypeScryptChacha20poly1305Insecure uses chacha20poly1305 + scrypt key derivation with a weak work factor (unsafe)
 	CryptoTypeScryptChacha20poly1305Insecure = CryptoType("scrypt-chacha20poly1305-insecure")
+	// CryptoTypeArgon2idChacha20poly1305 uses chacha20poly1305 + argon2id key derivation, with the costs recorded in the wallet meta
+	CryptoTypeArgon2idChacha20poly1305 = CryptoType("argon2id-chacha20poly1305")
 )
 
 // cryptoTable records all supported wallet crypto methods
@@ -50,6 +54,7 @@ var cryptoTable = map[CryptoType]cryptor{
 		P:      encrypt.ScryptP,
 		KeyLen: encrypt.ScryptKeyLen,
 	},
+	CryptoTypeArgon2idChacha20poly1305: encrypt.DefaultArgon2idChacha20poly1305,
 }
 
 // getCrypto gets crypto of given type
@@ -61,3 +66,49 @@ func getCrypto(cryptoType CryptoType) (cryptor, error) {
 
 	return c, nil
 }
+
+// newCryptoWithCosts gets the crypto of the given type. For argon2id, the given costs
+// are used, zero costs default to the costs of encrypt.DefaultArgon2idChacha20poly1305.
+func newCryptoWithCosts(cryptoType CryptoType, timeCost, memoryCost uint32) (cryptor, error) {
+	c, err := getCrypto(cryptoType)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := c.(encrypt.Argon2idChacha20poly1305)
+	if !ok {
+		return c, nil
+	}
+
+	if timeCost != 0 {
+		a.Time = timeCost
+	}
+	if memoryCost != 0 {
+		a.Memory = memoryCost
+	}
+
+	return a, nil
+}
+
+// newCrypto gets the crypto for locking the wallet with the given crypto type.
+// For argon2id, the costs set in the wallet meta are used, defaulting to the
+// costs of encrypt.DefaultArgon2idChacha20poly1305, and the costs used are recorded
+// in the meta. The costs are removed from the meta for other crypto types.
+// The costs of an encrypted wallet are also stored in its encrypted data, so
+// decrypting it doesn't depend on the meta.
+func (w *Wallet) newCrypto(cryptoType CryptoType) (cryptor, error) {
+	timeCost, memoryCost := w.Argon2Costs()
+	c, err := newCryptoWithCosts(cryptoType, timeCost, memoryCost)
+	if err != nil {
+		return nil, err
+	}
+
+	a, ok := c.(encrypt.Argon2idChacha20poly1305)
+	if !ok {
+		w.setArgon2Costs(0, 0)
+		return c, nil
+	}
+
+	w.setArgon2Costs(a.Time, a.Memory)
+	return a, nil
+}
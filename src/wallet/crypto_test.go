@@ -1,9 +1,14 @@
 package wallet
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher/encrypt"
 )
 
 func TestSecrets(t *testing.T) {
@@ -27,3 +32,156 @@ func TestSecrets(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, s, s1)
 }
+
+func TestWalletArgon2idCosts(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed:         "seed",
+		Encrypt:      true,
+		Password:     []byte("pwd"),
+		CryptoType:   CryptoTypeArgon2idChacha20poly1305,
+		Argon2Time:   2,
+		Argon2Memory: 1024,
+	})
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeArgon2idChacha20poly1305, w.CryptoType())
+	require.Equal(t, "2", w.Meta[metaArgon2Time])
+	require.Equal(t, "1024", w.Meta[metaArgon2Memory])
+
+	// The costs are saved and the wallet decrypts after loading
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	require.NoError(t, w.Save(dir))
+	w, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	timeCost, memoryCost := w.Argon2Costs()
+	require.Equal(t, uint32(2), timeCost)
+	require.Equal(t, uint32(1024), memoryCost)
+
+	uw, err := w.Unlock([]byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, "seed", uw.seed())
+	require.Empty(t, uw.Meta[metaArgon2Time])
+	require.Empty(t, uw.Meta[metaArgon2Memory])
+
+	_, err = w.Unlock([]byte("wrong"))
+	require.Equal(t, ErrInvalidPassword, err)
+
+	// Updates and password changes keep the costs
+	require.NoError(t, w.GuardUpdate([]byte("pwd"), func(w *Wallet) error {
+		_, err := w.GenerateAddresses(1)
+		return err
+	}))
+	require.NoError(t, w.Reencrypt([]byte("pwd"), []byte("pwd2"), ""))
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Equal(t, uint32(2), timeCost)
+	require.Equal(t, uint32(1024), memoryCost)
+	require.Len(t, w.Entries, 2)
+
+	// The costs are validated
+	w2 := w.clone()
+	w2.Meta[metaArgon2Memory] = "0"
+	require.Equal(t, errors.New("invalid argon2Memory field"), w2.Validate())
+	delete(w2.Meta, metaArgon2Time)
+	require.Equal(t, errors.New("invalid argon2Time field"), w2.Validate())
+
+	// Migrating to another crypto type removes the costs
+	require.NoError(t, w.Reencrypt([]byte("pwd2"), []byte("pwd2"), CryptoTypeScryptChacha20poly1305Insecure))
+	require.Equal(t, CryptoTypeScryptChacha20poly1305Insecure, w.CryptoType())
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Zero(t, timeCost)
+	require.Zero(t, memoryCost)
+	require.NoError(t, w.Validate())
+
+	// Migrating back uses the default costs
+	require.NoError(t, w.Reencrypt([]byte("pwd2"), []byte("pwd2"), CryptoTypeArgon2idChacha20poly1305))
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Equal(t, uint32(encrypt.Argon2Time), timeCost)
+	require.Equal(t, uint32(encrypt.Argon2Memory), memoryCost)
+
+	// Invalid costs are rejected when encrypting
+	_, err = NewWallet("t.wlt", Options{
+		Seed:         "seed",
+		Encrypt:      true,
+		Password:     []byte("pwd"),
+		CryptoType:   CryptoTypeArgon2idChacha20poly1305,
+		Argon2Memory: 16,
+	})
+	require.Equal(t, errors.New("invalid argon2 memory cost"), err)
+}
+
+func TestServiceArgon2idCosts(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		Argon2Time:      3,
+		Argon2Memory:    256,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	// The crypto type of the options overrides the config
+	w, err := s.CreateWallet("a.wlt", Options{
+		Seed:       "seed a",
+		Encrypt:    true,
+		Password:   []byte("pwd"),
+		CryptoType: CryptoTypeArgon2idChacha20poly1305,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeArgon2idChacha20poly1305, w.CryptoType())
+	timeCost, memoryCost := w.Argon2Costs()
+	require.Equal(t, uint32(3), timeCost)
+	require.Equal(t, uint32(256), memoryCost)
+
+	_, err = s.CreateWallet("b.wlt", Options{
+		Seed: "seed b",
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.EncryptWallet("b.wlt", []byte("pwd"), "foo")
+	require.Equal(t, NewError(errors.New("can not find crypto foo in crypto table")), err)
+
+	w, err = s.EncryptWallet("b.wlt", []byte("pwd"), CryptoTypeArgon2idChacha20poly1305)
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeArgon2idChacha20poly1305, w.CryptoType())
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Equal(t, uint32(3), timeCost)
+	require.Equal(t, uint32(256), memoryCost)
+
+	w, err = s.DecryptWallet("b.wlt", []byte("pwd"))
+	require.NoError(t, err)
+	timeCost, _ = w.Argon2Costs()
+	require.Zero(t, timeCost)
+
+	// Without a crypto type, the config crypto type is used
+	w, err = s.EncryptWallet("b.wlt", []byte("pwd"), "")
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeScryptChacha20poly1305Insecure, w.CryptoType())
+
+	// Migrating uses the config costs
+	w, err = s.MigrateCryptoType("b.wlt", []byte("pwd"), CryptoTypeArgon2idChacha20poly1305)
+	require.NoError(t, err)
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Equal(t, uint32(3), timeCost)
+	require.Equal(t, uint32(256), memoryCost)
+
+	// The wallets can be decrypted after reloading
+	s2, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+		EnableSeedAPI:   true,
+	})
+	require.NoError(t, err)
+	seed, err := s2.GetWalletSeed("a.wlt", []byte("pwd"))
+	require.NoError(t, err)
+	require.Equal(t, "seed a", seed)
+
+	w, err = s2.RecoverWallet("b.wlt", "seed b", "", []byte("pwd2"))
+	require.NoError(t, err)
+	require.Equal(t, CryptoTypeArgon2idChacha20poly1305, w.CryptoType())
+	timeCost, memoryCost = w.Argon2Costs()
+	require.Equal(t, uint32(3), timeCost)
+	require.Equal(t, uint32(256), memoryCost)
+}
@@ -8,9 +8,25 @@ import (
 
 // Entry represents the wallet entry
 type Entry struct {
-	Address cipher.Addresser
-	Public  cipher.PubKey
-	Secret  cipher.SecKey
+	Address     cipher.Addresser
+	Public      cipher.PubKey
+	Secret      cipher.SecKey
+	ChildNumber uint32            // bip44 address index, only for bip44 wallets
+	Change      uint32            // bip44 chain, 0 for external and 1 for change, only for bip44 wallets
+	Label       string            // optional label of the address
+	Meta        map[string]string // optional key/value metadata of the address
+}
+
+// clone returns a copy of the entry which doesn't share its metadata
+func (we *Entry) clone() Entry {
+	e := *we
+	if we.Meta != nil {
+		e.Meta = make(map[string]string, len(we.Meta))
+		for k, v := range we.Meta {
+			e.Meta[k] = v
+		}
+	}
+	return e
 }
 
 // MDLAddress returns the MDL address of an entry. Panics if Address is not a MDL address
@@ -0,0 +1,116 @@
+package wallet
+
+// This file contains the signing of arbitrary messages with the secret keys of wallet entries,
+// which proves the control of an address without a transaction.
+//
+// The signed hash is the double SHA256 of MessagePrefix, the uvarint length of the message and the message,
+// so that a signed message can't be a transaction hash. The signature is a cipher.Sig, the public key
+// is recovered from it and compared with the address.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+// MessagePrefix is prepended to a message before it is hashed for signing
+const MessagePrefix = "MDL Signed Message:\n"
+
+var (
+	// ErrInvalidMessageSignature is returned if a message signature was not made by the secret key of an address
+	ErrInvalidMessageSignature = NewError(errors.New("message signature does not match the address"))
+	// ErrInvalidMessageAddress is returned if an address of a signed message is neither a MDL nor a Bitcoin address
+	ErrInvalidMessageAddress = NewError(errors.New("invalid address, must be a MDL or a Bitcoin address"))
+)
+
+// MessageHash returns the hash of a message which is signed
+func MessageHash(msg string) cipher.SHA256 {
+	b := make([]byte, 0, len(MessagePrefix)+binary.MaxVarintLen64+len(msg))
+	b = append(b, MessagePrefix...)
+
+	var n [binary.MaxVarintLen64]byte
+	b = append(b, n[:binary.PutUvarint(n[:], uint64(len(msg)))]...)
+	b = append(b, msg...)
+
+	return cipher.DoubleSHA256(b)
+}
+
+// SignMessage signs a message with a secret key
+func SignMessage(msg string, secKey cipher.SecKey) (cipher.Sig, error) {
+	return cipher.SignHash(MessageHash(msg), secKey)
+}
+
+// VerifyMessage checks that a message was signed by the secret key of an address, a MDL or a Bitcoin address.
+// Returns ErrInvalidMessageSignature if the signature is not valid.
+func VerifyMessage(addr cipher.Addresser, sig cipher.Sig, msg string) error {
+	pubKey, err := cipher.PubKeyFromSig(sig, MessageHash(msg))
+	if err != nil {
+		return ErrInvalidMessageSignature
+	}
+
+	if err := addr.Verify(pubKey); err != nil {
+		return ErrInvalidMessageSignature
+	}
+
+	return nil
+}
+
+// DecodeMessageAddress decodes the address of a signed message, a MDL or a Bitcoin address
+func DecodeMessageAddress(s string) (cipher.Addresser, error) {
+	if addr, err := cipher.DecodeBase58Address(s); err == nil {
+		return addr, nil
+	}
+
+	if addr, err := cipher.DecodeBase58BitcoinAddress(s); err == nil {
+		return addr, nil
+	}
+
+	return nil, ErrInvalidMessageAddress
+}
+
+// SignMessage signs a message with the secret key of the entry of an address.
+// The address is a MDL or a Bitcoin address, depending on the coin type of the wallet.
+func (w *Wallet) SignMessage(addr cipher.Addresser, msg string) (cipher.Sig, error) {
+	if w.IsWatchOnly() {
+		return cipher.Sig{}, ErrWalletWatchOnly
+	}
+
+	if w.IsRemote() {
+		return cipher.Sig{}, ErrWalletRemote
+	}
+
+	if w.IsEncrypted() {
+		return cipher.Sig{}, ErrWalletEncrypted
+	}
+
+	for _, e := range w.Entries {
+		if e.Address != addr {
+			continue
+		}
+
+		if e.Secret.Null() {
+			return cipher.Sig{}, NewError(fmt.Errorf("no secret key for address %s", addr))
+		}
+
+		return SignMessage(msg, e.Secret)
+	}
+
+	return cipher.Sig{}, ErrUnknownAddress
+}
+
+// SignMessage signs a message with the secret key of an address of a wallet,
+// decrypting the wallet with the password if it is encrypted
+func (serv *Service) SignMessage(wltID string, password []byte, addr cipher.Addresser, msg string) (cipher.Sig, error) {
+	var sig cipher.Sig
+	if err := serv.ViewSecrets(wltID, password, func(w *Wallet) error {
+		var err error
+		sig, err = w.SignMessage(addr, msg)
+		return err
+	}); err != nil {
+		return cipher.Sig{}, err
+	}
+
+	return sig, nil
+}
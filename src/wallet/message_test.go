@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/testutil"
+)
+
+func TestMessageHash(t *testing.T) {
+	// The length of the message is part of the hash
+	require.NotEqual(t, MessageHash(""), MessageHash("\x00"))
+	require.NotEqual(t, MessageHash("foo"), MessageHash("foo "))
+	require.NotEqual(t, MessageHash("foo"), cipher.DoubleSHA256([]byte("foo")))
+	require.Equal(t, cipher.DoubleSHA256([]byte(MessagePrefix+"\x03foo")), MessageHash("foo"))
+
+	// Lengths of 128 and more are encoded in more than one byte
+	msg := strings.Repeat("a", 300)
+	require.Equal(t, cipher.DoubleSHA256([]byte(MessagePrefix+"\xac\x02"+msg)), MessageHash(msg))
+}
+
+func TestSignVerifyMessage(t *testing.T) {
+	pubKey, secKey := cipher.GenerateKeyPair()
+
+	for _, addr := range []cipher.Addresser{
+		cipher.AddressFromPubKey(pubKey),
+		cipher.BitcoinAddressFromPubKey(pubKey),
+	} {
+		sig, err := SignMessage("foo", secKey)
+		require.NoError(t, err)
+
+		require.NoError(t, VerifyMessage(addr, sig, "foo"))
+		require.Equal(t, ErrInvalidMessageSignature, VerifyMessage(addr, sig, "bar"))
+		require.Equal(t, ErrInvalidMessageSignature, VerifyMessage(testutil.MakeAddress(), sig, "foo"))
+		require.Equal(t, ErrInvalidMessageSignature, VerifyMessage(addr, cipher.Sig{}, "foo"))
+	}
+}
+
+func TestDecodeMessageAddress(t *testing.T) {
+	pubKey, _ := cipher.GenerateKeyPair()
+
+	addr := cipher.AddressFromPubKey(pubKey)
+	a, err := DecodeMessageAddress(addr.String())
+	require.NoError(t, err)
+	require.Equal(t, addr, a)
+
+	btcAddr := cipher.BitcoinAddressFromPubKey(pubKey)
+	a, err = DecodeMessageAddress(btcAddr.String())
+	require.NoError(t, err)
+	require.Equal(t, btcAddr, a)
+
+	_, err = DecodeMessageAddress("foo")
+	require.Equal(t, ErrInvalidMessageAddress, err)
+}
+
+func TestWalletSignMessage(t *testing.T) {
+	for _, coin := range []CoinType{CoinTypeMDL, CoinTypeBitcoin} {
+		t.Run(string(coin), func(t *testing.T) {
+			w, err := NewWallet("t.wlt", Options{
+				Coin:      coin,
+				Seed:      "seed",
+				GenerateN: 2,
+			})
+			require.NoError(t, err)
+
+			addr := w.Entries[1].Address
+			sig, err := w.SignMessage(addr, "foo")
+			require.NoError(t, err)
+			require.NoError(t, VerifyMessage(addr, sig, "foo"))
+			require.Equal(t, ErrInvalidMessageSignature, VerifyMessage(w.Entries[0].Address, sig, "foo"))
+
+			_, err = w.SignMessage(testutil.MakeAddress(), "foo")
+			require.Equal(t, ErrUnknownAddress, err)
+		})
+	}
+
+	w, err := NewWallet("t.wlt", Options{
+		Type:      WalletTypeAddresses,
+		Addresses: []cipher.Addresser{testutil.MakeAddress()},
+	})
+	require.NoError(t, err)
+
+	_, err = w.SignMessage(w.Entries[0].Address, "foo")
+	require.Equal(t, ErrWalletWatchOnly, err)
+}
+
+func TestServiceSignMessage(t *testing.T) {
+	s, err := NewService(Config{
+		WalletDir:       prepareWltDir(),
+		CryptoType:      CryptoTypeScryptChacha20poly1305,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed:      "seed",
+		Encrypt:   true,
+		Password:  []byte("pwd"),
+		GenerateN: 1,
+	}, nil)
+	require.NoError(t, err)
+
+	addr := w.Entries[0].Address
+
+	_, err = s.SignMessage("t.wlt", nil, addr, "foo")
+	require.Equal(t, ErrMissingPassword, err)
+
+	_, err = s.SignMessage("t.wlt", []byte("wrong"), addr, "foo")
+	require.Equal(t, ErrInvalidPassword, err)
+
+	sig, err := s.SignMessage("t.wlt", []byte("pwd"), addr, "foo")
+	require.NoError(t, err)
+	require.NoError(t, VerifyMessage(addr, sig, "foo"))
+
+	_, err = s.SignMessage("t.wlt", []byte("pwd"), testutil.MakeAddress(), "foo")
+	require.Equal(t, ErrUnknownAddress, err)
+
+	_, err = s.SignMessage("foo.wlt", nil, addr, "foo")
+	require.Equal(t, ErrWalletNotExist, err)
+}
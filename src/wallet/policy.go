@@ -0,0 +1,453 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/util/droplet"
+	"github.com/MDLlife/MDL/src/util/mathutil"
+)
+
+// SpendingRule names a rule of a spending policy
+type SpendingRule string
+
+const (
+	// RuleMaxCoinsPerTransaction limits the coins sent out of the wallet by a transaction
+	RuleMaxCoinsPerTransaction SpendingRule = "max_coins_per_transaction"
+	// RuleMaxCoinsPerDay limits the coins sent out of the wallet in the last 24 hours
+	RuleMaxCoinsPerDay SpendingRule = "max_coins_per_day"
+	// RuleAllowlist limits the addresses outside of the wallet which coins are sent to
+	RuleAllowlist SpendingRule = "allowlist"
+	// RuleMaxOutputs limits the number of outputs of a transaction
+	RuleMaxOutputs SpendingRule = "max_outputs"
+	// RuleMinChange requires a minimum amount of coins sent back to the wallet by a transaction
+	RuleMinChange SpendingRule = "min_change"
+
+	// spendingPolicyDay is the period of the daily limit
+	spendingPolicyDay = 24 * time.Hour
+)
+
+var (
+	// ErrSpendingPolicyNotEncrypted is returned when setting the spending policy of a wallet which is not encrypted
+	ErrSpendingPolicyNotEncrypted = NewError(errors.New("spending policies can only be set on encrypted wallets"))
+)
+
+// PolicyViolationError is returned when a transaction violates a rule of the spending policy of a wallet
+type PolicyViolationError struct {
+	Rule    SpendingRule
+	Message string
+}
+
+func (e PolicyViolationError) Error() string {
+	return fmt.Sprintf("spending policy rule %s violated: %s", e.Rule, e.Message)
+}
+
+// SpendingPolicy is a set of rules which the transactions signed by a wallet must comply with.
+// Coins sent to the addresses of the wallet are change, all other outputs are spent.
+// A zero value disables a rule.
+type SpendingPolicy struct {
+	// MaxCoinsPerTransaction is the maximum amount of droplets spent by a transaction
+	MaxCoinsPerTransaction uint64
+	// MaxCoinsPerDay is the maximum amount of droplets spent by the transactions injected in the last 24 hours
+	MaxCoinsPerDay uint64
+	// Allowlist are the only addresses outside of the wallet which coins can be sent to
+	Allowlist []cipher.Address
+	// MaxOutputs is the maximum number of outputs of a transaction
+	MaxOutputs uint64
+	// MinChange is the minimum amount of droplets which a transaction must send back to the wallet
+	MinChange uint64
+}
+
+// IsEmpty returns true if the policy has no rule
+func (p SpendingPolicy) IsEmpty() bool {
+	return p.MaxCoinsPerTransaction == 0 &&
+		p.MaxCoinsPerDay == 0 &&
+		len(p.Allowlist) == 0 &&
+		p.MaxOutputs == 0 &&
+		p.MinChange == 0
+}
+
+// Validate validates the policy
+func (p SpendingPolicy) Validate() error {
+	for _, a := range []uint64{p.MaxCoinsPerTransaction, p.MaxCoinsPerDay, p.MinChange} {
+		if _, err := droplet.ToString(a); err != nil {
+			return err
+		}
+	}
+
+	if p.MaxCoinsPerDay != 0 && p.MaxCoinsPerTransaction > p.MaxCoinsPerDay {
+		return errors.New("max coins per transaction exceeds max coins per day")
+	}
+
+	for _, a := range p.Allowlist {
+		if a.Null() {
+			return errors.New("allowlist contains a null address")
+		}
+	}
+
+	return nil
+}
+
+// spendingPolicyJSON is the serialized SpendingPolicy in the wallet meta
+type spendingPolicyJSON struct {
+	MaxCoinsPerTransaction uint64   `json:"max_coins_per_transaction,omitempty"`
+	MaxCoinsPerDay         uint64   `json:"max_coins_per_day,omitempty"`
+	Allowlist              []string `json:"allowlist,omitempty"`
+	MaxOutputs             uint64   `json:"max_outputs,omitempty"`
+	MinChange              uint64   `json:"min_change,omitempty"`
+}
+
+func parseSpendingPolicy(s string) (SpendingPolicy, error) {
+	var pj spendingPolicyJSON
+	if err := json.Unmarshal([]byte(s), &pj); err != nil {
+		return SpendingPolicy{}, err
+	}
+
+	p := SpendingPolicy{
+		MaxCoinsPerTransaction: pj.MaxCoinsPerTransaction,
+		MaxCoinsPerDay:         pj.MaxCoinsPerDay,
+		MaxOutputs:             pj.MaxOutputs,
+		MinChange:              pj.MinChange,
+	}
+
+	for _, as := range pj.Allowlist {
+		a, err := cipher.DecodeBase58Address(as)
+		if err != nil {
+			return SpendingPolicy{}, fmt.Errorf("invalid allowlist address %q: %v", as, err)
+		}
+		p.Allowlist = append(p.Allowlist, a)
+	}
+
+	return p, p.Validate()
+}
+
+// SpendingPolicy returns the spending policy of the wallet, which is empty if the wallet has none
+func (w *Wallet) SpendingPolicy() SpendingPolicy {
+	s := w.Meta[metaSpendingPolicy]
+	if s == "" {
+		return SpendingPolicy{}
+	}
+
+	// Intentionally ignore the error, the spending policy is validated by wallet.Validate()
+	p, _ := parseSpendingPolicy(s) // nolint: errcheck
+	return p
+}
+
+// SetSpendingPolicy sets the spending policy of the wallet, an empty policy removes it.
+// The daily spending is kept, so that replacing the policy doesn't reset the daily limit.
+func (w *Wallet) SetSpendingPolicy(p SpendingPolicy) error {
+	if err := p.Validate(); err != nil {
+		return NewError(err)
+	}
+
+	if p.IsEmpty() {
+		delete(w.Meta, metaSpendingPolicy)
+		delete(w.Meta, metaPolicySpends)
+		return nil
+	}
+
+	pj := spendingPolicyJSON{
+		MaxCoinsPerTransaction: p.MaxCoinsPerTransaction,
+		MaxCoinsPerDay:         p.MaxCoinsPerDay,
+		MaxOutputs:             p.MaxOutputs,
+		MinChange:              p.MinChange,
+	}
+	for _, a := range p.Allowlist {
+		pj.Allowlist = append(pj.Allowlist, a.String())
+	}
+
+	b, err := json.Marshal(pj)
+	if err != nil {
+		return err
+	}
+
+	w.Meta[metaSpendingPolicy] = string(b)
+	return nil
+}
+
+// keepSpendingPolicy copies the spending policy and the daily spending of old, a wallet which w replaces,
+// so that replacing a wallet doesn't remove its policy or reset its daily limit.
+// Nothing is copied if old has no spending policy.
+func (w *Wallet) keepSpendingPolicy(old *Wallet) {
+	p, ok := old.Meta[metaSpendingPolicy]
+	if !ok {
+		return
+	}
+
+	w.Meta[metaSpendingPolicy] = p
+	if s, ok := old.Meta[metaPolicySpends]; ok {
+		w.Meta[metaPolicySpends] = s
+	} else {
+		delete(w.Meta, metaPolicySpends)
+	}
+}
+
+// policySpend is a transaction recorded for the daily limit of a spending policy
+type policySpend struct {
+	time   int64
+	coins  uint64
+	inputs []cipher.SHA256
+}
+
+// conflicts returns whether the spend shares an input with a transaction,
+// in which case the transaction replaces the spend
+func (s policySpend) conflicts(inputs map[cipher.SHA256]struct{}) bool {
+	for _, h := range s.inputs {
+		if _, ok := inputs[h]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s policySpend) String() string {
+	ins := make([]string, len(s.inputs))
+	for i, h := range s.inputs {
+		ins[i] = h.Hex()
+	}
+	return fmt.Sprintf("%d:%d:%s", s.time, s.coins, strings.Join(ins, "."))
+}
+
+// parsePolicySpend parses a "<unix time>:<droplets>:<input>.<input>" spend.
+// Spends recorded by older versions have no inputs.
+func parsePolicySpend(ss string) (policySpend, error) {
+	pts := strings.Split(ss, ":")
+	if len(pts) != 2 && len(pts) != 3 {
+		return policySpend{}, errors.New("invalid format")
+	}
+
+	t, err := strconv.ParseInt(pts[0], 10, 64)
+	if err != nil {
+		return policySpend{}, err
+	}
+	coins, err := strconv.ParseUint(pts[1], 10, 64)
+	if err != nil {
+		return policySpend{}, err
+	}
+
+	var inputs []cipher.SHA256
+	if len(pts) == 3 && pts[2] != "" {
+		for _, hs := range strings.Split(pts[2], ".") {
+			h, err := cipher.SHA256FromHex(hs)
+			if err != nil {
+				return policySpend{}, err
+			}
+			inputs = append(inputs, h)
+		}
+	}
+
+	return policySpend{
+		time:   t,
+		coins:  coins,
+		inputs: inputs,
+	}, nil
+}
+
+// policySpends returns the spends recorded for the daily limit, comma separated in the meta
+func (w *Wallet) policySpends() []policySpend {
+	s := w.Meta[metaPolicySpends]
+	if s == "" {
+		return nil
+	}
+
+	var spends []policySpend
+	for _, ss := range strings.Split(s, ",") {
+		// Intentionally ignore the errors, the spends are validated by wallet.Validate()
+		spend, err := parsePolicySpend(ss)
+		if err != nil {
+			continue
+		}
+		spends = append(spends, spend)
+	}
+	return spends
+}
+
+func validatePolicySpends(s string) error {
+	for _, ss := range strings.Split(s, ",") {
+		if _, err := parsePolicySpend(ss); err != nil {
+			return fmt.Errorf("invalid policy spend %q: %v", ss, err)
+		}
+	}
+	return nil
+}
+
+// SpentToday returns the droplets spent by the transactions injected in the 24 hours before now,
+// which count towards the daily limit of the spending policy
+func (w *Wallet) SpentToday(now time.Time) uint64 {
+	return w.spentToday(now, nil)
+}
+
+// spentToday returns the droplets spent in the 24 hours before now,
+// without the spends replaced by a transaction with the given inputs
+func (w *Wallet) spentToday(now time.Time, inputs []cipher.SHA256) uint64 {
+	since := now.Add(-spendingPolicyDay).Unix()
+	ins := inputSet(inputs)
+
+	var coins uint64
+	for _, s := range w.policySpends() {
+		if s.time > since && !s.conflicts(ins) {
+			// The spends can't overflow, they have been checked against the daily limit
+			coins += s.coins
+		}
+	}
+	return coins
+}
+
+// RecordSpend records the coins spent by an injected transaction for the daily limit of the spending policy.
+// The spends which share an input with the transaction are replaced by it, so that a transaction
+// replacing another one counts once. Spends older than 24 hours are removed.
+// Nothing is recorded if the policy has no daily limit.
+func (w *Wallet) RecordSpend(txn *coin.Transaction, now time.Time) {
+	if w.SpendingPolicy().MaxCoinsPerDay == 0 {
+		delete(w.Meta, metaPolicySpends)
+		return
+	}
+
+	_, spent, err := w.transactionSpending(txn)
+	if err != nil {
+		return
+	}
+
+	since := now.Add(-spendingPolicyDay).Unix()
+	ins := inputSet(txn.In)
+
+	var strs []string
+	for _, s := range w.policySpends() {
+		if s.time > since && !s.conflicts(ins) {
+			strs = append(strs, s.String())
+		}
+	}
+	if spent != 0 {
+		strs = append(strs, policySpend{
+			time:   now.Unix(),
+			coins:  spent,
+			inputs: txn.In,
+		}.String())
+	}
+
+	if len(strs) == 0 {
+		delete(w.Meta, metaPolicySpends)
+		return
+	}
+
+	w.Meta[metaPolicySpends] = strings.Join(strs, ",")
+}
+
+func inputSet(inputs []cipher.SHA256) map[cipher.SHA256]struct{} {
+	m := make(map[cipher.SHA256]struct{}, len(inputs))
+	for _, h := range inputs {
+		m[h] = struct{}{}
+	}
+	return m
+}
+
+// transactionSpending returns the droplets which a transaction sends back to the wallet
+// and the droplets which it sends to other addresses
+func (w *Wallet) transactionSpending(txn *coin.Transaction) (change, spent uint64, err error) {
+	for _, o := range txn.Out {
+		if w.HasEntry(o.Address) {
+			change, err = mathutil.AddUint64(change, o.Coins)
+		} else {
+			spent, err = mathutil.AddUint64(spent, o.Coins)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return change, spent, nil
+}
+
+// CheckSpendingPolicy checks that a transaction complies with the spending policy of the wallet,
+// with the transactions injected in the 24 hours before now counting towards the daily limit.
+// Returns a PolicyViolationError naming the first violated rule.
+func (w *Wallet) CheckSpendingPolicy(txn *coin.Transaction, now time.Time) error {
+	p := w.SpendingPolicy()
+	if p.IsEmpty() {
+		return nil
+	}
+
+	if p.MaxOutputs != 0 && uint64(len(txn.Out)) > p.MaxOutputs {
+		return PolicyViolationError{
+			Rule:    RuleMaxOutputs,
+			Message: fmt.Sprintf("transaction has %d outputs, the maximum is %d", len(txn.Out), p.MaxOutputs),
+		}
+	}
+
+	if len(p.Allowlist) != 0 {
+		allowed := make(map[cipher.Address]struct{}, len(p.Allowlist))
+		for _, a := range p.Allowlist {
+			allowed[a] = struct{}{}
+		}
+
+		for _, o := range txn.Out {
+			if w.HasEntry(o.Address) {
+				continue
+			}
+			if _, ok := allowed[o.Address]; !ok {
+				return PolicyViolationError{
+					Rule:    RuleAllowlist,
+					Message: fmt.Sprintf("address %s is not in the allowlist", o.Address),
+				}
+			}
+		}
+	}
+
+	change, spent, err := w.transactionSpending(txn)
+	if err != nil {
+		return NewError(err)
+	}
+
+	if p.MaxCoinsPerTransaction != 0 && spent > p.MaxCoinsPerTransaction {
+		return PolicyViolationError{
+			Rule:    RuleMaxCoinsPerTransaction,
+			Message: fmt.Sprintf("transaction spends %s coins, the maximum is %s", dropletString(spent), dropletString(p.MaxCoinsPerTransaction)),
+		}
+	}
+
+	if err := w.checkDailyLimit(p, txn, spent, now); err != nil {
+		return err
+	}
+
+	if p.MinChange != 0 && change < p.MinChange {
+		return PolicyViolationError{
+			Rule:    RuleMinChange,
+			Message: fmt.Sprintf("transaction sends %s coins back to the wallet, the minimum is %s", dropletString(change), dropletString(p.MinChange)),
+		}
+	}
+
+	return nil
+}
+
+// checkDailyLimit checks that a transaction spending spent droplets doesn't exceed the daily limit of the policy,
+// with the transactions injected in the 24 hours before now counting towards it, except those which the transaction replaces
+func (w *Wallet) checkDailyLimit(p SpendingPolicy, txn *coin.Transaction, spent uint64, now time.Time) error {
+	if p.MaxCoinsPerDay == 0 {
+		return nil
+	}
+
+	today := w.spentToday(now, txn.In)
+	total, err := mathutil.AddUint64(today, spent)
+	if err != nil || total > p.MaxCoinsPerDay {
+		return PolicyViolationError{
+			Rule: RuleMaxCoinsPerDay,
+			Message: fmt.Sprintf("transaction spends %s coins and %s coins were spent in the last 24 hours, the maximum is %s",
+				dropletString(spent), dropletString(today), dropletString(p.MaxCoinsPerDay)),
+		}
+	}
+	return nil
+}
+
+// dropletString formats droplets as coins for the messages of policy violations
+func dropletString(n uint64) string {
+	s, err := droplet.ToString(n)
+	if err != nil {
+		return fmt.Sprintf("%d droplets", n)
+	}
+	return s
+}
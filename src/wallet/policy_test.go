@@ -0,0 +1,359 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+)
+
+func TestWalletSetSpendingPolicy(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	require.True(t, w.SpendingPolicy().IsEmpty())
+
+	err = w.SetSpendingPolicy(SpendingPolicy{
+		MaxCoinsPerTransaction: 10e6,
+		MaxCoinsPerDay:         5e6,
+	})
+	testutil.RequireError(t, err, "max coins per transaction exceeds max coins per day")
+
+	err = w.SetSpendingPolicy(SpendingPolicy{
+		Allowlist: []cipher.Address{{}},
+	})
+	testutil.RequireError(t, err, "allowlist contains a null address")
+
+	p := SpendingPolicy{
+		MaxCoinsPerTransaction: 5e6,
+		MaxCoinsPerDay:         10e6,
+		Allowlist:              []cipher.Address{testutil.MakeAddress()},
+		MaxOutputs:             3,
+		MinChange:              1e6,
+	}
+	require.NoError(t, w.SetSpendingPolicy(p))
+	require.Equal(t, p, w.SpendingPolicy())
+	require.NoError(t, w.Validate())
+
+	// The policy is persisted in the wallet file
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	require.NoError(t, w.Save(dir))
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, p, lw.SpendingPolicy())
+
+	// An invalid policy in the wallet file fails validation
+	lw.Meta[metaSpendingPolicy] = `{"allowlist":["foo"]}`
+	testutil.RequireError(t, lw.Validate(), `invalid spending policy: invalid allowlist address "foo": Invalid address length`)
+	lw.Meta[metaSpendingPolicy] = w.Meta[metaSpendingPolicy]
+	lw.Meta[metaPolicySpends] = "123:abc"
+	testutil.RequireError(t, lw.Validate(), `invalid policy spend "123:abc": strconv.ParseUint: parsing "abc": invalid syntax`)
+
+	// An empty policy removes the policy and the daily spending
+	w.Meta[metaPolicySpends] = "123:456"
+	require.NoError(t, w.SetSpendingPolicy(SpendingPolicy{}))
+	require.True(t, w.SpendingPolicy().IsEmpty())
+	_, ok := w.Meta[metaSpendingPolicy]
+	require.False(t, ok)
+	_, ok = w.Meta[metaPolicySpends]
+	require.False(t, ok)
+}
+
+func TestWalletCheckSpendingPolicy(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(2)
+	require.NoError(t, err)
+
+	change := w.Entries[0].MDLAddress()
+	allowed := testutil.MakeAddress()
+	other := testutil.MakeAddress()
+	input := testutil.RandSHA256(t)
+
+	makeTxn := func(outs ...coin.TransactionOutput) *coin.Transaction {
+		return &coin.Transaction{
+			Out: outs,
+		}
+	}
+
+	now := time.Now()
+
+	cases := []struct {
+		name   string
+		policy SpendingPolicy
+		spends string
+		txn    *coin.Transaction
+		rule   SpendingRule
+	}{
+		{
+			name: "no policy",
+			txn:  makeTxn(coin.TransactionOutput{Address: other, Coins: 100e6}),
+		},
+		{
+			name: "max outputs",
+			policy: SpendingPolicy{
+				MaxOutputs: 1,
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: other, Coins: 1e6},
+				coin.TransactionOutput{Address: change, Coins: 1e6},
+			),
+			rule: RuleMaxOutputs,
+		},
+		{
+			name: "allowlist, change is allowed",
+			policy: SpendingPolicy{
+				Allowlist: []cipher.Address{allowed},
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: allowed, Coins: 1e6},
+				coin.TransactionOutput{Address: change, Coins: 1e6},
+			),
+		},
+		{
+			name: "allowlist",
+			policy: SpendingPolicy{
+				Allowlist: []cipher.Address{allowed},
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: allowed, Coins: 1e6},
+				coin.TransactionOutput{Address: other, Coins: 1e6},
+			),
+			rule: RuleAllowlist,
+		},
+		{
+			name: "max coins per transaction, change is not spent",
+			policy: SpendingPolicy{
+				MaxCoinsPerTransaction: 2e6,
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: other, Coins: 2e6},
+				coin.TransactionOutput{Address: change, Coins: 10e6},
+			),
+		},
+		{
+			name: "max coins per transaction",
+			policy: SpendingPolicy{
+				MaxCoinsPerTransaction: 2e6,
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: other, Coins: 1e6},
+				coin.TransactionOutput{Address: allowed, Coins: 2e6},
+			),
+			rule: RuleMaxCoinsPerTransaction,
+		},
+		{
+			name: "max coins per day, old spends are ignored",
+			policy: SpendingPolicy{
+				MaxCoinsPerDay: 5e6,
+			},
+			spends: fmtSpends(now.Add(-25*time.Hour), 4e6, now.Add(-time.Hour), 2e6),
+			txn:    makeTxn(coin.TransactionOutput{Address: other, Coins: 3e6}),
+		},
+		{
+			name: "max coins per day",
+			policy: SpendingPolicy{
+				MaxCoinsPerDay: 5e6,
+			},
+			spends: fmtSpends(now.Add(-2*time.Hour), 2e6, now.Add(-time.Hour), 2e6),
+			txn:    makeTxn(coin.TransactionOutput{Address: other, Coins: 2e6}),
+			rule:   RuleMaxCoinsPerDay,
+		},
+		{
+			name: "max coins per day, replaced spends are ignored",
+			policy: SpendingPolicy{
+				MaxCoinsPerDay: 5e6,
+			},
+			spends: policySpend{
+				time:   now.Add(-time.Hour).Unix(),
+				coins:  4e6,
+				inputs: []cipher.SHA256{testutil.RandSHA256(t), input},
+			}.String(),
+			txn: &coin.Transaction{
+				In:  []cipher.SHA256{input},
+				Out: []coin.TransactionOutput{{Address: other, Coins: 5e6}},
+			},
+		},
+		{
+			name: "min change",
+			policy: SpendingPolicy{
+				MinChange: 1e6,
+			},
+			txn: makeTxn(
+				coin.TransactionOutput{Address: other, Coins: 5e6},
+				coin.TransactionOutput{Address: change, Coins: 999999},
+			),
+			rule: RuleMinChange,
+		},
+		{
+			name: "min change, no change",
+			policy: SpendingPolicy{
+				MinChange: 1e6,
+			},
+			txn:  makeTxn(coin.TransactionOutput{Address: other, Coins: 5e6}),
+			rule: RuleMinChange,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wlt := w.clone()
+			require.NoError(t, wlt.SetSpendingPolicy(tc.policy))
+			if tc.spends != "" {
+				wlt.Meta[metaPolicySpends] = tc.spends
+			}
+
+			err := wlt.CheckSpendingPolicy(tc.txn, now)
+			if tc.rule == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			e, ok := err.(PolicyViolationError)
+			require.True(t, ok, "%T", err)
+			require.Equal(t, tc.rule, e.Rule)
+		})
+	}
+}
+
+func fmtSpends(t1 time.Time, coins1 uint64, t2 time.Time, coins2 uint64) string {
+	w := &Wallet{
+		Meta: map[string]string{},
+	}
+	if err := w.SetSpendingPolicy(SpendingPolicy{MaxCoinsPerDay: 100e6}); err != nil {
+		panic(err)
+	}
+	w.RecordSpend(&coin.Transaction{Out: []coin.TransactionOutput{{Address: testutil.MakeAddress(), Coins: coins1}}}, t1)
+	w.RecordSpend(&coin.Transaction{Out: []coin.TransactionOutput{{Address: testutil.MakeAddress(), Coins: coins2}}}, t2)
+	return w.Meta[metaPolicySpends]
+}
+
+func TestWalletRecordSpend(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(1)
+	require.NoError(t, err)
+
+	change := w.Entries[0].MDLAddress()
+	txn := &coin.Transaction{
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 2e6},
+			{Address: change, Coins: 10e6},
+		},
+	}
+
+	now := time.Unix(1e9, 0)
+
+	// Nothing is recorded without a daily limit
+	w.RecordSpend(txn, now)
+	require.Equal(t, uint64(0), w.SpentToday(now))
+	_, ok := w.Meta[metaPolicySpends]
+	require.False(t, ok)
+
+	require.NoError(t, w.SetSpendingPolicy(SpendingPolicy{
+		MaxCoinsPerDay: 10e6,
+	}))
+
+	// Only the coins sent out of the wallet are recorded
+	w.RecordSpend(txn, now)
+	w.RecordSpend(txn, now.Add(time.Hour))
+	require.Equal(t, uint64(4e6), w.SpentToday(now.Add(time.Hour)))
+	require.NoError(t, w.Validate())
+
+	// Spends older than 24 hours don't count and are removed by the next spend
+	later := now.Add(spendingPolicyDay + time.Minute)
+	require.Equal(t, uint64(2e6), w.SpentToday(later))
+	w.RecordSpend(txn, later)
+	require.Len(t, w.policySpends(), 2)
+	require.Equal(t, uint64(4e6), w.SpentToday(later))
+
+	// A transaction replaces the spends which share an input with it, so that it counts once
+	delete(w.Meta, metaPolicySpends)
+	in1 := testutil.RandSHA256(t)
+	in2 := testutil.RandSHA256(t)
+	txn.In = []cipher.SHA256{in1, in2}
+	w.RecordSpend(txn, now)
+	require.Equal(t, uint64(2e6), w.SpentToday(now))
+	require.NoError(t, w.Validate())
+
+	replacement := &coin.Transaction{
+		In: []cipher.SHA256{in2},
+		Out: []coin.TransactionOutput{
+			{Address: testutil.MakeAddress(), Coins: 3e6},
+		},
+	}
+	w.RecordSpend(replacement, now.Add(time.Minute))
+	require.Equal(t, uint64(3e6), w.SpentToday(now.Add(time.Minute)))
+	require.Equal(t, []policySpend{{
+		time:   now.Add(time.Minute).Unix(),
+		coins:  3e6,
+		inputs: []cipher.SHA256{in2},
+	}}, w.policySpends())
+
+	// A replacement which doesn't spend out of the wallet removes the spend
+	w.RecordSpend(&coin.Transaction{
+		In: []cipher.SHA256{in2},
+		Out: []coin.TransactionOutput{
+			{Address: change, Coins: 3e6},
+		},
+	}, now.Add(2*time.Minute))
+	require.Equal(t, uint64(0), w.SpentToday(now.Add(2*time.Minute)))
+	_, ok = w.Meta[metaPolicySpends]
+	require.False(t, ok)
+
+	// Spends recorded without their inputs are still valid
+	w.Meta[metaPolicySpends] = fmt.Sprintf("%d:1000000", now.Unix())
+	require.NoError(t, w.Validate())
+	require.Equal(t, uint64(1e6), w.SpentToday(now))
+	w.Meta[metaPolicySpends] = fmt.Sprintf("%d:1000000:abc", now.Unix())
+	require.Error(t, w.Validate())
+}
+
+func TestWalletSignTransactionSpendingPolicy(t *testing.T) {
+	txnSigned, uxs, seckeys := makeTransaction(t, 1)
+	txn := txnSigned
+	txn.Sigs = make([]cipher.Sig, len(txnSigned.Sigs))
+
+	w := &Wallet{
+		Meta: map[string]string{},
+	}
+	p := cipher.MustPubKeyFromSecKey(seckeys[0])
+	require.NoError(t, w.AddEntry(Entry{
+		Address: cipher.AddressFromPubKey(p),
+		Public:  p,
+		Secret:  seckeys[0],
+	}))
+
+	require.NoError(t, w.SetSpendingPolicy(SpendingPolicy{
+		MaxOutputs: 1,
+	}))
+
+	// The transaction is refused before it is signed
+	_, err := w.SignTransaction(&txn, nil, uxs)
+	require.Equal(t, PolicyViolationError{
+		Rule:    RuleMaxOutputs,
+		Message: "transaction has 2 outputs, the maximum is 1",
+	}, err)
+	require.Equal(t, "spending policy rule max_outputs violated: transaction has 2 outputs, the maximum is 1", err.Error())
+
+	require.NoError(t, w.SetSpendingPolicy(SpendingPolicy{
+		MaxOutputs: 2,
+	}))
+	signedTxn, err := w.SignTransaction(&txn, nil, uxs)
+	require.NoError(t, err)
+	require.True(t, signedTxn.IsFullySigned())
+}
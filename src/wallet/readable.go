@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -10,18 +11,37 @@ import (
 
 // ReadableEntry wallet entry with json tags
 type ReadableEntry struct {
-	Address string `json:"address"`
-	Public  string `json:"public_key"`
-	Secret  string `json:"secret_key"`
+	Address     string            `json:"address"`
+	Public      string            `json:"public_key"`
+	Secret      string            `json:"secret_key"`
+	ChildNumber *uint32           `json:"child_number,omitempty"`
+	Change      *uint32           `json:"change,omitempty"`
+	Label       string            `json:"label,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
 }
 
 // NewReadableEntry creates readable wallet entry
-func NewReadableEntry(coinType CoinType, w Entry) ReadableEntry {
+func NewReadableEntry(coinType CoinType, walletType string, w Entry) ReadableEntry {
 	re := ReadableEntry{}
+	if hasBip44Chains(walletType) {
+		childNumber := w.ChildNumber
+		change := w.Change
+		re.ChildNumber = &childNumber
+		re.Change = &change
+	}
+
 	if !w.Address.Null() {
 		re.Address = w.Address.String()
 	}
 
+	re.Label = w.Label
+	if len(w.Meta) > 0 {
+		re.Meta = make(map[string]string, len(w.Meta))
+		for k, v := range w.Meta {
+			re.Meta[k] = v
+		}
+	}
+
 	if !w.Public.Null() {
 		re.Public = w.Public.Hex()
 	}
@@ -45,10 +65,10 @@ type ReadableEntries []ReadableEntry
 
 // ToWalletEntries convert readable entries to entries
 // converts base on the wallet version.
-func (res ReadableEntries) toWalletEntries(coinType CoinType, isEncrypted bool) ([]Entry, error) {
+func (res ReadableEntries) toWalletEntries(coinType CoinType, walletType string, isEncrypted bool) ([]Entry, error) {
 	entries := make([]Entry, len(res))
 	for i, re := range res {
-		e, err := newEntryFromReadable(coinType, &re)
+		e, err := newEntryFromReadable(coinType, walletType, &re)
 		if err != nil {
 			return []Entry{}, err
 		}
@@ -60,13 +80,20 @@ func (res ReadableEntries) toWalletEntries(coinType CoinType, isEncrypted bool)
 			}
 		}
 
+		// The entries of a remote wallet have no secret key, only their public key is verified
+		if walletType == WalletTypeRemote {
+			if err := e.VerifyPublic(); err != nil {
+				return nil, err
+			}
+		}
+
 		entries[i] = *e
 	}
 	return entries, nil
 }
 
 // newEntryFromReadable creates WalletEntry base one ReadableWalletEntry
-func newEntryFromReadable(coinType CoinType, w *ReadableEntry) (*Entry, error) {
+func newEntryFromReadable(coinType CoinType, walletType string, w *ReadableEntry) (*Entry, error) {
 	var a cipher.Addresser
 	var err error
 
@@ -83,9 +110,13 @@ func newEntryFromReadable(coinType CoinType, w *ReadableEntry) (*Entry, error) {
 		return nil, err
 	}
 
-	p, err := cipher.PubKeyFromHex(w.Public)
-	if err != nil {
-		return nil, err
+	// The entries of an addresses wallet have no public key
+	var p cipher.PubKey
+	if w.Public != "" || walletType != WalletTypeAddresses {
+		p, err = cipher.PubKeyFromHex(w.Public)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Decodes the secret hex string if any
@@ -104,11 +135,29 @@ func newEntryFromReadable(coinType CoinType, w *ReadableEntry) (*Entry, error) {
 		}
 	}
 
-	return &Entry{
+	e := &Entry{
 		Address: a,
 		Public:  p,
 		Secret:  secret,
-	}, nil
+		Label:   w.Label,
+	}
+
+	if len(w.Meta) > 0 {
+		e.Meta = make(map[string]string, len(w.Meta))
+		for k, v := range w.Meta {
+			e.Meta[k] = v
+		}
+	}
+
+	if hasBip44Chains(walletType) {
+		if w.ChildNumber == nil || w.Change == nil {
+			return nil, errors.New("bip44 wallet entry missing child_number or change")
+		}
+		e.ChildNumber = *w.ChildNumber
+		e.Change = *w.Change
+	}
+
+	return e, nil
 }
 
 // ReadableWallet used for [de]serialization of a Wallet
@@ -121,7 +170,7 @@ type ReadableWallet struct {
 func NewReadableWallet(w *Wallet) *ReadableWallet {
 	readable := make(ReadableEntries, len(w.Entries))
 	for i, e := range w.Entries {
-		readable[i] = NewReadableEntry(w.coin(), e)
+		readable[i] = NewReadableEntry(w.coin(), w.Type(), e)
 	}
 
 	meta := make(map[string]string, len(w.Meta))
@@ -154,12 +203,13 @@ func (rw *ReadableWallet) ToWallet() (*Wallet, error) {
 		return nil, fmt.Errorf("invalid wallet %s: %v", w.Filename(), err)
 	}
 
-	ets, err := rw.Entries.toWalletEntries(w.coin(), w.IsEncrypted())
+	ets, err := rw.Entries.toWalletEntries(w.coin(), w.Type(), w.IsEncrypted())
 	if err != nil {
 		return nil, err
 	}
 
 	w.Entries = ets
+	w.migrate()
 
 	return w, nil
 }
@@ -189,6 +239,7 @@ func (rw *ReadableWallet) filename() string {
 func (rw *ReadableWallet) Erase() {
 	delete(rw.Meta, metaSeed)
 	delete(rw.Meta, metaLastSeed)
+	delete(rw.Meta, metaPassphrase)
 	delete(rw.Meta, metaSecrets)
 	for i := range rw.Entries {
 		rw.Entries[i].Secret = ""
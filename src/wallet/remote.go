@@ -0,0 +1,96 @@
+package wallet
+
+// This file contains the remote wallet type support.
+// A remote wallet has no seed and no secret keys, its entries hold the addresses and public keys
+// of the secret keys held by an external signer process. Transactions are signed by forwarding
+// the sign requests of their inputs to the signer, see remote_signer.go.
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+var (
+	// ErrWalletRemote is returned when trying to encrypt or access the secrets of a remote wallet
+	ErrWalletRemote = NewError(errors.New("wallet is remote, its secret keys are held by its signer"))
+	// ErrMissingSigner is returned when trying to create a remote wallet without a signer url
+	ErrMissingSigner = NewError(errors.New("missing signer url"))
+	// ErrRemoteOptionsNotAllowed is returned if the signer or public keys options are set when creating a wallet of another type
+	ErrRemoteOptionsNotAllowed = NewError(errors.New("signer and public keys options are only allowed for remote wallets"))
+)
+
+// IsRemote returns true if the secret keys of the wallet are held by a remote signer
+func (w *Wallet) IsRemote() bool {
+	return w.Type() == WalletTypeRemote
+}
+
+// SignerURL returns the signer url of a remote wallet
+func (w *Wallet) SignerURL() string {
+	return w.Meta[metaSigner]
+}
+
+// initRemote sets the signer url and adds the public keys of a new remote wallet.
+// If no public key is given, the public keys held by the signer are added.
+func (w *Wallet) initRemote(opts Options) error {
+	if opts.Signer == "" {
+		return ErrMissingSigner
+	}
+
+	if w.coin() != CoinTypeMDL {
+		return NewError(fmt.Errorf("remote wallets are not supported for %s wallets", w.coin()))
+	}
+
+	if opts.GenerateN != 0 || opts.ScanN != 0 {
+		return NewError(errors.New("generate and scan options are not allowed for remote wallets"))
+	}
+
+	signer, err := NewRemoteSigner(opts.Signer)
+	if err != nil {
+		return err
+	}
+
+	pubkeys := opts.PublicKeys
+	if len(pubkeys) == 0 {
+		pubkeys, err = signer.Keys()
+		if err != nil {
+			return err
+		}
+
+		if len(pubkeys) == 0 {
+			return NewError(errors.New("signer has no public keys"))
+		}
+	}
+
+	w.Meta[metaSigner] = opts.Signer
+	w.setLastSeed("")
+
+	for _, pk := range pubkeys {
+		if err := w.addRemoteEntry(pk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addRemoteEntry adds an entry without secret key to a remote wallet
+func (w *Wallet) addRemoteEntry(pk cipher.PubKey) error {
+	if err := pk.Verify(); err != nil {
+		return NewError(fmt.Errorf("invalid public key %s: %v", pk.Hex(), err))
+	}
+
+	a := w.addressConstructor()(pk)
+	for _, e := range w.Entries {
+		if e.Address == a {
+			return NewError(fmt.Errorf("duplicate address %s", a))
+		}
+	}
+
+	w.Entries = append(w.Entries, Entry{
+		Address: a,
+		Public:  pk,
+	})
+	return nil
+}
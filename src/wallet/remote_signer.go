@@ -0,0 +1,318 @@
+package wallet
+
+// This file contains the remote signer protocol.
+// A remote signer is an external process holding secret keys, which signs transaction inputs
+// on request over HTTP, on a loopback address or on a Unix socket.
+//
+// POST /sign signs a transaction input. The request body is a JSON RemoteSignRequest,
+// the response is a JSON RemoteSignResponse.
+// GET /keys returns the addresses and public keys of the signer, a JSON array of RemoteSignerKey.
+// Errors are returned with a non-200 status and a JSON RemoteSignerError.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MDLlife/MDL/src/cipher"
+)
+
+const (
+	// RemoteSignerTimeout is the timeout of the requests to a remote signer
+	RemoteSignerTimeout = 30 * time.Second
+
+	remoteSignerMaxBodySize = 1024 * 1024
+)
+
+// ErrInvalidSignerURL is returned if a remote signer URL is not a loopback http URL or a unix socket URL
+var ErrInvalidSignerURL = NewError(errors.New("invalid signer url, must be an http url of a loopback address or a unix:// url of a socket path"))
+
+// RemoteSignRequest is the request body of POST /sign of a remote signer
+type RemoteSignRequest struct {
+	InnerHash string `json:"inner_hash"`
+	Index     int    `json:"index"`
+	UxID      string `json:"uxid"`
+	Address   string `json:"address"`
+}
+
+// RemoteSignResponse is the response body of POST /sign of a remote signer
+type RemoteSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// RemoteSignerKey is an address and public key held by a remote signer, returned by GET /keys
+type RemoteSignerKey struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+}
+
+// RemoteSignerError is the response body of a remote signer request which failed
+type RemoteSignerError struct {
+	Error string `json:"error"`
+}
+
+// ParseSignerURL parses a remote signer URL, returning the network and address to dial and the base path of the requests.
+// The URL is either an http URL of a loopback address, e.g. http://127.0.0.1:6480,
+// or the unix:// URL of a Unix socket, e.g. unix:///var/run/mdl-signer.sock.
+func ParseSignerURL(s string) (network, addr, basePath string, err error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", "", "", ErrInvalidSignerURL
+	}
+
+	switch u.Scheme {
+	case "unix":
+		if u.Host != "" || !strings.HasPrefix(u.Path, "/") || u.RawQuery != "" {
+			return "", "", "", ErrInvalidSignerURL
+		}
+		return "unix", u.Path, "", nil
+	case "http":
+		host := u.Hostname()
+		if host != "localhost" {
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsLoopback() {
+				return "", "", "", ErrInvalidSignerURL
+			}
+		}
+		if u.Port() == "" || u.User != nil || u.RawQuery != "" {
+			return "", "", "", ErrInvalidSignerURL
+		}
+		return "tcp", u.Host, strings.TrimRight(u.Path, "/"), nil
+	default:
+		return "", "", "", ErrInvalidSignerURL
+	}
+}
+
+// RemoteSigner forwards sign requests to a remote signer process
+type RemoteSigner struct {
+	HTTPClient *http.Client
+	baseURL    string
+}
+
+// NewRemoteSigner creates a RemoteSigner for a signer URL, see ParseSignerURL
+func NewRemoteSigner(signerURL string) (*RemoteSigner, error) {
+	network, addr, basePath, err := ParseSignerURL(signerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout: RemoteSignerTimeout,
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	// The host of the URL is ignored by the transport, which always dials the signer address
+	baseURL := "http://" + addr + basePath
+	if network == "unix" {
+		baseURL = "http://signer"
+	}
+
+	return &RemoteSigner{
+		HTTPClient: &http.Client{
+			Transport: transport,
+			Timeout:   RemoteSignerTimeout,
+		},
+		baseURL: baseURL,
+	}, nil
+}
+
+// SignInput requests the signature of a transaction input from the remote signer.
+// The signature is not verified, the caller must verify it.
+func (s *RemoteSigner) SignInput(r SignInputRequest) (cipher.Sig, error) {
+	body, err := json.Marshal(RemoteSignRequest{
+		InnerHash: r.InnerHash.Hex(),
+		Index:     r.Index,
+		UxID:      r.UxID.Hex(),
+		Address:   r.Address.String(),
+	})
+	if err != nil {
+		return cipher.Sig{}, err
+	}
+
+	var rsp RemoteSignResponse
+	if err := s.do(http.MethodPost, "/sign", bytes.NewReader(body), &rsp); err != nil {
+		return cipher.Sig{}, err
+	}
+
+	sig, err := cipher.SigFromHex(rsp.Signature)
+	if err != nil {
+		return cipher.Sig{}, fmt.Errorf("remote signer returned an invalid signature: %v", err)
+	}
+
+	return sig, nil
+}
+
+// Keys returns the addresses and public keys held by the remote signer
+func (s *RemoteSigner) Keys() ([]cipher.PubKey, error) {
+	var keys []RemoteSignerKey
+	if err := s.do(http.MethodGet, "/keys", nil, &keys); err != nil {
+		return nil, err
+	}
+
+	pubkeys := make([]cipher.PubKey, len(keys))
+	for i, k := range keys {
+		pk, err := cipher.PubKeyFromHex(k.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("remote signer returned an invalid public key: %v", err)
+		}
+
+		if cipher.AddressFromPubKey(pk).String() != k.Address {
+			return nil, fmt.Errorf("remote signer returned public key %s for address %s", k.PublicKey, k.Address)
+		}
+
+		pubkeys[i] = pk
+	}
+
+	return pubkeys, nil
+}
+
+func (s *RemoteSigner) do(method, endpoint string, body io.Reader, obj interface{}) error {
+	req, err := http.NewRequest(method, s.baseURL+endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return NewError(fmt.Errorf("remote signer request failed: %v", err))
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, remoteSignerMaxBodySize))
+	if err != nil {
+		return NewError(fmt.Errorf("remote signer request failed: %v", err))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e RemoteSignerError
+		if err := json.Unmarshal(b, &e); err != nil || e.Error == "" {
+			return NewError(fmt.Errorf("remote signer request failed: %s", resp.Status))
+		}
+		return NewError(fmt.Errorf("remote signer refused to sign: %s", e.Error))
+	}
+
+	return json.Unmarshal(b, obj)
+}
+
+// NewRemoteSignerHandler returns the http.Handler of a remote signer, which signs with signer
+// the inputs owned by the MDL addresses of entries
+func NewRemoteSignerHandler(signer Signer, entries []Entry) http.Handler {
+	keys := make([]RemoteSignerKey, len(entries))
+	addrs := make(map[cipher.Address]struct{}, len(entries))
+	for i, e := range entries {
+		keys[i] = RemoteSignerKey{
+			Address:   e.Address.String(),
+			PublicKey: e.Public.Hex(),
+		}
+		addrs[e.MDLAddress()] = struct{}{}
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeRemoteSignerError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		writeRemoteSignerJSON(w, http.StatusOK, keys)
+	})
+
+	mux.HandleFunc("/sign", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeRemoteSignerError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var req RemoteSignRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, remoteSignerMaxBodySize)).Decode(&req); err != nil {
+			writeRemoteSignerError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		sr, err := parseRemoteSignRequest(req)
+		if err != nil {
+			writeRemoteSignerError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if _, ok := addrs[sr.Address]; !ok {
+			writeRemoteSignerError(w, http.StatusNotFound, fmt.Sprintf("unknown address %s", sr.Address))
+			return
+		}
+
+		sig, err := signer.SignInput(sr)
+		if err != nil {
+			writeRemoteSignerError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		logger.Infof("Remote signer signed input %d of transaction %s for address %s", sr.Index, sr.InnerHash.Hex(), sr.Address)
+
+		writeRemoteSignerJSON(w, http.StatusOK, RemoteSignResponse{
+			Signature: sig.Hex(),
+		})
+	})
+
+	return mux
+}
+
+func parseRemoteSignRequest(req RemoteSignRequest) (SignInputRequest, error) {
+	innerHash, err := cipher.SHA256FromHex(req.InnerHash)
+	if err != nil {
+		return SignInputRequest{}, fmt.Errorf("invalid inner_hash: %v", err)
+	}
+
+	uxID, err := cipher.SHA256FromHex(req.UxID)
+	if err != nil {
+		return SignInputRequest{}, fmt.Errorf("invalid uxid: %v", err)
+	}
+
+	addr, err := cipher.DecodeBase58Address(req.Address)
+	if err != nil {
+		return SignInputRequest{}, fmt.Errorf("invalid address: %v", err)
+	}
+
+	if req.Index < 0 {
+		return SignInputRequest{}, errors.New("invalid index")
+	}
+
+	return SignInputRequest{
+		InnerHash: innerHash,
+		Index:     req.Index,
+		UxID:      uxID,
+		Address:   addr,
+	}, nil
+}
+
+func writeRemoteSignerJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		logger.WithError(err).Error("Remote signer failed to write response")
+	}
+}
+
+func writeRemoteSignerError(w http.ResponseWriter, status int, msg string) {
+	writeRemoteSignerJSON(w, status, RemoteSignerError{
+		Error: msg,
+	})
+}
@@ -0,0 +1,267 @@
+package wallet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+)
+
+func TestParseSignerURL(t *testing.T) {
+	tt := []struct {
+		url      string
+		network  string
+		addr     string
+		basePath string
+		err      error
+	}{
+		{
+			url:     "http://127.0.0.1:6480",
+			network: "tcp",
+			addr:    "127.0.0.1:6480",
+		},
+		{
+			url:      "http://localhost:6480/signer/",
+			network:  "tcp",
+			addr:     "localhost:6480",
+			basePath: "/signer",
+		},
+		{
+			url:     "http://[::1]:6480",
+			network: "tcp",
+			addr:    "[::1]:6480",
+		},
+		{
+			url:     "unix:///var/run/mdl-signer.sock",
+			network: "unix",
+			addr:    "/var/run/mdl-signer.sock",
+		},
+		{
+			url: "http://192.168.1.2:6480",
+			err: ErrInvalidSignerURL,
+		},
+		{
+			url: "http://example.com:6480",
+			err: ErrInvalidSignerURL,
+		},
+		{
+			url: "http://127.0.0.1",
+			err: ErrInvalidSignerURL,
+		},
+		{
+			url: "https://127.0.0.1:6480",
+			err: ErrInvalidSignerURL,
+		},
+		{
+			url: "unix://mdl-signer.sock",
+			err: ErrInvalidSignerURL,
+		},
+		{
+			url: "",
+			err: ErrInvalidSignerURL,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.url, func(t *testing.T) {
+			network, addr, basePath, err := ParseSignerURL(tc.url)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.network, network)
+			require.Equal(t, tc.addr, addr)
+			require.Equal(t, tc.basePath, basePath)
+		})
+	}
+}
+
+// newTestRemoteSigner returns a collection wallet holding the secret keys and a remote signer server signing with it
+func newTestRemoteSigner(t *testing.T, keys []cipher.SecKey) (*Wallet, *httptest.Server) {
+	sw, err := NewWallet("signer.wlt", Options{
+		Type: WalletTypeCollection,
+	})
+	require.NoError(t, err)
+
+	for _, k := range keys {
+		_, err := sw.ImportSecretKey(k)
+		require.NoError(t, err)
+	}
+
+	srv := httptest.NewServer(NewRemoteSignerHandler(NewLocalSigner(sw), sw.Entries))
+	return sw, srv
+}
+
+func TestRemoteSignerSignTransaction(t *testing.T) {
+	txnSigned, uxs, seckeys := makeTransaction(t, 2)
+	txn := txnSigned
+	txn.Sigs = make([]cipher.Sig, len(txnSigned.Sigs))
+
+	sw, srv := newTestRemoteSigner(t, seckeys)
+	defer srv.Close()
+
+	// The public keys are fetched from the signer
+	w, err := NewWallet("remote.wlt", Options{
+		Type:   WalletTypeRemote,
+		Signer: srv.URL,
+	})
+	require.NoError(t, err)
+	require.True(t, w.IsRemote())
+	require.Len(t, w.Entries, 2)
+	for i, e := range w.Entries {
+		require.Equal(t, sw.Entries[i].Address, e.Address)
+		require.Equal(t, sw.Entries[i].Public, e.Public)
+		require.True(t, e.Secret.Null())
+	}
+
+	// Sign one input, then the other
+	signedTxn, err := w.SignTransaction(&txn, []int{1}, uxs)
+	require.NoError(t, err)
+	require.False(t, signedTxn.IsFullySigned())
+	require.True(t, signedTxn.Sigs[0].Null())
+
+	signedTxn, err = w.SignTransaction(signedTxn, nil, uxs)
+	require.NoError(t, err)
+	require.True(t, signedTxn.IsFullySigned())
+	require.NoError(t, signedTxn.Verify())
+	require.NoError(t, signedTxn.VerifyInputSignatures(uxs))
+
+	// The signer doesn't sign for the addresses of other wallets
+	otherTxn, otherUxs, _ := makeTransaction(t, 1)
+	otherTxn.Sigs = make([]cipher.Sig, 1)
+	w.Entries = append(w.Entries, Entry{
+		Address: otherUxs[0].Body.Address,
+	})
+	_, err = w.SignTransaction(&otherTxn, nil, otherUxs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "remote signer refused to sign: unknown address "+otherUxs[0].Body.Address.String())
+
+	// The signer is unreachable
+	srv.Close()
+	_, err = w.SignTransaction(&txn, nil, uxs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "remote signer request failed")
+}
+
+func TestRemoteSignerCreateTransactionSigned(t *testing.T) {
+	_, uxs, seckeys := makeTransaction(t, 1)
+
+	_, srv := newTestRemoteSigner(t, seckeys)
+	defer srv.Close()
+
+	w, err := NewWallet("remote.wlt", Options{
+		Type:       WalletTypeRemote,
+		Signer:     srv.URL,
+		PublicKeys: []cipher.PubKey{cipher.MustPubKeyFromSecKey(seckeys[0])},
+	})
+	require.NoError(t, err)
+
+	uxs[0].Body.Coins = 10e6
+	uxs[0].Body.Hours = 100
+	auxs := coin.AddressUxOuts{
+		uxs[0].Body.Address: uxs,
+	}
+
+	txn, _, err := w.CreateTransactionSigned(transaction.Params{
+		HoursSelection: transaction.HoursSelection{
+			Type: transaction.HoursSelectionTypeManual,
+		},
+		To: []coin.TransactionOutput{
+			{
+				Address: testutil.MakeAddress(),
+				Coins:   1e6,
+				Hours:   10,
+			},
+		},
+		ChangeAddress: &uxs[0].Body.Address,
+	}, auxs, uxs[0].Head.Time)
+	require.NoError(t, err)
+	require.True(t, txn.IsFullySigned())
+	require.NoError(t, txn.VerifyInputSignatures(uxs))
+}
+
+func TestRemoteSignerInvalidSignature(t *testing.T) {
+	txnSigned, uxs, _ := makeTransaction(t, 1)
+	txn := txnSigned
+	txn.Sigs = make([]cipher.Sig, 1)
+
+	// A signer which signs with another key
+	_, s := cipher.GenerateKeyPair()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req RemoteSignRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		sr, err := parseRemoteSignRequest(req)
+		require.NoError(t, err)
+		sig := cipher.MustSignHash(sr.Hash(), s)
+		writeRemoteSignerJSON(w, http.StatusOK, RemoteSignResponse{
+			Signature: sig.Hex(),
+		})
+	}))
+	defer srv.Close()
+
+	w := &Wallet{
+		Meta: map[string]string{
+			metaType:   WalletTypeRemote,
+			metaSigner: srv.URL,
+		},
+		Entries: []Entry{
+			{
+				Address: uxs[0].Body.Address,
+			},
+		},
+	}
+
+	_, err := w.SignTransaction(&txn, nil, uxs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid signature for input 0")
+}
+
+func TestRemoteSignerUnixSocket(t *testing.T) {
+	_, uxs, seckeys := makeTransaction(t, 1)
+
+	sw, srv := newTestRemoteSigner(t, seckeys)
+	srv.Close()
+
+	dir, err := ioutil.TempDir("", "remote-signer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "signer.sock")
+	ln, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+
+	usrv := &http.Server{
+		Handler: NewRemoteSignerHandler(NewLocalSigner(sw), sw.Entries),
+	}
+	go usrv.Serve(ln) // nolint: errcheck
+	defer usrv.Close()
+
+	s, err := NewRemoteSigner("unix://" + sock)
+	require.NoError(t, err)
+
+	keys, err := s.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []cipher.PubKey{sw.Entries[0].Public}, keys)
+
+	r := SignInputRequest{
+		InnerHash: testutil.RandSHA256(t),
+		UxID:      uxs[0].Hash(),
+		Address:   uxs[0].Body.Address,
+	}
+	sig, err := s.SignInput(r)
+	require.NoError(t, err)
+	require.NoError(t, cipher.VerifyAddressSignedHash(r.Address, sig, r.Hash()))
+}
@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/testutil"
+)
+
+func TestNewRemoteWallet(t *testing.T) {
+	pk1 := testutil.MakePubKey()
+	pk2 := testutil.MakePubKey()
+
+	tt := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{
+			name: "ok",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1, pk2},
+			},
+		},
+		{
+			name: "ok unix socket",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "unix:///var/run/mdl-signer.sock",
+				PublicKeys: []cipher.PubKey{pk1, pk2},
+			},
+		},
+		{
+			name: "missing signer",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				PublicKeys: []cipher.PubKey{pk1},
+			},
+			err: ErrMissingSigner,
+		},
+		{
+			name: "invalid signer",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://10.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1},
+			},
+			err: ErrInvalidSignerURL,
+		},
+		{
+			name: "duplicate public key",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1, pk1},
+			},
+			err: NewError(errors.New("duplicate address " + cipher.AddressFromPubKey(pk1).String())),
+		},
+		{
+			name: "seed not allowed",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1},
+				Seed:       "seed",
+			},
+			err: ErrSeedNotAllowed,
+		},
+		{
+			name: "encrypt not allowed",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1},
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+			},
+			err: ErrWalletRemote,
+		},
+		{
+			name: "generate not allowed",
+			opts: Options{
+				Type:       WalletTypeRemote,
+				Signer:     "http://127.0.0.1:6480",
+				PublicKeys: []cipher.PubKey{pk1},
+				GenerateN:  2,
+			},
+			err: NewError(errors.New("generate and scan options are not allowed for remote wallets")),
+		},
+		{
+			name: "signer for deterministic wallet",
+			opts: Options{
+				Seed:   "seed",
+				Signer: "http://127.0.0.1:6480",
+			},
+			err: ErrRemoteOptionsNotAllowed,
+		},
+		{
+			name: "public keys for collection wallet",
+			opts: Options{
+				Type:       WalletTypeCollection,
+				PublicKeys: []cipher.PubKey{pk1},
+			},
+			err: ErrRemoteOptionsNotAllowed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", tc.opts)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			require.True(t, w.IsRemote())
+			require.False(t, w.IsWatchOnly())
+			require.Equal(t, tc.opts.Signer, w.SignerURL())
+			require.Empty(t, w.seed())
+			require.Empty(t, w.secrets())
+			require.NoError(t, w.Validate())
+
+			require.Len(t, w.Entries, 2)
+			for i, e := range w.Entries {
+				require.Equal(t, cipher.AddressFromPubKey(tc.opts.PublicKeys[i]), e.Address)
+				require.Equal(t, tc.opts.PublicKeys[i], e.Public)
+				require.True(t, e.Secret.Null())
+			}
+
+			// Encrypting a remote wallet is refused
+			require.Equal(t, ErrWalletRemote, w.Lock([]byte("pwd"), CryptoTypeSha256Xor))
+
+			_, err = w.GenerateAddresses(1)
+			require.Equal(t, ErrWalletCantGenerateAddresses, err)
+
+			// The wallet can be saved and loaded
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			require.NoError(t, w.Save(dir))
+			lw, err := Load(dir + "/t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, w.Entries, lw.Entries)
+			require.Equal(t, w.SignerURL(), lw.SignerURL())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package wallet
+
+import (
+	"github.com/MDLlife/MDL/src/cipher/shamir"
+)
+
+// SplitSeed splits a wallet seed into n share mnemonics, any threshold of which recover the seed.
+// Fewer shares than the threshold reveal nothing about the seed. The seed passphrase of a bip44
+// wallet is not part of the shares.
+func SplitSeed(seed string, threshold, n int) ([]string, error) {
+	if seed == "" {
+		return nil, ErrWalletNoSeed
+	}
+
+	shares, err := shamir.Split([]byte(seed), threshold, n)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return shares, nil
+}
+
+// CombineSeedShares recovers a wallet seed from share mnemonics created by SplitSeed
+func CombineSeedShares(shares []string) (string, error) {
+	seed, err := shamir.Combine(shares)
+	if err != nil {
+		return "", NewError(err)
+	}
+
+	return string(seed), nil
+}
+
+// SplitWalletSeed splits the seed of an encrypted wallet into n share mnemonics,
+// any threshold of which recover the seed with RecoverWalletFromSeedShares.
+// Like GetWalletSeed, it requires the seed API to be enabled.
+func (serv *Service) SplitWalletSeed(wltID string, password []byte, threshold, n int) ([]string, error) {
+	seed, err := serv.GetWalletSeed(wltID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return SplitSeed(seed, threshold, n)
+}
+
+// RecoverWalletFromSeedShares recovers an encrypted wallet by the share mnemonics of its seed,
+// like RecoverWallet
+func (serv *Service) RecoverWalletFromSeedShares(wltName string, shares []string, passphrase string, password []byte) (*Wallet, error) {
+	seed, err := CombineSeedShares(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	return serv.RecoverWallet(wltName, seed, passphrase, password)
+}
@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher/bip39"
+	"github.com/MDLlife/MDL/src/cipher/shamir"
+)
+
+func TestSplitSeed(t *testing.T) {
+	seed := bip39.MustNewDefaultMnemonic()
+
+	shares, err := SplitSeed(seed, 2, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+
+	s, err := CombineSeedShares(shares[1:])
+	require.NoError(t, err)
+	require.Equal(t, seed, s)
+
+	_, err = CombineSeedShares(shares[:1])
+	require.Equal(t, NewError(shamir.ErrNotEnoughShares), err)
+
+	_, err = SplitSeed("", 2, 3)
+	require.Equal(t, ErrWalletNoSeed, err)
+
+	_, err = SplitSeed(seed, 3, 2)
+	require.Equal(t, NewError(shamir.ErrInvalidThreshold), err)
+}
+
+func TestServiceSplitWalletSeed(t *testing.T) {
+	seed := bip39.MustNewDefaultMnemonic()
+
+	for ct := range cryptoTable {
+		t.Run(string(ct), func(t *testing.T) {
+			s, err := NewService(Config{
+				WalletDir:       prepareWltDir(),
+				CryptoType:      ct,
+				EnableWalletAPI: true,
+				EnableSeedAPI:   true,
+			})
+			require.NoError(t, err)
+
+			w, err := s.CreateWallet("t.wlt", Options{
+				Type:      WalletTypeBip44,
+				Seed:      seed,
+				Encrypt:   true,
+				Password:  []byte("pwd"),
+				GenerateN: 2,
+			}, nil)
+			require.NoError(t, err)
+
+			_, err = s.SplitWalletSeed("t.wlt", []byte("wrong"), 2, 3)
+			require.Equal(t, ErrInvalidPassword, err)
+
+			shares, err := s.SplitWalletSeed("t.wlt", []byte("pwd"), 2, 3)
+			require.NoError(t, err)
+			require.Len(t, shares, 3)
+
+			// The shares of another seed don't recover the wallet
+			otherShares, err := SplitSeed(bip39.MustNewDefaultMnemonic(), 2, 2)
+			require.NoError(t, err)
+			_, err = s.RecoverWalletFromSeedShares("t.wlt", otherShares, "", []byte("pwd2"))
+			require.Equal(t, ErrWalletRecoverSeedWrong, err)
+
+			_, err = s.RecoverWalletFromSeedShares("t.wlt", shares[:1], "", []byte("pwd2"))
+			require.Equal(t, NewError(shamir.ErrNotEnoughShares), err)
+
+			w2, err := s.RecoverWalletFromSeedShares("t.wlt", []string{shares[2], shares[0]}, "", []byte("pwd2"))
+			require.NoError(t, err)
+			require.True(t, w2.IsEncrypted())
+			require.Equal(t, w.GetAddresses(), w2.GetAddresses())
+
+			// The wallet is encrypted with the new password
+			_, err = s.SplitWalletSeed("t.wlt", []byte("pwd"), 2, 3)
+			require.Equal(t, ErrInvalidPassword, err)
+		})
+	}
+
+	// The seed API must be enabled to split a seed
+	s, err := NewService(Config{
+		WalletDir:       prepareWltDir(),
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed:     seed,
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.SplitWalletSeed("t.wlt", []byte("pwd"), 2, 3)
+	require.Equal(t, ErrSeedAPIDisabled, err)
+}
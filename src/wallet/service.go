@@ -1,11 +1,17 @@
 package wallet
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
 )
 
 // BalanceGetter interface for getting the balance of given addresses
@@ -13,11 +19,19 @@ type BalanceGetter interface {
 	GetBalanceOfAddrs(addrs []cipher.Address) ([]BalancePair, error)
 }
 
-// Service wallet service struct
+// Service wallet service struct.
+// The embedded lock only guards the wallets, walletLocks and firstAddrIDMap maps and is held briefly.
+// Each wallet has its own lock, which is held during the operations on the wallet,
+// so that operations on different wallets run concurrently.
+// The wallets in the wallets map are never modified, a wallet is changed by replacing it
+// while holding its lock, so a wallet can be read from the map without holding its lock.
+// Locks are always acquired in the order: wallet lock, then service lock.
 type Service struct {
 	sync.RWMutex
 	wallets Wallets
-	config  Config
+	// walletLocks Key: wallet id; Value: lock of the operations on the wallet
+	walletLocks map[string]*sync.RWMutex
+	config      Config
 	// firstAddrIDMap Key: first address in wallet; Value: wallet id
 	firstAddrIDMap map[string]string
 }
@@ -26,8 +40,16 @@ type Service struct {
 type Config struct {
 	WalletDir       string
 	CryptoType      CryptoType
+	Argon2Time      uint32 // argon2id time cost of wallets encrypted with argon2id-chacha20poly1305, 0 for the default
+	Argon2Memory    uint32 // argon2id memory cost in KiB of wallets encrypted with argon2id-chacha20poly1305, 0 for the default
 	EnableWalletAPI bool
 	EnableSeedAPI   bool
+
+	// Timed backups
+	BackupDir       string        // directory of the timed backup archives
+	BackupInterval  time.Duration // interval between timed backups, 0 disables them
+	BackupRetention int           // number of timed backup archives kept, 0 keeps all of them
+	BackupPassword  []byte        // password of the timed backup archives
 }
 
 // NewConfig creates a default Config
@@ -44,6 +66,7 @@ func NewConfig() Config {
 func NewService(c Config) (*Service, error) {
 	serv := &Service{
 		config:         c,
+		walletLocks:    make(map[string]*sync.RWMutex),
 		firstAddrIDMap: make(map[string]string),
 	}
 
@@ -55,6 +78,18 @@ func NewService(c Config) (*Service, error) {
 		return nil, fmt.Errorf("failed to create wallet directory %s: %v", c.WalletDir, err)
 	}
 
+	if c.BackupInterval > 0 {
+		if c.BackupDir == "" {
+			return nil, errors.New("timed wallet backups require a backup directory")
+		}
+		if len(c.BackupPassword) == 0 {
+			return nil, errors.New("timed wallet backups require a backup password")
+		}
+		if err := os.MkdirAll(c.BackupDir, os.FileMode(0700)); err != nil {
+			return nil, fmt.Errorf("failed to create wallet backup directory %s: %v", c.BackupDir, err)
+		}
+	}
+
 	// Removes .wlt.bak files before loading wallets
 	if err := removeBackupFiles(serv.config.WalletDir); err != nil {
 		return nil, fmt.Errorf("remove .wlt.bak files in %v failed: %v", serv.config.WalletDir, err)
@@ -94,23 +129,29 @@ func (serv *Service) WalletDir() (string, error) {
 // CreateWallet creates a wallet with the given wallet file name and options.
 // A address will be automatically generated by default.
 func (serv *Service) CreateWallet(wltName string, options Options, bg BalanceGetter) (*Wallet, error) {
-	serv.Lock()
-	defer serv.Unlock()
+	serv.RLock()
 	if !serv.config.EnableWalletAPI {
+		serv.RUnlock()
 		return nil, ErrWalletAPIDisabled
 	}
 	if wltName == "" {
 		wltName = serv.generateUniqueWalletFilename()
 	}
+	serv.RUnlock()
 
 	return serv.loadWallet(wltName, options, bg)
 }
 
-// loadWallet loads wallet from seed and scan the first N addresses
+// loadWallet loads wallet from seed and scan the first N addresses.
+// The wallet is generated and scanned without holding the service lock.
 func (serv *Service) loadWallet(wltName string, options Options, bg BalanceGetter) (*Wallet, error) {
-	// service decides what crypto type the wallet should use.
+	// service decides what crypto type the wallet should use, unless it is given in the options.
 	if options.Encrypt {
-		options.CryptoType = serv.config.CryptoType
+		if options.CryptoType == "" {
+			options.CryptoType = serv.config.CryptoType
+		}
+		options.Argon2Time = serv.config.Argon2Time
+		options.Argon2Memory = serv.config.Argon2Memory
 	}
 
 	w, err := NewWalletScanAhead(wltName, options, bg)
@@ -118,9 +159,15 @@ func (serv *Service) loadWallet(wltName string, options Options, bg BalanceGette
 		return nil, err
 	}
 
-	// Check for duplicate wallets by initial seed
-	if _, ok := serv.firstAddrIDMap[w.Entries[0].Address.String()]; ok {
-		return nil, ErrSeedUsed
+	serv.Lock()
+	defer serv.Unlock()
+
+	// Check for duplicate wallets by initial seed.
+	// Wallets without a seed are not checked, watch-only wallets may watch the addresses of another wallet.
+	if hasSeedType(w.Type()) {
+		if _, ok := serv.firstAddrIDMap[w.Entries[0].Address.String()]; ok {
+			return nil, ErrSeedUsed
+		}
 	}
 
 	if err := serv.wallets.add(w); err != nil {
@@ -133,7 +180,10 @@ func (serv *Service) loadWallet(wltName string, options Options, bg BalanceGette
 		return nil, err
 	}
 
-	serv.firstAddrIDMap[w.Entries[0].Address.String()] = w.Filename()
+	serv.walletLocks[w.Filename()] = &sync.RWMutex{}
+	if hasSeedType(w.Type()) {
+		serv.firstAddrIDMap[w.Entries[0].Address.String()] = w.Filename()
+	}
 
 	return w.clone(), nil
 }
@@ -150,24 +200,29 @@ func (serv *Service) generateUniqueWalletFilename() string {
 	return wltName
 }
 
-// EncryptWallet encrypts wallet with password
-func (serv *Service) EncryptWallet(wltID string, password []byte) (*Wallet, error) {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return nil, ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+// EncryptWallet encrypts wallet with password.
+// If cryptoType is empty, the crypto type of the service config is used.
+func (serv *Service) EncryptWallet(wltID string, password []byte, cryptoType CryptoType) (*Wallet, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
 	if w.IsEncrypted() {
 		return nil, ErrWalletEncrypted
 	}
 
-	if err := w.Lock(password, serv.config.CryptoType); err != nil {
+	if cryptoType == "" {
+		cryptoType = serv.config.CryptoType
+	}
+
+	if _, err := getCrypto(cryptoType); err != nil {
+		return nil, NewError(err)
+	}
+
+	w.setArgon2Costs(serv.config.Argon2Time, serv.config.Argon2Memory)
+	if err := w.Lock(password, cryptoType); err != nil {
 		return nil, err
 	}
 
@@ -177,22 +232,17 @@ func (serv *Service) EncryptWallet(wltID string, password []byte) (*Wallet, erro
 	}
 
 	// Sets the encrypted wallet
-	serv.wallets.set(w)
+	serv.setWallet(w)
 	return w, nil
 }
 
 // DecryptWallet decrypts wallet with password
 func (serv *Service) DecryptWallet(wltID string, password []byte) (*Wallet, error) {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return nil, ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
 	// Returns error if wallet is not encrypted
 	if !w.IsEncrypted() {
@@ -211,25 +261,56 @@ func (serv *Service) DecryptWallet(wltID string, password []byte) (*Wallet, erro
 	}
 
 	// Sets the decrypted wallet in memory
-	serv.wallets.set(unlockWlt)
+	serv.setWallet(unlockWlt)
 	return unlockWlt, nil
 }
 
+// ChangePassword re-encrypts an encrypted wallet with a new password and crypto type in one step,
+// the wallet is never saved unencrypted. If cryptoType is empty, the wallet's crypto type is kept.
+func (serv *Service) ChangePassword(wltID string, password, newPassword []byte, cryptoType CryptoType) (*Wallet, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Wallets migrated to argon2id use the costs of the service config,
+	// wallets already encrypted with argon2id keep their costs
+	if cryptoType != "" && cryptoType != w.cryptoType() {
+		w.setArgon2Costs(serv.config.Argon2Time, serv.config.Argon2Memory)
+	}
+
+	if err := w.Reencrypt(password, newPassword, cryptoType); err != nil {
+		return nil, err
+	}
+
+	// Save to disk first
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	serv.setWallet(w)
+	return w.clone(), nil
+}
+
+// MigrateCryptoType re-encrypts an encrypted wallet with another crypto type, keeping its password
+func (serv *Service) MigrateCryptoType(wltID string, password []byte, cryptoType CryptoType) (*Wallet, error) {
+	if cryptoType == "" {
+		return nil, ErrMissingCryptoType
+	}
+
+	return serv.ChangePassword(wltID, password, password, cryptoType)
+}
+
 // NewAddresses generate address entries in given wallet,
 // return nil if wallet does not exist.
 // Set password as nil if the wallet is not encrypted, otherwise the password must be provided.
 func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]cipher.Address, error) {
-	serv.Lock()
-	defer serv.Unlock()
-
-	if !serv.config.EnableWalletAPI {
-		return nil, ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
 	var addrs []cipher.Address
 	f := func(wlt *Wallet) error {
@@ -257,11 +338,85 @@ func (serv *Service) NewAddresses(wltID string, password []byte, num uint64) ([]
 		return nil, err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
+
+	return addrs, nil
+}
+
+// ImportSecretKeys adds the secret keys to a collection wallet and returns their addresses.
+// Set password as nil if the wallet is not encrypted, otherwise the password must be provided.
+func (serv *Service) ImportSecretKeys(wltID string, password []byte, keys []cipher.SecKey) ([]cipher.Address, error) {
+	var addrs []cipher.Address
+	if err := serv.updateCollection(wltID, password, func(w *Wallet) error {
+		if w.coin() != CoinTypeMDL {
+			return errors.New("ImportSecretKeys called for non-mdl wallet")
+		}
+
+		addrs = make([]cipher.Address, len(keys))
+		for i, k := range keys {
+			a, err := w.ImportSecretKey(k)
+			if err != nil {
+				return err
+			}
+			addrs[i] = a.(cipher.Address)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
 
 	return addrs, nil
 }
 
+// RemoveAddresses removes the entries of the addresses, and their secret keys, from a collection wallet.
+// Set password as nil if the wallet is not encrypted, otherwise the password must be provided.
+func (serv *Service) RemoveAddresses(wltID string, password []byte, addrs []cipher.Address) error {
+	return serv.updateCollection(wltID, password, func(w *Wallet) error {
+		for _, a := range addrs {
+			if err := w.RemoveEntry(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// updateCollection opens a collection wallet for modification of its entries and saves it safely
+func (serv *Service) updateCollection(wltID string, password []byte, f func(*Wallet) error) error {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if w.Type() != WalletTypeCollection {
+		return ErrWalletNotCollection
+	}
+
+	if w.IsEncrypted() {
+		if err := w.GuardUpdate(password, f); err != nil {
+			return err
+		}
+	} else {
+		if len(password) != 0 {
+			return ErrWalletNotEncrypted
+		}
+
+		if err := f(w); err != nil {
+			return err
+		}
+	}
+
+	// Save the wallet first
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return err
+	}
+
+	serv.setWallet(w)
+
+	return nil
+}
+
 // GetMDLAddresses returns all addresses in given wallet
 func (serv *Service) GetMDLAddresses(wltID string) ([]cipher.Address, error) {
 	serv.RLock()
@@ -315,16 +470,11 @@ func (serv *Service) GetWallets() (Wallets, error) {
 
 // UpdateWalletLabel updates the wallet label
 func (serv *Service) UpdateWalletLabel(wltID, label string) error {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	w.setLabel(label)
 
@@ -332,32 +482,319 @@ func (serv *Service) UpdateWalletLabel(wltID, label string) error {
 		return err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 	return nil
 }
 
+// FreezeOutputs freezes unspent outputs in the wallet, so that they are only spent
+// when they are selected explicitly for a transaction.
+// The outputs are not required to belong to the wallet's addresses.
+func (serv *Service) FreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error) {
+	return serv.updateFrozenOutputs(wltID, func(w *Wallet) {
+		w.FreezeOutputs(uxIDs)
+	})
+}
+
+// UnfreezeOutputs unfreezes unspent outputs of the wallet, outputs which are not frozen are ignored
+func (serv *Service) UnfreezeOutputs(wltID string, uxIDs []cipher.SHA256) ([]cipher.SHA256, error) {
+	return serv.updateFrozenOutputs(wltID, func(w *Wallet) {
+		w.UnfreezeOutputs(uxIDs)
+	})
+}
+
+// updateFrozenOutputs updates the frozen outputs of a wallet and returns the outputs frozen after the update
+func (serv *Service) updateFrozenOutputs(wltID string, f func(*Wallet)) ([]cipher.SHA256, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	f(w)
+
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	serv.setWallet(w)
+	return w.FrozenOutputs(), nil
+}
+
+// GetFrozenOutputs returns the frozen outputs of the wallet
+func (serv *Service) GetFrozenOutputs(wltID string) ([]cipher.SHA256, error) {
+	w, release, err := serv.acquireWallet(wltID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return w.FrozenOutputs(), nil
+}
+
+// UpdateEntry updates the label and metadata of the entry of an address in the wallet.
+// Encrypted wallets don't require the password, labels and metadata are not encrypted.
+func (serv *Service) UpdateEntry(wltID string, addr cipher.Address, u EntryUpdate) (*Wallet, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if _, err := w.UpdateEntry(addr, u); err != nil {
+		return nil, err
+	}
+
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	serv.setWallet(w)
+	return w.clone(), nil
+}
+
+// SetSpendingPolicy sets the spending policy of an encrypted wallet, an empty policy removes it.
+// The password of the wallet is required, so that the policy can't be changed without it.
+func (serv *Service) SetSpendingPolicy(wltID string, password []byte, p SpendingPolicy) (*Wallet, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if !w.IsEncrypted() {
+		return nil, ErrSpendingPolicyNotEncrypted
+	}
+
+	// Verifies the password
+	if err := w.GuardView(password, func(*Wallet) error {
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := w.SetSpendingPolicy(p); err != nil {
+		return nil, err
+	}
+
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	serv.setWallet(w)
+	return w.clone(), nil
+}
+
 // UnloadWallet removes wallet of given wallet id from the service
 func (serv *Service) UnloadWallet(wltID string) error {
+	_, release, err := serv.acquireWallet(wltID, true)
+	switch err {
+	case nil:
+	case ErrWalletNotExist:
+		return nil
+	default:
+		return err
+	}
+	defer release()
+
 	serv.Lock()
 	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
-	}
+	serv.unloadWallet(wltID)
+	return nil
+}
 
+// unloadWallet removes the wallet of given wallet id, and its lock, from the service maps.
+// The caller must hold the service lock and the wallet lock.
+func (serv *Service) unloadWallet(wltID string) {
 	wlt := serv.wallets.get(wltID)
-	if wlt != nil && len(wlt.Entries) > 0 {
+	if wlt != nil && len(wlt.Entries) > 0 && hasSeedType(wlt.Type()) {
 		addr := wlt.Entries[0].Address.String()
 		delete(serv.firstAddrIDMap, addr)
 	}
 
 	serv.wallets.remove(wltID)
+	delete(serv.walletLocks, wltID)
+}
+
+// acquireWallet waits for the lock of the wallet of given wallet id and returns a clone of the wallet,
+// with the function which releases the lock. The lock is exclusive if write is true, shared otherwise.
+// The service lock is not held while waiting, so operations on other wallets are not blocked.
+func (serv *Service) acquireWallet(wltID string, write bool) (*Wallet, func(), error) {
+	serv.RLock()
+	if !serv.config.EnableWalletAPI {
+		serv.RUnlock()
+		return nil, nil, ErrWalletAPIDisabled
+	}
+	l := serv.walletLocks[wltID]
+	serv.RUnlock()
+
+	if l == nil {
+		return nil, nil, ErrWalletNotExist
+	}
+
+	release := l.RUnlock
+	if write {
+		l.Lock()
+		release = l.Unlock
+	} else {
+		l.RLock()
+	}
+
+	serv.RLock()
+	defer serv.RUnlock()
+
+	// The wallet may have been removed, or replaced by another wallet with the same id, while waiting
+	if serv.walletLocks[wltID] != l {
+		release()
+		return nil, nil, ErrWalletNotExist
+	}
+
+	return serv.wallets.get(wltID).clone(), release, nil
+}
+
+// setWallet replaces a wallet in the service. The caller must hold the wallet lock for writing.
+func (serv *Service) setWallet(w *Wallet) {
+	serv.Lock()
+	defer serv.Unlock()
+	serv.wallets.set(w)
+}
+
+// ArchiveWallet unloads the wallet of given wallet id and moves its file into the
+// archive subdirectory of the wallet directory. Returns the path of the archived file.
+// If a wallet file of the same name was archived before, a timestamp is appended to the name.
+func (serv *Service) ArchiveWallet(wltID string) (string, error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	dir := filepath.Join(serv.config.WalletDir, WalletArchiveDir)
+	if err := os.MkdirAll(dir, os.FileMode(0700)); err != nil {
+		return "", fmt.Errorf("failed to create wallet archive directory %s: %v", dir, err)
+	}
+
+	dst := filepath.Join(dir, w.Filename())
+	if _, err := os.Stat(dst); err == nil {
+		name := strings.TrimSuffix(w.Filename(), "."+WalletExt)
+		dst = filepath.Join(dir, fmt.Sprintf("%s_%d.%s", name, time.Now().UTC().UnixNano(), WalletExt))
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.Rename(filepath.Join(serv.config.WalletDir, w.Filename()), dst); err != nil {
+		return "", err
+	}
+
+	serv.Lock()
+	defer serv.Unlock()
+	serv.unloadWallet(wltID)
+	return dst, nil
+}
+
+// DeleteWallet unloads the wallet of given wallet id and permanently removes its file.
+// Set password as nil if the wallet is not encrypted, otherwise the password must be provided,
+// it is verified before the wallet is removed.
+func (serv *Service) DeleteWallet(wltID string, password []byte) error {
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if w.IsEncrypted() {
+		if err := w.GuardView(password, func(*Wallet) error {
+			return nil
+		}); err != nil {
+			return err
+		}
+	} else if len(password) != 0 {
+		return ErrWalletNotEncrypted
+	}
+
+	if err := os.Remove(filepath.Join(serv.config.WalletDir, w.Filename())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	serv.Lock()
+	defer serv.Unlock()
+	serv.unloadWallet(wltID)
 	return nil
 }
 
+// RenameWallet changes the filename, which is the id, of a loaded wallet.
+// The wallet is saved under the new filename before the old file is removed,
+// so the wallet is never lost if renaming fails.
+func (serv *Service) RenameWallet(wltID, newWltID string) (*Wallet, error) {
+	serv.RLock()
+	enableWalletAPI := serv.config.EnableWalletAPI
+	serv.RUnlock()
+
+	if !enableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	if !isWalletFilename(newWltID) {
+		return nil, ErrInvalidWalletFilename
+	}
+
+	w, release, err := serv.acquireWallet(wltID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if newWltID == wltID {
+		return w, nil
+	}
+
+	// The service lock is held until the wallet is registered with its new id,
+	// so that no other wallet can be created with the new id in the meantime
+	serv.Lock()
+	defer serv.Unlock()
+
+	newPath := filepath.Join(serv.config.WalletDir, newWltID)
+	if serv.wallets.get(newWltID) != nil {
+		return nil, ErrWalletFileExists
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, ErrWalletFileExists
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	w.setFilename(newWltID)
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(filepath.Join(serv.config.WalletDir, wltID)); err != nil && !os.IsNotExist(err) {
+		// Keep the wallet under its old filename only
+		if rmErr := os.Remove(newPath); rmErr != nil {
+			logger.WithError(rmErr).Errorf("os.Remove(%s) failed", newPath)
+		}
+		return nil, err
+	}
+
+	// The wallet keeps its lock, operations waiting for the lock of the old id fail with ErrWalletNotExist
+	l := serv.walletLocks[wltID]
+	serv.unloadWallet(wltID)
+	serv.wallets.set(w)
+	serv.walletLocks[newWltID] = l
+	if len(w.Entries) > 0 && hasSeedType(w.Type()) {
+		serv.firstAddrIDMap[w.Entries[0].Address.String()] = newWltID
+	}
+
+	return w.clone(), nil
+}
+
 func (serv *Service) setWallets(wlts Wallets) {
 	serv.wallets = wlts
 
 	for wltID, wlt := range wlts {
+		serv.walletLocks[wltID] = &sync.RWMutex{}
+		if !hasSeedType(wlt.Type()) {
+			continue
+		}
 		addr := wlt.Entries[0].Address.String()
 		serv.firstAddrIDMap[addr] = wltID
 	}
@@ -367,19 +804,30 @@ func (serv *Service) setWallets(wlts Wallets) {
 // Returns ErrWalletNotEncrypted if it's not encrypted
 func (serv *Service) GetWalletSeed(wltID string, password []byte) (string, error) {
 	serv.RLock()
-	defer serv.RUnlock()
-	if !serv.config.EnableWalletAPI {
+	enableWalletAPI, enableSeedAPI := serv.config.EnableWalletAPI, serv.config.EnableSeedAPI
+	serv.RUnlock()
+
+	if !enableWalletAPI {
 		return "", ErrWalletAPIDisabled
 	}
 
-	if !serv.config.EnableSeedAPI {
+	if !enableSeedAPI {
 		return "", ErrSeedAPIDisabled
 	}
 
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, false)
 	if err != nil {
 		return "", err
 	}
+	defer release()
+
+	if w.IsWatchOnly() {
+		return "", ErrWalletWatchOnly
+	}
+
+	if !hasSeedType(w.Type()) {
+		return "", ErrWalletNoSeed
+	}
 
 	if !w.IsEncrypted() {
 		return "", ErrWalletNotEncrypted
@@ -396,18 +844,18 @@ func (serv *Service) GetWalletSeed(wltID string, password []byte) (string, error
 	return seed, nil
 }
 
-// UpdateSecrets opens a wallet for modification of secret data and saves it safely
+// UpdateSecrets opens a wallet for modification of secret data and saves it safely.
+// Returns ErrWalletWatchOnly for watch-only wallets.
 func (serv *Service) UpdateSecrets(wltID string, password []byte, f func(*Wallet) error) error {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return err
 	}
+	defer release()
+
+	if w.IsWatchOnly() {
+		return ErrWalletWatchOnly
+	}
 
 	if w.IsEncrypted() {
 		if err := w.GuardUpdate(password, f); err != nil {
@@ -426,23 +874,18 @@ func (serv *Service) UpdateSecrets(wltID string, password []byte, f func(*Wallet
 		return err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 
 	return nil
 }
 
 // Update opens a wallet for modification of non-secret data and saves it safely
 func (serv *Service) Update(wltID string, f func(*Wallet) error) error {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, true)
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	if err := f(w); err != nil {
 		return err
@@ -453,23 +896,23 @@ func (serv *Service) Update(wltID string, f func(*Wallet) error) error {
 		return err
 	}
 
-	serv.wallets.set(w)
+	serv.setWallet(w)
 
 	return nil
 }
 
-// ViewSecrets opens a wallet for reading secret data
+// ViewSecrets opens a wallet for reading secret data.
+// Returns ErrWalletWatchOnly for watch-only wallets.
 func (serv *Service) ViewSecrets(wltID string, password []byte, f func(*Wallet) error) error {
-	serv.RLock()
-	defer serv.RUnlock()
-	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltID)
+	w, release, err := serv.acquireWallet(wltID, false)
 	if err != nil {
 		return err
 	}
+	defer release()
+
+	if w.IsWatchOnly() {
+		return ErrWalletWatchOnly
+	}
 
 	if w.IsEncrypted() {
 		return w.GuardView(password, f)
@@ -480,47 +923,185 @@ func (serv *Service) ViewSecrets(wltID string, password []byte, f func(*Wallet)
 	}
 }
 
-// View opens a wallet for reading non-secret data
-func (serv *Service) View(wltID string, f func(*Wallet) error) error {
+// RecordSpend records an injected transaction for the daily limit of the spending policies of the wallets
+// which own an input address of it, see Wallet.RecordSpend.
+// Returns a PolicyViolationError if the transaction exceeds the daily limit of a wallet, in which case nothing is recorded.
+// The returned function undoes the recording, for when the injection is rolled back.
+func (serv *Service) RecordSpend(txn *coin.Transaction, inputAddrs []cipher.Address, now time.Time) (func(), error) {
+	undo := func() {}
+
 	serv.RLock()
-	defer serv.RUnlock()
 	if !serv.config.EnableWalletAPI {
-		return ErrWalletAPIDisabled
+		serv.RUnlock()
+		return undo, nil
 	}
 
-	w, err := serv.getWallet(wltID)
+	var wltIDs []string
+	for id, w := range serv.wallets {
+		if w.SpendingPolicy().MaxCoinsPerDay != 0 && ownsAnyAddress(w, inputAddrs) {
+			wltIDs = append(wltIDs, id)
+		}
+	}
+	serv.RUnlock()
+
+	// The wallets are recorded one at a time, in a deterministic order
+	sort.Strings(wltIDs)
+
+	var undos []func()
+	undo = func() {
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
+	}
+
+	for _, id := range wltIDs {
+		u, err := serv.recordSpend(id, txn, now)
+		if err != nil {
+			undo()
+			return nil, err
+		}
+		if u != nil {
+			undos = append(undos, u)
+		}
+	}
+
+	return undo, nil
+}
+
+// recordSpend records an injected transaction for the daily limit of the spending policy of a wallet.
+// Returns nil if nothing was recorded.
+func (serv *Service) recordSpend(wltID string, txn *coin.Transaction, now time.Time) (func(), error) {
+	w, release, err := serv.acquireWallet(wltID, true)
+	switch err {
+	case nil:
+	case ErrWalletNotExist:
+		// The wallet was unloaded
+		return nil, nil
+	default:
+		return nil, err
+	}
+	defer release()
+
+	// The policy may have been changed before the wallet was locked
+	p := w.SpendingPolicy()
+	if p.MaxCoinsPerDay == 0 {
+		return nil, nil
+	}
+
+	_, spent, err := w.transactionSpending(txn)
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	if err := w.checkDailyLimit(p, txn, spent, now); err != nil {
+		return nil, err
+	}
+
+	prev, hadPrev := w.Meta[metaPolicySpends]
+	w.RecordSpend(txn, now)
+
+	if err := w.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+	serv.setWallet(w)
+
+	return func() {
+		w, release, err := serv.acquireWallet(wltID, true)
+		if err != nil {
+			return
+		}
+		defer release()
+
+		if hadPrev {
+			w.Meta[metaPolicySpends] = prev
+		} else {
+			delete(w.Meta, metaPolicySpends)
+		}
+
+		if err := w.Save(serv.config.WalletDir); err != nil {
+			logger.WithError(err).WithField("id", wltID).Error("Failed to save the wallet after undoing a policy spend")
+			return
+		}
+		serv.setWallet(w)
+	}, nil
+}
+
+// ownsAnyAddress returns whether a wallet owns any of the addresses
+func ownsAnyAddress(w *Wallet, addrs []cipher.Address) bool {
+	for _, a := range addrs {
+		if w.HasEntry(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// View opens a wallet for reading non-secret data
+func (serv *Service) View(wltID string, f func(*Wallet) error) error {
+	w, release, err := serv.acquireWallet(wltID, false)
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	return f(w)
 }
 
-// RecoverWallet recovers an encrypted wallet from seed.
+// RecoverWallet recovers an encrypted wallet from seed, and the bip39 seed passphrase of a bip44 wallet if it has one.
 // The recovered wallet will be encrypted with the new password, if provided.
-func (serv *Service) RecoverWallet(wltName, seed string, password []byte) (*Wallet, error) {
-	serv.Lock()
-	defer serv.Unlock()
-	if !serv.config.EnableWalletAPI {
-		return nil, ErrWalletAPIDisabled
-	}
-
-	w, err := serv.getWallet(wltName)
+// A wallet with a seed passphrase must be recovered with a new password.
+func (serv *Service) RecoverWallet(wltName, seed, passphrase string, password []byte) (*Wallet, error) {
+	w, release, err := serv.acquireWallet(wltName, true)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
 	if !w.IsEncrypted() {
 		return nil, ErrWalletNotEncrypted
 	}
 
-	if w.Type() != WalletTypeDeterministic {
+	if passphrase != "" {
+		if w.Type() != WalletTypeBip44 {
+			return nil, ErrSeedPassphraseNotAllowed
+		}
+
+		if len(password) == 0 {
+			return nil, ErrSeedPassphraseNotEncrypted
+		}
+	}
+
+	var w2 *Wallet
+	switch w.Type() {
+	case WalletTypeDeterministic:
+		w2, err = recoverDeterministicWallet(w, seed, password)
+	case WalletTypeBip44:
+		w2, err = recoverBip44Wallet(w, seed, passphrase, password)
+	default:
 		return nil, ErrWalletNotDeterministic
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Preserve the timestamp of the old wallet
+	w2.setTimestamp(w.timestamp())
+
+	// Save to disk
+	if err := w2.Save(serv.config.WalletDir); err != nil {
+		return nil, err
+	}
+
+	serv.setWallet(w2)
 
+	return w2.clone(), nil
+}
+
+// recoverDeterministicWallet recreates a deterministic wallet from seed,
+// with the same number of addresses as w
+func recoverDeterministicWallet(w *Wallet, seed string, password []byte) (*Wallet, error) {
 	// Generate the first address from the seed
-	var pk cipher.PubKey
-	pk, _, err = cipher.GenerateDeterministicKeyPair([]byte(seed))
+	pk, _, err := cipher.GenerateDeterministicKeyPair([]byte(seed))
 	if err != nil {
 		return nil, err
 	}
@@ -532,28 +1113,247 @@ func (serv *Service) RecoverWallet(wltName, seed string, password []byte) (*Wall
 	}
 
 	// Create a new wallet with the same number of addresses, encrypting if needed
-	w2, err := NewWallet(wltName, Options{
-		Coin:       w.coin(),
-		Label:      w.Label(),
-		Seed:       seed,
-		Encrypt:    len(password) != 0,
-		Password:   password,
-		CryptoType: w.cryptoType(),
-		GenerateN:  uint64(len(w.Entries)),
+	argon2Time, argon2Memory := w.Argon2Costs()
+	return NewWallet(w.Filename(), Options{
+		Coin:         w.coin(),
+		Label:        w.Label(),
+		Seed:         seed,
+		Encrypt:      len(password) != 0,
+		Password:     password,
+		CryptoType:   w.cryptoType(),
+		Argon2Time:   argon2Time,
+		Argon2Memory: argon2Memory,
+		GenerateN:    uint64(len(w.Entries)),
+	})
+}
+
+// recoverBip44Wallet recreates a bip44 wallet from seed and seed passphrase,
+// with the same number of external and change addresses as w
+func recoverBip44Wallet(w *Wallet, seed, passphrase string, password []byte) (*Wallet, error) {
+	coinType := w.Bip44Coin()
+	argon2Time, argon2Memory := w.Argon2Costs()
+	w2, err := NewWallet(w.Filename(), Options{
+		Coin:           w.coin(),
+		Type:           WalletTypeBip44,
+		Label:          w.Label(),
+		Seed:           seed,
+		SeedPassphrase: passphrase,
+		Encrypt:        len(password) != 0,
+		Password:       password,
+		CryptoType:     w.cryptoType(),
+		Argon2Time:     argon2Time,
+		Argon2Memory:   argon2Memory,
+		GenerateN:      uint64(len(w.ExternalEntries())),
+		Bip44Coin:      &coinType,
+		Bip44Account:   w.Bip44Account(),
 	})
 	if err != nil {
+		// An invalid mnemonic can't be the seed of the wallet
+		if _, ok := err.(Error); ok {
+			return nil, ErrWalletRecoverSeedWrong
+		}
 		return nil, err
 	}
 
-	// Preserve the timestamp of the old wallet
-	w2.setTimestamp(w.timestamp())
+	// Compare to the wallet's first address
+	if w2.Entries[0].Address != w.Entries[0].Address {
+		w2.Erase()
+		return nil, ErrWalletRecoverSeedWrong
+	}
 
-	// Save to disk
-	if err := w2.Save(serv.config.WalletDir); err != nil {
+	// Change addresses are derived from the xpub, the wallet may already be encrypted
+	if _, err := w2.GenerateChangeAddresses(uint64(len(w.ChangeEntries()))); err != nil {
 		return nil, err
 	}
 
-	serv.wallets.set(w2)
+	return w2, nil
+}
 
-	return w2.clone(), nil
+// Backup creates a backup archive of the wallet wltID, or of all loaded wallets if wltID is empty.
+// The archive is encrypted with password and the service's crypto type.
+func (serv *Service) Backup(wltID string, password []byte) (*BackupArchive, error) {
+	wlts, err := serv.backupWallets(wltID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newCryptoWithCosts(serv.config.CryptoType, serv.config.Argon2Time, serv.config.Argon2Memory)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBackupArchive(wlts, password, serv.config.CryptoType, c)
+}
+
+// backupWallets returns the wallet wltID, or all loaded wallets sorted by filename if wltID is empty.
+// The wallets of the service are never modified, the service lock is only held while they are collected.
+func (serv *Service) backupWallets(wltID string) ([]*Wallet, error) {
+	serv.RLock()
+	defer serv.RUnlock()
+	if !serv.config.EnableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	if wltID != "" {
+		w, err := serv.getWallet(wltID)
+		if err != nil {
+			return nil, err
+		}
+		return []*Wallet{w}, nil
+	}
+
+	wlts := make([]*Wallet, 0, len(serv.wallets))
+	for _, w := range serv.wallets {
+		wlts = append(wlts, w)
+	}
+	sort.Slice(wlts, func(i, j int) bool {
+		return wlts[i].Filename() < wlts[j].Filename()
+	})
+
+	return wlts, nil
+}
+
+// Restore restores the wallets of a backup archive into the wallet directory and loads them.
+// All wallets of the archive are validated before any of them is written.
+// A wallet that is already loaded is replaced only if overwrite is true,
+// otherwise ErrBackupWalletExists is returned and nothing is restored.
+// A replaced wallet keeps its spending policy and its daily spending, so that restoring
+// a backup can't remove the policy or reset the daily limit without the wallet's password.
+func (serv *Service) Restore(a *BackupArchive, password []byte, overwrite bool) ([]*Wallet, error) {
+	serv.RLock()
+	enableWalletAPI := serv.config.EnableWalletAPI
+	serv.RUnlock()
+
+	if !enableWalletAPI {
+		return nil, ErrWalletAPIDisabled
+	}
+
+	wlts, err := a.Wallets(password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for the operations on the loaded wallets of the archive before replacing them.
+	// The locks are acquired in filename order, so that concurrent restores can't deadlock.
+	ids := make([]string, len(wlts))
+	for i, w := range wlts {
+		ids[i] = w.Filename()
+	}
+	sort.Strings(ids)
+
+	locks := make(map[string]*sync.RWMutex, len(ids))
+	for _, id := range ids {
+		_, release, err := serv.acquireWallet(id, true)
+		switch err {
+		case nil:
+			defer release()
+			serv.RLock()
+			locks[id] = serv.walletLocks[id]
+			serv.RUnlock()
+		case ErrWalletNotExist:
+		default:
+			return nil, err
+		}
+	}
+
+	serv.Lock()
+	defer serv.Unlock()
+
+	for _, w := range wlts {
+		if serv.wallets.get(w.Filename()) != nil {
+			// A wallet loaded after its lock was acquired is not replaced
+			if !overwrite || serv.walletLocks[w.Filename()] != locks[w.Filename()] {
+				return nil, ErrBackupWalletExists
+			}
+		}
+
+		// A seed can't be restored into another wallet than the one already using it
+		if hasSeedType(w.Type()) {
+			if id, ok := serv.firstAddrIDMap[w.Entries[0].Address.String()]; ok && id != w.Filename() {
+				return nil, ErrSeedUsed
+			}
+		}
+	}
+
+	restored := make([]*Wallet, len(wlts))
+	for i, w := range wlts {
+		// The spending policy can only be changed with the password of the wallet
+		if old := serv.wallets.get(w.Filename()); old != nil {
+			w.keepSpendingPolicy(old)
+		}
+
+		if err := w.Save(serv.config.WalletDir); err != nil {
+			return nil, err
+		}
+
+		if old := serv.wallets.get(w.Filename()); old != nil && len(old.Entries) > 0 && hasSeedType(old.Type()) {
+			delete(serv.firstAddrIDMap, old.Entries[0].Address.String())
+		}
+
+		serv.wallets.set(w)
+		if _, ok := serv.walletLocks[w.Filename()]; !ok {
+			serv.walletLocks[w.Filename()] = &sync.RWMutex{}
+		}
+		if hasSeedType(w.Type()) {
+			serv.firstAddrIDMap[w.Entries[0].Address.String()] = w.Filename()
+		}
+
+		restored[i] = w.clone()
+	}
+
+	return restored, nil
+}
+
+// RunBackups writes a backup archive of all wallets into the backup directory
+// every BackupInterval, until quit is closed. Only the latest BackupRetention
+// archives are kept. It returns immediately if timed backups are disabled.
+func (serv *Service) RunBackups(quit <-chan struct{}) {
+	if !serv.config.EnableWalletAPI || serv.config.BackupInterval <= 0 {
+		return
+	}
+
+	logger.Infof("Backing up wallets to %s every %v", serv.config.BackupDir, serv.config.BackupInterval)
+
+	ticker := time.NewTicker(serv.config.BackupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			fn, err := serv.backupToDir()
+			if err != nil {
+				logger.WithError(err).Error("Timed wallet backup failed")
+				continue
+			}
+			if fn != "" {
+				logger.Infof("Backed up wallets to %s", fn)
+			}
+		}
+	}
+}
+
+// backupToDir writes a backup archive of all wallets into the backup directory and
+// removes the archives beyond the retention. If there are no wallets, nothing is written.
+func (serv *Service) backupToDir() (string, error) {
+	a, err := serv.Backup("", serv.config.BackupPassword)
+	switch err {
+	case nil:
+	case ErrBackupEmpty:
+		return "", nil
+	default:
+		return "", err
+	}
+
+	fn := filepath.Join(serv.config.BackupDir, BackupFilename(time.Unix(a.Timestamp, 0)))
+	if err := a.Save(fn); err != nil {
+		return "", err
+	}
+
+	if err := removeOldBackups(serv.config.BackupDir, serv.config.BackupRetention); err != nil {
+		return "", err
+	}
+
+	return fn, nil
 }
@@ -0,0 +1,88 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// benchmarkService creates a service with n wallets, encrypted with an insecure crypto type if encrypt is true
+func benchmarkService(b *testing.B, n int, encrypt bool) (*Service, []string, func()) {
+	dir := prepareWltDir()
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeScryptChacha20poly1305Insecure,
+		EnableWalletAPI: true,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%d.wlt", i)
+		opts := Options{
+			Seed: fmt.Sprintf("seed %d", i),
+		}
+		if encrypt {
+			opts.Encrypt = true
+			opts.Password = []byte("pwd")
+		}
+		if _, err := s.CreateWallet(ids[i], opts, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return s, ids, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+// runParallel runs f in parallel goroutines, each goroutine operates on another wallet of ids
+func runParallel(b *testing.B, ids []string, f func(id string) error) {
+	var next uint32
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		id := ids[int(atomic.AddUint32(&next, 1)-1)%len(ids)]
+		for pb.Next() {
+			if err := f(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkServiceNewAddresses(b *testing.B) {
+	s, ids, cleanup := benchmarkService(b, 16, false)
+	defer cleanup()
+
+	runParallel(b, ids, func(id string) error {
+		_, err := s.NewAddresses(id, nil, 1)
+		return err
+	})
+}
+
+func BenchmarkServiceView(b *testing.B) {
+	s, ids, cleanup := benchmarkService(b, 16, false)
+	defer cleanup()
+
+	runParallel(b, ids, func(id string) error {
+		return s.View(id, func(w *Wallet) error {
+			_, err := w.GetMDLAddresses()
+			return err
+		})
+	})
+}
+
+func BenchmarkServiceUpdateSecrets(b *testing.B) {
+	s, ids, cleanup := benchmarkService(b, 16, true)
+	defer cleanup()
+
+	runParallel(b, ids, func(id string) error {
+		return s.UpdateSecrets(id, []byte("pwd"), func(w *Wallet) error {
+			_, err := w.GenerateMDLAddresses(1)
+			return err
+		})
+	})
+}
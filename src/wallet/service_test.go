@@ -2,13 +2,16 @@ package wallet
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -686,6 +689,559 @@ func TestServiceUpdateWalletLabel(t *testing.T) {
 	}
 }
 
+func TestServiceArchiveWallet(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	_, err = s.ArchiveWallet("t.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed: "seed",
+	}, nil)
+	require.NoError(t, err)
+
+	fn, err := s.ArchiveWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, WalletArchiveDir, "t.wlt"), fn)
+
+	// The wallet is unloaded and moved into the archive directory
+	_, err = s.GetWallet("t.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+	_, err = os.Stat(filepath.Join(dir, "t.wlt"))
+	require.True(t, os.IsNotExist(err))
+	aw, err := Load(fn)
+	require.NoError(t, err)
+	require.Equal(t, w.Entries, aw.Entries)
+
+	// The archive directory is not loaded by the service
+	s, err = NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+	wlts, err := s.GetWallets()
+	require.NoError(t, err)
+	require.Empty(t, wlts)
+
+	// The seed can be used again, archiving the new wallet doesn't replace the archived one
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed: "seed",
+	}, nil)
+	require.NoError(t, err)
+	fn2, err := s.ArchiveWallet("t.wlt")
+	require.NoError(t, err)
+	require.NotEqual(t, fn, fn2)
+	require.Equal(t, filepath.Join(dir, WalletArchiveDir), filepath.Dir(fn2))
+	fs, err := filterDir(filepath.Join(dir, WalletArchiveDir), WalletExt)
+	require.NoError(t, err)
+	require.Len(t, fs, 2)
+
+	s.config.EnableWalletAPI = false
+	_, err = s.ArchiveWallet("t.wlt")
+	require.Equal(t, ErrWalletAPIDisabled, err)
+}
+
+func TestServiceDeleteWallet(t *testing.T) {
+	tt := []struct {
+		name             string
+		opts             Options
+		id               string
+		pwd              []byte
+		disableWalletAPI bool
+		err              error
+	}{
+		{
+			name: "ok",
+			opts: Options{
+				Seed: "seed",
+			},
+			id: "t.wlt",
+		},
+		{
+			name: "ok encrypted",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			id:  "t.wlt",
+			pwd: []byte("pwd"),
+		},
+		{
+			name: "encrypted wallet missing password",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			id:  "t.wlt",
+			err: ErrMissingPassword,
+		},
+		{
+			name: "encrypted wallet invalid password",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			id:  "t.wlt",
+			pwd: []byte("wrong"),
+			err: ErrInvalidPassword,
+		},
+		{
+			name: "password for unencrypted wallet",
+			opts: Options{
+				Seed: "seed",
+			},
+			id:  "t.wlt",
+			pwd: []byte("pwd"),
+			err: ErrWalletNotEncrypted,
+		},
+		{
+			name: "wallet doesn't exist",
+			opts: Options{
+				Seed: "seed",
+			},
+			id:  "t1.wlt",
+			err: ErrWalletNotExist,
+		},
+		{
+			name: "wallet api disabled",
+			opts: Options{
+				Seed: "seed",
+			},
+			id:               "t.wlt",
+			disableWalletAPI: true,
+			err:              ErrWalletAPIDisabled,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				CryptoType:      CryptoTypeSha256Xor,
+				EnableWalletAPI: true,
+			})
+			require.NoError(t, err)
+
+			_, err = s.CreateWallet("t.wlt", tc.opts, nil)
+			require.NoError(t, err)
+
+			s.config.EnableWalletAPI = !tc.disableWalletAPI
+
+			err = s.DeleteWallet(tc.id, tc.pwd)
+			require.Equal(t, tc.err, err)
+
+			s.config.EnableWalletAPI = true
+
+			if err != nil {
+				// The wallet is kept
+				_, err = s.GetWallet("t.wlt")
+				require.NoError(t, err)
+				_, err = os.Stat(filepath.Join(dir, "t.wlt"))
+				require.NoError(t, err)
+				return
+			}
+
+			_, err = s.GetWallet("t.wlt")
+			require.Equal(t, ErrWalletNotExist, err)
+			dirIsEmpty(t, dir)
+
+			// The seed can be used again
+			_, err = s.CreateWallet("t.wlt", tc.opts, nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceRenameWallet(t *testing.T) {
+	tt := []struct {
+		name             string
+		id               string
+		newID            string
+		disableWalletAPI bool
+		err              error
+	}{
+		{
+			name:  "ok",
+			id:    "a.wlt",
+			newID: "c.wlt",
+		},
+		{
+			name:  "same filename",
+			id:    "a.wlt",
+			newID: "a.wlt",
+		},
+		{
+			name:  "loaded wallet exists",
+			id:    "a.wlt",
+			newID: "b.wlt",
+			err:   ErrWalletFileExists,
+		},
+		{
+			name:  "wallet file exists",
+			id:    "a.wlt",
+			newID: "other.wlt",
+			err:   ErrWalletFileExists,
+		},
+		{
+			name:  "new filename is a path",
+			id:    "a.wlt",
+			newID: "../c.wlt",
+			err:   ErrInvalidWalletFilename,
+		},
+		{
+			name:  "new filename without extension",
+			id:    "a.wlt",
+			newID: "c",
+			err:   ErrInvalidWalletFilename,
+		},
+		{
+			name:  "wallet doesn't exist",
+			id:    "x.wlt",
+			newID: "c.wlt",
+			err:   ErrWalletNotExist,
+		},
+		{
+			name:             "wallet api disabled",
+			id:               "a.wlt",
+			newID:            "c.wlt",
+			disableWalletAPI: true,
+			err:              ErrWalletAPIDisabled,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				CryptoType:      CryptoTypeSha256Xor,
+				EnableWalletAPI: true,
+			})
+			require.NoError(t, err)
+
+			w, err := s.CreateWallet("a.wlt", Options{
+				Seed:     "seed a",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			}, nil)
+			require.NoError(t, err)
+			_, err = s.CreateWallet("b.wlt", Options{
+				Seed: "seed b",
+			}, nil)
+			require.NoError(t, err)
+
+			// A file that is not loaded by the service
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.wlt"), []byte("{}"), 0600))
+
+			s.config.EnableWalletAPI = !tc.disableWalletAPI
+
+			nw, err := s.RenameWallet(tc.id, tc.newID)
+			require.Equal(t, tc.err, err)
+
+			s.config.EnableWalletAPI = true
+
+			if err != nil {
+				_, err = s.GetWallet("a.wlt")
+				require.NoError(t, err)
+				_, err = os.Stat(filepath.Join(dir, "a.wlt"))
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, tc.newID, nw.Filename())
+			require.Equal(t, w.Entries, nw.Entries)
+
+			lw, err := s.GetWallet(tc.newID)
+			require.NoError(t, err)
+			require.Equal(t, nw, lw)
+
+			// The wallet is saved under its new filename and stays encrypted
+			fw, err := Load(filepath.Join(dir, tc.newID))
+			require.NoError(t, err)
+			require.True(t, fw.IsEncrypted())
+			_, err = fw.Unlock([]byte("pwd"))
+			require.NoError(t, err)
+
+			if tc.newID != tc.id {
+				_, err = s.GetWallet(tc.id)
+				require.Equal(t, ErrWalletNotExist, err)
+				_, err = os.Stat(filepath.Join(dir, tc.id))
+				require.True(t, os.IsNotExist(err))
+			}
+
+			// The seed stays registered to the renamed wallet
+			_, err = s.CreateWallet("d.wlt", Options{
+				Seed: "seed a",
+			}, nil)
+			require.Equal(t, ErrSeedUsed, err)
+			require.Equal(t, tc.newID, s.firstAddrIDMap[w.Entries[0].Address.String()])
+		})
+	}
+}
+
+func TestServiceFreezeOutputs(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	a := cipher.SumSHA256([]byte("a"))
+	b := cipher.SumSHA256([]byte("b"))
+
+	_, err = s.FreezeOutputs("t.wlt", []cipher.SHA256{a})
+	require.Equal(t, ErrWalletNotExist, err)
+
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	// Encrypted wallets are updated without the password
+	frozen, err := s.FreezeOutputs("t.wlt", []cipher.SHA256{a, b})
+	require.NoError(t, err)
+	require.Len(t, frozen, 2)
+
+	frozen, err = s.UnfreezeOutputs("t.wlt", []cipher.SHA256{b})
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{a}, frozen)
+
+	frozen, err = s.GetFrozenOutputs("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{a}, frozen)
+
+	w, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, []cipher.SHA256{a}, w.FrozenOutputs())
+
+	s.config.EnableWalletAPI = false
+	_, err = s.FreezeOutputs("t.wlt", []cipher.SHA256{b})
+	require.Equal(t, ErrWalletAPIDisabled, err)
+	_, err = s.UnfreezeOutputs("t.wlt", []cipher.SHA256{a})
+	require.Equal(t, ErrWalletAPIDisabled, err)
+	_, err = s.GetFrozenOutputs("t.wlt")
+	require.Equal(t, ErrWalletAPIDisabled, err)
+}
+
+func TestServiceUpdateEntry(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	label := "cold storage"
+	u := EntryUpdate{
+		Label: &label,
+		Meta:  map[string]string{"owner": "treasury"},
+	}
+
+	_, err = s.UpdateEntry("t.wlt", testutil.MakeAddress(), u)
+	require.Equal(t, ErrWalletNotExist, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+	addr := w.Entries[0].MDLAddress()
+
+	_, err = s.UpdateEntry("t.wlt", testutil.MakeAddress(), u)
+	require.Equal(t, ErrUnknownAddress, err)
+
+	// Encrypted wallets are updated without the password
+	w, err = s.UpdateEntry("t.wlt", addr, u)
+	require.NoError(t, err)
+	require.Equal(t, label, w.Entries[0].Label)
+	require.Equal(t, u.Meta, w.Entries[0].Meta)
+
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	entries := w.EntriesByLabel(label)
+	require.Len(t, entries, 1)
+	require.Equal(t, addr, entries[0].MDLAddress())
+
+	// The labels survive decrypting the wallet
+	_, err = s.DecryptWallet("t.wlt", []byte("pwd"))
+	require.NoError(t, err)
+
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, label, lw.Entries[0].Label)
+	require.Equal(t, u.Meta, lw.Entries[0].Meta)
+
+	s.config.EnableWalletAPI = false
+	_, err = s.UpdateEntry("t.wlt", addr, u)
+	require.Equal(t, ErrWalletAPIDisabled, err)
+}
+
+func TestServiceSetSpendingPolicy(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	p := SpendingPolicy{
+		MaxCoinsPerDay: 10e6,
+		MaxOutputs:     2,
+	}
+
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), p)
+	require.Equal(t, ErrWalletNotExist, err)
+
+	_, err = s.CreateWallet("u.wlt", Options{
+		Seed: "seed2",
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.SetSpendingPolicy("u.wlt", nil, p)
+	require.Equal(t, ErrSpendingPolicyNotEncrypted, err)
+
+	_, err = s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.SetSpendingPolicy("t.wlt", nil, p)
+	require.Equal(t, ErrMissingPassword, err)
+
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("wrong"), p)
+	require.Equal(t, ErrInvalidPassword, err)
+
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), SpendingPolicy{
+		MaxCoinsPerTransaction: 20e6,
+		MaxCoinsPerDay:         10e6,
+	})
+	testutil.RequireError(t, err, "max coins per transaction exceeds max coins per day")
+
+	w, err := s.SetSpendingPolicy("t.wlt", []byte("pwd"), p)
+	require.NoError(t, err)
+	require.Equal(t, p, w.SpendingPolicy())
+
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, p, lw.SpendingPolicy())
+
+	s.config.EnableWalletAPI = false
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), p)
+	require.Equal(t, ErrWalletAPIDisabled, err)
+}
+
+func TestServiceRecordSpend(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	w, err := s.CreateWallet("t.wlt", Options{
+		Seed:     "seed",
+		Encrypt:  true,
+		Password: []byte("pwd"),
+	}, nil)
+	require.NoError(t, err)
+	addr := w.Entries[0].MDLAddress()
+
+	_, err = s.CreateWallet("u.wlt", Options{
+		Seed: "seed2",
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = s.SetSpendingPolicy("t.wlt", []byte("pwd"), SpendingPolicy{
+		MaxCoinsPerDay: 10e6,
+	})
+	require.NoError(t, err)
+
+	txn, _, _ := makeTransaction(t, 1)
+	now := time.Now()
+
+	// Transactions which don't spend the outputs of the wallet are not recorded
+	undo, err := s.RecordSpend(&txn, []cipher.Address{testutil.MakeAddress()}, now)
+	require.NoError(t, err)
+	undo()
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), w.SpentToday(now))
+
+	// Injected transactions count towards the daily limit
+	undo, err = s.RecordSpend(&txn, []cipher.Address{addr}, now)
+	require.NoError(t, err)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6e6), w.SpentToday(now))
+
+	// The daily spending is persisted
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(6e6), lw.SpentToday(now))
+
+	// Injecting the transaction again doesn't count it twice
+	_, err = s.RecordSpend(&txn, []cipher.Address{addr}, now)
+	require.NoError(t, err)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6e6), w.SpentToday(now))
+
+	// Another transaction exceeding the daily limit is refused and not recorded
+	other, _, _ := makeTransaction(t, 1)
+	_, err = s.RecordSpend(&other, []cipher.Address{addr}, now)
+	require.Error(t, err)
+	require.Equal(t, RuleMaxCoinsPerDay, err.(PolicyViolationError).Rule)
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6e6), w.SpentToday(now))
+
+	// The recording is undone when the injection is rolled back
+	undo()
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), w.SpentToday(now))
+	lw, err = Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lw.SpentToday(now))
+
+	// Nothing is recorded if the wallet API is disabled
+	s.config.EnableWalletAPI = false
+	_, err = s.RecordSpend(&txn, []cipher.Address{addr}, now)
+	require.NoError(t, err)
+	s.config.EnableWalletAPI = true
+	w, err = s.GetWallet("t.wlt")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), w.SpentToday(now))
+}
+
 func TestServiceEncryptWallet(t *testing.T) {
 	tt := []struct {
 		name             string
@@ -755,7 +1311,7 @@ func TestServiceEncryptWallet(t *testing.T) {
 				require.NoError(t, err)
 
 				if tc.disableWalletAPI {
-					_, err = s.EncryptWallet("", tc.pwd)
+					_, err = s.EncryptWallet("", tc.pwd, "")
 					require.Equal(t, tc.err, err)
 					return
 				}
@@ -765,7 +1321,7 @@ func TestServiceEncryptWallet(t *testing.T) {
 				require.NoError(t, err)
 
 				// Encrypt the wallet
-				encWlt, err := s.EncryptWallet(tc.encWltName, tc.pwd)
+				encWlt, err := s.EncryptWallet(tc.encWltName, tc.pwd, "")
 				require.Equal(t, tc.err, err)
 				if err != nil {
 					return
@@ -936,6 +1492,232 @@ func TestServiceDecryptWallet(t *testing.T) {
 	}
 }
 
+func TestServiceChangePassword(t *testing.T) {
+	tt := []struct {
+		name             string
+		wltName          string
+		opts             Options
+		changeWltName    string
+		password         []byte
+		newPassword      []byte
+		cryptoType       CryptoType
+		migrate          bool
+		expectCryptoType CryptoType
+		disableWalletAPI bool
+		err              error
+	}{
+		{
+			name:    "ok, change password",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:       "seed",
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+				CryptoType: CryptoTypeSha256Xor,
+			},
+			changeWltName:    "test.wlt",
+			password:         []byte("pwd"),
+			newPassword:      []byte("new pwd"),
+			expectCryptoType: CryptoTypeSha256Xor,
+		},
+		{
+			name:    "ok, change password and crypto type",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:       "seed",
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+				CryptoType: CryptoTypeSha256Xor,
+			},
+			changeWltName:    "test.wlt",
+			password:         []byte("pwd"),
+			newPassword:      []byte("new pwd"),
+			cryptoType:       CryptoTypeScryptChacha20poly1305Insecure,
+			expectCryptoType: CryptoTypeScryptChacha20poly1305Insecure,
+		},
+		{
+			name:    "ok, migrate crypto type",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:       "seed",
+				Encrypt:    true,
+				Password:   []byte("pwd"),
+				CryptoType: CryptoTypeSha256Xor,
+			},
+			changeWltName:    "test.wlt",
+			password:         []byte("pwd"),
+			cryptoType:       CryptoTypeScryptChacha20poly1305Insecure,
+			migrate:          true,
+			expectCryptoType: CryptoTypeScryptChacha20poly1305Insecure,
+		},
+		{
+			name:    "migrate without crypto type",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "test.wlt",
+			password:      []byte("pwd"),
+			migrate:       true,
+			err:           ErrMissingCryptoType,
+		},
+		{
+			name:    "invalid crypto type",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "test.wlt",
+			password:      []byte("pwd"),
+			cryptoType:    CryptoType("foo"),
+			migrate:       true,
+			err:           NewError(errors.New("can not find crypto foo in crypto table")),
+		},
+		{
+			name:    "wallet not exist",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "t.wlt",
+			password:      []byte("pwd"),
+			newPassword:   []byte("new pwd"),
+			err:           ErrWalletNotExist,
+		},
+		{
+			name:    "wallet not encrypted",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed: "seed",
+			},
+			changeWltName: "test.wlt",
+			password:      []byte("pwd"),
+			newPassword:   []byte("new pwd"),
+			err:           ErrWalletNotEncrypted,
+		},
+		{
+			name:    "invalid password",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "test.wlt",
+			password:      []byte("wrong password"),
+			newPassword:   []byte("new pwd"),
+			err:           ErrInvalidPassword,
+		},
+		{
+			name:    "missing password",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "test.wlt",
+			newPassword:   []byte("new pwd"),
+			err:           ErrMissingPassword,
+		},
+		{
+			name:    "missing new password",
+			wltName: "test.wlt",
+			opts: Options{
+				Seed:     "seed",
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			changeWltName: "test.wlt",
+			password:      []byte("pwd"),
+			err:           ErrMissingNewPassword,
+		},
+		{
+			name:             "wallet api disabled",
+			changeWltName:    "test.wlt",
+			password:         []byte("pwd"),
+			newPassword:      []byte("new pwd"),
+			disableWalletAPI: true,
+			err:              ErrWalletAPIDisabled,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			s, err := NewService(Config{
+				WalletDir:       dir,
+				CryptoType:      CryptoTypeSha256Xor,
+				EnableWalletAPI: !tc.disableWalletAPI,
+			})
+			require.NoError(t, err)
+
+			if !tc.disableWalletAPI {
+				_, err = s.CreateWallet(tc.wltName, tc.opts, nil)
+				require.NoError(t, err)
+			}
+
+			var w *Wallet
+			if tc.migrate {
+				w, err = s.MigrateCryptoType(tc.changeWltName, tc.password, tc.cryptoType)
+			} else {
+				w, err = s.ChangePassword(tc.changeWltName, tc.password, tc.newPassword, tc.cryptoType)
+			}
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			newPassword := tc.newPassword
+			if tc.migrate {
+				newPassword = tc.password
+			}
+
+			verifyWlt := func(wlt *Wallet) {
+				require.True(t, wlt.IsEncrypted())
+				require.Equal(t, tc.expectCryptoType, wlt.cryptoType())
+				require.Empty(t, wlt.seed())
+				for _, e := range wlt.Entries {
+					require.True(t, e.Secret.Null())
+				}
+
+				if !tc.migrate {
+					_, err := wlt.Unlock(tc.password)
+					require.Equal(t, ErrInvalidPassword, err)
+				}
+
+				uw, err := wlt.Unlock(newPassword)
+				require.NoError(t, err)
+				require.Equal(t, tc.opts.Seed, uw.seed())
+				for _, e := range uw.Entries {
+					require.Equal(t, e.Address, cipher.MustAddressFromSecKey(e.Secret))
+				}
+			}
+
+			verifyWlt(w)
+
+			// Checks the wallet in service
+			w1, err := s.getWallet(tc.changeWltName)
+			require.NoError(t, err)
+			verifyWlt(w1)
+
+			// Loads wallet from the file and checks it's re-encrypted
+			w2, err := Load(filepath.Join(dir, tc.changeWltName))
+			require.NoError(t, err)
+			verifyWlt(w2)
+		})
+	}
+}
+
 func TestServiceCreateWalletWithScan(t *testing.T) {
 	seed := "seed1"
 	addrs := make([]cipher.Address, 20)
@@ -2070,3 +2852,95 @@ func checkNoSensitiveData(t *testing.T, w *Wallet) {
 		require.Equal(t, empty, e.Secret)
 	}
 }
+
+func TestServiceWalletLocks(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+
+	for _, id := range []string{"a.wlt", "b.wlt"} {
+		_, err := s.CreateWallet(id, Options{
+			Seed: "seed " + id,
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	done := func(f func()) <-chan struct{} {
+		c := make(chan struct{})
+		go func() {
+			defer close(c)
+			f()
+		}()
+		return c
+	}
+
+	// Hold the lock of a.wlt, as a long operation on it would
+	_, release, err := s.acquireWallet("a.wlt", true)
+	require.NoError(t, err)
+
+	// Operations on other wallets are not blocked
+	select {
+	case <-done(func() {
+		_, err := s.NewAddresses("b.wlt", nil, 1)
+		require.NoError(t, err)
+		require.NoError(t, s.View("b.wlt", func(*Wallet) error { return nil }))
+		_, err = s.GetWallet("a.wlt")
+		require.NoError(t, err)
+		_, err = s.CreateWallet("c.wlt", Options{
+			Seed: "seed c.wlt",
+		}, nil)
+		require.NoError(t, err)
+	}):
+	case <-time.After(5 * time.Second):
+		t.Fatal("operations on other wallets are blocked")
+	}
+
+	// Operations on the locked wallet wait for the lock
+	viewed := done(func() {
+		require.NoError(t, s.View("a.wlt", func(*Wallet) error { return nil }))
+	})
+	unloaded := done(func() {
+		require.NoError(t, s.UnloadWallet("a.wlt"))
+	})
+	select {
+	case <-viewed:
+		t.Fatal("view of a locked wallet is not blocked")
+	case <-unloaded:
+		t.Fatal("unload of a locked wallet is not blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+	<-unloaded
+	<-viewed
+
+	_, err = s.GetWallet("a.wlt")
+	require.Equal(t, ErrWalletNotExist, err)
+	_, _, err = s.acquireWallet("a.wlt", false)
+	require.Equal(t, ErrWalletNotExist, err)
+
+	// Concurrent updates of a wallet are serialized
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.NewAddresses("b.wlt", nil, 2)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	w, err := s.GetWallet("b.wlt")
+	require.NoError(t, err)
+	require.Len(t, w.Entries, 22)
+
+	w, err = Load(filepath.Join(dir, "b.wlt"))
+	require.NoError(t, err)
+	require.Len(t, w.Entries, 22)
+}
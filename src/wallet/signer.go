@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+)
+
+// SignInputRequest is a request to sign one input of a transaction
+type SignInputRequest struct {
+	InnerHash cipher.SHA256  // inner hash of the transaction
+	Index     int            // index of the input in the transaction
+	UxID      cipher.SHA256  // hash of the unspent output spent by the input
+	Address   cipher.Address // address which owns the unspent output
+}
+
+// Hash returns the hash which is signed for the input
+func (r SignInputRequest) Hash() cipher.SHA256 {
+	return cipher.AddSHA256(r.InnerHash, r.UxID)
+}
+
+// Signer signs transaction inputs with the secret key of an address.
+// The secret keys of the wallet entries are used by LocalSigner, which is the default,
+// the secret keys of remote wallets are held by an external signer process reached by RemoteSigner.
+type Signer interface {
+	SignInput(r SignInputRequest) (cipher.Sig, error)
+}
+
+// LocalSigner signs transaction inputs with the secret keys of the entries of an unlocked wallet
+type LocalSigner struct {
+	w *Wallet
+}
+
+// NewLocalSigner creates a LocalSigner for an unencrypted or unlocked wallet
+func NewLocalSigner(w *Wallet) *LocalSigner {
+	return &LocalSigner{
+		w: w,
+	}
+}
+
+// SignInput signs a transaction input with the secret key of the wallet entry of its address
+func (s *LocalSigner) SignInput(r SignInputRequest) (cipher.Sig, error) {
+	e, ok := s.w.GetEntry(r.Address)
+	if !ok {
+		return cipher.Sig{}, ErrUnknownAddress
+	}
+
+	if e.Secret.Null() {
+		return cipher.Sig{}, NewError(fmt.Errorf("no secret key for address %s", r.Address))
+	}
+
+	return cipher.SignHash(r.Hash(), e.Secret)
+}
+
+// Signer returns the signer of the wallet, a RemoteSigner for remote wallets and a LocalSigner otherwise
+func (w *Wallet) Signer() (Signer, error) {
+	if w.Type() == WalletTypeRemote {
+		return NewRemoteSigner(w.SignerURL())
+	}
+
+	return NewLocalSigner(w), nil
+}
+
+// signInput signs the input of a transaction at index with signer, and checks the signature
+func signInput(signer Signer, txn *coin.Transaction, index int, addr cipher.Address) error {
+	if index < 0 || index >= len(txn.In) {
+		return errors.New("Signature index out of range")
+	}
+	if len(txn.In) != len(txn.Sigs) {
+		return errors.New("Number of signatures does not match number of inputs")
+	}
+	if !txn.Sigs[index].Null() {
+		return errors.New("Input already signed")
+	}
+
+	r := SignInputRequest{
+		InnerHash: txn.InnerHash,
+		Index:     index,
+		UxID:      txn.In[index],
+		Address:   addr,
+	}
+
+	sig, err := signer.SignInput(r)
+	if err != nil {
+		return err
+	}
+
+	// The signature of a remote signer is not trusted
+	if err := cipher.VerifyAddressSignedHash(addr, sig, r.Hash()); err != nil {
+		return NewError(fmt.Errorf("invalid signature for input %d: %v", index, err))
+	}
+
+	txn.Sigs[index] = sig
+	return nil
+}
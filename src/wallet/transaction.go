@@ -3,6 +3,7 @@ package wallet
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/coin"
@@ -66,6 +67,10 @@ func copyTransaction(txn *coin.Transaction) *coin.Transaction {
 // but a valid existing signature cannot be overwritten.
 // Clients should avoid signing the same transaction multiple times.
 func (w *Wallet) SignTransaction(txn *coin.Transaction, signIndexes []int, uxOuts []coin.UxOut) (*coin.Transaction, error) {
+	if w.IsWatchOnly() {
+		return nil, ErrWalletWatchOnly
+	}
+
 	signedTxn := copyTransaction(txn)
 	txnInnerHash := signedTxn.HashInner()
 
@@ -94,6 +99,11 @@ func (w *Wallet) SignTransaction(txn *coin.Transaction, signIndexes []int, uxOut
 		return nil, NewError(err)
 	}
 
+	// The spending policy is checked before any input is signed
+	if err := w.CheckSpendingPolicy(signedTxn, time.Now()); err != nil {
+		return nil, err
+	}
+
 	nMissingSigs := 0
 	for _, s := range signedTxn.Sigs {
 		if s.Null() {
@@ -135,13 +145,18 @@ func (w *Wallet) SignTransaction(txn *coin.Transaction, signIndexes []int, uxOut
 		return nil, NewError(errors.New("Wallet cannot sign all requested inputs"))
 	}
 
+	signer, err := w.Signer()
+	if err != nil {
+		return nil, err
+	}
+
 	// Sign the selected inputs
 	for k, v := range toSign {
 		for _, x := range v {
 			if !signedTxn.Sigs[x].Null() {
 				return nil, NewError(fmt.Errorf("Transaction is already signed at index %d", x))
 			}
-			if err := signedTxn.SignInput(w.Entries[k].Secret, x); err != nil {
+			if err := signInput(signer, signedTxn, x, w.Entries[k].MDLAddress()); err != nil {
 				return nil, err
 			}
 		}
@@ -203,28 +218,36 @@ func (w *Wallet) CreateTransaction(p transaction.Params, auxs coin.AddressUxOuts
 // Set the password as nil if the wallet is not encrypted, otherwise the password must be provided.
 // Refer to CreateTransaction for information about transaction creation.
 func (w *Wallet) CreateTransactionSigned(p transaction.Params, auxs coin.AddressUxOuts, headTime uint64) (*coin.Transaction, []transaction.UxBalance, error) {
+	if w.IsWatchOnly() {
+		return nil, nil, ErrWalletWatchOnly
+	}
+
 	txn, uxb, err := w.CreateTransaction(p, auxs, headTime)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// The spending policy is checked before the transaction is signed
+	if err := w.CheckSpendingPolicy(txn, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := w.Signer()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Sign the transaction
-	entriesMap := make(map[cipher.Address]Entry)
 	for i, s := range uxb {
-		entry, ok := entriesMap[s.Address]
-		if !ok {
-			entry, ok = w.GetEntry(s.Address)
-			if !ok {
-				// This should not occur because CreateTransaction should have checked it already
-				err := fmt.Errorf("Chosen spend address %s not found in wallet", s.Address)
-				logger.Critical().WithError(err).Error()
-				return nil, nil, err
-			}
-			entriesMap[s.Address] = entry
+		if !w.HasEntry(s.Address) {
+			// This should not occur because CreateTransaction should have checked it already
+			err := fmt.Errorf("Chosen spend address %s not found in wallet", s.Address)
+			logger.Critical().WithError(err).Error()
+			return nil, nil, err
 		}
 
-		if err := txn.SignInput(entry.Secret, i); err != nil {
-			logger.Critical().WithError(err).Error("CreateTransaction SignInput failed")
+		if err := signInput(signer, txn, i, s.Address); err != nil {
+			logger.WithError(err).Error("CreateTransaction signInput failed")
 			return nil, nil, err
 		}
 	}
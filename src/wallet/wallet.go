@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +17,8 @@ import (
 	"encoding/hex"
 
 	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip32"
+	"github.com/MDLlife/MDL/src/cipher/bip44"
 
 	"github.com/MDLlife/MDL/src/util/logging"
 )
@@ -36,7 +39,7 @@ func NewError(err error) error {
 
 var (
 	// Version represents the current wallet version
-	Version = "0.2"
+	Version = "0.3"
 
 	logger = logging.MustGetLogger("wallet")
 
@@ -76,6 +79,41 @@ var (
 	ErrWalletNotDeterministic = NewError(errors.New("wallet type is not deterministic"))
 	// ErrInvalidCoinType is returned for invalid coin types
 	ErrInvalidCoinType = NewError(errors.New("invalid coin type"))
+	// ErrInvalidWalletType is returned for invalid wallet types
+	ErrInvalidWalletType = NewError(errors.New("invalid wallet type"))
+	// ErrWalletNotBip44 is returned if a wallet does not have a bip44 change chain but it is necessary for the requested operation
+	ErrWalletNotBip44 = NewError(errors.New("wallet type is not bip44 or xpub"))
+	// ErrBip44OptionsNotAllowed is returned if bip44 options are set when creating a non-bip44 wallet
+	ErrBip44OptionsNotAllowed = NewError(errors.New("bip44 coin and account options are only allowed for bip44 wallets"))
+	// ErrWalletWatchOnly is returned when trying to sign, encrypt or access the secrets of a watch-only wallet
+	ErrWalletWatchOnly = NewError(errors.New("wallet is watch-only and has no secret keys"))
+	// ErrWatchOnlyOptionsNotAllowed is returned if the xpub or addresses options are set when creating a wallet of another type
+	ErrWatchOnlyOptionsNotAllowed = NewError(errors.New("xpub and addresses options are only allowed for xpub and addresses wallets"))
+	// ErrSeedNotAllowed is returned when a seed is given for a wallet type that has no seed
+	ErrSeedNotAllowed = NewError(errors.New("xpub, addresses, collection and remote wallets can't have a seed"))
+	// ErrMissingXPub is returned when trying to create an xpub wallet without an xpub
+	ErrMissingXPub = NewError(errors.New("missing xpub"))
+	// ErrMissingAddresses is returned when trying to create an addresses wallet without addresses
+	ErrMissingAddresses = NewError(errors.New("missing addresses"))
+	// ErrWalletCantGenerateAddresses is returned when trying to generate addresses in an addresses, collection or remote wallet
+	ErrWalletCantGenerateAddresses = NewError(errors.New("addresses can't be generated for addresses, collection and remote wallets"))
+	// ErrWalletNotCollection is returned if a wallet's type is not collection but it is necessary for the requested operation
+	ErrWalletNotCollection = NewError(errors.New("wallet type is not collection"))
+	// ErrWalletNoSeed is returned when trying to get the seed of a wallet that has no seed
+	ErrWalletNoSeed = NewError(errors.New("wallet has no seed"))
+	// ErrMissingCryptoType is returned when trying to migrate a wallet, but the crypto type is not provided
+	ErrMissingCryptoType = NewError(errors.New("missing crypto type"))
+	// ErrMissingNewPassword is returned when trying to change the password of a wallet, but the new password is not provided
+	ErrMissingNewPassword = NewError(errors.New("missing new password"))
+	// ErrSeedPassphraseNotAllowed is returned when a bip39 seed passphrase is given for a wallet type other than bip44
+	ErrSeedPassphraseNotAllowed = NewError(errors.New("seed passphrase is only allowed for bip44 wallets"))
+	// ErrSeedPassphraseNotEncrypted is returned when a wallet with a seed passphrase would be unencrypted,
+	// the passphrase is only persisted in the encrypted secrets
+	ErrSeedPassphraseNotEncrypted = NewError(errors.New("a wallet with a seed passphrase must be encrypted"))
+	// ErrInvalidWalletFilename is returned when a wallet filename is not a file name with the wallet file extension
+	ErrInvalidWalletFilename = NewError(errors.New("invalid wallet filename, must be a file name with the .wlt extension"))
+	// ErrWalletFileExists is returned when renaming a wallet to the filename of another wallet
+	ErrWalletFileExists = NewError(errors.New("a wallet file with this filename already exists"))
 )
 
 const (
@@ -85,6 +123,9 @@ const (
 	// WalletTimestampFormat wallet timestamp layout
 	WalletTimestampFormat = "2006_01_02"
 
+	// WalletArchiveDir name of the subdirectory of the wallet directory that archived wallets are moved to
+	WalletArchiveDir = "archive"
+
 	// CoinTypeMDL mdl type
 	CoinTypeMDL CoinType = "mdl"
 
@@ -93,6 +134,21 @@ const (
 
 	// WalletTypeDeterministic deterministic wallet type
 	WalletTypeDeterministic = "deterministic"
+
+	// WalletTypeBip44 bip44 hierarchical deterministic wallet type
+	WalletTypeBip44 = "bip44"
+
+	// WalletTypeXPub watch-only wallet type, with addresses derived from the xpub of a bip44 account
+	WalletTypeXPub = "xpub"
+
+	// WalletTypeAddresses watch-only wallet type, with an imported list of addresses
+	WalletTypeAddresses = "addresses"
+
+	// WalletTypeCollection wallet type holding individually imported secret keys
+	WalletTypeCollection = "collection"
+
+	// WalletTypeRemote wallet type holding public keys, whose secret keys are held by a remote signer
+	WalletTypeRemote = "remote"
 )
 
 // ResolveCoinType normalizes a coin type string to a CoinType constant
@@ -107,6 +163,37 @@ func ResolveCoinType(s string) (CoinType, error) {
 	}
 }
 
+// ResolveWalletType normalizes a wallet type string to a wallet type constant.
+// An empty string resolves to the deterministic wallet type.
+func ResolveWalletType(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "", WalletTypeDeterministic:
+		return WalletTypeDeterministic, nil
+	case WalletTypeBip44:
+		return WalletTypeBip44, nil
+	case WalletTypeXPub:
+		return WalletTypeXPub, nil
+	case WalletTypeAddresses:
+		return WalletTypeAddresses, nil
+	case WalletTypeCollection:
+		return WalletTypeCollection, nil
+	case WalletTypeRemote:
+		return WalletTypeRemote, nil
+	default:
+		return "", ErrInvalidWalletType
+	}
+}
+
+// hasSeedType returns true if the addresses of a wallet type are generated from a seed
+func hasSeedType(walletType string) bool {
+	switch walletType {
+	case WalletTypeDeterministic, WalletTypeBip44:
+		return true
+	default:
+		return false
+	}
+}
+
 // wallet meta fields
 const (
 	metaVersion    = "version"    // wallet version
@@ -118,8 +205,23 @@ const (
 	metaEncrypted  = "encrypted"  // whether the wallet is encrypted
 	metaCryptoType = "cryptoType" // encrytion/decryption type
 	metaSeed       = "seed"       // wallet seed
+	metaPassphrase = "passphrase" // bip39 seed passphrase of a bip44 wallet, only set while the wallet is unlocked
 	metaLastSeed   = "lastSeed"   // seed for generating next address
 	metaSecrets    = "secrets"    // secrets which records the encrypted seeds and secrets of address entries
+
+	metaBip44Coin    = "bip44Coin"    // bip44 coin type of a bip44 wallet
+	metaBip44Account = "bip44Account" // bip44 account number of a bip44 wallet
+	metaXPub         = "xpub"         // extended public key of the bip44 account, for bip44 and xpub wallets
+
+	metaSigner = "signer" // url of the remote signer of a remote wallet
+
+	metaArgon2Time   = "argon2Time"   // argon2id time cost of a wallet encrypted with argon2id-chacha20poly1305
+	metaArgon2Memory = "argon2Memory" // argon2id memory cost in KiB of a wallet encrypted with argon2id-chacha20poly1305
+
+	metaFrozenOutputs = "frozenOutputs" // comma separated list of the hex encoded unspent output hashes which are not spent automatically
+
+	metaSpendingPolicy = "spendingPolicy" // JSON encoded spending policy enforced when signing transactions
+	metaPolicySpends   = "policySpends"   // comma separated "<unix time>:<droplets>:<input>.<input>" spends of the last 24 hours, for the daily limit of the spending policy
 )
 
 // CoinType represents the wallet coin type
@@ -133,16 +235,32 @@ func NewWalletFilename() string {
 	return fmt.Sprintf("%s_%s.%s", timestamp, padding, WalletExt)
 }
 
+// isWalletFilename returns true if fn is a file name, not a path, with the wallet file extension
+func isWalletFilename(fn string) bool {
+	ext := "." + WalletExt
+	return filepath.Base(fn) == fn && len(fn) > len(ext) && strings.HasSuffix(fn, ext)
+}
+
 // Options options that could be used when creating a wallet
 type Options struct {
-	Coin       CoinType   // coin type, mdl, bitcoin, etc.
-	Label      string     // wallet label.
-	Seed       string     // wallet seed.
-	Encrypt    bool       // whether the wallet need to be encrypted.
-	Password   []byte     // password that would be used for encryption, and would only be used when 'Encrypt' is true.
-	CryptoType CryptoType // wallet encryption type, scrypt-chacha20poly1305 or sha256-xor.
-	ScanN      uint64     // number of addresses that're going to be scanned for a balance. The highest address with a balance will be used.
-	GenerateN  uint64     // number of addresses to generate, regardless of balance
+	Coin           CoinType           // coin type, mdl, bitcoin, etc.
+	Type           string             // wallet type, deterministic or bip44. Defaults to deterministic.
+	Label          string             // wallet label.
+	Seed           string             // wallet seed. For bip44 wallets, it must be a valid bip39 mnemonic.
+	SeedPassphrase string             // bip39 seed passphrase, only for bip44 wallets. The wallet must be encrypted if set.
+	Encrypt        bool               // whether the wallet need to be encrypted.
+	Password       []byte             // password that would be used for encryption, and would only be used when 'Encrypt' is true.
+	CryptoType     CryptoType         // wallet encryption type, scrypt-chacha20poly1305, argon2id-chacha20poly1305 or sha256-xor.
+	Argon2Time     uint32             // argon2id time cost, only for argon2id-chacha20poly1305 encryption. Defaults to encrypt.Argon2Time.
+	Argon2Memory   uint32             // argon2id memory cost in KiB, only for argon2id-chacha20poly1305 encryption. Defaults to encrypt.Argon2Memory.
+	ScanN          uint64             // number of addresses that're going to be scanned for a balance. The highest address with a balance will be used.
+	GenerateN      uint64             // number of addresses to generate, regardless of balance
+	Bip44Coin      *bip44.CoinType    // bip44 coin type, only for bip44 wallets. Defaults to the registered coin type of Coin.
+	Bip44Account   uint32             // bip44 account number, only for bip44 wallets.
+	XPub           string             // extended public key of a bip44 account, only for xpub wallets.
+	Addresses      []cipher.Addresser // addresses to watch, only for addresses wallets.
+	Signer         string             // url of the remote signer, only for remote wallets.
+	PublicKeys     []cipher.PubKey    // public keys held by the remote signer, only for remote wallets. Defaults to all the keys of the signer.
 }
 
 // Wallet is consisted of meta and entries.
@@ -158,7 +276,24 @@ type Wallet struct {
 
 // newWallet creates a wallet instance with given name and options.
 func newWallet(wltName string, opts Options, bg BalanceGetter) (*Wallet, error) {
-	if opts.Seed == "" {
+	walletType, err := ResolveWalletType(opts.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasSeedType(walletType) {
+		if opts.Seed != "" {
+			return nil, ErrSeedNotAllowed
+		}
+
+		if opts.Encrypt && isWatchOnlyType(walletType) {
+			return nil, ErrWalletWatchOnly
+		}
+
+		if opts.Encrypt && walletType == WalletTypeRemote {
+			return nil, ErrWalletRemote
+		}
+	} else if opts.Seed == "" {
 		return nil, ErrMissingSeed
 	}
 
@@ -177,10 +312,26 @@ func newWallet(wltName string, opts Options, bg BalanceGetter) (*Wallet, error)
 		return nil, fmt.Errorf("Invalid coin type %q", coin)
 	}
 
-	switch coin {
-	case CoinTypeMDL, CoinTypeBitcoin:
-	default:
-		return nil, fmt.Errorf("Invalid coin type %q", coin)
+	if walletType != WalletTypeBip44 && (opts.Bip44Coin != nil || opts.Bip44Account != 0) {
+		return nil, ErrBip44OptionsNotAllowed
+	}
+
+	if (walletType != WalletTypeXPub && opts.XPub != "") || (walletType != WalletTypeAddresses && len(opts.Addresses) != 0) {
+		return nil, ErrWatchOnlyOptionsNotAllowed
+	}
+
+	if walletType != WalletTypeRemote && (opts.Signer != "" || len(opts.PublicKeys) != 0) {
+		return nil, ErrRemoteOptionsNotAllowed
+	}
+
+	if opts.SeedPassphrase != "" {
+		if walletType != WalletTypeBip44 {
+			return nil, ErrSeedPassphraseNotAllowed
+		}
+
+		if !opts.Encrypt {
+			return nil, ErrSeedPassphraseNotEncrypted
+		}
 	}
 
 	w := &Wallet{
@@ -191,7 +342,7 @@ func newWallet(wltName string, opts Options, bg BalanceGetter) (*Wallet, error)
 			metaSeed:       opts.Seed,
 			metaLastSeed:   opts.Seed,
 			metaTimestamp:  strconv.FormatInt(time.Now().Unix(), 10),
-			metaType:       WalletTypeDeterministic,
+			metaType:       walletType,
 			metaCoin:       string(coin),
 			metaEncrypted:  "false",
 			metaCryptoType: "",
@@ -199,24 +350,54 @@ func newWallet(wltName string, opts Options, bg BalanceGetter) (*Wallet, error)
 		},
 	}
 
-	// Create a default wallet
-	generateN := opts.GenerateN
-	if generateN == 0 {
-		generateN = 1
-	}
-	if _, err := w.GenerateAddresses(generateN); err != nil {
-		return nil, err
+	if opts.SeedPassphrase != "" {
+		w.setPassphrase(opts.SeedPassphrase)
 	}
 
-	if opts.ScanN != 0 && coin != CoinTypeMDL {
-		return nil, errors.New("Wallet address scanning is not supported for Bitcoin wallets")
+	switch walletType {
+	case WalletTypeBip44:
+		if err := w.initBip44(opts.Bip44Coin, opts.Bip44Account); err != nil {
+			return nil, err
+		}
+	case WalletTypeXPub:
+		if err := w.initXPub(opts.XPub); err != nil {
+			return nil, err
+		}
+	case WalletTypeAddresses:
+		if err := w.initAddresses(opts); err != nil {
+			return nil, err
+		}
+	case WalletTypeCollection:
+		if err := w.initCollection(opts); err != nil {
+			return nil, err
+		}
+	case WalletTypeRemote:
+		if err := w.initRemote(opts); err != nil {
+			return nil, err
+		}
 	}
 
-	if opts.ScanN > generateN {
-		// Scan for addresses with balances
-		if _, err := w.ScanAddresses(opts.ScanN, bg); err != nil {
+	// The addresses of addresses, collection and remote wallets are imported, not generated
+	if walletType != WalletTypeAddresses && walletType != WalletTypeCollection && walletType != WalletTypeRemote {
+		// Create a default wallet
+		generateN := opts.GenerateN
+		if generateN == 0 {
+			generateN = 1
+		}
+		if _, err := w.GenerateAddresses(generateN); err != nil {
 			return nil, err
 		}
+
+		if opts.ScanN != 0 && coin != CoinTypeMDL {
+			return nil, errors.New("Wallet address scanning is not supported for Bitcoin wallets")
+		}
+
+		if opts.ScanN > generateN {
+			// Scan for addresses with balances
+			if _, err := w.ScanAddresses(opts.ScanN, bg); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Checks if the wallet need to encrypt
@@ -238,6 +419,7 @@ func newWallet(wltName string, opts Options, bg BalanceGetter) (*Wallet, error)
 	}
 
 	// Encrypt the wallet
+	w.setArgon2Costs(opts.Argon2Time, opts.Argon2Memory)
 	if err := w.Lock(opts.Password, opts.CryptoType); err != nil {
 		return nil, err
 	}
@@ -262,6 +444,14 @@ func NewWalletScanAhead(wltName string, opts Options, bg BalanceGetter) (*Wallet
 
 // Lock encrypts the wallet with the given password and specific crypto type
 func (w *Wallet) Lock(password []byte, cryptoType CryptoType) error {
+	if w.IsWatchOnly() {
+		return ErrWalletWatchOnly
+	}
+
+	if w.IsRemote() {
+		return ErrWalletRemote
+	}
+
 	if len(password) == 0 {
 		return ErrMissingPassword
 	}
@@ -282,6 +472,9 @@ func (w *Wallet) Lock(password []byte, cryptoType CryptoType) error {
 
 	ss.set(secretSeed, wlt.seed())
 	ss.set(secretLastSeed, wlt.lastSeed())
+	if p := wlt.passphrase(); p != "" {
+		ss.set(secretPassphrase, p)
+	}
 
 	// Saves address's secret keys in secrets
 	for _, e := range wlt.Entries {
@@ -293,7 +486,7 @@ func (w *Wallet) Lock(password []byte, cryptoType CryptoType) error {
 		return err
 	}
 
-	crypto, err := getCrypto(cryptoType)
+	crypto, err := wlt.newCrypto(cryptoType)
 	if err != nil {
 		return err
 	}
@@ -383,11 +576,22 @@ func (w *Wallet) Unlock(password []byte) (*Wallet, error) {
 	}
 	wlt.setLastSeed(lastSeed)
 
+	if p, ok := ss.get(secretPassphrase); ok {
+		wlt.setPassphrase(p)
+	}
+
 	// Gets addresses related secrets
+	var missing []int
 	for i, e := range wlt.Entries {
 		sstr, ok := ss.get(e.Address.String())
 		if !ok {
-			return nil, fmt.Errorf("secret of address %s doesn't exist in secrets", e.Address)
+			if wlt.Type() != WalletTypeBip44 {
+				return nil, fmt.Errorf("secret of address %s doesn't exist in secrets", e.Address)
+			}
+			// Change addresses can be added to an encrypted bip44 wallet from its xpub,
+			// their secret keys are derived from the seed instead
+			missing = append(missing, i)
+			continue
 		}
 		s, err := hex.DecodeString(sstr)
 		if err != nil {
@@ -397,9 +601,16 @@ func (w *Wallet) Unlock(password []byte) (*Wallet, error) {
 		copy(wlt.Entries[i].Secret[:], s[:])
 	}
 
+	if len(missing) != 0 {
+		if err := wlt.deriveBip44Secrets(missing); err != nil {
+			return nil, err
+		}
+	}
+
 	wlt.setEncrypted(false)
 	wlt.setSecrets("")
 	wlt.setCryptoType("")
+	wlt.setArgon2Costs(0, 0)
 	return wlt, nil
 }
 
@@ -415,14 +626,17 @@ func (w *Wallet) copyFrom(src *Wallet) {
 	}
 
 	// Copies the address entries
-	w.Entries = append(w.Entries, src.Entries...)
+	for i := range src.Entries {
+		w.Entries = append(w.Entries, src.Entries[i].clone())
+	}
 }
 
 // Erase wipes secret fields in wallet
 func (w *Wallet) Erase() {
-	// Wipes the seed and last seed
+	// Wipes the seed, last seed and seed passphrase
 	w.setSeed("")
 	w.setLastSeed("")
+	w.setPassphrase("")
 
 	// Wipes private keys in entries
 	for i := range w.Entries {
@@ -446,6 +660,7 @@ func (w *Wallet) GuardUpdate(password []byte, fn func(w *Wallet) error) error {
 	}
 
 	cryptoType := w.cryptoType()
+	timeCost, memoryCost := w.Argon2Costs()
 	wlt, err := w.Unlock(password)
 	if err != nil {
 		return err
@@ -457,6 +672,7 @@ func (w *Wallet) GuardUpdate(password []byte, fn func(w *Wallet) error) error {
 		return err
 	}
 
+	wlt.setArgon2Costs(timeCost, memoryCost)
 	if err := wlt.Lock(password, cryptoType); err != nil {
 		return err
 	}
@@ -467,6 +683,47 @@ func (w *Wallet) GuardUpdate(password []byte, fn func(w *Wallet) error) error {
 	return nil
 }
 
+// Reencrypt re-encrypts the secrets of an encrypted wallet with a new password and crypto type.
+// If cryptoType is empty, the wallet's crypto type is kept, with its argon2id costs if any.
+// The secrets are decrypted in memory only, the wallet is not unencrypted in between.
+func (w *Wallet) Reencrypt(password, newPassword []byte, cryptoType CryptoType) error {
+	if !w.IsEncrypted() {
+		return ErrWalletNotEncrypted
+	}
+
+	if len(password) == 0 {
+		return ErrMissingPassword
+	}
+
+	if len(newPassword) == 0 {
+		return ErrMissingNewPassword
+	}
+
+	if cryptoType == "" {
+		cryptoType = w.cryptoType()
+	}
+
+	if _, err := getCrypto(cryptoType); err != nil {
+		return NewError(err)
+	}
+
+	timeCost, memoryCost := w.Argon2Costs()
+	wlt, err := w.Unlock(password)
+	if err != nil {
+		return err
+	}
+
+	defer wlt.Erase()
+
+	wlt.setArgon2Costs(timeCost, memoryCost)
+	if err := wlt.Lock(newPassword, cryptoType); err != nil {
+		return err
+	}
+
+	w.copyFrom(wlt)
+	return nil
+}
+
 // GuardView executes a function within the context of a read-only managed decrypted wallet.
 // Returns ErrWalletNotEncrypted if wallet is not encrypted.
 func (w *Wallet) GuardView(password []byte, f func(w *Wallet) error) error {
@@ -506,6 +763,11 @@ func Load(wltFile string) (*Wallet, error) {
 
 // Save saves the wallet to given dir
 func (w *Wallet) Save(dir string) error {
+	// The seed passphrase is never saved in plaintext
+	if !w.IsEncrypted() && w.passphrase() != "" {
+		return ErrSeedPassphraseNotEncrypted
+	}
+
 	r := NewReadableWallet(w)
 	return r.Save(filepath.Join(dir, w.Filename()))
 }
@@ -533,13 +795,14 @@ func removeBackupFiles(dir string) error {
 	for _, bf := range bakFs {
 		f := strings.TrimRight(bf, ".bak")
 		if _, ok := fm[f]; ok {
-			// Load and check the wallet version
-			w, err := Load(f)
+			// Load and check the wallet version, the version of the
+			// file is checked because loading a wallet migrates it
+			rw, err := LoadReadableWallet(f)
 			if err != nil {
 				return err
 			}
 
-			if w.Version() == "0.1" {
+			if rw.Meta[metaVersion] == "0.1" {
 				if err := os.Remove(bf); err != nil {
 					return err
 				}
@@ -564,12 +827,6 @@ func filterDir(dir string, suffix string) ([]string, error) {
 	return res, nil
 }
 
-// reset resets the wallet entries and move the lastSeed to origin
-func (w *Wallet) reset() {
-	w.Entries = []Entry{}
-	w.setLastSeed(w.seed())
-}
-
 // Validate validates the wallet
 func (w *Wallet) Validate() error {
 	if fn := w.Meta[metaFilename]; fn == "" {
@@ -587,7 +844,22 @@ func (w *Wallet) Validate() error {
 	if !ok {
 		return errors.New("type field not set")
 	}
-	if walletType != WalletTypeDeterministic {
+	switch walletType {
+	case WalletTypeDeterministic:
+	case WalletTypeBip44:
+		if err := w.validateBip44(); err != nil {
+			return err
+		}
+	case WalletTypeXPub:
+		if _, err := bip32.DeserializeEncodedPublicKey(w.XPub()); err != nil {
+			return fmt.Errorf("invalid xpub: %v", err)
+		}
+	case WalletTypeAddresses, WalletTypeCollection:
+	case WalletTypeRemote:
+		if _, _, _, err := ParseSignerURL(w.SignerURL()); err != nil {
+			return fmt.Errorf("invalid signer url: %v", err)
+		}
+	default:
 		return errors.New("wallet type invalid")
 	}
 
@@ -595,6 +867,26 @@ func (w *Wallet) Validate() error {
 		return errors.New("coin field not set")
 	}
 
+	if s := w.Meta[metaFrozenOutputs]; s != "" {
+		for _, h := range strings.Split(s, ",") {
+			if _, err := cipher.SHA256FromHex(h); err != nil {
+				return fmt.Errorf("invalid frozen output %q: %v", h, err)
+			}
+		}
+	}
+
+	if s := w.Meta[metaSpendingPolicy]; s != "" {
+		if _, err := parseSpendingPolicy(s); err != nil {
+			return fmt.Errorf("invalid spending policy: %v", err)
+		}
+	}
+
+	if s := w.Meta[metaPolicySpends]; s != "" {
+		if err := validatePolicySpends(s); err != nil {
+			return err
+		}
+	}
+
 	var isEncrypted bool
 	if encStr, ok := w.Meta[metaEncrypted]; ok {
 		// validate the encrypted value
@@ -607,6 +899,14 @@ func (w *Wallet) Validate() error {
 
 	// checks if the secrets field is empty
 	if isEncrypted {
+		if isWatchOnlyType(walletType) {
+			return errors.New("watch-only wallet can't be encrypted")
+		}
+
+		if walletType == WalletTypeRemote {
+			return errors.New("remote wallet can't be encrypted")
+		}
+
 		cryptoType, ok := w.Meta[metaCryptoType]
 		if !ok {
 			return errors.New("crypto type field not set")
@@ -616,15 +916,33 @@ func (w *Wallet) Validate() error {
 			return errors.New("unknown crypto type")
 		}
 
+		if CryptoType(cryptoType) == CryptoTypeArgon2idChacha20poly1305 {
+			for _, k := range []string{metaArgon2Time, metaArgon2Memory} {
+				if v, err := strconv.ParseUint(w.Meta[k], 10, 32); err != nil || v == 0 {
+					return fmt.Errorf("invalid %s field", k)
+				}
+			}
+		}
+
 		if s := w.Meta[metaSecrets]; s == "" {
 			return errors.New("wallet is encrypted, but secrets field not set")
 		}
+	} else if s := w.Meta[metaPassphrase]; s != "" {
+		return errors.New("seed passphrase set in unencrypted wallet")
+	} else if !hasSeedType(walletType) {
+		if s := w.Meta[metaSeed]; s != "" {
+			return fmt.Errorf("seed set in %s wallet", walletType)
+		}
+
+		if s := w.Meta[metaSecrets]; s != "" {
+			return errors.New("secrets set in unencrypted wallet")
+		}
 	} else {
 		if s := w.Meta[metaSeed]; s == "" {
 			return errors.New("seed missing in unencrypted wallet")
 		}
 
-		if s := w.Meta[metaLastSeed]; s == "" {
+		if s := w.Meta[metaLastSeed]; s == "" && walletType == WalletTypeDeterministic {
 			return errors.New("lastSeed missing in unencrypted wallet")
 		}
 	}
@@ -637,6 +955,11 @@ func (w *Wallet) Type() string {
 	return w.Meta[metaType]
 }
 
+// CryptoType gets the crypto type of an encrypted wallet
+func (w *Wallet) CryptoType() CryptoType {
+	return w.cryptoType()
+}
+
 // Version gets the wallet version
 func (w *Wallet) Version() string {
 	return w.Meta[metaVersion]
@@ -646,6 +969,17 @@ func (w *Wallet) setVersion(v string) {
 	w.Meta[metaVersion] = v
 }
 
+// migrate upgrades a wallet of an older version to the current version.
+// The upgraded wallet is written to disk on its next save.
+// Entries of 0.1 and 0.2 wallets have no label or metadata, which are optional,
+// so only the version changes.
+func (w *Wallet) migrate() {
+	switch w.Version() {
+	case "0.1", "0.2":
+		w.setVersion(Version)
+	}
+}
+
 // Filename gets the wallet filename
 func (w *Wallet) Filename() string {
 	return w.Meta[metaFilename]
@@ -683,6 +1017,19 @@ func (w *Wallet) setSeed(seed string) {
 	w.Meta[metaSeed] = seed
 }
 
+func (w *Wallet) passphrase() string {
+	return w.Meta[metaPassphrase]
+}
+
+// setPassphrase sets the seed passphrase, an empty passphrase removes it from the meta
+func (w *Wallet) setPassphrase(p string) {
+	if p == "" {
+		delete(w.Meta, metaPassphrase)
+		return
+	}
+	w.Meta[metaPassphrase] = p
+}
+
 func (w *Wallet) coin() CoinType {
 	return CoinType(w.Meta[metaCoin])
 }
@@ -733,6 +1080,102 @@ func (w *Wallet) cryptoType() CryptoType {
 	return CryptoType(w.Meta[metaCryptoType])
 }
 
+// Argon2Costs gets the argon2id time and memory costs of a wallet encrypted with argon2id-chacha20poly1305,
+// returns zeros for other wallets
+func (w *Wallet) Argon2Costs() (timeCost, memoryCost uint32) {
+	// Intentionally ignore the error when parsing the costs,
+	// these values are validated by wallet.Validate()
+	t, _ := strconv.ParseUint(w.Meta[metaArgon2Time], 10, 32)   // nolint: errcheck
+	m, _ := strconv.ParseUint(w.Meta[metaArgon2Memory], 10, 32) // nolint: errcheck
+	return uint32(t), uint32(m)
+}
+
+// setArgon2Costs sets the argon2id costs used the next time the wallet is locked,
+// zero costs are removed from the meta
+func (w *Wallet) setArgon2Costs(timeCost, memoryCost uint32) {
+	for k, v := range map[string]uint32{
+		metaArgon2Time:   timeCost,
+		metaArgon2Memory: memoryCost,
+	} {
+		if v == 0 {
+			delete(w.Meta, k)
+			continue
+		}
+		w.Meta[k] = strconv.FormatUint(uint64(v), 10)
+	}
+}
+
+// FrozenOutputs returns the hashes of the unspent outputs which are frozen in the wallet, in ascending hex order.
+// Frozen outputs are not spent when creating a transaction, unless they are selected explicitly.
+func (w *Wallet) FrozenOutputs() []cipher.SHA256 {
+	s := w.Meta[metaFrozenOutputs]
+	if s == "" {
+		return nil
+	}
+
+	strs := strings.Split(s, ",")
+	hashes := make([]cipher.SHA256, 0, len(strs))
+	for _, hs := range strs {
+		// Intentionally ignore the error, the frozen outputs are validated by wallet.Validate()
+		h, err := cipher.SHA256FromHex(hs)
+		if err != nil {
+			continue
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
+
+// IsFrozenOutput returns true if the unspent output is frozen in the wallet
+func (w *Wallet) IsFrozenOutput(h cipher.SHA256) bool {
+	for _, fh := range w.FrozenOutputs() {
+		if fh == h {
+			return true
+		}
+	}
+	return false
+}
+
+// FreezeOutputs adds unspent outputs to the frozen outputs of the wallet
+func (w *Wallet) FreezeOutputs(hashes []cipher.SHA256) {
+	frozen := make(map[cipher.SHA256]struct{})
+	for _, h := range w.FrozenOutputs() {
+		frozen[h] = struct{}{}
+	}
+	for _, h := range hashes {
+		frozen[h] = struct{}{}
+	}
+	w.setFrozenOutputs(frozen)
+}
+
+// UnfreezeOutputs removes unspent outputs from the frozen outputs of the wallet.
+// Outputs which are not frozen are ignored.
+func (w *Wallet) UnfreezeOutputs(hashes []cipher.SHA256) {
+	frozen := make(map[cipher.SHA256]struct{})
+	for _, h := range w.FrozenOutputs() {
+		frozen[h] = struct{}{}
+	}
+	for _, h := range hashes {
+		delete(frozen, h)
+	}
+	w.setFrozenOutputs(frozen)
+}
+
+// setFrozenOutputs sets the frozen outputs, an empty set removes them from the meta
+func (w *Wallet) setFrozenOutputs(frozen map[cipher.SHA256]struct{}) {
+	if len(frozen) == 0 {
+		delete(w.Meta, metaFrozenOutputs)
+		return
+	}
+
+	strs := make([]string, 0, len(frozen))
+	for h := range frozen {
+		strs = append(strs, h.Hex())
+	}
+	sort.Strings(strs)
+	w.Meta[metaFrozenOutputs] = strings.Join(strs, ",")
+}
+
 func (w *Wallet) secrets() string {
 	return w.Meta[metaSecrets]
 }
@@ -759,10 +1202,21 @@ func (w *Wallet) GenerateAddresses(num uint64) ([]cipher.Addresser, error) {
 		return nil, nil
 	}
 
+	switch w.Type() {
+	case WalletTypeXPub:
+		return w.generateBip44PublicAddresses(num, bip44.ExternalChainIndex)
+	case WalletTypeAddresses, WalletTypeCollection, WalletTypeRemote:
+		return nil, ErrWalletCantGenerateAddresses
+	}
+
 	if w.IsEncrypted() {
 		return nil, ErrWalletEncrypted
 	}
 
+	if w.Type() == WalletTypeBip44 {
+		return w.generateBip44Addresses(num, bip44.ExternalChainIndex)
+	}
+
 	var seckeys []cipher.SecKey
 	var seed []byte
 	if len(w.Entries) == 0 {
@@ -825,7 +1279,6 @@ func (w *Wallet) ScanAddresses(scanN uint64, bg BalanceGetter) (uint64, error) {
 
 	w2 := w.clone()
 
-	nExistingAddrs := uint64(len(w2.Entries))
 	nAddAddrs := uint64(0)
 	n := scanN
 	extraScan := uint64(0)
@@ -866,14 +1319,14 @@ func (w *Wallet) ScanAddresses(scanN uint64, bg BalanceGetter) (uint64, error) {
 		n = scanN - extraScan
 	}
 
-	// Regenerate addresses up to nExistingAddrs + nAddAddrss.
-	// This is necessary to keep the lastSeed updated.
-	w2.reset()
-	if _, err := w2.GenerateMDLAddresses(nExistingAddrs + nAddAddrs); err != nil {
+	// Generate the addresses with a balance on a fresh copy of the wallet.
+	// This is necessary to keep the lastSeed and child numbers updated.
+	w3 := w.clone()
+	if _, err := w3.GenerateMDLAddresses(nAddAddrs); err != nil {
 		return 0, err
 	}
 
-	*w = *w2
+	*w = *w3
 
 	return nAddAddrs, nil
 }
@@ -935,6 +1388,60 @@ func (w *Wallet) AddEntry(entry Entry) error {
 	return nil
 }
 
+// EntryUpdate updates the label and metadata of an entry, nil fields are left unchanged
+type EntryUpdate struct {
+	// Label replaces the label of the entry, an empty label removes it
+	Label *string
+	// Meta replaces the metadata of the entry, an empty map removes it
+	Meta map[string]string
+}
+
+// UpdateEntry updates the label and metadata of the entry of an address.
+// Returns ErrUnknownAddress if the address is not in the wallet.
+func (w *Wallet) UpdateEntry(a cipher.Address, u EntryUpdate) (Entry, error) {
+	for k := range u.Meta {
+		if k == "" {
+			return Entry{}, NewError(errors.New("entry metadata key can't be empty"))
+		}
+	}
+
+	for i := range w.Entries {
+		e := &w.Entries[i]
+		if e.MDLAddress() != a {
+			continue
+		}
+
+		if u.Label != nil {
+			e.Label = *u.Label
+		}
+
+		if u.Meta != nil {
+			e.Meta = nil
+			if len(u.Meta) > 0 {
+				e.Meta = make(map[string]string, len(u.Meta))
+				for k, v := range u.Meta {
+					e.Meta[k] = v
+				}
+			}
+		}
+
+		return e.clone(), nil
+	}
+
+	return Entry{}, ErrUnknownAddress
+}
+
+// EntriesByLabel returns the entries which have the given label, in wallet order
+func (w *Wallet) EntriesByLabel(label string) []Entry {
+	var entries []Entry
+	for i := range w.Entries {
+		if w.Entries[i].Label == label {
+			entries = append(entries, w.Entries[i].clone())
+		}
+	}
+	return entries
+}
+
 // clone returns the clone of self
 func (w *Wallet) clone() *Wallet {
 	wlt := Wallet{
@@ -944,7 +1451,9 @@ func (w *Wallet) clone() *Wallet {
 		wlt.Meta[k] = v
 	}
 
-	wlt.Entries = append(wlt.Entries, w.Entries...)
+	for i := range w.Entries {
+		wlt.Entries = append(wlt.Entries, w.Entries[i].clone())
+	}
 
 	return &wlt
 }
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 
 	"github.com/MDLlife/MDL/src/cipher"
 	"github.com/MDLlife/MDL/src/cipher/encrypt"
+	"github.com/MDLlife/MDL/src/testutil"
 	"github.com/MDLlife/MDL/src/util/logging"
 )
 
@@ -507,7 +511,7 @@ func TestLoadWallet(t *testing.T) {
 					"seed":     "buddy fossil side modify turtle door label grunt baby worth brush master",
 					"tm":       "1503458909",
 					"type":     WalletTypeDeterministic,
-					"version":  "0.1",
+					"version":  Version,
 				},
 				err: nil,
 			},
@@ -565,7 +569,7 @@ func TestLoadWallet(t *testing.T) {
 					"lastSeed":   "",
 					"seed":       "",
 					"type":       WalletTypeDeterministic,
-					"version":    "0.2",
+					"version":    Version,
 				},
 				err: nil,
 			},
@@ -583,7 +587,7 @@ func TestLoadWallet(t *testing.T) {
 					"lastSeed":   "",
 					"seed":       "",
 					"type":       WalletTypeDeterministic,
-					"version":    "0.2",
+					"version":    Version,
 				},
 				err: nil,
 			},
@@ -602,7 +606,7 @@ func TestLoadWallet(t *testing.T) {
 					"secrets":    "",
 					"seed":       "seed",
 					"type":       WalletTypeDeterministic,
-					"version":    "0.2",
+					"version":    Version,
 				},
 				err: nil,
 			},
@@ -1250,6 +1254,11 @@ func TestWalletValidate(t *testing.T) {
 			meta: setField(goodMetaEncrypted, metaSecrets, ""),
 			err:  errors.New("wallet is encrypted, but secrets field not set"),
 		},
+		{
+			name: "frozen outputs invalid",
+			meta: setField(goodMetaUnencrypted, metaFrozenOutputs, "abcd"),
+			err:  fmt.Errorf("invalid frozen output %q: %v", "abcd", cipher.ErrInvalidHexLength),
+		},
 		{
 			name: "valid unencrypted",
 			meta: goodMetaUnencrypted,
@@ -1258,6 +1267,10 @@ func TestWalletValidate(t *testing.T) {
 			name: "valid encrypted",
 			meta: goodMetaEncrypted,
 		},
+		{
+			name: "valid frozen outputs",
+			meta: setField(goodMetaUnencrypted, metaFrozenOutputs, cipher.SumSHA256([]byte("a")).Hex()),
+		},
 	}
 
 	for _, tc := range cases {
@@ -1270,3 +1283,144 @@ func TestWalletValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletFrozenOutputs(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	require.Empty(t, w.FrozenOutputs())
+
+	a := cipher.SumSHA256([]byte("a"))
+	b := cipher.SumSHA256([]byte("b"))
+	c := cipher.SumSHA256([]byte("c"))
+
+	// Duplicates are ignored, the outputs are sorted by hex
+	w.FreezeOutputs([]cipher.SHA256{c, a, c})
+	w.FreezeOutputs([]cipher.SHA256{b, a})
+	frozen := []cipher.SHA256{a, b, c}
+	sort.Slice(frozen, func(i, j int) bool {
+		return frozen[i].Hex() < frozen[j].Hex()
+	})
+	require.Equal(t, frozen, w.FrozenOutputs())
+	require.True(t, w.IsFrozenOutput(b))
+	require.NoError(t, w.Validate())
+
+	// The frozen outputs are persisted in the wallet file
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	require.NoError(t, w.Save(dir))
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, frozen, lw.FrozenOutputs())
+
+	// Unfreezing an output which is not frozen is ignored
+	w.UnfreezeOutputs([]cipher.SHA256{b, cipher.SumSHA256([]byte("d"))})
+	require.False(t, w.IsFrozenOutput(b))
+	require.Len(t, w.FrozenOutputs(), 2)
+
+	w.UnfreezeOutputs([]cipher.SHA256{a, c})
+	require.Empty(t, w.FrozenOutputs())
+	_, ok := w.Meta[metaFrozenOutputs]
+	require.False(t, ok)
+}
+
+func TestWalletUpdateEntry(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Seed: "seed",
+	})
+	require.NoError(t, err)
+	_, err = w.GenerateAddresses(3)
+	require.NoError(t, err)
+
+	addrs := w.GetAddresses()
+	label := "customer-1"
+
+	_, err = w.UpdateEntry(testutil.MakeAddress(), EntryUpdate{Label: &label})
+	require.Equal(t, ErrUnknownAddress, err)
+
+	_, err = w.UpdateEntry(addrs[0].(cipher.Address), EntryUpdate{
+		Meta: map[string]string{"": "x"},
+	})
+	testutil.RequireError(t, err, "entry metadata key can't be empty")
+
+	meta := map[string]string{"invoice": "42"}
+	e, err := w.UpdateEntry(addrs[0].(cipher.Address), EntryUpdate{
+		Label: &label,
+		Meta:  meta,
+	})
+	require.NoError(t, err)
+	require.Equal(t, label, e.Label)
+	require.Equal(t, meta, e.Meta)
+
+	// The entry doesn't share the metadata of the update or of the returned entry
+	meta["invoice"] = "43"
+	e.Meta["invoice"] = "44"
+	require.Equal(t, map[string]string{"invoice": "42"}, w.Entries[0].Meta)
+
+	_, err = w.UpdateEntry(addrs[2].(cipher.Address), EntryUpdate{Label: &label})
+	require.NoError(t, err)
+
+	entries := w.EntriesByLabel(label)
+	require.Len(t, entries, 2)
+	require.Equal(t, addrs[0], entries[0].Address)
+	require.Equal(t, addrs[2], entries[1].Address)
+	require.Empty(t, w.EntriesByLabel("unknown"))
+
+	// The labels and metadata are persisted in the wallet file, and the clone doesn't share them
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	require.NoError(t, w.Save(dir))
+	lw, err := Load(filepath.Join(dir, "t.wlt"))
+	require.NoError(t, err)
+	require.Equal(t, w.Entries, lw.Entries)
+
+	cw := w.clone()
+	cw.Entries[0].Meta["invoice"] = "45"
+	require.Equal(t, "42", w.Entries[0].Meta["invoice"])
+
+	// Nil fields are left unchanged, empty fields remove the label and metadata
+	e, err = w.UpdateEntry(addrs[0].(cipher.Address), EntryUpdate{})
+	require.NoError(t, err)
+	require.Equal(t, label, e.Label)
+	require.Len(t, e.Meta, 1)
+
+	empty := ""
+	e, err = w.UpdateEntry(addrs[0].(cipher.Address), EntryUpdate{
+		Label: &empty,
+		Meta:  map[string]string{},
+	})
+	require.NoError(t, err)
+	require.Empty(t, e.Label)
+	require.Nil(t, e.Meta)
+	require.Len(t, w.EntriesByLabel(label), 1)
+}
+
+func TestWalletMigrate(t *testing.T) {
+	for _, version := range []string{"0.1", "0.2"} {
+		t.Run(version, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", Options{
+				Seed: "seed",
+			})
+			require.NoError(t, err)
+			w.setVersion(version)
+
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			require.NoError(t, w.Save(dir))
+
+			lw, err := Load(filepath.Join(dir, "t.wlt"))
+			require.NoError(t, err)
+			require.Equal(t, Version, lw.Version())
+		})
+	}
+
+	// The wallets of the testdata are of version 0.2
+	w, err := Load("./testdata/test1.wlt")
+	require.NoError(t, err)
+	require.Equal(t, Version, w.Version())
+	for _, e := range w.Entries {
+		require.Empty(t, e.Label)
+		require.Nil(t, e.Meta)
+	}
+}
@@ -126,7 +126,7 @@ func (wlts Wallets) ToReadable() []*ReadableWallet {
 func (wlts Wallets) containsDuplicate() (string, cipher.Address, bool) {
 	m := make(map[cipher.Address]struct{}, len(wlts))
 	for wltID, wlt := range wlts {
-		if len(wlt.Entries) == 0 {
+		if len(wlt.Entries) == 0 || !hasSeedType(wlt.Type()) {
 			continue
 		}
 		addr := wlt.Entries[0].MDLAddress()
@@ -140,10 +140,11 @@ func (wlts Wallets) containsDuplicate() (string, cipher.Address, bool) {
 	return "", cipher.Address{}, false
 }
 
-// containsEmpty returns true there is an empty wallet and the ID of that wallet if true
+// containsEmpty returns true there is an empty wallet and the ID of that wallet if true.
+// Collection wallets may be empty.
 func (wlts Wallets) containsEmpty() (string, bool) {
 	for wltID, wlt := range wlts {
-		if len(wlt.Entries) == 0 {
+		if len(wlt.Entries) == 0 && wlt.Type() != WalletTypeCollection {
 			return wltID, true
 		}
 	}
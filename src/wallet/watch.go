@@ -0,0 +1,98 @@
+package wallet
+
+// This file contains the watch-only wallet types support.
+// Watch-only wallets have no seed and no secret keys. They can be used to check balances
+// and to create unsigned transactions, but not to sign transactions.
+// An xpub wallet derives its addresses from the extended public key of a bip44 account,
+// along the external and change chains of the account.
+// An addresses wallet holds an imported list of addresses.
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/cipher/bip32"
+)
+
+// isWatchOnlyType returns true if the wallet type has no secrets
+func isWatchOnlyType(walletType string) bool {
+	switch walletType {
+	case WalletTypeXPub, WalletTypeAddresses:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsWatchOnly returns true if the wallet has no seed and no secret keys
+func (w *Wallet) IsWatchOnly() bool {
+	return isWatchOnlyType(w.Type())
+}
+
+// initXPub sets the xpub of a new xpub wallet
+func (w *Wallet) initXPub(xpub string) error {
+	if xpub == "" {
+		return ErrMissingXPub
+	}
+
+	if _, err := bip32.DeserializeEncodedPublicKey(xpub); err != nil {
+		return NewError(fmt.Errorf("invalid xpub: %v", err))
+	}
+
+	w.Meta[metaXPub] = xpub
+	w.setLastSeed("")
+	return nil
+}
+
+// initAddresses adds the imported addresses of a new addresses wallet
+func (w *Wallet) initAddresses(opts Options) error {
+	if len(opts.Addresses) == 0 {
+		return ErrMissingAddresses
+	}
+
+	if opts.GenerateN != 0 || opts.ScanN != 0 {
+		return NewError(errors.New("generate and scan options are not allowed for addresses wallets"))
+	}
+
+	w.setLastSeed("")
+
+	for _, a := range opts.Addresses {
+		if err := w.addWatchedAddress(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addWatchedAddress adds an address without keys to an addresses wallet
+func (w *Wallet) addWatchedAddress(a cipher.Addresser) error {
+	if a == nil || a.Null() {
+		return NewError(errors.New("null address"))
+	}
+
+	switch a.(type) {
+	case cipher.Address:
+		if w.coin() != CoinTypeMDL {
+			return NewError(fmt.Errorf("address %s is not a %s address", a, w.coin()))
+		}
+	case cipher.BitcoinAddress:
+		if w.coin() != CoinTypeBitcoin {
+			return NewError(fmt.Errorf("address %s is not a %s address", a, w.coin()))
+		}
+	default:
+		return NewError(fmt.Errorf("unsupported address type %T", a))
+	}
+
+	for _, e := range w.Entries {
+		if e.Address == a {
+			return NewError(fmt.Errorf("duplicate address %s", a))
+		}
+	}
+
+	w.Entries = append(w.Entries, Entry{
+		Address: a,
+	})
+	return nil
+}
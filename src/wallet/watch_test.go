@@ -0,0 +1,315 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/MDLlife/MDL/src/cipher"
+	"github.com/MDLlife/MDL/src/coin"
+	"github.com/MDLlife/MDL/src/testutil"
+	"github.com/MDLlife/MDL/src/transaction"
+)
+
+func TestNewXPubWallet(t *testing.T) {
+	bw, err := NewWallet("bip44.wlt", Options{
+		Type:      WalletTypeBip44,
+		Seed:      testBip44Seed,
+		GenerateN: 3,
+	})
+	require.NoError(t, err)
+	_, err = bw.GenerateChangeAddresses(2)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{
+			name: "ok",
+			opts: Options{
+				Type:      WalletTypeXPub,
+				XPub:      bw.XPub(),
+				GenerateN: 3,
+			},
+		},
+		{
+			name: "missing xpub",
+			opts: Options{
+				Type: WalletTypeXPub,
+			},
+			err: ErrMissingXPub,
+		},
+		{
+			name: "invalid xpub",
+			opts: Options{
+				Type: WalletTypeXPub,
+				XPub: "xpub",
+			},
+			err: NewError(errors.New("invalid xpub: Serialized keys should be exactly 82 bytes")),
+		},
+		{
+			name: "seed not allowed",
+			opts: Options{
+				Type: WalletTypeXPub,
+				XPub: bw.XPub(),
+				Seed: testBip44Seed,
+			},
+			err: ErrSeedNotAllowed,
+		},
+		{
+			name: "encrypt not allowed",
+			opts: Options{
+				Type:     WalletTypeXPub,
+				XPub:     bw.XPub(),
+				Encrypt:  true,
+				Password: []byte("pwd"),
+			},
+			err: ErrWalletWatchOnly,
+		},
+		{
+			name: "xpub for deterministic wallet",
+			opts: Options{
+				Seed: "seed",
+				XPub: bw.XPub(),
+			},
+			err: ErrWatchOnlyOptionsNotAllowed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", tc.opts)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			require.True(t, w.IsWatchOnly())
+			require.True(t, w.HasChangeChain())
+			require.Equal(t, bw.XPub(), w.XPub())
+			require.Empty(t, w.seed())
+			require.Empty(t, w.secrets())
+			require.NoError(t, w.Validate())
+
+			// The addresses match the addresses of the bip44 wallet, without the secret keys
+			require.Len(t, w.ExternalEntries(), 3)
+			for i, e := range w.ExternalEntries() {
+				require.Equal(t, bw.Entries[i].Address, e.Address)
+				require.Equal(t, bw.Entries[i].Public, e.Public)
+				require.True(t, e.Secret.Null())
+			}
+
+			_, err = w.GenerateChangeAddresses(2)
+			require.NoError(t, err)
+			require.Equal(t, bw.ChangeEntries()[1].Address, w.ChangeEntries()[1].Address)
+			require.True(t, w.ChangeEntries()[1].Secret.Null())
+
+			// Encrypting a watch-only wallet is refused
+			require.Equal(t, ErrWalletWatchOnly, w.Lock([]byte("pwd"), CryptoTypeSha256Xor))
+
+			// The wallet can be saved and loaded
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			require.NoError(t, w.Save(dir))
+			lw, err := Load(dir + "/t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, w.Entries, lw.Entries)
+			require.Equal(t, w.XPub(), lw.XPub())
+		})
+	}
+}
+
+func TestNewAddressesWallet(t *testing.T) {
+	addrs := []cipher.Addresser{
+		testutil.MakeAddress(),
+		testutil.MakeAddress(),
+	}
+	btcAddr := cipher.BitcoinAddressFromPubKey(testutil.MakePubKey())
+
+	tt := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{
+			name: "ok",
+			opts: Options{
+				Type:      WalletTypeAddresses,
+				Addresses: addrs,
+			},
+		},
+		{
+			name: "missing addresses",
+			opts: Options{
+				Type: WalletTypeAddresses,
+			},
+			err: ErrMissingAddresses,
+		},
+		{
+			name: "duplicate addresses",
+			opts: Options{
+				Type:      WalletTypeAddresses,
+				Addresses: []cipher.Addresser{addrs[0], addrs[0]},
+			},
+			err: NewError(errors.New("duplicate address " + addrs[0].String())),
+		},
+		{
+			name: "bitcoin address in mdl wallet",
+			opts: Options{
+				Type:      WalletTypeAddresses,
+				Addresses: []cipher.Addresser{btcAddr},
+			},
+			err: NewError(fmt.Errorf("address %s is not a mdl address", btcAddr)),
+		},
+		{
+			name: "generate not allowed",
+			opts: Options{
+				Type:      WalletTypeAddresses,
+				Addresses: addrs,
+				GenerateN: 2,
+			},
+			err: NewError(errors.New("generate and scan options are not allowed for addresses wallets")),
+		},
+		{
+			name: "addresses for bip44 wallet",
+			opts: Options{
+				Type:      WalletTypeBip44,
+				Seed:      testBip44Seed,
+				Addresses: addrs,
+			},
+			err: ErrWatchOnlyOptionsNotAllowed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewWallet("t.wlt", tc.opts)
+			if tc.err != nil {
+				require.Error(t, err)
+				require.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			require.True(t, w.IsWatchOnly())
+			require.False(t, w.HasChangeChain())
+			require.NoError(t, w.Validate())
+			require.Len(t, w.Entries, len(addrs))
+			for i, e := range w.Entries {
+				require.Equal(t, addrs[i], e.Address)
+				require.True(t, e.Public.Null())
+				require.True(t, e.Secret.Null())
+			}
+
+			_, err = w.GenerateAddresses(1)
+			require.Equal(t, ErrWalletCantGenerateAddresses, err)
+
+			// The wallet can be saved and loaded
+			dir := prepareWltDir()
+			defer os.RemoveAll(dir)
+			require.NoError(t, w.Save(dir))
+			lw, err := Load(dir + "/t.wlt")
+			require.NoError(t, err)
+			require.Equal(t, w.Entries, lw.Entries)
+		})
+	}
+}
+
+func TestWatchOnlyWalletSigning(t *testing.T) {
+	w, err := NewWallet("t.wlt", Options{
+		Type:      WalletTypeAddresses,
+		Addresses: []cipher.Addresser{testutil.MakeAddress()},
+	})
+	require.NoError(t, err)
+
+	_, err = w.SignTransaction(&coin.Transaction{}, nil, nil)
+	require.Equal(t, ErrWalletWatchOnly, err)
+
+	_, _, err = w.CreateTransactionSigned(transaction.Params{}, nil, 0)
+	require.Equal(t, ErrWalletWatchOnly, err)
+}
+
+func TestServiceWatchOnlyWallet(t *testing.T) {
+	dir := prepareWltDir()
+	defer os.RemoveAll(dir)
+	s, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+		EnableSeedAPI:   true,
+	})
+	require.NoError(t, err)
+
+	bw, err := s.CreateWallet("bip44.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	}, nil)
+	require.NoError(t, err)
+
+	// A watch-only wallet may watch the addresses of another wallet
+	w, err := s.CreateWallet("xpub.wlt", Options{
+		Type: WalletTypeXPub,
+		XPub: bw.XPub(),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, bw.Entries[0].Address, w.Entries[0].Address)
+
+	_, err = s.CreateWallet("addrs.wlt", Options{
+		Type:      WalletTypeAddresses,
+		Addresses: []cipher.Addresser{bw.Entries[0].Address},
+	}, nil)
+	require.NoError(t, err)
+
+	// Addresses are derived from the xpub
+	addrs, err := s.NewAddresses("xpub.wlt", nil, 2)
+	require.NoError(t, err)
+	require.Len(t, addrs, 2)
+
+	_, err = s.NewAddresses("addrs.wlt", nil, 1)
+	require.Equal(t, ErrWalletCantGenerateAddresses, err)
+
+	// Secrets are not available
+	err = s.ViewSecrets("xpub.wlt", nil, func(*Wallet) error {
+		return nil
+	})
+	require.Equal(t, ErrWalletWatchOnly, err)
+
+	err = s.UpdateSecrets("addrs.wlt", nil, func(*Wallet) error {
+		return nil
+	})
+	require.Equal(t, ErrWalletWatchOnly, err)
+
+	_, err = s.GetWalletSeed("xpub.wlt", []byte("pwd"))
+	require.Equal(t, ErrWalletWatchOnly, err)
+
+	_, err = s.EncryptWallet("xpub.wlt", []byte("pwd"), "")
+	require.Equal(t, ErrWalletWatchOnly, err)
+
+	// Unloading a watch-only wallet doesn't allow a duplicate of the watched wallet
+	require.NoError(t, s.UnloadWallet("xpub.wlt"))
+	_, err = s.CreateWallet("bip44-2.wlt", Options{
+		Type: WalletTypeBip44,
+		Seed: testBip44Seed,
+	}, nil)
+	require.Equal(t, ErrSeedUsed, err)
+
+	// The wallets are loaded from disk
+	s2, err := NewService(Config{
+		WalletDir:       dir,
+		CryptoType:      CryptoTypeSha256Xor,
+		EnableWalletAPI: true,
+	})
+	require.NoError(t, err)
+	wlts, err := s2.GetWallets()
+	require.NoError(t, err)
+	require.Len(t, wlts, 3)
+	require.True(t, wlts["addrs.wlt"].IsWatchOnly())
+	require.Len(t, wlts["xpub.wlt"].Entries, 3)
+}